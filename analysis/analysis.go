@@ -0,0 +1,525 @@
+// Package analysis statically checks sql statements against a schema,
+// reporting likely mistakes (unknown, ambiguous columns, type mismatches)
+// and slow query patterns (SELECT *, non-sargable predicates, cartesian
+// joins) without executing them.
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var _ = u.EMPTY
+
+// Severity of an Issue.
+type Severity int
+
+const (
+	// SeverityWarning the statement runs, but likely not as intended or slowly.
+	SeverityWarning Severity = iota
+	// SeverityError the statement will fail.
+	SeverityError
+)
+
+func (m Severity) String() string {
+	if m == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue codes
+const (
+	UnknownTable    = "unknown-table"
+	UnknownColumn   = "unknown-column"
+	AmbiguousColumn = "ambiguous-column"
+	TypeMismatch    = "type-mismatch"
+	SelectStar      = "select-star"
+	NonSargable     = "non-sargable"
+	CartesianJoin   = "cartesian-join"
+)
+
+// Issue a problem found in a statement.  Line, Column are the 1 based
+// position of the first occurrence of the offending text in the sql, 0
+// if not found.
+type Issue struct {
+	Code     string
+	Severity Severity
+	Message  string
+	Line     int
+	Column   int
+}
+
+func (m *Issue) String() string {
+	if m.Line > 0 {
+		return fmt.Sprintf("line %d column %d: %s %s: %s", m.Line, m.Column, m.Severity, m.Code, m.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", m.Severity, m.Code, m.Message)
+}
+
+// Analyze the statements of sql against schema s.  Syntax errors are the
+// returned error, the statements that did parse are still analyzed.
+//
+//     issues, err := analysis.Analyze("SELECT * FROM users WHERE yy(reg_date) > 10", s)
+//     // select-star, non-sargable
+//
+func Analyze(sql string, s *schema.Schema) ([]*Issue, error) {
+	stmts, err := rel.ParseSqlRecover(sql)
+	a := &analyzer{sql: sql, s: s, issues: make([]*Issue, 0)}
+	for _, stmt := range stmts {
+		a.statement(stmt)
+	}
+	return a.issues, err
+}
+
+// AnalyzeStatement a parsed statement against schema s.
+func AnalyzeStatement(stmt rel.SqlStatement, s *schema.Schema) []*Issue {
+	a := &analyzer{s: s, issues: make([]*Issue, 0)}
+	a.statement(stmt)
+	return a.issues
+}
+
+type analyzer struct {
+	sql    string
+	s      *schema.Schema
+	issues []*Issue
+}
+
+// source a table of the statement being analyzed, tbl is nil if its
+// columns are not known (unknown table, sub-query).
+type source struct {
+	alias string
+	from  *rel.SqlSource
+	tbl   *schema.Table
+}
+
+// scope the sources and select column aliases columns resolve against.
+type scope struct {
+	sources []*source
+	aliases map[string]bool
+}
+
+func (m *analyzer) add(code string, sev Severity, near string, msg string, args ...interface{}) {
+	is := &Issue{Code: code, Severity: sev, Message: fmt.Sprintf(msg, args...)}
+	is.Line, is.Column = position(m.sql, near)
+	m.issues = append(m.issues, is)
+}
+
+func (m *analyzer) statement(stmt rel.SqlStatement) {
+	switch st := stmt.(type) {
+	case *rel.SqlSelect:
+		m.selectStmt(st)
+	case *rel.SqlDelete:
+		sc := m.tableScope(st.Table)
+		if st.Where != nil {
+			m.where(sc, st.Where)
+		}
+	case *rel.SqlUpdate:
+		sc := m.tableScope(st.Table)
+		for col := range st.Values {
+			m.identity(sc, expr.NewIdentityNodeVal(col))
+		}
+		if st.Where != nil {
+			m.where(sc, st.Where)
+		}
+	case *rel.SqlInsert:
+		sc := m.tableScope(st.Table)
+		for _, col := range st.Columns {
+			m.identity(sc, expr.NewIdentityNodeVal(col.SourceField))
+		}
+		if st.Select != nil {
+			m.selectStmt(st.Select)
+		}
+	}
+}
+
+// tableScope the scope of a single table statement.
+func (m *analyzer) tableScope(table string) *scope {
+	from := &rel.SqlSource{Name: table}
+	return &scope{sources: []*source{m.source(from)}}
+}
+
+func (m *analyzer) source(from *rel.SqlSource) *source {
+	src := &source{alias: from.Alias, from: from}
+	if src.alias == "" {
+		src.alias = from.SourceName()
+	}
+	if from.SubQuery != nil {
+		m.selectStmt(from.SubQuery)
+		return src
+	}
+	if m.s == nil {
+		return src
+	}
+	tbl, err := m.s.Table(from.SourceName())
+	if err != nil || tbl == nil {
+		m.add(UnknownTable, SeverityError, from.Name, "table %q does not exist", from.Name)
+		return src
+	}
+	src.tbl = tbl
+	return src
+}
+
+func (m *analyzer) selectStmt(stmt *rel.SqlSelect) {
+	if len(stmt.From) == 0 {
+		return
+	}
+	sc := &scope{aliases: make(map[string]bool)}
+	for _, from := range stmt.From {
+		sc.sources = append(sc.sources, m.source(from))
+	}
+
+	star := stmt.Star
+	for _, col := range stmt.Columns {
+		if col.Star {
+			star = true
+			continue
+		}
+		if col.Expr != nil {
+			m.expr(sc, col.Expr)
+		}
+		if col.As != "" {
+			sc.aliases[strings.ToLower(col.As)] = true
+		}
+	}
+	if star {
+		m.add(SelectStar, SeverityWarning, "*", "SELECT * reads every column, list the columns needed")
+	}
+
+	for i, from := range stmt.From {
+		if from.JoinExpr != nil {
+			m.expr(sc, from.JoinExpr)
+		} else if i > 0 && !m.joined(sc, stmt, i) {
+			m.add(CartesianJoin, SeverityWarning, from.Name,
+				"%q is joined without a join condition, every row is joined to every row", sc.sources[i].alias)
+		}
+	}
+
+	if stmt.Where != nil {
+		m.where(sc, stmt.Where)
+	}
+	for _, col := range stmt.GroupBy {
+		if col.Expr != nil {
+			m.expr(sc, col.Expr)
+		}
+	}
+	if stmt.Having != nil {
+		m.expr(sc, stmt.Having)
+	}
+	for _, col := range stmt.OrderBy {
+		if col.Expr != nil {
+			m.expr(sc, col.Expr)
+		}
+	}
+}
+
+func (m *analyzer) where(sc *scope, w *rel.SqlWhere) {
+	if w.Source != nil {
+		m.selectStmt(w.Source)
+	}
+	if w.Expr == nil {
+		return
+	}
+	m.expr(sc, w.Expr)
+	m.sargable(sc, w.Expr)
+}
+
+// joined is the i'th source of stmt joined to an earlier source by a
+// WHERE equality, or a parent/child table join.
+func (m *analyzer) joined(sc *scope, stmt *rel.SqlSelect, i int) bool {
+	src := sc.sources[i]
+	for _, prev := range sc.sources[:i] {
+		if src.tbl != nil && prev.tbl != nil {
+			if src.tbl.Parent == prev.tbl.Name {
+				if _, _, ok := src.tbl.ParentKeys(); ok {
+					return true
+				}
+			}
+			if prev.tbl.Parent == src.tbl.Name {
+				if _, _, ok := prev.tbl.ParentKeys(); ok {
+					return true
+				}
+			}
+		}
+	}
+	if stmt.Where == nil || stmt.Where.Expr == nil {
+		return false
+	}
+	found := false
+	walk(stmt.Where.Expr, func(n expr.Node) {
+		bn, ok := n.(*expr.BinaryNode)
+		if !ok || len(bn.Args) != 2 {
+			return
+		}
+		switch bn.Operator.T {
+		case lex.TokenEqual, lex.TokenEqualEqual:
+		default:
+			return
+		}
+		l, lok := m.sourceOf(sc, bn.Args[0])
+		r, rok := m.sourceOf(sc, bn.Args[1])
+		if lok && rok && l != r && (l == i || r == i) {
+			found = true
+		}
+	})
+	return found
+}
+
+// sourceOf the index of the source the identity n is a column of.
+func (m *analyzer) sourceOf(sc *scope, n expr.Node) (int, bool) {
+	in, ok := n.(*expr.IdentityNode)
+	if !ok {
+		return 0, false
+	}
+	left, right, hasLeft := in.LeftRight()
+	for i, src := range sc.sources {
+		if hasLeft && strings.EqualFold(left, src.alias) {
+			return i, true
+		}
+		if !hasLeft && src.tbl != nil && src.tbl.HasField(right) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// expr check the identities, and comparisons of n.
+func (m *analyzer) expr(sc *scope, n expr.Node) {
+	for _, in := range expr.FindAllIdentities(n) {
+		m.identity(sc, in)
+	}
+	walk(n, func(n expr.Node) {
+		if bn, ok := n.(*expr.BinaryNode); ok {
+			m.comparison(sc, bn)
+		}
+	})
+}
+
+// identity check the column exists in exactly one source.
+func (m *analyzer) identity(sc *scope, in *expr.IdentityNode) *schema.Field {
+	if len(expr.FilterSpecialIdentities([]string{in.Text})) == 0 || strings.HasPrefix(in.Text, "@") {
+		return nil
+	}
+	left, right, hasLeft := in.LeftRight()
+	if hasLeft {
+		for _, src := range sc.sources {
+			if !strings.EqualFold(left, src.alias) {
+				continue
+			}
+			if src.tbl == nil {
+				return nil
+			}
+			if f, ok := field(src.tbl, right); ok {
+				return f
+			}
+			m.add(UnknownColumn, SeverityError, in.Text, "column %q does not exist in %q", right, src.tbl.Name)
+			return nil
+		}
+		// not an alias, may be a nested field name  json.name
+	}
+	if sc.aliases[strings.ToLower(in.Text)] {
+		return nil
+	}
+	var found *schema.Field
+	matches := make([]string, 0)
+	for _, src := range sc.sources {
+		if src.tbl == nil {
+			// columns of sub-queries, unknown tables are not known
+			return nil
+		}
+		if f, ok := field(src.tbl, in.Text); ok {
+			found = f
+			matches = append(matches, src.alias)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		m.add(UnknownColumn, SeverityError, in.Text, "column %q does not exist", in.Text)
+	case 1:
+		return found
+	default:
+		m.add(AmbiguousColumn, SeverityError, in.Text, "column %q is ambiguous, it is in %s",
+			in.Text, strings.Join(matches, ", "))
+	}
+	return nil
+}
+
+// comparison check the literal compared to a column is of its type.
+func (m *analyzer) comparison(sc *scope, bn *expr.BinaryNode) {
+	if len(bn.Args) != 2 {
+		return
+	}
+	switch bn.Operator.T {
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+	default:
+		return
+	}
+	for i := 0; i < 2; i++ {
+		in, ok := bn.Args[i].(*expr.IdentityNode)
+		if !ok {
+			continue
+		}
+		f := m.quietField(sc, in)
+		if f == nil {
+			continue
+		}
+		if lit, ok := literalType(bn.Args[1-i]); ok && !comparable(f.ValueType(), lit) {
+			m.add(TypeMismatch, SeverityWarning, in.Text, "%s column %q compared to %s %s",
+				f.ValueType(), in.Text, lit, bn.Args[1-i])
+		}
+	}
+}
+
+// sargable warn of WHERE predicates that can not use an index, or be
+// pushed down to a source as a range, as the column is in an expression.
+func (m *analyzer) sargable(sc *scope, n expr.Node) {
+	switch nt := n.(type) {
+	case *expr.BooleanNode:
+		for _, arg := range nt.Args {
+			m.sargable(sc, arg)
+		}
+	case *expr.BinaryNode:
+		if len(nt.Args) != 2 {
+			return
+		}
+		switch nt.Operator.T {
+		case lex.TokenLogicAnd, lex.TokenAnd, lex.TokenLogicOr, lex.TokenOr:
+			m.sargable(sc, nt.Args[0])
+			m.sargable(sc, nt.Args[1])
+			return
+		case lex.TokenLike:
+			if sn, ok := nt.Args[1].(*expr.StringNode); ok && strings.HasPrefix(sn.Text, "%") {
+				m.add(NonSargable, SeverityWarning, sn.Text, "LIKE %q with a leading wildcard scans every row", sn.Text)
+			}
+			return
+		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+		default:
+			return
+		}
+		for i := 0; i < 2; i++ {
+			switch arg := nt.Args[i].(type) {
+			case *expr.FuncNode, *expr.BinaryNode:
+				if len(expr.FindAllIdentities(arg)) > 0 && len(expr.FindAllIdentities(nt.Args[1-i])) == 0 {
+					m.add(NonSargable, SeverityWarning, arg.String(),
+						"column in expression %s can not use an index, compare the bare column", arg)
+				}
+			}
+		}
+	}
+}
+
+// quietField the field of identity in, nil if not exactly one.
+func (m *analyzer) quietField(sc *scope, in *expr.IdentityNode) *schema.Field {
+	left, right, hasLeft := in.LeftRight()
+	var found *schema.Field
+	for _, src := range sc.sources {
+		if src.tbl == nil {
+			continue
+		}
+		if hasLeft {
+			if strings.EqualFold(left, src.alias) {
+				f, _ := field(src.tbl, right)
+				return f
+			}
+			continue
+		}
+		if f, ok := field(src.tbl, right); ok {
+			if found != nil {
+				return nil
+			}
+			found = f
+		}
+	}
+	return found
+}
+
+func field(tbl *schema.Table, name string) (*schema.Field, bool) {
+	name = expr.IdentityTrim(name)
+	if f, ok := tbl.Field(name); ok {
+		return f, true
+	}
+	return tbl.Field(strings.ToLower(name))
+}
+
+// literalType the value type of literal node n.
+func literalType(n expr.Node) (value.ValueType, bool) {
+	switch nt := n.(type) {
+	case *expr.StringNode:
+		return value.ValueTypeFromString(nt.Text), true
+	case *expr.NumberNode:
+		if nt.IsInt {
+			return value.IntType, true
+		}
+		return value.NumberType, true
+	}
+	return value.UnknownType, false
+}
+
+// comparable can a column of type col be compared to a literal of type lit.
+func comparable(col, lit value.ValueType) bool {
+	switch col {
+	case value.IntType, value.NumberType:
+		return lit == value.IntType || lit == value.NumberType
+	case value.TimeType:
+		return lit == value.TimeType || lit == value.IntType
+	case value.BoolType:
+		return lit == value.BoolType || lit == value.IntType
+	case value.StringType:
+		return lit != value.IntType && lit != value.NumberType
+	}
+	return true
+}
+
+// walk call fn for n, and each of its descendants.
+func walk(n expr.Node, fn func(expr.Node)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	switch nt := n.(type) {
+	case *expr.BinaryNode:
+		for _, arg := range nt.Args {
+			walk(arg, fn)
+		}
+	case *expr.BooleanNode:
+		for _, arg := range nt.Args {
+			walk(arg, fn)
+		}
+	case *expr.UnaryNode:
+		walk(nt.Arg, fn)
+	case *expr.TriNode:
+		for _, arg := range nt.Args {
+			walk(arg, fn)
+		}
+	case *expr.ArrayNode:
+		for _, arg := range nt.Args {
+			walk(arg, fn)
+		}
+	case *expr.FuncNode:
+		for _, arg := range nt.Args {
+			walk(arg, fn)
+		}
+	}
+}
+
+// position the 1 based line, column of the first case-insensitive
+// occurrence of text in sql.
+func position(sql, text string) (int, int) {
+	if sql == "" || text == "" {
+		return 0, 0
+	}
+	idx := strings.Index(strings.ToLower(sql), strings.ToLower(text))
+	if idx < 0 {
+		return 0, 0
+	}
+	line := 1 + strings.Count(sql[:idx], "\n")
+	return line, idx - strings.LastIndex(sql[:idx], "\n")
+}
@@ -0,0 +1,66 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/analysis"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+	td.LoadTestDataOnce()
+}
+
+func codes(issues []*analysis.Issue) []string {
+	c := make([]string, len(issues))
+	for i, is := range issues {
+		c[i] = is.Code
+	}
+	return c
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		sql   string
+		codes []string
+	}{
+		{`SELECT user_id, email FROM users WHERE referral_count > 10 ORDER BY email`, []string{}},
+		{`SELECT count(*) AS ct, interests FROM users GROUP BY interests ORDER BY ct`, []string{}},
+		{`SELECT * FROM users`, []string{analysis.SelectStar}},
+		{`SELECT user_id FROM not_a_table`, []string{analysis.UnknownTable}},
+		{`SELECT user_id, emial FROM users`, []string{analysis.UnknownColumn}},
+		{`SELECT u.emial FROM users AS u`, []string{analysis.UnknownColumn}},
+		{`SELECT user_id FROM users WHERE referral_count = 'lots'`, []string{analysis.TypeMismatch}},
+		{`SELECT user_id FROM users WHERE email = 22`, []string{analysis.TypeMismatch}},
+		{`SELECT user_id FROM users WHERE referral_count = '22'`, []string{}},
+		{`SELECT user_id FROM users WHERE yy(reg_date) > 10`, []string{analysis.NonSargable}},
+		{`SELECT user_id FROM users WHERE referral_count * 2 > 10 AND email LIKE '%.com'`,
+			[]string{analysis.NonSargable, analysis.NonSargable}},
+		{`SELECT u.email, o.price FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id`, []string{}},
+		{`SELECT user_id, price FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id`,
+			[]string{analysis.AmbiguousColumn}},
+		{`SELECT u.email, o.price FROM users AS u INNER JOIN orders AS o`, []string{analysis.CartesianJoin}},
+		{`SELECT u.email, o.price FROM users AS u INNER JOIN orders AS o WHERE u.user_id = o.user_id`, []string{}},
+		{`DELETE FROM users WHERE emial = 'x'`, []string{analysis.UnknownColumn}},
+		{`UPDATE users SET email = 'x' WHERE referral_count = 'x'`, []string{analysis.TypeMismatch}},
+	}
+	for _, tt := range tests {
+		issues, err := analysis.Analyze(tt.sql, td.MockSchema)
+		assert.Equal(t, nil, err, tt.sql)
+		assert.Equal(t, tt.codes, codes(issues), "%s  %v", tt.sql, issues)
+	}
+
+	issues, _ := analysis.Analyze("SELECT user_id\nFROM users WHERE emial = 'x'", td.MockSchema)
+	assert.Equal(t, 1, len(issues))
+	assert.Equal(t, analysis.SeverityError, issues[0].Severity)
+	assert.Equal(t, "line 2 column 18: error unknown-column: column \"emial\" does not exist", issues[0].String())
+
+	// syntax errors are returned, the valid statements still analyzed
+	issues, err := analysis.Analyze("SELECT * FROM users; SELEC 1", td.MockSchema)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, []string{analysis.SelectStar}, codes(issues))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+// config the sources to register, uses the same keys as the json
+// schema.ConfigSource so yaml and json configs are interchangeable.
+//
+//	schema: mydb
+//	sources:
+//	  - name: mydb
+//	    type: cloudstore
+//	    settings:
+//	      type: localfs
+//	      path: tables/
+//	      localpath: tables/
+//	      format: csv
+type config struct {
+	Schema  string                 `json:"schema"`
+	Sources []*schema.ConfigSource `json:"sources"`
+}
+
+func loadConfig(path string) (*config, error) {
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err = yaml.Unmarshal(by, &doc); err != nil {
+			return nil, fmt.Errorf("could not read config %q: %v", path, err)
+		}
+		if by, err = json.Marshal(jsonValue(doc)); err != nil {
+			return nil, fmt.Errorf("could not read config %q: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized config file type %q, expected .yaml, .json", path)
+	}
+	conf := &config{}
+	if err = json.Unmarshal(by, conf); err != nil {
+		return nil, fmt.Errorf("could not read config %q: %v", path, err)
+	}
+	for i, src := range conf.Sources {
+		if src.Name == "" || src.SourceType == "" {
+			return nil, fmt.Errorf("config %q source %d requires name and type", path, i)
+		}
+	}
+	return conf, nil
+}
+
+// jsonValue convert the map[interface{}]interface{} of yaml documents
+// to json compatible map[string]interface{}.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprintf("%v", k)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = jsonValue(val)
+		}
+		return v
+	}
+	return v
+}
+
+// register each source as a schema in registry.
+func (m *config) register(reg *schema.Registry) error {
+	for _, src := range m.Sources {
+		if err := reg.SchemaAddFromConfig(src); err != nil {
+			return fmt.Errorf("could not register source %q: %v", src.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *config) defaultSchema() string {
+	if m.Schema != "" {
+		return m.Schema
+	}
+	for _, src := range m.Sources {
+		if src.Schema != "" {
+			return src.Schema
+		}
+		return src.Name
+	}
+	return ""
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxHistory = 1000
+
+// editor reads lines with emacs style editing and history when input is a
+// terminal, otherwise plain lines without prompts (ie piped input).
+//
+//	ctrl-a/e, home/end     start, end of line
+//	ctrl-b/f, left/right   move cursor
+//	ctrl-p/n, up/down      history
+//	ctrl-k/u/w             delete to end, to start, previous word
+//	ctrl-c                 discard input, ctrl-d on empty line exits
+type editor struct {
+	in          *bufio.Reader
+	out         io.Writer
+	raw         func() (restore func(), err error) // nil if not a terminal
+	history     []string
+	historyFile string
+}
+
+func newLineReader(in *os.File, out io.Writer, historyFile string) lineReader {
+	e := &editor{in: bufio.NewReader(in), out: out, raw: rawMode(int(in.Fd()))}
+	if e.raw != nil && historyFile != "" {
+		e.historyFile = historyFile
+		e.loadHistory()
+	}
+	return e
+}
+
+func (m *editor) loadHistory() {
+	f, err := os.Open(m.historyFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			m.history = append(m.history, line)
+		}
+	}
+	if len(m.history) > maxHistory {
+		m.history = m.history[len(m.history)-maxHistory:]
+	}
+}
+
+// AddHistory append line to history, and history file.
+func (m *editor) AddHistory(line string) {
+	if n := len(m.history); n > 0 && m.history[n-1] == line {
+		return
+	}
+	m.history = append(m.history, line)
+	if len(m.history) > maxHistory {
+		m.history = m.history[1:]
+	}
+	if m.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(m.historyFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(f, line)
+	f.Close()
+}
+
+func (m *editor) Close() error { return nil }
+
+// ReadLine read next line, editing it if input is a terminal.
+func (m *editor) ReadLine(prompt string) (string, error) {
+	if m.raw == nil {
+		line, err := m.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	restore, err := m.raw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return m.edit(prompt)
+}
+
+// edit the line, input must be in raw mode.
+func (m *editor) edit(prompt string) (string, error) {
+	var line []rune
+	pos := 0
+	hpos := len(m.history)
+	saved := ""
+
+	refresh := func() {
+		// redraw the line, move cursor to column
+		fmt.Fprintf(m.out, "\r%s%s\x1b[K\r", prompt, string(line))
+		if n := utf8.RuneCountInString(prompt) + pos; n > 0 {
+			fmt.Fprintf(m.out, "\x1b[%dC", n)
+		}
+	}
+	setLine := func(s string) {
+		line = []rune(s)
+		pos = len(line)
+	}
+	refresh()
+
+	for {
+		r, _, err := m.in.ReadRune()
+		if err != nil {
+			io.WriteString(m.out, "\r\n")
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			io.WriteString(m.out, "\r\n")
+			return string(line), nil
+		case 3: // ctrl-c
+			io.WriteString(m.out, "^C\r\n")
+			return "", errInterrupt
+		case 4: // ctrl-d
+			if len(line) == 0 {
+				io.WriteString(m.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(line) {
+				line = append(line[:pos], line[pos+1:]...)
+			}
+		case 127, 8: // backspace
+			if pos > 0 {
+				line = append(line[:pos-1], line[pos:]...)
+				pos--
+			}
+		case 1: // ctrl-a
+			pos = 0
+		case 5: // ctrl-e
+			pos = len(line)
+		case 2: // ctrl-b
+			if pos > 0 {
+				pos--
+			}
+		case 6: // ctrl-f
+			if pos < len(line) {
+				pos++
+			}
+		case 11: // ctrl-k
+			line = line[:pos]
+		case 21: // ctrl-u
+			line = line[pos:]
+			pos = 0
+		case 23: // ctrl-w
+			start := pos
+			for start > 0 && unicode.IsSpace(line[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(line[start-1]) {
+				start--
+			}
+			line = append(line[:start], line[pos:]...)
+			pos = start
+		case 16, 14: // ctrl-p, ctrl-n
+			hpos, saved = m.historyMove(r == 16, hpos, saved, string(line), setLine)
+		case 27: // escape sequence
+			switch m.escape() {
+			case 'A':
+				hpos, saved = m.historyMove(true, hpos, saved, string(line), setLine)
+			case 'B':
+				hpos, saved = m.historyMove(false, hpos, saved, string(line), setLine)
+			case 'C':
+				if pos < len(line) {
+					pos++
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+				}
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(line)
+			case 'd':
+				if pos < len(line) {
+					line = append(line[:pos], line[pos+1:]...)
+				}
+			}
+		default:
+			if r == '\t' {
+				r = ' '
+			}
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			line = append(line, 0)
+			copy(line[pos+1:], line[pos:])
+			line[pos] = r
+			pos++
+		}
+		refresh()
+	}
+}
+
+// escape read the rest of an escape sequence, returns the final byte of
+// arrow (A-D), home/end (H,F) keys, or 'd' for delete.
+func (m *editor) escape() byte {
+	b, err := m.in.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return 0
+	}
+	param := ""
+	for {
+		c, err := m.in.ReadByte()
+		if err != nil {
+			return 0
+		}
+		switch {
+		case c >= '0' && c <= '9', c == ';':
+			param += string(c)
+			continue
+		case c == '~':
+			switch param {
+			case "1", "7":
+				return 'H'
+			case "4", "8":
+				return 'F'
+			case "3":
+				return 'd'
+			}
+			return 0
+		}
+		return c
+	}
+}
+
+// historyMove move up (back) or down in history, saving the line being
+// edited so moving back down past the end restores it.
+func (m *editor) historyMove(up bool, hpos int, saved, current string, set func(string)) (int, string) {
+	if up {
+		if hpos == 0 {
+			return hpos, saved
+		}
+		if hpos == len(m.history) {
+			saved = current
+		}
+		hpos--
+		set(m.history[hpos])
+		return hpos, saved
+	}
+	if hpos >= len(m.history) {
+		return hpos, saved
+	}
+	hpos++
+	if hpos == len(m.history) {
+		set(saved)
+	} else {
+		set(m.history[hpos])
+	}
+	return hpos, saved
+}
@@ -0,0 +1,94 @@
+// Command qlbridge is an interactive sql shell for qlbridge schemas.
+//
+// Sources are registered from a yaml (or json) config file of schema
+// ConfigSource's, statements may span lines and are run on ";".
+//
+//	qlbridge --config=sources.yaml --schema=mydb
+//	qlbridge --config=sources.yaml --schema=mydb --format=csv -e "SELECT * FROM users;"
+//
+// Meta commands (\? for help) describe the schemas:
+//
+//	\l           list schemas
+//	\c schema    use schema
+//	\d           list tables
+//	\d table     describe table
+//	\f format    output format [table,csv,json]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	u "github.com/araddon/gou"
+
+	// Side-Effect Import the qlbridge sql driver, file sources
+	_ "github.com/araddon/qlbridge/datasource/files"
+	"github.com/araddon/qlbridge/expr/builtins"
+	_ "github.com/araddon/qlbridge/qlbdriver"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	configFile  string
+	schemaName  string
+	format      = FormatTable
+	execSql     string
+	historyFile string
+	logging     = "warn"
+)
+
+func init() {
+	home, _ := os.UserHomeDir()
+	flag.StringVar(&configFile, "config", "", "yaml or json config file of sources to register")
+	flag.StringVar(&schemaName, "schema", "", "schema to use, default is config schema or first source")
+	flag.StringVar(&format, "format", FormatTable, "output format [table,csv,json]")
+	flag.StringVar(&execSql, "e", "", "execute statements and exit")
+	flag.StringVar(&historyFile, "history", filepath.Join(home, ".qlbridge_history"), "history file, empty to disable")
+	flag.StringVar(&logging, "logging", "warn", "logging [debug,info,warn,error]")
+}
+
+func main() {
+	flag.Parse()
+	u.SetupLogging(logging)
+
+	builtins.LoadAllBuiltins()
+
+	if configFile != "" {
+		conf, err := loadConfig(configFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err = conf.register(schema.DefaultRegistry()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if schemaName == "" {
+			schemaName = conf.defaultSchema()
+		}
+	}
+
+	r := newRepl(os.Stdout)
+	r.schema = schemaName
+	if err := r.setFormat(format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if execSql != "" {
+		r.line(execSql)
+		if !r.flush() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	lr := newLineReader(os.Stdin, os.Stdout, historyFile)
+	defer lr.Close()
+	if err := r.run(lr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+// resultWriter writes rows of a result in one of the output formats.
+type resultWriter interface {
+	row(vals []interface{}) error
+	// done write any buffered output, the table format buffers all rows
+	// to size the columns.
+	done() error
+}
+
+func newResultWriter(w io.Writer, format string, cols []string) resultWriter {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(cols)
+		return &csvWriter{cw: cw}
+	case FormatJSON:
+		return &jsonWriter{w: w, cols: cols}
+	}
+	return &tableWriter{w: w, cols: cols}
+}
+
+// formatValue the display text of a result value, nil is NULL.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+type tableWriter struct {
+	w    io.Writer
+	cols []string
+	rows [][]string
+}
+
+func (m *tableWriter) row(vals []interface{}) error {
+	row := make([]string, len(vals))
+	for i, v := range vals {
+		row[i] = formatValue(v)
+	}
+	m.rows = append(m.rows, row)
+	return nil
+}
+
+func (m *tableWriter) done() error {
+	widths := make([]int, len(m.cols))
+	for i, col := range m.cols {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+	for _, row := range m.rows {
+		for i, val := range row {
+			if n := utf8.RuneCountInString(val); i < len(widths) && n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	sep := &strings.Builder{}
+	sep.WriteString("+")
+	for _, n := range widths {
+		sep.WriteString(strings.Repeat("-", n+2))
+		sep.WriteString("+")
+	}
+	sep.WriteString("\n")
+
+	line := func(vals []string) string {
+		b := &strings.Builder{}
+		b.WriteString("|")
+		for i, n := range widths {
+			val := ""
+			if i < len(vals) {
+				val = vals[i]
+			}
+			b.WriteString(" ")
+			b.WriteString(val)
+			b.WriteString(strings.Repeat(" ", n-utf8.RuneCountInString(val)+1))
+			b.WriteString("|")
+		}
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(sep.String())
+	b.WriteString(line(m.cols))
+	b.WriteString(sep.String())
+	for _, row := range m.rows {
+		b.WriteString(line(row))
+	}
+	if len(m.rows) > 0 {
+		b.WriteString(sep.String())
+	}
+	_, err := io.WriteString(m.w, b.String())
+	return err
+}
+
+type csvWriter struct {
+	cw *csv.Writer
+}
+
+func (m *csvWriter) row(vals []interface{}) error {
+	rec := make([]string, len(vals))
+	for i, v := range vals {
+		if v != nil {
+			rec[i] = formatValue(v)
+		}
+	}
+	return m.cw.Write(rec)
+}
+
+func (m *csvWriter) done() error {
+	m.cw.Flush()
+	return m.cw.Error()
+}
+
+// jsonWriter one json object per row, keys in column order.
+type jsonWriter struct {
+	w    io.Writer
+	cols []string
+}
+
+func (m *jsonWriter) row(vals []interface{}) error {
+	b := &strings.Builder{}
+	b.WriteString("{")
+	for i, v := range vals {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		if i < len(m.cols) {
+			k, _ := json.Marshal(m.cols[i])
+			b.Write(k)
+		} else {
+			fmt.Fprintf(b, `"%d"`, i)
+		}
+		b.WriteString(":")
+		if by, ok := v.([]byte); ok {
+			v = string(by)
+		}
+		jv, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(jv)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(m.w, b.String())
+	return err
+}
+
+func (m *jsonWriter) done() error { return nil }
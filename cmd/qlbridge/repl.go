@@ -0,0 +1,333 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+const (
+	prompt     = "qlbridge> "
+	contPrompt = "       -> "
+)
+
+const helpText = `Statements end with ";" and may span multiple lines.
+
+  \?            this help
+  \q            quit
+  \l            list schemas
+  \c schema     use schema
+  \d            list tables in current schema
+  \d table      describe table columns
+  \f format     set output format [table,csv,json]
+  \timing       toggle statement timing
+`
+
+var errInterrupt = errors.New("interrupt")
+
+// lineReader reads input lines, errInterrupt on ctrl-c and io.EOF
+// when there is no more input.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+	AddHistory(line string)
+	Close() error
+}
+
+type repl struct {
+	out    io.Writer
+	schema string
+	format string
+	timing bool
+	buf    string // pending statement text, waiting for ";"
+	errs   int
+}
+
+func newRepl(out io.Writer) *repl {
+	return &repl{out: out, format: FormatTable}
+}
+
+// run read and evaluate lines until \q or end of input.
+func (m *repl) run(lr lineReader) error {
+	for {
+		p := prompt
+		if m.buf != "" {
+			p = contPrompt
+		}
+		line, err := lr.ReadLine(p)
+		if err == errInterrupt {
+			m.buf = ""
+			continue
+		}
+		if err == io.EOF {
+			m.flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			lr.AddHistory(line)
+		}
+		if quit := m.line(line); quit {
+			return nil
+		}
+	}
+}
+
+// line evaluate a line of input, meta commands at the start of a
+// statement, and any statements completed by this line.
+func (m *repl) line(line string) (quit bool) {
+	if m.buf == "" {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, `\`) {
+			return m.meta(trimmed)
+		}
+		if trimmed == "" {
+			return false
+		}
+	}
+	stmts, rest := splitStatements(m.buf + line + "\n")
+	for _, stmt := range stmts {
+		m.exec(stmt)
+	}
+	m.buf = rest
+	switch trimmed := strings.TrimSpace(rest); {
+	case trimmed == "":
+		m.buf = ""
+	case len(stmts) > 0 && strings.HasPrefix(trimmed, `\`):
+		// meta command following statements on the same line
+		m.buf = ""
+		return m.meta(trimmed)
+	}
+	return false
+}
+
+// flush run the pending unterminated statement, true if no statement
+// so far has errored.
+func (m *repl) flush() bool {
+	if stmt := strings.TrimSpace(m.buf); stmt != "" {
+		m.exec(stmt)
+	}
+	m.buf = ""
+	return m.errs == 0
+}
+
+// splitStatements split text on ";" outside of quotes and comments,
+// the complete statements and the remaining unterminated text.
+func splitStatements(text string) ([]string, string) {
+	var stmts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && strings.HasPrefix(text[i:], "--"):
+			nl := strings.IndexByte(text[i:], '\n')
+			if nl < 0 {
+				return stmts, text[start:]
+			}
+			i += nl
+		case c == ';':
+			if stmt := strings.TrimSpace(text[start:i]); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+	return stmts, text[start:]
+}
+
+func (m *repl) errorf(format string, args ...interface{}) {
+	m.errs++
+	fmt.Fprintf(m.out, "ERROR: "+format+"\n", args...)
+}
+
+func (m *repl) setFormat(format string) error {
+	switch format {
+	case FormatTable, FormatCSV, FormatJSON:
+		m.format = format
+		return nil
+	}
+	return fmt.Errorf("unrecognized format %q expected table, csv, json", format)
+}
+
+func (m *repl) meta(cmd string) (quit bool) {
+	args := strings.Fields(strings.TrimSuffix(cmd, ";"))
+	arg := ""
+	if len(args) > 1 {
+		arg = args[1]
+	}
+	switch args[0] {
+	case `\q`, `\quit`:
+		return true
+	case `\?`, `\h`, `\help`:
+		io.WriteString(m.out, helpText)
+	case `\l`, `\dn`:
+		names := schema.DefaultRegistry().Schemas()
+		sort.Strings(names)
+		rw := newResultWriter(m.out, m.format, []string{"schema"})
+		for _, name := range names {
+			rw.row([]interface{}{name})
+		}
+		rw.done()
+	case `\c`, `\connect`, `\use`:
+		if arg == "" {
+			fmt.Fprintf(m.out, "using schema %q\n", m.schema)
+			return false
+		}
+		if _, ok := schema.DefaultRegistry().Schema(arg); !ok {
+			m.errorf("schema %q not found", arg)
+			return false
+		}
+		m.schema = arg
+		fmt.Fprintf(m.out, "using schema %q\n", m.schema)
+	case `\d`, `\dt`:
+		s, ok := m.currentSchema()
+		if !ok {
+			return false
+		}
+		if arg == "" {
+			rw := newResultWriter(m.out, m.format, []string{"table"})
+			for _, name := range s.Tables() {
+				rw.row([]interface{}{name})
+			}
+			rw.done()
+			return false
+		}
+		tbl, err := s.Table(strings.ToLower(arg))
+		if err != nil || tbl == nil {
+			m.errorf("table %q not found in schema %q", arg, s.Name)
+			return false
+		}
+		rw := newResultWriter(m.out, m.format, []string{"column", "type", "nullable", "key", "description"})
+		for _, f := range tbl.FieldList() {
+			rw.row([]interface{}{f.Name, f.ValueType().String(), !f.NoNulls, f.Key, f.Description})
+		}
+		rw.done()
+	case `\f`, `\format`:
+		if arg == "" {
+			fmt.Fprintf(m.out, "format is %s\n", m.format)
+		} else if err := m.setFormat(arg); err != nil {
+			m.errorf("%v", err)
+		}
+	case `\timing`:
+		m.timing = !m.timing
+		fmt.Fprintf(m.out, "timing is %v\n", m.timing)
+	default:
+		m.errorf("unrecognized command %s, \\? for help", args[0])
+	}
+	return false
+}
+
+func (m *repl) currentSchema() (*schema.Schema, bool) {
+	if m.schema == "" {
+		m.errorf(`no schema selected, \c schema to choose one`)
+		return nil, false
+	}
+	s, ok := schema.DefaultRegistry().Schema(m.schema)
+	if !ok {
+		m.errorf("schema %q not found", m.schema)
+	}
+	return s, ok
+}
+
+// exec run a statement writing its results.
+func (m *repl) exec(text string) {
+	stmt, err := rel.ParseSql(text)
+	if err != nil {
+		// report every syntax error, with where they are
+		if _, rerr := rel.ParseSqlRecover(text); rerr != nil {
+			err = rerr
+		}
+		m.errorf("%v", err)
+		return
+	}
+	if _, ok := m.currentSchema(); !ok {
+		return
+	}
+	db, err := sql.Open("qlbridge", m.schema)
+	if err != nil {
+		m.errorf("%v", err)
+		return
+	}
+	defer db.Close()
+
+	start := time.Now()
+	defer func() {
+		if m.timing {
+			fmt.Fprintf(m.out, "Time: %v\n", time.Since(start))
+		}
+	}()
+
+	switch stmt.(type) {
+	case *rel.SqlSelect, *rel.SqlShow, *rel.SqlDescribe:
+		// these return rows
+	default:
+		result, err := db.Exec(text)
+		if err != nil {
+			m.errorf("%v", err)
+			return
+		}
+		affected, _ := result.RowsAffected()
+		fmt.Fprintf(m.out, "OK, %d rows affected\n", affected)
+		return
+	}
+
+	rows, err := db.Query(text)
+	if err != nil {
+		m.errorf("%v", err)
+		return
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		m.errorf("%v", err)
+		return
+	}
+
+	vals := make([]interface{}, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	rw := newResultWriter(m.out, m.format, cols)
+	ct := 0
+	for rows.Next() {
+		for i := range vals {
+			vals[i] = nil
+		}
+		if err = rows.Scan(dest...); err != nil {
+			break
+		}
+		if err = rw.row(vals); err != nil {
+			break
+		}
+		ct++
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	rw.done()
+	if err != nil {
+		m.errorf("%v", err)
+		return
+	}
+	if m.format == FormatTable {
+		fmt.Fprintf(m.out, "%d rows\n", ct)
+	}
+}
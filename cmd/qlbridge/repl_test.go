@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts, rest := splitStatements("SELECT 1;\nSELECT ';' -- a; comment\n FROM x; SELECT")
+	assert.Equal(t, []string{"SELECT 1", "SELECT ';' -- a; comment\n FROM x"}, stmts)
+	assert.Equal(t, " SELECT", rest)
+
+	stmts, rest = splitStatements(`SELECT "a\";b"`)
+	assert.Equal(t, 0, len(stmts))
+	assert.Equal(t, `SELECT "a\";b"`, rest)
+}
+
+func TestRepl(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := newRepl(out)
+
+	input := strings.Join([]string{
+		`\c mockcsv`,
+		`\d`,
+		`\d users`,
+		`SELECT user_id, email`,
+		`FROM users WHERE user_id = "hT2impsOPUREcVPc";`,
+		`\f csv`,
+		`SELECT email FROM users WHERE user_id = "hT2impsOPUREcVPc"; \q`,
+	}, "\n")
+	err := r.run(&editor{in: bufio.NewReader(strings.NewReader(input)), out: ioutil.Discard})
+	assert.Equal(t, nil, err)
+	res := out.String()
+	assert.True(t, strings.Contains(res, `using schema "mockcsv"`), res)
+	assert.True(t, strings.Contains(res, "| users "), res)
+	assert.True(t, strings.Contains(res, "| referral_count | int "), res)
+	assert.True(t, strings.Contains(res, "| hT2impsOPUREcVPc | bob@email.com |"), res)
+	assert.True(t, strings.Contains(res, "1 rows\n"), res)
+	assert.True(t, strings.HasSuffix(res, "email\nbob@email.com\n"), res)
+	assert.Equal(t, 0, r.errs)
+
+	out.Reset()
+	r.line(`\f json`)
+	r.line(`SELECT user_id, email FROM users WHERE user_id = "hT2impsOPUREcVPc";`)
+	assert.Equal(t, `{"user_id":"hT2impsOPUREcVPc","email":"bob@email.com"}`+"\n", out.String())
+
+	// unterminated last statement is run at end of input
+	out.Reset()
+	r.line(`SELECT email FROM users WHERE user_id = "hT2impsOPUREcVPc"`)
+	assert.Equal(t, "", out.String())
+	assert.True(t, r.flush())
+	assert.Equal(t, `{"email":"bob@email.com"}`+"\n", out.String())
+
+	out.Reset()
+	r.line(`SELECTX * FROM users;`)
+	r.line(`\c not_a_schema`)
+	r.line(`\x`)
+	assert.Equal(t, 3, strings.Count(out.String(), "ERROR: "), out.String())
+
+	// every syntax error of a statement is reported, with a caret
+	out.Reset()
+	r.line(`SELECT email FROM users WHERE x = = 1 LIMIT y;`)
+	assert.Equal(t, 1, strings.Count(out.String(), "ERROR: "), out.String())
+	assert.Equal(t, 2, strings.Count(out.String(), "^"), out.String())
+	assert.False(t, r.flush())
+}
+
+func TestEditor(t *testing.T) {
+	// left arrow, backspace, insert; ctrl-a, ctrl-k; history up
+	keys := "abc\x1b[D\x7fX\r" + "hello\x01\x0bok\r" + "\x1b[A\x1b[A\r" + "\x04"
+	e := &editor{in: bufio.NewReader(strings.NewReader(keys)), out: ioutil.Discard}
+	var lines []string
+	for {
+		line, err := e.edit("> ")
+		if err == io.EOF {
+			break
+		}
+		assert.Equal(t, nil, err)
+		lines = append(lines, line)
+		e.AddHistory(line)
+	}
+	assert.Equal(t, []string{"aXc", "ok", "aXc"}, lines)
+
+	e = &editor{in: bufio.NewReader(strings.NewReader("abc\x03")), out: ioutil.Discard}
+	_, err := e.edit("> ")
+	assert.Equal(t, errInterrupt, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qlbridge")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sources.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+sources:
+  - name: mydb
+    type: cloudstore
+    tables_to_load: [users]
+    settings:
+      type: localfs
+      localpath: tables/
+      format: csv
+`), 0644)
+	assert.Equal(t, nil, err)
+	conf, err := loadConfig(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(conf.Sources))
+	assert.Equal(t, "cloudstore", conf.Sources[0].SourceType)
+	assert.Equal(t, []string{"users"}, conf.Sources[0].TablesToLoad)
+	assert.Equal(t, "localfs", conf.Sources[0].Settings.String("type"))
+	assert.Equal(t, "mydb", conf.defaultSchema())
+
+	err = ioutil.WriteFile(path, []byte("sources:\n  - name: nope\n"), 0644)
+	assert.Equal(t, nil, err)
+	_, err = loadConfig(path)
+	assert.NotEqual(t, nil, err)
+}
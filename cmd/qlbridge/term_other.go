@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package main
+
+// rawMode line editing is not supported, input is read as plain lines.
+func rawMode(fd int) func() (func(), error) {
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// rawMode func to put terminal fd into raw mode, nil if fd is not a terminal.
+func rawMode(fd int) func() (func(), error) {
+	if _, err := unix.IoctlGetTermios(fd, ioctlReadTermios); err != nil {
+		return nil
+	}
+	return func() (func(), error) {
+		orig, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+		if err != nil {
+			return nil, err
+		}
+		raw := *orig
+		raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+		raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		raw.Cflag &^= unix.CSIZE | unix.PARENB
+		raw.Cflag |= unix.CS8
+		raw.Cc[unix.VMIN] = 1
+		raw.Cc[unix.VTIME] = 0
+		if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &raw); err != nil {
+			return nil, err
+		}
+		return func() { unix.IoctlSetTermios(fd, ioctlWriteTermios, orig) }, nil
+	}
+}
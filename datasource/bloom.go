@@ -0,0 +1,54 @@
+package datasource
+
+import (
+	"github.com/dchest/siphash"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// Ensure that we implement the key filter interface
+	_ schema.KeyFilter = (*BloomFilter)(nil)
+)
+
+// BloomFilter a simple fixed size bloom filter, used for the column
+// zone-maps persisted by file sources and for the join keys pushed to
+// the probe side scan of a join.
+type BloomFilter struct {
+	Bits []uint64 `json:"bits"`
+	K    int      `json:"k"`
+}
+
+// NewBloomFilter create a bloom filter of @bits size using @k hashes.
+func NewBloomFilter(bits, k int) *BloomFilter {
+	if bits < 64 {
+		bits = 64
+	}
+	return &BloomFilter{Bits: make([]uint64, (bits+63)/64), K: k}
+}
+
+// Add key to filter.
+func (m *BloomFilter) Add(key string) {
+	h1, h2 := siphash.Hash128(0, 1, []byte(key))
+	n := uint64(len(m.Bits) * 64)
+	for i := 0; i < m.K; i++ {
+		loc := (h1 + uint64(i)*h2) % n
+		m.Bits[loc/64] |= 1 << (loc % 64)
+	}
+}
+
+// Has returns false if key is definitely not in filter.
+func (m *BloomFilter) Has(key string) bool {
+	if len(m.Bits) == 0 {
+		return true
+	}
+	h1, h2 := siphash.Hash128(0, 1, []byte(key))
+	n := uint64(len(m.Bits) * 64)
+	for i := 0; i < m.K; i++ {
+		loc := (h1 + uint64(i)*h2) % n
+		if m.Bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
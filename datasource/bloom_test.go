@@ -0,0 +1,38 @@
+package datasource_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+)
+
+func TestBloomFilter(t *testing.T) {
+	bf := datasource.NewBloomFilter(1000, 7)
+	for i := 0; i < 100; i++ {
+		bf.Add(fmt.Sprintf("key-%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		assert.True(t, bf.Has(fmt.Sprintf("key-%d", i)))
+	}
+	falsePositives := 0
+	for i := 100; i < 1100; i++ {
+		if bf.Has(fmt.Sprintf("key-%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.True(t, falsePositives < 50, "false positives %d", falsePositives)
+
+	// persisted as json by file source zone-maps
+	by, err := json.Marshal(bf)
+	assert.Equal(t, nil, err)
+	bf2 := &datasource.BloomFilter{}
+	assert.Equal(t, nil, json.Unmarshal(by, bf2))
+	assert.True(t, bf2.Has("key-5"))
+
+	// empty filter can't rule out a key
+	assert.True(t, (&datasource.BloomFilter{}).Has("nope"))
+}
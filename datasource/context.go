@@ -116,13 +116,14 @@ func (m *SqlDriverMessageMap) Values() []driver.Value    { return m.Vals }
 func (m *SqlDriverMessageMap) SetRow(row []driver.Value) { m.Vals = row }
 func (m *SqlDriverMessageMap) Ts() time.Time             { return time.Time{} }
 func (m *SqlDriverMessageMap) Get(key string) (value.Value, bool) {
-	if idx, ok := m.ColIndex[key]; ok {
+	// the index of a column not projected from the source is -1
+	if idx, ok := m.ColIndex[key]; ok && idx >= 0 && idx < len(m.Vals) {
 		return value.NewValue(m.Vals[idx]), true
 	}
 	_, right, hasLeft := expr.LeftRight(key)
 	//u.Debugf("could not find: %q  right=%q hasLeftRight?%v", key, right, hasLeft)
 	if hasLeft {
-		if idx, ok := m.ColIndex[right]; ok {
+		if idx, ok := m.ColIndex[right]; ok && idx >= 0 && idx < len(m.Vals) {
 			return value.NewValue(m.Vals[idx]), true
 		}
 	}
@@ -131,6 +132,9 @@ func (m *SqlDriverMessageMap) Get(key string) (value.Value, bool) {
 func (m *SqlDriverMessageMap) Row() map[string]value.Value {
 	row := make(map[string]value.Value)
 	for k, idx := range m.ColIndex {
+		if idx < 0 || idx >= len(m.Vals) {
+			continue
+		}
 		row[k] = value.NewValue(m.Vals[idx])
 	}
 	return row
@@ -0,0 +1,338 @@
+package datasource
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+var (
+	// ErrArtifactKey artifact is encrypted and no key provider can decrypt it.
+	ErrArtifactKey = fmt.Errorf("QLBridge: no key to decrypt artifact")
+	// ErrArtifactCorrupt encrypted artifact failed authentication or was truncated.
+	ErrArtifactCorrupt = fmt.Errorf("QLBridge: encrypted artifact corrupt or truncated")
+
+	artifactMagic = []byte("QLBE\x01")
+
+	keysMu       sync.RWMutex
+	artifactKeys KeyProvider
+)
+
+const (
+	artifactChunk = 64 * 1024
+	noncePrefix   = 8
+)
+
+// KeyProvider supplies AES keys (16, 24 or 32 bytes) used to encrypt the
+// temp and persisted artifacts (memdb snapshots, spill files) the engine
+// writes.  The key id is stored in the artifact header so keys may be
+// rotated while artifacts written with older keys remain readable.
+type KeyProvider interface {
+	// Key current key, and its id, to encrypt new artifacts.
+	Key() (id string, key []byte, err error)
+	// KeyByID key for id to decrypt an existing artifact.
+	KeyByID(id string) ([]byte, error)
+}
+
+type staticKey []byte
+
+// NewStaticKey a KeyProvider for a single key.
+func NewStaticKey(key []byte) KeyProvider { return staticKey(key) }
+
+func (m staticKey) Key() (string, []byte, error)      { return "", m, nil }
+func (m staticKey) KeyByID(id string) ([]byte, error) { return m, nil }
+
+// SetArtifactKeys set the key provider used to encrypt artifacts written by
+// the engine, nil (default) writes them un-encrypted.
+func SetArtifactKeys(kp KeyProvider) {
+	keysMu.Lock()
+	artifactKeys = kp
+	keysMu.Unlock()
+}
+
+// ArtifactKeys current artifact key provider, nil if not encrypting.
+func ArtifactKeys() KeyProvider {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return artifactKeys
+}
+
+// NewArtifactWriter wrap w so an artifact written to it is encrypted with
+// AES-GCM using the ArtifactKeys, if configured.  The writer must be closed
+// to flush the final chunk.
+//
+// Format: header of magic, key id length, key id and nonce prefix, then
+// chunks of (length, sealed chunk).  Each chunk is sealed with the header
+// as additional data so it can't be swapped, the final chunk marked final
+// so truncation is detected.
+func NewArtifactWriter(w io.Writer) (io.WriteCloser, error) {
+	kp := ArtifactKeys()
+	if kp == nil {
+		return nopWriteCloser{w}, nil
+	}
+	id, key, err := kp.Key()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("QLBridge: key id too long %q", id)
+	}
+	hdr := append(append([]byte{}, artifactMagic...), byte(len(id)))
+	hdr = append(hdr, id...)
+	prefix := make([]byte, noncePrefix)
+	if _, err = io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, err
+	}
+	hdr = append(hdr, prefix...)
+	if _, err = w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &artifactWriter{w: w, aead: aead, hdr: hdr, prefix: prefix, buf: make([]byte, 0, artifactChunk)}, nil
+}
+
+// NewArtifactReader read an artifact written by NewArtifactWriter.
+// Artifacts that are not encrypted are read as is if there are no
+// ArtifactKeys, else they are rejected as ErrArtifactCorrupt so a plain
+// text artifact can't be substituted for an encrypted one.
+func NewArtifactReader(r io.Reader) (io.Reader, error) {
+	kp := ArtifactKeys()
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(artifactMagic))
+	if err != nil || !bytes.Equal(magic, artifactMagic) {
+		if kp != nil {
+			return nil, ErrArtifactCorrupt
+		}
+		return br, nil
+	}
+	br.Discard(len(artifactMagic))
+	idLen, err := br.ReadByte()
+	if err != nil {
+		return nil, ErrArtifactCorrupt
+	}
+	id := make([]byte, idLen)
+	prefix := make([]byte, noncePrefix)
+	if _, err = io.ReadFull(br, id); err != nil {
+		return nil, ErrArtifactCorrupt
+	}
+	if _, err = io.ReadFull(br, prefix); err != nil {
+		return nil, ErrArtifactCorrupt
+	}
+	if kp == nil {
+		return nil, ErrArtifactKey
+	}
+	key, err := kp.KeyByID(string(id))
+	if err != nil || key == nil {
+		return nil, ErrArtifactKey
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	hdr := append(append([]byte{}, artifactMagic...), idLen)
+	hdr = append(append(hdr, id...), prefix...)
+	return &artifactReader{r: br, aead: aead, hdr: hdr, prefix: prefix}, nil
+}
+
+// CreateArtifact create (truncate) file at path for writing an artifact.
+func CreateArtifact(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewArtifactWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileWriter{WriteCloser: w, f: f}, nil
+}
+
+// CreateTempArtifact create a temp file, see ioutil.TempFile, for writing
+// an artifact returning the writer and its path.
+func CreateTempArtifact(dir, pattern string) (io.WriteCloser, string, error) {
+	f, err := ioutil.TempFile(dir, pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	w, err := NewArtifactWriter(f)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, "", err
+	}
+	return &fileWriter{WriteCloser: w, f: f}, f.Name(), nil
+}
+
+// OpenArtifact open the artifact file at path for reading.
+func OpenArtifact(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewArtifactReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileReader{Reader: r, f: f}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, seq uint32) []byte {
+	nonce := make([]byte, noncePrefix+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefix:], seq)
+	return nonce
+}
+
+// additional data for chunk, the artifact header and final chunk mark
+func chunkAD(hdr []byte, final bool) []byte {
+	ad := make([]byte, len(hdr)+1)
+	copy(ad, hdr)
+	if final {
+		ad[len(hdr)] = 1
+	}
+	return ad
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+type artifactWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	hdr    []byte
+	prefix []byte
+	seq    uint32
+	buf    []byte
+	closed bool
+}
+
+func (m *artifactWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if len(m.buf) == artifactChunk {
+			if err := m.flush(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(m.buf[len(m.buf):artifactChunk], p)
+		m.buf = m.buf[:len(m.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+func (m *artifactWriter) flush(final bool) error {
+	sealed := m.aead.Seal(nil, chunkNonce(m.prefix, m.seq), m.buf, chunkAD(m.hdr, final))
+	m.seq++
+	m.buf = m.buf[:0]
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(sealed)))
+	if _, err := m.w.Write(l[:]); err != nil {
+		return err
+	}
+	_, err := m.w.Write(sealed)
+	return err
+}
+
+func (m *artifactWriter) Close() error {
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	return m.flush(true)
+}
+
+type artifactReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	hdr    []byte
+	prefix []byte
+	seq    uint32
+	buf    []byte
+	final  bool
+}
+
+func (m *artifactReader) Read(p []byte) (int, error) {
+	for len(m.buf) == 0 {
+		if m.final {
+			return 0, io.EOF
+		}
+		if err := m.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, m.buf)
+	m.buf = m.buf[n:]
+	return n, nil
+}
+
+func (m *artifactReader) next() error {
+	var l [4]byte
+	if _, err := io.ReadFull(m.r, l[:]); err != nil {
+		return ErrArtifactCorrupt
+	}
+	size := binary.BigEndian.Uint32(l[:])
+	if size > artifactChunk+uint32(m.aead.Overhead()) {
+		return ErrArtifactCorrupt
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(m.r, sealed); err != nil {
+		return ErrArtifactCorrupt
+	}
+	nonce := chunkNonce(m.prefix, m.seq)
+	m.seq++
+	plain, err := m.aead.Open(nil, nonce, sealed, chunkAD(m.hdr, false))
+	if err != nil {
+		if plain, err = m.aead.Open(nil, nonce, sealed, chunkAD(m.hdr, true)); err != nil {
+			return ErrArtifactCorrupt
+		}
+		m.final = true
+	}
+	m.buf = plain
+	return nil
+}
+
+type fileWriter struct {
+	io.WriteCloser
+	f *os.File
+}
+
+func (m *fileWriter) Close() error {
+	err := m.WriteCloser.Close()
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+type fileReader struct {
+	io.Reader
+	f *os.File
+}
+
+func (m *fileReader) Close() error { return m.f.Close() }
@@ -0,0 +1,119 @@
+package datasource_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+)
+
+// rotatingKeys has key "k1" and new artifacts are written with "k2"
+type rotatingKeys map[string][]byte
+
+func (m rotatingKeys) Key() (string, []byte, error) { return "k2", m["k2"], nil }
+func (m rotatingKeys) KeyByID(id string) ([]byte, error) {
+	if k, ok := m[id]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("no key %q", id)
+}
+
+func writeArtifact(t *testing.T, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	w, err := datasource.NewArtifactWriter(buf)
+	assert.Equal(t, nil, err)
+	_, err = w.Write(data)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, w.Close())
+	return buf.Bytes()
+}
+
+func readArtifact(data []byte) ([]byte, error) {
+	r, err := datasource.NewArtifactReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestArtifactEncryption(t *testing.T) {
+	defer datasource.SetArtifactKeys(nil)
+
+	// larger than a chunk
+	data := bytes.Repeat([]byte("secret row data,"), 10000)
+
+	// no keys, plain text
+	plain := writeArtifact(t, data)
+	assert.Equal(t, data, plain)
+
+	datasource.SetArtifactKeys(datasource.NewStaticKey(bytes.Repeat([]byte("k"), 32)))
+	enc := writeArtifact(t, data)
+	assert.False(t, bytes.Contains(enc, []byte("secret")))
+	out, err := readArtifact(enc)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, data, out)
+
+	// un-encrypted artifacts are rejected once encrypting, so can't be
+	// substituted for encrypted ones
+	_, err = readArtifact(plain)
+	assert.Equal(t, datasource.ErrArtifactCorrupt, err)
+	datasource.SetArtifactKeys(nil)
+	out, err = readArtifact(plain)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, data, out)
+	datasource.SetArtifactKeys(datasource.NewStaticKey(bytes.Repeat([]byte("k"), 32)))
+
+	// empty
+	out, err = readArtifact(writeArtifact(t, nil))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(out))
+
+	// truncated at chunk boundary, or tampered
+	_, err = readArtifact(enc[:len(enc)-100])
+	assert.Equal(t, datasource.ErrArtifactCorrupt, err)
+	tampered := append([]byte{}, enc...)
+	tampered[len(tampered)/2] ^= 1
+	_, err = readArtifact(tampered)
+	assert.Equal(t, datasource.ErrArtifactCorrupt, err)
+
+	// rotated keys
+	keys := rotatingKeys{"k1": bytes.Repeat([]byte("1"), 16), "k2": bytes.Repeat([]byte("2"), 16)}
+	datasource.SetArtifactKeys(keys)
+	_, err = readArtifact(enc)
+	assert.Equal(t, datasource.ErrArtifactKey, err)
+	enc = writeArtifact(t, data)
+	// the header is authenticated, swapping the key id fails even if the
+	// keys are the same
+	keys["k1"] = keys["k2"]
+	swapped := append([]byte{}, enc...)
+	swapped[bytes.Index(swapped, []byte("k2"))+1] = '1'
+	_, err = readArtifact(swapped)
+	assert.Equal(t, datasource.ErrArtifactCorrupt, err)
+	delete(keys, "k2")
+	_, err = readArtifact(enc)
+	assert.Equal(t, datasource.ErrArtifactKey, err)
+
+	// temp files
+	datasource.SetArtifactKeys(datasource.NewStaticKey(bytes.Repeat([]byte("k"), 16)))
+	w, path, err := datasource.CreateTempArtifact("", "qlb_spill")
+	assert.Equal(t, nil, err)
+	defer os.Remove(path)
+	w.Write(data)
+	assert.Equal(t, nil, w.Close())
+	raw, _ := ioutil.ReadFile(path)
+	assert.False(t, bytes.Contains(raw, []byte("secret")))
+	r, err := datasource.OpenArtifact(path)
+	assert.Equal(t, nil, err)
+	out, _ = ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, data, out)
+
+	_, err = datasource.OpenArtifact(filepath.Join(os.TempDir(), "qlb_not_exists"))
+	assert.NotEqual(t, nil, err)
+}
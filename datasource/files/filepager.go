@@ -10,6 +10,7 @@ import (
 	"google.golang.org/api/iterator"
 
 	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -42,6 +43,9 @@ type FilePager struct {
 	tbl             *schema.Table
 	p               *plan.Source
 	usePartitioning bool
+	fr              *FileReader // current file
+	zone            *ZoneMap    // zone-map being built for current file
+	skipCt          int         // count of files skipped by zone-maps
 
 	schema.ConnScanner
 }
@@ -88,21 +92,84 @@ func (m *FilePager) Columns() []string {
 // represents different file, and multiple files for single source
 func (m *FilePager) NextScanner() (schema.ConnScanner, error) {
 
-	fr, err := m.NextFile()
-	if err == iterator.Done {
-		return nil, err
-	} else if err != nil {
-		u.Warnf("NextFile Error %v", err)
-		return nil, err
+	for {
+		fr, err := m.NextFile()
+		if err == iterator.Done {
+			return nil, err
+		} else if err != nil {
+			u.Warnf("NextFile Error %v", err)
+			return nil, err
+		}
+
+		if m.skipFile(fr) {
+			m.skipCt++
+			fr.F.Close()
+			continue
+		}
+
+		scanner, err := m.fs.fh.Scanner(m.fs.store, fr)
+		if err != nil {
+			u.Errorf("Could not open file scanner %v err=%v", m.fs.fileType, err)
+			return nil, err
+		}
+		m.fr = fr
+		m.zone = nil
+		if m.fs.useZoneMaps && m.fs.zoneMap(fr.FileInfo) == nil {
+			m.zone = NewZoneMap(fr.Name, fr.updated(), m.fs.bloomCols)
+		}
+		m.ConnScanner = scanner
+		return scanner, err
 	}
+}
 
-	scanner, err := m.fs.fh.Scanner(m.fs.store, fr)
-	if err != nil {
-		u.Errorf("Could not open file scanner %v err=%v", m.fs.fileType, err)
-		return nil, err
+// skipFile uses the zone-map of the file (if we have one) to see if the
+// where clause of this query can match any rows in it.
+func (m *FilePager) skipFile(fr *FileReader) bool {
+	if !m.fs.useZoneMaps || m.p == nil || m.p.Stmt == nil || m.p.Stmt.Source == nil {
+		return false
+	}
+	where := m.p.Stmt.Source.Where
+	if where == nil || where.Expr == nil {
+		return false
+	}
+	zm := m.fs.zoneMap(fr.FileInfo)
+	if zm == nil {
+		return false
+	}
+	return zm.CanSkip(where.Expr)
+}
+
+// finishZoneMap the scan of the current file ended, save its zone-map
+// only if the file was read to EOF.  A scan cut short by Close or exit,
+// or ended by a read error, has seen only some of the rows so its zone
+// is dropped.
+func (m *FilePager) finishZoneMap() {
+	zone := m.zone
+	m.zone = nil
+	if zone == nil || m.closed || m.exited() {
+		return
+	}
+	if ie, ok := m.ConnScanner.(schema.IteratorErr); ok && ie.Err() != nil {
+		u.Warnf("not saving zonemap for %q, scan failed err=%v", zone.File, ie.Err())
+		return
+	}
+	m.fs.saveZoneMap(zone)
+}
+
+// exited is the pager or the reader of the current file shut down.
+func (m *FilePager) exited() bool {
+	var frExit chan bool
+	if m.fr != nil {
+		frExit = m.fr.Exit
+	}
+	select {
+	case <-m.exit:
+		return true
+	case <-frExit:
+		return true
+	default:
+		return false
 	}
-	m.ConnScanner = scanner
-	return scanner, err
 }
 
 // NextFile gets next file
@@ -253,6 +320,7 @@ func (m *FilePager) Next() schema.Message {
 		}
 		msg := m.ConnScanner.Next()
 		if msg == nil {
+			m.finishZoneMap()
 			// Kind of crap api, side-effect method? uck
 			_, err := m.NextScanner()
 			if err != nil {
@@ -272,14 +340,24 @@ func (m *FilePager) Next() schema.Message {
 			}
 		}
 
+		if m.zone != nil {
+			if cr, ok := msg.(expr.ContextReader); ok {
+				m.zone.Add(cr.Row())
+			}
+		}
 		m.rowct++
 		return msg
 	}
 }
 
-// Close this connection/pager
+// Close this connection/pager, shutting down the reader of the current
+// file.
 func (m *FilePager) Close() error {
+	if !m.closed && m.fr != nil {
+		close(m.fr.Exit)
+	}
 	m.closed = true
+	m.zone = nil
 	//close(m.exit)
 	return nil
 }
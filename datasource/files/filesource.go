@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	u "github.com/araddon/gou"
@@ -59,6 +60,8 @@ func SipPartitioner(partitionCt uint64, fi *FileInfo) int {
 //                 multiple files per table (ie partitioned, per-day, etc)
 // - scanners:     responsible for file-specific
 // - files table:  a "table" of all the files from this cloud source
+// - zonemaps:     optional per-file column min/max and bloom filters used to
+//                 skip files, see ZoneMap
 //
 type FileSource struct {
 	ss             *schema.Schema
@@ -78,6 +81,10 @@ type FileSource struct {
 	Partitioner    string // random, ??  (date, keyed?)
 	partitionFunc  Partitioner
 	partitionCt    uint64
+	useZoneMaps    bool     // build and use per-file zone-maps to skip files
+	bloomCols      []string // columns to maintain bloom filters for in zone-maps
+	zmu            sync.Mutex
+	zoneMaps       map[string]*ZoneMap
 }
 
 // NewFileSource provides a singleton manager for a particular
@@ -89,6 +96,7 @@ func NewFileSource() *FileSource {
 		tables:        make(map[string]*FileTable),
 		tablenames:    make([]string, 0),
 		partitionFunc: SipPartitioner,
+		zoneMaps:      make(map[string]*ZoneMap),
 	}
 	return &m
 }
@@ -146,6 +154,8 @@ func (m *FileSource) init() error {
 		if partitioner := conf.String("partitioner"); partitioner != "" {
 			m.Partitioner = partitioner
 		}
+		m.useZoneMaps = conf.Bool("zonemaps")
+		m.bloomCols = conf.Strings("bloomfilters")
 
 		store, err := FileStoreLoader(m.ss)
 		if err != nil {
@@ -191,6 +201,9 @@ func (m *FileSource) init() error {
 }
 
 func (m *FileSource) File(o cloudstorage.Object) *FileInfo {
+	if isZoneMapFile(o.Name()) {
+		return nil
+	}
 	fi := m.fh.File(m.path, o)
 	if fi == nil {
 		// u.Debugf("ignoring file, path:%v  %q  is nil", m.path, o.Name())
@@ -266,6 +279,9 @@ func (m *FileSource) findTablesFromFileNames() error {
 				return err
 			}
 
+			if isZoneMapFile(o.Name()) {
+				continue
+			}
 			fi := m.fh.File(m.path, o)
 			if fi == nil || fi.Name == "" {
 				u.Warnf("no file?? %#v", o)
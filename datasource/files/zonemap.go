@@ -0,0 +1,362 @@
+package files
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+	"golang.org/x/net/context"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/metrics"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// ZoneMapSuffix is appended to the file name to persist the zone-map
+	// for that file alongside it in the store.
+	ZoneMapSuffix = ".zonemap.json"
+
+	// BloomFilterBits is the size in bits of each column bloom filter.
+	BloomFilterBits = 1 << 16
+	// BloomFilterHashes is the number of hash functions per bloom filter.
+	BloomFilterHashes = 4
+)
+
+// zoneMapWriter is the optional write side of a cloudstorage Store
+// used to persist zone-maps alongside the files they describe.
+type zoneMapWriter interface {
+	NewWriter(o string, metadata map[string]string) (io.WriteCloser, error)
+}
+
+// ZoneMap is light-weight per-file metadata, the min/max of each column
+// and optional bloom filters, built on first full scan of a file and used
+// to skip files that cannot match the WHERE predicate on later queries.
+type ZoneMap struct {
+	File    string                 `json:"file"`
+	Updated time.Time              `json:"updated"`
+	Rows    int64                  `json:"rows"`
+	Columns map[string]*ColumnZone `json:"columns"`
+}
+
+// ColumnZone is the min/max (and optional bloom filter) of the non-null
+// values of a single column in a single file.  Numeric is true if every
+// value parsed as a number, in which case MinNum/MaxNum are valid.  Opaque
+// columns had values of other types (time, maps, etc) and are not used.
+type ColumnZone struct {
+	Count   int64                   `json:"count"`
+	Opaque  bool                    `json:"opaque,omitempty"`
+	Numeric bool                    `json:"numeric"`
+	MinNum  float64                 `json:"minnum"`
+	MaxNum  float64                 `json:"maxnum"`
+	MinStr  string                  `json:"minstr"`
+	MaxStr  string                  `json:"maxstr"`
+	Bloom   *datasource.BloomFilter `json:"bloom,omitempty"`
+}
+
+// NewZoneMap create an empty zone-map for given file, bloom filters are
+// maintained for the @bloomCols columns.
+func NewZoneMap(file string, updated time.Time, bloomCols []string) *ZoneMap {
+	zm := &ZoneMap{File: file, Updated: updated, Columns: make(map[string]*ColumnZone)}
+	for _, col := range bloomCols {
+		zm.Columns[strings.ToLower(col)] = &ColumnZone{Numeric: true, Bloom: datasource.NewBloomFilter(BloomFilterBits, BloomFilterHashes)}
+	}
+	return zm
+}
+
+// Add a row of values to this zone-map.
+func (m *ZoneMap) Add(row map[string]value.Value) {
+	m.Rows++
+	for col, v := range row {
+		if v == nil || v.Nil() {
+			continue
+		}
+		col = strings.ToLower(col)
+		cz, ok := m.Columns[col]
+		if !ok {
+			cz = &ColumnZone{Numeric: true}
+			m.Columns[col] = cz
+		}
+		switch v.Type() {
+		case value.StringType, value.IntType, value.NumberType:
+			cz.add(v.ToString())
+		default:
+			// we only reason about strings and numbers
+			cz.Opaque = true
+		}
+	}
+}
+
+// CanSkip returns true if the file described by this zone-map cannot
+// contain any row matching the given expression.
+func (m *ZoneMap) CanSkip(n expr.Node) bool {
+	switch n := n.(type) {
+	case *expr.BooleanNode:
+		if n.Negated() {
+			return false
+		}
+		switch n.Operator.T {
+		case lex.TokenLogicAnd, lex.TokenAnd:
+			for _, arg := range n.Args {
+				if m.CanSkip(arg) {
+					return true
+				}
+			}
+		case lex.TokenLogicOr, lex.TokenOr:
+			for _, arg := range n.Args {
+				if !m.CanSkip(arg) {
+					return false
+				}
+			}
+			return len(n.Args) > 0
+		}
+	case *expr.BinaryNode:
+		if len(n.Args) != 2 {
+			return false
+		}
+		switch n.Operator.T {
+		case lex.TokenLogicAnd, lex.TokenAnd:
+			return m.CanSkip(n.Args[0]) || m.CanSkip(n.Args[1])
+		case lex.TokenLogicOr, lex.TokenOr:
+			return m.CanSkip(n.Args[0]) && m.CanSkip(n.Args[1])
+		case lex.TokenIN:
+			cz, ok := m.column(n.Args[0])
+			arr, isArr := n.Args[1].(*expr.ArrayNode)
+			if !ok || !isArr {
+				return false
+			}
+			for _, arg := range arr.Args {
+				if !cz.canSkip(lex.TokenEqual, arg) {
+					return false
+				}
+			}
+			return true
+		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+			if cz, ok := m.column(n.Args[0]); ok {
+				return cz.canSkip(n.Operator.T, n.Args[1])
+			}
+			if cz, ok := m.column(n.Args[1]); ok {
+				return cz.canSkip(flipOperator(n.Operator.T), n.Args[0])
+			}
+		}
+	case *expr.TriNode:
+		if n.Negated() || n.Operator.T != lex.TokenBetween || len(n.Args) != 3 {
+			return false
+		}
+		if cz, ok := m.column(n.Args[0]); ok {
+			return cz.canSkip(lex.TokenGE, n.Args[1]) || cz.canSkip(lex.TokenLE, n.Args[2])
+		}
+	}
+	return false
+}
+
+// column find the zone for an identity node, columns never seen in this
+// file (possibly not even in it) are not used to skip.
+func (m *ZoneMap) column(n expr.Node) (*ColumnZone, bool) {
+	in, ok := n.(*expr.IdentityNode)
+	if !ok {
+		return nil, false
+	}
+	cz, ok := m.Columns[strings.ToLower(in.Text)]
+	if !ok {
+		_, col, _ := in.LeftRight()
+		cz, ok = m.Columns[strings.ToLower(col)]
+	}
+	if !ok || cz.Opaque {
+		return nil, false
+	}
+	return cz, true
+}
+
+func (m *ColumnZone) add(s string) {
+	if m.Count == 0 || s < m.MinStr {
+		m.MinStr = s
+	}
+	if m.Count == 0 || s > m.MaxStr {
+		m.MaxStr = s
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		m.Numeric = false
+	} else if m.Numeric {
+		if m.Count == 0 || f < m.MinNum {
+			m.MinNum = f
+		}
+		if m.Count == 0 || f > m.MaxNum {
+			m.MaxNum = f
+		}
+	}
+	if m.Bloom != nil {
+		m.Bloom.Add(bloomKey(s))
+	}
+	m.Count++
+}
+
+// canSkip evaluate (column @op literal) against this zone, the literal
+// must be a string or number for us to reason about it.
+func (m *ColumnZone) canSkip(op lex.TokenType, n expr.Node) bool {
+
+	var s string
+	isNum := false
+	var f float64
+	switch n := n.(type) {
+	case *expr.StringNode:
+		s = n.Text
+	case *expr.NumberNode:
+		s, f, isNum = n.Text, n.Float64, true
+	default:
+		return false
+	}
+	if m.Count == 0 {
+		// bloom column that was never seen, may not be in this file
+		return false
+	}
+
+	if op == lex.TokenEqual || op == lex.TokenEqualEqual {
+		if m.Bloom != nil && !m.Bloom.Has(bloomKey(s)) {
+			return true
+		}
+		if m.Numeric {
+			if nf, err := strconv.ParseFloat(s, 64); err == nil {
+				return nf < m.MinNum || nf > m.MaxNum
+			}
+		}
+		if isNum {
+			return false
+		}
+		return s < m.MinStr || s > m.MaxStr
+	}
+
+	// range comparisons only when literal and column types agree
+	switch {
+	case isNum && m.Numeric:
+		switch op {
+		case lex.TokenGT:
+			return m.MaxNum <= f
+		case lex.TokenGE:
+			return m.MaxNum < f
+		case lex.TokenLT:
+			return m.MinNum >= f
+		case lex.TokenLE:
+			return m.MinNum > f
+		}
+	case !isNum && !m.Numeric:
+		switch op {
+		case lex.TokenGT:
+			return m.MaxStr <= s
+		case lex.TokenGE:
+			return m.MaxStr < s
+		case lex.TokenLT:
+			return m.MinStr >= s
+		case lex.TokenLE:
+			return m.MinStr > s
+		}
+	}
+	return false
+}
+
+func flipOperator(op lex.TokenType) lex.TokenType {
+	switch op {
+	case lex.TokenGT:
+		return lex.TokenLT
+	case lex.TokenGE:
+		return lex.TokenLE
+	case lex.TokenLT:
+		return lex.TokenGT
+	case lex.TokenLE:
+		return lex.TokenGE
+	}
+	return op
+}
+
+// bloomKey normalize numbers so "5", "5.0" share a key.
+func bloomKey(s string) string {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return s
+}
+
+func isZoneMapFile(name string) bool {
+	return strings.HasSuffix(name, ZoneMapSuffix)
+}
+
+// zoneMap get the zone-map for given file from cache, or store if it
+// was persisted on a previous scan.  Stale zone-maps (file has been
+// updated since) are ignored.
+func (m *FileSource) zoneMap(fi *FileInfo) *ZoneMap {
+
+	m.zmu.Lock()
+	zm, ok := m.zoneMaps[fi.Name]
+	m.zmu.Unlock()
+	metrics.CacheAccess("zonemap", ok)
+	if !ok {
+		zm = m.loadZoneMap(fi.Name)
+		m.zmu.Lock()
+		m.zoneMaps[fi.Name] = zm
+		m.zmu.Unlock()
+	}
+	if zm == nil || !zm.Updated.Equal(fi.updated()) {
+		return nil
+	}
+	return zm
+}
+
+func (m *FileSource) loadZoneMap(name string) *ZoneMap {
+	rc, err := m.store.NewReaderWithContext(context.Background(), name+ZoneMapSuffix)
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	// column min/max and bloom filters are encrypted like other artifacts
+	r, err := datasource.NewArtifactReader(rc)
+	if err != nil {
+		u.Warnf("could not read zonemap for %q err=%v", name, err)
+		return nil
+	}
+	zm := &ZoneMap{}
+	if err = json.NewDecoder(r).Decode(zm); err != nil {
+		u.Warnf("could not read zonemap for %q err=%v", name, err)
+		return nil
+	}
+	return zm
+}
+
+// saveZoneMap cache the zone-map and persist it alongside the file if
+// the store is writeable.
+func (m *FileSource) saveZoneMap(zm *ZoneMap) {
+
+	m.zmu.Lock()
+	m.zoneMaps[zm.File] = zm
+	m.zmu.Unlock()
+
+	w, ok := m.store.(zoneMapWriter)
+	if !ok {
+		return
+	}
+	wc, err := w.NewWriter(zm.File+ZoneMapSuffix, nil)
+	if err != nil {
+		u.Warnf("could not persist zonemap for %q err=%v", zm.File, err)
+		return
+	}
+	aw, err := datasource.NewArtifactWriter(wc)
+	if err != nil {
+		wc.Close()
+		u.Warnf("could not persist zonemap for %q err=%v", zm.File, err)
+		return
+	}
+	if err = json.NewEncoder(aw).Encode(zm); err != nil {
+		u.Warnf("could not persist zonemap for %q err=%v", zm.File, err)
+	}
+	if err = aw.Close(); err != nil {
+		u.Warnf("could not persist zonemap for %q err=%v", zm.File, err)
+	}
+	if err = wc.Close(); err != nil {
+		u.Warnf("could not persist zonemap for %q err=%v", zm.File, err)
+	}
+}
@@ -0,0 +1,133 @@
+package files_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	u "github.com/araddon/gou"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/files"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+func TestZoneMap(t *testing.T) {
+
+	zm := files.NewZoneMap("baseball/appearances/appearances.csv", time.Now(), []string{"playerid"})
+	for i, player := range []string{"barnero01", "barrofr01", "birdsda01"} {
+		zm.Add(map[string]value.Value{
+			"playerid": value.NewStringValue(player),
+			"yearid":   value.NewStringValue(fmt.Sprintf("%d", 1871+i)),
+			"teamid":   value.NewStringValue("BS1"),
+			"created":  value.NewTimeValue(time.Now()),
+		})
+	}
+	assert.Equal(t, int64(3), zm.Rows)
+
+	by, err := json.Marshal(zm)
+	assert.Equal(t, nil, err)
+	zm2 := &files.ZoneMap{}
+	assert.Equal(t, nil, json.Unmarshal(by, zm2))
+
+	tests := []struct {
+		where string
+		skip  bool
+	}{
+		{`yearid = "1871"`, false},
+		{`yearid = 1873`, false},
+		{`yearid = 1880`, true},
+		{`yearid > 1873`, true},
+		{`1873 < yearid`, true},
+		{`yearid >= 1873`, false},
+		{`yearid < 1871`, true},
+		{`yearid BETWEEN 1860 AND 1870`, true},
+		{`yearid BETWEEN 1860 AND 1871`, false},
+		{`yearid IN (1850, 1900)`, true},
+		{`yearid IN (1850, 1872)`, false},
+		{`teamid = "BS1"`, false},
+		{`teamid > "BS1"`, true},
+		{`teamid = "CH1"`, true},
+		{`playerid = "barnero01"`, false},
+		{`playerid = "zzzzzzz01"`, true},
+		// only bloom filter can skip, inside min/max
+		{`playerid = "barrxxx01"`, true},
+		{`playerid = "barnero01" AND yearid = 1900`, true},
+		{`playerid = "barnero01" OR yearid = 1900`, false},
+		{`teamid = "CH1" OR yearid = 1900`, true},
+		{`NOT (yearid = 1880)`, false},
+		{`created = "2017-01-01"`, false},
+		{`missing = "abc"`, false},
+		{`a.yearid = 1880`, true},
+	}
+	for _, tc := range tests {
+		n := expr.MustParse(tc.where)
+		assert.Equal(t, tc.skip, zm.CanSkip(n), tc.where)
+		assert.Equal(t, tc.skip, zm2.CanSkip(n), tc.where)
+	}
+}
+
+func TestZoneMapPartialScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zonemap")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	writeFile := func(name, data string) string {
+		fn := filepath.Join(dir, name)
+		assert.Equal(t, nil, os.MkdirAll(filepath.Dir(fn), 0755))
+		assert.Equal(t, nil, ioutil.WriteFile(fn, []byte(data), 0644))
+		return fn + files.ZoneMapSuffix
+	}
+	open := func(path, format, table string) schema.ConnScanner {
+		fs := files.NewFileSource()
+		ss := schema.NewSchema("zmtest")
+		ss.Conf = &schema.ConfigSource{
+			Name: "zmtest",
+			Settings: u.JsonHelper{
+				"path":      path,
+				"format":    format,
+				"type":      "localfs",
+				"localpath": dir,
+				"zonemaps":  true,
+			},
+		}
+		assert.Equal(t, nil, fs.Setup(ss))
+		conn, err := fs.Open(table)
+		assert.Equal(t, nil, err)
+		return conn.(schema.ConnScanner)
+	}
+	noZoneMap := func(sidecar string) {
+		_, err := os.Stat(sidecar)
+		assert.True(t, os.IsNotExist(err), "no zonemap for a partial scan: %v", err)
+	}
+
+	// closed mid-file, the zone has seen only some of the rows
+	sidecar := writeFile("zm/users/users.csv", "user_id,name\n1,bob\n2,alice\n3,eve\n4,mary\n")
+	conn := open("zm", "csv", "users")
+	assert.NotEqual(t, nil, conn.Next())
+	assert.Equal(t, nil, conn.Close())
+	assert.Equal(t, nil, conn.Next())
+	noZoneMap(sidecar)
+
+	// a read error ends the scan part way
+	jsonSidecar := writeFile("zmjson/events/events.json", "{\"id\":1}\nnot json\n{\"id\":3}\n")
+	conn = open("zmjson", "json", "events")
+	assert.NotEqual(t, nil, conn.Next())
+	assert.Equal(t, nil, conn.Next())
+	noZoneMap(jsonSidecar)
+
+	// read to EOF it is saved
+	conn = open("zm", "csv", "users")
+	rows := 0
+	for conn.Next() != nil {
+		rows++
+	}
+	assert.Equal(t, 4, rows)
+	_, err = os.Stat(sidecar)
+	assert.Equal(t, nil, err)
+}
@@ -0,0 +1,339 @@
+package datasource
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	_ schema.Source          = (*FlattenSource)(nil)
+	_ schema.SourceHierarchy = (*FlattenSource)(nil)
+	_ schema.ConnScanner     = (*flattenConn)(nil)
+	_ schema.ConnColumns     = (*flattenConn)(nil)
+)
+
+const (
+	// FlattenKeyColumn the column of a flattened view uniquely identifying
+	// each of its rows, the key its own nested arrays join on.
+	FlattenKeyColumn = "_key"
+	// FlattenOrdinalColumn the column of a flattened view with the position
+	// of the row in its parents array.
+	FlattenOrdinalColumn = "_ord"
+	// FlattenValueColumn the column of a flattened view of an array of
+	// scalars (tags: ["a","b"]) holding the value.
+	FlattenValueColumn = "value"
+)
+
+// FlattenSource wraps a document source whose rows have nested arrays,
+// and exposes each nested array as a child "view" table of its parent, so
+// relational tools can query nested data with a plain join instead of
+// UNNEST.  Given an orders table of documents
+//
+//     {"id": 1, "items": [{"sku": "a", "qty": 2}, {"sku": "b", "qty": 1}]}
+//
+// the schema gets an orders_items table with columns
+//
+//     orders_id, _key, _ord, qty, sku
+//
+// which is a child of orders joined ON orders.id = orders_items.orders_id,
+// arrays nested in the items are in turn views of orders_items, joined on
+// its _key.  Views are discovered by sampling IntrospectCount rows.
+type FlattenSource struct {
+	schema.Source
+	mu       sync.Mutex
+	keys     map[string]string       // table -> key field, if not discovered
+	views    map[string]*flattenView // view name -> view
+	children map[string][]string     // table -> names of its views
+}
+
+// flattenView an array field of a parent table, flattened to rows.
+type flattenView struct {
+	name      string
+	parent    string
+	field     string // array field of parent
+	parentKey string // field of parent identifying its rows
+	childKey  string // column of this view holding the parentKey value
+	cols      []string
+	scalar    bool // array of scalars, not objects
+	tbl       *schema.Table
+}
+
+// NewFlattenSource wrap src to flatten the nested arrays of its tables.
+func NewFlattenSource(src schema.Source) *FlattenSource {
+	return &FlattenSource{
+		Source:   src,
+		keys:     make(map[string]string),
+		views:    make(map[string]*flattenView),
+		children: make(map[string][]string),
+	}
+}
+
+// SetKey the field identifying rows of table its views join on, instead
+// of the primary index, or id, _id field.
+func (m *FlattenSource) SetKey(table, field string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[strings.ToLower(table)] = field
+}
+
+// Table the schema of a table of the underlying source, or flattened view.
+func (m *FlattenSource) Table(table string) (*schema.Table, error) {
+	table = strings.ToLower(table)
+	m.mu.Lock()
+	v, ok := m.views[table]
+	m.mu.Unlock()
+	if !ok {
+		return m.Source.Table(table)
+	}
+	if v.tbl != nil {
+		return v.tbl, nil
+	}
+	tbl := schema.NewTable(v.name)
+	tbl.SetColumns(v.cols)
+	conn, err := m.Open(v.name)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = IntrospectTable(tbl, conn.(schema.Iterator)); err != nil {
+		return nil, err
+	}
+	tbl.SetParent(v.parent, v.parentKey, v.childKey)
+	m.mu.Lock()
+	v.tbl = tbl
+	m.mu.Unlock()
+	return tbl, nil
+}
+
+// Open a conn to a table of the underlying source, or flattened view.
+func (m *FlattenSource) Open(table string) (schema.Conn, error) {
+	m.mu.Lock()
+	v, ok := m.views[strings.ToLower(table)]
+	m.mu.Unlock()
+	if !ok {
+		return m.Source.Open(table)
+	}
+	conn, err := m.Open(v.parent)
+	if err != nil {
+		return nil, err
+	}
+	iter, ok := conn.(schema.Iterator)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("can not flatten %q, %T is not a scanner", v.parent, conn)
+	}
+	return &flattenConn{v: v, conn: conn, iter: iter, colIndex: colIndex(v.cols)}, nil
+}
+
+// ChildTables the flattened views of the nested arrays of table.
+func (m *FlattenSource) ChildTables(table string) []string {
+	table = strings.ToLower(table)
+	m.mu.Lock()
+	children, ok := m.children[table]
+	m.mu.Unlock()
+	if ok {
+		return children
+	}
+	views, err := m.discover(table)
+	if err != nil {
+		u.Warnf("could not discover nested arrays of %q err=%v", table, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	children = make([]string, 0, len(views))
+	for _, v := range views {
+		if _, exists := m.views[v.name]; !exists {
+			m.views[v.name] = v
+		}
+		children = append(children, v.name)
+	}
+	m.children[table] = children
+	return children
+}
+
+// discover the array fields of a sample of the rows of table.
+func (m *FlattenSource) discover(table string) ([]*flattenView, error) {
+	conn, err := m.Open(table)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	iter, ok := conn.(schema.Iterator)
+	if !ok {
+		return nil, nil
+	}
+
+	arrays := make(map[string]*flattenView)
+	fields := make(map[string]map[string]bool)
+	var keyField string
+	for ct := 0; ct < IntrospectCount; ct++ {
+		msg := iter.Next()
+		if msg == nil {
+			break
+		}
+		mm, ok := msg.Body().(*SqlDriverMessageMap)
+		if !ok {
+			continue
+		}
+		if keyField == "" {
+			keyField = m.keyField(table, mm)
+		}
+		for col, i := range mm.ColIndex {
+			if i >= len(mm.Vals) {
+				continue
+			}
+			items, ok := mm.Vals[i].([]interface{})
+			if !ok || len(items) == 0 {
+				continue
+			}
+			v, exists := arrays[col]
+			if !exists {
+				v = &flattenView{parent: table, field: col, scalar: true}
+				arrays[col] = v
+				fields[col] = make(map[string]bool)
+			}
+			for _, item := range items {
+				if obj, ok := item.(map[string]interface{}); ok {
+					v.scalar = false
+					for k := range obj {
+						fields[col][strings.ToLower(k)] = true
+					}
+				}
+			}
+		}
+	}
+	if keyField == "" || len(arrays) == 0 {
+		return nil, nil
+	}
+
+	childKey := fmt.Sprintf("%s_%s", table, strings.TrimLeft(keyField, "_"))
+	views := make([]*flattenView, 0, len(arrays))
+	for col, v := range arrays {
+		v.name = fmt.Sprintf("%s_%s", table, strings.ToLower(col))
+		v.parentKey = keyField
+		v.childKey = childKey
+		v.cols = []string{childKey, FlattenKeyColumn, FlattenOrdinalColumn}
+		if v.scalar {
+			v.cols = append(v.cols, FlattenValueColumn)
+		} else {
+			names := make([]string, 0, len(fields[col]))
+			for name := range fields[col] {
+				if name != childKey && name != FlattenKeyColumn && name != FlattenOrdinalColumn {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			v.cols = append(v.cols, names...)
+		}
+		views = append(views, v)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].name < views[j].name })
+	return views, nil
+}
+
+// keyField the field identifying rows of table, flattened views key is
+// _key, tables the SetKey field, the primary index, or an id field.
+func (m *FlattenSource) keyField(table string, mm *SqlDriverMessageMap) string {
+	m.mu.Lock()
+	_, isView := m.views[table]
+	key := m.keys[table]
+	m.mu.Unlock()
+	switch {
+	case isView:
+		return FlattenKeyColumn
+	case key != "":
+		return key
+	}
+	if tbl, err := m.Source.Table(table); err == nil && tbl != nil {
+		for _, idx := range tbl.Indexes {
+			if idx.PrimaryKey && len(idx.Fields) == 1 {
+				return idx.Fields[0]
+			}
+		}
+	}
+	for _, name := range []string{"id", "_id"} {
+		if _, ok := mm.ColIndex[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// flattenConn scans the parent table of a view, emitting a row per item
+// of the array field of each parent row.
+type flattenConn struct {
+	v        *flattenView
+	conn     schema.Conn
+	iter     schema.Iterator
+	colIndex map[string]int
+	rows     []*SqlDriverMessageMap
+	rowct    uint64
+}
+
+func (m *flattenConn) Columns() []string { return m.v.cols }
+func (m *flattenConn) Close() error      { return m.conn.Close() }
+func (m *flattenConn) Next() schema.Message {
+	for len(m.rows) == 0 {
+		msg := m.iter.Next()
+		if msg == nil {
+			return nil
+		}
+		if mm, ok := msg.Body().(*SqlDriverMessageMap); ok {
+			m.flatten(mm)
+		}
+	}
+	row := m.rows[0]
+	m.rows = m.rows[1:]
+	return row
+}
+
+// flatten the array of parent row mm into rows.
+func (m *flattenConn) flatten(mm *SqlDriverMessageMap) {
+	ai, ok := mm.ColIndex[m.v.field]
+	if !ok || ai >= len(mm.Vals) {
+		return
+	}
+	items, ok := mm.Vals[ai].([]interface{})
+	if !ok {
+		return
+	}
+	var key driver.Value
+	if ki, ok := mm.ColIndex[m.v.parentKey]; ok && ki < len(mm.Vals) {
+		key = mm.Vals[ki]
+	}
+	for ord, item := range items {
+		vals := make([]driver.Value, len(m.v.cols))
+		vals[0] = key
+		vals[1] = fmt.Sprintf("%v#%d", key, ord)
+		vals[2] = int64(ord)
+		switch it := item.(type) {
+		case map[string]interface{}:
+			for k, iv := range it {
+				if i, ok := m.colIndex[strings.ToLower(k)]; ok && i > 2 {
+					vals[i] = iv
+				}
+			}
+		default:
+			if m.v.scalar {
+				vals[3] = it
+			}
+		}
+		m.rowct++
+		m.rows = append(m.rows, NewSqlDriverMessageMap(m.rowct, vals, m.colIndex))
+	}
+}
+
+func colIndex(cols []string) map[string]int {
+	idx := make(map[string]int, len(cols))
+	for i, col := range cols {
+		idx[col] = i
+	}
+	return idx
+}
@@ -0,0 +1,89 @@
+package datasource_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestFlattenSource(t *testing.T) {
+	items := func(vals ...map[string]interface{}) []interface{} {
+		list := make([]interface{}, len(vals))
+		for i, v := range vals {
+			list[i] = v
+		}
+		return list
+	}
+	db, err := memdb.NewMemDbData("orders", [][]driver.Value{
+		{int64(1), "bob", items(
+			map[string]interface{}{"sku": "a", "qty": int64(2), "options": []interface{}{map[string]interface{}{"color": "red"}}},
+			map[string]interface{}{"sku": "b", "qty": int64(1)},
+		), []interface{}{"gift", "rush"}},
+		{int64(2), "alice", items(map[string]interface{}{"sku": "c", "qty": int64(5)}), []interface{}{}},
+	}, []string{"id", "customer", "items", "tags"})
+	assert.Equal(t, nil, err)
+
+	a := schema.NewApplyer(func(s *schema.Schema) schema.Source {
+		sdb := datasource.NewSchemaDb(s)
+		s.InfoSchema.DS = sdb
+		return sdb
+	})
+	reg := schema.NewRegistry(a)
+	a.Init(reg)
+	s := schema.NewSchemaSource("flatten_test", datasource.NewFlattenSource(db))
+	assert.Equal(t, nil, reg.SchemaAdd(s))
+	assert.Equal(t, []string{"orders", "orders_items", "orders_items_options", "orders_tags"}, s.Tables())
+
+	tbl, err := s.Table("orders_items")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"orders_id", "_key", "_ord", "options", "qty", "sku"}, tbl.Columns())
+	assert.Equal(t, "orders", tbl.Parent)
+	parentKey, childKey, ok := tbl.ParentKeys()
+	assert.True(t, ok)
+	assert.Equal(t, "id", parentKey)
+	assert.Equal(t, "orders_id", childKey)
+
+	// nested arrays of views are views, joined on the views _key
+	tbl, err = s.Table("orders_items_options")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"orders_items_key", "_key", "_ord", "color"}, tbl.Columns())
+	parentKey, childKey, _ = tbl.ParentKeys()
+	assert.Equal(t, "_key", parentKey)
+	assert.Equal(t, "orders_items_key", childKey)
+
+	rows := func(table string) [][]driver.Value {
+		conn, err := s.OpenConn(table)
+		assert.Equal(t, nil, err)
+		defer conn.Close()
+		out := make([][]driver.Value, 0)
+		iter := conn.(schema.Iterator)
+		for msg := iter.Next(); msg != nil; msg = iter.Next() {
+			out = append(out, msg.Body().(*datasource.SqlDriverMessageMap).Values())
+		}
+		return out
+	}
+	assert.Equal(t, [][]driver.Value{
+		{int64(1), "1#0", int64(0), nil, int64(2), "a"},
+		{int64(1), "1#1", int64(1), nil, int64(1), "b"},
+		{int64(2), "2#0", int64(0), nil, int64(5), "c"},
+	}, blankOptions(rows("orders_items")))
+	assert.Equal(t, [][]driver.Value{{"1#0", "1#0#0", int64(0), "red"}}, rows("orders_items_options"))
+	assert.Equal(t, [][]driver.Value{
+		{int64(1), "1#0", int64(0), "gift"},
+		{int64(1), "1#1", int64(1), "rush"},
+	}, rows("orders_tags"))
+}
+
+// blankOptions the nested options array of orders_items rows is itself
+// a view, not compared.
+func blankOptions(rows [][]driver.Value) [][]driver.Value {
+	for _, row := range rows {
+		row[3] = nil
+	}
+	return rows
+}
@@ -51,7 +51,7 @@ func IntrospectTable(tbl *schema.Table, iter schema.Iterator) error {
 			for i, v := range mt {
 
 				k := nameIndex[i]
-				_, exists := tbl.FieldMap[k]
+				exists := tbl.HasField(k)
 
 				//u.Debugf("i:%v k:%s  v: %T %v", i, k, v, v)
 				switch val := v.(type) {
@@ -96,7 +96,7 @@ func IntrospectTable(tbl *schema.Table, iter schema.Iterator) error {
 				// 	}
 				// }
 
-				_, exists := tbl.FieldMap[k]
+				exists := tbl.HasField(k)
 
 				//u.Debugf("i:%v k:%s  v: %T %v", i, k, v, v)
 				switch val := v.(type) {
@@ -135,8 +135,9 @@ func IntrospectTable(tbl *schema.Table, iter schema.Iterator) error {
 		ct++
 	}
 	if needsCols {
-		cols := make([]string, len(tbl.Fields))
-		for i, f := range tbl.Fields {
+		fields := tbl.FieldList()
+		cols := make([]string, len(fields))
+		for i, f := range fields {
 			//u.Debugf("%+v", f)
 			cols[i] = f.Name
 		}
@@ -21,6 +21,7 @@ var (
 	_ schema.Source      = (*JsonSource)(nil)
 	_ schema.Conn        = (*JsonSource)(nil)
 	_ schema.ConnScanner = (*JsonSource)(nil)
+	_ schema.IteratorErr = (*JsonSource)(nil)
 )
 
 type FileLineHandler func(line []byte) (schema.Message, error)
@@ -169,6 +170,9 @@ func (m *JsonSource) Next() schema.Message {
 	}
 }
 
+// Err the read or parse error that ended the scan, if any.
+func (m *JsonSource) Err() error { return m.err }
+
 func (m *JsonSource) jsonDefaultLine(line []byte) (schema.Message, error) {
 	jm := make(map[string]interface{})
 	err := json.Unmarshal(line, &jm)
@@ -25,13 +25,15 @@ const (
 
 var (
 	// Different Features of this Static Data Source
-	_ schema.Source       = (*StaticDataSource)(nil)
-	_ schema.Conn         = (*StaticDataSource)(nil)
-	_ schema.ConnColumns  = (*StaticDataSource)(nil)
-	_ schema.ConnScanner  = (*StaticDataSource)(nil)
-	_ schema.ConnSeeker   = (*StaticDataSource)(nil)
-	_ schema.ConnUpsert   = (*StaticDataSource)(nil)
-	_ schema.ConnDeletion = (*StaticDataSource)(nil)
+	_ schema.Source           = (*StaticDataSource)(nil)
+	_ schema.SourceRowCounter = (*StaticDataSource)(nil)
+	_ schema.Conn             = (*StaticDataSource)(nil)
+	_ schema.ConnColumns      = (*StaticDataSource)(nil)
+	_ schema.ConnScanner      = (*StaticDataSource)(nil)
+	_ schema.ConnSeeker       = (*StaticDataSource)(nil)
+	_ schema.ConnUpsert       = (*StaticDataSource)(nil)
+	_ schema.ConnDeletion     = (*StaticDataSource)(nil)
+	_ schema.ConnResetter     = (*StaticDataSource)(nil)
 )
 
 // Key implements Key and Sort interfaces.
@@ -139,6 +141,9 @@ func NewStaticDataSource(name string, indexedCol int, data [][]driver.Value, col
 	if err := datasource.IntrospectTable(m.tbl, m.CreateIterator()); err != nil {
 		u.Errorf("Could not introspect schema %v", err)
 	}
+	// introspection reads only the first rows, reset so the next scan
+	// starts from the first row
+	m.cursor = nil
 	return &m
 }
 
@@ -162,8 +167,16 @@ func (m *StaticDataSource) CreateIterator() schema.Iterator           { return m
 func (m *StaticDataSource) Tables() []string                          { return []string{m.name} }
 func (m *StaticDataSource) Columns() []string                         { return m.tbl.Columns() }
 func (m *StaticDataSource) Length() int                               { return m.bt.Len() }
+func (m *StaticDataSource) RowCount(string) (int64, bool)             { return int64(m.bt.Len()), true }
 func (m *StaticDataSource) SetColumns(cols []string)                  { m.tbl.SetColumns(cols) }
 
+// Reset the scan cursor so the next scan starts from the first row.
+func (m *StaticDataSource) Reset() error {
+	m.cursor = nil
+	m.max = 0
+	return nil
+}
+
 func (m *StaticDataSource) Next() schema.Message {
 	//u.Infof("Next()")
 	select {
@@ -6,6 +6,9 @@ package memdb
 import (
 	"database/sql/driver"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	u "github.com/araddon/gou"
 	"github.com/hashicorp/go-memdb"
@@ -13,13 +16,22 @@ import (
 
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
 	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
 
 const (
 	sourceType = "memdb"
+
+	// SettingVersionColumn setting (or CREATE TABLE option) of the
+	// optimistic concurrency version column of the table, incremented by
+	// each UPDATE changing a row, see schema.ConnCompareAndSwap.
+	SettingVersionColumn = "version_column"
 )
 
 var (
@@ -33,8 +45,21 @@ var (
 	_ schema.ConnUpsert   = (*dbConn)(nil)
 	_ schema.ConnDeletion = (*dbConn)(nil)
 	_ schema.ConnSeeker   = (*dbConn)(nil)
+
+	_ schema.ConnIndexSeeker    = (*dbConn)(nil)
+	_ schema.ConnKeyFilter      = (*dbConn)(nil)
+	_ schema.ConnCompareAndSwap = (*dbConn)(nil)
+	_ schema.ConnPutBatch       = (*dbConn)(nil)
+	_ schema.ConnResetter       = (*dbConn)(nil)
 )
 
+// MemDb rows are versioned (multi-version concurrency), each write makes
+// a new immutable version of the table and readers keep reading the
+// version (snapshot) they started with, so long running scans see
+// consistent rows without blocking writers.  A conn's scan reads from one
+// snapshot, and once given the statement context (SetContext) all its
+// scans and seeks read the statement's snapshot of the db.
+
 // MemDb implements qlbridge `Source` to allow in-memory native go data
 // to have a Schema and implement and be operated on by Sql Statements.
 type MemDb struct {
@@ -45,12 +70,18 @@ type MemDb struct {
 	primaryIndex   string
 	db             *memdb.MemDB
 	max            int
+	ttlMu          sync.RWMutex
+	ttl            *rowTTL // expiry of rows, nil if they don't expire
 }
 type dbConn struct {
-	md     *MemDb
-	db     *memdb.MemDB
-	txn    *memdb.Txn
-	result memdb.ResultIterator
+	md        *MemDb
+	db        *memdb.MemDB
+	ctx       *plan.Context
+	snap      *snapshot // of the scan, or the statement if ctx
+	result    memdb.ResultIterator
+	scanIndex string // index to scan, from index hints, defaults to primary
+	keyNodes  []expr.Node
+	keyFilter schema.KeyFilter // join key filter, rows not in it are skipped
 }
 
 // NewMemDbData creates a MemDb with given indexes, columns, and values
@@ -100,8 +131,36 @@ func NewMemDbForSchema(name string, cols []string) (*MemDb, error) {
 // Init initilize this db
 func (m *MemDb) Init() {}
 
-// Setup this db with parent schema.
-func (m *MemDb) Setup(*schema.Schema) error { return nil }
+// Setup this db with parent schema, the ttl of its rows and version column
+// from the Settings of the schema source config.
+func (m *MemDb) Setup(s *schema.Schema) error {
+	if s == nil || s.Conf == nil || s.Conf.Settings == nil {
+		return nil
+	}
+	return m.setSettings(s.Conf.Settings.String)
+}
+
+// SetTableOptions the ttl, ttl_column, ttl_sweep, version_column table
+// options of CREATE TABLE.
+func (m *MemDb) SetTableOptions(table string, opts map[string]interface{}) error {
+	return m.setSettings(func(name string) string {
+		if v, ok := opts[name]; ok {
+			return fmt.Sprintf("%v", v)
+		}
+		return ""
+	})
+}
+
+// setSettings set the ttl, version column from settings read by get.
+func (m *MemDb) setSettings(get func(name string) string) error {
+	if col := get(SettingVersionColumn); col != "" {
+		if _, ok := m.tbl.FieldPositions[strings.ToLower(col)]; !ok {
+			return fmt.Errorf("Unknown %s %q in table %q", SettingVersionColumn, col, m.tbl.Name)
+		}
+		m.tbl.VersionColumn = strings.ToLower(col)
+	}
+	return m.setTTLSettings(get)
+}
 
 // Open a Conn for this source @table name
 func (m *MemDb) Open(table string) (schema.Conn, error) { return newDbConn(m), nil }
@@ -143,18 +202,113 @@ func (m *MemDb) buildDefaultIndexes() {
 	}
 }
 
+// AddExprIndex create index name on the value of expression of the
+// columns of this table, existing rows are re-indexed.
+//
+//     db.AddExprIndex("idx_email_lower", "tolower(email)")
+//
+func (m *MemDb) AddExprIndex(name, expression string) error {
+	if m.hasIndex(name) {
+		return fmt.Errorf("Key %q already exists in table %q", name, m.tbl.Name)
+	}
+	n, err := expr.ParseExpression(expression)
+	if err != nil {
+		return err
+	}
+	m.tbl.AddExprIndex(name, n)
+	db, err := memdb.NewMemDB(makeMemDbSchema(m))
+	if err != nil {
+		return err
+	}
+	// copy the rows to the re-indexed db
+	rtxn := m.db.Txn(false)
+	defer rtxn.Abort()
+	iter, err := rtxn.Get(m.tbl.Name, m.primaryIndex)
+	if err != nil {
+		return err
+	}
+	wtxn := db.Txn(true)
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if err = wtxn.Insert(m.tbl.Name, raw); err != nil {
+			wtxn.Abort()
+			return err
+		}
+	}
+	wtxn.Commit()
+	m.db = db
+	return nil
+}
+
 //func (m *MemDb) SetColumns(cols []string)                  { m.tbl.SetColumns(cols) }
 
+func (m *MemDb) hasIndex(name string) bool {
+	for _, idx := range m.indexes {
+		if idx.Name == name {
+			return true
+		}
+	}
+	for _, idx := range m.tbl.ExprIndexes() {
+		if idx.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot a consistent read version of the rows, and the time their
+// expiry is evaluated at.
+type snapshot struct {
+	txn *memdb.Txn
+	at  time.Time
+}
+
+func newSnapshot(db *memdb.MemDB) *snapshot {
+	return &snapshot{txn: db.Txn(false), at: now()}
+}
+
 func newDbConn(mdb *MemDb) *dbConn {
-	c := &dbConn{md: mdb, db: mdb.db}
+	c := &dbConn{md: mdb, db: mdb.db, scanIndex: mdb.primaryIndex}
 	return c
 }
+
+// SetContext read the snapshot of the statement of ctx, shared with the
+// other conns to this db in the statement.
+func (m *dbConn) SetContext(ctx *plan.Context) {
+	if ctx == m.ctx {
+		return
+	}
+	m.ctx = ctx
+	m.snap = nil
+	m.result = nil
+}
+
+// readSnapshot the snapshot of the statement, or a new one if there is no
+// statement context.
+func (m *dbConn) readSnapshot() *snapshot {
+	if m.ctx == nil {
+		return newSnapshot(m.db)
+	}
+	return m.ctx.Snapshot(m.md, func() interface{} { return newSnapshot(m.db) }).(*snapshot)
+}
+
+// Reset clear the scan state, statement snapshot and filters so a pooled
+// conn starts its next query from scratch.
+func (m *dbConn) Reset() error {
+	m.ctx = nil
+	m.snap = nil
+	m.result = nil
+	m.scanIndex = m.md.primaryIndex
+	m.keyNodes = nil
+	m.keyFilter = nil
+	return nil
+}
+
 func (m *dbConn) Columns() []string { return m.md.tbl.Columns() }
 func (m *dbConn) Close() error      { return nil }
 func (m *dbConn) Next() schema.Message {
 
-	if m.txn == nil {
-		m.txn = m.db.Txn(false)
+	if m.snap == nil {
+		m.snap = m.readSnapshot()
 	}
 	select {
 	case <-m.md.exit:
@@ -162,7 +316,7 @@ func (m *dbConn) Next() schema.Message {
 	default:
 		for {
 			if m.result == nil {
-				result, err := m.txn.Get(m.md.tbl.Name, m.md.primaryIndex)
+				result, err := m.snap.txn.Get(m.md.tbl.Name, m.scanIndex)
 				if err != nil {
 					u.Errorf("error %v", err)
 					return nil
@@ -174,7 +328,14 @@ func (m *dbConn) Next() schema.Message {
 				return nil
 			}
 			if msg, ok := raw.(*datasource.SqlDriverMessage); ok {
-				return msg.ToMsgMap(m.md.tbl.FieldPositions)
+				if m.md.expiredAt(msg, m.snap.at) {
+					continue
+				}
+				mm := msg.ToMsgMap(m.md.tbl.FieldPositions)
+				if m.keyFilter != nil && !m.keyMatches(mm) {
+					continue
+				}
+				return mm
 			}
 			u.Warnf("error, not correct type: %#v", raw)
 			return nil
@@ -182,6 +343,45 @@ func (m *dbConn) Next() schema.Message {
 	}
 }
 
+// SetKeyFilter skip the rows of the scan whose join key, the values of
+// nodes, is not in the join key filter f.
+func (m *dbConn) SetKeyFilter(nodes []expr.Node, f schema.KeyFilter) {
+	m.keyNodes = nodes
+	m.keyFilter = f
+}
+
+func (m *dbConn) keyMatches(mm *datasource.SqlDriverMessageMap) bool {
+	vals := make([]string, len(m.keyNodes))
+	for i, node := range m.keyNodes {
+		v, ok := vm.Eval(mm, node)
+		if !ok {
+			// can't tell, let the join decide
+			return true
+		}
+		vals[i] = v.ToString()
+	}
+	return m.keyFilter.Has(strings.Join(vals, string(byte(0))))
+}
+
+// IndexHints validate the USE/FORCE/IGNORE INDEX hints for this table, a
+// single USE or FORCE index is used to scan instead of the primary index.
+func (m *dbConn) IndexHints(hints []*rel.IndexHint) error {
+	for _, hint := range hints {
+		for _, name := range hint.Indexes {
+			if !m.md.hasIndex(name) {
+				return fmt.Errorf("Key %q doesn't exist in table %q", name, m.md.tbl.Name)
+			}
+		}
+		switch hint.Type {
+		case lex.TokenUse, lex.TokenForce:
+			if len(hint.Indexes) == 1 && hint.For == 0 {
+				m.scanIndex = hint.Indexes[0]
+			}
+		}
+	}
+	return nil
+}
+
 // Put interface for allowing this to accept writes via ConnUpsert.Put()
 func (m *dbConn) Put(ctx context.Context, key schema.Key, row interface{}) (schema.Key, error) {
 
@@ -206,10 +406,14 @@ func (m *dbConn) putValues(txn *memdb.Txn, row []driver.Value) (schema.Key, erro
 		return nil, fmt.Errorf("Wrong number of columns, expected %v got %v", len(m.Columns()), len(row))
 	}
 	id := makeId(row[0])
-	msg := &datasource.SqlDriverMessage{Vals: row, IdVal: id}
+	// copy, versions are immutable once written
+	vals := make([]driver.Value, len(row))
+	copy(vals, row)
+	msg := &datasource.SqlDriverMessage{Vals: vals, IdVal: id}
 	if err := txn.Insert(m.md.tbl.Name, msg); err != nil {
 		return nil, err
 	}
+	m.md.rowTTL().stamp(id)
 	return schema.NewKeyUint(id), nil
 }
 
@@ -233,18 +437,170 @@ func (m *dbConn) PutMulti(ctx context.Context, keys []schema.Key, objs interface
 	return nil, fmt.Errorf("unrecognized put object type: %T", objs)
 }
 
-func (m *dbConn) Get(key driver.Value) (schema.Message, error) {
-	txn := m.db.Txn(false)
-	iter, err := txn.Get(m.md.tbl.Name, m.md.primaryIndex, fmt.Sprintf("%v", key))
+// PutBatch write rows in one transaction, a row that can't be written (ie
+// wrong number of columns) is reported failed and the others are written.
+func (m *dbConn) PutBatch(ctx context.Context, rows [][]driver.Value) ([]*schema.PutResult, error) {
+	txn := m.db.Txn(true)
+	results := make([]*schema.PutResult, len(rows))
+	for i, row := range rows {
+		outcome := schema.PutInserted
+		if len(row) > 0 {
+			if existing, err := txn.First(m.md.tbl.Name, m.md.primaryIndex, fmt.Sprintf("%v", row[0])); err == nil && existing != nil {
+				outcome = schema.PutUpdated
+			}
+		}
+		key, err := m.putValues(txn, row)
+		if err != nil {
+			results[i] = &schema.PutResult{Outcome: schema.PutFailed, Err: err}
+			continue
+		}
+		results[i] = &schema.PutResult{Key: key, Outcome: outcome}
+	}
+	txn.Commit()
+	return results, nil
+}
+
+// CompareAndSwap patch the rows matching where (all if nil) atomically
+// with reading them, incrementing the version column of the rows changed.
+func (m *dbConn) CompareAndSwap(ctx context.Context, where expr.Node, patch map[string]driver.Value) (int64, int64, error) {
+	tbl := m.md.tbl
+	patchPos := make(map[int]driver.Value, len(patch))
+	for col, v := range patch {
+		pos, ok := tbl.FieldPositions[strings.ToLower(col)]
+		if !ok {
+			return 0, 0, sqlerr.New(sqlerr.ErBadField, "Unknown column '%s' in 'field list'", col)
+		}
+		patchPos[pos] = v
+	}
+	versionPos := -1
+	if pos, ok := tbl.FieldPositions[tbl.VersionColumn]; ok && tbl.VersionColumn != "" {
+		if _, patched := patchPos[pos]; !patched {
+			versionPos = pos
+		}
+	}
+
+	txn := m.db.Txn(true)
+	var iter memdb.ResultIterator
+	var err error
+	if key := m.primaryKeyOf(where); key != nil {
+		// the where pins the primary key, seek to it instead of a scan
+		iter, err = txn.Get(tbl.Name, m.md.primaryIndex, fmt.Sprintf("%v", key))
+	} else {
+		iter, err = txn.Get(tbl.Name, m.md.primaryIndex)
+	}
 	if err != nil {
 		txn.Abort()
+		return 0, 0, err
+	}
+	at := now()
+	var matched []*datasource.SqlDriverMessage
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		msg, ok := raw.(*datasource.SqlDriverMessage)
+		if !ok || m.md.expiredAt(msg, at) {
+			continue
+		}
+		if where != nil {
+			wv, ok := vm.Eval(msg.ToMsgMap(tbl.FieldPositions), where)
+			if bv, isBool := wv.(value.BoolValue); !ok || !isBool || !bv.Val() {
+				continue
+			}
+		}
+		matched = append(matched, msg)
+	}
+
+	changed := int64(0)
+	for _, msg := range matched {
+		vals := make([]driver.Value, len(msg.Vals))
+		copy(vals, msg.Vals)
+		same := true
+		for pos, v := range patchPos {
+			if !sameValue(vals[pos], v) {
+				same = false
+			}
+			vals[pos] = v
+		}
+		if same {
+			continue
+		}
+		if versionPos >= 0 {
+			version, _ := value.ValueToInt64(value.NewValue(vals[versionPos]))
+			vals[versionPos] = version + 1
+		}
+		if makeId(vals[0]) != msg.IdVal {
+			// the key changed, the row moves
+			if err = txn.Delete(tbl.Name, msg); err != nil {
+				txn.Abort()
+				return 0, 0, err
+			}
+			m.md.rowTTL().forget(msg.IdVal)
+		}
+		if _, err = m.putValues(txn, vals); err != nil {
+			txn.Abort()
+			return 0, 0, err
+		}
+		changed++
+	}
+	txn.Commit()
+	return int64(len(matched)), changed, nil
+}
+
+// primaryKeyOf the value where pins the primary key column to, ie
+// `id = 3` or `id = 3 AND version = 2`, nil if it doesn't.
+func (m *dbConn) primaryKeyOf(where expr.Node) driver.Value {
+	bn, ok := where.(*expr.BinaryNode)
+	if !ok || len(bn.Args) != 2 {
+		return nil
+	}
+	switch bn.Operator.T {
+	case lex.TokenLogicAnd:
+		if key := m.primaryKeyOf(bn.Args[0]); key != nil {
+			return key
+		}
+		return m.primaryKeyOf(bn.Args[1])
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		in, ok := bn.Args[0].(*expr.IdentityNode)
+		if !ok {
+			return nil
+		}
+		if _, col, _ := in.LeftRight(); !strings.EqualFold(col, m.md.tbl.Columns()[0]) {
+			return nil
+		}
+		switch vn := bn.Args[1].(type) {
+		case *expr.StringNode:
+			return vn.Text
+		case *expr.NumberNode:
+			if vn.IsInt {
+				return vn.Int64
+			}
+			return vn.Float64
+		}
+	}
+	return nil
+}
+
+// sameValue are l and r the same type and value, ie int64(1) is not "1".
+func sameValue(l, r driver.Value) bool {
+	lv, rv := value.NewValue(l), value.NewValue(r)
+	if lv.Type() != rv.Type() {
+		return false
+	}
+	eq, err := value.Equal(lv, rv)
+	return err == nil && eq
+}
+
+func (m *dbConn) Get(key driver.Value) (schema.Message, error) {
+	snap := m.readSnapshot()
+	iter, err := snap.txn.Get(m.md.tbl.Name, m.md.primaryIndex, fmt.Sprintf("%v", key))
+	if err != nil {
 		u.Errorf("error reading %v because %v", key, err)
 		return nil, err
 	}
-	txn.Commit() // noop
 
 	if item := iter.Next(); item != nil {
-		if msg, ok := item.(schema.Message); ok {
+		if msg, ok := item.(*datasource.SqlDriverMessage); ok {
+			if m.md.expiredAt(msg, snap.at) {
+				return nil, schema.ErrNotFound
+			}
 			return msg, nil
 		}
 		u.Warnf("unexpected type %T", item)
@@ -252,6 +608,23 @@ func (m *dbConn) Get(key driver.Value) (schema.Message, error) {
 	return nil, schema.ErrNotFound // Should not found be an error?
 }
 
+// GetIndex the rows whose value of index (primary, or an expression
+// index) is key.
+func (m *dbConn) GetIndex(index string, key driver.Value) ([]schema.Message, error) {
+	snap := m.readSnapshot()
+	iter, err := snap.txn.Get(m.md.tbl.Name, index, fmt.Sprintf("%v", key))
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]schema.Message, 0)
+	for item := iter.Next(); item != nil; item = iter.Next() {
+		if msg, ok := item.(*datasource.SqlDriverMessage); ok && !m.md.expiredAt(msg, snap.at) {
+			msgs = append(msgs, msg.ToMsgMap(m.md.tbl.FieldPositions))
+		}
+	}
+	return msgs, nil
+}
+
 // Interface for Deletion
 func (m *dbConn) Delete(key driver.Value) (int, error) {
 	txn := m.db.Txn(true)
@@ -261,6 +634,7 @@ func (m *dbConn) Delete(key driver.Value) (int, error) {
 		u.Warnf("could not delete: %v  err=%v", key, err)
 		return 0, err
 	}
+	m.md.rowTTL().forget(makeId(key))
 	txn.Commit()
 	return 1, nil
 }
@@ -303,6 +677,7 @@ deleteLoop:
 					u.Errorf("could not delete %v", err)
 					break deleteLoop
 				}
+				m.md.rowTTL().forget(msg.IdVal)
 				indexVal := msg.Vals[0]
 				deletedKeys = append(deletedKeys, schema.NewKeyUint(makeId(indexVal)))
 			}
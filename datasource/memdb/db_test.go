@@ -2,6 +2,10 @@ package memdb
 
 import (
 	"database/sql/driver"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,6 +14,9 @@ import (
 
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
 )
@@ -111,3 +118,288 @@ func TestMemDb(t *testing.T) {
 	}
 	assert.Equal(t, 0, ct)
 }
+
+func TestMemDbIndexHints(t *testing.T) {
+
+	cols := []string{"user_id", "name"}
+	rows := [][]driver.Value{{1, "bob"}, {2, "aaron"}}
+	db, err := NewMemDbData("users", rows, cols)
+	assert.Equal(t, nil, err)
+
+	c, err := db.Open("users")
+	assert.Equal(t, nil, err)
+	dc := c.(*dbConn)
+
+	err = dc.IndexHints([]*rel.IndexHint{rel.NewIndexHint(lex.TokenUse, "id")})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "id", dc.scanIndex)
+
+	err = dc.IndexHints([]*rel.IndexHint{rel.NewIndexHint(lex.TokenForce, "idx_missing")})
+	assert.NotEqual(t, nil, err)
+
+	ct := 0
+	for msg := dc.Next(); msg != nil; msg = dc.Next() {
+		ct++
+	}
+	assert.Equal(t, 2, ct)
+}
+
+func TestMemDbExprIndex(t *testing.T) {
+
+	cols := []string{"user_id", "email"}
+	rows := [][]driver.Value{{1, "Bob@Email.com"}, {2, "aaron@email.com"}, {3, "BOB@email.com"}, {4, nil}}
+	db, err := NewMemDbData("users", rows, cols)
+	assert.Equal(t, nil, err)
+
+	err = db.AddExprIndex("idx_email_lower", "tolower(email)")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, db.AddExprIndex("idx_email_lower", "tolower(email)"))
+	assert.NotEqual(t, nil, db.tbl.ExprIndex(expr.MustParse("ToLower(email)")))
+
+	c, err := db.Open("users")
+	assert.Equal(t, nil, err)
+	dc := c.(*dbConn)
+
+	msgs, err := dc.GetIndex("idx_email_lower", "bob@email.com")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(msgs))
+
+	// rows written after the index was created are indexed
+	dc.Put(nil, nil, []driver.Value{5, "Aaron@Email.com"})
+	msgs, err = dc.GetIndex("idx_email_lower", "aaron@email.com")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(msgs))
+
+	msgs, err = dc.GetIndex("idx_email_lower", "nobody@email.com")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(msgs))
+}
+
+func TestMemDbSnapshot(t *testing.T) {
+	defer datasource.SetArtifactKeys(nil)
+	datasource.SetArtifactKeys(datasource.NewStaticKey([]byte("0123456789abcdef")))
+
+	created := dateparse.MustParse("2015/07/04").In(time.UTC)
+	cols := []string{"user_id", "name", "created"}
+	db, err := NewMemDbData("users", [][]driver.Value{
+		{int64(1), "bob", created},
+		{int64(2), "aaron", created},
+	}, cols)
+	assert.Equal(t, nil, err)
+
+	path := filepath.Join(os.TempDir(), "qlb_memdb_snapshot")
+	defer os.Remove(path)
+	assert.Equal(t, nil, db.SnapshotFile(path))
+	raw, _ := ioutil.ReadFile(path)
+	assert.False(t, strings.Contains(string(raw), "aaron"))
+
+	db2, err := NewMemDb("users", cols)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, db2.RestoreFile(path))
+	c, _ := db2.Open("users")
+	row, err := c.(schema.ConnSeeker).Get(int64(2))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []driver.Value{int64(2), "aaron", created}, row.Body().([]driver.Value))
+
+	db3, _ := NewMemDb("users", []string{"user_id", "email"})
+	assert.NotEqual(t, nil, db3.RestoreFile(path))
+}
+
+func TestFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qlbridge_fixtures")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	users := filepath.Join(dir, "fx_users.csv")
+	assert.Equal(t, nil, ioutil.WriteFile(users, []byte("user_id,name,score,admin\n1,bob,2.5,true\n2,alice,,false\n"), 0644))
+	orders := filepath.Join(dir, "orders.yaml")
+	assert.Equal(t, nil, ioutil.WriteFile(orders, []byte(`
+fx_orders:
+  - {order_id: 10, user_id: 1, item: hat}
+  - {order_id: 11, user_id: 1, tags: [red, large]}
+  - {order_id: 12, user_id: 2, item: shoe}
+`), 0644))
+
+	sch, err := LoadFixtures("fixtures_test", users, orders)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"fx_orders", "fx_users"}, sch.Tables())
+
+	tbl, err := sch.Table("fx_orders")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"order_id", "user_id", "item", "tags"}, tbl.Columns())
+
+	testutil.TestSqlSelect(t, "fixtures_test", `SELECT name, score, admin FROM fx_users WHERE user_id = 1`,
+		[][]driver.Value{{"bob", 2.5, true}})
+	testutil.TestSqlSelect(t, "fixtures_test", `SELECT order_id, tags FROM fx_orders WHERE user_id = 1 ORDER BY order_id ASC`,
+		[][]driver.Value{{int64(10), nil}, {int64(11), `["red","large"]`}})
+
+	// loading again replaces the schema
+	sch, err = LoadFixtures("fixtures_test", users)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"fx_users"}, sch.Tables())
+
+	_, err = NewFixtures(filepath.Join(dir, "missing.csv"))
+	assert.NotEqual(t, nil, err)
+	_, err = NewFixtures(filepath.Join(dir, "users.txt"))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTTL(t *testing.T) {
+	clock := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	db, err := NewMemDbData("sessions", [][]driver.Value{{1, "bob"}}, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	assert.Equal(t, nil, db.SetTTL(time.Minute, "", time.Hour))
+
+	c, _ := db.Open("sessions")
+	conn := c.(schema.ConnAll)
+	clock = clock.Add(30 * time.Second)
+	conn.Put(nil, nil, []driver.Value{2, "sue"})
+	count := func() int {
+		c, _ := db.Open("sessions")
+		n := 0
+		for msg := c.(schema.ConnScanner).Next(); msg != nil; msg = c.(schema.ConnScanner).Next() {
+			n++
+		}
+		return n
+	}
+	assert.Equal(t, 2, count())
+
+	// bob expires, sue was written later
+	clock = clock.Add(40 * time.Second)
+	assert.Equal(t, 1, count())
+	_, err = conn.Get(1)
+	assert.Equal(t, schema.ErrNotFound, err)
+	_, err = conn.Get(2)
+	assert.Equal(t, nil, err)
+
+	// re-writing sue keeps it alive
+	conn.Put(nil, nil, []driver.Value{2, "sue"})
+	clock = clock.Add(40 * time.Second)
+	assert.Equal(t, 1, count())
+	n, err := db.Sweep()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, len(db.ttl.written))
+
+	// expire by the time in a column, configured by the source settings
+	last := clock.Add(-90 * time.Second)
+	db2, err := NewMemDbData("logins", [][]driver.Value{{1, last}, {2, clock}, {3, nil}}, []string{"id", "last_seen"})
+	assert.Equal(t, nil, err)
+	defer db2.Close()
+	s := schema.NewSchemaSource("logins", db2)
+	s.Conf = &schema.ConfigSource{Settings: map[string]interface{}{"ttl": "1m", "ttl_column": "last_seen", "ttl_sweep": 3600}}
+	assert.Equal(t, nil, db2.Setup(s))
+	n, err = db2.Sweep()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, n)
+	c2, _ := db2.Open("logins")
+	_, err = c2.(schema.ConnSeeker).Get(2)
+	assert.Equal(t, nil, err)
+
+	assert.NotEqual(t, nil, db2.SetTableOptions("logins", map[string]interface{}{"ttl": "1m", "ttl_column": "nope"}))
+	assert.NotEqual(t, nil, db2.SetTableOptions("logins", map[string]interface{}{"ttl": "-1m"}))
+	assert.Equal(t, nil, db2.SetTTL(0, "", 0))
+	assert.True(t, db2.rowTTL() == nil)
+}
+
+func TestSnapshotReads(t *testing.T) {
+	db, err := NewMemDbData("accounts", [][]driver.Value{{1, 10}, {2, 20}, {3, 30}}, []string{"id", "balance"})
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	scanAll := func(c schema.Conn) []driver.Value {
+		var balances []driver.Value
+		scanner := c.(schema.ConnScanner)
+		for msg := scanner.Next(); msg != nil; msg = scanner.Next() {
+			balances = append(balances, msg.Body().(*datasource.SqlDriverMessageMap).Vals[1])
+		}
+		return balances
+	}
+
+	ctx := plan.NewContext("SELECT * FROM accounts")
+	c, _ := db.Open("accounts")
+	c.(*dbConn).SetContext(ctx)
+	first := c.(schema.ConnScanner).Next()
+	assert.NotEqual(t, nil, first)
+
+	// writes while the scan is running
+	w, _ := db.Open("accounts")
+	_, err = w.(schema.ConnUpsert).Put(nil, nil, []driver.Value{2, 0})
+	assert.Equal(t, nil, err)
+	_, err = w.(schema.ConnUpsert).Put(nil, nil, []driver.Value{4, 40})
+	assert.Equal(t, nil, err)
+	_, err = w.(schema.ConnDeletion).Delete(3)
+	assert.Equal(t, nil, err)
+
+	// the scan sees the rows as of its start
+	assert.Equal(t, []driver.Value{20, 30}, scanAll(c))
+
+	// as do the other conns of the statement, ie a self join
+	c2, _ := db.Open("accounts")
+	c2.(*dbConn).SetContext(ctx)
+	row, err := c2.(schema.ConnSeeker).Get(2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []driver.Value{2, 20}, row.Body().([]driver.Value))
+	_, err = c2.(schema.ConnSeeker).Get(4)
+	assert.Equal(t, schema.ErrNotFound, err)
+	assert.Equal(t, []driver.Value{10, 20, 30}, scanAll(c2))
+
+	// a new statement sees the writes
+	c3, _ := db.Open("accounts")
+	c3.(*dbConn).SetContext(plan.NewContext("SELECT * FROM accounts"))
+	assert.Equal(t, []driver.Value{10, 0, 40}, scanAll(c3))
+
+	// the written version doesn't change with the writers row
+	vals := []driver.Value{5, 50}
+	w.(schema.ConnUpsert).Put(nil, nil, vals)
+	vals[1] = 0
+	row, err = w.(schema.ConnSeeker).Get(5)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []driver.Value{5, 50}, row.Body().([]driver.Value))
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	db, err := NewMemDbData("accounts", [][]driver.Value{{1, "1", 1}, {2, "2", 1}}, []string{"id", "code", "version"})
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	db.tbl.VersionColumn = "version"
+
+	c, _ := db.Open("accounts")
+	cas := c.(schema.ConnCompareAndSwap)
+	swap := func(where string, patch map[string]driver.Value) (int64, int64) {
+		matched, changed, err := cas.CompareAndSwap(nil, expr.MustParse(where), patch)
+		assert.Equal(t, nil, err, where)
+		return matched, changed
+	}
+
+	// the where pins the key and the version
+	matched, changed := swap(`id = 1 AND version = 1`, map[string]driver.Value{"code": "a"})
+	assert.Equal(t, int64(1), matched)
+	assert.Equal(t, int64(1), changed)
+	matched, _ = swap(`id = 1 AND version = 1`, map[string]driver.Value{"code": "b"})
+	assert.Equal(t, int64(0), matched)
+
+	// an int is not the same value as the string holding it
+	matched, changed = swap(`id = 2`, map[string]driver.Value{"code": int64(2)})
+	assert.Equal(t, int64(1), matched)
+	assert.Equal(t, int64(1), changed)
+	matched, changed = swap(`id = 2`, map[string]driver.Value{"code": int64(2)})
+	assert.Equal(t, int64(1), matched)
+	assert.Equal(t, int64(0), changed)
+
+	// not keyed, scans
+	matched, changed = swap(`version = 2`, map[string]driver.Value{"code": "c"})
+	assert.Equal(t, int64(2), matched)
+	assert.Equal(t, int64(2), changed)
+
+	row, err := c.(schema.ConnSeeker).Get(1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []driver.Value{1, "c", int64(3)}, row.Body().([]driver.Value))
+	row, err = c.(schema.ConnSeeker).Get(2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []driver.Value{2, "c", int64(3)}, row.Body().([]driver.Value))
+}
@@ -0,0 +1,293 @@
+package memdb
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// Ensure Fixtures implements schema.Source
+	_ schema.Source = (*Fixtures)(nil)
+)
+
+// Fixtures a schema.Source of memdb tables loaded from fixture files, for
+// tests to declare their data in files instead of building tables of
+// []driver.Value.  The first column of each table is its primary key.
+//
+// A .csv file is one table, named for the file, the header row its columns,
+// values are typed as int, float, bool or string, empty as null:
+//
+//     user_id,name,age
+//     1,bob,32
+//
+// A .yaml, .yml or .json file is a map of table name to its rows, the
+// columns in order they first appear:
+//
+//     users:
+//       - {user_id: 1, name: bob, age: 32}
+//       - {user_id: 2, name: alice}
+//     orders:
+//       - {order_id: 10, user_id: 1}
+type Fixtures struct {
+	tables map[string]*MemDb
+	names  []string
+}
+
+// NewFixtures load the fixture files into memdb tables.
+func NewFixtures(paths ...string) (*Fixtures, error) {
+	m := &Fixtures{tables: make(map[string]*MemDb)}
+	for _, path := range paths {
+		if err := m.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// LoadFixtures load the fixture files into memdb tables registered as the
+// schema name, replacing an existing schema of that name, so one call sets
+// up the data of a test.
+//
+//     sch, err := memdb.LoadFixtures("testdata", "testdata/users.csv", "testdata/orders.yaml")
+//
+func LoadFixtures(name string, paths ...string) (*schema.Schema, error) {
+	fx, err := NewFixtures(paths...)
+	if err != nil {
+		return nil, err
+	}
+	reg := schema.DefaultRegistry()
+	if _, exists := reg.Schema(strings.ToLower(name)); exists {
+		if err = reg.SchemaDrop(name, name, lex.TokenSchema); err != nil {
+			return nil, err
+		}
+	}
+	if err = schema.RegisterSourceAsSchema(name, fx); err != nil {
+		return nil, err
+	}
+	sch, ok := reg.Schema(strings.ToLower(name))
+	if !ok {
+		return nil, schema.ErrNotFound
+	}
+	return sch, nil
+}
+
+// AddTable add table of rows of columns cols.
+func (m *Fixtures) AddTable(name string, cols []string, rows [][]driver.Value) error {
+	name = strings.ToLower(name)
+	if _, exists := m.tables[name]; exists {
+		return fmt.Errorf("duplicate fixture table %q", name)
+	}
+	db, err := NewMemDbData(name, rows, cols)
+	if err != nil {
+		return err
+	}
+	m.tables[name] = db
+	m.names = append(m.names, name)
+	sort.Strings(m.names)
+	return nil
+}
+
+// Init no-op.
+func (m *Fixtures) Init() {}
+
+// Setup no-op.
+func (m *Fixtures) Setup(*schema.Schema) error { return nil }
+
+// Tables names of the fixture tables.
+func (m *Fixtures) Tables() []string { return m.names }
+
+// Open a connection to table.
+func (m *Fixtures) Open(table string) (schema.Conn, error) {
+	db, ok := m.tables[strings.ToLower(table)]
+	if !ok {
+		return nil, schema.ErrNotFound
+	}
+	return db.Open(table)
+}
+
+// Table get schema of table.
+func (m *Fixtures) Table(table string) (*schema.Table, error) {
+	db, ok := m.tables[strings.ToLower(table)]
+	if !ok {
+		return nil, schema.ErrNotFound
+	}
+	return db.Table(table)
+}
+
+// Close the tables.
+func (m *Fixtures) Close() error {
+	for _, db := range m.tables {
+		db.Close()
+	}
+	return nil
+}
+
+func (m *Fixtures) loadFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		cols, rows, err := readCsvFixture(f)
+		if err != nil {
+			return fmt.Errorf("could not read fixture %q: %v", path, err)
+		}
+		return m.AddTable(name, cols, rows)
+	case ".yaml", ".yml", ".json":
+		by, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var tables yaml.MapSlice
+		if err = yaml.Unmarshal(by, &tables); err != nil {
+			return fmt.Errorf("could not read fixture %q: %v", path, err)
+		}
+		for _, t := range tables {
+			var doc []yaml.MapSlice
+			// round trip the table to read its rows in column order
+			raw, err := yaml.Marshal(t.Value)
+			if err == nil {
+				err = yaml.Unmarshal(raw, &doc)
+			}
+			if err != nil {
+				return fmt.Errorf("fixture %q table %v must be a list of rows: %v", path, t.Key, err)
+			}
+			cols, rows := mapFixtureRows(doc)
+			if len(cols) == 0 {
+				return fmt.Errorf("fixture %q table %v has no columns", path, t.Key)
+			}
+			if err = m.AddTable(fmt.Sprint(t.Key), cols, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unrecognized fixture file type %q, expected .csv, .yaml, .json", path)
+}
+
+func readCsvFixture(r io.Reader) ([]string, [][]driver.Value, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	recs, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(recs) == 0 {
+		return nil, nil, fmt.Errorf("missing header row")
+	}
+	cols := recs[0]
+	rows := make([][]driver.Value, 0, len(recs)-1)
+	for _, rec := range recs[1:] {
+		row := make([]driver.Value, len(cols))
+		for i := range cols {
+			if i < len(rec) {
+				row[i] = csvFixtureValue(rec[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return cols, rows, nil
+}
+
+func csvFixtureValue(s string) driver.Value {
+	if s == "" {
+		return nil
+	}
+	if iv, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return iv
+	}
+	if fv, err := strconv.ParseFloat(s, 64); err == nil {
+		return fv
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return s
+}
+
+// mapFixtureRows the columns, in order of first appearance, and rows of
+// yaml rows of column: value.
+func mapFixtureRows(doc []yaml.MapSlice) ([]string, [][]driver.Value) {
+	var cols []string
+	colIdx := make(map[string]int)
+	for _, row := range doc {
+		for _, kv := range row {
+			col := fmt.Sprint(kv.Key)
+			if _, ok := colIdx[col]; !ok {
+				colIdx[col] = len(cols)
+				cols = append(cols, col)
+			}
+		}
+	}
+	rows := make([][]driver.Value, 0, len(doc))
+	for _, row := range doc {
+		vals := make([]driver.Value, len(cols))
+		for _, kv := range row {
+			vals[colIdx[fmt.Sprint(kv.Key)]] = yamlFixtureValue(kv.Value)
+		}
+		rows = append(rows, vals)
+	}
+	return cols, rows
+}
+
+func yamlFixtureValue(v interface{}) driver.Value {
+	switch vt := v.(type) {
+	case nil, int64, float64, bool, string, time.Time:
+		return vt
+	case int:
+		return int64(vt)
+	case uint64:
+		return int64(vt)
+	}
+	// nested lists, maps are stored as json
+	by, err := json.Marshal(jsonFixtureValue(v))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(by)
+}
+
+func jsonFixtureValue(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case yaml.MapSlice:
+		m := make(map[string]interface{}, len(vt))
+		for _, kv := range vt {
+			m[fmt.Sprint(kv.Key)] = jsonFixtureValue(kv.Value)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vt))
+		for k, kv := range vt {
+			m[fmt.Sprint(k)] = jsonFixtureValue(kv)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(vt))
+		for i, iv := range vt {
+			l[i] = jsonFixtureValue(iv)
+		}
+		return l
+	}
+	return v
+}
@@ -10,12 +10,14 @@ import (
 
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/vm"
 )
 
 var (
 	_ = u.EMPTY
 	// Indexes
 	_ memdb.Indexer = (*indexWrapper)(nil)
+	_ memdb.Indexer = (*exprIndexWrapper)(nil)
 )
 
 func makeId(dv driver.Value) uint64 {
@@ -70,6 +72,35 @@ func (s *indexWrapper) FromArgs(args ...interface{}) ([]byte, error) {
 	return []byte(arg), nil
 }
 
+// Wrap an expression index, rows are indexed on the (string) value of
+// the expression evaluated against the row, rows where it evaluates to nil
+// are not indexed.
+type exprIndexWrapper struct {
+	t *schema.Table
+	*schema.ExprIndex
+}
+
+func (s *exprIndexWrapper) FromObject(obj interface{}) (bool, []byte, error) {
+	row, ok := obj.(*datasource.SqlDriverMessage)
+	if !ok {
+		return false, nil, u.LogErrorf("Unrecognized type %T", obj)
+	}
+	v, ok := vm.Eval(row.ToMsgMap(s.t.FieldPositions), s.Expr)
+	if !ok || v == nil || v.Nil() {
+		return false, nil, nil
+	}
+	// Add the null character as a terminator
+	return true, []byte(v.ToString() + "\x00"), nil
+}
+
+func (s *exprIndexWrapper) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	// Add the null character as a terminator
+	return []byte(fmt.Sprintf("%v\x00", args[0])), nil
+}
+
 func makeMemDbSchema(m *MemDb) *memdb.DBSchema {
 
 	sindexes := make(map[string]*memdb.IndexSchema)
@@ -84,6 +115,13 @@ func makeMemDbSchema(m *MemDb) *memdb.DBSchema {
 		}
 		sindexes[idx.Name] = sidx
 	}
+	for _, idx := range m.tbl.ExprIndexes() {
+		sindexes[idx.Name] = &memdb.IndexSchema{
+			Name:         idx.Name,
+			AllowMissing: true,
+			Indexer:      &exprIndexWrapper{t: m.tbl, ExprIndex: idx},
+		}
+	}
 	/*
 		{
 			"id": &memdb.IndexSchema{
@@ -0,0 +1,117 @@
+package memdb
+
+import (
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+)
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+// snapshotHeader first record of a snapshot.
+type snapshotHeader struct {
+	Table   string
+	Columns []string
+}
+
+// Snapshot write all (unexpired) rows of this db to w, encrypted if the datasource
+// artifact keys are set (see datasource.SetArtifactKeys).
+func (m *MemDb) Snapshot(w io.Writer) error {
+	aw, err := datasource.NewArtifactWriter(w)
+	if err != nil {
+		return err
+	}
+	enc := gob.NewEncoder(aw)
+	if err = enc.Encode(&snapshotHeader{Table: m.tbl.Name, Columns: m.tbl.Columns()}); err != nil {
+		return err
+	}
+	txn := m.db.Txn(false)
+	defer txn.Abort()
+	iter, err := txn.Get(m.tbl.Name, m.primaryIndex)
+	if err != nil {
+		return err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		msg, ok := raw.(*datasource.SqlDriverMessage)
+		if !ok {
+			return fmt.Errorf("unexpected row type %T", raw)
+		}
+		if m.expired(msg) {
+			continue
+		}
+		if err = enc.Encode(msg.Vals); err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// Restore load rows from a Snapshot into this db, the columns of the
+// snapshot must match.
+func (m *MemDb) Restore(r io.Reader) error {
+	ar, err := datasource.NewArtifactReader(r)
+	if err != nil {
+		return err
+	}
+	dec := gob.NewDecoder(ar)
+	hdr := &snapshotHeader{}
+	if err = dec.Decode(hdr); err != nil {
+		return err
+	}
+	cols := m.tbl.Columns()
+	if len(hdr.Columns) != len(cols) {
+		return fmt.Errorf("snapshot columns %v don't match %v", hdr.Columns, cols)
+	}
+	for i, col := range cols {
+		if hdr.Columns[i] != col {
+			return fmt.Errorf("snapshot columns %v don't match %v", hdr.Columns, cols)
+		}
+	}
+	conn := newDbConn(m)
+	txn := m.db.Txn(true)
+	for {
+		var row []driver.Value
+		if err = dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			txn.Abort()
+			return err
+		}
+		if _, err = conn.putValues(txn, row); err != nil {
+			txn.Abort()
+			return err
+		}
+	}
+	txn.Commit()
+	return nil
+}
+
+// SnapshotFile write a Snapshot to file at path.
+func (m *MemDb) SnapshotFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = m.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RestoreFile Restore from a snapshot file at path.
+func (m *MemDb) RestoreFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Restore(f)
+}
@@ -0,0 +1,15 @@
+package memdb
+
+import (
+	"database/sql/driver"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func init() {
+	// session temporary tables are memdb tables
+	plan.TempSource = func(name string, cols []string, rows [][]driver.Value) (schema.Source, error) {
+		return NewMemDbData(name, rows, cols)
+	}
+}
@@ -0,0 +1,240 @@
+package memdb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/value"
+)
+
+// Settings (of the ConfigSource, or table options of CREATE TABLE) for rows
+// of a memdb table that expire, ie for caches and session stores.  Rows
+// expire ttl after they were last written, or ttl after the time in their
+// ttl_column.  Expired rows are not read, and are removed by a background
+// sweeper every ttl_sweep (default the ttl, at most a minute).
+//
+//	{"name": "sessions", "type": "memdb", "settings": {"ttl": "30m", "ttl_column": "last_seen"}}
+//
+//	CREATE TEMPORARY TABLE cache (k varchar(255), v text) ENGINE=memdb TTL="10m" TTL_SWEEP="30s"
+const (
+	SettingTTL       = "ttl"
+	SettingTTLColumn = "ttl_column"
+	SettingTTLSweep  = "ttl_sweep"
+)
+
+// now the clock rows expire by.
+var now = time.Now
+
+// rowTTL the expiry of the rows of a table.
+type rowTTL struct {
+	ttl     time.Duration
+	col     int // position of the ttl column, -1 for the write time
+	sweep   time.Duration
+	stop    chan bool
+	mu      sync.Mutex
+	written map[uint64]time.Time // by row id, if no ttl column
+}
+
+// SetTTL expire rows ttl after they were written, or if column is set, ttl
+// after the time in it, and start removing them every sweep (0 for the
+// default).  A ttl of 0 removes the expiry.
+func (m *MemDb) SetTTL(ttl time.Duration, column string, sweep time.Duration) error {
+	if ttl < 0 || sweep < 0 {
+		return fmt.Errorf("invalid ttl %v, sweep %v", ttl, sweep)
+	}
+	var rt *rowTTL
+	if ttl > 0 {
+		rt = &rowTTL{ttl: ttl, col: -1, sweep: sweep, stop: make(chan bool)}
+		if column != "" {
+			pos, ok := m.tbl.FieldPositions[strings.ToLower(column)]
+			if !ok {
+				return fmt.Errorf("Unknown %s %q in table %q", SettingTTLColumn, column, m.tbl.Name)
+			}
+			rt.col = pos
+		} else {
+			rt.written = make(map[uint64]time.Time)
+			rt.stampAll(m)
+		}
+		if rt.sweep == 0 {
+			rt.sweep = ttl
+			if rt.sweep > time.Minute {
+				rt.sweep = time.Minute
+			}
+		}
+	}
+	m.ttlMu.Lock()
+	if m.ttl != nil {
+		close(m.ttl.stop)
+	}
+	m.ttl = rt
+	m.ttlMu.Unlock()
+	if rt != nil {
+		go m.sweeper(rt)
+	}
+	return nil
+}
+
+// setTTLSettings set the ttl from settings read by get.
+func (m *MemDb) setTTLSettings(get func(name string) string) error {
+	ttl, err := parseTTLSetting(SettingTTL, get(SettingTTL))
+	if err != nil || ttl == 0 {
+		return err
+	}
+	sweep, err := parseTTLSetting(SettingTTLSweep, get(SettingTTLSweep))
+	if err != nil {
+		return err
+	}
+	return m.SetTTL(ttl, get(SettingTTLColumn), sweep)
+}
+
+// parseTTLSetting a duration "10m", or number of seconds.
+func parseTTLSetting(name, val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+	dur, err := time.ParseDuration(val)
+	if err != nil {
+		secs, ok := value.StringToFloat64(val)
+		if !ok {
+			return 0, fmt.Errorf("invalid %s %q: %v", name, val, err)
+		}
+		dur = time.Duration(secs * float64(time.Second))
+	}
+	if dur < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return dur, nil
+}
+
+// rowTTL the expiry of the rows, nil if they don't expire.
+func (m *MemDb) rowTTL() *rowTTL {
+	m.ttlMu.RLock()
+	defer m.ttlMu.RUnlock()
+	return m.ttl
+}
+
+// stampAll the rows without a write time as written now.
+func (m *rowTTL) stampAll(db *MemDb) {
+	txn := db.db.Txn(false)
+	defer txn.Abort()
+	iter, err := txn.Get(db.tbl.Name, db.primaryIndex)
+	if err != nil {
+		return
+	}
+	ts := now()
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if msg, ok := raw.(*datasource.SqlDriverMessage); ok {
+			m.written[msg.IdVal] = ts
+		}
+	}
+}
+
+// stamp row id as (re-)written now.
+func (m *rowTTL) stamp(id uint64) {
+	if m == nil || m.written == nil {
+		return
+	}
+	m.mu.Lock()
+	m.written[id] = now()
+	m.mu.Unlock()
+}
+
+// forget row id was deleted.
+func (m *rowTTL) forget(id uint64) {
+	if m == nil || m.written == nil {
+		return
+	}
+	m.mu.Lock()
+	delete(m.written, id)
+	m.mu.Unlock()
+}
+
+// expired is the row expired at t.  Rows with no (or an invalid) time in
+// the ttl column never expire.
+func (m *rowTTL) expired(msg *datasource.SqlDriverMessage, t time.Time) bool {
+	if m == nil {
+		return false
+	}
+	if m.col < 0 {
+		m.mu.Lock()
+		written, ok := m.written[msg.IdVal]
+		m.mu.Unlock()
+		return ok && !t.Before(written.Add(m.ttl))
+	}
+	if m.col >= len(msg.Vals) || msg.Vals[m.col] == nil {
+		return false
+	}
+	ts, ok := value.ValueToTime(value.NewValue(msg.Vals[m.col]))
+	if !ok {
+		return false
+	}
+	return !t.Before(ts.Add(m.ttl))
+}
+
+// expired is the row expired now.
+func (m *MemDb) expired(msg *datasource.SqlDriverMessage) bool {
+	return m.expiredAt(msg, now())
+}
+
+// expiredAt is the row expired at t.
+func (m *MemDb) expiredAt(msg *datasource.SqlDriverMessage, t time.Time) bool {
+	rt := m.rowTTL()
+	if rt == nil {
+		return false
+	}
+	return rt.expired(msg, t)
+}
+
+// sweeper remove expired rows every sweep, until the db is closed or
+// the ttl replaced.
+func (m *MemDb) sweeper(rt *rowTTL) {
+	ticker := time.NewTicker(rt.sweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.exit:
+			return
+		case <-rt.stop:
+			return
+		case <-ticker.C:
+			if _, err := m.Sweep(); err != nil {
+				u.Warnf("could not sweep expired rows of %q: %v", m.tbl.Name, err)
+			}
+		}
+	}
+}
+
+// Sweep remove the expired rows, returning how many.
+func (m *MemDb) Sweep() (int, error) {
+	rt := m.rowTTL()
+	if rt == nil {
+		return 0, nil
+	}
+	t := now()
+	txn := m.db.Txn(true)
+	iter, err := txn.Get(m.tbl.Name, m.primaryIndex)
+	if err != nil {
+		txn.Abort()
+		return 0, err
+	}
+	var expired []*datasource.SqlDriverMessage
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if msg, ok := raw.(*datasource.SqlDriverMessage); ok && rt.expired(msg, t) {
+			expired = append(expired, msg)
+		}
+	}
+	for _, msg := range expired {
+		if err = txn.Delete(m.tbl.Name, msg); err != nil {
+			txn.Abort()
+			return 0, err
+		}
+		rt.forget(msg.IdVal)
+	}
+	txn.Commit()
+	return len(expired), nil
+}
@@ -0,0 +1,181 @@
+package mockcsv
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// ErrInjected the default error of a scan failed by Faults.ErrAtRow
+	ErrInjected = fmt.Errorf("mockcsv: injected scan error")
+	// ErrConnRefused the error of an Open failed by Faults.FailOpens
+	ErrConnRefused = fmt.Errorf("mockcsv: connection refused")
+
+	_ schema.ConnScanner = (*faultyTable)(nil)
+	_ schema.IteratorErr = (*faultyTable)(nil)
+)
+
+// Faults simulated misbehavior of a mock table, so retry, timeout and
+// partial failure handling of exec can be tested.
+//
+//     mockcsv.SetFaults("users", &mockcsv.Faults{RowLatency: time.Millisecond, ErrAtRow: 3})
+//     defer mockcsv.SetFaults("users", nil)
+//
+type Faults struct {
+	RowLatency  time.Duration // sleep before returning each row
+	PageSize    int           // scan fetches rows in pages of this size, 0 not paged
+	PageLatency time.Duration // sleep before fetching each page
+	ErrAtRow    int           // scan fails at this row (1 based), the rows before it are returned, 0 never
+	Err         error         // error of ErrAtRow, ErrInjected if nil
+	FailOpens   int           // the first n opens of the table fail with ErrConnRefused
+}
+
+// FaultStats counts of what a table with Faults has done.
+type FaultStats struct {
+	Opens       int // opens, including the failed ones
+	FailedOpens int
+	Pages       int // pages fetched
+	Rows        int // rows returned
+	Errors      int // scans failed
+}
+
+type tableFaults struct {
+	mu    sync.Mutex
+	f     Faults
+	stats FaultStats
+}
+
+func (m *tableFaults) count(fn func(s *FaultStats)) {
+	m.mu.Lock()
+	fn(&m.stats)
+	m.mu.Unlock()
+}
+
+// SetFaults of table of the global mock source, nil clears them.
+func SetFaults(table string, f *Faults) { CsvGlobal.SetFaults(table, f) }
+
+// GetFaultStats of table of the global mock source.
+func GetFaultStats(table string) FaultStats { return CsvGlobal.FaultStats(table) }
+
+// SetFaults of table, nil clears them.  Stats are reset.
+func (m *Source) SetFaults(table string, f *Faults) {
+	m.faultsMu.Lock()
+	defer m.faultsMu.Unlock()
+	table = strings.ToLower(table)
+	if f == nil {
+		delete(m.faults, table)
+		return
+	}
+	m.faults[table] = &tableFaults{f: *f}
+}
+
+// FaultStats of table, zero if it has no faults.
+func (m *Source) FaultStats(table string) FaultStats {
+	tf := m.tableFaults(table)
+	if tf == nil {
+		return FaultStats{}
+	}
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	return tf.stats
+}
+
+func (m *Source) tableFaults(table string) *tableFaults {
+	m.faultsMu.Lock()
+	defer m.faultsMu.Unlock()
+	return m.faults[strings.ToLower(table)]
+}
+
+// openFaulty the conn of a table with faults.
+func (m *Source) openFaulty(tf *tableFaults, tbl *Table) (schema.Conn, error) {
+	failed := false
+	tf.count(func(s *FaultStats) {
+		s.Opens++
+		if s.Opens <= tf.f.FailOpens {
+			s.FailedOpens++
+			failed = true
+		}
+	})
+	if failed {
+		return nil, ErrConnRefused
+	}
+	return &faultyTable{Table: tbl, tf: tf}, nil
+}
+
+// faultyTable a Table whose scan is slowed, paged or failed per its Faults.
+type faultyTable struct {
+	*Table
+	tf   *tableFaults
+	page []schema.Message
+	done bool
+	rows int
+	err  error
+}
+
+func (m *faultyTable) Next() schema.Message {
+	if m.err != nil {
+		return nil
+	}
+	f := &m.tf.f
+	var msg schema.Message
+	if f.PageSize > 0 {
+		if len(m.page) == 0 {
+			if m.done {
+				return nil
+			}
+			time.Sleep(f.PageLatency)
+			for len(m.page) < f.PageSize {
+				next := m.Table.Next()
+				if next == nil {
+					m.done = true
+					break
+				}
+				m.page = append(m.page, next)
+			}
+			if len(m.page) == 0 {
+				return nil
+			}
+			m.tf.count(func(s *FaultStats) { s.Pages++ })
+		}
+		msg, m.page = m.page[0], m.page[1:]
+	} else {
+		msg = m.Table.Next()
+		if msg == nil {
+			return nil
+		}
+	}
+
+	if f.ErrAtRow > 0 && m.rows+1 >= f.ErrAtRow {
+		m.err = f.Err
+		if m.err == nil {
+			m.err = ErrInjected
+		}
+		m.tf.count(func(s *FaultStats) { s.Errors++ })
+		// the btree cursor is shared, finish it so the next scan starts over
+		if !m.done {
+			for m.Table.Next() != nil {
+			}
+		}
+		return nil
+	}
+	time.Sleep(f.RowLatency)
+	m.rows++
+	m.tf.count(func(s *FaultStats) { s.Rows++ })
+	return msg
+}
+
+// Reset the page and injected error along with the table cursor.
+func (m *faultyTable) Reset() error {
+	m.page = nil
+	m.done = false
+	m.rows = 0
+	m.err = nil
+	return m.Table.Reset()
+}
+
+// Err the injected error that ended the scan, if any.
+func (m *faultyTable) Err() error { return m.err }
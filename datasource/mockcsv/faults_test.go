@@ -0,0 +1,81 @@
+package mockcsv_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func TestFaults(t *testing.T) {
+	td.LoadTestDataOnce()
+	mockcsv.LoadTable(mockcsv.SchemaName, "faulty", "id,name\n1,a\n2,b\n3,c\n4,d\n5,e")
+	defer mockcsv.SetFaults("faulty", nil)
+
+	scan := func() (int, error) {
+		conn, err := mockcsv.CsvGlobal.Open("faulty")
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		it := conn.(schema.ConnScanner)
+		ct := 0
+		for msg := it.Next(); msg != nil; msg = it.Next() {
+			ct++
+		}
+		if ie, ok := it.(schema.IteratorErr); ok {
+			return ct, ie.Err()
+		}
+		return ct, nil
+	}
+
+	// paged, slowed scan returns all rows
+	mockcsv.SetFaults("faulty", &mockcsv.Faults{PageSize: 2, RowLatency: time.Millisecond})
+	start := time.Now()
+	ct, err := scan()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, ct)
+	assert.True(t, time.Since(start) >= 5*time.Millisecond)
+	stats := mockcsv.GetFaultStats("faulty")
+	assert.Equal(t, 3, stats.Pages)
+	assert.Equal(t, 5, stats.Rows)
+
+	// error at row 3 returns the first 2, and the next scan starts over
+	mockcsv.SetFaults("faulty", &mockcsv.Faults{ErrAtRow: 3})
+	ct, err = scan()
+	assert.Equal(t, mockcsv.ErrInjected, err)
+	assert.Equal(t, 2, ct)
+	mockcsv.SetFaults("faulty", nil)
+	ct, err = scan()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, ct)
+
+	// flaky opens
+	mockcsv.SetFaults("faulty", &mockcsv.Faults{FailOpens: 2})
+	_, err = scan()
+	assert.Equal(t, mockcsv.ErrConnRefused, err)
+	_, err = scan()
+	assert.Equal(t, mockcsv.ErrConnRefused, err)
+	ct, err = scan()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, ct)
+	stats = mockcsv.GetFaultStats("faulty")
+	assert.Equal(t, 3, stats.Opens)
+	assert.Equal(t, 2, stats.FailedOpens)
+
+	// a query over a failing source fails with the injected error
+	boom := fmt.Errorf("disk on fire")
+	mockcsv.SetFaults("faulty", &mockcsv.Faults{ErrAtRow: 2, Err: boom})
+	s := testutil.TakeSnapshot(td.TestContext, "SELECT id, name FROM faulty")
+	assert.Contains(t, s.Err, "disk on fire")
+	mockcsv.SetFaults("faulty", nil)
+	s = testutil.TakeSnapshot(td.TestContext, "SELECT id, name FROM faulty")
+	assert.Equal(t, "", s.Err)
+	assert.Equal(t, 5, s.RowCt)
+}
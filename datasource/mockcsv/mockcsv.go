@@ -6,6 +6,7 @@ package mockcsv
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	u "github.com/araddon/gou"
 
@@ -21,11 +22,13 @@ const (
 
 var (
 	// Ensure this Csv Data Source implements expected interfaces
-	_ schema.Source       = (*Source)(nil)
-	_ schema.Alter        = (*Source)(nil)
-	_ schema.Conn         = (*Table)(nil)
-	_ schema.ConnUpsert   = (*Table)(nil)
-	_ schema.ConnDeletion = (*Table)(nil)
+	_ schema.Source           = (*Source)(nil)
+	_ schema.Alter            = (*Source)(nil)
+	_ schema.SourceRowCounter = (*Source)(nil)
+	_ schema.SourceHierarchy  = (*Source)(nil)
+	_ schema.Conn             = (*Table)(nil)
+	_ schema.ConnUpsert       = (*Table)(nil)
+	_ schema.ConnDeletion     = (*Table)(nil)
 
 	// CsvGlobal mock csv in mem store
 	CsvGlobal = New()
@@ -52,6 +55,13 @@ func LoadTable(schemaName, name, csvRaw string) {
 	schema.DefaultRegistry().SchemaRefresh(SchemaName)
 }
 
+// LoadChildTable load a csv table as a child of parent, its rows belong to
+// the parent row whose parentKey equals their childKey.
+func LoadChildTable(schemaName, parent, name, parentKey, childKey, csvRaw string) {
+	CsvGlobal.CreateChildTable(parent, name, parentKey, childKey, csvRaw)
+	schema.DefaultRegistry().SchemaRefresh(SchemaName)
+}
+
 // Source DataSource for testing creates an in memory b-tree per "table".
 // Is not thread safe.
 type Source struct {
@@ -59,6 +69,10 @@ type Source struct {
 	tablenamelist []string
 	tables        map[string]*membtree.StaticDataSource
 	raw           map[string]string
+	children      map[string][]string
+	parents       map[string][3]string // child -> parent, parentKey, childKey
+	faults        map[string]*tableFaults
+	faultsMu      sync.Mutex
 }
 
 // Table converts the static csv-source into a schema.Conn source
@@ -72,6 +86,9 @@ func New() *Source {
 		tablenamelist: make([]string, 0),
 		raw:           make(map[string]string),
 		tables:        make(map[string]*membtree.StaticDataSource),
+		children:      make(map[string][]string),
+		parents:       make(map[string][3]string),
+		faults:        make(map[string]*tableFaults),
 	}
 }
 
@@ -88,9 +105,22 @@ func (m *Source) Setup(s *schema.Schema) error {
 func (m *Source) DropTable(t string) error {
 	delete(m.raw, t)
 	delete(m.tables, t)
+	m.SetFaults(t, nil)
+	if p, ok := m.parents[t]; ok {
+		children := make([]string, 0, len(m.children[p[0]]))
+		for _, child := range m.children[p[0]] {
+			if child != t {
+				children = append(children, child)
+			}
+		}
+		m.children[p[0]] = children
+		delete(m.parents, t)
+	}
 	names := make([]string, 0, len(m.tables))
 	for tableName, _ := range m.raw {
-		names = append(names, tableName)
+		if _, isChild := m.parents[tableName]; !isChild {
+			names = append(names, tableName)
+		}
 	}
 	m.tablenamelist = names
 	return nil
@@ -100,15 +130,18 @@ func (m *Source) DropTable(t string) error {
 func (m *Source) Open(tableName string) (schema.Conn, error) {
 
 	tableName = strings.ToLower(tableName)
-	if ds, ok := m.tables[tableName]; ok {
-		return &Table{StaticDataSource: ds}, nil
+	ds, ok := m.tables[tableName]
+	if !ok {
+		err := m.loadTable(tableName)
+		if err != nil {
+			u.Errorf("could not load table %q  err=%v", tableName, err)
+			return nil, err
+		}
+		ds = m.tables[tableName]
 	}
-	err := m.loadTable(tableName)
-	if err != nil {
-		u.Errorf("could not load table %q  err=%v", tableName, err)
-		return nil, err
+	if tf := m.tableFaults(tableName); tf != nil {
+		return m.openFaulty(tf, &Table{StaticDataSource: ds})
 	}
-	ds := m.tables[tableName]
 	return &Table{StaticDataSource: ds}, nil
 }
 
@@ -174,15 +207,37 @@ func (m *Source) loadTable(tableName string) error {
 	if err != nil {
 		return err
 	}
-	return datasource.IntrospectTable(tbl, iter)
+	if p, ok := m.parents[tableName]; ok {
+		tbl.SetParent(p[0], p[1], p[2])
+	}
+	err = datasource.IntrospectTable(tbl, iter)
+	// introspection reads only the first rows, finish the scan so the
+	// next one starts from the first row
+	for msg := ds.Next(); msg != nil; msg = ds.Next() {
+	}
+	return err
 }
 
 // Close csv source.
 func (m *Source) Close() error { return nil }
 
-// Tables list of tables.
+// Tables list of tables, not including child tables.
 func (m *Source) Tables() []string { return m.tablenamelist }
 
+// ChildTables list of the child tables of table.
+func (m *Source) ChildTables(table string) []string { return m.children[table] }
+
+// RowCount of table, tables are in memory so this is exact.
+func (m *Source) RowCount(tableName string) (int64, bool) {
+	tableName = strings.ToLower(tableName)
+	if _, ok := m.tables[tableName]; !ok {
+		if err := m.loadTable(tableName); err != nil {
+			return 0, false
+		}
+	}
+	return int64(m.tables[tableName].Length()), true
+}
+
 // CreateTable create a csv table in this source.
 func (m *Source) CreateTable(tableName, csvRaw string) {
 	if _, exists := m.raw[tableName]; !exists {
@@ -191,3 +246,14 @@ func (m *Source) CreateTable(tableName, csvRaw string) {
 	m.raw[tableName] = csvRaw
 	m.loadTable(tableName)
 }
+
+// CreateChildTable create a csv table in this source that is a child of
+// parent, joined to it on parent.parentKey = name.childKey.
+func (m *Source) CreateChildTable(parent, tableName, parentKey, childKey, csvRaw string) {
+	if _, exists := m.raw[tableName]; !exists {
+		m.children[parent] = append(m.children[parent], tableName)
+	}
+	m.parents[tableName] = [3]string{parent, parentKey, childKey}
+	m.raw[tableName] = csvRaw
+	m.loadTable(tableName)
+}
@@ -3,6 +3,7 @@ package datasource
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"sort"
 
@@ -28,7 +29,7 @@ var (
 
 	// normal tables
 	defaultSchemaTables = []string{"tables", "databases", "columns", "global_variables", "session_variables",
-		"functions", "procedures", "engines", "status", "indexes"}
+		"functions", "procedures", "engines", "status", "indexes", "collations", "character_sets", "statistics"}
 	// DialectWriterCols list of columns for dialectwriter.
 	DialectWriterCols = []string{"mysql"}
 	// DialectWriters list of differnt writers.
@@ -111,6 +112,12 @@ func (m *SchemaDb) Table(table string) (*schema.Table, error) {
 		return m.tableForEngines()
 	case "indexes", "keys":
 		return m.tableForIndexes()
+	case "statistics":
+		return m.tableForStatistics()
+	case "collations":
+		return m.tableForCollations()
+	case "character_sets":
+		return m.tableForCharsets()
 	case "status":
 		return m.tableForVariables(table)
 	case "columns":
@@ -129,8 +136,14 @@ func (m *SchemaDb) Open(schemaObjectName string) (schema.Conn, error) {
 		switch schemaObjectName {
 		case "session_variables", "global_variables":
 			return &SchemaSource{db: m, tbl: tbl, session: true}, nil
-		case "engines", "procedures", "functions", "indexes":
+		case "engines", "procedures", "functions":
 			return &SchemaSource{db: m, tbl: tbl, rows: nil}, nil
+		case "indexes":
+			return &SchemaSource{db: m, tbl: tbl, rows: m.indexRows()}, nil
+		case "statistics":
+			return &SchemaSource{db: m, tbl: tbl, rows: m.statisticsRows()}, nil
+		case "status":
+			return &SchemaSource{db: m, tbl: tbl, rows: StatusRows()}, nil
 		default:
 			return &SchemaSource{db: m, tbl: tbl, rows: tbl.AsRows()}, nil
 		}
@@ -143,7 +156,11 @@ func (m *SchemaDb) Open(schemaObjectName string) (schema.Conn, error) {
 func (m *SchemaSource) SetContext(ctx *plan.Context) {
 	m.ctx = ctx
 	if m.session {
-		m.rows = RowsForSession(ctx)
+		if m.tbl.Name == "global_variables" {
+			m.rows = GlobalVarRows()
+		} else {
+			m.rows = RowsForSession(ctx)
+		}
 	}
 }
 
@@ -209,7 +226,7 @@ func (m *SchemaDb) tableForTable(table string) (*schema.Table, error) {
 	if srcTbl == nil {
 		return nil, schema.ErrNotFound
 	}
-	if len(srcTbl.Columns()) > 0 && len(srcTbl.Fields) == 0 {
+	if len(srcTbl.Columns()) > 0 && len(srcTbl.FieldList()) == 0 {
 		// I really don't like where/how this gets called
 		// needs to be in schema somewhere?
 		m.inspect(table)
@@ -315,7 +332,7 @@ func (m *SchemaDb) tableForTables() (*schema.Table, error) {
 	for i, tableName := range m.s.Tables() {
 		rows[i] = []driver.Value{tableName, "BASE TABLE"}
 		tbl, err := m.s.Table(tableName)
-		if tbl != nil && len(tbl.Columns()) > 0 && len(tbl.Fields) == 0 {
+		if tbl != nil && len(tbl.Columns()) > 0 && len(tbl.FieldList()) == 0 {
 			// I really don't like where this is, needs to be in schema somewhere
 			m.inspect(tbl.Name)
 		}
@@ -356,7 +373,7 @@ func (m *SchemaDb) tableForIndexes() (*schema.Table, error) {
 		+-------+------------+----------+--------------+-------------+-----------+-------------+----------+--------+------+------------+---------+---------------+
 	*/
 	t.AddField(schema.NewFieldBase("Table", value.StringType, 64, "string"))
-	t.AddField(schema.NewFieldBase("Non_unique", value.BoolType, 1, "tinyint"))
+	t.AddField(schema.NewFieldBase("Non_unique", value.IntType, 1, "tinyint"))
 	t.AddField(schema.NewFieldBase("Key_name", value.StringType, 20, "string"))
 	t.AddField(schema.NewFieldBase("Seq_in_index", value.IntType, 8, "integer"))
 	t.AddField(schema.NewFieldBase("Column_name", value.StringType, 64, "string"))
@@ -370,7 +387,204 @@ func (m *SchemaDb) tableForIndexes() (*schema.Table, error) {
 	t.AddField(schema.NewFieldBase("Index_comment", value.StringType, 255, "string"))
 
 	t.SetColumns(schema.ShowIndexCols)
-	//t.SetRows(rows)
+	m.tableMap[table] = t
+	return t, nil
+}
+
+// indexRows the SHOW INDEX rows of the indexes of every table of the
+// schema, one row per column of each index.
+func (m *SchemaDb) indexRows() [][]driver.Value {
+	rows := make([][]driver.Value, 0)
+	for _, name := range m.s.Tables() {
+		tbl, err := m.s.Table(name)
+		if err != nil || tbl == nil {
+			continue
+		}
+		rowCt, hasCt := m.rowCount(name)
+		ts, hasStats := tbl.Stats()
+		if hasStats {
+			rowCt, hasCt = ts.Rows, true
+		}
+		for _, idx := range tbl.Indexes {
+			keyName, nonUnique := idx.Name, int64(1)
+			if idx.PrimaryKey {
+				keyName = "PRIMARY"
+			}
+			unique := tbl.IsUniqueKey(idx.Fields...)
+			if unique {
+				nonUnique = 0
+			}
+			indexType := "BTREE"
+			if len(idx.HashPartition) > 0 {
+				indexType = "HASH"
+			}
+			for i, col := range idx.Fields {
+				// Cardinality is the distinct values of the index prefix
+				// through this column, known for the whole of a unique key,
+				// and from the analyzed statistics for the first column.
+				var cardinality driver.Value
+				if unique && hasCt && i == len(idx.Fields)-1 {
+					cardinality = rowCt
+				} else if cs := ts.Column(col); i == 0 && cs != nil {
+					cardinality = cs.Ndv
+				}
+				null := "YES"
+				if fld, ok := tbl.Field(col); idx.PrimaryKey || (ok && fld.NoNulls) {
+					null = ""
+				}
+				rows = append(rows, []driver.Value{tbl.Name, nonUnique, keyName, int64(i + 1), col,
+					"A", cardinality, nil, nil, null, indexType, "", ""})
+			}
+		}
+	}
+	return rows
+}
+
+func (m *SchemaDb) tableForStatistics() (*schema.Table, error) {
+
+	table := "statistics"
+
+	t, hasTable := m.tableMap[table]
+	if hasTable {
+		return t, nil
+	}
+
+	/*
+		mysql> ANALYZE TABLE users;
+		mysql> SELECT * FROM information_schema.STATISTICS WHERE TABLE_NAME = "users";
+		+--------------+------------+-------------+------------+-------------+-------+-----------+-----------+-----------+---------------------+
+		| TABLE_SCHEMA | TABLE_NAME | COLUMN_NAME | TABLE_ROWS | CARDINALITY | NULLS | MIN_VALUE | MAX_VALUE | HISTOGRAM | LAST_ANALYZED       |
+		+--------------+------------+-------------+------------+-------------+-------+-----------+-----------+-----------+---------------------+
+		| mockcsv      | users      | user_id     |          3 |           3 |     0 | 9Ip1a...  | hT2im...  | {...}     | 2017-02-01 10:00:00 |
+		+--------------+------------+-------------+------------+-------------+-------+-----------+-----------+-----------+---------------------+
+	*/
+	t = schema.NewTable(table)
+	t.AddField(schema.NewFieldBase("TABLE_SCHEMA", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("TABLE_NAME", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("COLUMN_NAME", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("TABLE_ROWS", value.IntType, 8, "integer"))
+	t.AddField(schema.NewFieldBase("CARDINALITY", value.IntType, 8, "integer"))
+	t.AddField(schema.NewFieldBase("NULLS", value.IntType, 8, "integer"))
+	t.AddField(schema.NewFieldBase("MIN_VALUE", value.StringType, 255, "string"))
+	t.AddField(schema.NewFieldBase("MAX_VALUE", value.StringType, 255, "string"))
+	t.AddField(schema.NewFieldBase("HISTOGRAM", value.StringType, 65535, "string"))
+	t.AddField(schema.NewFieldBase("LAST_ANALYZED", value.TimeType, 8, "datetime"))
+	t.SetColumns(schema.StatisticsCols)
+	m.tableMap[table] = t
+	return t, nil
+}
+
+// statisticsRows a row per column of the analyzed tables of the schema.
+func (m *SchemaDb) statisticsRows() [][]driver.Value {
+	rows := make([][]driver.Value, 0)
+	for _, ts := range schema.DefaultStatsStore.AllTableStats() {
+		if ts.Schema != m.s.Name {
+			continue
+		}
+		cols := make([]string, 0, len(ts.Columns))
+		if tbl, err := m.s.Table(ts.Table); err == nil && tbl != nil {
+			for _, col := range tbl.Columns() {
+				if ts.Column(col) != nil {
+					cols = append(cols, col)
+				}
+			}
+		} else {
+			for col := range ts.Columns {
+				cols = append(cols, col)
+			}
+			sort.Strings(cols)
+		}
+		for _, col := range cols {
+			cs := ts.Column(col)
+			var hist driver.Value
+			if cs.Histogram != nil {
+				if by, err := json.Marshal(cs.Histogram); err == nil {
+					hist = string(by)
+				}
+			}
+			rows = append(rows, []driver.Value{ts.Schema, ts.Table, cs.Name, ts.Rows, cs.Ndv, cs.Nulls,
+				statsValueString(cs.Min), statsValueString(cs.Max), hist, ts.Analyzed})
+		}
+	}
+	return rows
+}
+
+func statsValueString(v driver.Value) driver.Value {
+	if v == nil {
+		return nil
+	}
+	return value.NewValue(v).ToString()
+}
+
+// rowCount of table from its source if it knows it.
+func (m *SchemaDb) rowCount(table string) (int64, bool) {
+	ss, err := m.s.SchemaForTable(table)
+	if err != nil || ss.DS == nil {
+		return 0, false
+	}
+	if rc, ok := ss.DS.(schema.SourceRowCounter); ok {
+		return rc.RowCount(table)
+	}
+	return 0, false
+}
+
+func (m *SchemaDb) tableForCollations() (*schema.Table, error) {
+
+	/*
+		mysql> show collation like 'utf8mb4%';
+		+--------------------+---------+-----+---------+----------+---------+
+		| Collation          | Charset | Id  | Default | Compiled | Sortlen |
+		+--------------------+---------+-----+---------+----------+---------+
+		| utf8mb4_general_ci | utf8mb4 |  45 | Yes     | Yes      |       1 |
+		| utf8mb4_bin        | utf8mb4 |  46 |         | Yes      |       1 |
+		+--------------------+---------+-----+---------+----------+---------+
+	*/
+	t := schema.NewTable("collations")
+	t.AddField(schema.NewFieldBase("Collation", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Charset", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Id", value.IntType, 8, "integer"))
+	t.AddField(schema.NewFieldBase("Default", value.StringType, 3, "string"))
+	t.AddField(schema.NewFieldBase("Compiled", value.StringType, 3, "string"))
+	t.AddField(schema.NewFieldBase("Sortlen", value.IntType, 8, "integer"))
+	t.SetColumns(schema.ShowCollationCols)
+
+	collations := schema.Collations()
+	rows := make([][]driver.Value, 0, len(collations))
+	for _, c := range collations {
+		isDefault := ""
+		if c.IsDefault() {
+			isDefault = "Yes"
+		}
+		rows = append(rows, []driver.Value{c.Name, c.Charset, int64(c.ID), isDefault, "Yes", int64(c.Sortlen)})
+	}
+	t.SetRows(rows)
+	return t, nil
+}
+
+func (m *SchemaDb) tableForCharsets() (*schema.Table, error) {
+
+	/*
+		mysql> show character set like 'utf8%';
+		+---------+---------------+--------------------+--------+
+		| Charset | Description   | Default collation  | Maxlen |
+		+---------+---------------+--------------------+--------+
+		| utf8    | UTF-8 Unicode | utf8_general_ci    |      3 |
+		| utf8mb4 | UTF-8 Unicode | utf8mb4_general_ci |      4 |
+		+---------+---------------+--------------------+--------+
+	*/
+	t := schema.NewTable("character_sets")
+	t.AddField(schema.NewFieldBase("Charset", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Description", value.StringType, 255, "string"))
+	t.AddField(schema.NewFieldBase("Default collation", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Maxlen", value.IntType, 8, "integer"))
+	t.SetColumns(schema.ShowCharsetCols)
+
+	charsets := schema.Charsets()
+	rows := make([][]driver.Value, 0, len(charsets))
+	for _, cs := range charsets {
+		rows = append(rows, []driver.Value{cs.Name, cs.Description, cs.DefaultCollation, int64(cs.Maxlen)})
+	}
+	t.SetRows(rows)
 	return t, nil
 }
 
@@ -408,7 +622,7 @@ func (m *mysqlWriter) Table(tbl *schema.Table) string {
 	w := &bytes.Buffer{}
 	//u.Infof("%s tbl=%p fields? %#v fields?%v", tbl.Name, tbl, tbl.FieldMap, len(tbl.Fields))
 	fmt.Fprintf(w, "CREATE TABLE `%s` (", tbl.Name)
-	for i, fld := range tbl.Fields {
+	for i, fld := range tbl.FieldList() {
 		if i != 0 {
 			w.WriteByte(',')
 		}
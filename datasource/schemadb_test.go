@@ -5,9 +5,11 @@ import (
 	"testing"
 
 	u "github.com/araddon/gou"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
 	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
 )
 
@@ -103,6 +105,26 @@ func TestSchemaShowStatements(t *testing.T) {
 			{"user_id", "string", "", "", "", ""},
 		},
 	)
+
+	// SHOW {INDEX | INDEXES | KEYS} FROM tbl_name [FROM db_name]
+	testutil.TestSelect(t, `show index from users;`, [][]driver.Value{})
+	users, err := td.MockSchema.Table("users")
+	assert.Equal(t, nil, err)
+	users.Indexes = []*schema.Index{
+		{Name: "pk", Fields: []string{"user_id"}, PrimaryKey: true},
+		{Name: "idx_email_date", Fields: []string{"email", "reg_date"}},
+	}
+	defer func() { users.Indexes = nil }()
+	showIndex := [][]driver.Value{
+		{"users", int64(0), "PRIMARY", int64(1), "user_id", "A", int64(3), nil, nil, "", "BTREE", "", ""},
+		{"users", int64(1), "idx_email_date", int64(1), "email", "A", nil, nil, nil, "YES", "BTREE", "", ""},
+		{"users", int64(1), "idx_email_date", int64(2), "reg_date", "A", nil, nil, nil, "YES", "BTREE", "", ""},
+	}
+	testutil.TestSelect(t, `show index from users;`, showIndex)
+	testutil.TestSelect(t, `show keys in users in mockcsv;`, showIndex)
+	testutil.TestSelect(t, `show indexes from users where Key_name = "PRIMARY";`, showIndex[:1])
+	testutil.TestSelect(t, `show index from orders;`, [][]driver.Value{})
+
 	// VARIABLES
 	testutil.TestSelect(t, `show global variables like 'max_allowed*';`,
 		[][]driver.Value{
@@ -110,6 +132,31 @@ func TestSchemaShowStatements(t *testing.T) {
 		},
 	)
 
+	// COLLATION, CHARACTER SET
+	testutil.TestSelect(t, `show collation like 'utf8mb4%';`,
+		[][]driver.Value{
+			{"utf8mb4_bin", "utf8mb4", int64(46), "", "Yes", int64(1)},
+			{"utf8mb4_general_ci", "utf8mb4", int64(45), "Yes", "Yes", int64(1)},
+			{"utf8mb4_unicode_ci", "utf8mb4", int64(224), "", "Yes", int64(8)},
+		},
+	)
+	testutil.TestSelect(t, `show collation where Id = 33;`,
+		[][]driver.Value{
+			{"utf8_general_ci", "utf8", int64(33), "Yes", "Yes", int64(1)},
+		},
+	)
+	testutil.TestSelect(t, `show character set like 'utf8%';`,
+		[][]driver.Value{
+			{"utf8", "UTF-8 Unicode", "utf8_general_ci", int64(3)},
+			{"utf8mb4", "UTF-8 Unicode", "utf8mb4_general_ci", int64(4)},
+		},
+	)
+	testutil.TestSelect(t, `show charset where Charset = "latin1";`,
+		[][]driver.Value{
+			{"latin1", "cp1252 West European", "latin1_swedish_ci", int64(1)},
+		},
+	)
+
 	// DESCRIBE
 	testutil.TestSelect(t, `describe users;`,
 		[][]driver.Value{
@@ -2,7 +2,6 @@ package datasource
 
 import (
 	"database/sql/driver"
-	"time"
 
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
@@ -15,10 +14,80 @@ const (
 )
 
 // http://dev.mysql.com/doc/refman/5.6/en/server-system-variables.html
-var mysqlGlobalVars *ContextSimple = NewMySqlGlobalVars()
+func init() {
+	str := func(name, def string, scope VarScope, values ...string) *SysVar {
+		return &SysVar{Name: name, Type: value.StringType, Default: value.NewStringValue(def), Scope: scope, Values: values}
+	}
+	num := func(name string, def int64, scope VarScope) *SysVar {
+		return &SysVar{Name: name, Type: value.IntType, Default: value.NewIntValue(def), Scope: scope}
+	}
+	boolean := func(name string, def bool, scope VarScope) *SysVar {
+		v := int64(0)
+		if def {
+			v = 1
+		}
+		return &SysVar{Name: name, Type: value.BoolType, Default: value.NewIntValue(v), Scope: scope}
+	}
+	readOnly := func(v *SysVar) *SysVar {
+		v.ReadOnly = true
+		return v
+	}
+	isolation := []string{"READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE"}
+	sqlMode := str("sql_mode", "NO_ENGINE_SUBSTITUTION", ScopeBoth, "ALLOW_INVALID_DATES",
+		"ANSI_QUOTES", "ERROR_FOR_DIVISION_BY_ZERO", "HIGH_NOT_PRECEDENCE", "IGNORE_SPACE",
+		"NO_AUTO_CREATE_USER", "NO_AUTO_VALUE_ON_ZERO", "NO_BACKSLASH_ESCAPES", "NO_DIR_IN_CREATE",
+		"NO_ENGINE_SUBSTITUTION", "NO_FIELD_OPTIONS", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS",
+		"NO_UNSIGNED_SUBTRACTION", "NO_ZERO_DATE", "NO_ZERO_IN_DATE", "ONLY_FULL_GROUP_BY",
+		"PAD_CHAR_TO_FULL_LENGTH", "PIPES_AS_CONCAT", "REAL_AS_FLOAT", "STRICT_ALL_TABLES",
+		"STRICT_TRANS_TABLES", "TRADITIONAL", "ANSI")
+	sqlMode.List = true
 
+	for _, v := range []*SysVar{
+		boolean("autocommit", true, ScopeBoth),
+		num("auto_increment_increment", 1, ScopeBoth),
+		str("character_set_client", "utf8", ScopeBoth),
+		str("character_set_connection", "utf8", ScopeBoth),
+		str("character_set_results", "utf8", ScopeBoth),
+		str("character_set_server", "utf8", ScopeBoth),
+		str("collation_connection", "utf8_general_ci", ScopeBoth),
+		str("init_connect", "", ScopeGlobal),
+		num("interactive_timeout", 28800, ScopeBoth),
+		readOnly(str("license", "MIT", ScopeGlobal)),
+		readOnly(num("lower_case_table_names", 0, ScopeGlobal)),
+		num("max_allowed_packet", MaxAllowedPacket, ScopeBoth),
+		num("max_execution_time", 0, ScopeBoth),
+		num("net_buffer_length", 16384, ScopeBoth),
+		num("net_write_timeout", 600, ScopeBoth),
+		str("null_grouping", plan.NullGroupLegacy, ScopeBoth, plan.NullGroupLegacy, plan.NullGroupStrict),
+		str("null_order", "", ScopeBoth, "", plan.NullsFirst, plan.NullsLast),
+		num("query_cache_size", 1048576, ScopeGlobal),
+		str("query_cache_type", "OFF", ScopeBoth, "OFF", "ON", "DEMAND"),
+		num("query_memory_limit", 0, ScopeBoth),
+		num("result_spool_quota", 0, ScopeBoth),
+		boolean("sample_mode", false, ScopeBoth),
+		sqlMode,
+		num("sql_select_limit", 0, ScopeBoth),
+		str("stream_row_errors", "", ScopeBoth, "", "drop", "halt", "dead_letter"),
+		readOnly(str("system_time_zone", "UTC", ScopeGlobal)),
+		str("time_zone", "SYSTEM", ScopeBoth),
+		str("tx_isolation", "REPEATABLE-READ", ScopeBoth, isolation...),
+		boolean("tx_read_only", false, ScopeBoth),
+		readOnly(str("version_comment", "DataUX (MIT), Release .0.9", ScopeGlobal)),
+		num("wait_timeout", 28800, ScopeBoth),
+	} {
+		RegisterSysVar(v)
+	}
+}
+
+// RowsForSession Variable_name, Value rows of the session variables.
 func RowsForSession(ctx *plan.Context) [][]driver.Value {
 
+	switch ses := ctx.Session.(type) {
+	case nil:
+		return GlobalVarRows()
+	case *SessionVars:
+		return ses.VarRows()
+	}
 	ses := ctx.Session.Row()
 	rows := make([][]driver.Value, 0, len(ses))
 	for k, v := range ses {
@@ -27,45 +96,19 @@ func RowsForSession(ctx *plan.Context) [][]driver.Value {
 	return rows
 }
 
+// NewMySqlSessionVars create session variables for a new connection.
 func NewMySqlSessionVars() expr.ContextReadWriter {
-	ctx := NewContextSimple()
-	ctx.Data["@@max_allowed_packet"] = value.NewIntValue(MaxAllowedPacket)
-	ctx.Data["@@session.auto_increment_increment"] = value.NewIntValue(1)
-	ctx.Data["@@session.tx_isolation"] = value.NewStringValue("REPEATABLE-READ")
-	rdr := NewNestedContextReadWriter([]expr.ContextReader{
-		ctx,
-		mysqlGlobalVars,
-	}, ctx, time.Now())
-	return rdr
+	return NewSessionVars()
 }
 
+// NewMySqlGlobalVars a context of the current global variable values.
 func NewMySqlGlobalVars() *ContextSimple {
 	ctx := NewContextSimple()
-
-	ctx.Data["@@session.auto_increment_increment"] = value.NewIntValue(1)
-	ctx.Data["auto_increment_increment"] = value.NewIntValue(1)
-	ctx.Data["@@session.tx_read_only"] = value.NewIntValue(1)
-	//ctx.Data["@@session.auto_increment_increment"] = value.NewBoolValue(true)
-	ctx.Data["@@character_set_client"] = value.NewStringValue("utf8")
-	ctx.Data["@@character_set_connection"] = value.NewStringValue("utf8")
-	ctx.Data["@@character_set_results"] = value.NewStringValue("utf8")
-	ctx.Data["@@character_set_server"] = value.NewStringValue("utf8")
-	ctx.Data["@@init_connect"] = value.NewStringValue("")
-	ctx.Data["@@interactive_timeout"] = value.NewIntValue(28800)
-	ctx.Data["@@license"] = value.NewStringValue("MIT")
-	ctx.Data["@@lower_case_table_names"] = value.NewIntValue(0)
-	ctx.Data["max_allowed_packet"] = value.NewIntValue(MaxAllowedPacket)
-	ctx.Data["@@max_allowed_packet"] = value.NewIntValue(MaxAllowedPacket)
-	ctx.Data["@@max_allowed_packets"] = value.NewIntValue(MaxAllowedPacket)
-	ctx.Data["@@net_buffer_length"] = value.NewIntValue(16384)
-	ctx.Data["@@net_write_timeout"] = value.NewIntValue(600)
-	ctx.Data["@@query_cache_size"] = value.NewIntValue(1048576)
-	ctx.Data["@@query_cache_type"] = value.NewStringValue("OFF")
-	ctx.Data["@@sql_mode"] = value.NewStringValue("NO_ENGINE_SUBSTITUTION")
-	ctx.Data["@@system_time_zone"] = value.NewStringValue("UTC")
-	ctx.Data["@@time_zone"] = value.NewStringValue("SYSTEM")
-	ctx.Data["@@tx_isolation"] = value.NewStringValue("REPEATABLE-READ")
-	ctx.Data["@@version_comment"] = value.NewStringValue("DataUX (MIT), Release .0.9")
-	ctx.Data["@@wait_timeout"] = value.NewIntValue(28800)
+	for _, row := range GlobalVarRows() {
+		name := row[0].(string)
+		v, _ := GlobalVar(name)
+		ctx.Data["@@"+name] = v
+		ctx.Data[name] = v
+	}
 	return ctx
 }
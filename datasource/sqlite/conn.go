@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"strconv"
 	"strings"
 
 	u "github.com/araddon/gou"
@@ -21,6 +22,7 @@ import (
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
 )
 
 var (
@@ -33,6 +35,9 @@ var (
 	// 	WalkSourceSelect(pl Planner, s *Source) (Task, error)
 	// }
 	_ plan.SourcePlanner = (*qryconn)(nil)
+
+	// sqlite samples natively, see rewrite.walkSample
+	_ plan.SourceTableSampler = (*qryconn)(nil)
 )
 
 type (
@@ -45,11 +50,14 @@ type (
 		source    *Source
 		tbl       *schema.Table
 		ps        *plan.Source
+		sample    *rel.SqlSample
 		indexCol  int
 		rows      *sql.Rows
 		ct        uint64
 		cols      []string
 		colidx    map[string]int
+		partial   []partialCol // aggregates read as partials, see rewritePartialAgg
+		scanCt    int          // values per sqlite row
 		err       error
 		sqlInsert string
 		sqlUpdate string
@@ -127,8 +135,8 @@ func (m *qryconn) Next() schema.Message {
 			}
 			//vals := make([]driver.Value, len(m.cols))
 			//u.Infof("expecting %d cols", len(m.cols))
-			readCols := make([]interface{}, len(m.cols))
-			writeCols := make([]driver.Value, len(m.cols))
+			readCols := make([]interface{}, m.scanCt)
+			writeCols := make([]driver.Value, m.scanCt)
 			for i := range writeCols {
 				readCols[i] = &writeCols[i]
 			}
@@ -150,6 +158,9 @@ func (m *qryconn) Next() schema.Message {
 					writeCols[i] = driver.Value(string(val))
 				}
 			}
+			if m.partial != nil {
+				writeCols = m.partialRow(writeCols)
+			}
 			msg := datasource.NewSqlDriverMessageMap(m.ct, writeCols, m.colidx)
 
 			m.ct++
@@ -161,6 +172,31 @@ func (m *qryconn) Next() schema.Message {
 	}
 }
 
+// partialRow the row of partial aggregates, plus the group key, read from
+// the sqlite row vals of a partial aggregate query.
+func (m *qryconn) partialRow(vals []driver.Value) []driver.Value {
+	row := make([]driver.Value, len(m.partial)+1)
+	for i, pc := range m.partial {
+		switch pc.fn {
+		case "count":
+			ct, _ := value.ValueToInt64(value.NewValue(vals[pc.idx]))
+			row[i] = &exec.AggPartial{Ct: ct}
+		case "sum":
+			n, _ := value.ValueToFloat64(value.NewValue(vals[pc.idx]))
+			row[i] = &exec.AggPartial{N: n}
+		case "avg":
+			n, _ := value.ValueToFloat64(value.NewValue(vals[pc.idx]))
+			ct, _ := value.ValueToInt64(value.NewValue(vals[pc.idx+1]))
+			row[i] = &exec.AggPartial{N: n, Ct: ct}
+		default:
+			row[i] = vals[pc.idx]
+		}
+	}
+	// sqlite grouped the rows, each row is a whole group so its own key
+	row[len(m.partial)] = strconv.FormatUint(m.ct, 10)
+	return row
+}
+
 // Put interface for Upsert.Put() to do single row insert based on key.
 func (m *qryconn) Put(ctx context.Context, key schema.Key, row interface{}) (schema.Key, error) {
 
@@ -233,6 +269,7 @@ func (m *qryconn) Delete(key driver.Value) (int, error) {
 func (m *qryconn) WalkSourceSelect(planner plan.Planner, p *plan.Source) (plan.Task, error) {
 
 	sqlSelect := p.Stmt.Source
+	orig := sqlSelect
 	u.Infof("original %s", sqlSelect.String())
 	p.Stmt.Source = nil
 	p.Stmt.Rewrite(sqlSelect)
@@ -242,7 +279,32 @@ func (m *qryconn) WalkSourceSelect(planner plan.Planner, p *plan.Source) (plan.T
 
 	m.cols = sqlSelect.Columns.UnAliasedFieldNames()
 	m.colidx = sqlSelect.ColIndexes()
-	sqlString, _ := newRewriter(sqlSelect).rewrite()
+	m.scanCt = len(m.cols)
+	rw := newRewriter(sqlSelect)
+	rw.sample = m.sample
+	rw.tbl = m.tbl
+	rw.nullsFirst = p.Context().NullsFirst
+	sqlString, err := rw.rewrite()
+	if err != nil {
+		// release the table lock of this conn, the query is abandoned
+		m.Close()
+		return nil, err
+	}
+	if p.Final && orig != nil && orig.IsAggQuery() {
+		// group in sqlite, only the partial aggregates are finished locally
+		if partialSql, partial := rw.rewritePartialAgg(orig); partial != nil {
+			sqlString = partialSql
+			m.partial = partial
+			m.scanCt = len(rw.result.Columns)
+			m.colidx = orig.ColIndexes()
+			p.PartialAgg = true
+		}
+	} else if p.Final && orig != nil {
+		// only the first rows in order are read from sqlite
+		if topSql, ok := rw.rewriteTopN(orig); ok {
+			sqlString = topSql
+		}
+	}
 
 	u.Infof("after sqlite-rewrite %s", sqlSelect.String())
 	u.Infof("pushdown sql: %s", sqlString)
@@ -250,6 +312,7 @@ func (m *qryconn) WalkSourceSelect(planner plan.Planner, p *plan.Source) (plan.T
 	rows, err := m.source.db.Query(sqlString)
 	if err != nil {
 		u.Errorf("could not open master err=%v", err)
+		m.Close()
 		return nil, err
 	}
 	m.rows = rows
@@ -260,6 +323,13 @@ func (m *qryconn) WalkSourceSelect(planner plan.Planner, p *plan.Source) (plan.T
 	return nil, nil
 }
 
+// TableSample the TABLESAMPLE of the table, applied in the sql pushed down
+// to sqlite.
+func (m *qryconn) TableSample(sample *rel.SqlSample) bool {
+	m.sample = sample
+	return true
+}
+
 // DeleteExpression Delete using a Where Expression
 func (m *qryconn) DeleteExpression(p interface{}, where expr.Node) (int, error) {
 
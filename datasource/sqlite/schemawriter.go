@@ -46,7 +46,7 @@ func TableToString(tbl *schema.Table) string {
 	w := &bytes.Buffer{}
 	//u.Infof("%s tbl=%p fields? %#v fields?%v", tbl.Name, tbl, tbl.FieldMap, len(tbl.Fields))
 	fmt.Fprintf(w, "CREATE TABLE `%s` (", tbl.Name)
-	for i, fld := range tbl.Fields {
+	for i, fld := range tbl.FieldList() {
 		if i != 0 {
 			w.WriteByte(',')
 		}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
 	"sync"
 	"testing"
 
@@ -16,6 +18,7 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
@@ -145,3 +148,134 @@ func TestSuite(t *testing.T) {
 	LoadTestDataOnce(t)
 	testutil.RunSimpleSuite(t)
 }
+
+func TestTableSample(t *testing.T) {
+	LoadTestDataOnce(t)
+	run := func(sql string) ([]schema.Message, error) {
+		ctx := planContext(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		err = job.Run()
+		return msgs, err
+	}
+	all, err := run(`SELECT user_id FROM users`)
+	assert.Equal(t, nil, err)
+
+	// sample is pushed down to sqlite as ORDER BY random() LIMIT n
+	msgs, err := run(`SELECT user_id FROM users TABLESAMPLE (2 ROWS)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(msgs))
+	msgs, err = run(`SELECT user_id FROM users TABLESAMPLE (100 PERCENT)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(all), len(msgs))
+	_, err = run(`SELECT user_id FROM users TABLESAMPLE (2 ROWS) WHERE user_id != "abc"`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestMatchAgainst(t *testing.T) {
+	LoadTestDataOnce(t)
+	run := func(sql string) ([]schema.Message, error) {
+		ctx := planContext(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		err = job.Run()
+		return msgs, err
+	}
+	// no native full-text search, so filtered by qlbridge
+	msgs, err := run(`SELECT user_id FROM users WHERE MATCH(email, interests) AGAINST ("fishing")`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(msgs))
+	msgs, err = run(`SELECT user_id FROM users WHERE MATCH(email) AGAINST ("bob") > 0 AND referral_count = 12`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(msgs))
+
+	// scored in the projection, after sqlite returns the raw columns
+	msgs, err = run(`SELECT user_id, MATCH(email) AGAINST ("bob") AS score FROM users`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(msgs))
+}
+
+func TestPartialAggPushdown(t *testing.T) {
+	LoadTestDataOnce(t)
+	run := func(ctx *plan.Context) ([]string, bool) {
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		partial := false
+		for _, task := range job.Plan.Children() {
+			if src, ok := task.(*plan.Source); ok {
+				partial = src.PartialAgg
+			}
+		}
+		rows := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, fmt.Sprint(msg.(*datasource.SqlDriverMessageMap).Values()))
+		}
+		sort.Strings(rows)
+		return rows, partial
+	}
+	for _, sql := range []string{
+		"SELECT user_id, count(*) AS ct, sum(price) AS total, avg(price) AS av FROM orders GROUP BY user_id",
+		"SELECT count(*) AS ct, sum(item_count) AS items FROM orders WHERE price > 30",
+		"SELECT `orders`.`user_id` AS uid, count(*) AS ct FROM orders GROUP BY `orders`.`user_id` HAVING ct > 1",
+	} {
+		// aggregated by sqlite, the same as aggregated locally over mockcsv
+		rows, partial := run(planContext(sql))
+		assert.True(t, partial, sql)
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = td.MockSchema
+		expected, _ := run(ctx)
+		assert.Equal(t, expected, rows, sql)
+	}
+
+	// expressions in aggregates are aggregated locally from raw rows
+	rows, partial := run(planContext("SELECT sum(price * 2) AS total FROM orders"))
+	assert.False(t, partial)
+	assert.Equal(t, []string{"[165]"}, rows)
+}
+
+func TestTopNPushdown(t *testing.T) {
+	LoadTestDataOnce(t)
+	run := func(ctx *plan.Context) []string {
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		rows := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, fmt.Sprint(msg.(*datasource.SqlDriverMessageMap).Values()))
+		}
+		return rows
+	}
+	for _, sql := range []string{
+		"SELECT user_id, email FROM users ORDER BY email DESC LIMIT 2",
+		"SELECT user_id FROM users ORDER BY user_id DESC LIMIT 1 OFFSET 1",
+		"SELECT user_id, email AS e FROM users ORDER BY e LIMIT 2",
+	} {
+		// ordered and limited by sqlite, the same rows as the TopN of mockcsv
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = td.MockSchema
+		assert.Equal(t, run(ctx), run(planContext(sql)), sql)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
@@ -16,7 +17,12 @@ import (
 type rewrite struct {
 	sel           *rel.SqlSelect
 	result        *rel.SqlSelect
-	needsPolyFill bool // do we request that features be polyfilled?
+	sample        *rel.SqlSample // TABLESAMPLE applied natively by sqlite
+	needsPolyFill bool           // do we request that features be polyfilled?
+	tbl           *schema.Table  // table of the select, identities pushed down must be its columns
+
+	// nullsFirst the NULL ordering of an ORDER BY column
+	nullsFirst func(col *rel.Column) bool
 }
 
 func newRewriter(stmt *rel.SqlSelect) *rewrite {
@@ -34,10 +40,20 @@ func (m *rewrite) rewrite() (string, error) {
 	var err error
 
 	if m.sel.Where != nil {
-		m.result.Where = m.sel.Where
-		m.result.Where.Expr, err = m.walkNode(m.sel.Where.Expr)
-		if err != nil {
-			return "", err
+		// MATCH AGAINST needs an fts5 virtual table in sqlite, so the
+		// filters using it are left out for qlbridge to polyfill
+		where := withoutMatchAgainst(m.sel.Where.Expr)
+		if where == nil {
+			m.needsPolyFill = true
+		} else {
+			if where != m.sel.Where.Expr {
+				m.needsPolyFill = true
+			}
+			m.result.Where = &rel.SqlWhere{Expr: where}
+			m.result.Where.Expr, err = m.walkNode(where)
+			if err != nil {
+				return "", err
+			}
 		}
 	}
 
@@ -62,9 +78,212 @@ func (m *rewrite) rewrite() (string, error) {
 		m.result.OrderBy = m.sel.OrderBy
 	}
 
+	if err = m.walkSample(); err != nil {
+		return "", err
+	}
+
 	return m.result.String(), nil
 }
 
+// partialCol a column of a select whose aggregates sqlite computes as
+// partials, read from the sqlite row at idx.
+type partialCol struct {
+	fn  string // count, sum, avg, empty for a group by value
+	idx int    // position of its value, for avg the sum then the count
+}
+
+// rewritePartialAgg rewrite the aggregate select sel, after rewrite(), to
+// group and aggregate in sqlite, returning the partials of each column so
+// qlbridge only finishes the aggregation instead of reading every row.  Nil
+// if sel uses aggregates, expressions or filters that can't be pushed down.
+//
+//    SELECT region, count(*), avg(amount) FROM orders GROUP BY region
+//
+//    => SELECT region, count(*), sum(amount), count(amount) FROM orders GROUP BY region
+//
+func (m *rewrite) rewritePartialAgg(sel *rel.SqlSelect) (string, []partialCol) {
+	if m.needsPolyFill || sel.Star || sel.Distinct || len(sel.From) != 1 {
+		return "", nil
+	}
+	from := sel.From[0]
+	groupBy := make(rel.Columns, 0, len(sel.GroupBy))
+	for _, col := range sel.GroupBy {
+		in := m.sourceIdentity(from, col.Expr)
+		if in == nil {
+			return "", nil
+		}
+		groupBy = append(groupBy, &rel.Column{Expr: in})
+	}
+	cols := make(rel.Columns, 0, len(sel.Columns)+1)
+	partial := make([]partialCol, 0, len(sel.Columns))
+	for _, col := range sel.Columns {
+		if col.Expr == nil || col.Filter != nil {
+			return "", nil
+		}
+		pc := partialCol{idx: len(cols)}
+		switch n := col.Expr.(type) {
+		case *expr.IdentityNode:
+			in := m.sourceIdentity(from, n)
+			if in == nil || !hasExpr(groupBy, in) {
+				return "", nil
+			}
+			cols = append(cols, &rel.Column{Expr: in})
+		case *expr.FuncNode:
+			pc.fn = strings.ToLower(n.Name)
+			if len(n.Args) != 1 {
+				return "", nil
+			}
+			var arg expr.Node
+			if pc.fn == "count" && n.Args[0].String() == "*" {
+				arg = n.Args[0]
+			} else if in := m.sourceIdentity(from, n.Args[0]); in != nil {
+				arg = in
+			} else {
+				return "", nil
+			}
+			switch pc.fn {
+			case "count", "sum":
+				cols = append(cols, &rel.Column{Expr: &expr.FuncNode{Name: pc.fn, Args: []expr.Node{arg}}})
+			case "avg":
+				cols = append(cols,
+					&rel.Column{Expr: &expr.FuncNode{Name: "sum", Args: []expr.Node{arg}}},
+					&rel.Column{Expr: &expr.FuncNode{Name: "count", Args: []expr.Node{arg}}})
+			default:
+				return "", nil
+			}
+		default:
+			return "", nil
+		}
+		partial = append(partial, pc)
+	}
+	m.result.Columns = cols
+	m.result.GroupBy = groupBy
+	m.result.OrderBy = nil
+	return m.result.String(), partial
+}
+
+// sourceIdentity the identity n un-qualified of the table from, nil if n
+// is not an identity of from.
+func (m *rewrite) sourceIdentity(from *rel.SqlSource, n expr.Node) *expr.IdentityNode {
+	in, ok := n.(*expr.IdentityNode)
+	if !ok || in.IsBooleanIdentity() {
+		return nil
+	}
+	left, right, hasLeft := in.LeftRight()
+	if !hasLeft {
+		right = in.Text
+	} else if !from.IsNamed(left) {
+		return nil
+	}
+	if m.tbl != nil && !m.tbl.HasField(right) {
+		// ie an alias of a select column
+		return nil
+	}
+	return &expr.IdentityNode{Text: right}
+}
+
+// rewriteTopN push the ORDER BY and LIMIT of sel down, after rewrite(), so
+// sqlite returns only the first rows in order for the TopN to order.  False
+// if rows are filtered or de-duplicated after sqlite, or ordered on more
+// than columns.
+func (m *rewrite) rewriteTopN(sel *rel.SqlSelect) (string, bool) {
+	if m.needsPolyFill || m.sample != nil || sel.Limit <= 0 || sel.Distinct ||
+		sel.IsAggQuery() || len(sel.OrderBy) == 0 || len(sel.From) != 1 {
+		return "", false
+	}
+	orderBy := make(rel.Columns, 0, len(sel.OrderBy))
+	for _, col := range sel.OrderBy {
+		in := m.sourceIdentity(sel.From[0], col.Expr)
+		if in == nil {
+			return "", false
+		}
+		// sqlite orders NULLs as the smallest value
+		if m.nullsFirst != nil && m.nullsFirst(col) != col.Asc() {
+			return "", false
+		}
+		orderBy = append(orderBy, &rel.Column{Expr: in, Order: col.Order})
+	}
+	m.result.OrderBy = orderBy
+	m.result.Limit = sel.Limit + sel.Offset
+	return m.result.String(), true
+}
+
+// hasExpr is n the expression of one of cols.
+func hasExpr(cols rel.Columns, n expr.Node) bool {
+	for _, col := range cols {
+		if col.Expr.Equal(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkSample apply the TABLESAMPLE natively, a percent sample is a filter
+// on random(), a rows sample is the first n rows in random order.
+func (m *rewrite) walkSample() error {
+	if m.sample == nil {
+		return nil
+	}
+	random := &expr.FuncNode{Name: "random"}
+	if m.sample.Rows > 0 {
+		if m.sel.Where != nil || m.sel.IsAggQuery() || len(m.sel.OrderBy) > 0 {
+			return fmt.Errorf("sqlite source only supports TABLESAMPLE (n ROWS) without WHERE, aggregates or ORDER BY")
+		}
+		m.result.OrderBy = rel.Columns{&rel.Column{Expr: random}}
+		m.result.Limit = int(m.sample.Rows)
+		return nil
+	}
+	// (abs(random()) % 1000000) < percent * 10000
+	abs := &expr.FuncNode{Name: "abs"}
+	abs.Args = []expr.Node{random}
+	mod, _ := expr.NewNumberStr("1000000")
+	threshold, _ := expr.NewNumberStr(fmt.Sprintf("%d", int64(m.sample.Percent*10000)))
+	filter := expr.NewBinaryNode(lex.Token{T: lex.TokenLT, V: "<"},
+		expr.NewBinaryNode(lex.Token{T: lex.TokenModulus, V: "%"}, abs, mod), threshold)
+	if m.result.Where == nil {
+		m.result.Where = &rel.SqlWhere{Expr: filter}
+		return nil
+	}
+	m.result.Where.Expr = expr.NewBinaryNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, m.result.Where.Expr, filter)
+	return nil
+}
+
+// withoutMatchAgainst the AND'ed terms of the filter n that don't use a
+// MATCH AGAINST full-text search, nil if there are none.
+func withoutMatchAgainst(n expr.Node) expr.Node {
+	if bn, ok := n.(*expr.BinaryNode); ok && (bn.Operator.T == lex.TokenLogicAnd || bn.Operator.T == lex.TokenAnd) {
+		l, r := withoutMatchAgainst(bn.Args[0]), withoutMatchAgainst(bn.Args[1])
+		switch {
+		case l == nil:
+			return r
+		case r == nil:
+			return l
+		case l == bn.Args[0] && r == bn.Args[1]:
+			return n
+		}
+		return expr.NewBinaryNode(bn.Operator, l, r)
+	}
+	if hasMatchAgainst(n) {
+		return nil
+	}
+	return n
+}
+
+// hasMatchAgainst does the expression use a MATCH AGAINST full-text search.
+func hasMatchAgainst(n expr.Node) bool {
+	if fn, ok := n.(*expr.FuncNode); ok && strings.ToLower(fn.Name) == expr.MatchAgainstFunc {
+		return true
+	}
+	if na, ok := n.(expr.NodeArgs); ok {
+		for _, arg := range na.ChildrenArgs() {
+			if hasMatchAgainst(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // eval() returns ( value, isOk, isIdentity )
 func (m *rewrite) eval(arg expr.Node) (value.Value, bool, bool) {
 	switch arg := arg.(type) {
@@ -0,0 +1,39 @@
+package datasource
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var (
+	statusMu   sync.RWMutex
+	statusVars = make(map[string]func() int64)
+)
+
+// RegisterStatusVar register (or replace) an engine status counter shown
+// by SHOW STATUS, fn reads its current value.
+//
+//	SHOW GLOBAL STATUS LIKE 'Memory%';
+func RegisterStatusVar(name string, fn func() int64) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statusVars[name] = fn
+}
+
+// StatusRows Variable_name, Value rows of the status counters sorted by name.
+func StatusRows() [][]driver.Value {
+	statusMu.RLock()
+	names := make([]string, 0, len(statusVars))
+	for name := range statusVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([][]driver.Value, len(names))
+	for i, name := range names {
+		rows[i] = []driver.Value{name, strconv.FormatInt(statusVars[name](), 10)}
+	}
+	statusMu.RUnlock()
+	return rows
+}
@@ -0,0 +1,386 @@
+package datasource
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// Ensure SessionVars is a read/write context for SET and expressions.
+	_ expr.ContextReadWriter = (*SessionVars)(nil)
+
+	sysVarsMu  sync.RWMutex
+	sysVars    = make(map[string]*SysVar)
+	globalVals = make(map[string]value.Value)
+)
+
+// Variable scopes.
+const (
+	// ScopeBoth variable has a global value, sessions may override.
+	ScopeBoth VarScope = iota
+	// ScopeGlobal variable is only set globally (SET GLOBAL).
+	ScopeGlobal
+	// ScopeSession variable is only set per session.
+	ScopeSession
+)
+
+type (
+	// VarScope the scope(s) a system variable may be set in.
+	VarScope uint8
+
+	// SysVar describes a system variable (@@name).
+	SysVar struct {
+		Name     string          // lower case name without @@
+		Type     value.ValueType // value.IntType, BoolType or StringType
+		Default  value.Value     // global default
+		Scope    VarScope        // scope(s) it may be set in
+		ReadOnly bool            // may not be SET
+		Values   []string        // allowed values of a string var, empty is any
+		List     bool            // value is a comma separated list of Values (sql_mode)
+		// OnGlobal optional hook called with the new (validated) global
+		// value, an error rejects the SET.
+		OnGlobal func(v value.Value) error
+	}
+
+	// SessionVars are the variables of a connection: session values of
+	// system variables (falling back to their global values), user
+	// variables (@name), and any other values written to the context.
+	//
+	//	SET @x = 5;                  user variable
+	//	SET SESSION sql_mode = '';   @@session.sql_mode
+	//	SET GLOBAL wait_timeout = 60 @@global.wait_timeout
+	SessionVars struct {
+		mu   sync.RWMutex
+		vars map[string]value.Value // session values of system vars
+		user map[string]value.Value // user variables
+		data map[string]value.Value // other values
+		ts   time.Time
+	}
+)
+
+// RegisterSysVar register (or replace) a system variable, setting its
+// global value to the Default.
+func RegisterSysVar(v *SysVar) {
+	v.Name = strings.ToLower(v.Name)
+	sysVarsMu.Lock()
+	defer sysVarsMu.Unlock()
+	sysVars[v.Name] = v
+	if v.Default != nil {
+		globalVals[v.Name] = v.Default
+	} else {
+		delete(globalVals, v.Name)
+	}
+}
+
+// LookupSysVar find system variable by name.
+func LookupSysVar(name string) (*SysVar, bool) {
+	sysVarsMu.RLock()
+	defer sysVarsMu.RUnlock()
+	v, ok := sysVars[strings.ToLower(name)]
+	return v, ok
+}
+
+// GlobalVar the global value of system variable.
+func GlobalVar(name string) (value.Value, bool) {
+	sysVarsMu.RLock()
+	defer sysVarsMu.RUnlock()
+	v, ok := globalVals[strings.ToLower(name)]
+	return v, ok
+}
+
+// SetGlobalVar validate and set the global value of system variable.
+func SetGlobalVar(name string, v value.Value) error {
+	sv, ok := LookupSysVar(name)
+	if !ok {
+		return sqlerr.New(sqlerr.ErUnknownSystemVariable, "Unknown system variable '%s'", name)
+	}
+	if sv.Scope == ScopeSession {
+		return fmt.Errorf("Variable '%s' is a SESSION variable and can't be used with SET GLOBAL", sv.Name)
+	}
+	v, err := sv.Validate(v)
+	if err != nil {
+		return err
+	}
+	if sv.OnGlobal != nil {
+		if err = sv.OnGlobal(v); err != nil {
+			return err
+		}
+	}
+	sysVarsMu.Lock()
+	globalVals[sv.Name] = v
+	sysVarsMu.Unlock()
+	return nil
+}
+
+// GlobalVarRows Variable_name, Value rows of global variables sorted by name.
+func GlobalVarRows() [][]driver.Value {
+	sysVarsMu.RLock()
+	vals := make(map[string]value.Value, len(globalVals))
+	for name, v := range globalVals {
+		if sysVars[name].Scope != ScopeSession {
+			vals[name] = v
+		}
+	}
+	sysVarsMu.RUnlock()
+	return varRows(vals)
+}
+
+// Validate check v is a valid value for this variable, converting it to
+// the variables type.
+func (m *SysVar) Validate(v value.Value) (value.Value, error) {
+	if m.ReadOnly {
+		return nil, sqlerr.New(sqlerr.ErReadOnlyVariable, "Variable '%s' is a read only variable", m.Name)
+	}
+	if v == nil || v.Nil() {
+		if m.Default == nil {
+			return value.NilValueVal, nil
+		}
+		return m.Default, nil
+	}
+	switch m.Type {
+	case value.IntType:
+		if iv, ok := value.ValueToInt64(v); ok {
+			return value.NewIntValue(iv), nil
+		}
+	case value.BoolType:
+		switch strings.ToLower(v.ToString()) {
+		case "1", "on", "true":
+			return value.NewIntValue(1), nil
+		case "0", "off", "false":
+			return value.NewIntValue(0), nil
+		}
+	default:
+		s := v.ToString()
+		if len(m.Values) == 0 {
+			return value.NewStringValue(s), nil
+		}
+		parts := []string{s}
+		if m.List {
+			parts = strings.Split(s, ",")
+			if s == "" {
+				parts = nil
+			}
+		}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			found := false
+			for _, allowed := range m.Values {
+				if strings.EqualFold(part, allowed) {
+					parts[i] = allowed
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, sqlerr.New(sqlerr.ErWrongValueForVar, "Variable '%s' can't be set to the value of '%s'", m.Name, part)
+			}
+		}
+		return value.NewStringValue(strings.Join(parts, ",")), nil
+	}
+	return nil, fmt.Errorf("Incorrect argument type to variable '%s'", m.Name)
+}
+
+// NewSessionVars create empty session variables.
+func NewSessionVars() *SessionVars {
+	return &SessionVars{
+		vars: make(map[string]value.Value),
+		user: make(map[string]value.Value),
+		data: make(map[string]value.Value),
+		ts:   time.Now(),
+	}
+}
+
+// splitVarName split a variable reference into scope and name
+//
+//	@@global.name => global, name
+//	@@session.name, @@local.name, @@name => session, name
+//	@name => user, name
+func splitVarName(key string) (scope, name string) {
+	switch {
+	case strings.HasPrefix(key, "@@"):
+		name = strings.ToLower(key[2:])
+		switch {
+		case strings.HasPrefix(name, "global."):
+			return "global", name[len("global."):]
+		case strings.HasPrefix(name, "session."):
+			return "session", name[len("session."):]
+		case strings.HasPrefix(name, "local."):
+			return "session", name[len("local."):]
+		}
+		return "session", name
+	case strings.HasPrefix(key, "@"):
+		return "user", strings.ToLower(strings.Trim(key[1:], "`'\""))
+	}
+	return "", key
+}
+
+// Get value of variable (@@name, @name), undefined user variables are NULL.
+func (m *SessionVars) Get(key string) (value.Value, bool) {
+	scope, name := splitVarName(key)
+	switch scope {
+	case "global":
+		return GlobalVar(name)
+	case "user":
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if v, ok := m.user[name]; ok {
+			return v, true
+		}
+		return value.NilValueVal, true
+	case "":
+		// bare names are not variables in expressions, they would shadow
+		// missing columns
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		v, ok := m.data[key]
+		return v, ok
+	}
+	m.mu.RLock()
+	v, ok := m.vars[name]
+	m.mu.RUnlock()
+	if ok {
+		return v, true
+	}
+	return GlobalVar(name)
+}
+
+// Put set a variable, system variables are validated.
+func (m *SessionVars) Put(col expr.SchemaInfo, rctx expr.ContextReader, v value.Value) error {
+	key := col.Key()
+	scope, name := splitVarName(key)
+	switch scope {
+	case "global":
+		return SetGlobalVar(name, v)
+	case "user":
+		m.mu.Lock()
+		m.user[name] = v
+		m.mu.Unlock()
+		return nil
+	case "":
+		if _, ok := LookupSysVar(name); !ok {
+			// not a variable, ie a writeable context for expressions
+			m.mu.Lock()
+			m.data[key] = v
+			m.mu.Unlock()
+			return nil
+		}
+		name = strings.ToLower(name)
+	}
+	return m.SetSessionVar(name, v)
+}
+
+// SetSessionVar validate and set the session value of a system variable.
+func (m *SessionVars) SetSessionVar(name string, v value.Value) error {
+	sv, ok := LookupSysVar(name)
+	if !ok {
+		return sqlerr.New(sqlerr.ErUnknownSystemVariable, "Unknown system variable '%s'", name)
+	}
+	if sv.Scope == ScopeGlobal {
+		return fmt.Errorf("Variable '%s' is a GLOBAL variable and should be set with SET GLOBAL", sv.Name)
+	}
+	v, err := sv.Validate(v)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.vars[sv.Name] = v
+	m.mu.Unlock()
+	return nil
+}
+
+// Delete remove keys, not supported for variables.
+func (m *SessionVars) Delete(row map[string]value.Value) error {
+	return nil
+}
+
+// Row all values of this session, system variables as @@name, user
+// variables as @name.
+func (m *SessionVars) Row() map[string]value.Value {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	row := make(map[string]value.Value)
+	for name, v := range m.effective() {
+		row["@@"+name] = v
+	}
+	for name, v := range m.user {
+		row["@"+name] = v
+	}
+	for k, v := range m.data {
+		row[k] = v
+	}
+	return row
+}
+
+// Ts time session was created.
+func (m *SessionVars) Ts() time.Time { return m.ts }
+
+// VarRows Variable_name, Value rows of the session values of system
+// variables, sorted by name.
+func (m *SessionVars) VarRows() [][]driver.Value {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return varRows(m.effective())
+}
+
+// UserVars copy of the user variables.
+func (m *SessionVars) UserVars() map[string]value.Value {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	vars := make(map[string]value.Value, len(m.user))
+	for k, v := range m.user {
+		vars[k] = v
+	}
+	return vars
+}
+
+// effective session values of global and session scoped system vars,
+// must hold read lock.
+func (m *SessionVars) effective() map[string]value.Value {
+	sysVarsMu.RLock()
+	vals := make(map[string]value.Value, len(globalVals))
+	for name, v := range globalVals {
+		if sysVars[name].Scope != ScopeGlobal {
+			vals[name] = v
+		}
+	}
+	sysVarsMu.RUnlock()
+	for name, v := range m.vars {
+		vals[name] = v
+	}
+	return vals
+}
+
+func varRows(vals map[string]value.Value) [][]driver.Value {
+	names := make([]string, 0, len(vals))
+	for name := range vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([][]driver.Value, len(names))
+	for i, name := range names {
+		rows[i] = []driver.Value{name, varValue(name, vals[name])}
+	}
+	return rows
+}
+
+// varValue display value of variable, boolean variables are ON/OFF.
+func varValue(name string, v value.Value) driver.Value {
+	if v == nil || v.Nil() {
+		return ""
+	}
+	if sv, ok := LookupSysVar(name); ok && sv.Type == value.BoolType {
+		if b, _ := value.ValueToBool(v); b {
+			return "ON"
+		}
+		return "OFF"
+	}
+	return v.Value()
+}
@@ -0,0 +1,59 @@
+package datasource_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+)
+
+func TestSessionVars(t *testing.T) {
+	ses := datasource.NewSessionVars()
+	put := func(name string, v value.Value) error {
+		return ses.Put(expr.SchemaInfoString(name), nil, v)
+	}
+
+	// user variables, undefined are NULL
+	v, ok := ses.Get("@x")
+	assert.True(t, ok)
+	assert.True(t, v.Nil())
+	assert.Equal(t, nil, put("@x", value.NewIntValue(5)))
+	v, _ = ses.Get("@X")
+	assert.Equal(t, int64(5), v.Value())
+
+	// session values shadow global, validated and normalized
+	assert.Equal(t, nil, put("@@session.sql_mode", value.NewStringValue("ansi_quotes, strict_all_tables")))
+	v, _ = ses.Get("@@sql_mode")
+	assert.Equal(t, "ANSI_QUOTES,STRICT_ALL_TABLES", v.ToString())
+	v, _ = ses.Get("@@global.sql_mode")
+	assert.Equal(t, "NO_ENGINE_SUBSTITUTION", v.ToString())
+	assert.NotEqual(t, nil, put("@@sql_mode", value.NewStringValue("not_a_mode")))
+	assert.Equal(t, nil, put("autocommit", value.NewStringValue("off")))
+	v, _ = ses.Get("@@autocommit")
+	assert.Equal(t, int64(0), v.Value())
+	assert.NotEqual(t, nil, put("@@wait_timeout", value.NewStringValue("abc")))
+	assert.NotEqual(t, nil, put("@@version_comment", value.NewStringValue("x")))
+	assert.NotEqual(t, nil, put("@@query_cache_size", value.NewIntValue(1)))
+
+	// bare names that aren't variables are plain context values
+	assert.Equal(t, nil, put("name", value.NewStringValue("bob")))
+	v, _ = ses.Get("name")
+	assert.Equal(t, "bob", v.ToString())
+
+	found := false
+	for _, row := range ses.VarRows() {
+		if row[0] == "autocommit" {
+			found = true
+			assert.Equal(t, "OFF", row[1])
+		}
+	}
+	assert.True(t, found)
+	for _, row := range datasource.GlobalVarRows() {
+		if row[0] == "autocommit" {
+			assert.Equal(t, "ON", row[1])
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package exec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/araddon/qlbridge/metrics"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+var (
+	// ErrAdmissionQueueFull query rejected, the admission queue is full.
+	ErrAdmissionQueueFull = sqlerr.New(sqlerr.ErConCount, "Too many queries, admission queue is full")
+	// ErrAdmissionTimeout query rejected after waiting in the admission
+	// queue for its QueueTimeout.
+	ErrAdmissionTimeout = sqlerr.New(sqlerr.ErConCount, "Too many queries, timed out waiting for admission")
+
+	admissionMu sync.RWMutex
+	admission   *Admission
+)
+
+// Admission control, limits the jobs running at once, in total and per
+// user, so a storm of queries can't overwhelm shared backends.  Queries
+// over the limits wait in a FIFO queue until a running query finishes,
+// their QueueTimeout passes or their context is cancelled.
+//
+//    exec.SetAdmission(exec.NewAdmission(20, 4))
+//
+type Admission struct {
+	MaxRunning   int           // max running jobs, 0 for no limit
+	MaxPerUser   int           // max running jobs per Context.User, 0 for no limit
+	MaxQueued    int           // max queued jobs, more are rejected, 0 for no limit
+	QueueTimeout time.Duration // max time a job waits in queue, 0 to wait forever
+
+	mu      sync.Mutex
+	running int
+	users   map[string]int
+	queue   []*admissionWaiter
+}
+
+type admissionWaiter struct {
+	user  string
+	ready chan struct{}
+}
+
+// NewAdmission an admission scheduler running at most maxRunning jobs,
+// and at most maxPerUser for any one user.
+func NewAdmission(maxRunning, maxPerUser int) *Admission {
+	return &Admission{
+		MaxRunning: maxRunning,
+		MaxPerUser: maxPerUser,
+		users:      make(map[string]int),
+	}
+}
+
+// SetAdmission the admission control of all jobs, nil to admit every job.
+func SetAdmission(a *Admission) {
+	admissionMu.Lock()
+	admission = a
+	admissionMu.Unlock()
+}
+
+// admit the job of ctx, blocking until it is admitted, returning the func
+// to call once it has finished.
+func admit(ctx *plan.Context) (func(), error) {
+	admissionMu.RLock()
+	a := admission
+	admissionMu.RUnlock()
+	if a == nil {
+		return func() {}, nil
+	}
+	return a.Acquire(ctx)
+}
+
+// Stats running and queued jobs.
+func (m *Admission) Stats() (running, queued int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running, len(m.queue)
+}
+
+// Acquire admission for the job of ctx, waiting in queue if over the
+// limits, release must be called once the job is finished.
+func (m *Admission) Acquire(ctx *plan.Context) (release func(), err error) {
+	user := ctx.User
+	m.mu.Lock()
+	// queued jobs never fit, they would have been admitted by finish
+	if m.fits(user) {
+		m.start(user)
+		m.mu.Unlock()
+		return m.releaser(user), nil
+	}
+	if m.MaxQueued > 0 && len(m.queue) >= m.MaxQueued {
+		m.mu.Unlock()
+		return nil, ErrAdmissionQueueFull
+	}
+	w := &admissionWaiter{user: user, ready: make(chan struct{})}
+	m.queue = append(m.queue, w)
+	m.report()
+	m.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if m.QueueTimeout > 0 {
+		timer := time.NewTimer(m.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var done <-chan struct{}
+	if ctx.Context != nil {
+		done = ctx.Context.Done()
+	}
+	select {
+	case <-w.ready:
+		return m.releaser(user), nil
+	case <-timeout:
+		err = ErrAdmissionTimeout
+	case <-done:
+		err = ctx.Context.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dequeue(w) {
+		// admitted while we gave up, let the next one in instead
+		m.finish(user)
+	}
+	return nil, err
+}
+
+// fits can a job of user run now, m.mu must be held.
+func (m *Admission) fits(user string) bool {
+	if m.MaxRunning > 0 && m.running >= m.MaxRunning {
+		return false
+	}
+	return m.MaxPerUser <= 0 || m.users[user] < m.MaxPerUser
+}
+
+func (m *Admission) start(user string) {
+	m.running++
+	if m.users == nil {
+		m.users = make(map[string]int)
+	}
+	m.users[user]++
+	m.report()
+}
+
+// finish a job of user, admitting the queued jobs that now fit, in order.
+// Jobs of users at their limit don't hold up the jobs of other users.
+func (m *Admission) finish(user string) {
+	m.running--
+	if m.users[user]--; m.users[user] <= 0 {
+		delete(m.users, user)
+	}
+	queue := m.queue[:0]
+	for _, w := range m.queue {
+		if m.fits(w.user) {
+			m.start(w.user)
+			close(w.ready)
+			continue
+		}
+		queue = append(queue, w)
+	}
+	m.queue = queue
+	m.report()
+}
+
+func (m *Admission) releaser(user string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			m.finish(user)
+			m.mu.Unlock()
+		})
+	}
+}
+
+// dequeue remove waiter w, false if it was already admitted.
+func (m *Admission) dequeue(w *admissionWaiter) bool {
+	for i, qw := range m.queue {
+		if qw == w {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			m.report()
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Admission) report() {
+	if metrics.Enabled() {
+		metrics.Get().Admission(m.running, len(m.queue))
+	}
+}
@@ -0,0 +1,114 @@
+package exec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/metrics"
+	"github.com/araddon/qlbridge/plan"
+)
+
+func TestAdmission(t *testing.T) {
+	userCtx := func(user string) *plan.Context {
+		ctx := plan.NewContext("SELECT 1")
+		ctx.User = user
+		return ctx
+	}
+	waitQueued := func(a *exec.Admission, n int) {
+		for i := 0; i < 200; i++ {
+			if _, queued := a.Stats(); queued == n {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expected %d queued", n)
+	}
+
+	a := exec.NewAdmission(2, 1)
+	a.QueueTimeout = time.Second
+
+	bob1, err := a.Acquire(userCtx("bob"))
+	assert.Equal(t, nil, err)
+
+	// bob is at the per user limit, queued
+	admitted := make(chan error, 1)
+	go func() {
+		release, err := a.Acquire(userCtx("bob"))
+		if err == nil {
+			defer release()
+		}
+		admitted <- err
+	}()
+	waitQueued(a, 1)
+
+	// other users are not held up by bob
+	alice, err := a.Acquire(userCtx("alice"))
+	assert.Equal(t, nil, err)
+	running, queued := a.Stats()
+	assert.Equal(t, 2, running)
+	assert.Equal(t, 1, queued)
+
+	// the second query of bob runs once the first finishes
+	bob1()
+	bob1()
+	assert.Equal(t, nil, <-admitted)
+	alice()
+
+	// full, the query times out in queue
+	a = exec.NewAdmission(1, 0)
+	a.QueueTimeout = 20 * time.Millisecond
+	first, err := a.Acquire(userCtx("bob"))
+	assert.Equal(t, nil, err)
+	_, err = a.Acquire(userCtx("alice"))
+	assert.Equal(t, exec.ErrAdmissionTimeout, err)
+	running, queued = a.Stats()
+	assert.Equal(t, 1, running)
+	assert.Equal(t, 0, queued)
+
+	// the queue is full, rejected without waiting
+	a.MaxQueued = 1
+	a.QueueTimeout = time.Second
+	go a.Acquire(userCtx("eve"))
+	waitQueued(a, 1)
+	_, err = a.Acquire(userCtx("alice"))
+	assert.Equal(t, exec.ErrAdmissionQueueFull, err)
+	first()
+	waitQueued(a, 0)
+}
+
+func TestAdmissionJobs(t *testing.T) {
+	p := metrics.NewPrometheus("qlb")
+	metrics.Set(p)
+	defer metrics.Set(nil)
+
+	a := exec.NewAdmission(1, 0)
+	a.QueueTimeout = 20 * time.Millisecond
+	exec.SetAdmission(a)
+	defer exec.SetAdmission(nil)
+
+	rows, err := runSession(t, datasource.NewContextSimple(), `SELECT user_id FROM users`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(rows))
+
+	// the only slot is taken, queries queue then time out
+	release, err := a.Acquire(plan.NewContext("SELECT 1"))
+	assert.Equal(t, nil, err)
+	_, err = runSession(t, datasource.NewContextSimple(), `SELECT user_id FROM users`)
+	assert.NotEqual(t, nil, err)
+	release()
+
+	rows, err = runSession(t, datasource.NewContextSimple(), `SELECT user_id FROM users`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(rows))
+
+	buf := &bytes.Buffer{}
+	assert.Equal(t, nil, p.Write(buf))
+	assert.True(t, strings.Contains(buf.String(), "qlb_admission_queued_queries 0"), buf.String())
+	assert.True(t, strings.Contains(buf.String(), "qlb_admission_running_queries 0"), buf.String())
+}
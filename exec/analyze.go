@@ -0,0 +1,446 @@
+package exec
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// AnalyzeMaxDistinct the distinct values per column ANALYZE TABLE counts
+	// exactly, the distinct values of columns with more are estimated with
+	// a HyperLogLog sketch.
+	AnalyzeMaxDistinct = 10000
+	// HistogramBuckets the most buckets of a column histogram, columns with
+	// at most this many distinct values get a singleton histogram, others
+	// an equi-height histogram of about this many buckets.
+	HistogramBuckets = 100
+	// AnalyzeSampleRows the size of the random sample of rows equi-height
+	// histograms and column group statistics are computed from.
+	AnalyzeSampleRows = 10000
+	// AnalyzeMinDependency the smallest functional dependency degree of a
+	// pair of columns for ANALYZE TABLE to derive statistics of them as a
+	// column group, without the table declaring it.
+	AnalyzeMinDependency = 0.8
+)
+
+// AnalyzeTable scan the rows of table computing its statistics (row count,
+// distinct values, nulls, min, max and histogram per column, and combined
+// distinct values and functional dependencies of correlated column
+// groups), and store them in the schema.DefaultStatsStore.
+//
+//    ANALYZE TABLE users, orders
+//
+func AnalyzeTable(ctx *plan.Context, name string) (*schema.TableStats, error) {
+	if ctx.Schema == nil {
+		return nil, ErrNoSchemaSelected
+	}
+	tbl, err := ctx.Schema.Table(name)
+	if err != nil || tbl == nil {
+		return nil, fmt.Errorf("could not find table %q to analyze: %v", name, err)
+	}
+	conn, err := ctx.Schema.OpenConn(tbl.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	scanner, ok := conn.(schema.ConnScanner)
+	if !ok {
+		return nil, fmt.Errorf("table %q can not be scanned to analyze", tbl.Name)
+	}
+
+	cols := tbl.Columns()
+	if len(cols) == 0 {
+		if cc, ok := conn.(schema.ConnColumns); ok {
+			cols = cc.Columns()
+		}
+	}
+	analyzers := make([]*columnAnalyzer, len(cols))
+	for i, col := range cols {
+		analyzers[i] = newColumnAnalyzer(col)
+	}
+	groups := make([]*groupAnalyzer, 0, len(tbl.ColumnGroups))
+	for _, g := range tbl.ColumnGroups {
+		if ga := newGroupAnalyzer(cols, g); ga != nil {
+			groups = append(groups, ga)
+		} else {
+			u.Warnf("analyze %q column group %v has unknown columns", tbl.Name, g)
+		}
+	}
+	sample := &rowSample{rnd: rand.New(rand.NewSource(1))}
+
+	rows := int64(0)
+	for msg := scanner.Next(); msg != nil; msg = scanner.Next() {
+		rows++
+		switch mt := msg.(type) {
+		case expr.ContextReader:
+			row := make([]value.Value, len(analyzers))
+			for i, ca := range analyzers {
+				row[i], _ = mt.Get(ca.name)
+				ca.add(row[i])
+			}
+			for _, ga := range groups {
+				ga.add(row)
+			}
+			sample.add(row)
+		default:
+			u.Warnf("analyze %q could not read %T", tbl.Name, msg)
+			return nil, fmt.Errorf("table %q messages of type %T can not be analyzed", tbl.Name, msg)
+		}
+	}
+
+	ts := &schema.TableStats{
+		Schema:   tbl.SchemaName(),
+		Table:    tbl.Name,
+		Rows:     rows,
+		Columns:  make(map[string]*schema.ColumnStats, len(analyzers)),
+		Analyzed: time.Now(),
+	}
+	for i, ca := range analyzers {
+		cs := ca.stats(sample.column(i))
+		ts.Columns[strings.ToLower(cs.Name)] = cs
+	}
+	ts.Groups = sample.groups(cols, groups, rows)
+	schema.DefaultStatsStore.SetTableStats(ts)
+	u.Debugf("analyzed %s.%s rows=%d", ts.Schema, ts.Table, rows)
+	return ts, nil
+}
+
+// columnAnalyzer accumulates the statistics of the values of a column.
+type columnAnalyzer struct {
+	name     string
+	nulls    int64
+	nonNulls int64
+	min      value.Value
+	max      value.Value
+	counts   map[string]*valueCount // nil once there are too many to count
+	hll      *HyperLogLog
+}
+
+// valueCount occurrences of a distinct value.
+type valueCount struct {
+	v  value.Value
+	ct int64
+}
+
+func newColumnAnalyzer(name string) *columnAnalyzer {
+	return &columnAnalyzer{
+		name:   name,
+		counts: make(map[string]*valueCount),
+		hll:    NewHyperLogLog(HllPrecision),
+	}
+}
+
+func (m *columnAnalyzer) add(v value.Value) {
+	if v == nil || v.Nil() {
+		m.nulls++
+		return
+	}
+	m.nonNulls++
+	key := v.ToString()
+	m.hll.Add(key)
+	if m.min == nil || compareValues(v, m.min) < 0 {
+		m.min = v
+	}
+	if m.max == nil || compareValues(v, m.max) > 0 {
+		m.max = v
+	}
+	if m.counts == nil {
+		return
+	}
+	if vc, ok := m.counts[key]; ok {
+		vc.ct++
+		return
+	}
+	if len(m.counts) >= AnalyzeMaxDistinct {
+		m.counts = nil
+		return
+	}
+	m.counts[key] = &valueCount{v: v, ct: 1}
+}
+
+// stats of the column, sample the non-null values of the sampled rows.
+func (m *columnAnalyzer) stats(sample []value.Value) *schema.ColumnStats {
+	cs := &schema.ColumnStats{Name: m.name, Nulls: m.nulls}
+	if m.nonNulls == 0 {
+		return cs
+	}
+	cs.Min, cs.Max = m.min.Value(), m.max.Value()
+	if m.counts == nil {
+		cs.Ndv = m.hll.Count()
+		cs.Histogram = equiHeight(sample)
+		return cs
+	}
+	cs.Ndv = int64(len(m.counts))
+	if len(m.counts) <= HistogramBuckets {
+		cs.Histogram = m.singleton()
+	} else {
+		cs.Histogram = equiHeight(sample)
+	}
+	return cs
+}
+
+// singleton histogram, a bucket per distinct value.
+func (m *columnAnalyzer) singleton() *schema.Histogram {
+	vals := make([]*valueCount, 0, len(m.counts))
+	for _, vc := range m.counts {
+		vals = append(vals, vc)
+	}
+	sort.Slice(vals, func(i, j int) bool { return compareValues(vals[i].v, vals[j].v) < 0 })
+	h := &schema.Histogram{Type: schema.HistogramSingleton, Buckets: make([]*schema.HistogramBucket, len(vals))}
+	for i, vc := range vals {
+		h.Buckets[i] = &schema.HistogramBucket{
+			Lower: vc.v.Value(),
+			Upper: vc.v.Value(),
+			Freq:  float64(vc.ct) / float64(m.nonNulls),
+			Ndv:   1,
+		}
+	}
+	return h
+}
+
+// equiHeight histogram of the sampled values, buckets of about the same
+// number of values.  Equal values are never split across buckets, so a
+// frequent value gets a bucket of its own (possibly larger than others).
+func equiHeight(vals []value.Value) *schema.Histogram {
+	if len(vals) == 0 {
+		return nil
+	}
+	sort.Slice(vals, func(i, j int) bool { return compareValues(vals[i], vals[j]) < 0 })
+	depth := (len(vals) + HistogramBuckets - 1) / HistogramBuckets
+	h := &schema.Histogram{Type: schema.HistogramEquiHeight}
+	for start := 0; start < len(vals); {
+		end, ndv := start+1, int64(1)
+		for end < len(vals) {
+			same := compareValues(vals[end], vals[end-1]) == 0
+			if !same && end-start >= depth {
+				break
+			}
+			if !same {
+				ndv++
+			}
+			end++
+		}
+		h.Buckets = append(h.Buckets, &schema.HistogramBucket{
+			Lower: vals[start].Value(),
+			Upper: vals[end-1].Value(),
+			Freq:  float64(end-start) / float64(len(vals)),
+			Ndv:   ndv,
+		})
+		start = end
+	}
+	return h
+}
+
+// groupAnalyzer counts the distinct combinations of the values of a column
+// group declared by the table.
+type groupAnalyzer struct {
+	cols []string
+	idx  []int
+	hll  *HyperLogLog
+}
+
+// newGroupAnalyzer for the group of columns, nil if any are not in cols.
+func newGroupAnalyzer(cols, group []string) *groupAnalyzer {
+	ga := &groupAnalyzer{hll: NewHyperLogLog(HllPrecision)}
+	for _, name := range group {
+		i := columnIndex(cols, name)
+		if i < 0 {
+			return nil
+		}
+		ga.cols = append(ga.cols, strings.ToLower(cols[i]))
+		ga.idx = append(ga.idx, i)
+	}
+	return ga
+}
+
+func (m *groupAnalyzer) add(row []value.Value) {
+	if key, ok := rowKey(row, m.idx); ok {
+		m.hll.Add(key)
+	}
+}
+
+func columnIndex(cols []string, name string) int {
+	for i, col := range cols {
+		if strings.EqualFold(col, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowKey the combined values of columns idx of row, false if any is null.
+func rowKey(row []value.Value, idx []int) (string, bool) {
+	parts := make([]string, len(idx))
+	for i, ci := range idx {
+		v := row[ci]
+		if v == nil || v.Nil() {
+			return "", false
+		}
+		parts[i] = v.ToString()
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// rowSample a reservoir sample of the rows of a table.
+type rowSample struct {
+	rows [][]value.Value
+	seen int64
+	rnd  *rand.Rand
+}
+
+func (m *rowSample) add(row []value.Value) {
+	m.seen++
+	if len(m.rows) < AnalyzeSampleRows {
+		m.rows = append(m.rows, row)
+	} else if i := m.rnd.Int63n(m.seen); i < int64(len(m.rows)) {
+		m.rows[i] = row
+	}
+}
+
+// column the non-null values of column i of the sampled rows.
+func (m *rowSample) column(i int) []value.Value {
+	vals := make([]value.Value, 0, len(m.rows))
+	for _, row := range m.rows {
+		if v := row[i]; v != nil && !v.Nil() {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+// groups statistics of the declared column groups, and of the pairs of
+// columns whose values are functionally dependent in the sample, largest
+// groups first.
+func (m *rowSample) groups(cols []string, declared []*groupAnalyzer, rows int64) []*schema.ColumnGroupStats {
+	var groups []*schema.ColumnGroupStats
+	seen := make(map[string]bool)
+	for _, ga := range declared {
+		gs := m.groupStats(ga.cols, ga.idx, rows)
+		gs.Ndv = ga.hll.Count()
+		groups = append(groups, gs)
+		seen[strings.Join(ga.cols, ",")] = true
+	}
+	for i := range cols {
+		for j := i + 1; j < len(cols); j++ {
+			idx := []int{i, j}
+			names := []string{strings.ToLower(cols[i]), strings.ToLower(cols[j])}
+			if seen[strings.Join(names, ",")] {
+				continue
+			}
+			d1, _ := m.dependency(i, j)
+			d2, _ := m.dependency(j, i)
+			if d1 < AnalyzeMinDependency && d2 < AnalyzeMinDependency {
+				continue
+			}
+			groups = append(groups, m.groupStats(names, idx, rows))
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Columns) > len(groups[j].Columns) })
+	return groups
+}
+
+// groupStats distinct combinations and functional dependencies between
+// the columns idx of the sampled rows.
+func (m *rowSample) groupStats(names []string, idx []int, rows int64) *schema.ColumnGroupStats {
+	gs := &schema.ColumnGroupStats{Columns: names}
+	counts := make(map[string]int)
+	n := 0
+	for _, row := range m.rows {
+		if key, ok := rowKey(row, idx); ok {
+			counts[key]++
+			n++
+		}
+	}
+	once := 0
+	for _, ct := range counts {
+		if ct == 1 {
+			once++
+		}
+	}
+	gs.Ndv = sampleNdv(n, len(counts), once, float64(n)*float64(rows)/float64(len(m.rows)))
+	for from := range idx {
+		for to := range idx {
+			if from == to {
+				continue
+			}
+			if degree, ok := m.dependency(idx[from], idx[to]); ok {
+				gs.Dependencies = append(gs.Dependencies, &schema.Dependency{
+					From:   names[from],
+					To:     names[to],
+					Degree: degree,
+				})
+			}
+		}
+	}
+	return gs
+}
+
+// dependency degree [0,1] to which the value of column from determines the
+// value of column to, the fraction of sampled rows sharing their from value
+// with other rows that also all share the to value.  False if no rows share
+// a from value, or either column has a single value.
+func (m *rowSample) dependency(from, to int) (float64, bool) {
+	type determined struct {
+		to         string
+		rows       int
+		consistent bool
+	}
+	groups := make(map[string]*determined)
+	tos := make(map[string]bool)
+	for _, row := range m.rows {
+		fv, tv := row[from], row[to]
+		if fv == nil || fv.Nil() || tv == nil || tv.Nil() {
+			continue
+		}
+		t := tv.ToString()
+		tos[t] = true
+		if d, ok := groups[fv.ToString()]; ok {
+			d.rows++
+			d.consistent = d.consistent && d.to == t
+			continue
+		}
+		groups[fv.ToString()] = &determined{to: t, rows: 1, consistent: true}
+	}
+	if len(groups) < 2 || len(tos) < 2 {
+		return 0, false
+	}
+	shared, consistent := 0, 0
+	for _, d := range groups {
+		if d.rows < 2 {
+			continue
+		}
+		shared += d.rows
+		if d.consistent {
+			consistent += d.rows
+		}
+	}
+	if shared == 0 {
+		return 0, false
+	}
+	return float64(consistent) / float64(shared), true
+}
+
+// sampleNdv estimated distinct values of total rows from a sample of n
+// rows having d distinct values, once of them seen only once (the Haas and
+// Stokes Duj1 estimator), exact when the sample is all the rows.
+func sampleNdv(n, d, once int, total float64) int64 {
+	if n == 0 {
+		return 0
+	}
+	if total <= float64(n) {
+		return int64(d)
+	}
+	est := float64(n) * float64(d) / (float64(n-once) + float64(once)*float64(n)/total)
+	return int64(math.Min(math.Max(est, float64(d)), total))
+}
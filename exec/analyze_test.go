@@ -0,0 +1,192 @@
+package exec_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestAnalyzeTable(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "analyze_events", `event_id,kind,user_id
+1,click,10
+2,click,11
+3,view,10
+4,click,
+5,buy,12`)
+	defer schema.DefaultStatsStore.DropTableStats(mockcsv.SchemaName, "analyze_events")
+
+	_, err := runSession(t, nil, "ANALYZE TABLE analyze_events")
+	assert.Equal(t, nil, err)
+
+	ts, ok := schema.DefaultStatsStore.TableStats(mockcsv.SchemaName, "analyze_events")
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), ts.Rows)
+	assert.Equal(t, int64(5), ts.Column("event_id").Ndv)
+	kind := ts.Column("kind")
+	assert.Equal(t, int64(3), kind.Ndv)
+	assert.Equal(t, int64(0), kind.Nulls)
+	assert.Equal(t, "buy", kind.Min)
+	assert.Equal(t, "view", kind.Max)
+	assert.Equal(t, schema.HistogramSingleton, kind.Histogram.Type)
+	assert.Equal(t, 3, len(kind.Histogram.Buckets))
+	assert.Equal(t, "click", kind.Histogram.Buckets[1].Lower)
+	assert.InDelta(t, 0.6, kind.Histogram.Buckets[1].Freq, 0.0001)
+	assert.Equal(t, int64(1), ts.Column("user_id").Nulls)
+	assert.Equal(t, int64(3), ts.Column("user_id").Ndv)
+
+	// the planner estimates from the statistics
+	tbl, err := mockcsv.Schema().Table("analyze_events")
+	assert.Equal(t, nil, err)
+	rows, ok := plan.TableStatsEstimator.TableRows(tbl)
+	assert.True(t, ok)
+	assert.Equal(t, float64(5), rows)
+	for _, tc := range []struct {
+		where string
+		sel   float64
+	}{
+		{`kind = "click"`, 0.6},
+		{`kind = "buy"`, 0.2},
+		{`kind != "click"`, 0.4},
+		{`kind IN ("buy", "view")`, 0.4},
+		{`user_id = NULL`, 0.2},
+		{`event_id = 3`, 0.2},
+	} {
+		n := expr.MustParse(tc.where)
+		sel, ok := plan.TableStatsEstimator.Selectivity(tbl, n)
+		assert.True(t, ok, tc.where)
+		assert.InDelta(t, tc.sel, sel, 0.0001, tc.where)
+	}
+	_, ok = plan.TableStatsEstimator.Selectivity(tbl, expr.MustParse(`kind LIKE "c%"`))
+	assert.False(t, ok)
+
+	// and visible in information_schema.STATISTICS
+	srows, err := runSession(t, nil, `SELECT COLUMN_NAME, TABLE_ROWS, CARDINALITY, NULLS, MIN_VALUE, MAX_VALUE
+		FROM information_schema.statistics WHERE TABLE_NAME = "analyze_events"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{
+		{"event_id", int64(5), int64(5), int64(0), "1", "5"},
+		{"kind", int64(5), int64(3), int64(0), "buy", "view"},
+		{"user_id", int64(5), int64(3), int64(1), "10", "12"},
+	}, srows)
+
+	_, err = runSession(t, nil, "ANALYZE TABLE not_a_table")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestAnalyzeHistogram(t *testing.T) {
+	// skewed, 60 of the 100 rows have amount 1, the rest are 2..41
+	rows := []string{"id,amount"}
+	for i := 0; i < 100; i++ {
+		amount := 1
+		if i >= 60 {
+			amount = i - 58
+		}
+		rows = append(rows, fmt.Sprintf("%d,%d", i, amount))
+	}
+	mockcsv.LoadTable(mockcsv.SchemaName, "analyze_skewed", strings.Join(rows, "\n"))
+	defer schema.DefaultStatsStore.DropTableStats(mockcsv.SchemaName, "analyze_skewed")
+
+	buckets := exec.HistogramBuckets
+	exec.HistogramBuckets = 10
+	defer func() { exec.HistogramBuckets = buckets }()
+
+	_, err := runSession(t, nil, "ANALYZE TABLE analyze_skewed")
+	assert.Equal(t, nil, err)
+
+	ts, ok := schema.DefaultStatsStore.TableStats(mockcsv.SchemaName, "analyze_skewed")
+	assert.True(t, ok)
+	h := ts.Column("amount").Histogram
+	assert.Equal(t, schema.HistogramEquiHeight, h.Type)
+	// the frequent value is never split across buckets
+	assert.Equal(t, 5, len(h.Buckets))
+	assert.InDelta(t, 0.6, h.Buckets[0].Freq, 0.0001)
+	assert.Equal(t, int64(1), h.Buckets[0].Ndv)
+	assert.InDelta(t, 0.1, h.Buckets[1].Freq, 0.0001)
+	assert.Equal(t, int64(10), h.Buckets[1].Ndv)
+	assert.Equal(t, schema.HistogramEquiHeight, ts.Column("id").Histogram.Type)
+
+	tbl, err := mockcsv.Schema().Table("analyze_skewed")
+	assert.Equal(t, nil, err)
+	for _, tc := range []struct {
+		where string
+		sel   float64
+	}{
+		{`amount > 1`, 0.4},
+		{`amount >= 1`, 1},
+		{`amount <= 1`, 0.6},
+		{`1 < amount`, 0.4},
+		{`amount < 7`, 0.65},
+		{`amount BETWEEN 2 AND 21`, 0.2},
+		{`amount NOT BETWEEN 2 AND 21`, 0.8},
+		{`amount > 100`, 0},
+		{`id < 50`, 0.5},
+	} {
+		n := expr.MustParse(tc.where)
+		sel, ok := plan.TableStatsEstimator.Selectivity(tbl, n)
+		assert.True(t, ok, tc.where)
+		assert.InDelta(t, tc.sel, sel, 0.02, tc.where)
+	}
+}
+
+func TestAnalyzeColumnGroups(t *testing.T) {
+	// the city determines the state, the zip is independent of both
+	rows := []string{"id,city,state,zip"}
+	for i := 0; i < 100; i++ {
+		rows = append(rows, fmt.Sprintf("%d,c%d,s%d,%d", i, i%20, (i%20)%5, i%7))
+	}
+	mockcsv.LoadTable(mockcsv.SchemaName, "analyze_addresses", strings.Join(rows, "\n"))
+	defer schema.DefaultStatsStore.DropTableStats(mockcsv.SchemaName, "analyze_addresses")
+
+	tbl, err := mockcsv.Schema().Table("analyze_addresses")
+	assert.Equal(t, nil, err)
+	tbl.ColumnGroups = [][]string{{"state", "ZIP"}}
+	defer func() { tbl.ColumnGroups = nil }()
+
+	_, err = runSession(t, nil, "ANALYZE TABLE analyze_addresses")
+	assert.Equal(t, nil, err)
+
+	ts, ok := schema.DefaultStatsStore.TableStats(mockcsv.SchemaName, "analyze_addresses")
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(ts.Groups))
+	declared, derived := ts.Groups[0], ts.Groups[1]
+	assert.Equal(t, []string{"state", "zip"}, declared.Columns)
+	assert.Equal(t, int64(35), declared.Ndv)
+	assert.Equal(t, []string{"city", "state"}, derived.Columns)
+	assert.Equal(t, int64(20), derived.Ndv)
+	for _, d := range derived.Dependencies {
+		switch d.From {
+		case "city":
+			assert.Equal(t, 1.0, d.Degree)
+		case "state":
+			assert.True(t, d.Degree < 0.5, d.Degree)
+		}
+	}
+
+	for _, tc := range []struct {
+		where string
+		sel   float64
+	}{
+		// not 0.05 * 0.2 as if independent
+		{`city = "c1" AND state = "s1"`, 0.05},
+		{`state = "s1" AND city = "c1"`, 0.05},
+		{`city = "c1" AND state = "s1" AND zip = 3`, 0.05 / 7},
+		{`(city = "c1" AND zip = 3) AND state = "s1"`, 0.05 / 7},
+		{`city = "c1" AND state = "s1" AND id > 49`, 0.025},
+	} {
+		n := expr.MustParse(tc.where)
+		sel, ok := plan.TableStatsEstimator.Selectivity(tbl, n)
+		assert.True(t, ok, tc.where)
+		assert.InDelta(t, tc.sel, sel, 0.002, tc.where)
+	}
+	// no group applies
+	_, ok = plan.TableStatsEstimator.Selectivity(tbl, expr.MustParse(`city = "c1" AND id > 49`))
+	assert.False(t, ok)
+}
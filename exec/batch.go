@@ -0,0 +1,124 @@
+package exec
+
+import (
+	"database/sql/driver"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// BatchResult is the result of one statement of a multi-statement batch.
+type BatchResult struct {
+	Sql          string           // raw sql of this statement
+	Stmt         rel.SqlStatement // the parsed statement
+	Columns      []string         // result columns, of statements returning rows
+	Rows         [][]driver.Value // result rows, of statements returning rows
+	RowsAffected int64            // rows affected, of statements not returning rows
+	RowsMatched  int64            // rows matched by the where of an update, changed or not
+	LastInsertId int64            // last insert id, of statements not returning rows
+	Warnings     []string         // non-fatal notes, ie answered from a sample
+	Err          error            // error running this statement
+}
+
+// RunBatch parse the sql of one or more semicolon separated statements
+// and run them in order, sharing the schema, session, user of ctx.
+//
+//    CREATE TABLE t (...); INSERT INTO t VALUES (...); SELECT * FROM t;
+//
+// The whole batch is parsed first, a parse error runs no statements.  A
+// failed statement stops the batch unless continueOnError, the results
+// of the statements run are returned along with the first error.
+func RunBatch(ctx *plan.Context, sql string, continueOnError bool) ([]*BatchResult, error) {
+	raws, stmts, err := rel.SplitSqlStatementsMode(sql, ctx.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	var firstErr error
+	results := make([]*BatchResult, 0, len(raws))
+	for i, raw := range raws {
+		res := runBatchStatement(connContext(ctx, raw), raw)
+		res.Stmt = stmts[i]
+		results = append(results, res)
+		if res.Err != nil {
+			if firstErr == nil {
+				firstErr = res.Err
+			}
+			if !continueOnError {
+				break
+			}
+		}
+	}
+	return results, firstErr
+}
+
+// connContext a new plan context for statement raw, with the connection
+// state (schema, session, temp tables, user) of ctx.
+func connContext(ctx *plan.Context, raw string) *plan.Context {
+	sctx := plan.NewContext(raw)
+	sctx.Context = ctx.Context
+	sctx.Conn = ctx.Conn
+	sctx.Schema = ctx.Schema
+	sctx.Temp = ctx.Temp
+	sctx.Session = ctx.Session
+	sctx.User = ctx.User
+	sctx.Funcs = ctx.Funcs
+	sctx.Dialect = ctx.Dialect
+	sctx.CardinalityEstimator = ctx.CardinalityEstimator
+	sctx.Budget = ctx.Budget
+	sctx.DisableRecover = ctx.DisableRecover
+	return sctx
+}
+
+func runBatchStatement(ctx *plan.Context, raw string) *BatchResult {
+	res := &BatchResult{Sql: raw}
+	job, err := BuildSqlJob(ctx)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer job.Close()
+
+	if _, isSelect := ctx.Stmt.(*rel.SqlSelect); !isSelect {
+		rw := NewResultExecWriter(ctx)
+		job.RootTask.Add(rw)
+		if res.Err = job.Setup(); res.Err != nil {
+			return res
+		}
+		if res.Err = job.Run(); res.Err != nil {
+			return res
+		}
+		res.RowsAffected = rw.rowsAffected
+		res.RowsMatched = rw.rowsMatched
+		res.LastInsertId = rw.lastInsertID
+		res.Warnings = ctx.Warnings
+		return res
+	}
+
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(NewResultBuffer(ctx, &msgs))
+	if res.Err = job.Setup(); res.Err != nil {
+		return res
+	}
+	if res.Err = job.Run(); res.Err != nil {
+		return res
+	}
+	if ctx.Projection != nil && ctx.Projection.Proj != nil {
+		for _, rc := range ctx.Projection.Proj.Columns {
+			if rc.As != "" {
+				res.Columns = append(res.Columns, rc.As)
+			} else {
+				res.Columns = append(res.Columns, rc.Name)
+			}
+		}
+	}
+	res.Rows = make([][]driver.Value, 0, len(msgs))
+	for _, msg := range msgs {
+		if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+			res.Rows = append(res.Rows, mm.Values())
+		}
+	}
+	res.Warnings = ctx.Warnings
+	return res
+}
@@ -0,0 +1,90 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/vm"
+)
+
+const (
+	// bloomBitsPerKey, bloomHashes size join key bloom filters for ~1% false
+	// positives.
+	bloomBitsPerKey = 10
+	bloomHashes     = 7
+)
+
+// bloomOfKeys bloom filter of the keys of the hashed rows of a join side,
+// pushed to the probe side scan so rows that cannot join are not read.
+func bloomOfKeys(h map[driver.Value][]*datasource.SqlDriverMessageMap) *datasource.BloomFilter {
+	bf := datasource.NewBloomFilter(len(h)*bloomBitsPerKey, bloomHashes)
+	for key := range h {
+		if ks, ok := key.(string); ok {
+			bf.Add(ks)
+		}
+	}
+	return bf
+}
+
+// joinKeyFilter hands the key filter of the build side of a join, once it
+// has been read, to the source of the probe side.  The filter is nil if
+// the build side could not be read.
+type joinKeyFilter struct {
+	once  sync.Once
+	ready chan struct{}
+	f     schema.KeyFilter
+}
+
+func newJoinKeyFilter() *joinKeyFilter {
+	return &joinKeyFilter{ready: make(chan struct{})}
+}
+
+// set the filter, only the first call has effect.
+func (m *joinKeyFilter) set(f schema.KeyFilter) {
+	m.once.Do(func() {
+		m.f = f
+		close(m.ready)
+	})
+}
+
+// joinKeyOf the composite join key of msg, the string values of each of
+// the join nodes NUL separated.
+func joinKeyOf(msg expr.ContextReader, nodes []expr.Node) (string, bool) {
+	vals := make([]string, len(nodes))
+	for i, node := range nodes {
+		joinVal, ok := vm.Eval(msg, node)
+		if !ok {
+			return "", false
+		}
+		vals[i] = joinVal.ToString()
+	}
+	return strings.Join(vals, string(byte(0))), true
+}
+
+// findSource the source task of plan p in task t.
+func findSource(t Task, p *plan.Source) *Source {
+	switch tt := t.(type) {
+	case *Source:
+		if tt.p == p {
+			return tt
+		}
+	case *TaskSequential:
+		for _, child := range tt.tasks {
+			if s := findSource(child, p); s != nil {
+				return s
+			}
+		}
+	case *TaskParallel:
+		for _, child := range tt.tasks {
+			if s := findSource(child, p); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+var (
+	// ErrBudgetRows query returned more rows than the client budget allows.
+	ErrBudgetRows = sqlerr.New(sqlerr.ErQueryInterrupted, "Query execution was interrupted, client max rows budget exceeded")
+	// ErrBudgetBytes query returned more bytes than the client budget allows.
+	ErrBudgetBytes = sqlerr.New(sqlerr.ErQueryInterrupted, "Query execution was interrupted, client max bytes budget exceeded")
+)
+
+// budgetCounter enforces the row, byte limits of the client budget of a
+// query on the rows returned to the client.
+type budgetCounter struct {
+	ctx   *plan.Context
+	start time.Time
+	rows  int64
+	bytes int64
+}
+
+func newBudgetCounter(ctx *plan.Context) *budgetCounter {
+	return &budgetCounter{ctx: ctx, start: time.Now()}
+}
+
+// add a row, error if it exceeds the budget.
+func (m *budgetCounter) add(row []driver.Value) error {
+	if m == nil || m.ctx == nil || m.ctx.Budget == nil {
+		return nil
+	}
+	b := m.ctx.Budget
+	m.rows++
+	if b.MaxRows > 0 && m.rows > b.MaxRows {
+		return ErrBudgetRows
+	}
+	if b.MaxBytes > 0 {
+		for _, v := range row {
+			m.bytes += valueSize(v)
+		}
+		if m.bytes > b.MaxBytes {
+			return ErrBudgetBytes
+		}
+	}
+	return nil
+}
+
+// timedOut is the query past its deadline.
+func (m *budgetCounter) timedOut() bool {
+	if m == nil || m.ctx == nil {
+		return false
+	}
+	deadline, ok := m.ctx.QueryDeadline(m.start)
+	return ok && !time.Now().Before(deadline)
+}
+
+// valueSize approximate size in bytes of a row value.
+func valueSize(v driver.Value) int64 {
+	switch vt := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(vt))
+	case []byte:
+		return int64(len(vt))
+	case bool:
+		return 1
+	case int64, float64, time.Time:
+		return 8
+	}
+	return int64(len(fmt.Sprint(v)))
+}
@@ -0,0 +1,698 @@
+// Package columnar encodes result rows in columnar formats, Arrow IPC and
+// Parquet, for bulk consumers that don't want to read row by row.
+package columnar
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/araddon/qlbridge/value"
+)
+
+// Minimal Arrow IPC support, the flatbuffer encoded Schema and RecordBatch
+// messages for the handful of column types qlbridge produces.
+//   https://arrow.apache.org/docs/format/Columnar.html#serialization-and-interprocess-communication-ipc
+//   https://github.com/apache/arrow/blob/main/format/Schema.fbs
+//   https://github.com/apache/arrow/blob/main/format/Message.fbs
+
+const (
+	metadataV5 = 4
+
+	// MessageHeader union
+	msgSchema      byte = 1
+	msgRecordBatch byte = 3
+
+	// Type union
+	ArrowInt       ArrowType = 2
+	ArrowFloat     ArrowType = 3
+	ArrowBinary    ArrowType = 4
+	ArrowUtf8      ArrowType = 5
+	ArrowBool      ArrowType = 6
+	ArrowTimestamp ArrowType = 10
+
+	precisionDouble = 2
+	unitMillisecond = 1
+
+	ipcContinuation = 0xffffffff
+)
+
+// ArrowType the arrow type of a column.
+type ArrowType byte
+
+// Field a schema field (column), name type and nullability.
+type Field struct {
+	Name     string
+	Type     ArrowType
+	Nullable bool
+}
+
+// ArrowTypeOf the arrow column type for a qlbridge value type, anything
+// we don't have a native type for (json, maps, slices) is sent as text.
+func ArrowTypeOf(vt value.ValueType) ArrowType {
+	switch vt {
+	case value.IntType:
+		return ArrowInt
+	case value.NumberType:
+		return ArrowFloat
+	case value.BoolType:
+		return ArrowBool
+	case value.TimeType:
+		return ArrowTimestamp
+	case value.ByteSliceType:
+		return ArrowBinary
+	}
+	return ArrowUtf8
+}
+
+// SchemaMessage flatbuffer Message with Schema header.
+func SchemaMessage(fields []*Field) []byte {
+	fv := make(fbVector, len(fields))
+	for i, f := range fields {
+		ft := &fbTable{}
+		ft.offset(0, fbString(f.Name))
+		ft.scalar(1, fbBool(f.Nullable))
+		ft.scalar(2, []byte{byte(f.Type)})
+		ft.offset(3, typeTable(f.Type))
+		ft.offset(5, fbVector{}) // children, required even if empty
+		fv[i] = ft
+	}
+	st := &fbTable{}
+	st.scalar(0, fbInt16(0)) // little endian
+	st.offset(1, fv)
+
+	msg := &fbTable{}
+	msg.scalar(0, fbInt16(metadataV5))
+	msg.scalar(1, []byte{msgSchema})
+	msg.offset(2, st)
+	msg.scalar(3, fbInt64(0))
+	return fbFinish(msg)
+}
+
+func typeTable(typ ArrowType) *fbTable {
+	t := &fbTable{}
+	switch typ {
+	case ArrowInt:
+		t.scalar(0, fbInt32(64))
+		t.scalar(1, fbBool(true))
+	case ArrowFloat:
+		t.scalar(0, fbInt16(precisionDouble))
+	case ArrowTimestamp:
+		t.scalar(0, fbInt16(unitMillisecond))
+		t.offset(1, fbString("UTC"))
+	}
+	return t
+}
+
+// Encapsulate a message as an ipc stream message, ie the schema bytes
+// of FlightInfo and SchemaResult.
+func Encapsulate(meta []byte) []byte {
+	for len(meta)%8 != 0 {
+		meta = append(meta, 0)
+	}
+	buf := make([]byte, 8, 8+len(meta))
+	binary.LittleEndian.PutUint32(buf, ipcContinuation)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(meta)))
+	return append(buf, meta...)
+}
+
+// RecordBatch flatbuffer Message with RecordBatch header, and the body
+// holding column buffers.  Values that can't be converted to the column
+// type are null.
+func RecordBatch(fields []*Field, rows [][]driver.Value) ([]byte, []byte) {
+	n := len(rows)
+	var body []byte
+	var nodes, buffers []byte
+	addBuffer := func(b []byte) {
+		buffers = append(buffers, fbInt64(int64(len(body)))...)
+		buffers = append(buffers, fbInt64(int64(len(b)))...)
+		body = append(body, b...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for col, f := range fields {
+		validity := make([]byte, (n+7)/8)
+		nulls := 0
+		setValid := func(i int) { validity[i/8] |= 1 << uint(i%8) }
+		switch f.Type {
+		case ArrowInt, ArrowFloat, ArrowTimestamp:
+			vals := make([]byte, 8*n)
+			for i, row := range rows {
+				bits, ok := fixedValue(f.Type, row[col])
+				if !ok {
+					nulls++
+					continue
+				}
+				setValid(i)
+				binary.LittleEndian.PutUint64(vals[8*i:], bits)
+			}
+			addBuffer(validity)
+			addBuffer(vals)
+		case ArrowBool:
+			vals := make([]byte, (n+7)/8)
+			for i, row := range rows {
+				if row[col] == nil {
+					nulls++
+					continue
+				}
+				b, ok := value.ValueToBool(value.NewValue(row[col]))
+				if !ok {
+					nulls++
+					continue
+				}
+				setValid(i)
+				if b {
+					vals[i/8] |= 1 << uint(i%8)
+				}
+			}
+			addBuffer(validity)
+			addBuffer(vals)
+		default:
+			offsets := make([]byte, 4*(n+1))
+			var data []byte
+			for i, row := range rows {
+				if row[col] == nil {
+					nulls++
+				} else {
+					setValid(i)
+					if f.Type == ArrowBinary {
+						data = append(data, bytesValue(row[col])...)
+					} else {
+						data = append(data, textValue(row[col])...)
+					}
+				}
+				binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+			}
+			addBuffer(validity)
+			addBuffer(offsets)
+			addBuffer(data)
+		}
+		nodes = append(nodes, fbInt64(int64(n))...)
+		nodes = append(nodes, fbInt64(int64(nulls))...)
+	}
+
+	rb := &fbTable{}
+	rb.scalar(0, fbInt64(int64(n)))
+	rb.offset(1, fbStructs(nodes))
+	rb.offset(2, fbStructs(buffers))
+
+	msg := &fbTable{}
+	msg.scalar(0, fbInt16(metadataV5))
+	msg.scalar(1, []byte{msgRecordBatch})
+	msg.offset(2, rb)
+	msg.scalar(3, fbInt64(int64(len(body))))
+	return fbFinish(msg), body
+}
+
+// fixedValue the 64 bit little endian bits of v as int64, float64 or
+// timestamp (ms).
+func fixedValue(typ ArrowType, v driver.Value) (uint64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	switch typ {
+	case ArrowInt:
+		n, ok := value.ValueToInt64(value.NewValue(v))
+		return uint64(n), ok
+	case ArrowFloat:
+		f, ok := value.ValueToFloat64(value.NewValue(v))
+		return math.Float64bits(f), ok
+	}
+	t, ok := value.ValueToTime(value.NewValue(v))
+	if !ok {
+		return 0, false
+	}
+	return uint64(t.UnixNano() / int64(time.Millisecond)), true
+}
+
+func textValue(v driver.Value) string {
+	switch vt := v.(type) {
+	case string:
+		return vt
+	case []byte:
+		return string(vt)
+	case int64:
+		return strconv.FormatInt(vt, 10)
+	case int:
+		return strconv.Itoa(vt)
+	case float64:
+		return strconv.FormatFloat(vt, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(vt)
+	case time.Time:
+		return vt.Format(time.RFC3339Nano)
+	}
+	by, err := json.Marshal(v)
+	if err != nil {
+		return value.NewValue(v).ToString()
+	}
+	return string(by)
+}
+
+func bytesValue(v driver.Value) []byte {
+	if by, ok := v.([]byte); ok {
+		return by
+	}
+	return []byte(textValue(v))
+}
+
+// readMessage the header type, and header table of a flatbuffer Message.
+func readMessage(meta []byte) (typ byte, hdr fbTbl, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("QLBridge.columnar: invalid arrow message: %v", r)
+		}
+	}()
+	msg := fbRoot(meta)
+	typ = msg.uint8(1)
+	hdr, ok := msg.table(2)
+	if !ok {
+		return 0, hdr, fmt.Errorf("QLBridge.columnar: arrow message has no header")
+	}
+	return typ, hdr, nil
+}
+
+// ReadSchema the fields of an ipc message, either a raw flatbuffer
+// Message or encapsulated with continuation/length prefix.
+func ReadSchema(meta []byte) (fields []*Field, err error) {
+	if len(meta) >= 8 && binary.LittleEndian.Uint32(meta) == ipcContinuation {
+		meta = meta[8:]
+	}
+	typ, st, err := readMessage(meta)
+	if err != nil {
+		return nil, err
+	}
+	if typ != msgSchema {
+		return nil, fmt.Errorf("QLBridge.columnar: expected schema message got %d", typ)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("QLBridge.columnar: invalid arrow schema: %v", r)
+		}
+	}()
+	start, n := st.vector(1)
+	fields = make([]*Field, n)
+	for i := 0; i < n; i++ {
+		ft := st.vectorTable(start, i)
+		fields[i] = &Field{Name: ft.string(0), Nullable: ft.uint8(1) == 1, Type: ArrowType(ft.uint8(2))}
+		switch fields[i].Type {
+		case ArrowInt:
+			if tt, ok := ft.table(3); ok && tt.int32(0) != 64 {
+				return nil, fmt.Errorf("QLBridge.columnar: unsupported int width %d", tt.int32(0))
+			}
+		case ArrowTimestamp:
+			if tt, ok := ft.table(3); ok && tt.int16(0) != unitMillisecond {
+				return nil, fmt.Errorf("QLBridge.columnar: unsupported timestamp unit %d", tt.int16(0))
+			}
+		case ArrowFloat, ArrowUtf8, ArrowBinary, ArrowBool:
+		default:
+			return nil, fmt.Errorf("QLBridge.columnar: unsupported arrow type %d", fields[i].Type)
+		}
+	}
+	return fields, nil
+}
+
+// ReadRecordBatch decode rows of a RecordBatch message for given schema.
+func ReadRecordBatch(fields []*Field, meta, body []byte) (rows [][]driver.Value, err error) {
+	typ, rb, err := readMessage(meta)
+	if err != nil {
+		return nil, err
+	}
+	if typ != msgRecordBatch {
+		return nil, fmt.Errorf("QLBridge.columnar: expected record batch message got %d", typ)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("QLBridge.columnar: invalid arrow record batch: %v", r)
+		}
+	}()
+	n := int(rb.int64(0))
+	bufStart, bufCt := rb.vector(2)
+	buffer := func(i int) []byte {
+		if i >= bufCt {
+			panic("missing buffer")
+		}
+		off := int(binary.LittleEndian.Uint64(rb.buf[bufStart+16*i:]))
+		ln := int(binary.LittleEndian.Uint64(rb.buf[bufStart+16*i+8:]))
+		return body[off : off+ln]
+	}
+	isSet := func(bitmap []byte, i int) bool {
+		return len(bitmap) == 0 || bitmap[i/8]&(1<<uint(i%8)) != 0
+	}
+	rows = make([][]driver.Value, n)
+	for i := range rows {
+		rows[i] = make([]driver.Value, len(fields))
+	}
+	bi := 0
+	for col, f := range fields {
+		validity := buffer(bi)
+		switch f.Type {
+		case ArrowInt, ArrowFloat, ArrowTimestamp:
+			vals := buffer(bi + 1)
+			bi += 2
+			for i := 0; i < n; i++ {
+				if !isSet(validity, i) {
+					continue
+				}
+				bits := binary.LittleEndian.Uint64(vals[8*i:])
+				switch f.Type {
+				case ArrowInt:
+					rows[i][col] = int64(bits)
+				case ArrowFloat:
+					rows[i][col] = math.Float64frombits(bits)
+				default:
+					rows[i][col] = time.Unix(0, int64(bits)*int64(time.Millisecond)).UTC()
+				}
+			}
+		case ArrowBool:
+			vals := buffer(bi + 1)
+			bi += 2
+			for i := 0; i < n; i++ {
+				if isSet(validity, i) {
+					rows[i][col] = isSet(vals, i)
+				}
+			}
+		default:
+			offsets, data := buffer(bi+1), buffer(bi+2)
+			bi += 3
+			for i := 0; i < n; i++ {
+				if !isSet(validity, i) {
+					continue
+				}
+				s := binary.LittleEndian.Uint32(offsets[4*i:])
+				e := binary.LittleEndian.Uint32(offsets[4*(i+1):])
+				if f.Type == ArrowBinary {
+					rows[i][col] = append([]byte(nil), data[s:e]...)
+				} else {
+					rows[i][col] = string(data[s:e])
+				}
+			}
+		}
+	}
+	return rows, nil
+}
+
+// WriteArrow write rows as an Arrow IPC stream, the schema message, one
+// record batch and the end of stream marker.
+func WriteArrow(w io.Writer, fields []*Field, rows [][]driver.Value) error {
+	if _, err := w.Write(Encapsulate(SchemaMessage(fields))); err != nil {
+		return err
+	}
+	meta, body := RecordBatch(fields, rows)
+	if _, err := w.Write(Encapsulate(meta)); err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	eos := make([]byte, 8)
+	binary.LittleEndian.PutUint32(eos, ipcContinuation)
+	_, err := w.Write(eos)
+	return err
+}
+
+// ReadArrow read an Arrow IPC stream, as written by WriteArrow, into its
+// schema fields and the rows of all its record batches.
+func ReadArrow(data []byte) ([]*Field, [][]driver.Value, error) {
+	next := func() ([]byte, error) {
+		if len(data) < 8 || binary.LittleEndian.Uint32(data) != ipcContinuation {
+			return nil, fmt.Errorf("QLBridge.columnar: invalid arrow stream")
+		}
+		n := int(binary.LittleEndian.Uint32(data[4:]))
+		if n == 0 {
+			return nil, io.EOF
+		}
+		if len(data) < 8+n {
+			return nil, fmt.Errorf("QLBridge.columnar: truncated arrow stream")
+		}
+		meta := data[8 : 8+n]
+		data = data[8+n:]
+		return meta, nil
+	}
+	meta, err := next()
+	if err != nil {
+		return nil, nil, err
+	}
+	fields, err := ReadSchema(meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows [][]driver.Value
+	for {
+		meta, err = next()
+		if err == io.EOF {
+			return fields, rows, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, _, err = readMessage(meta); err != nil {
+			return nil, nil, err
+		}
+		bodyLen := int(fbRoot(meta).int64(3))
+		if bodyLen > len(data) {
+			return nil, nil, fmt.Errorf("QLBridge.columnar: truncated arrow stream")
+		}
+		batch, err := ReadRecordBatch(fields, meta, data[:bodyLen])
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, batch...)
+		data = data[bodyLen:]
+	}
+}
+
+// Flatbuffers, just enough of a builder and reader for the arrow messages.
+// The builder lays out front-to-back, tables before the objects they
+// reference so all offsets are forward (unsigned) as required.
+//   https://flatbuffers.dev/flatbuffers_internals.html
+
+type (
+	// fbNode is one of *fbTable, fbString, fbVector, fbStructs
+	fbNode interface{}
+	// fbTable a table being built, its fields by slot id.
+	fbTable struct {
+		slots []fbSlot
+	}
+	fbSlot struct {
+		id    int
+		data  []byte // scalar value, little endian
+		child fbNode // or offset to child object
+	}
+	fbString string
+	// fbVector vector of tables, strings
+	fbVector []fbNode
+	// fbStructs vector of 16 byte (2 x int64) structs
+	fbStructs []byte
+)
+
+func (m *fbTable) scalar(id int, data []byte) {
+	m.slots = append(m.slots, fbSlot{id: id, data: data})
+}
+func (m *fbTable) offset(id int, child fbNode) {
+	m.slots = append(m.slots, fbSlot{id: id, child: child})
+}
+
+func fbBool(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+func fbInt16(n int16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, uint16(n))
+	return b
+}
+func fbInt32(n int32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(n))
+	return b
+}
+func fbInt64(n int64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(n))
+	return b
+}
+
+type fbWriter struct {
+	buf []byte
+}
+
+// fbFinish serialize root table, padded to 8 bytes.
+func fbFinish(root *fbTable) []byte {
+	w := &fbWriter{buf: make([]byte, 4)}
+	pos := w.table(root)
+	binary.LittleEndian.PutUint32(w.buf, uint32(pos))
+	w.pad(8)
+	return w.buf
+}
+
+func (w *fbWriter) pad(align int) {
+	for len(w.buf)%align != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *fbWriter) write(n fbNode) int {
+	switch n := n.(type) {
+	case *fbTable:
+		return w.table(n)
+	case fbString:
+		w.pad(4)
+		pos := len(w.buf)
+		w.buf = append(w.buf, fbInt32(int32(len(n)))...)
+		w.buf = append(w.buf, n...)
+		w.buf = append(w.buf, 0)
+		return pos
+	case fbVector:
+		w.pad(4)
+		pos := len(w.buf)
+		w.buf = append(w.buf, fbInt32(int32(len(n)))...)
+		w.buf = append(w.buf, make([]byte, 4*len(n))...)
+		for i, child := range n {
+			at := pos + 4 + 4*i
+			// write may grow buf, evaluate before indexing it
+			cpos := w.write(child)
+			binary.LittleEndian.PutUint32(w.buf[at:], uint32(cpos-at))
+		}
+		return pos
+	case fbStructs:
+		// elements are 8 byte aligned, the length prefix precedes them
+		for (len(w.buf)+4)%8 != 0 {
+			w.buf = append(w.buf, 0)
+		}
+		pos := len(w.buf)
+		w.buf = append(w.buf, fbInt32(int32(len(n)/16))...)
+		w.buf = append(w.buf, n...)
+		return pos
+	}
+	panic(fmt.Sprintf("unknown flatbuffer node %T", n))
+}
+
+func (w *fbWriter) table(t *fbTable) int {
+	maxID, align := -1, 4
+	for _, s := range t.slots {
+		if s.id > maxID {
+			maxID = s.id
+		}
+		if len(s.data) == 8 {
+			align = 8
+		}
+	}
+	w.pad(2)
+	vt := len(w.buf)
+	vtSize := 4 + 2*(maxID+1)
+	w.buf = append(w.buf, make([]byte, vtSize)...)
+	binary.LittleEndian.PutUint16(w.buf[vt:], uint16(vtSize))
+
+	w.pad(align)
+	pos := len(w.buf)
+	w.buf = append(w.buf, fbInt32(int32(pos-vt))...)
+	type patch struct {
+		at    int
+		child fbNode
+	}
+	var patches []patch
+	for _, s := range t.slots {
+		size := len(s.data)
+		if s.child != nil {
+			size = 4
+		}
+		w.pad(size)
+		binary.LittleEndian.PutUint16(w.buf[vt+4+2*s.id:], uint16(len(w.buf)-pos))
+		if s.child != nil {
+			patches = append(patches, patch{len(w.buf), s.child})
+			w.buf = append(w.buf, 0, 0, 0, 0)
+		} else {
+			w.buf = append(w.buf, s.data...)
+		}
+	}
+	binary.LittleEndian.PutUint16(w.buf[vt+2:], uint16(len(w.buf)-pos))
+	for _, p := range patches {
+		cpos := w.write(p.child)
+		binary.LittleEndian.PutUint32(w.buf[p.at:], uint32(cpos-p.at))
+	}
+	return pos
+}
+
+// fbTbl a table being read.
+type fbTbl struct {
+	buf []byte
+	pos int
+}
+
+func fbRoot(buf []byte) fbTbl {
+	return fbTbl{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+// slot absolute position of field id, 0 if not present.
+func (m fbTbl) slot(id int) int {
+	vt := m.pos - int(int32(binary.LittleEndian.Uint32(m.buf[m.pos:])))
+	vtSize := int(binary.LittleEndian.Uint16(m.buf[vt:]))
+	if 4+2*id+2 > vtSize {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(m.buf[vt+4+2*id:]))
+	if off == 0 {
+		return 0
+	}
+	return m.pos + off
+}
+func (m fbTbl) uint8(id int) byte {
+	if p := m.slot(id); p > 0 {
+		return m.buf[p]
+	}
+	return 0
+}
+func (m fbTbl) int16(id int) int16 {
+	if p := m.slot(id); p > 0 {
+		return int16(binary.LittleEndian.Uint16(m.buf[p:]))
+	}
+	return 0
+}
+func (m fbTbl) int32(id int) int32 {
+	if p := m.slot(id); p > 0 {
+		return int32(binary.LittleEndian.Uint32(m.buf[p:]))
+	}
+	return 0
+}
+func (m fbTbl) int64(id int) int64 {
+	if p := m.slot(id); p > 0 {
+		return int64(binary.LittleEndian.Uint64(m.buf[p:]))
+	}
+	return 0
+}
+func (m fbTbl) deref(p int) int {
+	return p + int(binary.LittleEndian.Uint32(m.buf[p:]))
+}
+func (m fbTbl) table(id int) (fbTbl, bool) {
+	p := m.slot(id)
+	if p == 0 {
+		return fbTbl{}, false
+	}
+	return fbTbl{buf: m.buf, pos: m.deref(p)}, true
+}
+
+// vector start of elements, and length.
+func (m fbTbl) vector(id int) (int, int) {
+	p := m.slot(id)
+	if p == 0 {
+		return 0, 0
+	}
+	vp := m.deref(p)
+	return vp + 4, int(binary.LittleEndian.Uint32(m.buf[vp:]))
+}
+func (m fbTbl) vectorTable(start, i int) fbTbl {
+	return fbTbl{buf: m.buf, pos: m.deref(start + 4*i)}
+}
+func (m fbTbl) string(id int) string {
+	start, n := m.vector(id)
+	return string(m.buf[start : start+n])
+}
@@ -0,0 +1,64 @@
+package columnar_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/exec/columnar"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	testFields = []*columnar.Field{
+		{Name: "id", Type: columnar.ArrowTypeOf(value.IntType), Nullable: true},
+		{Name: "name", Type: columnar.ArrowTypeOf(value.StringType), Nullable: true},
+		{Name: "score", Type: columnar.ArrowTypeOf(value.NumberType), Nullable: true},
+		{Name: "ok", Type: columnar.ArrowTypeOf(value.BoolType), Nullable: true},
+		{Name: "created", Type: columnar.ArrowTypeOf(value.TimeType), Nullable: true},
+	}
+	created  = time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	testRows = [][]driver.Value{
+		{int64(1), "aaron", 1.5, true, created},
+		{int64(2), nil, nil, false, nil},
+		{"not-an-int", "bob", 3.25, nil, created},
+	}
+)
+
+func TestArrowRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, nil, columnar.WriteArrow(&buf, testFields, testRows))
+
+	fields, rows, err := columnar.ReadArrow(buf.Bytes())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(testFields), len(fields))
+	for i, f := range fields {
+		assert.Equal(t, *testFields[i], *f)
+	}
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, []driver.Value{int64(1), "aaron", 1.5, true, created}, rows[0])
+	assert.Equal(t, []driver.Value{int64(2), nil, nil, false, nil}, rows[1])
+	// values that don't convert to the column type are null
+	assert.Equal(t, []driver.Value{nil, "bob", 3.25, nil, created}, rows[2])
+
+	_, _, err = columnar.ReadArrow(buf.Bytes()[:20])
+	assert.NotEqual(t, nil, err)
+}
+
+func TestParquet(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, nil, columnar.WriteParquet(&buf, testFields, testRows))
+	data := buf.Bytes()
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	assert.True(t, footer > 0 && footer < len(data)-12, "footer len %d", footer)
+	meta := data[len(data)-8-footer : len(data)-8]
+	for _, f := range testFields {
+		assert.True(t, bytes.Contains(meta, []byte(f.Name)), "has column %q", f.Name)
+	}
+}
@@ -0,0 +1,265 @@
+package columnar
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"io"
+
+	"github.com/araddon/qlbridge/value"
+)
+
+// Minimal Parquet writer, a single row group of one uncompressed PLAIN
+// encoded data page per column, all columns optional.  The page headers
+// and file metadata are thrift compact protocol encoded.
+//   https://github.com/apache/parquet-format
+//   https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+//   https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+
+const (
+	parquetMagic = "PAR1"
+
+	// Type
+	pqBoolean   = 0
+	pqInt64     = 2
+	pqDouble    = 5
+	pqByteArray = 6
+
+	// ConvertedType
+	pqUtf8            = 0
+	pqTimestampMillis = 9
+
+	pqOptional     = 1 // FieldRepetitionType
+	pqPlain        = 0 // Encoding
+	pqRle          = 3 // Encoding
+	pqUncompressed = 0 // CompressionCodec
+	pqDataPage     = 0 // PageType
+
+	// thrift compact protocol types
+	tI32    = 5
+	tI64    = 6
+	tBinary = 8
+	tList   = 9
+	tStruct = 12
+)
+
+// parquetType physical and converted type of a column, converted type
+// -1 for none.
+func parquetType(typ ArrowType) (int32, int32) {
+	switch typ {
+	case ArrowInt:
+		return pqInt64, -1
+	case ArrowFloat:
+		return pqDouble, -1
+	case ArrowBool:
+		return pqBoolean, -1
+	case ArrowTimestamp:
+		return pqInt64, pqTimestampMillis
+	case ArrowBinary:
+		return pqByteArray, -1
+	}
+	return pqByteArray, pqUtf8
+}
+
+// WriteParquet write rows as a Parquet file of a single row group.  Values
+// that can't be converted to the column type are null.
+func WriteParquet(w io.Writer, fields []*Field, rows [][]driver.Value) error {
+	buf := []byte(parquetMagic)
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(fields))
+	for col, f := range fields {
+		page := parquetPage(f.Type, col, rows)
+		hdr := &thriftWriter{}
+		hdr.i32(1, pqDataPage)
+		hdr.i32(2, int32(len(page)))
+		hdr.i32(3, int32(len(page)))
+		hdr.structBegin(5)
+		hdr.i32(1, int32(len(rows)))
+		hdr.i32(2, pqPlain)
+		hdr.i32(3, pqRle)
+		hdr.i32(4, pqRle)
+		hdr.structEnd()
+		hdr.stop()
+		chunks[col] = chunk{offset: int64(len(buf)), size: int64(len(hdr.buf) + len(page))}
+		buf = append(buf, hdr.buf...)
+		buf = append(buf, page...)
+	}
+
+	meta := &thriftWriter{}
+	meta.i32(1, 1)
+	meta.listBegin(2, tStruct, len(fields)+1)
+	meta.elemBegin()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(fields)))
+	meta.structEnd()
+	for _, f := range fields {
+		pt, ct := parquetType(f.Type)
+		meta.elemBegin()
+		meta.i32(1, pt)
+		meta.i32(3, pqOptional)
+		meta.str(4, f.Name)
+		if ct >= 0 {
+			meta.i32(6, ct)
+		}
+		meta.structEnd()
+	}
+	meta.i64(3, int64(len(rows)))
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
+	meta.listBegin(4, tStruct, 1)
+	meta.elemBegin()
+	meta.listBegin(1, tStruct, len(fields))
+	for col, f := range fields {
+		pt, _ := parquetType(f.Type)
+		meta.elemBegin()
+		meta.i64(2, chunks[col].offset)
+		meta.structBegin(3)
+		meta.i32(1, pt)
+		meta.listBegin(2, tI32, 2)
+		meta.varint(pqPlain)
+		meta.varint(pqRle)
+		meta.listBegin(3, tBinary, 1)
+		meta.binary(f.Name)
+		meta.i32(4, pqUncompressed)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, chunks[col].size)
+		meta.i64(7, chunks[col].size)
+		meta.i64(9, chunks[col].offset)
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(rows)))
+	meta.structEnd()
+	meta.str(6, "qlbridge")
+	meta.stop()
+
+	buf = append(buf, meta.buf...)
+	buf = append(buf, fbInt32(int32(len(meta.buf)))...)
+	buf = append(buf, parquetMagic...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// parquetPage the data page of a column, definition levels followed by
+// the PLAIN encoded non null values.
+func parquetPage(typ ArrowType, col int, rows [][]driver.Value) []byte {
+	n := len(rows)
+	// definition levels, bit width 1, as a single bit-packed run
+	levels := make([]byte, (n+7)/8)
+	var vals []byte
+	var bools []bool
+	for i, row := range rows {
+		v := row[col]
+		if v == nil {
+			continue
+		}
+		switch typ {
+		case ArrowInt, ArrowFloat, ArrowTimestamp:
+			bits, ok := fixedValue(typ, v)
+			if !ok {
+				continue
+			}
+			vals = append(vals, fbInt64(int64(bits))...)
+		case ArrowBool:
+			b, ok := value.ValueToBool(value.NewValue(v))
+			if !ok {
+				continue
+			}
+			bools = append(bools, b)
+		default:
+			var by []byte
+			if typ == ArrowBinary {
+				by = bytesValue(v)
+			} else {
+				by = []byte(textValue(v))
+			}
+			vals = append(vals, fbInt32(int32(len(by)))...)
+			vals = append(vals, by...)
+		}
+		levels[i/8] |= 1 << uint(i%8)
+	}
+	if typ == ArrowBool {
+		vals = make([]byte, (len(bools)+7)/8)
+		for i, b := range bools {
+			if b {
+				vals[i/8] |= 1 << uint(i%8)
+			}
+		}
+	}
+	run := appendUvarint(nil, uint64(len(levels))<<1|1)
+	run = append(run, levels...)
+	page := append(fbInt32(int32(len(run))), run...)
+	return append(page, vals...)
+}
+
+// thriftWriter thrift compact protocol writer, of just the types the
+// parquet metadata uses.
+type thriftWriter struct {
+	buf    []byte
+	last   int   // last field id of current struct
+	parent []int // last field ids of enclosing structs
+}
+
+func (m *thriftWriter) field(id int, typ byte) {
+	if delta := id - m.last; delta > 0 && delta <= 15 {
+		m.buf = append(m.buf, byte(delta)<<4|typ)
+	} else {
+		m.buf = append(m.buf, typ)
+		m.buf = appendUvarint(m.buf, zigzag(int64(id)))
+	}
+	m.last = id
+}
+func (m *thriftWriter) varint(n int64) { m.buf = appendUvarint(m.buf, zigzag(n)) }
+func (m *thriftWriter) binary(s string) {
+	m.buf = appendUvarint(m.buf, uint64(len(s)))
+	m.buf = append(m.buf, s...)
+}
+func (m *thriftWriter) i32(id int, n int32) {
+	m.field(id, tI32)
+	m.varint(int64(n))
+}
+func (m *thriftWriter) i64(id int, n int64) {
+	m.field(id, tI64)
+	m.varint(n)
+}
+func (m *thriftWriter) str(id int, s string) {
+	m.field(id, tBinary)
+	m.binary(s)
+}
+func (m *thriftWriter) listBegin(id int, elemType byte, n int) {
+	m.field(id, tList)
+	if n < 15 {
+		m.buf = append(m.buf, byte(n)<<4|elemType)
+	} else {
+		m.buf = append(m.buf, 0xf0|elemType)
+		m.buf = appendUvarint(m.buf, uint64(n))
+	}
+}
+func (m *thriftWriter) structBegin(id int) {
+	m.field(id, tStruct)
+	m.elemBegin()
+}
+
+// elemBegin begin a struct element of a list.
+func (m *thriftWriter) elemBegin() {
+	m.parent = append(m.parent, m.last)
+	m.last = 0
+}
+func (m *thriftWriter) structEnd() {
+	m.stop()
+	m.last = m.parent[len(m.parent)-1]
+	m.parent = m.parent[:len(m.parent)-1]
+}
+func (m *thriftWriter) stop() { m.buf = append(m.buf, 0) }
+
+func zigzag(n int64) uint64 { return uint64(n<<1) ^ uint64(n>>63) }
+
+func appendUvarint(buf []byte, n uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], n)]...)
+}
@@ -6,6 +6,7 @@ import (
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/plan"
@@ -20,6 +21,8 @@ var (
 	_ TaskRunner = (*Command)(nil)
 )
 
+const globalVarPrefix = "@@global."
+
 // Command is executeable task for SET SQL commands
 type Command struct {
 	*TaskBase
@@ -48,14 +51,23 @@ func (m *Command) Run() error {
 	//defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
-	if m.Ctx.Session == nil {
-		u.Warnf("no Context.Session?")
-		return fmt.Errorf("no Context.Session?")
-	}
-
 	switch kw := m.p.Stmt.Keyword(); kw {
 	case lex.TokenSet:
 		return m.runSet()
+	case lex.TokenUse:
+		// USE schema, the current schema of the session
+		if m.Ctx.Conn == nil {
+			return ErrNoSession
+		}
+		return m.Ctx.Conn.Use(m.p.Stmt.Identity)
+	case lex.TokenAnalyze:
+		// ANALYZE TABLE tbl [, tbl]
+		for _, col := range m.p.Stmt.Columns {
+			if _, err := AnalyzeTable(m.Ctx, col.Name); err != nil {
+				return err
+			}
+		}
+		return nil
 	case lex.TokenRollback, lex.TokenCommit:
 		u.Debugf("ignorning transaction, not implemented.  %v", kw.String())
 		return nil
@@ -67,14 +79,18 @@ func (m *Command) Run() error {
 }
 func (m *Command) runSet() error {
 
-	writeContext, ok := m.Ctx.Session.(expr.ContextWriter)
-	if !ok || writeContext == nil {
-		u.Warnf("expected context writer but no for %T", m.Ctx.Session)
-		return fmt.Errorf("No write context?")
-	}
-
 	//u.Debugf("running set? %v", m.p.Stmt.String())
 	for _, col := range m.p.Stmt.Columns {
+		if strings.HasPrefix(strings.ToLower(col.Name), globalVarPrefix) {
+			if err := setGlobal(col); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.Ctx.Session == nil {
+			u.Warnf("no Context.Session?")
+			return fmt.Errorf("no Context.Session?")
+		}
 		err := evalSetExpression(col, m.Ctx.Session, col.Expr)
 		if err != nil {
 			u.Warnf("Could not evaluate [%s] err=%v", col.Expr, err)
@@ -87,6 +103,21 @@ func (m *Command) runSet() error {
 	return nil
 }
 
+// setGlobal set a global (server wide) system variable
+//
+//	SET GLOBAL slow_query_threshold = 500
+func setGlobal(col *rel.CommandColumn) error {
+	bn, ok := col.Expr.(*expr.BinaryNode)
+	if !ok {
+		return fmt.Errorf("Expected SET GLOBAL name = value but got %s", col)
+	}
+	v, ok := vm.Eval(nil, bn.Args[1])
+	if !ok || v == nil {
+		return fmt.Errorf("Expected value but got %s", bn.Args[1])
+	}
+	return datasource.SetGlobalVar(col.Name[len(globalVarPrefix):], v)
+}
+
 func evalSetExpression(col *rel.CommandColumn, ctx expr.ContextReadWriter, arg expr.Node) error {
 
 	switch bn := arg.(type) {
@@ -102,7 +133,7 @@ func evalSetExpression(col *rel.CommandColumn, ctx expr.ContextReadWriter, arg e
 			return fmt.Errorf("Expected value but got %T", bn.Args[1])
 		}
 		//u.Infof(`writeContext.Put("%v",%v)`, col.Key(), rhv.Value())
-		ctx.Put(col, ctx, rhv)
+		return ctx.Put(col, ctx, rhv)
 	case nil:
 		// Special statements
 		name := strings.ToLower(col.Name)
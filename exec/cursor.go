@@ -0,0 +1,319 @@
+package exec
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+)
+
+var (
+	// CursorTTL how long the rows of a snapshot cursor are kept after
+	// its last page was read.
+	CursorTTL = time.Minute * 10
+
+	cursorMu  sync.Mutex
+	snapshots = make(map[string]*cursorSnapshot)
+)
+
+// Page is one page of the rows of a paged query.
+type Page struct {
+	Columns []string
+	Rows    [][]driver.Value
+	// Cursor opaque cursor to resume after this page, empty on the last.
+	Cursor string
+}
+
+// pageCursor the decoded opaque cursor.
+//   - keyset:   Keys are the ORDER BY values of the last row returned
+//   - snapshot: Id of the materialized result rows, Offset into them
+type pageCursor struct {
+	Query  uint64        `json:"q"`
+	Keys   []interface{} `json:"k,omitempty"`
+	Id     string        `json:"s,omitempty"`
+	Offset int           `json:"o,omitempty"`
+}
+
+// cursorSnapshot the materialized result rows of a snapshot cursor.
+type cursorSnapshot struct {
+	session expr.ContextReader // owning session, if any
+	cols    []string
+	rows    [][]driver.Value
+	expires time.Time
+}
+
+// RunPage run the select ctx.Raw returning at most size rows, and a cursor
+// to fetch the next page from by calling again with the same sql.  An
+// empty cursor starts at the first row.
+//
+// Ordered selects are keyset paged, the next page is the query re-run with
+// a WHERE on the ORDER BY columns of the last row returned, so the order
+// by columns should be unique (ties across a page boundary are skipped)
+// and not null.
+//
+//    SELECT user_id, email FROM users ORDER BY email ASC
+//    SELECT user_id, email FROM users WHERE email > "bob@email.com" ORDER BY email ASC
+//
+// Other selects (unordered, aggregate, limited, ...) are snapshot paged,
+// the query is run once and its rows kept for CursorTTL so later pages
+// are consistent with the first, and read without re-running the query.
+func RunPage(ctx *plan.Context, cursor string, size int) (*Page, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("page size must be > 0 got %d", size)
+	}
+	sel, err := rel.ParseSqlSelect(ctx.Raw)
+	if err != nil {
+		return nil, err
+	}
+	qid := queryId(ctx.Raw)
+	pc := &pageCursor{Query: qid}
+	if cursor != "" {
+		if pc, err = decodeCursor(cursor); err != nil {
+			return nil, err
+		}
+		if pc.Query != qid {
+			return nil, fmt.Errorf("cursor is not for this query")
+		}
+	}
+	if keys, ok := keysetOrder(sel); ok && pc.Id == "" {
+		return runKeysetPage(ctx, sel, keys, pc, size)
+	}
+	return runSnapshotPage(ctx, pc, size)
+}
+
+// keysetOrder the ORDER BY columns of a select that can be keyset paged.
+func keysetOrder(sel *rel.SqlSelect) ([]*rel.Column, bool) {
+	if len(sel.OrderBy) == 0 || sel.IsAggQuery() || sel.Distinct || sel.Having != nil ||
+		sel.Limit > 0 || sel.Offset > 0 || len(sel.With) > 0 || sel.Into != nil {
+		return nil, false
+	}
+	if sel.Where != nil && sel.Where.Expr == nil {
+		return nil, false
+	}
+	for _, col := range sel.OrderBy {
+		if _, ok := col.Expr.(*expr.IdentityNode); !ok {
+			return nil, false
+		}
+	}
+	return sel.OrderBy, true
+}
+
+func runKeysetPage(ctx *plan.Context, sel *rel.SqlSelect, keys []*rel.Column, pc *pageCursor, size int) (*Page, error) {
+	if len(pc.Keys) > 0 {
+		if len(pc.Keys) != len(keys) {
+			return nil, fmt.Errorf("cursor does not match ORDER BY of query")
+		}
+		pred, err := keysetPredicate(keys, pc.Keys)
+		if err != nil {
+			return nil, err
+		}
+		if sel.Where != nil {
+			pred = fmt.Sprintf("(%s) AND (%s)", sel.Where.Expr, pred)
+		}
+		where, err := expr.ParseExpression(pred)
+		if err != nil {
+			return nil, err
+		}
+		sel.Where = &rel.SqlWhere{Expr: where}
+	}
+	// one extra row to know if there is a next page
+	sel.Limit = size + 1
+	raw := sel.String()
+	u.Debugf("keyset page: %s", raw)
+	res := runBatchStatement(connContext(ctx, raw), raw)
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	page := &Page{Columns: res.Columns, Rows: res.Rows}
+	if len(res.Rows) <= size {
+		return page, nil
+	}
+	page.Rows = res.Rows[:size]
+
+	// the key values of the last row of the page
+	last := page.Rows[size-1]
+	next := &pageCursor{Query: pc.Query, Keys: make([]interface{}, len(keys))}
+	for i, key := range keys {
+		idx := keyColumn(sel, res.Columns, key.Expr.(*expr.IdentityNode))
+		if idx < 0 || idx >= len(last) {
+			return nil, fmt.Errorf("ORDER BY %s must be a column of the select to page", key.Expr)
+		}
+		next.Keys[i] = last[idx]
+		if b, ok := last[idx].([]byte); ok {
+			next.Keys[i] = string(b)
+		}
+	}
+	cursor, err := encodeCursor(next)
+	if err != nil {
+		return nil, err
+	}
+	page.Cursor = cursor
+	return page, nil
+}
+
+// keyColumn position in the result columns of an order by identity.
+func keyColumn(sel *rel.SqlSelect, cols []string, key *expr.IdentityNode) int {
+	for i, col := range sel.Columns {
+		if col.Star {
+			break
+		}
+		if in, ok := col.Expr.(*expr.IdentityNode); ok && in.Text == key.Text {
+			return i
+		}
+	}
+	_, name, _ := key.LeftRight()
+	for i, col := range cols {
+		if col == key.Text || col == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// keysetPredicate the where expression of rows after the key values.
+//
+//    ORDER BY a ASC, b DESC  =>  a > 1 OR (a = 1 AND b < "x")
+func keysetPredicate(keys []*rel.Column, vals []interface{}) (string, error) {
+	ors := make([]string, len(keys))
+	for i := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j <= i; j++ {
+			lit, err := keysetLiteral(vals[j])
+			if err != nil {
+				return "", err
+			}
+			op := "="
+			if j == i {
+				op = ">"
+				if strings.ToLower(keys[j].Order) == "desc" {
+					op = "<"
+				}
+			}
+			ands = append(ands, fmt.Sprintf("%s %s %s", keys[j].Expr, op, lit))
+		}
+		ors[i] = strings.Join(ands, " AND ")
+		if i > 0 {
+			ors[i] = "(" + ors[i] + ")"
+		}
+	}
+	return strings.Join(ors, " OR "), nil
+}
+
+// keysetLiteral a cursor key value as an expression literal.
+func keysetLiteral(v interface{}) (string, error) {
+	switch vt := v.(type) {
+	case json.Number:
+		return vt.String(), nil
+	case string:
+		return expr.NewStringNode(vt).String(), nil
+	case bool:
+		return strconv.FormatBool(vt), nil
+	}
+	return "", fmt.Errorf("cannot page on ORDER BY value %v (%T)", v, v)
+}
+
+func runSnapshotPage(ctx *plan.Context, pc *pageCursor, size int) (*Page, error) {
+	var snap *cursorSnapshot
+	if pc.Id == "" {
+		res := runBatchStatement(connContext(ctx, ctx.Raw), ctx.Raw)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		snap = &cursorSnapshot{session: ctx.Session, cols: res.Columns, rows: res.Rows}
+		pc.Id = fmt.Sprintf("%x", rand.Int63())
+	} else {
+		cursorMu.Lock()
+		snap = snapshots[pc.Id]
+		cursorMu.Unlock()
+		if snap == nil {
+			return nil, fmt.Errorf("cursor has expired")
+		}
+	}
+	page := &Page{Columns: snap.cols}
+	if pc.Offset < len(snap.rows) {
+		end := pc.Offset + size
+		if end > len(snap.rows) {
+			end = len(snap.rows)
+		}
+		page.Rows = snap.rows[pc.Offset:end]
+	}
+
+	cursorMu.Lock()
+	defer cursorMu.Unlock()
+	now := time.Now()
+	for id, s := range snapshots {
+		if now.After(s.expires) {
+			delete(snapshots, id)
+		}
+	}
+	if pc.Offset+size >= len(snap.rows) {
+		delete(snapshots, pc.Id)
+		return page, nil
+	}
+	snap.expires = now.Add(CursorTTL)
+	snapshots[pc.Id] = snap
+	var err error
+	page.Cursor, err = encodeCursor(&pageCursor{Query: pc.Query, Id: pc.Id, Offset: pc.Offset + size})
+	return page, err
+}
+
+// ReleaseCursors drop the snapshot rows of all cursors opened by session,
+// for servers to call when a client session ends rather than holding
+// them until CursorTTL.
+func ReleaseCursors(session expr.ContextReader) int {
+	if session == nil {
+		return 0
+	}
+	cursorMu.Lock()
+	defer cursorMu.Unlock()
+	ct := 0
+	for id, s := range snapshots {
+		if s.session == session {
+			delete(snapshots, id)
+			ct++
+		}
+	}
+	return ct
+}
+
+// queryId identifies the query a cursor was created for.
+func queryId(raw string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(raw))
+	return h.Sum64()
+}
+
+func encodeCursor(pc *pageCursor) (string, error) {
+	by, err := json.Marshal(pc)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(by), nil
+}
+
+func decodeCursor(cursor string) (*pageCursor, error) {
+	by, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(by))
+	dec.UseNumber()
+	pc := &pageCursor{}
+	if err = dec.Decode(pc); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	return pc, nil
+}
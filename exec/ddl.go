@@ -1,14 +1,18 @@
 package exec
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
 )
 
 var (
@@ -90,6 +94,40 @@ func (m *Create) Run() error {
 		reg := schema.DefaultRegistry()
 
 		return reg.SchemaAddFromConfig(sourceConf)
+	case lex.TokenSample:
+		// CREATE SAMPLE TABLE s AS SELECT * FROM big_t TABLESAMPLE (1 PERCENT)
+		s := m.Ctx.Schema
+		if s == nil {
+			return fmt.Errorf("must have schema")
+		}
+		if _, err := s.Table(cs.Identity); err == nil {
+			return sqlerr.New(sqlerr.ErTableExists, "Table '%s' already exists", cs.Identity)
+		}
+		src, err := NewSampleSource(s, cs.Identity, cs.Select)
+		if err != nil {
+			return err
+		}
+		reg := schema.DefaultRegistry()
+		return reg.SchemaAddChild(s.Name, schema.NewSchemaSource(cs.Identity, src))
+	case lex.TokenTable:
+		if !cs.Temp {
+			break
+		}
+		// CREATE TEMPORARY TABLE t (id int, name varchar(255))
+		if _, exists := m.Ctx.Temp.Table(cs.Identity); exists && cs.IfNotExists {
+			return nil
+		}
+		var cols []string
+		types := make(map[string]value.ValueType)
+		for _, col := range cs.Cols {
+			if col.Kw != lex.TokenIdentity {
+				// PRIMARY KEY, CONSTRAINT ..., the first column is the key
+				continue
+			}
+			cols = append(cols, col.Name)
+			types[col.Name] = DdlValueType(col.DataType)
+		}
+		return addTempTable(m.Ctx, cs.Identity, cols, types, nil, cs.Engine)
 	default:
 		u.Warnf("unrecognized create/alter: kw=%v   stmt:%s", cs.Tok, m.p.Stmt)
 	}
@@ -120,8 +158,23 @@ func (m *Drop) Run() error {
 		return fmt.Errorf("must have schema")
 	}
 
+	// temporary tables shadow tables of the schema
+	if cs.Tok.T == lex.TokenTable && m.Ctx.Temp.Drop(cs.Identity) {
+		return nil
+	}
+
 	switch cs.Tok.T {
-	case lex.TokenSource, lex.TokenSchema, lex.TokenTable:
+	case lex.TokenTable:
+		if cs.Temp {
+			return sqlerr.New(sqlerr.ErNoSuchTable, "Unknown table '%s'", cs.Identity)
+		}
+		reg := schema.DefaultRegistry()
+		if err := reg.SchemaDrop(s.Name, cs.Identity, cs.Tok.T); err != nil {
+			return err
+		}
+		schema.DefaultStatsStore.DropTableStats(s.Name, cs.Identity)
+		return nil
+	case lex.TokenSource, lex.TokenSchema:
 
 		reg := schema.DefaultRegistry()
 		return reg.SchemaDrop(s.Name, cs.Identity, cs.Tok.T)
@@ -132,6 +185,71 @@ func (m *Drop) Run() error {
 	return ErrNotImplemented
 }
 
+// addTempTable create temporary table name of cols, typed by types and
+// holding rows, in the session of ctx, with the CREATE TABLE options opts
+// if its source accepts them.  Temporary tables are memdb tables keyed by
+// their first column.
+func addTempTable(ctx *plan.Context, name string, cols []string, types map[string]value.ValueType, rows [][]driver.Value, opts map[string]interface{}) error {
+	if ctx.Temp == nil {
+		return fmt.Errorf("temporary tables require a session")
+	}
+	if plan.TempSource == nil {
+		return fmt.Errorf("temporary tables require the memdb datasource")
+	}
+	db, err := plan.TempSource(name, cols, rows)
+	if err != nil {
+		return err
+	}
+	if src, ok := db.(schema.SourceTableOptions); ok && len(opts) > 0 {
+		if err = src.SetTableOptions(name, opts); err != nil {
+			db.Close()
+			return err
+		}
+	}
+	tbl, err := db.Table(name)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	for _, col := range cols {
+		if vt, ok := types[col]; ok {
+			tbl.AddFieldType(col, vt)
+		} else if !tbl.HasField(col) {
+			tbl.AddFieldType(col, value.UnknownType)
+		}
+	}
+	if err := ctx.Temp.Add(tbl, db); err != nil {
+		db.Close()
+		return err
+	}
+	return nil
+}
+
+// DdlValueType the value type of the sql data type of a column definition.
+//
+//    int, bigint       IntType
+//    float, decimal    NumberType
+//    datetime, date    TimeType
+//    varchar, text ... StringType
+//
+func DdlValueType(dataType string) value.ValueType {
+	switch strings.ToLower(dataType) {
+	case "int", "integer", "bigint", "smallint", "tinyint", "mediumint":
+		return value.IntType
+	case "float", "double", "real", "decimal", "numeric":
+		return value.NumberType
+	case "bool", "boolean":
+		return value.BoolType
+	case "datetime", "timestamp", "date", "time":
+		return value.TimeType
+	case "json":
+		return value.JsonType
+	case "blob", "binary", "varbinary":
+		return value.ByteSliceType
+	}
+	return value.StringType
+}
+
 // NewAlter creates new ALTER exec task.
 func NewAlter(ctx *plan.Context, p *plan.Alter) *Alter {
 	m := &Alter{
@@ -0,0 +1,80 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/metrics"
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	statusRowsDropped      int64
+	statusRowsDeadLettered int64
+	statusRowErrorHalts    int64
+)
+
+func init() {
+	datasource.RegisterStatusVar("Stream_rows_dropped", func() int64 { return atomic.LoadInt64(&statusRowsDropped) })
+	datasource.RegisterStatusVar("Stream_rows_dead_lettered", func() int64 { return atomic.LoadInt64(&statusRowsDeadLettered) })
+	datasource.RegisterStatusVar("Stream_row_error_halts", func() int64 { return atomic.LoadInt64(&statusRowErrorHalts) })
+}
+
+// streamsRowErrors does the policy of ctx handle failed rows, ie is it a
+// streaming query.  Other statements keep their lenient behavior.
+func streamsRowErrors(ctx *plan.Context) bool {
+	return ctx != nil && ctx.RowErrors != plan.RowErrorDefault
+}
+
+// rowError handle a row (rdr, optional) that failed in op of a streaming
+// query with err by the policy of ctx.  A non-nil error halts the query,
+// either the row's own or that of the DeadLetterSink.
+func rowError(ctx *plan.Context, op string, rdr expr.ContextReader, err error) error {
+	action := ctx.RowErrors
+	if action == plan.RowErrorDeadLetter {
+		dl := &plan.DeadLetter{Op: op, Row: readerRow(rdr), Err: err}
+		if sinkErr := ctx.DeadLetters.OnDeadLetter(dl); sinkErr != nil {
+			action = plan.RowErrorHalt
+			err = fmt.Errorf("could not dead-letter row: %v (row error: %v)", sinkErr, err)
+		}
+	}
+	metrics.RowError(op, action.String())
+	switch action {
+	case plan.RowErrorHalt:
+		atomic.AddInt64(&statusRowErrorHalts, 1)
+		return err
+	case plan.RowErrorDeadLetter:
+		atomic.AddInt64(&statusRowsDeadLettered, 1)
+	default:
+		atomic.AddInt64(&statusRowsDropped, 1)
+	}
+	return nil
+}
+
+// haltTask stop task with err, the error of its Run.
+func haltTask(task TaskRunner, err error) {
+	select {
+	case task.ErrChan() <- err:
+	default:
+		// already halting
+	}
+}
+
+// readerRow the values of a row by column name.
+func readerRow(rdr expr.ContextReader) map[string]driver.Value {
+	if rdr == nil {
+		return nil
+	}
+	row := make(map[string]driver.Value)
+	for k, v := range rdr.Row() {
+		if v == nil || v.Nil() {
+			row[k] = nil
+			continue
+		}
+		row[k] = v.Value()
+	}
+	return row
+}
@@ -21,6 +21,9 @@ func (e errList) error() error {
 	return e
 }
 
+// Unwrap the errors, so a typed error of any of them may be found.
+func (e errList) Unwrap() []error { return e }
+
 func (e errList) Error() string {
 	a := make([]string, len(e))
 	for i, v := range e {
@@ -7,25 +7,28 @@
 package exec
 
 import (
-	"fmt"
-
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
 )
 
 var (
 	// ErrShuttingDown already shutting down error
-	ErrShuttingDown = fmt.Errorf("Received Shutdown Signal")
+	ErrShuttingDown = sqlerr.New(sqlerr.ErQueryInterrupted, "Received Shutdown Signal")
 	// ErrNotSupported statement not supported
-	ErrNotSupported = fmt.Errorf("QLBridge: Not supported")
+	ErrNotSupported = sqlerr.New(sqlerr.ErNotSupported, "QLBridge: Not supported")
 	// ErrNotImplemented exec not impelemented for statement
-	ErrNotImplemented = fmt.Errorf("QLBridge: Not implemented")
+	ErrNotImplemented = sqlerr.New(sqlerr.ErNotSupported, "QLBridge: Not implemented")
 	// ErrUnknownCommand unknown command error.
-	ErrUnknownCommand = fmt.Errorf("QLBridge: Unknown Command")
+	ErrUnknownCommand = sqlerr.New(sqlerr.ErUnknownCom, "QLBridge: Unknown Command")
 	// ErrInternalError internal error
-	ErrInternalError = fmt.Errorf("QLBridge: Internal Error")
+	ErrInternalError = sqlerr.New(sqlerr.ErUnknown, "QLBridge: Internal Error")
 	// ErrNoSchemaSelected no schema was selected when performing statement.
-	ErrNoSchemaSelected = fmt.Errorf("No Schema Selected")
+	ErrNoSchemaSelected = sqlerr.New(sqlerr.ErNoDB, "No Schema Selected")
+	// ErrMaxExecutionTime select ran longer than session max_execution_time.
+	ErrMaxExecutionTime = sqlerr.New(sqlerr.ErQueryTimeout, "Query execution was interrupted, maximum statement execution time exceeded")
+	// ErrNoSession statement needs the session of a connection, ie PREPARE.
+	ErrNoSession = sqlerr.New(sqlerr.ErNotSupported, "QLBridge: statement requires a session")
 )
 
 type (
@@ -101,6 +104,7 @@ type (
 		WalkSource(p *plan.Source) (Task, error)
 		WalkJoin(p *plan.JoinMerge) (Task, error)
 		WalkJoinKey(p *plan.JoinKey) (Task, error)
+		WalkScatter(p *plan.Scatter) (Task, error)
 		WalkWhere(p *plan.Where) (Task, error)
 		WalkHaving(p *plan.Having) (Task, error)
 		WalkGroupBy(p *plan.GroupBy) (Task, error)
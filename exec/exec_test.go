@@ -3,6 +3,9 @@ package exec_test
 import (
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,11 +13,16 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
 	"github.com/araddon/qlbridge/datasource/mockcsv"
 	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
 	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
 )
 
 func init() {
@@ -511,3 +519,856 @@ func testSubselect(t *testing.T) {
 	assert.True(t, err == nil, "no error %v", err)
 	assert.True(t, len(msgs) == 1, "should have filtered out 2 messages")
 }
+
+func runSqlMessages(t *testing.T, ctx *plan.Context) []schema.Message {
+	job, err := exec.BuildSqlJob(ctx)
+	assert.True(t, err == nil, "no error %v", err)
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	err = job.Setup()
+	assert.True(t, err == nil)
+	err = job.Run()
+	time.Sleep(time.Millisecond * 10)
+	assert.True(t, err == nil, "no error %v", err)
+	return msgs
+}
+
+func TestExecNullOrdering(t *testing.T) {
+
+	firstInterest := func(sqlText string, nullOrder string) driver.Value {
+		ctx := td.TestContext(sqlText)
+		if nullOrder != "" {
+			ctx.Session.Put(&rel.CommandColumn{Name: plan.NullOrderVar}, nil, value.NewStringValue(nullOrder))
+		}
+		msgs := runSqlMessages(t, ctx)
+		assert.Equal(t, 3, len(msgs), sqlText)
+		if len(msgs) == 0 {
+			return nil
+		}
+		return msgs[0].(*datasource.SqlDriverMessageMap).Values()[0]
+	}
+
+	// the csv source projects missing interests as empty value
+
+	assert.Equal(t, "", firstInterest("SELECT interests FROM users ORDER BY interests ASC", ""))
+	assert.Equal(t, "fishing", firstInterest("SELECT interests FROM users ORDER BY interests ASC NULLS LAST", ""))
+	assert.Equal(t, "swimming", firstInterest("SELECT interests FROM users ORDER BY interests DESC", ""))
+	assert.Equal(t, "", firstInterest("SELECT interests FROM users ORDER BY interests DESC NULLS FIRST", ""))
+
+	// session default, explicit NULLS on column still wins
+	assert.Equal(t, "fishing", firstInterest("SELECT interests FROM users ORDER BY interests ASC", "last"))
+	assert.Equal(t, "", firstInterest("SELECT interests FROM users ORDER BY interests DESC", "first"))
+	assert.Equal(t, "", firstInterest("SELECT interests FROM users ORDER BY interests ASC NULLS FIRST", "last"))
+}
+
+func TestExecNullGrouping(t *testing.T) {
+
+	mockcsv.LoadTable(mockcsv.SchemaName, "nullgroups", `id,category
+1,"a"
+2,
+3,
+4,"a"`)
+	data := [][]driver.Value{{1, "a"}, {2, nil}, {3, ""}, {4, nil}, {5, ""}, {6, "a"}}
+	db, err := memdb.NewMemDbData("nullgroups_empty", data, []string{"id", "category"})
+	assert.Equal(t, nil, err)
+	tbl, _ := db.Table("nullgroups_empty")
+
+	countRows := func(sqlText, grouping string) int {
+		ctx := td.TestContext(sqlText)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+		if grouping != "" {
+			ctx.Session.Put(&rel.CommandColumn{Name: plan.NullGroupingVar}, nil, value.NewStringValue(grouping))
+		}
+		return len(runSqlMessages(t, ctx))
+	}
+	assert.Equal(t, 2, countRows("SELECT category, count(id) FROM nullgroups GROUP BY category", ""))
+	assert.Equal(t, 2, countRows("SELECT DISTINCT category FROM nullgroups", ""))
+	assert.Equal(t, 2, countRows("SELECT DISTINCT category FROM nullgroups", plan.NullGroupStrict))
+
+	// legacy (the default) groups NULL with '', strict keeps them apart
+	for _, sql := range []string{
+		"SELECT category, count(id) FROM nullgroups_empty GROUP BY category",
+		"SELECT DISTINCT category FROM nullgroups_empty",
+	} {
+		assert.Equal(t, 2, countRows(sql, ""), sql)
+		assert.Equal(t, 2, countRows(sql, plan.NullGroupLegacy), sql)
+		assert.Equal(t, 3, countRows(sql, plan.NullGroupStrict), sql)
+	}
+}
+
+func TestApproxCount(t *testing.T) {
+	exact, err := runSession(t, nil, `SELECT COUNT(*) AS ct FROM users`)
+	assert.Equal(t, nil, err)
+	approx, err := runSession(t, nil, `SELECT COUNT(*) AS ct FROM users WITH approx=true`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(approx))
+	assert.Equal(t, exact, approx)
+	// filtered counts are not answered from metadata
+	rows, err := runSession(t, nil, `SELECT COUNT(*) AS ct FROM users WHERE email = "bob@email.com" WITH approx=true`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{int64(1)}}, rows)
+}
+
+func TestSampleTable(t *testing.T) {
+	_, err := runSession(t, nil, `CREATE SAMPLE TABLE users_sample AS SELECT * FROM users TABLESAMPLE (100 PERCENT)`)
+	assert.Equal(t, nil, err)
+	_, err = runSession(t, nil, `CREATE SAMPLE TABLE users_sample AS SELECT * FROM users TABLESAMPLE (1 PERCENT)`)
+	assert.NotEqual(t, nil, err)
+	_, err = runSession(t, nil, `CREATE SAMPLE TABLE bad_sample AS SELECT * FROM users`)
+	assert.NotEqual(t, nil, err)
+
+	exact, err := runSession(t, nil, `SELECT user_id, email FROM users`)
+	assert.Equal(t, nil, err)
+	rows, err := runSession(t, nil, `SELECT user_id, email FROM users_sample`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, exact, rows)
+
+	// bernoulli sample never has more rows than the table
+	rows, err = runSession(t, nil, `SELECT user_id FROM users TABLESAMPLE (50 PERCENT)`)
+	assert.Equal(t, nil, err)
+	assert.True(t, len(rows) <= len(exact))
+
+	// reservoir sample has exactly n rows, if the table has that many
+	rows, err = runSession(t, nil, `SELECT user_id FROM users TABLESAMPLE (2 ROWS)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(rows))
+	rows, err = runSession(t, nil, `SELECT user_id FROM users SAMPLE 1000 ROWS`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(exact), len(rows))
+	_, err = runSession(t, nil, `CREATE SAMPLE TABLE rows_sample AS SELECT * FROM users TABLESAMPLE (2 ROWS)`)
+	assert.NotEqual(t, nil, err)
+
+	// in sample mode queries on users are answered from the sample, with a warning
+	ses := datasource.NewMySqlSessionVars()
+	_, err = runSession(t, ses, `SET SESSION sample_mode = 1`)
+	assert.Equal(t, nil, err)
+	ctx := td.TestContext(`SELECT users.email FROM users WHERE user_id = "hT2impsOPUREcVPc"`)
+	ctx.Session = ses
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+	assert.Equal(t, nil, job.Setup())
+	assert.Equal(t, nil, job.Run())
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, "users_sample", ctx.Stmt.(*rel.SqlSelect).From[0].Name)
+	assert.Equal(t, 1, len(ctx.Warnings))
+
+	// without sample mode, no rewrite
+	ctx = td.TestContext(`SELECT email FROM users`)
+	_, err = exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(ctx.Warnings))
+}
+
+func TestRunBatch(t *testing.T) {
+	ctx := td.TestContext("")
+	ctx.Session = datasource.NewMySqlSessionVars()
+	sql := `SET @a = 5;
+		SELECT user_id FROM users WHERE email = "bob@email.com";
+		SELECT email FROM not_a_table;
+		SELECT @a + 1 AS b;`
+
+	// stop on first error
+	results, err := exec.RunBatch(ctx, sql, false)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, nil, results[0].Err)
+	assert.Equal(t, "SET @a = 5", results[0].Sql)
+	assert.Equal(t, nil, results[1].Err)
+	assert.Equal(t, []string{"user_id"}, results[1].Columns)
+	assert.Equal(t, [][]driver.Value{{"hT2impsOPUREcVPc"}}, results[1].Rows)
+	assert.Equal(t, err, results[2].Err)
+
+	// continue past errors
+	results, err = exec.RunBatch(ctx, sql, true)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 4, len(results))
+	assert.Equal(t, err, results[2].Err)
+	assert.Equal(t, nil, results[3].Err)
+	assert.Equal(t, [][]driver.Value{{int64(6)}}, results[3].Rows)
+
+	// parse errors run nothing
+	results, err = exec.RunBatch(ctx, `SET @a = 7; SELEC 1;`, true)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 0, len(results))
+	v, _ := ctx.Session.Get("@a")
+	assert.Equal(t, int64(5), v.Value())
+}
+
+func TestOptimisticUpdate(t *testing.T) {
+	ctx := td.TestContext("")
+	ctx.Temp = plan.NewTempTables()
+	defer ctx.Close()
+	results, err := exec.RunBatch(ctx, `CREATE TEMPORARY TABLE accounts (id int, balance int, version int) ENGINE=memdb VERSION_COLUMN=version;
+		INSERT INTO accounts (id, balance, version) VALUES (1, 10, 1), (2, 20, 1);`, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(results))
+
+	update := func(sql string) *exec.BatchResult {
+		results, err := exec.RunBatch(ctx, sql, false)
+		assert.Equal(t, nil, err, sql)
+		return results[0]
+	}
+	// the writer read version 1, the update bumps it
+	res := update(`UPDATE accounts SET balance = 15 WHERE id = 1 AND version = 1`)
+	assert.Equal(t, int64(1), res.RowsMatched)
+	assert.Equal(t, int64(1), res.RowsAffected)
+
+	// a second writer that also read version 1 loses
+	res = update(`UPDATE accounts SET balance = 5 WHERE id = 1 AND version = 1`)
+	assert.Equal(t, int64(0), res.RowsMatched)
+	assert.Equal(t, int64(0), res.RowsAffected)
+
+	// matched but already holding the values, not changed
+	res = update(`UPDATE accounts SET balance = 15 WHERE id = 1 AND version = 2`)
+	assert.Equal(t, int64(1), res.RowsMatched)
+	assert.Equal(t, int64(0), res.RowsAffected)
+
+	results, err = exec.RunBatch(ctx, `SELECT id, balance, version FROM accounts`, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(1), int64(15), int64(2)}, {int64(2), int64(20), int64(1)}}, results[0].Rows)
+
+	results, err = exec.RunBatch(ctx, `UPDATE accounts SET nope = 1 WHERE id = 2`, false)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestInsertOnErrorSkip(t *testing.T) {
+	ctx := td.TestContext("")
+	ctx.Temp = plan.NewTempTables()
+	defer ctx.Close()
+	results, err := exec.RunBatch(ctx, `CREATE TEMPORARY TABLE tags (id int, name varchar(255));
+		INSERT INTO tags (id, name) VALUES (1, "a");
+		INSERT INTO tags (id, name) VALUES (1, "b"), (2), (3, "c") ON ERROR SKIP;`, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(results))
+	res := results[2]
+	assert.Equal(t, int64(2), res.RowsAffected)
+	assert.Equal(t, 1, len(res.Warnings), "%v", res.Warnings)
+	assert.True(t, strings.HasPrefix(res.Warnings[0], "Row 2 skipped: "), "%v", res.Warnings)
+
+	results, err = exec.RunBatch(ctx, `SELECT id, name FROM tags`, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(1), "b"}, {int64(3), "c"}}, results[0].Rows)
+
+	// without skip the failed row fails the insert
+	_, err = exec.RunBatch(ctx, `INSERT INTO tags (id, name) VALUES (4, "d"), (5)`, false)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestCallProcedure(t *testing.T) {
+	// a procedure running sql against the callers schema
+	exec.RegisterProcedure("user_emails", exec.NewProcedureFunc(
+		[]*schema.Field{
+			schema.NewFieldBase("rank", value.IntType, 64, "rank"),
+			schema.NewFieldBase("email", value.StringType, 255, "email"),
+		},
+		func(ctx *plan.Context, args []value.Value) ([][]driver.Value, error) {
+			limit, _ := value.ValueToInt64(args[0])
+			results, err := exec.RunBatch(ctx, `SELECT email FROM users ORDER BY email ASC`, false)
+			if err != nil {
+				return nil, err
+			}
+			var rows [][]driver.Value
+			for i, row := range results[0].Rows {
+				if int64(i) >= limit {
+					break
+				}
+				rows = append(rows, []driver.Value{int64(i + 1), row[0]})
+			}
+			return rows, nil
+		}))
+
+	rows, err := runSession(t, datasource.NewMySqlSessionVars(), `CALL user_emails(2)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{
+		{int64(1), "aaron@email.com"},
+		{int64(2), "bob@email.com"},
+	}, rows)
+
+	ctx := td.TestContext("")
+	ctx.Session = datasource.NewMySqlSessionVars()
+	results, err := exec.RunBatch(ctx, `CALL user_emails(1); SELECT 1 AS one`, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"rank", "email"}, results[0].Columns)
+	assert.Equal(t, 1, len(results[0].Rows))
+
+	_, err = runSession(t, datasource.NewMySqlSessionVars(), `CALL not_a_procedure(1)`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRunPage(t *testing.T) {
+	readAll := func(sql string, size int) ([]string, [][]driver.Value) {
+		var cols []string
+		var rows [][]driver.Value
+		cursor := ""
+		for i := 0; i < 10; i++ {
+			page, err := exec.RunPage(td.TestContext(sql), cursor, size)
+			assert.Equal(t, nil, err, sql)
+			if err != nil {
+				break
+			}
+			assert.True(t, len(page.Rows) <= size)
+			cols = page.Columns
+			rows = append(rows, page.Rows...)
+			if cursor = page.Cursor; cursor == "" {
+				break
+			}
+		}
+		return cols, rows
+	}
+
+	// keyset paged
+	cols, rows := readAll(`SELECT user_id, email FROM users ORDER BY email DESC`, 1)
+	assert.Equal(t, []string{"user_id", "email"}, cols)
+	assert.Equal(t, [][]driver.Value{
+		{"hT2impsabc345c", "not_an_email_2"},
+		{"hT2impsOPUREcVPc", "bob@email.com"},
+		{"9Ip1aKbeZe2njCDM", "aaron@email.com"},
+	}, rows)
+
+	// compound keys with ties on the first
+	_, rows = readAll(`SELECT email, referral_count FROM users ORDER BY referral_count ASC, email ASC`, 2)
+	assert.Equal(t, [][]driver.Value{
+		{"bob@email.com", "12"},
+		{"not_an_email_2", "12"},
+		{"aaron@email.com", "82"},
+	}, rows)
+
+	// snapshot paged
+	_, rows = readAll(`SELECT user_id FROM users`, 2)
+	assert.Equal(t, 3, len(rows))
+	_, rows = readAll(`SELECT count(*) AS ct FROM users`, 2)
+	assert.Equal(t, 1, len(rows))
+
+	// a cursor only resumes the query it was created for
+	page, err := exec.RunPage(td.TestContext(`SELECT user_id FROM users`), "", 1)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", page.Cursor)
+	_, err = exec.RunPage(td.TestContext(`SELECT email FROM users`), page.Cursor, 1)
+	assert.NotEqual(t, nil, err)
+	_, err = exec.RunPage(td.TestContext(`SELECT user_id FROM users`), "not-a-cursor", 1)
+	assert.NotEqual(t, nil, err)
+
+	// cursors are released when their session ends
+	session := datasource.NewMySqlSessionVars()
+	ctx := td.TestContext(`SELECT user_id FROM users`)
+	ctx.Session = session
+	page, err = exec.RunPage(ctx, "", 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, exec.ReleaseCursors(session))
+	ctx = td.TestContext(`SELECT user_id FROM users`)
+	ctx.Session = session
+	_, err = exec.RunPage(ctx, page.Cursor, 1)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSimilarityJoin(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "fuzzy_people", `id,name
+1,Robert
+2,Jonathan
+3,Al
+4,Catherine`)
+	mockcsv.LoadTable(mockcsv.SchemaName, "fuzzy_customers", `cid,cname
+a,Rupert
+b,Jonathon
+c,Ali
+d,Kathryn
+e,Robbert`)
+
+	sorted := func(rows [][]interface{}) [][]interface{} {
+		sort.Slice(rows, func(i, j int) bool {
+			return fmt.Sprint(rows[i]) < fmt.Sprint(rows[j])
+		})
+		return rows
+	}
+
+	rows, err := runSession(t, nil, `SELECT p.id, c.cid FROM fuzzy_people AS p
+		INNER JOIN fuzzy_customers AS c ON levenshtein(p.name, c.cname) < 2`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"1", "e"}, {"2", "b"}, {"3", "c"}}, sorted(rows))
+
+	// flipped, inclusive, short strings are only length filtered
+	rows, err = runSession(t, nil, `SELECT p.id, c.cid FROM fuzzy_people AS p
+		INNER JOIN fuzzy_customers AS c ON 4 >= levenshtein(c.cname, p.name)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"1", "a"}, {"1", "e"}, {"2", "b"}, {"3", "c"}, {"4", "d"}}, sorted(rows))
+
+	rows, err = runSession(t, nil, `SELECT p.id, c.cid FROM fuzzy_people AS p
+		INNER JOIN fuzzy_customers AS c ON p.name SOUNDS LIKE c.cname`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"1", "a"}, {"1", "e"}, {"2", "b"}, {"3", "c"}}, sorted(rows))
+}
+
+func TestLookupJoin(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "lookup_orders", `oid,email
+1,Bob@Email.com
+2,ALICE@email.com
+3,eve@email.com`)
+	db, err := memdb.NewMemDbData("lookup_users", [][]driver.Value{
+		{10, "bob@email.com", "bob"},
+		{11, "alice@Email.com", "alice"},
+		{12, "nobody@email.com", "nobody"},
+	}, []string{"uid", "email", "name"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, db.AddExprIndex("idx_email_lower", "tolower(email)"))
+	tbl, _ := db.Table("lookup_users")
+
+	run := func(sql string) []string {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		rows := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, fmt.Sprint(msg.(*datasource.SqlDriverMessageMap).Values()))
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	sql := `SELECT o.oid, u.name FROM lookup_orders AS o
+		INNER JOIN lookup_users AS u /*+ ROWS(1e6) */ ON tolower(o.email) = tolower(u.email)`
+	rows := run(sql)
+	assert.Equal(t, []string{"[1 bob]", "[2 alice]"}, rows)
+
+	ctx := td.TestContext(sql)
+	ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+	stmt, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	p, err := plan.WalkStmt(ctx, stmt, plan.NewPlanner(ctx))
+	assert.Equal(t, nil, err)
+	var lookup *plan.LookupJoin
+	for _, task := range p.Children() {
+		if jm, ok := task.(*plan.JoinMerge); ok {
+			lookup = jm.Lookup
+		}
+	}
+	if assert.NotEqual(t, nil, lookup) {
+		assert.Equal(t, "idx_email_lower", lookup.Index)
+	}
+
+	// where of the looked up source, same rows as the hash join
+	rows = run(`SELECT o.oid, u.name FROM lookup_orders AS o
+		INNER JOIN lookup_users AS u /*+ ROWS(1e6) */ ON tolower(o.email) = tolower(u.email)
+		WHERE u.name = "alice"`)
+	hashRows := run(`SELECT o.oid, u.name FROM lookup_orders AS o
+		INNER JOIN lookup_users AS u ON tolower(o.email) = tolower(u.email)
+		WHERE u.name = "alice"`)
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, hashRows, rows)
+}
+
+// keyFilterConn a memdb conn counting the rows its scan returns.
+type keyFilterConn struct {
+	schema.ConnAll
+	rows int
+}
+
+func (m *keyFilterConn) Next() schema.Message {
+	msg := m.ConnAll.Next()
+	if msg != nil {
+		m.rows++
+	}
+	return msg
+}
+func (m *keyFilterConn) SetKeyFilter(nodes []expr.Node, f schema.KeyFilter) {
+	m.ConnAll.(schema.ConnKeyFilter).SetKeyFilter(nodes, f)
+}
+
+type keyFilterSource struct {
+	*memdb.MemDb
+	conn *keyFilterConn
+}
+
+func (m *keyFilterSource) Open(table string) (schema.Conn, error) {
+	conn, err := m.MemDb.Open(table)
+	if err != nil {
+		return nil, err
+	}
+	m.conn = &keyFilterConn{ConnAll: conn.(schema.ConnAll)}
+	return m.conn, nil
+}
+
+func TestJoinKeyFilter(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "bloom_users", `user_id,name,city
+1,bob,Portland
+2,alice,Denver
+3,eve,Portland`)
+	data := make([][]driver.Value, 0)
+	for i := 0; i < 100; i++ {
+		data = append(data, []driver.Value{i, i%20 + 1, i * 10})
+	}
+	db, err := memdb.NewMemDbData("bloom_orders", data, []string{"order_id", "user_id", "price"})
+	assert.Equal(t, nil, err)
+	src := &keyFilterSource{MemDb: db}
+	tbl, _ := db.Table("bloom_orders")
+
+	run := func(sql string) []string {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, src)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		rows := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, fmt.Sprint(msg.(*datasource.SqlDriverMessageMap).Values()[:2]))
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	// the filtered users are the build side, the orders scan only returns
+	// the orders of its keys (and bloom filter false positives)
+	rows := run(`SELECT o.order_id, u.name FROM bloom_orders AS o
+		INNER JOIN bloom_users AS u ON o.user_id = u.user_id WHERE u.city = "Denver"`)
+	assert.Equal(t, []string{"[1 alice]", "[21 alice]", "[41 alice]", "[61 alice]", "[81 alice]"}, rows)
+	if assert.NotEqual(t, nil, src.conn) {
+		assert.True(t, src.conn.rows < 20, "scanned %d", src.conn.rows)
+	}
+
+	// probe side without SetKeyFilter filtered as it is scanned
+	selfRows, err := runSession(t, nil, `SELECT u.name, o.city FROM bloom_users AS o
+		INNER JOIN bloom_users AS u ON o.user_id = u.user_id`)
+	assert.Equal(t, nil, err)
+	sort.Slice(selfRows, func(i, j int) bool { return fmt.Sprint(selfRows[i]) < fmt.Sprint(selfRows[j]) })
+	assert.Equal(t, [][]interface{}{{"alice", "Denver"}, {"bob", "Portland"}, {"eve", "Portland"}}, selfRows)
+}
+
+func TestMatchAgainst(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "fulltext_docs", `id,title,body
+1,The quick fox,a fox jumps over the fox den
+2,Lazy dogs,dogs sleep all day
+3,Fox news,nothing about animals`)
+
+	rows, err := runSession(t, nil, `SELECT id, MATCH(title, body) AGAINST ("fox") AS score
+		FROM fulltext_docs WHERE MATCH(title, body) AGAINST ("fox")`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(rows))
+	// more occurrences of the term in less text scores higher
+	scores := make(map[interface{}]float64)
+	for _, row := range rows {
+		scores[row[0]] = row[1].(float64)
+	}
+	assert.True(t, scores["1"] > scores["3"], "%v", scores)
+
+	rows, err = runSession(t, nil, `SELECT id FROM fulltext_docs
+		WHERE MATCH(title, body) AGAINST ("+fox -animals") > 0 AND id != "2"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"1"}}, rows)
+}
+
+func TestSimilarity(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "fuzzy_people", `id,name
+1,John Smith
+2,Jon Smyth
+3,Mary Jones`)
+
+	rows, err := runSession(t, nil, `SELECT id FROM fuzzy_people WHERE name % "jon smith"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(rows))
+
+	rows, err = runSession(t, nil, `SELECT id, levenshtein(name, "Jon Smith") AS dist FROM fuzzy_people
+		ORDER BY similarity(name, "jon smith") DESC`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"1", int64(1)}, {"2", int64(1)}, {"3", int64(9)}}, rows)
+}
+
+func TestParentChildJoin(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "hbase_users", `id,name
+1,bob
+2,alice
+3,eve`)
+	mockcsv.LoadChildTable(mockcsv.SchemaName, "hbase_users", "hbase_users_profile", "id", "user_id", `user_id,city
+1,Portland
+2,Denver`)
+
+	sch := mockcsv.Schema()
+	profile, err := sch.Table("hbase_users_profile")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "hbase_users", profile.Parent)
+	assert.Equal(t, 1, len(sch.ChildTables("hbase_users")))
+
+	sorted := func(rows [][]interface{}) [][]interface{} {
+		sort.Slice(rows, func(i, j int) bool {
+			return fmt.Sprint(rows[i]) < fmt.Sprint(rows[j])
+		})
+		return rows
+	}
+
+	// no ON, joined on the column family row key
+	rows, err := runSession(t, nil, `SELECT u.name, p.city FROM hbase_users AS u
+		INNER JOIN hbase_users_profile AS p`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"alice", "Denver"}, {"bob", "Portland"}}, sorted(rows))
+
+	// child first, parent joined to it
+	rows, err = runSession(t, nil, `SELECT p.city, u.name FROM hbase_users_profile AS p
+		INNER JOIN hbase_users AS u`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"Denver", "alice"}, {"Portland", "bob"}}, sorted(rows))
+}
+
+func TestPrunedJoin(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "dim_users", `user_id,name
+1,bob
+2,alice`)
+	mockcsv.LoadTable(mockcsv.SchemaName, "fact_orders", `order_id,user_id,price
+1,1,10
+2,2,20
+3,1,30`)
+	sch := mockcsv.Schema()
+	users, err := sch.Table("dim_users")
+	assert.Equal(t, nil, err)
+	users.Indexes = append(users.Indexes, &schema.Index{Name: "pk", Fields: []string{"user_id"}, PrimaryKey: true})
+	orders, err := sch.Table("fact_orders")
+	assert.Equal(t, nil, err)
+	orders.AddForeignKey([]string{"user_id"}, "dim_users", []string{"user_id"})
+	fld, _ := orders.Field("user_id")
+	fld.NoNulls = true
+
+	// dim_users is not used, the join is pruned but results are the same
+	rows, err := runSession(t, nil, `SELECT o.order_id, o.price FROM fact_orders AS o
+		INNER JOIN dim_users AS u ON o.user_id = u.user_id WHERE o.price > 15`)
+	assert.Equal(t, nil, err)
+	sort.Slice(rows, func(i, j int) bool { return fmt.Sprint(rows[i]) < fmt.Sprint(rows[j]) })
+	assert.Equal(t, [][]interface{}{{"2", "20"}, {"3", "30"}}, rows)
+
+	rows, err = runSession(t, nil, `SELECT count(*) AS ct FROM fact_orders AS o
+		INNER JOIN dim_users AS u ON o.user_id = u.user_id`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{int64(3)}}, rows)
+
+	sql := `SELECT o.price FROM fact_orders AS o INNER JOIN dim_users AS u ON o.user_id = u.user_id`
+	stmt, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	explain, err := exec.Explain(td.TestContext(sql), stmt)
+	assert.Equal(t, nil, err)
+	for _, row := range explain {
+		assert.NotEqual(t, "join", row[2])
+	}
+}
+
+func TestPivot(t *testing.T) {
+	// mockcsv rows are keyed by the first column
+	mockcsv.LoadTable(mockcsv.SchemaName, "pivot_sales", `amount,region,quarter
+10,west,Q1
+5,west,Q1
+20,west,Q2
+7,east,Q1
+100,east,Q3`)
+	mockcsv.LoadTable(mockcsv.SchemaName, "pivot_wide", `region,q1,q2
+west,15,20
+east,7,`)
+
+	rows, err := runSession(t, nil, `SELECT * FROM pivot_sales
+		PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS second))`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"west", float64(15), float64(20)}, {"east", float64(7), nil}}, rows)
+
+	rows, err = runSession(t, nil, `SELECT pivot_sales.region, second FROM pivot_sales
+		PIVOT (count(amount) FOR quarter IN ("Q1", "Q2" AS second)) WHERE region = "west"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"west", int64(1)}}, rows)
+
+	rows, err = runSession(t, nil, `SELECT region, quarter, amount FROM pivot_wide
+		UNPIVOT (amount FOR quarter IN (q1, q2 AS second))`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"west", "q1", "15"}, {"west", "second", "20"}, {"east", "q1", "7"}}, rows)
+
+	_, err = runSession(t, nil, `SELECT * FROM pivot_sales PIVOT (sum(amount) FOR nope IN ("Q1"))`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestValuesTable(t *testing.T) {
+	rows, err := runSession(t, nil, `SELECT * FROM (VALUES (1, 'a'), (2, 'b'), (3, 'c')) AS t(id, name) WHERE id > 1`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{int64(2), "b"}, {int64(3), "c"}}, rows)
+
+	// un-named columns are column1, column2 ...
+	rows, err = runSession(t, nil, `SELECT column2 FROM (VALUES (1, lower('AZ')), (-2, 'b')) AS t`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"az"}, {"b"}}, rows)
+
+	// a small dimension joined to a table
+	rows, err = runSession(t, nil, `SELECT u.user_id, r.label FROM users AS u
+		INNER JOIN (VALUES ('9Ip1aKbeZe2njCDM', 'gold'), ('hT2impsabc345c', 'silver')) AS r(user_id, label)
+		ON u.user_id = r.user_id`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(rows), "%v", rows)
+
+	_, err = runSession(t, nil, `SELECT * FROM (VALUES (1, 'a'), (2)) AS t`)
+	assert.NotEqual(t, nil, err)
+	_, err = runSession(t, nil, `SELECT * FROM (VALUES (1, 'a' || 'z')) AS t`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTempTables(t *testing.T) {
+	temp := plan.NewTempTables()
+	run := func(sql string) ([][]interface{}, error) {
+		ctx := td.TestContext(sql)
+		ctx.Temp = temp
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		if err = job.Run(); err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(msgs))
+		for _, msg := range msgs {
+			var row []interface{}
+			if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+				for _, v := range mm.Values() {
+					row = append(row, v)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	_, err := run(`CREATE TEMPORARY TABLE scores (id int, name varchar(255), score int)`)
+	assert.Equal(t, nil, err)
+	_, err = run(`CREATE TEMP TABLE scores (id int)`)
+	assert.NotEqual(t, nil, err, "already exists")
+	_, err = run(`CREATE TEMP TABLE IF NOT EXISTS scores (id int)`)
+	assert.Equal(t, nil, err)
+
+	_, err = run(`INSERT INTO scores (id, name, score) VALUES (1, "bob", 10), (2, "sue", 30)`)
+	assert.Equal(t, nil, err)
+	rows, err := run(`SELECT name FROM scores WHERE score > 20`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"sue"}}, rows)
+
+	// a context without the session temp tables doesn't see them
+	_, err = runSession(t, nil, `SELECT name FROM scores`)
+	assert.NotEqual(t, nil, err)
+
+	rows, err = run(`SELECT user_id, email INTO TEMP active FROM users WHERE user_id = "hT2impsabc345c"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(rows))
+	rows, err = run(`SELECT email FROM active`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(rows), "%v", rows)
+	assert.Equal(t, []string{"active", "scores"}, temp.Names())
+
+	_, err = run(`DROP TABLE active`)
+	assert.Equal(t, nil, err)
+	_, err = run(`DROP TEMPORARY TABLE active`)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, []string{"scores"}, temp.Names())
+
+	// table options of the memdb source
+	_, err = run(`CREATE TEMPORARY TABLE cache (k varchar(255), v text) ENGINE=memdb TTL="10m"`)
+	assert.Equal(t, nil, err)
+	_, err = run(`CREATE TEMPORARY TABLE bad_cache (k varchar(255)) ENGINE=memdb TTL="soon"`)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, []string{"cache", "scores"}, temp.Names())
+
+	// closing the session drops them
+	ctx := td.TestContext("")
+	ctx.Temp = temp
+	assert.Equal(t, nil, ctx.Close())
+	assert.Equal(t, 0, len(temp.Names()))
+
+	_, err = runSession(t, nil, `CREATE TEMP TABLE scores (id int)`)
+	assert.NotEqual(t, nil, err, "temporary tables require a session")
+}
+
+func TestSourceTimeColumns(t *testing.T) {
+	db, err := memdb.NewMemDbData("tz_events", [][]driver.Value{
+		{1, int64(1350494979738), "2012-10-17 11:29:39"},
+		{2, int64(1260561211547), "2009-12-11 12:53:31"},
+	}, []string{"id", "created", "local"})
+	assert.Equal(t, nil, err)
+	tbl, _ := db.Table("tz_events")
+
+	run := func(sql string) [][]driver.Value {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+		ss, err := ctx.Schema.SchemaForTable("tz_events")
+		assert.Equal(t, nil, err)
+		// created is epoch millis, local is local-time in Denver
+		ss.Conf = &schema.ConfigSource{Settings: u.JsonHelper{
+			"time_zone": "America/Denver",
+			"time_columns": map[string]interface{}{
+				"created": map[string]interface{}{"time_unit": "ms"},
+				"local":   map[string]interface{}{},
+			},
+		}}
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		rows := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, msg.(*datasource.SqlDriverMessageMap).Values())
+		}
+		return rows
+	}
+
+	rows := run(`SELECT id, created, local FROM tz_events WHERE created > "2012-01-01T00:00:00Z"`)
+	if assert.Equal(t, 1, len(rows)) {
+		assert.Equal(t, time.Date(2012, 10, 17, 17, 29, 39, 738000000, time.UTC), rows[0][1])
+		assert.Equal(t, time.Date(2012, 10, 17, 17, 29, 39, 0, time.UTC), rows[0][2])
+	}
+	// compared as times, the local time is behind utc
+	rows = run(`SELECT id FROM tz_events WHERE local > "2009-12-11T19:00:00Z"`)
+	assert.Equal(t, 2, len(rows))
+	rows = run(`SELECT id FROM tz_events WHERE local > "2009-12-11T20:00:00Z"`)
+	assert.Equal(t, 1, len(rows))
+}
+
+func TestBinaryValues(t *testing.T) {
+	payload := []byte{0x0a, 0x00, 0xff, 'x'}
+	db, err := memdb.NewMemDbData("blob_events", [][]driver.Value{
+		{1, payload},
+		{2, []byte("plain")},
+	}, []string{"id", "payload"})
+	assert.Equal(t, nil, err)
+	tbl, _ := db.Table("blob_events")
+
+	run := func(sql string) [][]driver.Value {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err, sql)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		rows := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, msg.(*datasource.SqlDriverMessageMap).Values())
+		}
+		return rows
+	}
+
+	// binary is passed through as is, not mangled through strings
+	rows := run(`SELECT id, payload, hex(payload) AS h, length(payload) AS l FROM blob_events WHERE payload = X'0A00FF78'`)
+	if assert.Equal(t, 1, len(rows)) {
+		assert.Equal(t, payload, rows[0][1])
+		assert.Equal(t, "0A00FF78", rows[0][2])
+		assert.Equal(t, int64(4), rows[0][3])
+	}
+	rows = run(`SELECT id FROM blob_events WHERE payload = unhex("706C61696E")`)
+	assert.Equal(t, 1, len(rows))
+}
@@ -2,12 +2,16 @@ package exec
 
 import (
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource/membtree"
+	"github.com/araddon/qlbridge/metrics"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
 )
 
 var (
@@ -28,6 +32,7 @@ type JobExecutor struct {
 	Executor Executor
 	RootTask TaskRunner
 	Ctx      *plan.Context
+	Plan     plan.Task // root plan task, once walked
 	distinct bool
 	children []Task
 }
@@ -65,7 +70,7 @@ func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Conte
 	if ctx.Raw == "" {
 		return nil, fmt.Errorf("no sql provided")
 	}
-	stmt, err := rel.ParseSql(ctx.Raw)
+	stmt, err := rel.ParseSqlMode(ctx.Raw, ctx.Dialect)
 	if err != nil {
 		u.Debugf("could not parse sql : %v", err)
 		return nil, err
@@ -75,6 +80,42 @@ func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Conte
 	}
 	ctx.Stmt = stmt
 
+	// session temporary tables shadow tables of the schema
+	if ctx.Temp != nil {
+		ctx.Schema = ctx.Temp.Schema(ctx.Schema)
+	}
+	if err := resolveTables(ctx, stmt); err != nil {
+		return nil, err
+	}
+
+	if sel, ok := stmt.(*rel.SqlSelect); ok {
+		plan.ApplySelectLimit(ctx, sel)
+		if err := rewritePivots(ctx, sel); err != nil {
+			return nil, err
+		}
+		if err := rewriteValues(ctx, sel); err != nil {
+			return nil, err
+		}
+	}
+
+	// CALL procedure(args) is run as a select of its result rows
+	if call, ok := stmt.(*rel.SqlCall); ok {
+		sel, err := rewriteCall(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		stmt = sel
+	}
+
+	// EXPLAIN statement is run as a select of its plan rows
+	if desc, ok := stmt.(*rel.SqlDescribe); ok && desc.Stmt != nil {
+		sel, err := rewriteExplain(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		stmt = sel
+	}
+
 	pln, err := plan.WalkStmt(ctx, stmt, planner)
 
 	if err != nil {
@@ -107,6 +148,7 @@ func (m *JobExecutor) NewTask(p plan.Task) Task {
 
 // WalkPlan Main Entry point to take a Plan, and convert into Execution DAG
 func (m *JobExecutor) WalkPlan(p plan.Task) (Task, error) {
+	m.Plan = p
 	switch p := p.(type) {
 	case *plan.PreparedStatement:
 		return m.Executor.WalkPreparedStatement(p)
@@ -140,9 +182,10 @@ func (m *JobExecutor) WalkPlan(p plan.Task) (Task, error) {
 	panic(fmt.Sprintf("Not implemented for %T", p))
 }
 
-// WalkPreparedStatement not implemented
+// WalkPreparedStatement save the prepared statement to the session.
 func (m *JobExecutor) WalkPreparedStatement(p *plan.PreparedStatement) (Task, error) {
-	return nil, ErrNotImplemented
+	root := m.NewTask(p)
+	return root, root.Add(NewPrepare(m.Ctx, p))
 }
 
 // DML
@@ -150,7 +193,16 @@ func (m *JobExecutor) WalkPreparedStatement(p *plan.PreparedStatement) (Task, er
 // WalkSelect create dag of plan Select.
 func (m *JobExecutor) WalkSelect(p *plan.Select) (Task, error) {
 	root := m.NewTask(p)
-	return root, m.WalkChildren(p, root)
+	if err := m.WalkChildren(p, root); err != nil {
+		return root, err
+	}
+	if p.Stmt.Into != nil && len(p.Stmt.Into.Vars) > 0 {
+		return root, root.Add(NewIntoVars(m.Ctx, p.Stmt.Into.Vars))
+	}
+	if p.Stmt.Into != nil && p.Stmt.Into.Temp {
+		return root, root.Add(NewIntoTemp(m.Ctx, p, p.Stmt.Into.Table))
+	}
+	return root, nil
 }
 func (m *JobExecutor) WalkUpsert(p *plan.Upsert) (Task, error) {
 	root := m.NewTask(p)
@@ -183,7 +235,7 @@ func (m *JobExecutor) WalkSource(p *plan.Source) (Task, error) {
 			u.Warnf("no datasource")
 			return nil, fmt.Errorf("missing data source")
 		}
-		source, err := p.DataSource.Open(p.Stmt.SourceName())
+		source, err := p.OpenConn()
 		if err != nil {
 			return nil, err
 		}
@@ -204,7 +256,7 @@ func (m *JobExecutor) WalkSourceExec(p *plan.Source) (Task, error) {
 			u.Warnf("no datasource")
 			return nil, fmt.Errorf("missing data source")
 		}
-		source, err := p.DataSource.Open(p.Stmt.SourceName())
+		source, err := p.OpenConn()
 		if err != nil {
 			return nil, err
 		}
@@ -225,9 +277,15 @@ func (m *JobExecutor) WalkHaving(p *plan.Having) (Task, error) {
 	return NewHaving(m.Ctx, p), nil
 }
 func (m *JobExecutor) WalkGroupBy(p *plan.GroupBy) (Task, error) {
+	if p.Merge {
+		return NewGroupByFinal(m.Ctx, p), nil
+	}
 	return NewGroupBy(m.Ctx, p), nil
 }
 func (m *JobExecutor) WalkOrder(p *plan.Order) (Task, error) {
+	if p.Limit > 0 {
+		return NewTopN(m.Ctx, p), nil
+	}
 	return NewOrder(m.Ctx, p), nil
 }
 func (m *JobExecutor) WalkProjection(p *plan.Projection) (Task, error) {
@@ -246,6 +304,17 @@ func (m *JobExecutor) WalkJoin(p *plan.JoinMerge) (Task, error) {
 		u.Errorf("whoops %T  %v", l, err)
 		return nil, err
 	}
+	if p.Lookup != nil {
+		// right source is read from its index, not scanned
+		if src, ok := p.Right.(*plan.Source); ok {
+			if seeker, ok := src.Conn.(schema.ConnIndexSeeker); ok {
+				if err = execTask.Add(NewJoinLookup(m.Ctx, l.(TaskRunner), seeker, p)); err != nil {
+					return nil, err
+				}
+				return execTask, nil
+			}
+		}
+	}
 	r, err := m.WalkPlanAll(p.Right)
 	if err != nil {
 		return nil, err
@@ -255,13 +324,68 @@ func (m *JobExecutor) WalkJoin(p *plan.JoinMerge) (Task, error) {
 		return nil, err
 	}
 
-	jm := NewJoinNaiveMerge(m.Ctx, l.(TaskRunner), r.(TaskRunner), p)
+	var jm TaskRunner
+	if p.Similarity != nil {
+		jm = NewJoinSimilarity(m.Ctx, l.(TaskRunner), r.(TaskRunner), p)
+	} else {
+		jmerge := NewJoinNaiveMerge(m.Ctx, l.(TaskRunner), r.(TaskRunner), p)
+		m.joinKeyFilter(jmerge, p, l, r)
+		jm = jmerge
+	}
 	err = execTask.Add(jm)
 	if err != nil {
 		return nil, err
 	}
 	return execTask, nil
 }
+
+// joinKeyFilter push a bloom filter of the join keys of the build side of
+// join jm to the scan of its probe side.  The build side is the filtered
+// one, ie the dimension of a star-schema join, else the left.
+func (m *JobExecutor) joinKeyFilter(jm *JoinMerge, p *plan.JoinMerge, l, r Task) {
+	hasWhere := func(from *rel.SqlSource) bool {
+		return from != nil && from.Source != nil && from.Source.Where != nil
+	}
+	jm.buildRight = hasWhere(p.RightFrom) && !hasWhere(p.LeftFrom)
+	probePlan, probe := p.Right, r
+	if jm.buildRight {
+		probePlan, probe = p.Left, l
+	}
+	ps, ok := probePlan.(*plan.Source)
+	if !ok {
+		return
+	}
+	if src := findSource(probe, ps); src != nil && src.Scanner != nil {
+		jm.keyFilter = newJoinKeyFilter()
+		src.keyFilter = jm.keyFilter
+	}
+}
+func (m *JobExecutor) WalkScatter(p *plan.Scatter) (Task, error) {
+	execTask := NewTaskParallel(m.Ctx)
+	shards := make([]TaskRunner, 0, len(p.Shards))
+	for _, shard := range p.Shards {
+		t, err := m.WalkPlanAll(shard)
+		if err != nil {
+			return nil, err
+		}
+		// the gather reads each shards own output channel
+		if _, ok := t.(*TaskSequential); !ok {
+			seq := NewTaskSequential(m.Ctx)
+			if err = seq.Add(t); err != nil {
+				return nil, err
+			}
+			t = seq
+		}
+		if err = execTask.Add(t); err != nil {
+			return nil, err
+		}
+		shards = append(shards, t.(TaskRunner))
+	}
+	if err := execTask.Add(NewGather(m.Ctx, shards)); err != nil {
+		return nil, err
+	}
+	return execTask, nil
+}
 func (m *JobExecutor) WalkJoinKey(p *plan.JoinKey) (Task, error) {
 	return NewJoinKey(m.Ctx, p), nil
 }
@@ -302,6 +426,8 @@ func (m *JobExecutor) WalkPlanTask(p plan.Task) (Task, error) {
 		return m.Executor.WalkJoin(p)
 	case *plan.JoinKey:
 		return m.Executor.WalkJoinKey(p)
+	case *plan.Scatter:
+		return m.Executor.WalkScatter(p)
 	}
 	panic(fmt.Sprintf("Task plan-exec Not implemented for %T", p))
 }
@@ -385,8 +511,45 @@ func (m *JobExecutor) Setup() error {
 }
 
 // Run this task
-func (m *JobExecutor) Run() error {
-	return m.RootTask.Run()
+func (m *JobExecutor) Run() (err error) {
+	start := time.Now()
+	if metrics.Enabled() {
+		kind := m.statementKind()
+		metrics.Get().QueryStarted(kind)
+		defer func() {
+			metrics.Get().QueryFinished(kind, time.Since(start), err)
+		}()
+	}
+	defer func() { countQuery(err) }()
+	// wait for admission, if limited, see SetAdmission
+	release, err := admit(m.Ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	// memory held by sort, aggregate, join operators is charged to the
+	// query, failing it when over its limit
+	if m.Ctx.Memory == nil {
+		m.Ctx.Memory = plan.NewMemoryAccount(m.Ctx.QueryMemoryLimit(), EngineMemory)
+	}
+	// SELECT statements are interrupted after session max_execution_time,
+	// or the deadline of the client budget, go context
+	var timedOut int32
+	if _, isSelect := m.Ctx.Stmt.(*rel.SqlSelect); isSelect {
+		if deadline, ok := m.Ctx.QueryDeadline(start); ok {
+			timer := time.AfterFunc(deadline.Sub(start), func() {
+				atomic.StoreInt32(&timedOut, 1)
+				m.RootTask.Close()
+			})
+			defer timer.Stop()
+		}
+	}
+	err = runLabeled(m.Ctx, m.RootTask.Run)
+	if atomic.LoadInt32(&timedOut) == 1 {
+		err = ErrMaxExecutionTime
+	}
+	m.logSlowQuery(start, err)
+	return err
 }
 
 // Close the normal close of root task
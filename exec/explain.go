@@ -0,0 +1,383 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource/membtree"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// ExplainColumns the result columns of EXPLAIN, one row per step of
+	// the plan, parent is the id of the enclosing step (0 for the root).
+	ExplainColumns = []string{"id", "parent", "operation", "target", "detail"}
+)
+
+// Explain the plan of stmt as rows of ExplainColumns, the statement is
+// planned but not run.  Selects show their task pipeline, INSERT, UPDATE,
+// DELETE the source receiving the writes, how it is written, constraint
+// checks and the select feeding an INSERT ... SELECT.  DDL statements
+// are a dry run, checked against the schema but not applied.
+//
+//    EXPLAIN DELETE FROM users WHERE user_id = "abc"
+//
+//    id  parent  operation  target  detail
+//    1   0       delete     users   source=*membtree.StaticDataSource method=delete_expression
+//    2   1       where      users   user_id = "abc"
+func Explain(ctx *plan.Context, stmt rel.SqlStatement) ([][]driver.Value, error) {
+	e := &explainer{ctx: ctx}
+	if err := e.statement(0, stmt); err != nil {
+		return nil, err
+	}
+	return e.rows, nil
+}
+
+// rewriteExplain EXPLAIN statement is run as a select of its plan rows.
+func rewriteExplain(ctx *plan.Context, desc *rel.SqlDescribe) (*rel.SqlSelect, error) {
+	rows, err := Explain(ctx, desc.Stmt)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Analyze {
+		detail, err := explainAnalyze(ctx, desc.Stmt)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, []driver.Value{int64(len(rows) + 1), int64(0), "analyze", "-", detail})
+	}
+	src := membtree.NewStaticDataSource("explain", 0, rows, ExplainColumns)
+	tbl, _ := src.Table("explain")
+	sel, err := rel.ParseSqlSelect("SELECT " + strings.Join(ExplainColumns, ", ") + " FROM explain")
+	if err != nil {
+		return nil, err
+	}
+	schemaName := tbl.Name
+	if ctx.Schema != nil {
+		schemaName = ctx.Schema.Name
+	}
+	ctx.Schema = schema.NewSchemaTable(schemaName, tbl, src)
+	ctx.Stmt = sel
+	return sel, nil
+}
+
+// explainAnalyze run the select, the detail of what it actually did: rows
+// returned, time taken and the peak memory held by its operators.
+//
+//    EXPLAIN ANALYZE SELECT user_id, count(*) FROM orders GROUP BY user_id
+//
+//    id  parent  operation  target  detail
+//    ...
+//    7   0       analyze    -       rows=2 time=1.2ms peak_memory=4096
+func explainAnalyze(ctx *plan.Context, stmt rel.SqlStatement) (string, error) {
+	if _, ok := stmt.(*rel.SqlSelect); !ok {
+		return "", fmt.Errorf("EXPLAIN ANALYZE not supported for %T", stmt)
+	}
+	raw := stmt.String()
+	actx := connContext(ctx, raw)
+	start := time.Now()
+	res := runBatchStatement(actx, raw)
+	if res.Err != nil {
+		return "", res.Err
+	}
+	return fmt.Sprintf("rows=%d time=%v peak_memory=%d", len(res.Rows), time.Since(start), actx.Memory.Peak()), nil
+}
+
+type explainer struct {
+	ctx  *plan.Context
+	rows [][]driver.Value
+}
+
+func (m *explainer) add(parent int64, op, target, detail string) int64 {
+	id := int64(len(m.rows) + 1)
+	m.rows = append(m.rows, []driver.Value{id, parent, op, target, detail})
+	return id
+}
+
+func (m *explainer) statement(parent int64, stmt rel.SqlStatement) error {
+	switch st := stmt.(type) {
+	case *rel.SqlSelect:
+		return m.selectPlan(parent, st)
+	case *rel.SqlInsert:
+		return m.insert(parent, st)
+	case *rel.SqlUpsert:
+		id, err := m.write(parent, "upsert", st)
+		if err != nil {
+			return err
+		}
+		m.add(id, "values", st.Table, fmt.Sprintf("rows=%d", len(st.Rows)))
+		if st.Where != nil {
+			m.add(id, "where", st.Table, st.Where.String())
+		}
+		return nil
+	case *rel.SqlUpdate:
+		return m.update(parent, st)
+	case *rel.SqlDelete:
+		id, err := m.write(parent, "delete", st)
+		if err != nil {
+			return err
+		}
+		if st.Where != nil {
+			m.add(id, "where", st.Table, st.Where.String())
+		}
+		if st.Limit > 0 {
+			m.add(id, "limit", st.Table, fmt.Sprintf("%d", st.Limit))
+		}
+		return nil
+	case *rel.SqlCreate:
+		return m.create(parent, st)
+	case *rel.SqlDrop:
+		return m.drop(parent, st)
+	case *rel.SqlAlter:
+		m.add(parent, "alter "+strings.ToLower(st.Tok.V), st.Identity, "dry run, not supported by executor")
+		return nil
+	}
+	return fmt.Errorf("EXPLAIN not supported for %T", stmt)
+}
+
+// selectPlan plan the select (in its own context, not ctx) and add its tasks.
+func (m *explainer) selectPlan(parent int64, sel *rel.SqlSelect) error {
+	sctx := connContext(m.ctx, sel.String())
+	sctx.Stmt = sel
+	if err := rewritePivots(sctx, sel); err != nil {
+		return err
+	}
+	if err := rewriteValues(sctx, sel); err != nil {
+		return err
+	}
+	p, err := plan.WalkStmt(sctx, sel, plan.NewPlanner(sctx))
+	if err != nil {
+		return err
+	}
+	m.task(parent, p, "")
+	return nil
+}
+
+// task add the step of plan task t, and its children.  Steps without a
+// source of their own (where, projection ...) target the tables of the
+// enclosing select.
+func (m *explainer) task(parent int64, t plan.Task, target string) {
+	var id int64
+	switch p := t.(type) {
+	case *plan.Select:
+		detail := "sequential"
+		if p.IsParallel() {
+			detail = "parallel"
+		}
+		names := make([]string, 0, len(p.Stmt.From))
+		for _, from := range p.Stmt.From {
+			names = append(names, from.SourceName())
+		}
+		target = strings.Join(names, ", ")
+		if target == "" {
+			target = "-"
+		}
+		id = m.add(parent, "select", target, detail)
+	case *plan.Source:
+		if p.Conn != nil {
+			defer p.Conn.Close()
+		}
+		if len(p.Static) > 0 {
+			id = m.add(parent, "source", target, "static")
+			break
+		}
+		detail := fmt.Sprintf("source=%T rows=%.0f cost=%.2f", p.Conn, p.EstimatedRows, p.Cost)
+		if p.Shard != nil {
+			detail += " shard=" + p.Shard.Name
+		} else if _, pushdown := p.Conn.(plan.SourcePlanner); pushdown && !p.Hints.HasTable(rel.HintNoPushdown, p.Stmt) {
+			detail += " pushdown"
+		}
+		if p.PartialAgg {
+			detail += " partial-agg"
+		}
+		target = p.Stmt.SourceName()
+		id = m.add(parent, "scan", target, detail)
+	case *plan.Where:
+		detail := "-"
+		if p.Stmt.Where != nil {
+			detail = p.Stmt.Where.String()
+		}
+		if p.Workers > 1 {
+			detail += fmt.Sprintf(" parallel=%d", p.Workers)
+		}
+		id = m.add(parent, "where", target, detail)
+	case *plan.Having:
+		detail := "-"
+		if p.Stmt.Having != nil {
+			detail = p.Stmt.Having.String()
+		}
+		id = m.add(parent, "having", target, detail)
+	case *plan.GroupBy:
+		detail := p.Stmt.GroupBy.String()
+		if p.Partial {
+			detail += " partial"
+		}
+		if p.Merge {
+			detail += " merge"
+		}
+		id = m.add(parent, "group by", target, detail)
+	case *plan.Order:
+		detail := p.Stmt.OrderBy.String()
+		if p.Limit > 0 {
+			detail += fmt.Sprintf(" top=%d", p.Limit)
+		}
+		id = m.add(parent, "order by", target, detail)
+	case *plan.Projection:
+		detail := "source"
+		if p.Final {
+			detail = "final"
+		}
+		id = m.add(parent, "projection", target, fmt.Sprintf("%s %s", detail, p.Stmt.Columns.String()))
+	case *plan.JoinMerge:
+		detail := "key"
+		if p.Similarity != nil {
+			detail = "similarity"
+		}
+		if p.Lookup != nil {
+			detail = "lookup " + p.Lookup.Index
+		}
+		id = m.add(parent, "join", p.LeftFrom.SourceName()+", "+p.RightFrom.SourceName(), detail)
+		m.task(id, p.Left, target)
+		m.task(id, p.Right, target)
+	case *plan.Scatter:
+		target = p.Stmt.From[0].SourceName()
+		id = m.add(parent, "scatter", target, fmt.Sprintf("shards=%d", len(p.Shards)))
+		for _, shard := range p.Shards {
+			m.task(id, shard, target)
+		}
+	case *plan.JoinKey:
+		target = p.Source.Stmt.SourceName()
+		keys := make([]string, 0, 1)
+		for _, n := range p.Source.Stmt.JoinNodes() {
+			keys = append(keys, n.String())
+		}
+		id = m.add(parent, "join key", target, strings.Join(keys, ", "))
+	case *plan.Into:
+		id = m.add(parent, "into", p.Stmt.Table, "-")
+	default:
+		id = m.add(parent, strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", t), "*plan.")), target, "-")
+	}
+	for _, c := range t.Children() {
+		m.task(id, c, target)
+	}
+}
+
+// write plan the write statement, adding the step of its target source.
+func (m *explainer) write(parent int64, op string, stmt rel.SqlStatement) (int64, error) {
+	wctx := connContext(m.ctx, stmt.String())
+	wctx.Stmt = stmt
+	p, err := plan.WalkStmt(wctx, stmt, plan.NewPlanner(wctx))
+	if err != nil {
+		return 0, err
+	}
+	var src interface{}
+	var table, method string
+	switch p := p.(type) {
+	case *plan.Insert:
+		src, table, method = p.Source, p.Stmt.Table, "put batch=1"
+	case *plan.Upsert:
+		src, table, method = p.Source, p.Stmt.Table, "put batch=1"
+	case *plan.Update:
+		src, table, method = p.Source, p.Stmt.Table, "put key"
+		if _, ok := p.Source.(schema.ConnPatchWhere); ok {
+			method = "patch_where"
+		}
+	case *plan.Delete:
+		src, table, method = p.Source, p.Stmt.Table, "delete_expression"
+	}
+	if closer, ok := src.(schema.Source); ok {
+		closer.Close()
+	}
+	return m.add(parent, op, table, fmt.Sprintf("source=%T method=%s", src, method)), nil
+}
+
+func (m *explainer) insert(parent int64, st *rel.SqlInsert) error {
+	op := "insert"
+	if st.Keyword() == lex.TokenReplace {
+		op = "replace"
+	}
+	id, err := m.write(parent, op, st)
+	if err != nil {
+		return err
+	}
+	constraint := "none"
+	if m.ctx.StrictMode() {
+		constraint = "strict sql_mode, values must convert to column types"
+	}
+	m.add(id, "constraint", st.Table, constraint)
+	if st.Select != nil {
+		vid := m.add(id, "values", st.Table, "select")
+		return m.selectPlan(vid, st.Select)
+	}
+	m.add(id, "values", st.Table, fmt.Sprintf("rows=%d", len(st.Rows)))
+	return nil
+}
+
+func (m *explainer) update(parent int64, st *rel.SqlUpdate) error {
+	id, err := m.write(parent, "update", st)
+	if err != nil {
+		return err
+	}
+	cols := make([]string, 0, len(st.Values))
+	for col := range st.Values {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	m.add(id, "set", st.Table, strings.Join(cols, ", "))
+	if st.Where != nil {
+		m.add(id, "where", st.Table, st.Where.String())
+	}
+	return nil
+}
+
+// create dry run of CREATE, checked against the schema but not applied.
+func (m *explainer) create(parent int64, st *rel.SqlCreate) error {
+	op := "create " + strings.ToLower(st.Tok.V)
+	switch st.Tok.T {
+	case lex.TokenSource, lex.TokenSchema:
+		m.add(parent, op, st.Identity, fmt.Sprintf("dry run, type=%v", st.With["type"]))
+		return nil
+	case lex.TokenSample:
+		if m.ctx.Schema != nil {
+			if _, err := m.ctx.Schema.Table(st.Identity); err == nil {
+				m.add(parent, op, st.Identity, "dry run, would fail: table exists")
+				return nil
+			}
+		}
+		id := m.add(parent, op, st.Identity, "dry run")
+		if st.Select != nil {
+			return m.selectPlan(id, st.Select)
+		}
+		return nil
+	}
+	m.add(parent, op, st.Identity, "dry run, not supported by executor")
+	return nil
+}
+
+// drop dry run of DROP, checked against the schema but not applied.
+func (m *explainer) drop(parent int64, st *rel.SqlDrop) error {
+	op := "drop " + strings.ToLower(st.Tok.V)
+	switch st.Tok.T {
+	case lex.TokenTable:
+		if m.ctx.Schema == nil {
+			m.add(parent, op, st.Identity, "dry run, would fail: no schema")
+			return nil
+		}
+		if _, err := m.ctx.Schema.Table(st.Identity); err != nil {
+			m.add(parent, op, st.Identity, "dry run, would fail: unknown table")
+			return nil
+		}
+	case lex.TokenSource, lex.TokenSchema:
+	default:
+		m.add(parent, op, st.Identity, "dry run, not supported by executor")
+		return nil
+	}
+	m.add(parent, op, st.Identity, "dry run")
+	return nil
+}
@@ -0,0 +1,97 @@
+package exec_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type explainRow struct {
+	id, parent         int64
+	op, target, detail string
+}
+
+func explainRows(t *testing.T, db *sql.DB, q string) []explainRow {
+	rows, err := db.Query(q)
+	assert.Equal(t, nil, err, q)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	cols, _ := rows.Columns()
+	assert.Equal(t, []string{"id", "parent", "operation", "target", "detail"}, cols)
+	var out []explainRow
+	for rows.Next() {
+		var r explainRow
+		assert.Equal(t, nil, rows.Scan(&r.id, &r.parent, &r.op, &r.target, &r.detail))
+		out = append(out, r)
+	}
+	assert.Equal(t, nil, rows.Err())
+	return out
+}
+
+func TestExplain(t *testing.T) {
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	rows := explainRows(t, db, `EXPLAIN SELECT user_id FROM users WHERE referral_count > 1`)
+	assert.True(t, len(rows) >= 3, "%v", rows)
+	assert.Equal(t, explainRow{1, 0, "select", "users", "sequential"}, rows[0])
+	assert.Equal(t, "scan", rows[1].op)
+	assert.Equal(t, int64(1), rows[1].parent)
+	assert.Equal(t, "projection", rows[len(rows)-1].op)
+
+	rows = explainRows(t, db, `EXPLAIN DELETE FROM users WHERE user_id = "abc"`)
+	assert.Equal(t, 2, len(rows), "%v", rows)
+	assert.Equal(t, "delete", rows[0].op)
+	assert.Equal(t, "users", rows[0].target)
+	assert.Contains(t, rows[0].detail, "method=delete_expression")
+	assert.Equal(t, explainRow{2, 1, "where", "users", `user_id = "abc"`}, rows[1])
+
+	rows = explainRows(t, db, `EXPLAIN UPDATE users SET email = "x", interests = "y" WHERE user_id = "abc"`)
+	assert.Equal(t, 3, len(rows), "%v", rows)
+	assert.Equal(t, "update", rows[0].op)
+	assert.Equal(t, explainRow{2, 1, "set", "users", "email, interests"}, rows[1])
+
+	rows = explainRows(t, db, `EXPLAIN INSERT INTO users (user_id, email) SELECT user_id, email FROM users WHERE referral_count > 50`)
+	assert.True(t, len(rows) > 4, "%v", rows)
+	assert.Equal(t, "insert", rows[0].op)
+	assert.Contains(t, rows[0].detail, "batch=1")
+	assert.Equal(t, explainRow{2, 1, "constraint", "users", "none"}, rows[1])
+	assert.Equal(t, explainRow{3, 1, "values", "users", "select"}, rows[2])
+	assert.Equal(t, explainRow{4, 3, "select", "users", "sequential"}, rows[3])
+
+	// ddl is a dry run
+	rows = explainRows(t, db, `EXPLAIN DROP TABLE users`)
+	assert.Equal(t, []explainRow{{1, 0, "drop table", "users", "dry run"}}, rows)
+	rows = explainRows(t, db, `EXPLAIN DROP TABLE not_a_table`)
+	assert.Equal(t, []explainRow{{1, 0, "drop table", "not_a_table", "dry run, would fail: unknown table"}}, rows)
+	var ct int
+	assert.Equal(t, nil, db.QueryRow(`SELECT count(*) FROM users`).Scan(&ct))
+	assert.Equal(t, 3, ct)
+}
+
+func TestOptimizerHintsQuery(t *testing.T) {
+	q := `SELECT /*+ PARALLEL(3) */ user_id, order_id FROM orders WHERE price > 20 ORDER BY order_id ASC`
+	rows, err := runSession(t, nil, q)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{
+		{"9Ip1aKbeZe2njCDM", "1"},
+		{"9Ip1aKbeZe2njCDM", "2"},
+		{"abcabcabc", "3"},
+	}, rows)
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	found := false
+	for _, row := range explainRows(t, db, "EXPLAIN "+q) {
+		if row.op == "where" {
+			found = true
+			assert.Equal(t, "price > 20 parallel=3", row.detail)
+		}
+	}
+	assert.True(t, found, "expected a where step")
+}
@@ -0,0 +1,200 @@
+package exec
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+// partialValue a value with its type for partial aggregate state, so an
+// int is merged as an int not a json float.
+type partialValue struct {
+	T value.ValueType `json:"t"`
+	V json.RawMessage `json:"v"`
+}
+
+func marshalValues(vals ...value.Value) []byte {
+	pvs := make([]partialValue, len(vals))
+	for i, v := range vals {
+		if v == nil || v.Nil() {
+			pvs[i] = partialValue{T: value.NilType, V: json.RawMessage("null")}
+			continue
+		}
+		by, err := json.Marshal(v.Value())
+		if err != nil {
+			by, _ = json.Marshal(v.ToString())
+		}
+		pvs[i] = partialValue{T: v.Type(), V: by}
+	}
+	by, _ := json.Marshal(pvs)
+	return by
+}
+
+func unmarshalValues(data []byte) ([]value.Value, error) {
+	var pvs []partialValue
+	if err := json.Unmarshal(data, &pvs); err != nil {
+		return nil, err
+	}
+	vals := make([]value.Value, len(pvs))
+	for i, pv := range pvs {
+		var err error
+		switch pv.T {
+		case value.NilType:
+			vals[i] = value.NewNilValue()
+		case value.IntType:
+			var iv int64
+			err = json.Unmarshal(pv.V, &iv)
+			vals[i] = value.NewIntValue(iv)
+		case value.NumberType:
+			var fv float64
+			err = json.Unmarshal(pv.V, &fv)
+			vals[i] = value.NewNumberValue(fv)
+		case value.TimeType:
+			var tv time.Time
+			err = json.Unmarshal(pv.V, &tv)
+			vals[i] = value.NewTimeValue(tv)
+		default:
+			var v interface{}
+			err = json.Unmarshal(pv.V, &v)
+			vals[i] = value.NewValue(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// compareValues -1, 0, 1 as l is less, equal or greater than r.  Times
+// and numbers (including numeric strings) compare as such, else as strings.
+func compareValues(l, r value.Value) int {
+	if lt, ok := l.(value.TimeValue); ok {
+		if rt, ok := value.ValueToTime(r); ok {
+			switch {
+			case lt.Val().Before(rt):
+				return -1
+			case lt.Val().After(rt):
+				return 1
+			}
+			return 0
+		}
+	}
+	if lf, ok := value.ValueToFloat64(l); ok {
+		if rf, ok := value.ValueToFloat64(r); ok {
+			switch {
+			case lf < rf:
+				return -1
+			case lf > rf:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(l.ToString(), r.ToString())
+}
+
+type firstLast struct {
+	partial bool
+	last    bool
+	v       value.Value
+}
+
+func (m *firstLast) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	if m.v == nil || m.last {
+		m.v = v
+	}
+}
+func (m *firstLast) Result() interface{} {
+	if m.partial {
+		ct := int64(0)
+		if m.v != nil {
+			ct = 1
+		}
+		return &AggPartial{Ct: ct, Sketch: marshalValues(m.v)}
+	}
+	if m.v == nil {
+		return nil
+	}
+	return m.v.Value()
+}
+func (m *firstLast) Reset() { m.v = nil }
+func (m *firstLast) Merge(a *AggPartial) {
+	vals, err := unmarshalValues(a.Sketch)
+	if err != nil || len(vals) != 1 {
+		u.Warnf("could not merge first/last %v", err)
+		return
+	}
+	m.Do(vals[0])
+}
+
+// NewFirst first(), last() aggregators, in input order.  Partitions are
+// merged in the order their partial results arrive.
+func NewFirst(col *rel.Column, partial, last bool) Aggregator {
+	return &firstLast{partial: partial, last: last}
+}
+
+type argMax struct {
+	partial bool
+	min     bool
+	v       value.Value
+	by      value.Value
+}
+
+func (m *argMax) Do(v value.Value) {
+	sv, ok := v.(value.SliceValue)
+	if !ok || sv.Len() != 2 {
+		return
+	}
+	vals := sv.Val()
+	m.add(vals[0], vals[1])
+}
+func (m *argMax) add(v, by value.Value) {
+	if by == nil || by.Nil() {
+		return
+	}
+	if m.by != nil {
+		// ties keep the first
+		c := compareValues(by, m.by)
+		if (m.min && c >= 0) || (!m.min && c <= 0) {
+			return
+		}
+	}
+	m.v, m.by = v, by
+}
+func (m *argMax) Result() interface{} {
+	if m.partial {
+		if m.by == nil {
+			return &AggPartial{}
+		}
+		return &AggPartial{Ct: 1, Sketch: marshalValues(m.v, m.by)}
+	}
+	if m.v == nil || m.v.Nil() {
+		return nil
+	}
+	return m.v.Value()
+}
+func (m *argMax) Reset() { m.v, m.by = nil, nil }
+func (m *argMax) Merge(a *AggPartial) {
+	if a.Ct == 0 {
+		return
+	}
+	vals, err := unmarshalValues(a.Sketch)
+	if err != nil || len(vals) != 2 {
+		u.Warnf("could not merge arg_max %v", err)
+		return
+	}
+	m.add(vals[0], vals[1])
+}
+
+// NewArgMax arg_max(value, by), arg_min(value, by) aggregators.
+func NewArgMax(col *rel.Column, partial, min bool) Aggregator {
+	return &argMax{partial: partial, min: min}
+}
@@ -0,0 +1,50 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/value"
+)
+
+func TestFirstLastArgMax(t *testing.T) {
+	rows, err := runSession(t, nil, `SELECT user_id, first(order_id), last(order_id), arg_max(item_id, price), arg_min(order_id, price)
+		FROM orders GROUP BY user_id ORDER BY user_id ASC`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{
+		{"9Ip1aKbeZe2njCDM", "1", "2", "2", "1"},
+		{"abcabcabc", "3", "3", "1", "3"},
+	}, rows)
+
+	_, err = runSession(t, nil, `SELECT arg_max(item_id) FROM orders`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestFirstLastArgMaxMerge(t *testing.T) {
+	pair := func(v, by value.Value) value.Value {
+		return value.NewSliceValues([]value.Value{v, by})
+	}
+	// partitions
+	p1, p2 := exec.NewArgMax(nil, true, false), exec.NewArgMax(nil, true, false)
+	p1.Do(pair(value.NewStringValue("a"), value.NewIntValue(9)))
+	p1.Do(pair(value.NewStringValue("b"), value.NewIntValue(100)))
+	p2.Do(pair(value.NewStringValue("c"), value.NewStringValue("20")))
+	p2.Do(pair(value.NewStringValue("d"), value.NewNilValue()))
+
+	final := exec.NewArgMax(nil, false, false)
+	final.Merge(p1.Result().(*exec.AggPartial))
+	final.Merge(p2.Result().(*exec.AggPartial))
+	final.Merge(exec.NewArgMax(nil, true, false).Result().(*exec.AggPartial))
+	assert.Equal(t, "b", final.Result())
+
+	f1, f2 := exec.NewFirst(nil, true, false), exec.NewFirst(nil, true, false)
+	f1.Do(value.NewNilValue())
+	f2.Do(value.NewIntValue(7))
+	f2.Do(value.NewIntValue(8))
+	first := exec.NewFirst(nil, false, false)
+	first.Merge(f1.Result().(*exec.AggPartial))
+	first.Merge(f2.Result().(*exec.AggPartial))
+	assert.Equal(t, int64(7), first.Result())
+}
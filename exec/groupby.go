@@ -3,7 +3,10 @@ package exec
 import (
 	"database/sql/driver"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -84,10 +87,13 @@ func (m *GroupBy) Run() error {
 		u.Warnf("Group By statement not supported? %v", err)
 		return err
 	}
+	nullGrouping := m.Ctx.NullGrouping()
 
 	// are are going to hold entire row in memory while we are calculating
 	//  so obviously not scalable.
 	gb := make(map[string][]*datasource.SqlDriverMessageMap)
+	mem := newMemoryUse(m.Ctx)
+	defer mem.release()
 
 msgReadLoop:
 	for {
@@ -121,11 +127,14 @@ msgReadLoop:
 				// then join each value together to create a unique key.
 				keys := make([]string, len(m.p.Stmt.GroupBy))
 				for i, col := range m.p.Stmt.GroupBy {
-					if key, ok := vm.Eval(sdm, col.Expr); ok {
-						keys[i] = key.ToString()
-					}
+					key, ok := vm.Eval(sdm, col.Expr)
+					keys[i] = groupKeyPart(nullGrouping, key, ok)
+				}
+				key := groupKey(keys)
+				if err := mem.hold(sdm.Vals); err != nil {
+					close(m.TaskBase.sigCh)
+					return err
 				}
-				key := strings.Join(keys, ",")
 				gb[key] = append(gb[key], sdm)
 			}
 		}
@@ -141,17 +150,11 @@ msgReadLoop:
 
 				if col.Expr == nil {
 					u.Warnf("wat?   nil col expr? %#v", col)
+				} else if col.Filter != nil && !filterMatches(mm, col.Filter) {
+					// aggregate FILTER (WHERE ...) not true for this row
+					continue
 				} else {
-					v, ok := vm.Eval(mm, col.Expr)
-					//u.Infof("mt: %T  mm %#v", mm, mm)
-					if !ok || v == nil {
-						//u.Debugf("evaled nil? key=%v  val=%v expr:%s", col.Key(), v, col.Expr.String())
-						//u.Infof("mt: %T  mm %#v", mm, mm)
-						aggs[i].Do(value.NewNilValue())
-					} else {
-						//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
-						aggs[i].Do(v)
-					}
+					doAgg(aggs[i], mm, col.Expr)
 				}
 			}
 		}
@@ -194,6 +197,8 @@ func (m *GroupByFinal) Run() error {
 	}
 
 	gb := make(map[string][][]driver.Value)
+	mem := newMemoryUse(m.Ctx)
+	defer mem.release()
 
 msgReadLoop:
 	for {
@@ -219,6 +224,11 @@ msgReadLoop:
 					}
 					vals := mt.Vals[0 : len(mt.Vals)-1]
 					//u.Infof("found key:%s for %#v", key, mt.Vals)
+					if err := mem.hold(vals); err != nil {
+						close(m.TaskBase.sigCh)
+						close(m.complete)
+						return err
+					}
 					gb[key] = append(gb[key], vals)
 				default:
 					err := fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
@@ -242,6 +252,9 @@ msgReadLoop:
 				}
 				if col.Expr == nil {
 					u.Warnf("wat?   nil col expr? %#v", col)
+				} else if gbf, isGroupBy := aggs[i].(*groupByFunc); isGroupBy {
+					// group by column, same value in every partial of the group
+					gbf.last = dv[i]
 				} else {
 					v := dv[i]
 					switch vt := v.(type) {
@@ -314,6 +327,17 @@ func (m *GroupByFinal) Close() error {
 	return m.TaskBase.Close()
 }
 
+// filterMatches does the aggregate column FILTER expression evaluate true
+// for the row, null or un-evaluable is not a match.
+func filterMatches(mm expr.EvalContext, filter expr.Node) bool {
+	v, ok := vm.Eval(mm, filter)
+	if !ok || v == nil || v.Nil() {
+		return false
+	}
+	b, ok := value.ValueToBool(v)
+	return ok && b
+}
+
 // AggPartial is a struct to represent the partial aggregation
 // that will be reduced on finalizer.  IE, for consistent-hash based
 // group-bys calculated across multiple nodes this holds info that
@@ -321,6 +345,9 @@ func (m *GroupByFinal) Close() error {
 type AggPartial struct {
 	Ct int64
 	N  float64
+	// Sketch serialized state of approximate aggregates (hll, t-digest,
+	// space-saving) which merge sketches rather than counts.
+	Sketch []byte
 }
 
 type AggFunc func(v value.Value)
@@ -367,8 +394,8 @@ func (m *sum) Result() interface{} {
 		return m.n
 	}
 	return &AggPartial{
-		m.ct,
-		m.n,
+		Ct: m.ct,
+		N:  m.n,
 	}
 }
 func (m *sum) Reset() { m.n = 0 }
@@ -400,8 +427,8 @@ func (m *avg) Result() interface{} {
 		return m.n / float64(m.ct)
 	}
 	return &AggPartial{
-		m.ct,
-		m.n,
+		Ct: m.ct,
+		N:  m.n,
 	}
 }
 func (m *avg) Reset() { m.n = 0; m.ct = 0 }
@@ -434,6 +461,293 @@ func NewCount(col *rel.Column) Aggregator {
 	return &count{}
 }
 
+type groupConcat struct {
+	partial  bool
+	sep      string
+	distinct bool
+	val      expr.Node
+	keys     []expr.Node
+	desc     []bool
+	rows     [][]value.Value // value, then its order by keys
+}
+
+// Do a value without order by keys, ie of a pivot.
+func (m *groupConcat) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	m.rows = append(m.rows, append([]value.Value{v}, make([]value.Value, len(m.keys))...))
+}
+
+// DoRow evaluate the value and order by keys of the row.
+func (m *groupConcat) DoRow(row expr.EvalContext) {
+	v, ok := vm.Eval(row, m.val)
+	if !ok || v == nil || v.Nil() {
+		return
+	}
+	vals := make([]value.Value, 1+len(m.keys))
+	vals[0] = v
+	for i, key := range m.keys {
+		if kv, ok := vm.Eval(row, key); ok && kv != nil && !kv.Nil() {
+			vals[i+1] = kv
+		}
+	}
+	m.rows = append(m.rows, vals)
+}
+func (m *groupConcat) Result() interface{} {
+	if m.partial {
+		rows := make([]json.RawMessage, len(m.rows))
+		for i, row := range m.rows {
+			rows[i] = marshalValues(row...)
+		}
+		sketch, _ := json.Marshal(rows)
+		return &AggPartial{Ct: int64(len(m.rows)), Sketch: sketch}
+	}
+	if len(m.rows) == 0 {
+		return nil
+	}
+	if len(m.desc) > 0 {
+		sort.SliceStable(m.rows, func(i, j int) bool {
+			for ki, desc := range m.desc {
+				if c := orderCompare(m.rows[i][ki+1], m.rows[j][ki+1]); c != 0 {
+					return (c < 0) != desc
+				}
+			}
+			return false
+		})
+	}
+	vals := make([]string, 0, len(m.rows))
+	seen := make(map[string]bool)
+	for _, row := range m.rows {
+		s := row[0].ToString()
+		if m.distinct {
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+		}
+		vals = append(vals, s)
+	}
+	return strings.Join(vals, m.sep)
+}
+func (m *groupConcat) Reset() { m.rows = nil }
+func (m *groupConcat) Merge(a *AggPartial) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(a.Sketch, &rows); err != nil {
+		u.Warnf("could not merge group_concat %v", err)
+		return
+	}
+	for _, by := range rows {
+		vals, err := unmarshalValues(by)
+		if err != nil || len(vals) != 1+len(m.keys) {
+			u.Warnf("could not merge group_concat %v", err)
+			return
+		}
+		for i, v := range vals {
+			if v.Nil() {
+				vals[i] = nil
+			}
+		}
+		m.rows = append(m.rows, vals)
+	}
+}
+
+// NewGroupConcat group_concat(), string_agg() aggregator, holds the values
+// of the group to order, de-duplicate and join them.
+func NewGroupConcat(col *rel.Column, partial bool) (Aggregator, error) {
+	fn, ok := col.Expr.(*expr.FuncNode)
+	if !ok || len(fn.Args) < 3 {
+		return nil, fmt.Errorf("expected group_concat(expr) but got %s", col.Expr)
+	}
+	m := &groupConcat{partial: partial, sep: ",", val: fn.Args[0]}
+	if sn, ok := fn.Args[1].(*expr.StringNode); ok {
+		m.sep = sn.Text
+	}
+	if sn, ok := fn.Args[2].(*expr.StringNode); ok {
+		m.distinct = sn.Text == "distinct"
+	}
+	for i := 3; i+1 < len(fn.Args); i += 2 {
+		m.keys = append(m.keys, fn.Args[i])
+		sn, ok := fn.Args[i+1].(*expr.StringNode)
+		m.desc = append(m.desc, ok && sn.Text == "desc")
+	}
+	return m, nil
+}
+
+// rowAggregator aggregators evaluating more of the row than the value of
+// their func, ie the order by keys of group_concat.
+type rowAggregator interface {
+	DoRow(row expr.EvalContext)
+}
+
+// doAgg aggregate the value of node for row, or the row for a
+// rowAggregator.
+func doAgg(agg Aggregator, row expr.EvalContext, node expr.Node) {
+	if ra, ok := agg.(rowAggregator); ok {
+		ra.DoRow(row)
+		return
+	}
+	v, ok := vm.Eval(row, node)
+	if !ok || v == nil {
+		agg.Do(value.NewNilValue())
+		return
+	}
+	agg.Do(v)
+}
+
+// nonNilAgg aggregates only the non-nil values, ie avg_if() whose values
+// are nil for the rows its condition is false for.
+type nonNilAgg struct {
+	Aggregator
+}
+
+func (m *nonNilAgg) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	m.Aggregator.Do(v)
+}
+
+type approxCountDistinct struct {
+	partial bool
+	hll     *HyperLogLog
+}
+
+func (m *approxCountDistinct) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	m.hll.Add(v.ToString())
+}
+func (m *approxCountDistinct) Result() interface{} {
+	if !m.partial {
+		return m.hll.Count()
+	}
+	sketch, _ := m.hll.MarshalBinary()
+	return &AggPartial{Sketch: sketch}
+}
+func (m *approxCountDistinct) Reset() { m.hll = NewHyperLogLog(HllPrecision) }
+func (m *approxCountDistinct) Merge(a *AggPartial) {
+	other := &HyperLogLog{}
+	if err := other.UnmarshalBinary(a.Sketch); err != nil {
+		u.Warnf("could not merge approx_count_distinct %v", err)
+		return
+	}
+	if err := m.hll.Merge(other); err != nil {
+		u.Warnf("could not merge approx_count_distinct %v", err)
+	}
+}
+
+// NewApproxCountDistinct approx_count_distinct(expr) aggregator, a
+// HyperLogLog sketch of the values.
+func NewApproxCountDistinct(col *rel.Column, partial bool) Aggregator {
+	return &approxCountDistinct{partial: partial, hll: NewHyperLogLog(HllPrecision)}
+}
+
+type approxQuantile struct {
+	partial bool
+	q       float64
+	td      *TDigest
+}
+
+func (m *approxQuantile) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	if f, ok := value.ValueToFloat64(v); ok {
+		m.td.Add(f)
+	}
+}
+func (m *approxQuantile) Result() interface{} {
+	if !m.partial {
+		return m.td.Quantile(m.q)
+	}
+	sketch, _ := m.td.MarshalBinary()
+	return &AggPartial{Ct: int64(m.td.Count()), Sketch: sketch}
+}
+func (m *approxQuantile) Reset() { m.td = NewTDigest(TDigestCompression) }
+func (m *approxQuantile) Merge(a *AggPartial) {
+	other := &TDigest{}
+	if err := other.UnmarshalBinary(a.Sketch); err != nil {
+		u.Warnf("could not merge approx_quantile %v", err)
+		return
+	}
+	m.td.Merge(other)
+}
+
+// NewApproxQuantile approx_quantile(expr, q) aggregator, a t-digest of
+// the numeric values.
+func NewApproxQuantile(col *rel.Column, partial bool) (Aggregator, error) {
+	q, err := aggLiteralArg(col, 1)
+	if err != nil {
+		return nil, err
+	}
+	if q < 0 || q > 1 {
+		return nil, fmt.Errorf("approx_quantile quantile must be between 0 and 1: %s", col.Expr)
+	}
+	return &approxQuantile{partial: partial, q: q, td: NewTDigest(TDigestCompression)}, nil
+}
+
+type approxTopK struct {
+	partial bool
+	k       int
+	ss      *SpaceSaving
+}
+
+func (m *approxTopK) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	m.ss.Add(v.ToString())
+}
+func (m *approxTopK) Result() interface{} {
+	if !m.partial {
+		top := m.ss.TopK(m.k)
+		vals := make([]string, len(top))
+		for i, item := range top {
+			vals[i] = item.Value
+		}
+		return vals
+	}
+	sketch, _ := m.ss.MarshalBinary()
+	return &AggPartial{Sketch: sketch}
+}
+func (m *approxTopK) Reset() { m.ss = NewSpaceSaving(m.k * SpaceSavingFactor) }
+func (m *approxTopK) Merge(a *AggPartial) {
+	other := &SpaceSaving{}
+	if err := other.UnmarshalBinary(a.Sketch); err != nil {
+		u.Warnf("could not merge approx_top_k %v", err)
+		return
+	}
+	m.ss.Merge(other)
+}
+
+// NewApproxTopK approx_top_k(expr, k) aggregator, a space-saving sketch
+// of the values, results in the k most frequent values most frequent first.
+func NewApproxTopK(col *rel.Column, partial bool) (Aggregator, error) {
+	k, err := aggLiteralArg(col, 1)
+	if err != nil {
+		return nil, err
+	}
+	if k < 1 || k != math.Trunc(k) {
+		return nil, fmt.Errorf("approx_top_k k must be a positive integer: %s", col.Expr)
+	}
+	return &approxTopK{partial: partial, k: int(k), ss: NewSpaceSaving(int(k) * SpaceSavingFactor)}, nil
+}
+
+// aggLiteralArg the numeric literal argument i of an aggregate func column.
+func aggLiteralArg(col *rel.Column, i int) (float64, error) {
+	fn, ok := col.Expr.(*expr.FuncNode)
+	if !ok || len(fn.Args) <= i {
+		return 0, fmt.Errorf("expected %d args for %s", i+1, col.Expr)
+	}
+	nn, ok := fn.Args[i].(*expr.NumberNode)
+	if !ok {
+		return 0, fmt.Errorf("expected numeric literal arg %d for %s", i+1, col.Expr)
+	}
+	return nn.Float64, nil
+}
+
 func buildAggs(p *plan.GroupBy) ([]Aggregator, error) {
 
 	aggs := make([]Aggregator, len(p.Stmt.Columns))
@@ -454,20 +768,13 @@ colLoop:
 
 		// Since we made it here, it is an aggregate func
 		//  move to a registry of some kind to allow extension
-		switch n := col.Expr.(type) {
+		switch col.Expr.(type) {
 		case *expr.FuncNode:
-
-			// TODO:  extract to a UDF Registry Similar to builtins
-			switch strings.ToLower(n.Name) {
-			case "avg":
-				aggs[colIdx] = NewAvg(col, p.Partial)
-			case "count":
-				aggs[colIdx] = NewCount(col)
-			case "sum":
-				aggs[colIdx] = NewSum(col, p.Partial)
-			default:
-				return nil, fmt.Errorf("Not implemented groupby for function: %s", col.Expr)
+			agg, err := NewAggregator(col, p.Partial)
+			if err != nil {
+				return nil, err
 			}
+			aggs[colIdx] = agg
 		case *expr.BinaryNode:
 			// expression logic?
 			return nil, fmt.Errorf("Not implemented groupby for expression column: %s", col.Expr)
@@ -480,3 +787,62 @@ colLoop:
 	}
 	return aggs, nil
 }
+
+// NewAggregator the Aggregator for a column whose expression is an
+// aggregate func, ie count(*), sum(x).
+func NewAggregator(col *rel.Column, partial bool) (Aggregator, error) {
+	n, ok := col.Expr.(*expr.FuncNode)
+	if !ok {
+		return nil, fmt.Errorf("Not implemented groupby for %T column: %s", col.Expr, col.Expr)
+	}
+	// TODO:  extract to a UDF Registry Similar to builtins
+	switch strings.ToLower(n.Name) {
+	case "avg":
+		return NewAvg(col, partial), nil
+	case "count":
+		return NewCount(col), nil
+	case "sum", "sum_if":
+		return NewSum(col, partial), nil
+	case "count_if":
+		return NewCount(col), nil
+	case "avg_if":
+		return &nonNilAgg{NewAvg(col, partial)}, nil
+	case "group_concat", "string_agg":
+		return NewGroupConcat(col, partial)
+	case "variance", "var_samp":
+		return NewVariance(col, partial, true, false), nil
+	case "var_pop":
+		return NewVariance(col, partial, false, false), nil
+	case "stddev", "stddev_samp":
+		return NewVariance(col, partial, true, true), nil
+	case "stddev_pop":
+		return NewVariance(col, partial, false, true), nil
+	case "corr":
+		return NewCovariance(col, partial, true, true), nil
+	case "covar_samp":
+		return NewCovariance(col, partial, false, true), nil
+	case "covar_pop":
+		return NewCovariance(col, partial, false, false), nil
+	case "median":
+		return NewPercentile(col, partial, true, true)
+	case "percentile_cont":
+		return NewPercentile(col, partial, true, false)
+	case "percentile_disc":
+		return NewPercentile(col, partial, false, false)
+	case "first":
+		return NewFirst(col, partial, false), nil
+	case "last":
+		return NewFirst(col, partial, true), nil
+	case "arg_max":
+		return NewArgMax(col, partial, false), nil
+	case "arg_min":
+		return NewArgMax(col, partial, true), nil
+	case "approx_count_distinct":
+		return NewApproxCountDistinct(col, partial), nil
+	case "approx_quantile":
+		return NewApproxQuantile(col, partial)
+	case "approx_top_k":
+		return NewApproxTopK(col, partial)
+	}
+	return nil, fmt.Errorf("Not implemented groupby for function: %s", col.Expr)
+}
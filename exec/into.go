@@ -0,0 +1,137 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// ErrIntoTooManyRows SELECT ... INTO @var returned more than one row
+	ErrIntoTooManyRows = sqlerr.New(sqlerr.ErTooManyRows, "Result consisted of more than one row")
+	// ErrIntoColumnCount SELECT ... INTO @var column and variable counts differ
+	ErrIntoColumnCount = sqlerr.New(sqlerr.ErWrongNumberOfColumns, "The used SELECT statements have a different number of columns")
+)
+
+// IntoVars assigns the row of a SELECT ... INTO @var1, @var2 to the
+// session user variables, it writes no rows.  A query returning no rows
+// leaves the variables unchanged, more than one row is an error.
+type IntoVars struct {
+	*TaskBase
+	vars []string
+}
+
+// NewIntoVars create task assigning the result row to user vars.
+func NewIntoVars(ctx *plan.Context, vars []string) *IntoVars {
+	return &IntoVars{TaskBase: NewTaskBase(ctx), vars: vars}
+}
+
+// Run read the result row, and assign it.
+func (m *IntoVars) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	var row []driver.Value
+	rowCt := 0
+	inCh := m.MessageIn()
+msgLoop:
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				break msgLoop
+			}
+			rowCt++
+			if rowCt == 1 {
+				if vm, ok := msg.(interface{ Values() []driver.Value }); ok {
+					row = vm.Values()
+				}
+			}
+		}
+	}
+	switch {
+	case rowCt == 0:
+		return nil
+	case rowCt > 1:
+		return ErrIntoTooManyRows
+	case len(row) != len(m.vars):
+		return ErrIntoColumnCount
+	case m.Ctx.Session == nil:
+		return fmt.Errorf("no Context.Session?")
+	}
+	for i, name := range m.vars {
+		if err := m.Ctx.Session.Put(expr.SchemaInfoString(name), nil, value.NewValue(row[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IntoTemp writes the rows of a SELECT ... INTO TEMP t to the new session
+// temporary table t, it writes no rows.  The table has the columns of the
+// select, keyed by the first.
+type IntoTemp struct {
+	*TaskBase
+	p    *plan.Select
+	name string
+}
+
+// NewIntoTemp create task writing the result rows to temporary table name.
+func NewIntoTemp(ctx *plan.Context, p *plan.Select, name string) *IntoTemp {
+	return &IntoTemp{TaskBase: NewTaskBase(ctx), p: p, name: name}
+}
+
+// Run read the result rows, and create the temporary table of them.
+func (m *IntoTemp) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	if _, exists := m.Ctx.Temp.Table(m.name); exists {
+		return sqlerr.New(sqlerr.ErTableExists, "Table '%s' already exists", m.name)
+	}
+
+	rows := make([][]driver.Value, 0)
+	inCh := m.MessageIn()
+msgLoop:
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				break msgLoop
+			}
+			if vm, ok := msg.(interface{ Values() []driver.Value }); ok {
+				rows = append(rows, vm.Values())
+			}
+		}
+	}
+
+	cols := m.p.Stmt.Columns.AliasedFieldNames()
+	types := make(map[string]value.ValueType)
+	if m.Ctx.Projection != nil && m.Ctx.Projection.Proj != nil {
+		cols = cols[:0]
+		for _, rc := range m.Ctx.Projection.Proj.Columns {
+			name := rc.As
+			if name == "" {
+				name = rc.Name
+			}
+			cols = append(cols, name)
+			if rc.Type != value.UnknownType && rc.Type != value.NilType {
+				types[name] = rc.Type
+			}
+		}
+	}
+	for _, row := range rows {
+		if len(row) != len(cols) {
+			return ErrIntoColumnCount
+		}
+	}
+	return addTempTable(m.Ctx, m.name, cols, types, rows, nil)
+}
@@ -3,7 +3,6 @@ package exec
 import (
 	"database/sql/driver"
 	"fmt"
-	"strings"
 	"sync"
 
 	u "github.com/araddon/gou"
@@ -12,7 +11,6 @@ import (
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
-	"github.com/araddon/qlbridge/vm"
 )
 
 var (
@@ -72,21 +70,14 @@ func (m *JoinKey) Run() error {
 			}
 
 			//u.Infof("In joinkey msg %#v", msg)
-		msgTypeSwitch:
 			switch mt := msg.(type) {
 			case *datasource.SqlDriverMessageMap:
-				vals := make([]string, len(joinNodes))
-				for i, node := range joinNodes {
-					joinVal, ok := vm.Eval(mt, node)
-					//u.Debugf("evaluating: ok?%v T:%T result=%v node '%v'", ok, joinVal, joinVal.ToString(), node.String())
-					if !ok {
-						u.Errorf("could not evaluate: %T %#v   %v", joinVal, joinVal, msg)
-						break msgTypeSwitch
-					}
-					vals[i] = joinVal.ToString()
+				key, ok := joinKeyOf(mt, joinNodes)
+				if !ok {
+					u.Errorf("could not evaluate join key: %v", msg)
+					continue
 				}
-				//u.Infof("joinkey: %v row:%v", vals, mt)
-				key := strings.Join(vals, string(byte(0)))
+				//u.Infof("joinkey: %v row:%v", key, mt)
 				mt.SetKeyHashed(key)
 				outCh <- mt
 			default:
@@ -106,6 +97,10 @@ type JoinMerge struct {
 	ltask     TaskRunner
 	rtask     TaskRunner
 	colIndex  map[string]int
+	// keyFilter bloom filter of the build side keys, sent to the probe side
+	// source once the build side is read, see JobExecutor.WalkJoin.
+	keyFilter  *joinKeyFilter
+	buildRight bool
 }
 
 // A very stupid naive parallel join merge, uses Key() as value to merge
@@ -154,6 +149,19 @@ func (m *JoinMerge) Run() error {
 	lh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
 	rh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
 
+	if m.keyFilter != nil {
+		// never leave the probe side waiting
+		defer m.keyFilter.set(nil)
+	}
+
+	// each side charges the rows it holds to the query, once over its
+	// limit the side keeps draining its input (dropping the rows) and the
+	// join fails once both are done
+	lmem, rmem := newMemoryUse(m.Ctx), newMemoryUse(m.Ctx)
+	defer lmem.release()
+	defer rmem.release()
+	var lmemErr, rmemErr error
+
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
 	var fatalErr error
@@ -169,6 +177,9 @@ func (m *JoinMerge) Run() error {
 			case msg, ok := <-leftIn:
 				if !ok {
 					//u.Debugf("NICE, got left shutdown")
+					if m.keyFilter != nil && !m.buildRight {
+						m.keyFilter.set(bloomOfKeys(lh))
+					}
 					wg.Done()
 					return
 				} else {
@@ -181,7 +192,12 @@ func (m *JoinMerge) Run() error {
 							close(m.TaskBase.sigCh)
 							return
 						}
-						lh[key] = append(lh[key], mt)
+						if lmemErr != nil {
+							continue
+						}
+						if lmemErr = lmem.hold(mt.Vals); lmemErr == nil {
+							lh[key] = append(lh[key], mt)
+						}
 					default:
 						fatalErr = fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
 						u.Errorf("unrecognized msg %T", msg)
@@ -207,6 +223,9 @@ func (m *JoinMerge) Run() error {
 			case msg, ok := <-rightIn:
 				if !ok {
 					//u.Debugf("NICE, got right shutdown")
+					if m.keyFilter != nil && m.buildRight {
+						m.keyFilter.set(bloomOfKeys(rh))
+					}
 					wg.Done()
 					return
 				} else {
@@ -219,7 +238,12 @@ func (m *JoinMerge) Run() error {
 							close(m.TaskBase.sigCh)
 							return
 						}
-						rh[key] = append(rh[key], mt)
+						if rmemErr != nil {
+							continue
+						}
+						if rmemErr = rmem.hold(mt.Vals); rmemErr == nil {
+							rh[key] = append(rh[key], mt)
+						}
 					default:
 						fatalErr = fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
 						u.Errorf("unrecognized msg %T", msg)
@@ -233,6 +257,11 @@ func (m *JoinMerge) Run() error {
 	}()
 	wg.Wait()
 	//u.Info("leaving source scanner")
+	for _, err := range []error{lmemErr, rmemErr} {
+		if err != nil {
+			return err
+		}
+	}
 	i := uint64(0)
 	for keyLeft, valLeft := range lh {
 		//u.Debugf("compare:  key:%v  left:%#v  right:%#v  rh: %#v", keyLeft, valLeft, rh[keyLeft], rh)
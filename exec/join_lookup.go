@@ -0,0 +1,123 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/metrics"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*JoinLookup)(nil)
+)
+
+// JoinLookup joins 2 sources by looking up each left row in an index of
+// the right source
+//
+//     ON tolower(a.email) = tolower(b.email)
+//
+// the right source is never scanned, its rows matching the left rows key
+// are read from its (expression) index, see schema.ConnIndexSeeker.
+type JoinLookup struct {
+	*JoinMerge
+	p      *plan.LookupJoin
+	seeker schema.ConnIndexSeeker
+}
+
+// NewJoinLookup a lookup join of l into the index of the right source seeker
+// on the plans Lookup condition.
+func NewJoinLookup(ctx *plan.Context, l TaskRunner, seeker schema.ConnIndexSeeker, p *plan.JoinMerge) *JoinLookup {
+	// the lookups read the statement's snapshot of the source
+	if sourceContext, needsContext := seeker.(RequiresContext); needsContext {
+		sourceContext.SetContext(ctx)
+	}
+	return &JoinLookup{
+		JoinMerge: NewJoinNaiveMerge(ctx, l, nil, p),
+		p:         p.Lookup,
+		seeker:    seeker,
+	}
+}
+
+func (m *JoinLookup) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	outCh := m.MessageOut()
+	inCh := m.ltask.MessageOut()
+	found := make(map[string][]*datasource.SqlDriverMessageMap)
+	i := uint64(0)
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				return nil
+			}
+			lm, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				return fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+			}
+			kv, ok := vm.Eval(lm, m.p.Left)
+			if !ok || kv == nil || kv.Nil() {
+				continue
+			}
+			key := kv.ToString()
+			rmsgs, cached := found[key]
+			metrics.CacheAccess("lookup_join", cached)
+			if !cached {
+				var err error
+				if rmsgs, err = m.lookup(key); err != nil {
+					return err
+				}
+				found[key] = rmsgs
+			}
+			for _, rm := range rmsgs {
+				vals := make([]driver.Value, len(m.colIndex))
+				vals = m.valIndexing(vals, lm.Values(), m.leftStmt.Source.Columns)
+				for _, col := range m.rightStmt.Source.Columns {
+					if col.ParentIndex < 0 || col.ParentIndex >= len(vals) || col.Expr == nil {
+						continue
+					}
+					if v, ok := vm.Eval(rm, col.Expr); ok && v != nil && !v.Nil() {
+						vals[col.ParentIndex] = v.Value()
+					}
+				}
+				out := datasource.NewSqlDriverMessageMap(i, vals, m.colIndex)
+				i++
+				select {
+				case <-m.SigChan():
+					return nil
+				case outCh <- out:
+				}
+			}
+		}
+	}
+}
+
+// lookup the right rows of key, filtered by the right sources where.
+func (m *JoinLookup) lookup(key string) ([]*datasource.SqlDriverMessageMap, error) {
+	msgs, err := m.seeker.GetIndex(m.p.Index, key)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*datasource.SqlDriverMessageMap, 0, len(msgs))
+	for _, msg := range msgs {
+		rm, ok := msg.(*datasource.SqlDriverMessageMap)
+		if !ok {
+			return nil, fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+		}
+		if where := m.rightStmt.Source.Where; where != nil && where.Expr != nil {
+			if matches, ok := vm.MatchesExpr(rm, where.Expr); !ok || !matches {
+				continue
+			}
+		}
+		rows = append(rows, rm)
+	}
+	return rows, nil
+}
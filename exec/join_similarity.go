@@ -0,0 +1,225 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*JoinSimilarity)(nil)
+)
+
+// JoinSimilarity joins 2 sources on string edit distance
+//
+//     ON levenshtein(a.name, b.name) < 3
+//
+// Rather than compare every pair, the right rows are indexed by q-gram
+// (blocking), strings within d edits of each other share at least
+// max(len) + q - 1 - d*q of their padded q-grams, so each left row is
+// only compared to the right rows that pass that count, and length filter.
+type JoinSimilarity struct {
+	*JoinMerge
+	p *plan.SimilarityJoin
+}
+
+// NewJoinSimilarity a similarity join of l, r on the plans Similarity condition.
+func NewJoinSimilarity(ctx *plan.Context, l, r TaskRunner, p *plan.JoinMerge) *JoinSimilarity {
+	return &JoinSimilarity{
+		JoinMerge: NewJoinNaiveMerge(ctx, l, r, p),
+		p:         p.Similarity,
+	}
+}
+
+// similarityRows the rows of one side of the join, and the string value
+// of its side of the distance expression.
+type similarityRows struct {
+	msgs []*datasource.SqlDriverMessageMap
+	vals []string
+}
+
+func (m *JoinSimilarity) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	var left, right similarityRows
+	var lerr, rerr error
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		lerr = m.collect(m.ltask.MessageOut(), &left, m.p.Left)
+	}()
+	go func() {
+		defer wg.Done()
+		rerr = m.collect(m.rtask.MessageOut(), &right, m.p.Right)
+	}()
+	wg.Wait()
+	if lerr != nil {
+		return lerr
+	}
+	if rerr != nil {
+		return rerr
+	}
+
+	idx := newQGramIndex(m.p.Q, right.vals)
+	outCh := m.MessageOut()
+	i := uint64(0)
+	for li, lv := range left.vals {
+		for _, ri := range idx.candidates(lv, m.p.MaxDistance) {
+			if !m.matches(lv, right.vals[ri]) {
+				continue
+			}
+			for _, msg := range m.mergeValueMessages(left.msgs[li:li+1], right.msgs[ri:ri+1]) {
+				msg.IdVal = i
+				i++
+				select {
+				case <-m.SigChan():
+					return nil
+				case outCh <- msg:
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// collect read all rows of in, rows whose value of n is not a string
+// can never match so are dropped.
+func (m *JoinSimilarity) collect(in MessageChan, rows *similarityRows, node expr.Node) error {
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-in:
+			if !ok {
+				return nil
+			}
+			mt, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				return fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+			}
+			v, ok := vm.Eval(mt, node)
+			if !ok || v == nil || v.Nil() {
+				continue
+			}
+			sv, ok := value.ValueToString(v)
+			if !ok {
+				continue
+			}
+			rows.msgs = append(rows.msgs, mt)
+			rows.vals = append(rows.vals, sv)
+		}
+	}
+}
+
+// matches verify a candidate pair with the distance func.
+func (m *JoinSimilarity) matches(l, r string) bool {
+	dv, ok := m.p.Distance.Eval(nil, []value.Value{value.NewStringValue(l), value.NewStringValue(r)})
+	if !ok {
+		return false
+	}
+	dist, ok := value.ValueToInt(dv)
+	return ok && dist <= m.p.MaxDistance
+}
+
+type qgramPosting struct {
+	row int
+	ct  int
+}
+
+// qgramIndex an inverted index of q-gram to the rows containing it.
+type qgramIndex struct {
+	q     int
+	grams map[string][]qgramPosting
+	lens  []int
+	byLen map[int][]int
+}
+
+func newQGramIndex(q int, vals []string) *qgramIndex {
+	if q < 1 {
+		q = 2
+	}
+	idx := &qgramIndex{
+		q:     q,
+		grams: make(map[string][]qgramPosting),
+		lens:  make([]int, len(vals)),
+		byLen: make(map[int][]int),
+	}
+	for row, v := range vals {
+		n := utf8.RuneCountInString(v)
+		idx.lens[row] = n
+		idx.byLen[n] = append(idx.byLen[n], row)
+		for g, ct := range qgrams(v, q) {
+			idx.grams[g] = append(idx.grams[g], qgramPosting{row, ct})
+		}
+	}
+	return idx
+}
+
+// minCommon the fewest q-grams strings of length a, b within d edits share.
+func (m *qgramIndex) minCommon(a, b, d int) int {
+	if b > a {
+		a = b
+	}
+	return a + m.q - 1 - d*m.q
+}
+
+// candidates rows of the index that may be within maxDist of s, in row order.
+func (m *qgramIndex) candidates(s string, maxDist int) []int {
+	n := utf8.RuneCountInString(s)
+	rows := make([]int, 0)
+	// too short to be sure of sharing any grams, length filter only
+	for l := n - maxDist; l <= n+maxDist; l++ {
+		if m.minCommon(n, l, maxDist) <= 0 {
+			rows = append(rows, m.byLen[l]...)
+		}
+	}
+	common := make(map[int]int)
+	for g, ct := range qgrams(s, m.q) {
+		for _, p := range m.grams[g] {
+			if p.ct < ct {
+				common[p.row] += p.ct
+			} else {
+				common[p.row] += ct
+			}
+		}
+	}
+	for row, ct := range common {
+		l := m.lens[row]
+		if l < n-maxDist || l > n+maxDist {
+			continue
+		}
+		if need := m.minCommon(n, l, maxDist); need > 0 && ct >= need {
+			rows = append(rows, row)
+		}
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// qgrams counts of the q-grams of s, padded at start and end so each
+// char is in q grams.
+func qgrams(s string, q int) map[string]int {
+	rs := make([]rune, 0, len(s)+2*(q-1))
+	for i := 0; i < q-1; i++ {
+		rs = append(rs, '\x02')
+	}
+	rs = append(rs, []rune(s)...)
+	for i := 0; i < q-1; i++ {
+		rs = append(rs, '\x03')
+	}
+	grams := make(map[string]int, len(rs))
+	for i := 0; i+q <= len(rs); i++ {
+		grams[string(rs[i:i+q])]++
+	}
+	return grams
+}
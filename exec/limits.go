@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// acquireSlot a scan (or write) slot of a source's limiter, giving up if
+// the task is stopped (sigChan closed) while waiting.  A nil release means
+// no slot was acquired, and the task should return err, which is nil if
+// it was stopped.
+func acquireSlot(l *schema.SourceLimiter, write bool, sigChan SigChan) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	acquire := l.AcquireScan
+	if write {
+		acquire = l.AcquireWrite
+	}
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-sigChan:
+			close(done)
+		case <-stop:
+		}
+	}()
+	release, err := acquire(done)
+	if err == context.Canceled {
+		return nil, nil
+	}
+	return release, err
+}
+
+// writeLimiter the concurrency limits of the source of table written by
+// a mutation, nil if none.
+func writeLimiter(ctx *plan.Context, table string) *schema.SourceLimiter {
+	if ctx == nil || ctx.Schema == nil {
+		return nil
+	}
+	ss, err := ctx.Schema.SchemaForTable(table)
+	if err != nil || ss == nil {
+		return nil
+	}
+	return ss.Limiter
+}
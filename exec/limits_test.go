@@ -0,0 +1,74 @@
+package exec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestSourceLimits(t *testing.T) {
+	td.LoadTestDataOnce()
+	mockcsv.LoadTable(mockcsv.SchemaName, "slow_scans", "id,name\n1,a\n2,b\n3,c")
+	defer mockcsv.SetFaults("slow_scans", nil)
+	ss, err := td.MockSchema.SchemaForTable("slow_scans")
+	assert.Equal(t, nil, err)
+	limiter := schema.NewSourceLimiter(1, 1)
+	limiter.Block = false
+	ss.Limiter = limiter
+	defer func() { ss.Limiter = nil }()
+
+	waitScans := func(n int) {
+		for i := 0; i < 200; i++ {
+			if scans, _ := limiter.InFlight(); scans == n {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expected %d scans in flight", n)
+	}
+
+	// a second scan while the first is in flight is rejected
+	mockcsv.SetFaults("slow_scans", &mockcsv.Faults{RowLatency: 50 * time.Millisecond})
+	first := make(chan error, 1)
+	go func() {
+		_, err := runSession(t, datasource.NewContextSimple(), `SELECT id FROM slow_scans`)
+		first <- err
+	}()
+	waitScans(1)
+	_, err = runSession(t, datasource.NewContextSimple(), `SELECT id FROM slow_scans`)
+	assert.Equal(t, schema.ErrSourceBusy, err)
+	assert.Equal(t, nil, <-first)
+	waitScans(0)
+
+	// blocking, it waits for the first to finish
+	limiter.Block = true
+	go func() {
+		_, err := runSession(t, datasource.NewContextSimple(), `SELECT id FROM slow_scans`)
+		first <- err
+	}()
+	waitScans(1)
+	rows, err := runSession(t, datasource.NewContextSimple(), `SELECT id FROM slow_scans`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, nil, <-first)
+
+	// writes are capped separately
+	mockcsv.SetFaults("slow_scans", nil)
+	limiter.Block = false
+	release, err := limiter.AcquireWrite(nil)
+	assert.Equal(t, nil, err)
+	_, err = runSession(t, datasource.NewContextSimple(), `INSERT INTO slow_scans (id, name) VALUES (4, "d")`)
+	assert.Equal(t, schema.ErrSourceBusy, err)
+	release()
+	_, err = runSession(t, datasource.NewContextSimple(), `INSERT INTO slow_scans (id, name) VALUES (4, "d")`)
+	assert.Equal(t, nil, err)
+	rows, err = runSession(t, datasource.NewContextSimple(), `SELECT id FROM slow_scans`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(rows))
+}
@@ -0,0 +1,78 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"sync/atomic"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+)
+
+const (
+	// rowOverhead approximate bytes of a held row besides its values, the
+	// message, slice headers and map entry.
+	rowOverhead = 64
+	// valueOverhead approximate bytes of each held value besides its data.
+	valueOverhead = 16
+)
+
+var (
+	// EngineMemory the memory held by the operators of all running
+	// queries, the parent of each query's account.
+	EngineMemory = plan.NewMemoryAccount(0, nil)
+
+	statusQueries        int64
+	statusQueriesFailed  int64
+	statusMemoryExceeded int64
+)
+
+func init() {
+	datasource.RegisterStatusVar("Queries", func() int64 { return atomic.LoadInt64(&statusQueries) })
+	datasource.RegisterStatusVar("Queries_failed", func() int64 { return atomic.LoadInt64(&statusQueriesFailed) })
+	datasource.RegisterStatusVar("Memory_limit_exceeded", func() int64 { return atomic.LoadInt64(&statusMemoryExceeded) })
+	datasource.RegisterStatusVar("Memory_used", EngineMemory.Used)
+	datasource.RegisterStatusVar("Memory_peak", EngineMemory.Peak)
+}
+
+// countQuery count a finished statement in the engine status counters.
+func countQuery(err error) {
+	atomic.AddInt64(&statusQueries, 1)
+	if err == nil {
+		return
+	}
+	atomic.AddInt64(&statusQueriesFailed, 1)
+	if err == plan.ErrMemoryLimit {
+		atomic.AddInt64(&statusMemoryExceeded, 1)
+	}
+}
+
+// memoryUse the memory held by one operator (or one side of a join) of a
+// query, charged to the query account and released together when the
+// operator is done.  Not safe for concurrent use.
+type memoryUse struct {
+	acct *plan.MemoryAccount
+	held int64
+}
+
+func newMemoryUse(ctx *plan.Context) *memoryUse {
+	return &memoryUse{acct: ctx.Memory}
+}
+
+// hold a row of vals, plan.ErrMemoryLimit if over the query limit.
+func (m *memoryUse) hold(vals []driver.Value) error {
+	n := int64(rowOverhead)
+	for _, v := range vals {
+		n += valueOverhead + valueSize(v)
+	}
+	if err := m.acct.Grow(n); err != nil {
+		return err
+	}
+	m.held += n
+	return nil
+}
+
+// release all the memory held.
+func (m *memoryUse) release() {
+	m.acct.Shrink(m.held)
+	m.held = 0
+}
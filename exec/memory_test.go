@@ -0,0 +1,75 @@
+package exec_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+)
+
+func TestQueryMemoryLimit(t *testing.T) {
+	rows := []string{"id,user_id,amount"}
+	for i := 0; i < 50; i++ {
+		rows = append(rows, fmt.Sprintf("%d,u%d,%d", i, i%5, i*10))
+	}
+	mockcsv.LoadTable(mockcsv.SchemaName, "memory_orders", strings.Join(rows, "\n"))
+	s := datasource.NewMySqlSessionVars()
+
+	statusOf := func(name string) string {
+		rows, err := runSession(t, s, fmt.Sprintf("SHOW STATUS LIKE '%s'", name))
+		assert.Equal(t, nil, err)
+		if len(rows) != 1 {
+			t.Fatalf("expected status %s got %v", name, rows)
+		}
+		return rows[0][1].(string)
+	}
+	exceeded := statusOf("Memory_limit_exceeded")
+
+	queries := []string{
+		`SELECT id, amount FROM memory_orders ORDER BY amount DESC`,
+		`SELECT user_id, sum(amount) FROM memory_orders GROUP BY user_id`,
+		`SELECT o.id, p.amount FROM memory_orders AS o INNER JOIN memory_orders AS p ON o.user_id = p.user_id`,
+	}
+	// no limit
+	for _, q := range queries {
+		_, err := runSession(t, s, q)
+		assert.Equal(t, nil, err, q)
+	}
+
+	// over the session limit the sort, aggregate, join fail cleanly
+	_, err := runSession(t, s, `SET SESSION query_memory_limit = 1000`)
+	assert.Equal(t, nil, err)
+	for _, q := range queries {
+		_, err = runSession(t, s, q)
+		assert.Equal(t, plan.ErrMemoryLimit, err, q)
+	}
+	// queries not holding rows are not limited
+	qrows, err := runSession(t, s, `SELECT id FROM memory_orders WHERE amount > 100`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 39, len(qrows))
+	assert.Equal(t, int64(0), exec.EngineMemory.Used())
+
+	// engine counters, SHOW STATUS
+	assert.NotEqual(t, exceeded, statusOf("Memory_limit_exceeded"))
+	assert.NotEqual(t, "0", statusOf("Memory_peak"))
+	assert.Equal(t, "0", statusOf("Memory_used"))
+	assert.NotEqual(t, "0", statusOf("Queries_failed"))
+
+	// EXPLAIN ANALYZE reports the peak memory of the query
+	_, err = runSession(t, s, `SET SESSION query_memory_limit = 0`)
+	assert.Equal(t, nil, err)
+	qrows, err = runSession(t, s, `EXPLAIN ANALYZE SELECT id, amount FROM memory_orders ORDER BY amount DESC`)
+	assert.Equal(t, nil, err)
+	last := qrows[len(qrows)-1]
+	assert.Equal(t, "analyze", last[2])
+	assert.Contains(t, last[4], "rows=50 ")
+	assert.NotContains(t, last[4], "peak_memory=0")
+	_, err = runSession(t, s, `EXPLAIN ANALYZE DELETE FROM memory_orders WHERE id = 1`)
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/metrics"
+)
+
+// runMeasured run the task, reporting the rows it received and its
+// duration to the engine metrics if enabled.
+func runMeasured(task TaskRunner) error {
+	if !metrics.Enabled() {
+		return task.Run()
+	}
+	switch task.(type) {
+	case *TaskSequential, *TaskParallel:
+		// their tasks are reported
+		return task.Run()
+	}
+	start := time.Now()
+	err := task.Run()
+	rows := int64(0)
+	if rc, ok := task.(interface {
+		Rows() int64
+	}); ok {
+		rows = rc.Rows()
+	}
+	metrics.Get().OperatorDone(operatorName(task), rows, time.Since(start))
+	return err
+}
+
+// operatorName the metrics name of a task, its lower cased type name
+// (source, where, projection ...).
+func operatorName(task Task) string {
+	name := fmt.Sprintf("%T", task)
+	return strings.ToLower(name[strings.LastIndex(name, ".")+1:])
+}
+
+// statementKind the keyword of the statement of the job, for metrics.
+func (m *JobExecutor) statementKind() string {
+	if m.Ctx.Stmt == nil {
+		return "unknown"
+	}
+	return m.Ctx.Stmt.Keyword().String()
+}
@@ -0,0 +1,43 @@
+package exec_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	p := metrics.NewPrometheus("qlb")
+	metrics.Set(p)
+	defer metrics.Set(nil)
+
+	rows, err := runSession(t, datasource.NewContextSimple(), `SELECT user_id FROM users WHERE user_id != "x"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(rows))
+
+	buf := &bytes.Buffer{}
+	assert.Equal(t, nil, p.Write(buf))
+	out := buf.String()
+	for _, want := range []string{
+		`qlb_queries_started_total{kind="select"} 1`,
+		`qlb_queries_finished_total{kind="select",status="ok"} 1`,
+		`qlb_query_duration_seconds_count{kind="select"} 1`,
+		`qlb_operator_rows_total{op="source"} 3`,
+		`qlb_operator_rows_total{op="projection"} 3`,
+		`qlb_operator_duration_seconds_count{op="where"}`,
+	} {
+		assert.True(t, strings.Contains(out, want), "missing %s in\n%s", want, out)
+	}
+
+	_, err = runSession(t, datasource.NewContextSimple(), `SELECT user_id FROM not_a_table`)
+	assert.NotEqual(t, nil, err)
+	buf.Reset()
+	p.Write(buf)
+	// planning failed, the job never ran
+	assert.Equal(t, 1, strings.Count(buf.String(), `qlb_queries_started_total{kind="select"} 1`))
+}
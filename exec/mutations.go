@@ -3,13 +3,17 @@ package exec
 import (
 	"database/sql/driver"
 	"fmt"
+	"sync/atomic"
 
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
 
@@ -31,6 +35,8 @@ type (
 		upsert  *rel.SqlUpsert
 		db      schema.ConnUpsert
 		dbpatch schema.ConnPatchWhere
+		retry   *schema.RetryPolicy
+		limiter *schema.SourceLimiter
 	}
 	// Delete task for sources that natively support delete
 	DeletionTask struct {
@@ -40,6 +46,8 @@ type (
 		sql     *rel.SqlDelete
 		db      schema.ConnDeletion
 		deleted int
+		retry   *schema.RetryPolicy
+		limiter *schema.SourceLimiter
 	}
 	// Delete scanner if we don't have a seek operation on this source
 	DeletionScanner struct {
@@ -53,6 +61,8 @@ func NewInsert(ctx *plan.Context, p *plan.Insert) *Upsert {
 		TaskBase: NewTaskBase(ctx),
 		db:       p.Source,
 		insert:   p.Stmt,
+		retry:    writeRetry(ctx, p.Stmt.Table),
+		limiter:  writeLimiter(ctx, p.Stmt.Table),
 	}
 	return m
 }
@@ -61,6 +71,8 @@ func NewUpdate(ctx *plan.Context, p *plan.Update) *Upsert {
 		TaskBase: NewTaskBase(ctx),
 		db:       p.Source,
 		update:   p.Stmt,
+		retry:    writeRetry(ctx, p.Stmt.Table),
+		limiter:  writeLimiter(ctx, p.Stmt.Table),
 	}
 	return m
 }
@@ -69,6 +81,8 @@ func NewUpsert(ctx *plan.Context, p *plan.Upsert) *Upsert {
 		TaskBase: NewTaskBase(ctx),
 		db:       p.Source,
 		upsert:   p.Stmt,
+		retry:    writeRetry(ctx, p.Stmt.Table),
+		limiter:  writeLimiter(ctx, p.Stmt.Table),
 	}
 	return m
 }
@@ -80,15 +94,20 @@ func NewDelete(ctx *plan.Context, p *plan.Delete) *DeletionTask {
 		db:       p.Source,
 		sql:      p.Stmt,
 		p:        p,
+		retry:    writeRetry(ctx, p.Stmt.Table),
+		limiter:  writeLimiter(ctx, p.Stmt.Table),
 	}
 	return m
 }
 
 func (m *Upsert) Close() error {
+	m.Lock()
 	if m.closed {
+		m.Unlock()
 		return nil
 	}
 	m.closed = true
+	m.Unlock()
 	if closer, ok := m.db.(schema.Source); ok {
 		if err := closer.Close(); err != nil {
 			return err
@@ -101,20 +120,27 @@ func (m *Upsert) Run() error {
 	defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
-	var err error
-	var affectedCt int64
+	release, err := acquireSlot(m.limiter, true, m.SigChan())
+	if release == nil {
+		return err
+	}
+	defer release()
+
+	var affectedCt, matchedCt int64
 	switch {
 	case m.insert != nil:
 		affectedCt, err = m.insertRows(m.insert.Rows)
+		matchedCt = affectedCt
 	case m.upsert != nil && len(m.upsert.Rows) > 0:
 		affectedCt, err = m.insertRows(m.upsert.Rows)
+		matchedCt = affectedCt
 	case m.update != nil:
-		affectedCt, err = m.updateValues()
+		matchedCt, affectedCt, err = m.updateValues()
 	default:
 		u.Warnf("unknown mutation op?  %v", m)
 	}
 
-	vals := make([]driver.Value, 2)
+	vals := make([]driver.Value, 3)
 	if err != nil {
 		u.Warnf("errored, should not complete %v", err)
 		vals[0] = err.Error()
@@ -124,16 +150,18 @@ func (m *Upsert) Run() error {
 	}
 	vals[0] = int64(0) // status?
 	vals[1] = affectedCt
+	vals[2] = matchedCt
 	u.Infof("affected? %v", affectedCt)
 	m.msgOutCh <- &datasource.SqlDriverMessage{Vals: vals, IdVal: 1}
 	return nil
 }
 
-func (m *Upsert) updateValues() (int64, error) {
+// updateValues returns the rows matched by the where, and those changed.
+func (m *Upsert) updateValues() (int64, int64, error) {
 
 	select {
 	case <-m.SigChan():
-		return 0, nil
+		return 0, 0, nil
 	default:
 		// fall through
 	}
@@ -147,7 +175,7 @@ func (m *Upsert) updateValues() (int64, error) {
 			exprVal, ok := vm.Eval(nil, valcol.Expr)
 			if !ok {
 				u.Errorf("Could not evaluate: %s", valcol.Expr)
-				return 0, fmt.Errorf("Could not evaluate expression: %v", valcol.Expr)
+				return 0, 0, fmt.Errorf("Could not evaluate expression: %v", valcol.Expr)
 			}
 			valmap[key] = exprVal.Value()
 		} else {
@@ -157,15 +185,41 @@ func (m *Upsert) updateValues() (int64, error) {
 		//u.Debugf("key:%v col: %v   vals:%v", key, valcol, valmap[key])
 	}
 
+	// sources that compare-and-swap check the where, ie the version read by
+	// an optimistic writer, atomically with the update
+	if cas, ok := m.db.(schema.ConnCompareAndSwap); ok {
+		var where expr.Node
+		if m.update.Where != nil {
+			where = m.update.Where.Expr
+		}
+		var matched, changed int64
+		// a timed out swap may have bumped the version, a retry would
+		// not match it, so it is not retried
+		err := m.retry.DoWrite(false, func() error {
+			mn, cn, err := cas.CompareAndSwap(m.Ctx, where, valmap)
+			atomic.StoreInt64(&matched, mn)
+			atomic.StoreInt64(&changed, cn)
+			return err
+		})
+		return atomic.LoadInt64(&matched), atomic.LoadInt64(&changed), err
+	}
+
 	// if our backend source supports Where-Patches, ie update multiple
 	dbpatch, ok := m.db.(schema.ConnPatchWhere)
 	if ok {
-		updated, err := dbpatch.PatchWhere(m.Ctx, m.update.Where.Expr, valmap)
-		u.Infof("patch: %v %v", updated, err)
+		// a timed out attempt may still finish, so the count is atomic
+		var updated int64
+		err := m.retry.DoWrite(true, func() error {
+			n, err := dbpatch.PatchWhere(m.Ctx, m.update.Where.Expr, valmap)
+			atomic.StoreInt64(&updated, n)
+			return err
+		})
+		patched := atomic.LoadInt64(&updated)
+		u.Infof("patch: %v %v", patched, err)
 		if err != nil {
-			return updated, err
+			return patched, patched, err
 		}
-		return updated, nil
+		return patched, patched, nil
 	}
 
 	// TODO:   If it does not implement Where Patch then we need to do a poly fill
@@ -174,14 +228,22 @@ func (m *Upsert) updateValues() (int64, error) {
 
 	// Create a key from Where
 	key := datasource.KeyFromWhere(m.update.Where)
-	if _, err := m.db.Put(m.Ctx, key, valmap); err != nil {
+	// without a key the put inserts, which is not idempotent
+	if err := m.retry.DoWrite(key != nil, func() error {
+		_, err := m.db.Put(m.Ctx, key, valmap)
+		return err
+	}); err != nil {
 		u.Errorf("Could not put values: %v", err)
-		return 0, err
+		return 0, 0, err
 	}
-	return 1, nil
+	return 1, 1, nil
 }
 
 func (m *Upsert) insertRows(rows [][]*rel.ValueColumn) (int64, error) {
+	if m.insert != nil && m.insert.OnErrorSkip {
+		return m.insertRowsSkipping(rows)
+	}
+	strict := m.strictTable()
 	for i, row := range rows {
 		select {
 		case <-m.SigChan():
@@ -190,21 +252,20 @@ func (m *Upsert) insertRows(rows [][]*rel.ValueColumn) (int64, error) {
 			}
 			return int64(i) - 1, nil
 		default:
-			vals := make([]driver.Value, len(row))
-			for x, val := range row {
-				if val.Expr != nil {
-					exprVal, ok := vm.Eval(nil, val.Expr)
-					if !ok {
-						u.Errorf("Could not evaluate: %v", val.Expr)
-						return 0, fmt.Errorf("Could not evaluate expression: %v", val.Expr)
-					}
-					vals[x] = exprVal.Value()
-				} else {
-					vals[x] = val.Value.Value()
+			vals, err := rowValues(row)
+			if err != nil {
+				return 0, err
+			}
+			if strict != nil {
+				if err := m.checkStrict(strict, i+1, vals); err != nil {
+					return int64(i), err
 				}
 			}
 
-			if _, err := m.db.Put(m.Ctx.Context, nil, vals); err != nil {
+			if err := m.retry.DoWrite(false, func() error {
+				_, err := m.db.Put(m.Ctx.Context, nil, vals)
+				return err
+			}); err != nil {
 				u.Errorf("Could not put values: fordb T:%T  %v", m.db, err)
 				return 0, err
 			}
@@ -213,6 +274,70 @@ func (m *Upsert) insertRows(rows [][]*rel.ValueColumn) (int64, error) {
 	return int64(len(rows)), nil
 }
 
+// insertRowsSkipping write rows as one batch for INSERT ... ON ERROR SKIP,
+// the rows that fail are skipped with a warning instead of failing the
+// insert.  Returns the rows written.
+func (m *Upsert) insertRowsSkipping(rows [][]*rel.ValueColumn) (int64, error) {
+	select {
+	case <-m.SigChan():
+		return 0, nil
+	default:
+	}
+	strict := m.strictTable()
+	failed := make([]error, len(rows))
+	batch := make([][]driver.Value, 0, len(rows))
+	batchRows := make([]int, 0, len(rows)) // row index of each batch row
+	for i, row := range rows {
+		vals, err := rowValues(row)
+		if err == nil && strict != nil {
+			err = m.checkStrict(strict, i+1, vals)
+		}
+		if err != nil {
+			failed[i] = err
+			continue
+		}
+		batch = append(batch, vals)
+		batchRows = append(batchRows, i)
+	}
+	results, err := schema.PutBatch(m.Ctx.Context, m.db, batch)
+	if err != nil {
+		u.Errorf("Could not put batch: fordb T:%T  %v", m.db, err)
+		return 0, err
+	}
+	written := int64(0)
+	for i, res := range results {
+		if res.Outcome == schema.PutFailed {
+			failed[batchRows[i]] = res.Err
+			continue
+		}
+		written++
+	}
+	for i, err := range failed {
+		if err != nil {
+			m.Ctx.Warnings = append(m.Ctx.Warnings, fmt.Sprintf("Row %d skipped: %v", i+1, err))
+		}
+	}
+	return written, nil
+}
+
+// rowValues evaluate the values of an insert row.
+func rowValues(row []*rel.ValueColumn) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(row))
+	for x, val := range row {
+		if val.Expr != nil {
+			exprVal, ok := vm.Eval(nil, val.Expr)
+			if !ok {
+				u.Errorf("Could not evaluate: %v", val.Expr)
+				return nil, fmt.Errorf("Could not evaluate expression: %v", val.Expr)
+			}
+			vals[x] = exprVal.Value()
+		} else {
+			vals[x] = val.Value.Value()
+		}
+	}
+	return vals, nil
+}
+
 func (m *DeletionTask) Close() error {
 	m.Lock()
 	if m.closed {
@@ -234,7 +359,7 @@ func (m *DeletionTask) Run() error {
 	defer close(m.msgOutCh)
 
 	vals := make([]driver.Value, 2)
-	deletedCt, err := m.db.DeleteExpression(m.p, m.sql.Where.Expr)
+	deletedCt, err := m.deleteWhere()
 	if err != nil {
 		u.Errorf("Could not delete values: %v", err)
 		vals[0] = err.Error()
@@ -251,6 +376,24 @@ func (m *DeletionTask) Run() error {
 	return nil
 }
 
+// deleteWhere delete the rows matching the where, retried per the
+// source retry policy, once the source has a write slot free.
+func (m *DeletionTask) deleteWhere() (int, error) {
+	release, err := acquireSlot(m.limiter, true, m.SigChan())
+	if release == nil {
+		return 0, err
+	}
+	defer release()
+	// a timed out attempt may still finish, so the count is atomic
+	var deletedCt int64
+	err = m.retry.DoWrite(true, func() error {
+		n, err := m.db.DeleteExpression(m.p, m.sql.Where.Expr)
+		atomic.StoreInt64(&deletedCt, int64(n))
+		return err
+	})
+	return int(atomic.LoadInt64(&deletedCt)), err
+}
+
 func (m *DeletionScanner) Run() error {
 	defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
@@ -262,7 +405,7 @@ func (m *DeletionScanner) Run() error {
 		if m.sql.Where != nil {
 
 			vals := make([]driver.Value, 2)
-			deletedCt, err := m.db.DeleteExpression(m.p, m.sql.Where.Expr)
+			deletedCt, err := m.deleteWhere()
 			if err != nil {
 				u.Errorf("Could not delete values: %v", err)
 
@@ -279,3 +422,47 @@ func (m *DeletionScanner) Run() error {
 	}
 	return nil
 }
+
+// strictTable the insert table if session sql_mode is strict.
+func (m *Upsert) strictTable() *schema.Table {
+	if m.insert == nil || m.Ctx.Schema == nil || !m.Ctx.StrictMode() {
+		return nil
+	}
+	tbl, err := m.Ctx.Schema.Table(m.insert.Table)
+	if err != nil {
+		return nil
+	}
+	return tbl
+}
+
+// checkStrict in strict sql_mode each value must convert to its column type.
+func (m *Upsert) checkStrict(tbl *schema.Table, rowNum int, vals []driver.Value) error {
+	cols := m.insert.Columns.FieldNames()
+	if len(cols) == 0 {
+		cols = tbl.Columns()
+	}
+	if len(cols) != len(vals) {
+		return sqlerr.New(sqlerr.ErWrongValueCount, "Column count doesn't match value count at row %d", rowNum)
+	}
+	for i, col := range cols {
+		f, ok := tbl.Field(col)
+		if !ok || vals[i] == nil {
+			continue
+		}
+		v := value.NewValue(vals[i])
+		switch f.ValueType() {
+		case value.IntType:
+			_, ok = value.ValueToInt64(v)
+		case value.NumberType:
+			_, ok = value.ValueToFloat64(v)
+		case value.BoolType:
+			_, ok = value.ValueToBool(v)
+		case value.TimeType:
+			_, ok = value.ValueToTime(v)
+		}
+		if !ok {
+			return sqlerr.New(sqlerr.ErTruncatedWrongValue, "Incorrect %s value: '%v' for column '%s' at row %d", f.ValueType(), vals[i], col, rowNum)
+		}
+	}
+	return nil
+}
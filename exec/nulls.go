@@ -0,0 +1,62 @@
+package exec
+
+import (
+	"strings"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// nullGroupKey is the key part used for NULL values under strict null grouping,
+// it cannot be produced by ToString() of a non-null value.
+const nullGroupKey = "\x00"
+
+// groupKeyPart create the hashable key part for a single value in a
+// group by or distinct key, respecting the session null grouping.  Only
+// NULL is null here, Nil() is also true for empty strings which strict
+// keeps apart.
+func groupKeyPart(nullGrouping string, v value.Value, ok bool) string {
+	if !ok || v == nil || v.Type() == value.NilType {
+		if nullGrouping == plan.NullGroupLegacy {
+			return ""
+		}
+		return nullGroupKey
+	}
+	return v.ToString()
+}
+
+// groupKey join the key parts into a single hashable key.
+func groupKey(parts []string) string {
+	return strings.Join(parts, ",")
+}
+
+// distinctFilter tracks rows already emitted for SELECT DISTINCT.
+type distinctFilter struct {
+	nullGrouping string
+	keys         map[string]struct{}
+}
+
+func newDistinctFilter(nullGrouping string) *distinctFilter {
+	return &distinctFilter{nullGrouping: nullGrouping, keys: make(map[string]struct{})}
+}
+
+// seen returns true if an equivalent row has already been seen, else
+// records this row and returns false.
+func (m *distinctFilter) seen(msg schema.Message) bool {
+	sdm, ok := msg.(*datasource.SqlDriverMessageMap)
+	if !ok {
+		return false
+	}
+	parts := make([]string, len(sdm.Vals))
+	for i, dv := range sdm.Vals {
+		parts[i] = groupKeyPart(m.nullGrouping, value.NewValue(dv), dv != nil)
+	}
+	key := groupKey(parts)
+	if _, exists := m.keys[key]; exists {
+		return true
+	}
+	m.keys[key] = struct{}{}
+	return false
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
 
@@ -64,11 +66,12 @@ func (m *Order) Run() error {
 	inCh := m.MessageIn()
 
 	colIndex := m.p.Stmt.ColIndexes()
-	orderCt := len(m.p.Stmt.OrderBy)
 
 	// are are going to hold entire row in memory while we are calculating
 	//  so obviously not scalable.
-	sl := NewOrderMessages(m.p)
+	sl := NewOrderMessages(m.Ctx, m.p)
+	mem := newMemoryUse(m.Ctx)
+	defer mem.release()
 
 msgReadLoop:
 	for {
@@ -82,43 +85,17 @@ msgReadLoop:
 				//u.Debugf("NICE, got closed channel shutdown")
 				break msgReadLoop
 			} else {
-				var sdm *datasource.SqlDriverMessageMap
-
-				switch mt := msg.(type) {
-				case *datasource.SqlDriverMessageMap:
-					sdm = mt
-				default:
-
-					msgReader, isContextReader := msg.(expr.ContextReader)
-					if !isContextReader {
-						err := fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
-						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
-						return err
-					}
-
-					sdm = datasource.NewSqlDriverMessageMapCtx(msg.Id(), msgReader, colIndex)
+				mk, err := m.orderKey(msg, colIndex)
+				if err == nil {
+					err = mem.hold(mk.msg.Vals)
 				}
-
-				// We are going to use VM Engine to create a value for each statement in group by
-				//  then join each value together to create a unique key.
-				keys := make([]string, orderCt)
-				for i, col := range m.p.Stmt.OrderBy {
-					if col.Expr != nil {
-						if key, ok := vm.Eval(sdm, col.Expr); ok {
-							//u.Debugf("msgtype:%T  key:%q for-expr:%s", sdm, key, col.Expr)
-							keys[i] = key.ToString()
-						} else {
-							// Is this an error?
-							//u.Warnf("no key?  %s for %+v", col.Expr, sdm)
-						}
-					} else {
-						//u.Warnf("no col.expr? %#v", col)
-					}
+				if err != nil {
+					close(m.TaskBase.sigCh)
+					close(m.complete)
+					return err
 				}
-
 				//u.Infof("found key:%s for %+v", key, sdm)
-				sl.l = append(sl.l, &msgkey{keys, sdm})
+				sl.l = append(sl.l, mk)
 			}
 		}
 	}
@@ -136,17 +113,62 @@ msgReadLoop:
 	return nil
 }
 
+// orderKey the sort keys of msg.
+func (m *Order) orderKey(msg schema.Message, colIndex map[string]int) (*msgkey, error) {
+	var sdm *datasource.SqlDriverMessageMap
+
+	switch mt := msg.(type) {
+	case *datasource.SqlDriverMessageMap:
+		sdm = mt
+	default:
+
+		msgReader, isContextReader := msg.(expr.ContextReader)
+		if !isContextReader {
+			u.Errorf("unrecognized msg %T", msg)
+			return nil, fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+		}
+
+		sdm = datasource.NewSqlDriverMessageMapCtx(msg.Id(), msgReader, colIndex)
+	}
+
+	// We are going to use VM Engine to create a value for each statement in group by
+	//  then join each value together to create a unique key.
+	orderCt := len(m.p.Stmt.OrderBy)
+	keys := make([]value.Value, orderCt)
+	nulls := make([]bool, orderCt)
+	for i, col := range m.p.Stmt.OrderBy {
+		if col.Expr != nil {
+			if key, ok := vm.Eval(sdm, col.Expr); ok && key != nil && !key.Nil() {
+				//u.Debugf("msgtype:%T  key:%q for-expr:%s", sdm, key, col.Expr)
+				keys[i] = key
+			} else {
+				nulls[i] = true
+			}
+		} else {
+			//u.Warnf("no col.expr? %#v", col)
+		}
+	}
+	return &msgkey{keys, nulls, sdm}, nil
+}
+
 type msgkey struct {
-	keys []string
-	msg  *datasource.SqlDriverMessageMap
+	keys  []value.Value
+	nulls []bool
+	msg   *datasource.SqlDriverMessageMap
 }
+
+// OrderMessages sortable list of messages for order by.
 type OrderMessages struct {
-	l      []*msgkey
-	invert []bool
+	l          []*msgkey
+	invert     []bool
+	nullsFirst []bool
 }
 
-func NewOrderMessages(p *plan.Order) *OrderMessages {
+// NewOrderMessages create sortable message list, the NULL ordering for
+// each column is resolved from the column and context session.
+func NewOrderMessages(ctx *plan.Context, p *plan.Order) *OrderMessages {
 	invert := make([]bool, len(p.Stmt.OrderBy))
+	nullsFirst := make([]bool, len(p.Stmt.OrderBy))
 	for i, col := range p.Stmt.OrderBy {
 		//u.Debugf("invert?  %s ORDER %v", col.Expr, col.Order)
 		if col.Expr != nil {
@@ -154,10 +176,12 @@ func NewOrderMessages(p *plan.Order) *OrderMessages {
 				invert[i] = true
 			}
 		}
+		nullsFirst[i] = ctx.NullsFirst(col)
 	}
 	return &OrderMessages{
-		l:      make([]*msgkey, 0),
-		invert: invert,
+		l:          make([]*msgkey, 0),
+		invert:     invert,
+		nullsFirst: nullsFirst,
 	}
 }
 func (m *OrderMessages) Len() int {
@@ -165,19 +189,37 @@ func (m *OrderMessages) Len() int {
 }
 func (m *OrderMessages) Less(i, j int) bool {
 	for ki, key := range m.l[i].keys {
-		if key < m.l[j].keys[ki] {
-			if m.invert[ki] {
-				return false
-			}
-			return true
-		} else {
-			if m.invert[ki] {
-				return true
-			}
+		iNull, jNull := m.l[i].nulls[ki], m.l[j].nulls[ki]
+		switch {
+		case iNull && jNull:
+			continue
+		case iNull || jNull:
+			// NULL placement is independent of ASC/DESC
+			return iNull == m.nullsFirst[ki]
+		}
+		c := orderCompare(key, m.l[j].keys[ki])
+		if c == 0 {
+			continue
 		}
+		return (c < 0) != m.invert[ki]
 	}
 	return false
 }
+
+// orderCompare -1, 0, 1 as sort key l orders before, with or after r
+// ascending, nil (NULL) keys first.  Numbers and times compare as such,
+// not as strings, so 9 orders before 10.
+func orderCompare(l, r value.Value) int {
+	switch {
+	case l == nil && r == nil:
+		return 0
+	case l == nil:
+		return -1
+	case r == nil:
+		return 1
+	}
+	return compareValues(l, r)
+}
 func (m *OrderMessages) Swap(i, j int) {
 	m.l[i], m.l[j] = m.l[j], m.l[i]
 }
@@ -0,0 +1,290 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// Ensure our PivotSource implements schema.Source
+	_ schema.Source = (*PivotSource)(nil)
+)
+
+// PivotSource is the table resulting from a PIVOT or UNPIVOT of a table
+//
+//    SELECT * FROM sales PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2))
+//
+//    region  Q1     q2
+//    west    100.5  20
+//
+//    SELECT * FROM sales_wide UNPIVOT (amount FOR quarter IN (q1, q2))
+//
+//    region  quarter  amount
+//    west    q1       100.5
+//    west    q2       20
+//
+// PIVOT groups the rows by all columns other than the FOR column and the
+// columns of the aggregate, with one aggregate column per IN value, rows
+// without a value are nil.  UNPIVOT keeps all columns other than the IN
+// columns, with one row per non-nil IN column.  The pivoted table is read,
+// and pivoted, each time the source is opened.
+type PivotSource struct {
+	s     *schema.Schema // schema the pivoted table is read from
+	of    string         // pivoted table
+	cols  []string       // columns of the pivoted table
+	pivot *rel.SqlPivot
+	tbl   *schema.Table
+	keep  []string // grouped (PIVOT) or carried (UNPIVOT) columns of the pivoted table
+}
+
+// NewPivotSource create the table name, the PIVOT or UNPIVOT pv of table
+// of in schema s.
+func NewPivotSource(s *schema.Schema, name, of string, pv *rel.SqlPivot) (*PivotSource, error) {
+	if s == nil {
+		return nil, fmt.Errorf("must have schema")
+	}
+	ofTbl, err := s.Table(of)
+	if err != nil {
+		return nil, err
+	}
+	if ofTbl == nil {
+		return nil, fmt.Errorf("could not find table %q to pivot", of)
+	}
+	if !ofTbl.HasField(pv.For) && !pv.Unpivot {
+		return nil, fmt.Errorf("PIVOT FOR column %q not found in %q", pv.For, of)
+	}
+
+	// columns of the pivoted table consumed by the pivot
+	used := make(map[string]bool)
+	if pv.Unpivot {
+		for _, in := range pv.In {
+			col := pivotColumn(in.Value.(*expr.IdentityNode))
+			if !ofTbl.HasField(col) {
+				return nil, fmt.Errorf("UNPIVOT column %q not found in %q", col, of)
+			}
+			used[strings.ToLower(col)] = true
+		}
+	} else {
+		used[strings.ToLower(pv.For)] = true
+		for _, id := range expr.FindAllIdentities(pv.Agg) {
+			used[strings.ToLower(pivotColumn(id))] = true
+		}
+	}
+
+	m := &PivotSource{s: s, of: of, pivot: pv, tbl: schema.NewTable(name)}
+	for _, fld := range ofTbl.FieldList() {
+		m.cols = append(m.cols, expr.IdentityMaybeQuote('`', fld.Name))
+		if used[strings.ToLower(fld.Name)] {
+			continue
+		}
+		m.keep = append(m.keep, fld.Name)
+		m.tbl.AddFieldType(fld.Name, fld.ValueType())
+	}
+	if pv.Unpivot {
+		vt := value.UnknownType
+		if fld, ok := ofTbl.Field(pivotColumn(pv.In[0].Value.(*expr.IdentityNode))); ok {
+			vt = fld.ValueType()
+		}
+		m.tbl.AddFieldType(pv.For, value.StringType)
+		m.tbl.AddFieldType(pv.Value, vt)
+	} else {
+		vt := value.UnknownType
+		if fn, ok := pv.Agg.(*expr.FuncNode); ok && fn.F.CustomFunc != nil {
+			vt = fn.F.Type()
+		}
+		for _, in := range pv.In {
+			m.tbl.AddFieldType(in.Name(), vt)
+		}
+	}
+	m.tbl.SetColumnsFromFields()
+	return m, nil
+}
+
+// pivotColumn the un-qualified column name of identity, ie amount of sales.amount
+func pivotColumn(id *expr.IdentityNode) string {
+	_, right, _ := id.LeftRight()
+	return right
+}
+
+// Init the pivot source.
+func (m *PivotSource) Init() {}
+
+// Setup the pivot source.
+func (m *PivotSource) Setup(*schema.Schema) error { return nil }
+
+// Close the pivot source.
+func (m *PivotSource) Close() error { return nil }
+
+// Tables list, the single pivot table.
+func (m *PivotSource) Tables() []string { return []string{m.tbl.Name} }
+
+// Table the pivot table schema.
+func (m *PivotSource) Table(table string) (*schema.Table, error) { return m.tbl, nil }
+
+// Open a scanner of the pivoted rows.
+func (m *PivotSource) Open(table string) (schema.Conn, error) {
+	msgs, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]driver.Value
+	if m.pivot.Unpivot {
+		rows = m.unpivotRows(msgs)
+	} else if rows, err = m.pivotRows(msgs); err != nil {
+		return nil, err
+	}
+	u.Debugf("pivoted %q rows in=%d out=%d", m.of, len(msgs), len(rows))
+	return &rowsConn{cols: m.tbl.Columns(), colIdx: m.tbl.FieldNamesPositions(), rows: rows}, nil
+}
+
+// read all rows of the pivoted table.
+func (m *PivotSource) read() ([]*datasource.SqlDriverMessageMap, error) {
+	ctx := plan.NewContext(fmt.Sprintf("SELECT %s FROM %s", strings.Join(m.cols, ", "), expr.IdentityMaybeQuote('`', m.of)))
+	ctx.Schema = m.s
+	job, err := BuildSqlJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer job.Close()
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(NewResultBuffer(ctx, &msgs))
+	if err = job.Setup(); err != nil {
+		return nil, err
+	}
+	if err = job.Run(); err != nil {
+		return nil, err
+	}
+	rows := make([]*datasource.SqlDriverMessageMap, 0, len(msgs))
+	for _, msg := range msgs {
+		if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+			rows = append(rows, mm)
+		}
+	}
+	return rows, nil
+}
+
+// inIndex the position of v in the PIVOT IN values, -1 if not found.
+func (m *PivotSource) inIndex(v value.Value) int {
+	if v == nil || v.Nil() {
+		return -1
+	}
+	sv := v.ToString()
+	for i, in := range m.pivot.In {
+		switch n := in.Value.(type) {
+		case *expr.NumberNode:
+			if fv, ok := value.StringToFloat64(sv); ok && fv == n.Float64 {
+				return i
+			}
+		case *expr.StringNode:
+			if sv == n.Text {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (m *PivotSource) pivotRows(msgs []*datasource.SqlDriverMessageMap) ([][]driver.Value, error) {
+	col := &rel.Column{Expr: m.pivot.Agg}
+	groups := make(map[string]int)
+	rows := make([][]driver.Value, 0)
+	aggs := make([][]Aggregator, 0)
+	for _, mm := range msgs {
+		forVal, _ := mm.Get(m.pivot.For)
+		in := m.inIndex(forVal)
+		if in < 0 {
+			continue
+		}
+		row := make([]driver.Value, len(m.keep)+len(m.pivot.In))
+		for i, name := range m.keep {
+			if v, ok := mm.Get(name); ok && v != nil {
+				row[i] = v.Value()
+			}
+		}
+		key := fmt.Sprintf("%#v", row[:len(m.keep)])
+		g, exists := groups[key]
+		if !exists {
+			g = len(rows)
+			groups[key] = g
+			rows = append(rows, row)
+			aggs = append(aggs, make([]Aggregator, len(m.pivot.In)))
+		}
+		if aggs[g][in] == nil {
+			agg, err := NewAggregator(col, false)
+			if err != nil {
+				return nil, err
+			}
+			aggs[g][in] = agg
+		}
+		doAgg(aggs[g][in], mm, m.pivot.Agg)
+	}
+	for g, row := range rows {
+		for i, agg := range aggs[g] {
+			if agg != nil {
+				row[len(m.keep)+i] = agg.Result()
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (m *PivotSource) unpivotRows(msgs []*datasource.SqlDriverMessageMap) [][]driver.Value {
+	rows := make([][]driver.Value, 0, len(msgs)*len(m.pivot.In))
+	for _, mm := range msgs {
+		for _, in := range m.pivot.In {
+			v, ok := mm.Get(pivotColumn(in.Value.(*expr.IdentityNode)))
+			if !ok || v == nil || v.Nil() {
+				continue
+			}
+			row := make([]driver.Value, len(m.keep), len(m.keep)+2)
+			for i, name := range m.keep {
+				if kv, ok := mm.Get(name); ok && kv != nil {
+					row[i] = kv.Value()
+				}
+			}
+			rows = append(rows, append(row, in.Name(), v.Value()))
+		}
+	}
+	return rows
+}
+
+// rewritePivots replace each PIVOT or UNPIVOT table of the select with
+// a PivotSource table of its result, added to the (statement only) schema
+// of ctx.  The table keeps its name as alias so columns qualified by it
+// are unchanged.
+func rewritePivots(ctx *plan.Context, sel *rel.SqlSelect) error {
+	for _, from := range sel.From {
+		if from.Pivot == nil {
+			continue
+		}
+		if ctx.Schema == nil {
+			return fmt.Errorf("must have schema for PIVOT")
+		}
+		if from.SubQuery != nil {
+			return fmt.Errorf("PIVOT of a sub-query is not supported")
+		}
+		name := from.SourceName()
+		src, err := NewPivotSource(ctx.Schema, name+"_pivot", name, from.Pivot)
+		if err != nil {
+			return err
+		}
+		ctx.Schema = ctx.Schema.WithTable(src.tbl, src)
+		if from.Alias == "" {
+			from.Alias = name
+		}
+		from.Name = src.tbl.Name
+		from.Schema = ""
+		from.Pivot = nil
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestPooledSource(t *testing.T) {
+	ss, err := td.MockSchema.SchemaForTable("users")
+	assert.Equal(t, nil, err)
+	pool, err := schema.NewConnPool("mockcsv", ss.DS, &schema.ConfigPool{MaxOpen: 1})
+	assert.Equal(t, nil, err)
+	ss.Pool = pool
+	defer func() { ss.Pool = nil }()
+
+	for i := 0; i < 3; i++ {
+		rows, err := runSession(t, datasource.NewContextSimple(), `SELECT user_id FROM users`)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 3, len(rows))
+		// the conn is back in the pool for the next query
+		stats := pool.Stats()
+		assert.Equal(t, 1, stats.Open)
+		assert.Equal(t, 1, stats.Idle)
+	}
+}
+
+func TestPooledMemDb(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "pool_users", `user_id,name,city
+1,bob,Portland
+2,alice,Denver
+3,eve,Portland`)
+	data := make([][]driver.Value, 0)
+	for i := 0; i < 40; i++ {
+		data = append(data, []driver.Value{i, i%20 + 1, i * 10})
+	}
+	db, err := memdb.NewMemDbData("pool_orders", data, []string{"order_id", "user_id", "price"})
+	assert.Equal(t, nil, err)
+	tbl, _ := db.Table("pool_orders")
+	s := mockcsv.Schema().WithTable(tbl, db)
+	ss, err := s.SchemaForTable("pool_orders")
+	assert.Equal(t, nil, err)
+	pool, err := schema.NewConnPool("pool_orders", db, &schema.ConfigPool{MaxOpen: 1})
+	assert.Equal(t, nil, err)
+	ss.Pool = pool
+
+	run := func(sql string) int {
+		ctx := td.TestContext(sql)
+		ctx.Schema = s
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		return len(msgs)
+	}
+
+	// the same scan on the re-used conn starts over
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, 40, run(`SELECT order_id FROM pool_orders`))
+		stats := pool.Stats()
+		assert.Equal(t, 1, stats.Open)
+		assert.Equal(t, 1, stats.Idle)
+	}
+
+	// the join key filter of the probe side is not kept by the pooled conn
+	assert.Equal(t, 2, run(`SELECT o.order_id, u.name FROM pool_orders AS o
+		INNER JOIN pool_users AS u ON o.user_id = u.user_id WHERE u.city = "Denver"`))
+	assert.Equal(t, 1, pool.Stats().Idle)
+	assert.Equal(t, 40, run(`SELECT order_id FROM pool_orders`))
+}
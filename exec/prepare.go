@@ -0,0 +1,30 @@
+package exec
+
+import (
+	"github.com/araddon/qlbridge/plan"
+)
+
+// Prepare saves the statement of a PREPARE to the session running it,
+// it writes no rows.
+//
+//    PREPARE stmt1 FROM 'SELECT * FROM users WHERE user_id = ?'
+//
+type Prepare struct {
+	*TaskBase
+	p *plan.PreparedStatement
+}
+
+// NewPrepare create task saving prepared statement p to the session.
+func NewPrepare(ctx *plan.Context, p *plan.PreparedStatement) *Prepare {
+	return &Prepare{TaskBase: NewTaskBase(ctx), p: p}
+}
+
+// Run save the prepared statement.
+func (m *Prepare) Run() error {
+	defer close(m.msgOutCh)
+	if m.Ctx.Conn == nil {
+		return ErrNoSession
+	}
+	m.Ctx.Conn.Prepare(m.p.Stmt)
+	return nil
+}
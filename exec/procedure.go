@@ -0,0 +1,155 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure our procedure source implements schema.Source
+	_ schema.Source = (*procedureSource)(nil)
+	_ Procedure     = (*procedureFunc)(nil)
+
+	procMu     sync.RWMutex
+	procedures = make(map[string]Procedure)
+)
+
+// Procedure is a Go registered stored procedure, invoked by
+//
+//    CALL name(arg, arg)
+//
+// A CALL is run as a SELECT of the procedure result rows, so they are
+// streamed through the normal exec pipeline (and the mysql server, sql
+// driver) as if read from a table.  Procedures that only have side effects
+// should return a status row.
+type Procedure interface {
+	// Fields the result columns of the procedure.
+	Fields() []*schema.Field
+	// Call run the procedure with the evaluated args.  The ctx is the
+	// callers (schema, session, user) so the procedure may itself run sql.
+	Call(ctx *plan.Context, args []value.Value) (schema.ConnScanner, error)
+}
+
+// ProcedureFunc a procedure returning all of its result rows at once.
+type ProcedureFunc func(ctx *plan.Context, args []value.Value) ([][]driver.Value, error)
+
+type procedureFunc struct {
+	fields []*schema.Field
+	fn     ProcedureFunc
+}
+
+// NewProcedureFunc create a Procedure of result columns fields, from a
+// func returning the rows.
+func NewProcedureFunc(fields []*schema.Field, fn ProcedureFunc) Procedure {
+	return &procedureFunc{fields: fields, fn: fn}
+}
+
+func (m *procedureFunc) Fields() []*schema.Field { return m.fields }
+func (m *procedureFunc) Call(ctx *plan.Context, args []value.Value) (schema.ConnScanner, error) {
+	rows, err := m.fn(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]string, len(m.fields))
+	colIdx := make(map[string]int, len(m.fields))
+	for i, f := range m.fields {
+		cols[i] = f.Name
+		colIdx[f.Name] = i
+	}
+	return &rowsConn{cols: cols, colIdx: colIdx, rows: rows}, nil
+}
+
+// RegisterProcedure register a procedure to be invoked by CALL name(args).
+// Registering an existing name replaces it.
+func RegisterProcedure(name string, p Procedure) {
+	procMu.Lock()
+	defer procMu.Unlock()
+	procedures[strings.ToLower(name)] = p
+}
+
+// ProcedureGet find a registered procedure by name.
+func ProcedureGet(name string) (Procedure, bool) {
+	procMu.RLock()
+	defer procMu.RUnlock()
+	p, ok := procedures[strings.ToLower(name)]
+	return p, ok
+}
+
+// rewriteCall rewrite a CALL as a SELECT of the procedure result columns,
+// from a schema of the single procedure result table scoped to ctx.
+//
+//    CALL top_users(10)  =>  SELECT `user_id`, `ct` FROM `top_users`
+func rewriteCall(ctx *plan.Context, call *rel.SqlCall) (*rel.SqlSelect, error) {
+	proc, ok := ProcedureGet(call.Name)
+	if !ok {
+		return nil, sqlerr.New(sqlerr.ErUnknownProcedure, "PROCEDURE %s does not exist", call.Name)
+	}
+	fields := proc.Fields()
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("PROCEDURE %s has no result columns", call.Name)
+	}
+	args := make([]value.Value, len(call.Args))
+	for i, arg := range call.Args {
+		if arg.Expr == nil {
+			args[i] = arg.Value
+			continue
+		}
+		v, ok := vm.Eval(nil, arg.Expr)
+		if !ok {
+			return nil, fmt.Errorf("could not evaluate arg %s of PROCEDURE %s", arg.Expr, call.Name)
+		}
+		args[i] = v
+	}
+
+	tbl := schema.NewTable(strings.ToLower(call.Name))
+	cols := make([]string, len(fields))
+	for i, f := range fields {
+		tbl.AddField(f)
+		cols[i] = "`" + strings.Replace(f.Name, "`", "``", -1) + "`"
+	}
+	tbl.SetColumnsFromFields()
+	sel, err := rel.ParseSqlSelect(fmt.Sprintf("SELECT %s FROM `%s`",
+		strings.Join(cols, ", "), strings.Replace(tbl.Name, "`", "``", -1)))
+	if err != nil {
+		return nil, err
+	}
+
+	src := &procedureSource{tbl: tbl, proc: proc, ctx: connContext(ctx, call.String()), args: args}
+	schemaName := tbl.Name
+	if ctx.Schema != nil {
+		schemaName = ctx.Schema.Name
+	}
+	ctx.Schema = schema.NewSchemaTable(schemaName, tbl, src)
+	ctx.Stmt = sel
+	return sel, nil
+}
+
+// procedureSource source of the result table of a single CALL, the
+// procedure is run when the table is opened.
+type procedureSource struct {
+	tbl  *schema.Table
+	proc Procedure
+	ctx  *plan.Context
+	args []value.Value
+}
+
+func (m *procedureSource) Init()                                     {}
+func (m *procedureSource) Setup(*schema.Schema) error                { return nil }
+func (m *procedureSource) Close() error                              { return nil }
+func (m *procedureSource) Tables() []string                          { return []string{m.tbl.Name} }
+func (m *procedureSource) Table(table string) (*schema.Table, error) { return m.tbl, nil }
+func (m *procedureSource) Open(table string) (schema.Conn, error) {
+	u.Debugf("CALL %s args=%v", m.tbl.Name, m.args)
+	return m.proc.Call(m.ctx, m.args)
+}
@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	// ProfileLabelsVar global variable, when on statements run with pprof
+	// labels of their query id, fingerprint so cpu (and goroutine)
+	// profiles can be attributed to query shapes.
+	//
+	//	SET GLOBAL profile_labels = 1;
+	//	go tool pprof -tagfocus qlb_fingerprint=9c3b2e1f0a4d5e6f cpu.prof
+	ProfileLabelsVar = "profile_labels"
+
+	// LabelQueryId pprof label of the unique id of the statement.
+	LabelQueryId = "qlb_query_id"
+	// LabelFingerprint pprof label of the statement fingerprint, the
+	// hash of its normalized sql, the same for statements differing
+	// only in literals.
+	LabelFingerprint = "qlb_fingerprint"
+	// LabelStatement pprof label of the statement keyword (select, insert ...).
+	LabelStatement = "qlb_statement"
+)
+
+var profileLabels int32
+
+func init() {
+	datasource.RegisterSysVar(&datasource.SysVar{
+		Name:     ProfileLabelsVar,
+		Type:     value.StringType,
+		Default:  value.NewStringValue("0"),
+		Scope:    datasource.ScopeGlobal,
+		OnGlobal: setProfileLabels,
+	})
+}
+
+// SetProfileLabels turn on/off labeling statements with pprof labels.
+func SetProfileLabels(on bool) {
+	if on {
+		atomic.StoreInt32(&profileLabels, 1)
+	} else {
+		atomic.StoreInt32(&profileLabels, 0)
+	}
+}
+
+// ProfileLabels are statements labeled with pprof labels.
+func ProfileLabels() bool {
+	return atomic.LoadInt32(&profileLabels) == 1
+}
+
+func setProfileLabels(v value.Value) error {
+	on, ok := value.ValueToBool(v)
+	if !ok {
+		// SET GLOBAL profile_labels = "on"
+		switch strings.ToLower(v.ToString()) {
+		case "on":
+			on, ok = true, true
+		case "off":
+			on, ok = false, true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("QLBridge: invalid %s %v", ProfileLabelsVar, v)
+	}
+	SetProfileLabels(on)
+	return nil
+}
+
+// QueryFingerprint the fingerprint of sql, hex of the hash of its
+// normalized form.
+func QueryFingerprint(sql string) string {
+	if norm, _, err := rel.NormalizeSql(sql); err == nil {
+		sql = norm
+	}
+	h := fnv.New64a()
+	h.Write([]byte(sql))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// queryLabels the pprof labels of the statement of ctx.
+func queryLabels(ctx *plan.Context) pprof.LabelSet {
+	kw := "unknown"
+	if ctx.Stmt != nil {
+		kw = ctx.Stmt.Keyword().String()
+	}
+	return pprof.Labels(
+		LabelQueryId, strconv.FormatUint(ctx.Id(), 10),
+		LabelFingerprint, QueryFingerprint(ctx.Raw),
+		LabelStatement, kw,
+	)
+}
+
+// runLabeled run fn with the pprof labels of the statement applied to
+// the calling go routine (and so the task go routines it starts), the
+// labeled go context replaces ctx.Context so sources can read them.
+func runLabeled(ctx *plan.Context, fn func() error) error {
+	if !ProfileLabels() {
+		return fn()
+	}
+	var parent context.Context = ctx.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	var err error
+	pprof.Do(parent, queryLabels(ctx), func(lctx context.Context) {
+		ctx.Context = lctx
+		err = fn()
+	})
+	return err
+}
@@ -0,0 +1,60 @@
+package exec_test
+
+import (
+	"database/sql"
+	"runtime/pprof"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestProfileLabels(t *testing.T) {
+	defer exec.SetProfileLabels(false)
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	_, err = db.Exec(`SET GLOBAL profile_labels = 1`)
+	assert.Equal(t, nil, err)
+	assert.True(t, exec.ProfileLabels())
+
+	run := func(sql string) map[string]string {
+		ctx := td.TestContext(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		labels := make(map[string]string)
+		pprof.ForLabels(ctx.Context, func(k, v string) bool {
+			labels[k] = v
+			return true
+		})
+		labels["id"] = strconv.FormatUint(ctx.Id(), 10)
+		return labels
+	}
+
+	l1 := run(`SELECT user_id FROM users WHERE referral_count > 1`)
+	assert.Equal(t, l1["id"], l1[exec.LabelQueryId])
+	assert.Equal(t, "select", l1[exec.LabelStatement])
+	assert.Equal(t, exec.QueryFingerprint(`SELECT user_id FROM users WHERE referral_count > 1`), l1[exec.LabelFingerprint])
+
+	// same shape, same fingerprint, different query
+	l2 := run(`SELECT user_id FROM users WHERE referral_count > 50`)
+	assert.Equal(t, l1[exec.LabelFingerprint], l2[exec.LabelFingerprint])
+	assert.NotEqual(t, l1[exec.LabelQueryId], l2[exec.LabelQueryId])
+	l3 := run(`SELECT email FROM users WHERE referral_count > 1`)
+	assert.NotEqual(t, l1[exec.LabelFingerprint], l3[exec.LabelFingerprint])
+
+	_, err = db.Exec(`SET GLOBAL profile_labels = "off"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", run(`SELECT user_id FROM users`)[exec.LabelQueryId])
+	db.Exec(`SET GLOBAL profile_labels = "lots"`)
+	assert.True(t, !exec.ProfileLabels())
+}
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"database/sql/driver"
+	"fmt"
 	"math"
 
 	u "github.com/araddon/gou"
@@ -125,6 +126,12 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 		colCt = len(m.p.Proj.Columns)
 	}
 
+	// SELECT DISTINCT de-duplicates on final projected row
+	var distinct *distinctFilter
+	if isFinal && m.p.Stmt.Distinct {
+		distinct = newDistinctFilter(m.Ctx.NullGrouping())
+	}
+
 	rowCt := 0
 	return func(ctx *plan.Context, msg schema.Message) bool {
 
@@ -137,14 +144,16 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 
 		//u.Infof("got projection message: %T %#v", msg, msg.Body())
 		var outMsg schema.Message
+		var evalErr error // first column that failed to evaluate
 		switch mt := msg.(type) {
 		case *datasource.SqlDriverMessageMap:
 			// use our custom write context for example purposes
 			row := make([]driver.Value, colCt)
-			rdr := datasource.NewNestedContextReader([]expr.ContextReader{
+			// session is writeable for  @var := expr  assignments, columns
+			// are evaluated left to right so later columns see the value
+			rdr := datasource.NewNestedContextReadWriter([]expr.ContextReader{
 				mt,
-				ctx.Session,
-			}, mt.Ts())
+			}, ctx.Session, mt.Ts())
 			//u.Debugf("about to project: %#v", mt)
 			colIdx := -1
 			for _, col := range columns {
@@ -206,6 +215,9 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 					v, ok := vm.Eval(rdr, col.Expr)
 					if !ok {
 						u.Warnf("failed eval key=%q  val=%#v expr:%q  expr:%#v mt:%#v", col.Key(), v, col.Expr, col.Expr, mt)
+						if evalErr == nil {
+							evalErr = fmt.Errorf("could not evaluate %s: %s", col.Key(), col.Expr)
+						}
 						// for k, v := range ctx.Session.Row() {
 						// 	u.Infof("%p session? %s: %v", ctx.Session, k, v.Value())
 						// }
@@ -272,6 +284,9 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 					v, ok := vm.Eval(mt, col.Expr)
 					if !ok {
 						//u.Warnf("failed eval key=%v  val=%#v expr:%s   mt:%#v", col.Key(), v, col.Expr, mt.Row())
+						if evalErr == nil {
+							evalErr = fmt.Errorf("could not evaluate %s: %s", col.Key(), col.Expr)
+						}
 					} else if v == nil {
 						//u.Debugf("%#v", col)
 						//u.Debugf("evaled nil? key=%v  val=%v expr:%s", col.Key(), v, col.Expr.String())
@@ -293,6 +308,20 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 			u.Errorf("could not project msg:  %T", msg)
 		}
 
+		// streaming queries drop, dead-letter or halt on the row
+		if evalErr != nil && streamsRowErrors(ctx) {
+			rdr, _ := msg.(expr.ContextReader)
+			if err := rowError(ctx, "projection", rdr, evalErr); err != nil {
+				haltTask(m, err)
+				return false
+			}
+			return true
+		}
+
+		if distinct != nil && outMsg != nil && distinct.seen(outMsg) {
+			return true
+		}
+
 		if rowCt >= limit {
 			//u.Debugf("%p Projection reaching Limit!!! rowct:%v  limit:%v", m, rowCt, limit)
 			out <- nil // Sending nil message is a message to downstream to shutdown
@@ -0,0 +1,137 @@
+package exec
+
+import (
+	"fmt"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// Ensure that we implement the scanner interfaces
+	_ schema.ConnScanner = (*ReplicaScanner)(nil)
+	_ schema.IteratorErr = (*ReplicaScanner)(nil)
+)
+
+// ReplicaScanner scans each partition of a replicated source in turn.  If
+// the scan of a partition fails part way (the partition conn reports an
+// error, or can't be opened) only that partition is re-issued on its next
+// replica, skipping the rows already read from it, so a long running
+// federated query survives the loss of a node.  The scan fails once every
+// replica of a partition has failed.
+type ReplicaScanner struct {
+	src     schema.SourceReplicated
+	parts   []*schema.Partition
+	part    int // index of partition being scanned
+	replica int // replica of partition being scanned
+	read    int // rows read from partition
+	conn    schema.ConnScanner
+	err     error
+}
+
+// NewReplicaScanner create a scanner over partitions of a replicated source.
+func NewReplicaScanner(src schema.SourceReplicated, parts []*schema.Partition) *ReplicaScanner {
+	return &ReplicaScanner{src: src, parts: parts}
+}
+
+// tablePartitions the partitions of table, or all of the sources partitions
+// if the table doesn't declare its own.
+func tablePartitions(src schema.SourceReplicated, tbl *schema.Table) []*schema.Partition {
+	if tbl != nil && tbl.Partition != nil && len(tbl.Partition.Partitions) > 0 {
+		return tbl.Partition.Partitions
+	}
+	return src.Partitions()
+}
+
+// Next the next message across all partitions, nil once all partitions are
+// read or the scan has failed, see Err.
+func (m *ReplicaScanner) Next() schema.Message {
+	for m.err == nil && m.part < len(m.parts) {
+		if m.conn == nil && !m.open() {
+			continue
+		}
+		if msg := m.conn.Next(); msg != nil {
+			m.read++
+			return msg
+		}
+		err := iterErr(m.conn)
+		m.closeConn()
+		if err != nil {
+			m.failover(err)
+			continue
+		}
+		m.part++
+		m.replica = 0
+		m.read = 0
+	}
+	return nil
+}
+
+// Err the error that ended the scan, if every replica of a partition failed.
+func (m *ReplicaScanner) Err() error { return m.err }
+
+// Close the current partition conn.
+func (m *ReplicaScanner) Close() error {
+	m.closeConn()
+	return nil
+}
+
+// open the current replica of current partition, skipping the rows already
+// read from it on a previous replica.
+func (m *ReplicaScanner) open() bool {
+	p := m.parts[m.part]
+	conn, err := m.src.ReplicaSource(p, m.replica)
+	if err != nil {
+		m.failover(err)
+		return false
+	}
+	scanner, ok := conn.(schema.ConnScanner)
+	if !ok {
+		conn.Close()
+		m.err = fmt.Errorf("QLBridge.exec: partition %s conn %T must implement Scanner", p.Id, conn)
+		return false
+	}
+	m.conn = scanner
+	for i := 0; i < m.read; i++ {
+		if scanner.Next() == nil {
+			err := iterErr(scanner)
+			if err == nil {
+				err = fmt.Errorf("replica has %d rows, %d already read", i, m.read)
+			}
+			m.closeConn()
+			m.failover(err)
+			return false
+		}
+	}
+	return true
+}
+
+// failover move the current partition to its next replica.
+func (m *ReplicaScanner) failover(err error) {
+	p := m.parts[m.part]
+	replicas := m.src.PartitionReplicas(p)
+	u.Warnf("partition %s replica %d/%d failed after %d rows: %v", p.Id, m.replica+1, replicas, m.read, err)
+	m.replica++
+	if m.replica >= replicas {
+		m.err = fmt.Errorf("QLBridge.exec: partition %s failed on all %d replicas: %v", p.Id, replicas, err)
+	}
+}
+
+func (m *ReplicaScanner) closeConn() {
+	if m.conn == nil {
+		return
+	}
+	if err := m.conn.Close(); err != nil {
+		u.Warnf("error closing partition conn %v", err)
+	}
+	m.conn = nil
+}
+
+// iterErr the error reported by an iterator, if it reports them.
+func iterErr(it schema.Iterator) error {
+	if ie, ok := it.(schema.IteratorErr); ok {
+		return ie.Err()
+	}
+	return nil
+}
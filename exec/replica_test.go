@@ -0,0 +1,85 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// replicated source of 2 partitions of 5 rows, replicas fail after failAt
+// rows (-1 never)
+type replicated struct {
+	parts  []*schema.Partition
+	failAt map[string][]int
+	opened []string
+}
+
+func newReplicated(failAt map[string][]int) *replicated {
+	return &replicated{
+		parts:  []*schema.Partition{{Id: "p1", Left: "0", Right: "5"}, {Id: "p2", Left: "5", Right: "10"}},
+		failAt: failAt,
+	}
+}
+func (m *replicated) Partitions() []*schema.Partition { return m.parts }
+func (m *replicated) PartitionSource(p *schema.Partition) (schema.Conn, error) {
+	return m.ReplicaSource(p, 0)
+}
+func (m *replicated) PartitionReplicas(p *schema.Partition) int { return len(m.failAt[p.Id]) }
+func (m *replicated) ReplicaSource(p *schema.Partition, replica int) (schema.Conn, error) {
+	m.opened = append(m.opened, fmt.Sprintf("%s:%d", p.Id, replica))
+	start := 0
+	if p.Id == "p2" {
+		start = 5
+	}
+	return &flakyConn{start: start, failAt: m.failAt[p.Id][replica]}, nil
+}
+
+type flakyConn struct {
+	start, read, failAt int
+	err                 error
+}
+
+func (m *flakyConn) Close() error { return nil }
+func (m *flakyConn) Err() error   { return m.err }
+func (m *flakyConn) Next() schema.Message {
+	if m.read == m.failAt {
+		m.err = fmt.Errorf("node down")
+		return nil
+	}
+	if m.read == 5 {
+		return nil
+	}
+	id := m.start + m.read
+	m.read++
+	return datasource.NewSqlDriverMessageMapVals(uint64(id), []driver.Value{int64(id)}, []string{"id"})
+}
+
+func scanIds(s *exec.ReplicaScanner) []uint64 {
+	var ids []uint64
+	for msg := s.Next(); msg != nil; msg = s.Next() {
+		ids = append(ids, msg.Id())
+	}
+	return ids
+}
+
+func TestReplicaScanner(t *testing.T) {
+	// p1 fails on first replica after 2 rows, resumes on second
+	src := newReplicated(map[string][]int{"p1": {2, -1}, "p2": {-1, -1}})
+	s := exec.NewReplicaScanner(src, src.Partitions())
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, scanIds(s))
+	assert.Equal(t, nil, s.Err())
+	assert.Equal(t, []string{"p1:0", "p1:1", "p2:0"}, src.opened)
+
+	// every replica of p2 fails
+	src = newReplicated(map[string][]int{"p1": {-1}, "p2": {3, 1}})
+	s = exec.NewReplicaScanner(src, src.Partitions())
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7}, scanIds(s))
+	assert.NotEqual(t, nil, s.Err())
+	assert.Equal(t, []string{"p1:0", "p2:0", "p2:1"}, src.opened)
+}
@@ -0,0 +1,114 @@
+package exec
+
+import (
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+// resolveTables resolve the table names of stmt, in order, in
+//
+//    1. the schema named by the statement  FROM schema.table
+//    2. the current schema of the session (USE), ctx.Schema
+//    3. the default schema of the registry
+//
+// Tables found outside of ctx.Schema are added to the (statement only)
+// schema of ctx.  A table in none of them, that other registered schemas
+// have, is an error listing those schemas.  Names no schema has are left
+// for the planner to report.
+func resolveTables(ctx *plan.Context, stmt rel.SqlStatement) error {
+	switch st := stmt.(type) {
+	case *rel.SqlSelect:
+		for _, from := range st.From {
+			if from.SubQuery != nil {
+				if err := resolveTables(ctx, from.SubQuery); err != nil {
+					return err
+				}
+				continue
+			}
+			if from.Name == "" {
+				continue
+			}
+			if err := resolveTable(ctx, from.Schema, from.Name); err != nil {
+				return err
+			}
+		}
+	case *rel.SqlInsert:
+		return resolveTable(ctx, "", st.Table)
+	case *rel.SqlUpsert:
+		return resolveTable(ctx, "", st.Table)
+	case *rel.SqlUpdate:
+		return resolveTable(ctx, "", st.Table)
+	case *rel.SqlDelete:
+		return resolveTable(ctx, "", st.Table)
+	case *rel.SqlCommand:
+		if st.Keyword() != lex.TokenAnalyze {
+			return nil
+		}
+		for _, col := range st.Columns {
+			if err := resolveTable(ctx, "", col.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func resolveTable(ctx *plan.Context, schemaName, name string) error {
+	if left, right, ok := expr.LeftRight(name); ok && schemaName == "" {
+		schemaName, name = left, right
+	}
+	reg := schema.DefaultRegistry()
+	if schemaName != "" {
+		if ctx.Schema != nil && strings.EqualFold(schemaName, ctx.Schema.Name) {
+			return nil
+		}
+		s, ok := reg.Schema(strings.ToLower(schemaName))
+		if !ok || s == nil {
+			// information_schema etc, the planner knows them
+			return nil
+		}
+		return useTable(ctx, s, name)
+	}
+	if ctx.Schema != nil {
+		if tbl, err := ctx.Schema.Table(name); err == nil && tbl != nil {
+			return nil
+		}
+	}
+	if def, ok := reg.DefaultSchema(); ok && def != ctx.Schema {
+		if tbl, err := def.Table(name); err == nil && tbl != nil {
+			return useTable(ctx, def, name)
+		}
+	}
+	candidates := reg.SchemasWithTable(name)
+	switch {
+	case len(candidates) == 0:
+		return nil
+	case ctx.Schema == nil:
+		return sqlerr.New(sqlerr.ErNoDB, "No database selected, table '%s' is in %s", name, strings.Join(candidates, ", "))
+	}
+	return sqlerr.New(sqlerr.ErNoSuchTable, "Table '%s.%s' doesn't exist, it is in %s", ctx.Schema.Name, name, strings.Join(candidates, ", "))
+}
+
+// useTable add table name of schema s to the schema of ctx.
+func useTable(ctx *plan.Context, s *schema.Schema, name string) error {
+	tbl, err := s.Table(name)
+	if err != nil {
+		return err
+	}
+	ss, err := s.SchemaForTable(name)
+	if err != nil {
+		return err
+	}
+	if ctx.Schema == nil {
+		ctx.Schema = schema.NewSchemaTable(s.Name, tbl, ss.DS)
+		return nil
+	}
+	ctx.Schema = ctx.Schema.WithTable(tbl, ss.DS)
+	return nil
+}
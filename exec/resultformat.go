@@ -0,0 +1,134 @@
+package exec
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec/columnar"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// ResultFormatOption is the WITH option to return the results of a SELECT
+// as a single row, of a single column, of columnar encoded bytes for bulk
+// consumers that don't want to read row by row.
+//
+//    SELECT * FROM users WITH result_format="arrow"
+//    SELECT * FROM users WITH result_format="parquet"
+const ResultFormatOption = "result_format"
+
+const (
+	// ResultFormatArrow is the Arrow IPC stream format.
+	ResultFormatArrow = "arrow"
+	// ResultFormatParquet is the Parquet file format.
+	ResultFormatParquet = "parquet"
+)
+
+var (
+	// Ensure our columnar result implements driver.Rows
+	_ driver.Rows = (*columnarRows)(nil)
+)
+
+// ResultFormat the columnar result format of the statement, empty for
+// normal row results.
+func ResultFormat(stmt rel.SqlStatement) (string, error) {
+	sel, ok := stmt.(*rel.SqlSelect)
+	if !ok || sel.With == nil {
+		return "", nil
+	}
+	format := strings.ToLower(sel.With.String(ResultFormatOption))
+	switch format {
+	case "", ResultFormatArrow, ResultFormatParquet:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported %s %q, must be %s or %s", ResultFormatOption,
+		format, ResultFormatArrow, ResultFormatParquet)
+}
+
+// ResultFields the columns of the select, typed from the planned projection.
+func ResultFields(ctx *plan.Context, sel *rel.SqlSelect) []*columnar.Field {
+	names := sel.Columns.AliasedFieldNames()
+	fields := make([]*columnar.Field, len(names))
+	for i, name := range names {
+		fields[i] = &columnar.Field{Name: name, Type: columnar.ArrowUtf8, Nullable: true}
+	}
+	if ctx.Projection == nil || ctx.Projection.Proj == nil {
+		return fields
+	}
+	for _, rc := range ctx.Projection.Proj.Columns {
+		name := rc.As
+		if name == "" {
+			name = rc.Name
+		}
+		for _, f := range fields {
+			if f.Name == name {
+				f.Type = columnar.ArrowTypeOf(rc.Type)
+			}
+		}
+	}
+	return fields
+}
+
+// RunColumnar run the select job, reading all result rows and encoding
+// them in the columnar format (arrow, parquet).
+func RunColumnar(job *JobExecutor, format string) ([]byte, error) {
+	sel, ok := job.Ctx.Stmt.(*rel.SqlSelect)
+	if !ok {
+		return nil, fmt.Errorf("%s requires a select got %T", ResultFormatOption, job.Ctx.Stmt)
+	}
+	fields := ResultFields(job.Ctx, sel)
+
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(NewResultBuffer(job.Ctx, &msgs))
+	if err := job.Setup(); err != nil {
+		return nil, err
+	}
+	if err := job.Run(); err != nil {
+		return nil, err
+	}
+	rows := make([][]driver.Value, 0, len(msgs))
+	for _, msg := range msgs {
+		if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+			rows = append(rows, mm.Values())
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case ResultFormatArrow:
+		err = columnar.WriteArrow(&buf, fields, rows)
+	case ResultFormatParquet:
+		err = columnar.WriteParquet(&buf, fields, rows)
+	default:
+		err = fmt.Errorf("unsupported %s %q", ResultFormatOption, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columnarRows the single row, single column driver.Rows of a columnar
+// encoded result, the column is named for the format.
+type columnarRows struct {
+	format string
+	data   []byte
+	read   bool
+}
+
+func (m *columnarRows) Columns() []string { return []string{m.format} }
+func (m *columnarRows) Close() error      { return nil }
+func (m *columnarRows) Next(dest []driver.Value) error {
+	if m.read {
+		return io.EOF
+	}
+	m.read = true
+	dest[0] = m.data
+	return nil
+}
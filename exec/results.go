@@ -3,6 +3,7 @@ package exec
 import (
 	"database/sql/driver"
 	"io"
+	"sync/atomic"
 
 	u "github.com/araddon/gou"
 
@@ -35,6 +36,7 @@ type (
 		closed       bool
 		err          error
 		rowsAffected int64
+		rowsMatched  int64
 		lastInsertID int64
 	}
 	// ResultWriter for writing tasks results
@@ -42,12 +44,16 @@ type (
 		*TaskBase
 		closed bool
 		cols   []string
+		rowCt  int64
+		budget *budgetCounter
+		spool  *resultSpool
 	}
 	// ResultBuffer for writing tasks results
 	ResultBuffer struct {
 		*TaskBase
 		closed bool
 		cols   []string
+		rowCt  int64
 	}
 )
 
@@ -59,9 +65,14 @@ func NewResultExecWriter(ctx *plan.Context) *ResultExecWriter {
 	m.Handler = func(ctx *plan.Context, msg schema.Message) bool {
 		switch mt := msg.(type) {
 		case *datasource.SqlDriverMessage:
+			// the first value is the error message of a failed mutation
 			if len(mt.Vals) > 1 {
-				m.lastInsertID = mt.Vals[0].(int64)
-				m.rowsAffected = mt.Vals[1].(int64)
+				m.lastInsertID, _ = mt.Vals[0].(int64)
+				m.rowsAffected, _ = mt.Vals[1].(int64)
+				m.rowsMatched = m.rowsAffected
+			}
+			if len(mt.Vals) > 2 {
+				m.rowsMatched, _ = mt.Vals[2].(int64)
 			}
 		case nil:
 			u.Warnf("got nil")
@@ -81,17 +92,23 @@ func NewResultExecWriter(ctx *plan.Context) *ResultExecWriter {
 func NewResultWriter(ctx *plan.Context) *ResultWriter {
 	m := &ResultWriter{
 		TaskBase: NewTaskBase(ctx),
+		budget:   newBudgetCounter(ctx),
 	}
 	m.Handler = resultWrite(m)
 	return m
 }
 
-// NewResultRows a resultwriter
+// NewResultRows a resultwriter, if the session has a result_spool_quota
+// the rows are spooled so slow clients don't hold the sources open.
 func NewResultRows(ctx *plan.Context, cols []string) *ResultWriter {
 	stepper := NewTaskStepper(ctx)
 	m := &ResultWriter{
 		TaskBase: stepper.TaskBase,
 		cols:     cols,
+		budget:   newBudgetCounter(ctx),
+	}
+	if quota := ctx.SpoolQuota(); quota > 0 {
+		m.spool = newResultSpool(cols, quota)
 	}
 	return m
 }
@@ -102,7 +119,12 @@ func NewResultBuffer(ctx *plan.Context, writeTo *[]schema.Message) *ResultBuffer
 		TaskBase: NewTaskBase(ctx),
 	}
 	m.Handler = func(ctx *plan.Context, msg schema.Message) bool {
+		if msg == nil {
+			// nil is the shutdown signal of a LIMIT upstream, not a row
+			return true
+		}
 		*writeTo = append(*writeTo, msg)
+		atomic.AddInt64(&m.rowCt, 1)
 		//u.Infof("write to msgs: %v", len(*writeTo))
 		return true
 	}
@@ -111,9 +133,16 @@ func NewResultBuffer(ctx *plan.Context, writeTo *[]schema.Message) *ResultBuffer
 
 // Result of exec task
 func (m *ResultExecWriter) Result() driver.Result {
-	return &qlbResult{m.lastInsertID, m.rowsAffected, m.err}
+	return &qlbResult{m.lastInsertID, m.rowsAffected, m.rowsMatched, m.err}
 }
 
+// RowCount rows affected.
+func (m *ResultExecWriter) RowCount() int64 { return m.rowsAffected }
+
+// RowsMatched rows the where of an update matched, which may be more than
+// it changed (affected).
+func (m *ResultExecWriter) RowsMatched() int64 { return m.rowsMatched }
+
 // Copy exec task
 func (m *ResultExecWriter) Copy() *ResultExecWriter { return NewResultExecWriter(m.Ctx) }
 
@@ -130,6 +159,9 @@ func (m *ResultExecWriter) Close() error {
 	return m.TaskBase.Close()
 }
 
+// RowCount rows read by Next.
+func (m *ResultWriter) RowCount() int64 { return atomic.LoadInt64(&m.rowCt) }
+
 // Copy result writter
 func (m *ResultWriter) Copy() *ResultWriter { return NewResultWriter(m.Ctx) }
 
@@ -143,9 +175,15 @@ func (m *ResultWriter) Close() error {
 	}
 	m.closed = true
 	m.Unlock()
+	if m.spool != nil {
+		m.spool.close()
+	}
 	return m.TaskBase.Close()
 }
 
+// RowCount rows written to buffer.
+func (m *ResultBuffer) RowCount() int64 { return atomic.LoadInt64(&m.rowCt) }
+
 // Copy the result buffer
 func (m *ResultBuffer) Copy() *ResultBuffer { return NewResultBuffer(m.Ctx, nil) }
 
@@ -164,8 +202,21 @@ func (m *ResultBuffer) Close() error {
 
 // Next his is implementation of the sql/driver Rows() Next() interface
 func (m *ResultWriter) Next(dest []driver.Value) error {
+	if m.spool != nil {
+		if err := m.spool.next(dest); err != nil {
+			if err == ErrShuttingDown && m.budget.timedOut() {
+				return ErrMaxExecutionTime
+			}
+			return err
+		}
+		atomic.AddInt64(&m.rowCt, 1)
+		return m.budget.add(dest)
+	}
 	select {
 	case <-m.SigChan():
+		if m.budget.timedOut() {
+			return ErrMaxExecutionTime
+		}
 		return ErrShuttingDown
 	case err := <-m.ErrChan():
 		return err
@@ -176,7 +227,11 @@ func (m *ResultWriter) Next(dest []driver.Value) error {
 		if msg == nil {
 			return io.EOF
 		}
-		return msgToRow(msg, m.cols, dest)
+		atomic.AddInt64(&m.rowCt, 1)
+		if err := msgToRow(msg, m.cols, dest); err != nil {
+			return err
+		}
+		return m.budget.add(dest)
 	}
 }
 
@@ -188,6 +243,12 @@ func (m *ResultWriter) Run() error {
 	defer func() {
 		close(m.msgOutCh) // closing output channels is the signal to stop
 	}()
+	if m.spool != nil {
+		// the spool reads the error channel
+		go m.spool.run(m)
+		<-m.sigCh
+		return nil
+	}
 	select {
 	case err := <-m.errCh:
 		u.Errorf("got error:  %v", err)
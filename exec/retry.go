@@ -0,0 +1,213 @@
+package exec
+
+import (
+	"fmt"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// Ensure that we implement the scanner interfaces
+	_ schema.ConnScanner = (*RetryScanner)(nil)
+	_ schema.IteratorErr = (*RetryScanner)(nil)
+)
+
+// RetryScanner scans a source conn per the RetryPolicy of its source.  A
+// scan that fails part way (the conn reports an error, or a row takes
+// longer than the policy Timeout) is re-opened and resumed, skipping the
+// rows already read, until the policy's attempts are used up or the
+// error is not retryable.
+type RetryScanner struct {
+	policy  *schema.RetryPolicy
+	open    func() (schema.Conn, error)
+	release func(schema.Conn, error) error
+	conn    schema.ConnScanner
+	results chan retryResult // rows read by the reader of conn if timed out
+	stop    chan struct{}
+	attempt int // attempts used, the first scan is 1
+	read    int // rows read
+	done    bool
+	err     error
+}
+
+type retryResult struct {
+	msg schema.Message
+	err error
+}
+
+// NewRetryScanner create a scanner of conn, re-opened with open and
+// released with release.
+func NewRetryScanner(policy *schema.RetryPolicy, conn schema.ConnScanner,
+	open func() (schema.Conn, error), release func(schema.Conn, error) error) *RetryScanner {
+	return &RetryScanner{policy: policy, open: open, release: release, conn: conn, attempt: 1}
+}
+
+// Next the next message, nil once the scan is complete or has failed, see
+// Err.
+func (m *RetryScanner) Next() schema.Message {
+	for m.err == nil && !m.done {
+		if m.conn == nil && !m.reopen() {
+			continue
+		}
+		msg, err := m.next()
+		if msg != nil {
+			m.read++
+			return msg
+		}
+		if err == nil {
+			m.done = true
+			return nil
+		}
+		m.drop(err)
+		m.retry(err)
+	}
+	return nil
+}
+
+// Err the error that ended the scan.
+func (m *RetryScanner) Err() error { return m.err }
+
+// Close release the conn.
+func (m *RetryScanner) Close() error {
+	m.drop(nil)
+	return nil
+}
+
+// next message of conn, timed out if the policy has a Timeout.  Timed
+// scans read the conn from a reader go routine so a hung conn can be
+// abandoned.
+func (m *RetryScanner) next() (schema.Message, error) {
+	if m.policy.Timeout <= 0 {
+		if msg := m.conn.Next(); msg != nil {
+			return msg, nil
+		}
+		return nil, iterErr(m.conn)
+	}
+	if m.results == nil {
+		m.results = make(chan retryResult)
+		m.stop = make(chan struct{})
+		go m.reader(m.conn, m.results, m.stop)
+	}
+	timer := time.NewTimer(m.policy.Timeout)
+	defer timer.Stop()
+	select {
+	case r, ok := <-m.results:
+		if !ok {
+			return nil, nil
+		}
+		return r.msg, r.err
+	case <-timer.C:
+		return nil, schema.ErrSourceTimeout
+	}
+}
+
+func (m *RetryScanner) reader(conn schema.ConnScanner, results chan retryResult, stop chan struct{}) {
+	defer close(results)
+	for {
+		r := retryResult{msg: conn.Next()}
+		if r.msg == nil {
+			r.err = iterErr(conn)
+		}
+		select {
+		case results <- r:
+		case <-stop:
+			return
+		}
+		if r.msg == nil {
+			return
+		}
+	}
+}
+
+// drop the conn, released with the error that ended its scan.  A conn
+// still being read by its reader is released once the reader is done.
+func (m *RetryScanner) drop(err error) {
+	if m.conn == nil {
+		return
+	}
+	conn := m.conn
+	m.conn = nil
+	if m.results == nil {
+		m.releaseConn(conn, err)
+		return
+	}
+	results := m.results
+	close(m.stop)
+	m.results, m.stop = nil, nil
+	go func() {
+		for range results {
+		}
+		m.releaseConn(conn, err)
+	}()
+}
+
+func (m *RetryScanner) releaseConn(conn schema.ConnScanner, err error) {
+	if rerr := m.release(conn, err); rerr != nil {
+		u.Warnf("error releasing conn %v", rerr)
+	}
+}
+
+// retry the scan after err, or fail it.
+func (m *RetryScanner) retry(err error) {
+	if !m.policy.IsRetryable(err) {
+		m.err = err
+		return
+	}
+	if m.attempt >= m.policy.Attempts() {
+		m.err = fmt.Errorf("QLBridge.exec: source scan failed after %d attempts: %v", m.attempt, err)
+		return
+	}
+	u.Warnf("source scan attempt %d/%d failed after %d rows: %v", m.attempt, m.policy.Attempts(), m.read, err)
+	time.Sleep(m.policy.Wait(m.attempt))
+	m.attempt++
+}
+
+// reopen the conn, skipping the rows already read.
+func (m *RetryScanner) reopen() bool {
+	conn, err := m.open()
+	if err == nil && conn == nil {
+		err = fmt.Errorf("QLBridge.exec: could not re-open source conn")
+	}
+	if err != nil {
+		// open is already retried per the policy
+		m.err = err
+		return false
+	}
+	scanner, ok := conn.(schema.ConnScanner)
+	if !ok {
+		m.release(conn, nil)
+		m.err = fmt.Errorf("QLBridge.exec: conn %T must implement Scanner", conn)
+		return false
+	}
+	m.conn = scanner
+	for i := 0; i < m.read; i++ {
+		if msg, err := m.next(); msg == nil {
+			if err == nil {
+				err = fmt.Errorf("source has %d rows, %d already read", i, m.read)
+			}
+			m.drop(err)
+			m.retry(err)
+			return false
+		}
+	}
+	return true
+}
+
+// writeRetry the retry policy of the source of table for writes, nil if
+// it has none.  Writes that time out may still complete, so they are run
+// with DoWrite, retrying a timed out write only if it is idempotent, ie a
+// Put by key, or the source opts in with retry_writes.
+func writeRetry(ctx *plan.Context, table string) *schema.RetryPolicy {
+	if ctx == nil || ctx.Schema == nil {
+		return nil
+	}
+	ss, err := ctx.Schema.SchemaForTable(table)
+	if err != nil || ss == nil {
+		return nil
+	}
+	return ss.Retry
+}
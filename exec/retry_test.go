@@ -0,0 +1,117 @@
+package exec_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// resetConn scans rows, failing after failAt rows of its first scan.
+type resetConn struct {
+	rows   []schema.Message
+	pos    int
+	failAt int
+	err    error
+	closed bool
+}
+
+func (m *resetConn) Close() error { m.closed = true; return nil }
+func (m *resetConn) Err() error   { return m.err }
+func (m *resetConn) Next() schema.Message {
+	if m.failAt > 0 && m.pos == m.failAt {
+		m.err = fmt.Errorf("connection reset")
+		return nil
+	}
+	if m.pos >= len(m.rows) {
+		return nil
+	}
+	m.pos++
+	return m.rows[m.pos-1]
+}
+
+func TestRetryScanner(t *testing.T) {
+	rows := make([]schema.Message, 5)
+	for i := range rows {
+		rows[i] = datasource.NewSqlDriverMessageMapVals(uint64(i), nil, nil)
+	}
+	first := &resetConn{rows: rows, failAt: 2}
+	var opened []*resetConn
+	open := func() (schema.Conn, error) {
+		c := &resetConn{rows: rows}
+		opened = append(opened, c)
+		return c, nil
+	}
+	var released []error
+	release := func(c schema.Conn, err error) error {
+		released = append(released, err)
+		return c.Close()
+	}
+	policy := &schema.RetryPolicy{MaxAttempts: 2}
+	rs := exec.NewRetryScanner(policy, first, open, release)
+	ids := make([]uint64, 0)
+	for msg := rs.Next(); msg != nil; msg = rs.Next() {
+		ids = append(ids, msg.Id())
+	}
+	assert.Equal(t, nil, rs.Err())
+	// resumed after the rows already read
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, ids)
+	assert.Equal(t, 1, len(opened))
+	assert.True(t, first.closed)
+	rs.Close()
+	assert.True(t, opened[0].closed)
+	assert.Equal(t, 2, len(released))
+	assert.NotEqual(t, nil, released[0])
+	assert.Equal(t, nil, released[1])
+
+	// attempts used up
+	open = func() (schema.Conn, error) {
+		return &resetConn{rows: rows, failAt: 3}, nil
+	}
+	rs = exec.NewRetryScanner(policy, &resetConn{rows: rows, failAt: 2}, open, release)
+	ct := 0
+	for msg := rs.Next(); msg != nil; msg = rs.Next() {
+		ct++
+	}
+	assert.Equal(t, 3, ct)
+	assert.True(t, strings.Contains(fmt.Sprint(rs.Err()), "after 2 attempts"), rs.Err())
+}
+
+func TestSourceRetry(t *testing.T) {
+	td.LoadTestDataOnce()
+	mockcsv.LoadTable(mockcsv.SchemaName, "flaky", "id,name\n1,a\n2,b\n3,c")
+	defer mockcsv.SetFaults("flaky", nil)
+	ss, err := td.MockSchema.SchemaForTable("flaky")
+	assert.Equal(t, nil, err)
+	ss.Retry = &schema.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	defer func() { ss.Retry = nil }()
+
+	// failed opens are retried
+	mockcsv.SetFaults("flaky", &mockcsv.Faults{FailOpens: 2})
+	rows, err := runSession(t, datasource.NewContextSimple(), `SELECT id FROM flaky`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, 2, mockcsv.GetFaultStats("flaky").FailedOpens)
+
+	// a scan failing every attempt fails the query
+	mockcsv.SetFaults("flaky", &mockcsv.Faults{ErrAtRow: 2})
+	_, err = runSession(t, datasource.NewContextSimple(), `SELECT id FROM flaky`)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 3, mockcsv.GetFaultStats("flaky").Errors)
+
+	// a hung backend is timed out
+	ss.Retry = &schema.RetryPolicy{MaxAttempts: 1, Timeout: 10 * time.Millisecond}
+	mockcsv.SetFaults("flaky", &mockcsv.Faults{RowLatency: time.Second})
+	start := time.Now()
+	_, err = runSession(t, datasource.NewContextSimple(), `SELECT id FROM flaky`)
+	assert.True(t, strings.Contains(fmt.Sprint(err), schema.ErrSourceTimeout.Error()), err)
+	assert.True(t, time.Since(start) < time.Second)
+}
@@ -0,0 +1,184 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// SampleRefreshInterval how long a materialized sample table is used
+	// before it is re-sampled from its source table on next read.
+	SampleRefreshInterval = time.Minute * 10
+
+	// Ensure our SampleSource implements schema.Source
+	_ schema.Source           = (*SampleSource)(nil)
+	_ schema.SourceSampler    = (*SampleSource)(nil)
+	_ schema.SourceRowCounter = (*SampleSource)(nil)
+	_ schema.ConnScanner      = (*rowsConn)(nil)
+	_ schema.ConnColumns      = (*rowsConn)(nil)
+)
+
+// SampleSource is a sample table created by
+//
+//    CREATE SAMPLE TABLE s AS SELECT * FROM big_t TABLESAMPLE (0.1 PERCENT)
+//
+// it is maintained lazily, the sample query is run on the first read and
+// again on the first read after SampleRefreshInterval, in between reads
+// share the materialized rows.
+type SampleSource struct {
+	mu        sync.Mutex
+	s         *schema.Schema // schema the sample query runs against
+	tbl       *schema.Table
+	stmt      *rel.SqlSelect
+	of        string  // sampled table
+	percent   float64 // sample percent
+	rows      [][]driver.Value
+	refreshed time.Time
+}
+
+// NewSampleSource create a sample table name defined by the select stmt
+// of a single TABLESAMPLE source in schema s.  The columns are planned
+// but no rows are read until the table is used.
+func NewSampleSource(s *schema.Schema, name string, stmt *rel.SqlSelect) (*SampleSource, error) {
+	if s == nil {
+		return nil, fmt.Errorf("must have schema")
+	}
+	if stmt == nil || len(stmt.From) != 1 || stmt.From[0].SubQuery != nil || stmt.From[0].Sample == nil {
+		return nil, fmt.Errorf("CREATE SAMPLE TABLE requires SELECT from a single table with TABLESAMPLE")
+	}
+	if stmt.From[0].Sample.Rows > 0 {
+		return nil, fmt.Errorf("CREATE SAMPLE TABLE requires a TABLESAMPLE (<n> PERCENT) sample")
+	}
+	ctx := plan.NewContext(stmt.String())
+	ctx.Schema = s
+	ctx.Stmt = stmt
+	if _, err := plan.WalkStmt(ctx, stmt, plan.NewPlanner(ctx)); err != nil {
+		return nil, err
+	}
+	if ctx.Projection == nil || ctx.Projection.Proj == nil {
+		return nil, fmt.Errorf("could not plan columns of sample table %q", name)
+	}
+	tbl := schema.NewTable(name)
+	for _, rc := range ctx.Projection.Proj.Columns {
+		col := rc.As
+		if col == "" {
+			col = rc.Name
+		}
+		tbl.AddFieldType(col, rc.Type)
+	}
+	tbl.SetColumnsFromFields()
+	return &SampleSource{
+		s:       s,
+		tbl:     tbl,
+		stmt:    stmt,
+		of:      stmt.From[0].SourceName(),
+		percent: stmt.From[0].Sample.Percent,
+	}, nil
+}
+
+// Init the sample source.
+func (m *SampleSource) Init() {}
+
+// Setup the sample source.
+func (m *SampleSource) Setup(*schema.Schema) error { return nil }
+
+// Close the sample source.
+func (m *SampleSource) Close() error { return nil }
+
+// Tables list, the single sample table.
+func (m *SampleSource) Tables() []string { return []string{m.tbl.Name} }
+
+// Table the sample table schema.
+func (m *SampleSource) Table(table string) (*schema.Table, error) { return m.tbl, nil }
+
+// SampleOf the sampled table and percent.
+func (m *SampleSource) SampleOf() (string, float64) { return m.of, m.percent }
+
+// RowCount of the materialized sample, false if not yet materialized.
+func (m *SampleSource) RowCount(string) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.refreshed.IsZero() {
+		return 0, false
+	}
+	return int64(len(m.rows)), true
+}
+
+// Open a scanner of the sample rows, materializing the sample first if
+// it has not been or is stale.
+func (m *SampleSource) Open(table string) (schema.Conn, error) {
+	rows, err := m.sampleRows()
+	if err != nil {
+		return nil, err
+	}
+	return &rowsConn{cols: m.tbl.Columns(), colIdx: m.tbl.FieldNamesPositions(), rows: rows}, nil
+}
+
+func (m *SampleSource) sampleRows() ([][]driver.Value, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.refreshed.IsZero() && time.Since(m.refreshed) < SampleRefreshInterval {
+		return m.rows, nil
+	}
+	rows, err := m.materialize()
+	if err != nil {
+		return nil, err
+	}
+	m.rows = rows
+	m.refreshed = time.Now()
+	return rows, nil
+}
+
+// materialize run the sample query, reading its rows.
+func (m *SampleSource) materialize() ([][]driver.Value, error) {
+	ctx := plan.NewContext(m.stmt.String())
+	ctx.Schema = m.s
+	job, err := BuildSqlJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer job.Close()
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(NewResultBuffer(ctx, &msgs))
+	if err = job.Setup(); err != nil {
+		return nil, err
+	}
+	if err = job.Run(); err != nil {
+		return nil, err
+	}
+	rows := make([][]driver.Value, 0, len(msgs))
+	for _, msg := range msgs {
+		if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+			rows = append(rows, mm.Values())
+		}
+	}
+	u.Debugf("materialized sample %q of %q rows=%d", m.tbl.Name, m.of, len(rows))
+	return rows, nil
+}
+
+// rowsConn scanner over a snapshot of rows.
+type rowsConn struct {
+	cols   []string
+	colIdx map[string]int
+	rows   [][]driver.Value
+	cursor int
+}
+
+func (m *rowsConn) Columns() []string { return m.cols }
+func (m *rowsConn) Close() error      { return nil }
+func (m *rowsConn) Next() schema.Message {
+	if m.cursor >= len(m.rows) {
+		return nil
+	}
+	m.cursor++
+	return datasource.NewSqlDriverMessageMap(uint64(m.cursor), m.rows[m.cursor-1], m.colIdx)
+}
@@ -0,0 +1,80 @@
+package exec
+
+import (
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*Gather)(nil)
+)
+
+// Gather the results of the shards of a scatter into one stream, in the
+// order they arrive.  Shards each number their rows from 0, so gathered
+// rows are re-numbered.
+//
+//   shard0  \
+//   shard1  -- gather -->
+//   shardn  /
+//
+type Gather struct {
+	*TaskBase
+	shards []TaskRunner
+}
+
+// NewGather create a gather of the output of shards.
+func NewGather(ctx *plan.Context, shards []TaskRunner) *Gather {
+	return &Gather{
+		TaskBase: NewTaskBase(ctx),
+		shards:   shards,
+	}
+}
+
+// Run the gather, until every shard has closed its output.
+func (m *Gather) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	outCh := m.MessageOut()
+
+	var (
+		mu sync.Mutex
+		id uint64
+		wg sync.WaitGroup
+	)
+	for _, shard := range m.shards {
+		wg.Add(1)
+		go func(in MessageChan) {
+			defer wg.Done()
+			for {
+				select {
+				case <-m.SigChan():
+					return
+				case msg, ok := <-in:
+					if !ok {
+						return
+					}
+					if mm, isMap := msg.(*datasource.SqlDriverMessageMap); isMap {
+						mu.Lock()
+						mm.IdVal = id
+						id++
+						mu.Unlock()
+					}
+					select {
+					case outCh <- msg:
+					case <-m.SigChan():
+						return
+					}
+				}
+			}
+		}(shard.MessageOut())
+	}
+	wg.Wait()
+	u.Debugf("gathered %d rows from %d shards", id, len(m.shards))
+	return nil
+}
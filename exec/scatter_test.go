@@ -0,0 +1,110 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var _ schema.SourceSharded = (*shardedSource)(nil)
+
+// shardedSource a source whose nodes each hold a shard of its one table,
+// as an in-memory db per node.
+type shardedSource struct {
+	*memdb.MemDb
+	shards map[string]*memdb.MemDb
+}
+
+func (m *shardedSource) ShardSource(node *schema.ConfigNode, table string) (schema.Conn, error) {
+	db, ok := m.shards[node.Name]
+	if !ok {
+		return nil, fmt.Errorf("no shard %q", node.Name)
+	}
+	return db.Open(table)
+}
+
+func TestScatterGather(t *testing.T) {
+	cols := []string{"id", "region", "amount"}
+	shardRows := map[string][][]driver.Value{
+		"a": {{1, "east", 10}, {2, "west", 5}},
+		"b": {{3, "east", 20}, {4, "north", 7}},
+		"c": {{5, "west", 15}},
+	}
+	src := &shardedSource{shards: make(map[string]*memdb.MemDb)}
+	conf := &schema.ConfigSource{Name: "shards"}
+	for _, name := range []string{"a", "b", "c"} {
+		db, err := memdb.NewMemDbData("shard_orders", shardRows[name], cols)
+		assert.Equal(t, nil, err)
+		if src.MemDb == nil {
+			src.MemDb = db
+		}
+		src.shards[name] = db
+		conf.Nodes = append(conf.Nodes, &schema.ConfigNode{Name: name, Source: "shards"})
+	}
+	tbl, _ := src.Table("shard_orders")
+
+	newCtx := func(sql string) *plan.Context {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, src)
+		ss, err := ctx.Schema.SchemaForTable("shard_orders")
+		assert.Equal(t, nil, err)
+		ss.Conf = conf
+		return ctx
+	}
+	run := func(sql string) []string {
+		ctx := newCtx(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		rows := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, fmt.Sprint(msg.(*datasource.SqlDriverMessageMap).Values()))
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	sql := `SELECT region, count(*) AS ct, sum(amount) AS total FROM shard_orders GROUP BY region`
+	ctx := newCtx(sql)
+	stmt, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	p, err := plan.WalkStmt(ctx, stmt, plan.NewPlanner(ctx))
+	assert.Equal(t, nil, err)
+	var scatter *plan.Scatter
+	for _, task := range p.Children() {
+		if sc, ok := task.(*plan.Scatter); ok {
+			scatter = sc
+		}
+	}
+	if assert.NotEqual(t, nil, scatter) {
+		assert.Equal(t, 3, len(scatter.Shards))
+		assert.Equal(t, "b", scatter.Shards[1].Shard.Name)
+	}
+
+	// rows of every shard are gathered
+	assert.Equal(t, []string{"[1]", "[2]", "[3]", "[4]", "[5]"}, run(`SELECT id FROM shard_orders`))
+	assert.Equal(t, []string{"[1]", "[3]", "[5]"}, run(`SELECT id FROM shard_orders WHERE amount > 8`))
+
+	// partial aggregates of the shards are re-aggregated
+	assert.Equal(t, []string{"[east 2 30]", "[north 1 7]", "[west 2 20]"}, run(sql))
+	assert.Equal(t, []string{"[east 15]", "[north 7]", "[west 10]"},
+		run(`SELECT region, avg(amount) AS av FROM shard_orders GROUP BY region`))
+	assert.Equal(t, []string{"[5 57]"}, run(`SELECT count(*) AS ct, sum(amount) AS total FROM shard_orders`))
+	assert.Equal(t, []string{"[east 30]"},
+		run(`SELECT region, sum(amount) AS total FROM shard_orders GROUP BY region HAVING total > 25`))
+}
@@ -0,0 +1,403 @@
+package exec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// HllPrecision the default precision (number of index bits) of the
+	// HyperLogLog sketch behind approx_count_distinct, 2^14 registers
+	// for a standard error of about 0.8%.
+	HllPrecision = 14
+	// TDigestCompression the default compression of the t-digest behind
+	// approx_quantile, larger is more accurate and more centroids.
+	TDigestCompression = 100
+	// SpaceSavingFactor counters kept per requested item by the
+	// space-saving sketch behind approx_top_k.
+	SpaceSavingFactor = 4
+)
+
+var errSketchData = fmt.Errorf("invalid sketch data")
+
+// sketchHash 64 bit hash of v, fnv with a murmur3 finalizer so the high
+// bits (hll register index) are well mixed.
+func sketchHash(v string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(v))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// HyperLogLog cardinality (count distinct) sketch, mergeable so partial
+// sketches from sub-tasks or nodes may be combined.
+type HyperLogLog struct {
+	p    uint8
+	regs []uint8
+}
+
+// NewHyperLogLog new sketch with precision p (4-18) index bits.
+func NewHyperLogLog(p uint8) *HyperLogLog {
+	if p < 4 {
+		p = 4
+	} else if p > 18 {
+		p = 18
+	}
+	return &HyperLogLog{p: p, regs: make([]uint8, 1<<p)}
+}
+
+// Add a value to the sketch.
+func (m *HyperLogLog) Add(v string) {
+	h := sketchHash(v)
+	idx := h >> (64 - m.p)
+	// guard bit so the rank is bounded by 64-p+1
+	w := h<<m.p | 1<<(m.p-1)
+	rank := uint8(bits.LeadingZeros64(w)) + 1
+	if rank > m.regs[idx] {
+		m.regs[idx] = rank
+	}
+}
+
+// Merge other sketch of the same precision into this one.
+func (m *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other.p != m.p {
+		return fmt.Errorf("cannot merge hyperloglog of precision %d into %d", other.p, m.p)
+	}
+	for i, r := range other.regs {
+		if r > m.regs[i] {
+			m.regs[i] = r
+		}
+	}
+	return nil
+}
+
+// Count estimated number of distinct values added.
+func (m *HyperLogLog) Count() int64 {
+	n := float64(len(m.regs))
+	sum := 0.0
+	zeros := 0
+	for _, r := range m.regs {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch m.p {
+	case 4:
+		alpha = 0.673
+	case 5:
+		alpha = 0.697
+	case 6:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/n)
+	}
+	est := alpha * n * n / sum
+	if est <= 2.5*n && zeros > 0 {
+		// small range correction, linear counting
+		est = n * math.Log(n/float64(zeros))
+	}
+	return int64(est + 0.5)
+}
+
+// MarshalBinary precision followed by the registers.
+func (m *HyperLogLog) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 1+len(m.regs))
+	buf[0] = m.p
+	copy(buf[1:], m.regs)
+	return buf, nil
+}
+
+// UnmarshalBinary read sketch written by MarshalBinary.
+func (m *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 || data[0] < 4 || data[0] > 18 || len(data) != 1+(1<<data[0]) {
+		return errSketchData
+	}
+	m.p = data[0]
+	m.regs = append([]uint8(nil), data[1:]...)
+	return nil
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest quantile sketch (merging t-digest), accurate at the tails and
+// mergeable so partial digests may be combined.
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buf         []centroid
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest new digest of given compression.
+func NewTDigest(compression float64) *TDigest {
+	if compression < 10 {
+		compression = 10
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Add a value to the digest.
+func (m *TDigest) Add(x float64) {
+	m.add(x, 1)
+}
+
+func (m *TDigest) add(x, w float64) {
+	if math.IsNaN(x) || w <= 0 {
+		return
+	}
+	m.buf = append(m.buf, centroid{x, w})
+	m.count += w
+	if x < m.min {
+		m.min = x
+	}
+	if x > m.max {
+		m.max = x
+	}
+	if len(m.buf) > int(m.compression)*5 {
+		m.compress()
+	}
+}
+
+// Merge other digest into this one.
+func (m *TDigest) Merge(other *TDigest) {
+	other.compress()
+	for _, c := range other.centroids {
+		m.add(c.mean, c.weight)
+	}
+	if other.min < m.min {
+		m.min = other.min
+	}
+	if other.max > m.max {
+		m.max = other.max
+	}
+}
+
+// Count total weight of values added.
+func (m *TDigest) Count() float64 {
+	return m.count
+}
+
+// k1 scale function, centroids near the tails are kept small.
+func (m *TDigest) scale(q float64) float64 {
+	return m.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (m *TDigest) compress() {
+	if len(m.buf) == 0 {
+		return
+	}
+	all := append(m.centroids, m.buf...)
+	m.buf = m.buf[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	out := make([]centroid, 0, len(all))
+	out = append(out, all[0])
+	sofar := 0.0
+	kLeft := m.scale(0)
+	for _, c := range all[1:] {
+		last := &out[len(out)-1]
+		q := (sofar + last.weight + c.weight) / m.count
+		if m.scale(q)-kLeft <= 1 {
+			last.weight += c.weight
+			last.mean += (c.mean - last.mean) * c.weight / last.weight
+			continue
+		}
+		sofar += last.weight
+		kLeft = m.scale(sofar / m.count)
+		out = append(out, c)
+	}
+	m.centroids = out
+}
+
+// Quantile estimated value at quantile q (0-1), NaN if empty.
+func (m *TDigest) Quantile(q float64) float64 {
+	m.compress()
+	cs := m.centroids
+	switch {
+	case len(cs) == 0 || q < 0 || q > 1:
+		return math.NaN()
+	case q == 0:
+		return m.min
+	case q == 1:
+		return m.max
+	case len(cs) == 1:
+		return cs[0].mean
+	}
+	target := q * m.count
+	if target < cs[0].weight/2 {
+		return m.min + (cs[0].mean-m.min)*target/(cs[0].weight/2)
+	}
+	cum := 0.0
+	for i := 0; i < len(cs)-1; i++ {
+		left := cum + cs[i].weight/2
+		right := cum + cs[i].weight + cs[i+1].weight/2
+		if target <= right {
+			return cs[i].mean + (cs[i+1].mean-cs[i].mean)*(target-left)/(right-left)
+		}
+		cum += cs[i].weight
+	}
+	last := cs[len(cs)-1]
+	left := m.count - last.weight/2
+	return last.mean + (m.max-last.mean)*(target-left)/(m.count-left)
+}
+
+// MarshalBinary compression, min, max then the (mean, weight) centroids.
+func (m *TDigest) MarshalBinary() ([]byte, error) {
+	m.compress()
+	buf := make([]byte, 0, 8*(3+2*len(m.centroids)))
+	for _, f := range []float64{m.compression, m.min, m.max} {
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(f))
+	}
+	for _, c := range m.centroids {
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(c.mean))
+		buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(c.weight))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary read digest written by MarshalBinary.
+func (m *TDigest) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 || len(data)%16 != 8 {
+		return errSketchData
+	}
+	f := func(i int) float64 { return math.Float64frombits(binary.BigEndian.Uint64(data[i*8:])) }
+	*m = TDigest{compression: f(0), min: f(1), max: f(2)}
+	for i := 3; i*8 < len(data); i += 2 {
+		c := centroid{f(i), f(i + 1)}
+		m.centroids = append(m.centroids, c)
+		m.count += c.weight
+	}
+	return nil
+}
+
+// SpaceSavingItem a value and its estimated count, the count may over
+// estimate by up to Err.
+type SpaceSavingItem struct {
+	Value string
+	Count int64
+	Err   int64
+}
+
+// SpaceSaving heavy hitters (top-k) sketch keeping a bounded number of
+// counters, the least frequent counter is replaced by new values.
+type SpaceSaving struct {
+	capacity int
+	counters map[string]*SpaceSavingItem
+}
+
+// NewSpaceSaving new sketch with capacity counters.
+func NewSpaceSaving(capacity int) *SpaceSaving {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SpaceSaving{capacity: capacity, counters: make(map[string]*SpaceSavingItem, capacity)}
+}
+
+// Add one occurrence of v.
+func (m *SpaceSaving) Add(v string) {
+	m.add(v, 1, 0)
+}
+
+func (m *SpaceSaving) add(v string, ct, errCt int64) {
+	if c, ok := m.counters[v]; ok {
+		c.Count += ct
+		c.Err += errCt
+		return
+	}
+	if len(m.counters) < m.capacity {
+		m.counters[v] = &SpaceSavingItem{Value: v, Count: ct, Err: errCt}
+		return
+	}
+	var min *SpaceSavingItem
+	for _, c := range m.counters {
+		if min == nil || c.Count < min.Count || (c.Count == min.Count && c.Value > min.Value) {
+			min = c
+		}
+	}
+	delete(m.counters, min.Value)
+	m.counters[v] = &SpaceSavingItem{Value: v, Count: min.Count + ct, Err: min.Count + errCt}
+}
+
+// Merge other sketch into this one.
+func (m *SpaceSaving) Merge(other *SpaceSaving) {
+	for _, c := range other.sorted() {
+		m.add(c.Value, c.Count, c.Err)
+	}
+}
+
+func (m *SpaceSaving) sorted() []SpaceSavingItem {
+	items := make([]SpaceSavingItem, 0, len(m.counters))
+	for _, c := range m.counters {
+		items = append(items, *c)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count == items[j].Count {
+			return items[i].Value < items[j].Value
+		}
+		return items[i].Count > items[j].Count
+	})
+	return items
+}
+
+// TopK the k most frequent values, most frequent first.
+func (m *SpaceSaving) TopK(k int) []SpaceSavingItem {
+	items := m.sorted()
+	if k < len(items) {
+		items = items[:k]
+	}
+	return items
+}
+
+// MarshalBinary capacity then (value, count, err) of each counter.
+func (m *SpaceSaving) MarshalBinary() ([]byte, error) {
+	buf := binary.AppendUvarint(nil, uint64(m.capacity))
+	for _, c := range m.sorted() {
+		buf = binary.AppendUvarint(buf, uint64(len(c.Value)))
+		buf = append(buf, c.Value...)
+		buf = binary.AppendVarint(buf, c.Count)
+		buf = binary.AppendVarint(buf, c.Err)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary read sketch written by MarshalBinary.
+func (m *SpaceSaving) UnmarshalBinary(data []byte) error {
+	capacity, n := binary.Uvarint(data)
+	if n <= 0 || capacity == 0 {
+		return errSketchData
+	}
+	*m = *NewSpaceSaving(int(capacity))
+	data = data[n:]
+	for len(data) > 0 {
+		l, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < l {
+			return errSketchData
+		}
+		c := &SpaceSavingItem{Value: string(data[n : n+int(l)])}
+		data = data[n+int(l):]
+		if c.Count, n = binary.Varint(data); n <= 0 {
+			return errSketchData
+		}
+		data = data[n:]
+		if c.Err, n = binary.Varint(data); n <= 0 {
+			return errSketchData
+		}
+		data = data[n:]
+		m.counters[c.Value] = c
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package exec_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	a := exec.NewHyperLogLog(exec.HllPrecision)
+	b := exec.NewHyperLogLog(exec.HllPrecision)
+	for i := 0; i < 50000; i++ {
+		a.Add(fmt.Sprintf("user-%d", i))
+		// b overlaps a by half
+		b.Add(fmt.Sprintf("user-%d", i+25000))
+	}
+	assert.InEpsilon(t, 50000, float64(a.Count()), 0.03)
+
+	data, err := b.MarshalBinary()
+	assert.Equal(t, nil, err)
+	b2 := &exec.HyperLogLog{}
+	assert.Equal(t, nil, b2.UnmarshalBinary(data))
+	assert.Equal(t, b.Count(), b2.Count())
+	assert.Equal(t, nil, a.Merge(b2))
+	assert.InEpsilon(t, 75000, float64(a.Count()), 0.03)
+
+	assert.NotEqual(t, nil, a.Merge(exec.NewHyperLogLog(10)))
+	assert.NotEqual(t, nil, b2.UnmarshalBinary([]byte{14, 1}))
+
+	small := exec.NewHyperLogLog(exec.HllPrecision)
+	for _, v := range []string{"a", "b", "c", "a"} {
+		small.Add(v)
+	}
+	assert.Equal(t, int64(3), small.Count())
+}
+
+func TestTDigest(t *testing.T) {
+	a := exec.NewTDigest(exec.TDigestCompression)
+	b := exec.NewTDigest(exec.TDigestCompression)
+	assert.True(t, math.IsNaN(a.Quantile(0.5)))
+	for i := 1; i <= 10000; i++ {
+		if i%2 == 0 {
+			a.Add(float64(i))
+		} else {
+			b.Add(float64(i))
+		}
+	}
+	data, err := b.MarshalBinary()
+	assert.Equal(t, nil, err)
+	b2 := &exec.TDigest{}
+	assert.Equal(t, nil, b2.UnmarshalBinary(data))
+	a.Merge(b2)
+	assert.Equal(t, float64(10000), a.Count())
+	assert.Equal(t, float64(1), a.Quantile(0))
+	assert.Equal(t, float64(10000), a.Quantile(1))
+	assert.InDelta(t, 5000, a.Quantile(0.5), 100)
+	assert.InDelta(t, 9900, a.Quantile(0.99), 20)
+	assert.InDelta(t, 100, a.Quantile(0.01), 20)
+
+	assert.NotEqual(t, nil, b2.UnmarshalBinary([]byte{1, 2, 3}))
+}
+
+func TestSpaceSaving(t *testing.T) {
+	a := exec.NewSpaceSaving(8)
+	b := exec.NewSpaceSaving(8)
+	for i := 0; i < 1000; i++ {
+		// heavy hitters hot-0 > hot-1 > hot-2, a long tail of singletons
+		a.Add(fmt.Sprintf("hot-%d", i%6/3+i%6/5))
+		a.Add(fmt.Sprintf("tail-%d", i))
+		b.Add("hot-0")
+	}
+	data, err := b.MarshalBinary()
+	assert.Equal(t, nil, err)
+	b2 := &exec.SpaceSaving{}
+	assert.Equal(t, nil, b2.UnmarshalBinary(data))
+	a.Merge(b2)
+	top := a.TopK(3)
+	assert.Equal(t, 3, len(top))
+	assert.Equal(t, "hot-0", top[0].Value)
+	assert.Equal(t, "hot-1", top[1].Value)
+	assert.True(t, top[0].Count >= 1500, "%v", top)
+	assert.NotEqual(t, nil, b2.UnmarshalBinary([]byte{}))
+}
+
+func TestApproxAggPartials(t *testing.T) {
+	sel, err := rel.ParseSqlSelect(`SELECT approx_count_distinct(user_id), approx_quantile(price, 0.5), approx_top_k(user_id, 2) FROM orders`)
+	assert.Equal(t, nil, err)
+	cols := sel.Columns
+
+	// two partial (sub-task) aggregations merged by a final one
+	final := []exec.Aggregator{exec.NewApproxCountDistinct(cols[0], false)}
+	partials := [][]exec.Aggregator{{exec.NewApproxCountDistinct(cols[0], true)}, {exec.NewApproxCountDistinct(cols[0], true)}}
+	for _, newAgg := range []func(*rel.Column, bool) (exec.Aggregator, error){exec.NewApproxQuantile, exec.NewApproxTopK} {
+		for i := range partials {
+			agg, err := newAgg(cols[len(final)], true)
+			assert.Equal(t, nil, err)
+			partials[i] = append(partials[i], agg)
+		}
+		agg, err := newAgg(cols[len(final)], false)
+		assert.Equal(t, nil, err)
+		final = append(final, agg)
+	}
+	rows := [][]value.Value{
+		{value.NewStringValue("a"), value.NewNumberValue(10), value.NewStringValue("a")},
+		{value.NewStringValue("b"), value.NewNumberValue(20), value.NewStringValue("b")},
+		{value.NewStringValue("a"), value.NewNumberValue(30), value.NewStringValue("a")},
+		{value.NewStringValue("c"), value.NewNumberValue(40), value.NewStringValue("c")},
+		{value.NewNilValue(), value.NewNilValue(), value.NewNilValue()},
+	}
+	for i, row := range rows {
+		for c, v := range row {
+			partials[i%2][c].Do(v)
+		}
+	}
+	for _, aggs := range partials {
+		for c, agg := range aggs {
+			ap, ok := agg.Result().(*exec.AggPartial)
+			assert.True(t, ok)
+			final[c].Merge(ap)
+		}
+	}
+	assert.Equal(t, int64(3), final[0].Result())
+	assert.InDelta(t, 25, final[1].Result(), 0.001)
+	assert.Equal(t, []string{"a", "b"}, final[2].Result())
+
+	bad := &rel.Column{Expr: &expr.FuncNode{Name: "approx_top_k", Args: []expr.Node{expr.NewIdentityNodeVal("x")}}}
+	_, err = exec.NewApproxTopK(bad, false)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestAggFilter(t *testing.T) {
+	rows, err := runSession(t, nil, `SELECT count(*) FILTER (WHERE price > 30) AS big, count_if(price < 30) AS small, sum_if(price, item_id = 1) AS s, avg(price) FILTER (WHERE item_id = 1) AS a1, avg_if(price, item_id = 2) AS a2 FROM orders`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{int64(1), int64(2), 45.0, 22.5, 37.5}}, rows)
+
+	rows, err = runSession(t, nil, `SELECT user_id, count(*), count(*) FILTER (WHERE item_id = 2) AS twos FROM orders GROUP BY user_id ORDER BY user_id ASC`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{
+		{"9Ip1aKbeZe2njCDM", int64(2), int64(1)},
+		{"abcabcabc", int64(1), int64(0)},
+	}, rows)
+}
+
+func TestGroupConcat(t *testing.T) {
+	rows, err := runSession(t, nil, `SELECT user_id, group_concat(price ORDER BY order_id DESC SEPARATOR "; ") AS prices, string_agg(DISTINCT item_id, "|" ORDER BY item_id) AS items FROM orders GROUP BY user_id ORDER BY user_id ASC`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{
+		{"9Ip1aKbeZe2njCDM", "37.50; 22.50", "1|2"},
+		{"abcabcabc", "22.50", "1"},
+	}, rows)
+
+	rows, err = runSession(t, nil, `SELECT group_concat(DISTINCT price ORDER BY price), group_concat(user_id ORDER BY user_id DESC, order_id) FROM orders`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"22.50,37.50", "abcabcabc,9Ip1aKbeZe2njCDM,9Ip1aKbeZe2njCDM"}}, rows)
+
+	// numeric keys order as numbers, 9 before 10
+	mockcsv.LoadTable(mockcsv.SchemaName, "concat_ranks", `name,rank
+ten,10
+nine,9
+hundred,100
+two,2`)
+	rows, err = runSession(t, nil, `SELECT group_concat(name ORDER BY rank), group_concat(name ORDER BY rank DESC) FROM concat_ranks`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"two,nine,ten,hundred", "hundred,ten,nine,two"}}, rows)
+	rows, err = runSession(t, nil, `SELECT name FROM concat_ranks ORDER BY rank ASC`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"two"}, {"nine"}, {"ten"}, {"hundred"}}, rows)
+}
+
+func TestApproxAggQuery(t *testing.T) {
+	rows, err := runSession(t, nil, `SELECT approx_count_distinct(user_id) AS users, approx_quantile(price, 0.5) AS med, approx_top_k(user_id, 1) AS top FROM orders`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{int64(2), 22.5, []string{"9Ip1aKbeZe2njCDM"}}}, rows)
+
+	rows, err = runSession(t, nil, `SELECT user_id, approx_count_distinct(item_id) FROM orders GROUP BY user_id`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(rows))
+
+	_, err = runSession(t, nil, `SELECT approx_quantile(price, 2) FROM orders`)
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,189 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+// SlowQueryThresholdVar global variable for the slow query threshold in
+// milliseconds, statements running longer are logged to slow query sinks.
+//
+//	SET GLOBAL slow_query_threshold = 2000;
+const SlowQueryThresholdVar = "slow_query_threshold"
+
+var (
+	slowThreshold int64 // nanoseconds, <= 0 disabled
+	slowMu        sync.RWMutex
+	slowSinks     []SlowQuerySink
+)
+
+type (
+	// SlowQuery is a statement that ran longer than the slow query threshold.
+	SlowQuery struct {
+		Time       time.Time     `json:"time"`
+		Duration   time.Duration `json:"duration"`
+		Rows       int64         `json:"rows"`
+		User       string        `json:"user,omitempty"`
+		Schema     string        `json:"schema,omitempty"`
+		Sql        string        `json:"sql"`
+		Normalized string        `json:"normalized,omitempty"`
+		Error      string        `json:"error,omitempty"`
+		// Plan snapshot of the select plan, protobuf serialized, see
+		// plan.SelectPlanFromPbBytes.
+		Plan []byte `json:"plan,omitempty"`
+	}
+	// SlowQuerySink receives slow queries, called from the go routine that
+	// ran the statement so must not block.
+	SlowQuerySink interface {
+		LogSlowQuery(q *SlowQuery)
+	}
+	// SlowQueryFunc adapts a func to a SlowQuerySink.
+	SlowQueryFunc func(q *SlowQuery)
+
+	slowQueryWriter struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+	// rowCounter is a result task that knows how many rows it wrote.
+	rowCounter interface {
+		RowCount() int64
+	}
+)
+
+// LogSlowQuery call f(q).
+func (f SlowQueryFunc) LogSlowQuery(q *SlowQuery) { f(q) }
+
+// NewSlowQueryWriter a sink writing each slow query as a line of json.
+func NewSlowQueryWriter(w io.Writer) SlowQuerySink {
+	return &slowQueryWriter{w: w}
+}
+
+// OpenSlowQueryFile a sink appending slow queries as json lines to file.
+func OpenSlowQueryFile(path string) (SlowQuerySink, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewSlowQueryWriter(f), f, nil
+}
+
+func (m *slowQueryWriter) LogSlowQuery(q *SlowQuery) {
+	by, err := json.Marshal(q)
+	if err != nil {
+		u.Warnf("could not marshal slow query %v", err)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err = m.w.Write(append(by, '\n')); err != nil {
+		u.Warnf("could not write slow query %v", err)
+	}
+}
+
+// AddSlowQuerySink register a sink to receive slow queries.
+func AddSlowQuerySink(s SlowQuerySink) {
+	slowMu.Lock()
+	slowSinks = append(slowSinks, s)
+	slowMu.Unlock()
+}
+
+// ClearSlowQuerySinks remove all slow query sinks.
+func ClearSlowQuerySinks() {
+	slowMu.Lock()
+	slowSinks = nil
+	slowMu.Unlock()
+}
+
+func init() {
+	datasource.RegisterSysVar(&datasource.SysVar{
+		Name:     SlowQueryThresholdVar,
+		Type:     value.StringType,
+		Default:  value.NewStringValue("0"),
+		Scope:    datasource.ScopeGlobal,
+		OnGlobal: setSlowQueryThreshold,
+	})
+}
+
+// SetSlowQueryThreshold set the duration statements must exceed to be
+// logged, 0 disables the slow query log.
+func SetSlowQueryThreshold(d time.Duration) {
+	atomic.StoreInt64(&slowThreshold, int64(d))
+}
+
+// SlowQueryThreshold current slow query threshold, 0 is disabled.
+func SlowQueryThreshold() time.Duration {
+	return time.Duration(atomic.LoadInt64(&slowThreshold))
+}
+
+// setSlowQueryThreshold from a SET value, integer milliseconds or a
+// duration string "2s".
+func setSlowQueryThreshold(v value.Value) error {
+	switch vt := v.(type) {
+	case value.NumericValue:
+		SetSlowQueryThreshold(time.Duration(vt.Float() * float64(time.Millisecond)))
+		return nil
+	case value.StringValue:
+		if ms, err := strconv.ParseFloat(vt.Val(), 64); err == nil {
+			SetSlowQueryThreshold(time.Duration(ms * float64(time.Millisecond)))
+			return nil
+		}
+		d, err := time.ParseDuration(vt.Val())
+		if err != nil {
+			return fmt.Errorf("QLBridge: invalid %s %q", SlowQueryThresholdVar, vt.Val())
+		}
+		SetSlowQueryThreshold(d)
+		return nil
+	}
+	return fmt.Errorf("QLBridge: invalid %s %v", SlowQueryThresholdVar, v)
+}
+
+// logSlowQuery send the statement to sinks if it ran longer than threshold.
+func (m *JobExecutor) logSlowQuery(start time.Time, err error) {
+	threshold := SlowQueryThreshold()
+	dur := time.Since(start)
+	if threshold <= 0 || dur < threshold {
+		return
+	}
+	slowMu.RLock()
+	sinks := slowSinks
+	slowMu.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+	q := &SlowQuery{Time: start, Duration: dur, Sql: m.Ctx.Raw, User: m.Ctx.User, Schema: m.Ctx.SchemaName}
+	if m.Ctx.Schema != nil {
+		q.Schema = m.Ctx.Schema.Name
+	}
+	if err != nil {
+		q.Error = err.Error()
+	}
+	if norm, _, err := rel.NormalizeSql(m.Ctx.Raw); err == nil {
+		q.Normalized = norm
+	}
+	if tasks := m.RootTask.Children(); len(tasks) > 0 {
+		if rc, ok := tasks[len(tasks)-1].(rowCounter); ok {
+			q.Rows = rc.RowCount()
+		}
+	}
+	if sel, ok := m.Plan.(*plan.Select); ok {
+		if pb, err := sel.Marshal(); err == nil {
+			q.Plan = pb
+		}
+	}
+	for _, s := range sinks {
+		s.LogSlowQuery(q)
+	}
+}
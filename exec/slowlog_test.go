@@ -0,0 +1,91 @@
+package exec_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/exec"
+)
+
+// lockedBuffer a buffer the test may read while a slow query sink, running
+// on the query go routine, writes to it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (m *lockedBuffer) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buf.Write(p)
+}
+
+func (m *lockedBuffer) ReadBytes(delim byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.buf.ReadBytes(delim)
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	defer exec.SetSlowQueryThreshold(0)
+	defer exec.ClearSlowQuerySinks()
+
+	logged := make(chan *exec.SlowQuery, 10)
+	exec.AddSlowQuerySink(exec.SlowQueryFunc(func(q *exec.SlowQuery) {
+		logged <- q
+	}))
+	buf := &lockedBuffer{}
+	exec.AddSlowQuerySink(exec.NewSlowQueryWriter(buf))
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	_, err = db.Exec(`SET GLOBAL slow_query_threshold = 1500`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1500*time.Millisecond, exec.SlowQueryThreshold())
+	_, err = db.Exec(`SET GLOBAL slow_query_threshold = "1ns"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, time.Nanosecond, exec.SlowQueryThreshold())
+	// drain the SETs
+	for len(logged) > 0 {
+		<-logged
+	}
+
+	rows, err := db.Query(`SELECT user_id FROM users WHERE referral_count > 1`)
+	assert.Equal(t, nil, err)
+	ct := 0
+	for rows.Next() {
+		ct++
+	}
+	rows.Close()
+
+	select {
+	case q := <-logged:
+		assert.Equal(t, int64(ct), q.Rows)
+		assert.Equal(t, "mockcsv", q.Schema)
+		assert.Equal(t, "SELECT user_id FROM users WHERE referral_count > ?", q.Normalized)
+		assert.NotEqual(t, 0, len(q.Plan))
+		assert.True(t, q.Duration > 0)
+	case <-time.After(time.Second):
+		t.Fatalf("expected slow query")
+	}
+
+	line, err := buf.ReadBytes('\n')
+	assert.Equal(t, nil, err)
+	q := &exec.SlowQuery{}
+	assert.Equal(t, nil, json.Unmarshal(line, q))
+	assert.Equal(t, `SET GLOBAL slow_query_threshold = "1ns"`, q.Sql)
+
+	// disabled
+	exec.SetSlowQueryThreshold(0)
+	_, err = db.Exec(`SET GLOBAL slow_query_threshold = 0`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(logged))
+}
@@ -1,10 +1,16 @@
 package exec
 
 import (
+	"database/sql/driver"
 	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -43,6 +49,9 @@ type Source struct {
 	ExecSource ExecutorSource
 	JoinKey    KeyEvaluator
 	closed     bool
+	// keyFilter of the build side of the join this source is the probe
+	// side of, the scan waits for it.
+	keyFilter *joinKeyFilter
 }
 
 // NewSource create a scanner to read from data source
@@ -55,13 +64,34 @@ func NewSource(ctx *plan.Context, p *plan.Source) (*Source, error) {
 		return nil, fmt.Errorf("Must have existing connection on Plan")
 	}
 
-	scanner, hasScanner := p.Conn.(schema.ConnScanner)
-
 	// Some sources require context so we seed it here
 	if sourceContext, needsContext := p.Conn.(RequiresContext); needsContext {
 		sourceContext.SetContext(ctx)
 	}
 
+	scanner, hasScanner := p.Conn.(schema.ConnScanner)
+
+	// Replicated partitioned sources are scanned per partition so a failed
+	// partition can be re-issued on another replica
+	if rs, ok := p.DataSource.(schema.SourceReplicated); ok && hasScanner {
+		if parts := tablePartitions(rs, p.Tbl); len(parts) > 0 {
+			p.ReleaseConn(scanner, nil)
+			p.Conn = nil
+			scanner = NewReplicaScanner(rs, parts)
+		}
+	} else if policy := p.Retry(); policy != nil && hasScanner {
+		// the retry scanner owns the conn, re-opening it on failures
+		open := func() (schema.Conn, error) {
+			conn, err := p.OpenConn()
+			if sourceContext, needsContext := conn.(RequiresContext); needsContext {
+				sourceContext.SetContext(ctx)
+			}
+			return conn, err
+		}
+		scanner = NewRetryScanner(policy, scanner, open, p.ReleaseConn)
+		p.Conn = nil
+	}
+
 	if !hasScanner {
 		e, hasSourceExec := p.Conn.(ExecutorSource)
 		if hasSourceExec {
@@ -104,6 +134,13 @@ func (m *Source) closeSource() error {
 	m.closed = true
 	if m.Scanner != nil {
 		if closer, ok := m.Scanner.(schema.Conn); ok {
+			if m.p.Pool() != nil {
+				// scanned from a pooled conn, return it for re-use
+				if m.p.Conn == closer {
+					m.p.Conn = nil
+				}
+				return m.p.ReleaseConn(closer, iterErr(m.Scanner))
+			}
 			if err := closer.Close(); err != nil {
 				return err
 			}
@@ -131,8 +168,63 @@ func (m *Source) Run() error {
 
 	sigChan := m.SigChan()
 
+	// TABLESAMPLE (n PERCENT) is a Bernoulli sample, each row kept with
+	// probability percent, (n ROWS) a reservoir sample of n rows.  Sources
+	// sampling natively have already applied it.
+	sample := 0.0
+	if m.p != nil && m.p.Stmt != nil && m.p.Stmt.Sample != nil && !m.p.SampleNative {
+		if m.p.Stmt.Sample.Rows > 0 {
+			return m.runReservoir(m.p.Stmt.Sample.Rows)
+		}
+		sample = m.p.Stmt.Sample.Percent / 100
+	}
+	times, err := m.timeFormats()
+	if err != nil {
+		return err
+	}
+
+	// Probe side of a join, rows whose join key is not in the build side
+	// filter are skipped, by the conn itself if it can.
+	var (
+		filter    schema.KeyFilter
+		joinNodes []expr.Node
+	)
+	if m.keyFilter != nil {
+		select {
+		case <-sigChan:
+			return nil
+		case <-m.keyFilter.ready:
+		}
+		if filter = m.keyFilter.f; filter != nil {
+			joinNodes = m.p.Stmt.JoinNodes()
+			if kf, ok := m.Scanner.(schema.ConnKeyFilter); ok {
+				kf.SetKeyFilter(joinNodes, filter)
+				filter = nil
+			}
+		}
+	}
+
+	// the slot is taken only now, so the probe side of a join waiting on
+	// its build side doesn't hold one
+	release, err := acquireSlot(m.p.Limiter(), false, sigChan)
+	if release == nil {
+		return err
+	}
+	defer release()
+
 	for item := m.Scanner.Next(); item != nil; item = m.Scanner.Next() {
 
+		atomic.AddInt64(&m.rows, 1)
+		if sample > 0 && rand.Float64() >= sample {
+			continue
+		}
+		if len(times) > 0 {
+			item = convertTimes(item, times)
+		}
+		if filter != nil && !keyFilterHas(filter, item, joinNodes) {
+			continue
+		}
+
 		select {
 		case <-sigChan:
 			return nil
@@ -141,5 +233,112 @@ func (m *Source) Run() error {
 		}
 
 	}
+	if err := iterErr(m.Scanner); err != nil {
+		u.Warnf("source scan failed %v", err)
+		return err
+	}
+	if m.Ctx.SpoolQuota() > 0 {
+		// results are spooled, release the connection now rather than
+		// when the client has read them
+		return m.closeSource()
+	}
 	return nil
 }
+
+// keyFilterHas false if the join key of msg is definitely not in filter.
+func keyFilterHas(filter schema.KeyFilter, msg schema.Message, nodes []expr.Node) bool {
+	mm, ok := msg.(*datasource.SqlDriverMessageMap)
+	if !ok {
+		return true
+	}
+	key, ok := joinKeyOf(mm, nodes)
+	return !ok || filter.Has(key)
+}
+
+// runReservoir reservoir sample (algorithm R) n rows of the scan, every
+// row has the same probability of being in the sample, which is sent
+// once the scan is complete.
+func (m *Source) runReservoir(n int64) error {
+	sigChan := m.SigChan()
+	release, err := acquireSlot(m.p.Limiter(), false, sigChan)
+	if release == nil {
+		return err
+	}
+	defer release()
+	reservoir := make([]schema.Message, 0, int(math.Min(float64(n), 1024)))
+	seen := int64(0)
+	for item := m.Scanner.Next(); item != nil; item = m.Scanner.Next() {
+		atomic.AddInt64(&m.rows, 1)
+		seen++
+		if int64(len(reservoir)) < n {
+			reservoir = append(reservoir, item)
+		} else if i := rand.Int63n(seen); i < n {
+			reservoir[i] = item
+		}
+		if seen%1000 == 0 {
+			select {
+			case <-sigChan:
+				return nil
+			default:
+			}
+		}
+	}
+	if err := iterErr(m.Scanner); err != nil {
+		u.Warnf("source scan failed %v", err)
+		return err
+	}
+	times, err := m.timeFormats()
+	if err != nil {
+		return err
+	}
+	for _, item := range reservoir {
+		if len(times) > 0 {
+			item = convertTimes(item, times)
+		}
+		select {
+		case <-sigChan:
+			return nil
+		case m.msgOutCh <- item:
+		}
+	}
+	return nil
+}
+
+// timeFormats the time columns of the source settings (time_zone, time_unit)
+// to be converted as they are scanned.
+func (m *Source) timeFormats() (map[string]*schema.TimeFormat, error) {
+	if m.p == nil || m.p.Schema == nil {
+		return nil, nil
+	}
+	return m.p.Schema.TimeFormats(m.p.Tbl)
+}
+
+// convertTimes convert the stored values (epoch numbers, local-time strings)
+// of the time columns of msg to UTC times.
+func convertTimes(msg schema.Message, times map[string]*schema.TimeFormat) schema.Message {
+	mm, ok := msg.(*datasource.SqlDriverMessageMap)
+	if !ok {
+		return msg
+	}
+	var vals []driver.Value
+	for col, tf := range times {
+		idx, ok := mm.ColIndex[col]
+		if !ok || idx >= len(mm.Vals) {
+			continue
+		}
+		t, ok := tf.Convert(mm.Vals[idx])
+		if !ok {
+			continue
+		}
+		if vals == nil {
+			// sources may share their rows, don't modify them
+			vals = make([]driver.Value, len(mm.Vals))
+			copy(vals, mm.Vals)
+		}
+		vals[idx] = t
+	}
+	if vals == nil {
+		return msg
+	}
+	return datasource.NewSqlDriverMessageMap(mm.IdVal, vals, mm.ColIndex)
+}
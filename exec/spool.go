@@ -0,0 +1,261 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+)
+
+var (
+	// SpoolDir directory result spool segments are written to, empty
+	// for the os temp dir.
+	SpoolDir = ""
+	// SpoolSegmentSize bytes of rows buffered in memory before they are
+	// written to a spool segment on disk.
+	SpoolSegmentSize int64 = 256 * 1024
+)
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+// resultSpool de-couples the rows a query produces from the rate the client
+// reads them.  Rows are read from the dag as fast as it produces them so
+// the sources finish their scan and release their connections, rows the
+// client has not yet read are written to disk in segments.  The bytes on
+// disk are bounded by the session result_spool_quota, once reached the
+// spool stops reading and the dag is back-pressured as it would be without
+// spooling.
+//
+// Segments are written as artifacts so they are encrypted at rest if
+// artifact keys are configured (see datasource.SetArtifactKeys).
+type resultSpool struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	cols      []string
+	quota     int64
+	size      int64          // bytes of segments on disk
+	segments  []spoolSegment // written, not yet read
+	tail      [][]driver.Value
+	tailBytes int64
+	buf       [][]driver.Value // rows of segment being read
+	done      bool             // dag finished, err is io.EOF or its error
+	err       error
+	closed    bool
+}
+
+type spoolSegment struct {
+	path string
+	size int64
+}
+
+func newResultSpool(cols []string, quota int64) *resultSpool {
+	m := &resultSpool{cols: cols, quota: quota}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// run read the messages of the result writer into the spool until the
+// dag finishes or the writer is closed.
+func (m *resultSpool) run(w *ResultWriter) {
+	in := w.MessageIn()
+	for {
+		select {
+		case <-w.SigChan():
+			return
+		case err := <-w.ErrChan():
+			m.finish(err)
+			return
+		case msg, ok := <-in:
+			if !ok || msg == nil {
+				m.finish(io.EOF)
+				return
+			}
+			row := make([]driver.Value, len(m.cols))
+			if err := msgToRow(msg, m.cols, row); err != nil {
+				m.finish(err)
+				return
+			}
+			if err := m.add(row); err != nil {
+				m.finish(err)
+				return
+			}
+		}
+	}
+}
+
+func (m *resultSpool) finish(err error) {
+	m.mu.Lock()
+	m.done = true
+	m.err = err
+	m.cond.Broadcast()
+	m.mu.Unlock()
+}
+
+// add a row, flushing the in memory rows to a segment once they reach the
+// segment size, waiting for the client to read segments if the quota is
+// reached.
+func (m *resultSpool) add(row []driver.Value) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tail = append(m.tail, row)
+	for _, v := range row {
+		m.tailBytes += valueSize(v)
+	}
+	m.cond.Broadcast()
+
+	segSize := SpoolSegmentSize
+	if m.quota < segSize {
+		segSize = m.quota
+	}
+	if m.tailBytes < segSize {
+		return nil
+	}
+	for len(m.segments) > 0 && m.size+m.tailBytes > m.quota && !m.closed {
+		m.cond.Wait()
+	}
+	if m.closed || len(m.tail) == 0 {
+		// closed, or the client read the rows while we waited
+		return nil
+	}
+	rows := m.tail
+	m.tail, m.tailBytes = nil, 0
+	seg, err := writeSegment(rows)
+	if err != nil {
+		return err
+	}
+	m.segments = append(m.segments, seg)
+	m.size += seg.size
+	m.cond.Broadcast()
+	return nil
+}
+
+// next row of the spool into dest, in the order they were produced.
+func (m *resultSpool) next(dest []driver.Value) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		switch {
+		case m.closed:
+			return ErrShuttingDown
+		case len(m.buf) > 0:
+			copy(dest, m.buf[0])
+			m.buf = m.buf[1:]
+			return nil
+		case len(m.segments) > 0:
+			seg := m.segments[0]
+			m.segments = m.segments[1:]
+			rows, err := readSegment(seg.path)
+			os.Remove(seg.path)
+			m.size -= seg.size
+			m.cond.Broadcast()
+			if err != nil {
+				return err
+			}
+			m.buf = rows
+		case len(m.tail) > 0:
+			m.buf, m.tail, m.tailBytes = m.tail, nil, 0
+			m.cond.Broadcast()
+		case m.done:
+			return m.err
+		default:
+			m.cond.Wait()
+		}
+	}
+}
+
+// close the spool removing segments not yet read.
+func (m *resultSpool) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	for _, seg := range m.segments {
+		os.Remove(seg.path)
+	}
+	m.segments, m.tail, m.buf, m.size = nil, nil, nil, 0
+	m.cond.Broadcast()
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (m *countWriter) Write(p []byte) (int, error) {
+	n, err := m.w.Write(p)
+	m.n += int64(n)
+	return n, err
+}
+
+func writeSegment(rows [][]driver.Value) (spoolSegment, error) {
+	w, path, err := datasource.CreateTempArtifact(SpoolDir, "qlb-spool-")
+	if err != nil {
+		return spoolSegment{}, err
+	}
+	cw := &countWriter{w: w}
+	enc := gob.NewEncoder(cw)
+	for _, row := range rows {
+		if err = enc.Encode(spoolRow(row)); err != nil {
+			break
+		}
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		u.Warnf("could not write result spool %v", err)
+		os.Remove(path)
+		return spoolSegment{}, err
+	}
+	return spoolSegment{path: path, size: cw.n}, nil
+}
+
+func readSegment(path string) ([][]driver.Value, error) {
+	r, err := datasource.OpenArtifact(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	dec := gob.NewDecoder(r)
+	var rows [][]driver.Value
+	for {
+		var row []interface{}
+		if err := dec.Decode(&row); err != nil {
+			if err == io.EOF {
+				return rows, nil
+			}
+			return nil, err
+		}
+		vals := make([]driver.Value, len(row))
+		for i, v := range row {
+			vals[i] = v
+		}
+		rows = append(rows, vals)
+	}
+}
+
+// spoolRow row values as gob encodable values, types gob doesn't know
+// are spooled as their string form.
+func spoolRow(row []driver.Value) []interface{} {
+	out := make([]interface{}, len(row))
+	for i, v := range row {
+		switch v.(type) {
+		case nil, int64, float64, bool, string, []byte, time.Time, int, []string:
+			out[i] = v
+		default:
+			out[i] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+)
+
+func TestResultSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qlb-spool")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	exec.SpoolDir = dir
+	exec.SpoolSegmentSize = 16
+	defer func() {
+		exec.SpoolDir = ""
+		exec.SpoolSegmentSize = 256 * 1024
+	}()
+
+	spooled := func() int {
+		files, err := ioutil.ReadDir(dir)
+		assert.Equal(t, nil, err)
+		return len(files)
+	}
+
+	run := func(quota string, waitSpool int) [][]driver.Value {
+		ses := datasource.NewMySqlSessionVars()
+		_, err := runSession(t, ses, `SET SESSION result_spool_quota = `+quota)
+		assert.Equal(t, nil, err)
+
+		sql := `SELECT user_id, email FROM users `
+		ctx := td.TestContext(sql)
+		ctx.Session = ses
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		defer job.Close()
+		rows := exec.NewResultRows(ctx, []string{"user_id", "email"})
+		job.RootTask.Add(rows)
+		assert.Equal(t, nil, job.Setup())
+		go job.Run()
+
+		// the query completes into the spool without the client reading
+		for i := 0; i < 100 && spooled() < waitSpool; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, waitSpool, spooled())
+
+		var out [][]driver.Value
+		for {
+			dest := make([]driver.Value, 2)
+			if err := rows.Next(dest); err != nil {
+				assert.Equal(t, io.EOF, err)
+				break
+			}
+			out = append(out, dest)
+		}
+		assert.Equal(t, 0, spooled())
+		return out
+	}
+
+	// without a quota nothing is spooled
+	want := run("0", 0)
+	assert.Equal(t, 3, len(want))
+
+	// each row is its own segment
+	assert.Equal(t, want, run("1048576", 3))
+
+	// a small quota only spools one segment, the rest wait on the client
+	assert.Equal(t, want, run("20", 1))
+}
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -14,6 +15,7 @@ import (
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
@@ -26,6 +28,9 @@ var (
 	_ driver.Driver  = (*qlbdriver)(nil)
 	_ driver.Execer  = (*qlbConn)(nil)
 	_ driver.Queryer = (*qlbConn)(nil)
+
+	_ driver.ExecerContext  = (*qlbConn)(nil)
+	_ driver.QueryerContext = (*qlbConn)(nil)
 	_ driver.Result  = (*qlbResult)(nil)
 	_ driver.Rows    = (*qlbRows)(nil)
 	_ driver.Stmt    = (*qlbStmt)(nil)
@@ -87,7 +92,9 @@ func (m *qlbdriver) Open(connInfo string) (driver.Conn, error) {
 	if !ok || s == nil {
 		return nil, fmt.Errorf("No schema was found for %q", connInfo)
 	}
-	return &qlbConn{schema: s}, nil
+	ses := plan.NewSession(datasource.NewMySqlSessionVars())
+	ses.SetSchema(s)
+	return &qlbConn{ses: ses}, nil
 }
 
 // A stateful connection to database/source
@@ -105,7 +112,7 @@ func (m *qlbdriver) Open(connInfo string) (driver.Conn, error) {
 type qlbConn struct {
 	parallel bool   // Do we Run In Background Mode?  Default = true
 	connInfo string //
-	schema   *schema.Schema
+	ses      *plan.Session // variables, temp tables, schema of the connection
 }
 
 // Exec may return ErrSkip.
@@ -125,6 +132,40 @@ func (m *qlbConn) Query(query string, args []driver.Value) (driver.Rows, error)
 	return stmt.Query(args)
 }
 
+// ExecContext is Exec, with the deadline and client budget (see
+// plan.WithBudget) of ctx.
+func (m *qlbConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	vals, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	stmt := &qlbStmt{conn: m, query: query, ctx: ctx}
+	return stmt.Exec(vals)
+}
+
+// QueryContext is Query, with the deadline and client budget (see
+// plan.WithBudget) of ctx.
+func (m *qlbConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	vals, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	stmt := &qlbStmt{conn: m, query: query, ctx: ctx}
+	return stmt.Query(vals)
+}
+
+// namedValues the values of args, named args are not supported.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("named args are not supported: %q", arg.Name)
+		}
+		vals[i] = arg.Value
+	}
+	return vals, nil
+}
+
 // Prepare returns a prepared statement, bound to this connection.
 func (m *qlbConn) Prepare(query string) (driver.Stmt, error) {
 	return nil, expr.ErrNotImplemented
@@ -139,8 +180,9 @@ func (m *qlbConn) Prepare(query string) (driver.Stmt, error) {
 // idle connections, it shouldn't be necessary for drivers to
 // do their own connection caching.
 func (m *qlbConn) Close() error {
-	//u.Debugf("sqlbConn.Close() do we need to do anything here?")
-	return nil
+	// the session, its temp tables and cursors only live as long as the connection
+	ReleaseCursors(m.ses.Vars)
+	return m.ses.Close()
 }
 
 // Begin starts and returns a new transaction.
@@ -165,6 +207,7 @@ type qlbStmt struct {
 	job   *JobExecutor
 	query string
 	conn  *qlbConn
+	ctx   context.Context // of QueryContext, ExecContext, optional
 }
 
 // Close closes the statement.
@@ -201,8 +244,7 @@ func (m *qlbStmt) Exec(args []driver.Value) (driver.Result, error) {
 	}
 
 	// Create a Job, which is Dag of Tasks that Run()
-	ctx := plan.NewContext(m.query)
-	ctx.Schema = m.conn.schema
+	ctx := m.newContext()
 	job, err := BuildSqlJob(ctx)
 	if err != nil {
 		return nil, err
@@ -224,6 +266,16 @@ func (m *qlbStmt) Exec(args []driver.Value) (driver.Result, error) {
 	return resultWriter.Result(), nil
 }
 
+// newContext the plan context of the statement.
+func (m *qlbStmt) newContext() *plan.Context {
+	ctx := m.conn.ses.NewContext(m.query)
+	if m.ctx != nil {
+		ctx.Context = m.ctx
+		ctx.Budget = plan.BudgetFromContext(m.ctx)
+	}
+	return ctx
+}
+
 // Query executes a query that may return rows, such as a SELECT
 func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 	var err error
@@ -236,8 +288,7 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 	u.Debugf("query: %v", m.query)
 
 	// Create a Job, which is Dag of Tasks that Run()
-	ctx := plan.NewContext(m.query)
-	ctx.Schema = m.conn.schema
+	ctx := m.newContext()
 	job, err := BuildSqlJob(ctx)
 	if err != nil {
 		u.Warnf("return error? %v", err)
@@ -253,6 +304,21 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 		return nil, fmt.Errorf("We could not recognize that as a select query: %T", job.Ctx.Stmt)
 	}
 
+	// Bulk consumers may ask for the whole result columnar encoded
+	format, err := ResultFormat(sqlSelect)
+	if err != nil {
+		job.Close()
+		return nil, err
+	}
+	if format != "" {
+		defer job.Close()
+		data, err := RunColumnar(job, format)
+		if err != nil {
+			return nil, err
+		}
+		return &columnarRows{format: format, data: data}, nil
+	}
+
 	// Prepare a result writer, we manually append this task to end
 	// of job?
 	resultWriter := NewResultRows(ctx, sqlSelect.Columns.AliasedFieldNames())
@@ -263,9 +329,11 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 
 	// TODO:   this can't run in parallel-buffered mode?
 	// how to open in go-routine and still be able to send error to rows?
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		//u.Debugf("Start Job.Run")
-		err = job.Run()
+		err := job.Run()
 		//u.Debugf("After job.Run()")
 		if err != nil {
 			u.Errorf("error on Query.Run(): %v", err)
@@ -276,7 +344,22 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 		//u.Debugf("exiting Background Query")
 	}()
 
-	return resultWriter, nil
+	return &jobRows{ResultWriter: resultWriter, done: done}, nil
+}
+
+// jobRows the rows of a query whose job runs in the background.  The caller
+// may close them before the job is done, ie on a budget error, so Close
+// stops the job and waits for its tasks to exit.
+type jobRows struct {
+	*ResultWriter
+	done chan struct{}
+}
+
+// Close the rows, stopping the job and waiting for it.
+func (m *jobRows) Close() error {
+	err := m.ResultWriter.Close()
+	<-m.done
+	return err
 }
 
 // driver.ColumnConverter Interface implementation.
@@ -324,6 +407,7 @@ func (conn *qlbRows) Next(dest []driver.Value) error { return expr.ErrNotImpleme
 type qlbResult struct {
 	lastId   int64
 	affected int64
+	matched  int64
 	err      error
 }
 
@@ -336,6 +420,11 @@ func (r *qlbResult) LastInsertId() (int64, error) { return r.lastId, r.err }
 // query.
 func (r *qlbResult) RowsAffected() (int64, error) { return r.affected, r.err }
 
+// RowsMatched returns the number of rows the where of an
+// update matched, rows already holding the new values are
+// matched but not affected.
+func (r *qlbResult) RowsMatched() (int64, error) { return r.matched, r.err }
+
 func join(a []string) string {
 	n := 0
 	for _, s := range a {
@@ -371,7 +460,8 @@ func queryArgsConvert(query string, args []driver.Value) (string, error) {
 		case string:
 			s = "'" + escapeString(v) + "'"
 		case []byte:
-			s = "'" + escapeString(string(v)) + "'"
+			// binary safe, X'ABCD' hex literal
+			s = expr.HexLiteral(v)
 		case int64:
 			s = strconv.FormatInt(v, 10)
 		case time.Time:
@@ -1,7 +1,9 @@
 package exec_test
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"testing"
 	"time"
 
@@ -9,7 +11,11 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
 	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/exec/columnar"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
 )
 
 var _ = u.EMPTY
@@ -374,3 +380,168 @@ func TestSqlDbConnFailure(t *testing.T) {
 	assert.True(t, uo1.Price == 22.5, "? %#v", uo1)
 	rows2.Close()
 }
+
+func TestSqlDriverResultFormat(t *testing.T) {
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	var data []byte
+	err = db.QueryRow(`SELECT user_id, email FROM users WHERE email = "bob@email.com" WITH result_format="arrow"`).Scan(&data)
+	assert.Equal(t, nil, err)
+	fields, rows, err := columnar.ReadArrow(data)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(fields))
+	assert.Equal(t, "user_id", fields[0].Name)
+	assert.Equal(t, "email", fields[1].Name)
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, "bob@email.com", rows[0][1])
+
+	rs, err := db.Query(`SELECT user_id, email FROM users WITH result_format="parquet"`)
+	assert.Equal(t, nil, err)
+	cols, _ := rs.Columns()
+	assert.Equal(t, []string{"parquet"}, cols)
+	assert.True(t, rs.Next())
+	assert.Equal(t, nil, rs.Scan(&data))
+	assert.False(t, rs.Next())
+	rs.Close()
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+
+	_, err = db.Query(`SELECT user_id FROM users WITH result_format="csv"`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSqlDriverBudget(t *testing.T) {
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	count := func(ctx context.Context, sql string) (int, error) {
+		rows, err := db.QueryContext(ctx, sql)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		ct := 0
+		for rows.Next() {
+			ct++
+		}
+		return ct, rows.Err()
+	}
+
+	ct, err := count(context.Background(), "SELECT user_id FROM users")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, ct)
+
+	ctx := plan.WithBudget(context.Background(), &plan.Budget{MaxRows: 2})
+	ct, err = count(ctx, "SELECT user_id FROM users")
+	assert.Equal(t, exec.ErrBudgetRows, err)
+	assert.Equal(t, 2, ct)
+	ct, err = count(ctx, "SELECT user_id FROM users LIMIT 2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, ct)
+
+	// user_id values are 16 bytes
+	ctx = plan.WithBudget(context.Background(), &plan.Budget{MaxBytes: 40})
+	ct, err = count(ctx, "SELECT user_id FROM users")
+	assert.Equal(t, exec.ErrBudgetBytes, err)
+	assert.Equal(t, 2, ct)
+}
+
+func TestSqlDriverSession(t *testing.T) {
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	assert.Equal(t, nil, err)
+
+	// variables, temp tables and prepared statements live as long as the connection
+	_, err = conn.ExecContext(ctx, `SET @who = "hT2impsabc345c"`)
+	assert.Equal(t, nil, err)
+	var email string
+	err = conn.QueryRowContext(ctx, `SELECT email FROM users WHERE user_id = @who`).Scan(&email)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", email)
+
+	_, err = conn.ExecContext(ctx, `CREATE TEMP TABLE seen (id int, name varchar(20))`)
+	assert.Equal(t, nil, err)
+	_, err = conn.ExecContext(ctx, `INSERT INTO seen (id, name) VALUES (1, "a")`)
+	assert.Equal(t, nil, err)
+	var name string
+	err = conn.QueryRowContext(ctx, `SELECT name FROM seen WHERE id = 1`).Scan(&name)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "a", name)
+
+	_, err = conn.ExecContext(ctx, `PREPARE byid FROM 'SELECT name FROM seen WHERE id = 1'`)
+	assert.Equal(t, nil, err)
+
+	// other connections have their own session
+	conn2, err := db.Conn(ctx)
+	assert.Equal(t, nil, err)
+	_, err = conn2.QueryContext(ctx, `SELECT name FROM seen`)
+	assert.NotEqual(t, nil, err)
+	conn2.Close()
+	conn.Close()
+}
+
+func TestSqlDriverUseSchema(t *testing.T) {
+	planets, err := memdb.NewMemDbData("planets", [][]driver.Value{
+		{1, "mercury"}, {2, "venus"},
+	}, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("use_planets", planets))
+
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	assert.Equal(t, nil, err)
+	defer conn.Close()
+
+	count := func(sql string) (int, error) {
+		rows, err := conn.QueryContext(ctx, sql)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		ct := 0
+		for rows.Next() {
+			ct++
+		}
+		return ct, rows.Err()
+	}
+
+	// not in the current schema, the error names the schemas that have it
+	_, err = count(`SELECT name FROM planets`)
+	assert.NotEqual(t, nil, err)
+	assert.Contains(t, err.Error(), "use_planets")
+
+	ct, err := count(`SELECT name FROM use_planets.planets`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, ct)
+
+	_, err = conn.ExecContext(ctx, `USE use_planets`)
+	assert.Equal(t, nil, err)
+	ct, err = count(`SELECT name FROM planets`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, ct)
+	_, err = count(`SELECT email FROM users`)
+	assert.NotEqual(t, nil, err)
+
+	// then the default schema
+	schema.DefaultRegistry().SetDefaultSchema("mockcsv")
+	defer schema.DefaultRegistry().SetDefaultSchema("")
+	ct, err = count(`SELECT email FROM users`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, ct)
+
+	// an unknown schema leaves the current one
+	conn.ExecContext(ctx, `USE not_a_schema`)
+	ct, err = count(`SELECT name FROM planets`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, ct)
+}
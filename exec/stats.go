@@ -0,0 +1,346 @@
+package exec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+// Moments running count, mean and sum of squared differences from the mean
+// of values (Welford), numerically stable where sum(x^2) - sum(x)^2/n is
+// not, and mergeable (Chan et al) for partial aggregation.
+type Moments struct {
+	N    float64
+	Mean float64
+	M2   float64
+}
+
+// Add value x.
+func (m *Moments) Add(x float64) {
+	m.N++
+	d := x - m.Mean
+	m.Mean += d / m.N
+	m.M2 += d * (x - m.Mean)
+}
+
+// Merge the moments of another partition.
+func (m *Moments) Merge(o *Moments) {
+	if o.N == 0 {
+		return
+	}
+	if m.N == 0 {
+		*m = *o
+		return
+	}
+	n := m.N + o.N
+	d := o.Mean - m.Mean
+	m.M2 += o.M2 + d*d*m.N*o.N/n
+	m.Mean += d * o.N / n
+	m.N = n
+}
+
+// Variance sample (n-1) or population (n) variance, false if there are
+// too few values.
+func (m *Moments) Variance(sample bool) (float64, bool) {
+	if sample {
+		if m.N < 2 {
+			return 0, false
+		}
+		return m.M2 / (m.N - 1), true
+	}
+	if m.N < 1 {
+		return 0, false
+	}
+	return m.M2 / m.N, true
+}
+
+// MarshalBinary n, mean, m2.
+func (m *Moments) MarshalBinary() ([]byte, error) {
+	return putFloats(m.N, m.Mean, m.M2), nil
+}
+
+// UnmarshalBinary read moments written by MarshalBinary.
+func (m *Moments) UnmarshalBinary(data []byte) error {
+	f, err := getFloats(data, 3)
+	if err != nil {
+		return err
+	}
+	m.N, m.Mean, m.M2 = f[0], f[1], f[2]
+	return nil
+}
+
+// CoMoments running co-moment of pairs of values, as Moments for the
+// covariance and correlation of x, y.
+type CoMoments struct {
+	N     float64
+	MeanX float64
+	MeanY float64
+	C     float64 // sum of (x - mean x) * (y - mean y)
+	M2X   float64
+	M2Y   float64
+}
+
+// Add the pair x, y.
+func (m *CoMoments) Add(x, y float64) {
+	m.N++
+	dx := x - m.MeanX
+	m.MeanX += dx / m.N
+	dy := y - m.MeanY
+	m.MeanY += dy / m.N
+	m.C += dx * (y - m.MeanY)
+	m.M2X += dx * (x - m.MeanX)
+	m.M2Y += dy * (y - m.MeanY)
+}
+
+// Merge the co-moments of another partition.
+func (m *CoMoments) Merge(o *CoMoments) {
+	if o.N == 0 {
+		return
+	}
+	if m.N == 0 {
+		*m = *o
+		return
+	}
+	n := m.N + o.N
+	dx := o.MeanX - m.MeanX
+	dy := o.MeanY - m.MeanY
+	f := m.N * o.N / n
+	m.C += o.C + dx*dy*f
+	m.M2X += o.M2X + dx*dx*f
+	m.M2Y += o.M2Y + dy*dy*f
+	m.MeanX += dx * o.N / n
+	m.MeanY += dy * o.N / n
+	m.N = n
+}
+
+// Covariance sample (n-1) or population (n) covariance.
+func (m *CoMoments) Covariance(sample bool) (float64, bool) {
+	if sample {
+		if m.N < 2 {
+			return 0, false
+		}
+		return m.C / (m.N - 1), true
+	}
+	if m.N < 1 {
+		return 0, false
+	}
+	return m.C / m.N, true
+}
+
+// Correlation pearson correlation coefficient, false if either has no
+// variance.
+func (m *CoMoments) Correlation() (float64, bool) {
+	if m.N < 2 || m.M2X == 0 || m.M2Y == 0 {
+		return 0, false
+	}
+	return m.C / math.Sqrt(m.M2X*m.M2Y), true
+}
+
+// MarshalBinary n, means, co-moment and m2s.
+func (m *CoMoments) MarshalBinary() ([]byte, error) {
+	return putFloats(m.N, m.MeanX, m.MeanY, m.C, m.M2X, m.M2Y), nil
+}
+
+// UnmarshalBinary read co-moments written by MarshalBinary.
+func (m *CoMoments) UnmarshalBinary(data []byte) error {
+	f, err := getFloats(data, 6)
+	if err != nil {
+		return err
+	}
+	m.N, m.MeanX, m.MeanY, m.C, m.M2X, m.M2Y = f[0], f[1], f[2], f[3], f[4], f[5]
+	return nil
+}
+
+// Percentile exact percentile of values, continuous interpolates between
+// the values either side of the rank, discrete is the first value whose
+// cumulative distribution is >= p.
+func Percentile(vals []float64, p float64, continuous bool) (float64, bool) {
+	if len(vals) == 0 {
+		return 0, false
+	}
+	sort.Float64s(vals)
+	if !continuous {
+		i := int(math.Ceil(p*float64(len(vals)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return vals[i], true
+	}
+	rank := p * float64(len(vals)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return vals[lo] + (vals[hi]-vals[lo])*(rank-float64(lo)), true
+}
+
+func putFloats(f ...float64) []byte {
+	buf := make([]byte, 8*len(f))
+	for i, v := range f {
+		binary.BigEndian.PutUint64(buf[i*8:], math.Float64bits(v))
+	}
+	return buf
+}
+
+func getFloats(data []byte, n int) ([]float64, error) {
+	if (n >= 0 && len(data) != 8*n) || len(data)%8 != 0 {
+		return nil, errSketchData
+	}
+	f := make([]float64, len(data)/8)
+	for i := range f {
+		f[i] = math.Float64frombits(binary.BigEndian.Uint64(data[i*8:]))
+	}
+	return f, nil
+}
+
+type statMoments struct {
+	partial bool
+	sample  bool
+	sqrt    bool
+	m       Moments
+}
+
+func (m *statMoments) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	if f, ok := value.ValueToFloat64(v); ok && !math.IsNaN(f) {
+		m.m.Add(f)
+	}
+}
+func (m *statMoments) Result() interface{} {
+	if m.partial {
+		sketch, _ := m.m.MarshalBinary()
+		return &AggPartial{Ct: int64(m.m.N), Sketch: sketch}
+	}
+	variance, ok := m.m.Variance(m.sample)
+	if !ok {
+		return nil
+	}
+	if m.sqrt {
+		return math.Sqrt(variance)
+	}
+	return variance
+}
+func (m *statMoments) Reset() { m.m = Moments{} }
+func (m *statMoments) Merge(a *AggPartial) {
+	other := &Moments{}
+	if err := other.UnmarshalBinary(a.Sketch); err != nil {
+		u.Warnf("could not merge variance %v", err)
+		return
+	}
+	m.m.Merge(other)
+}
+
+// NewVariance variance(), var_samp(), var_pop(), stddev(), stddev_samp(),
+// stddev_pop() aggregators, sample unless pop.
+func NewVariance(col *rel.Column, partial, sample, stddev bool) Aggregator {
+	return &statMoments{partial: partial, sample: sample, sqrt: stddev}
+}
+
+type statCoMoments struct {
+	partial bool
+	corr    bool
+	sample  bool
+	m       CoMoments
+}
+
+func (m *statCoMoments) Do(v value.Value) {
+	sv, ok := v.(value.SliceValue)
+	if !ok || sv.Len() != 2 {
+		return
+	}
+	vals := sv.Val()
+	x, okx := value.ValueToFloat64(vals[0])
+	y, oky := value.ValueToFloat64(vals[1])
+	if !okx || !oky || math.IsNaN(x) || math.IsNaN(y) {
+		return
+	}
+	m.m.Add(x, y)
+}
+func (m *statCoMoments) Result() interface{} {
+	if m.partial {
+		sketch, _ := m.m.MarshalBinary()
+		return &AggPartial{Ct: int64(m.m.N), Sketch: sketch}
+	}
+	var f float64
+	var ok bool
+	if m.corr {
+		f, ok = m.m.Correlation()
+	} else {
+		f, ok = m.m.Covariance(m.sample)
+	}
+	if !ok {
+		return nil
+	}
+	return f
+}
+func (m *statCoMoments) Reset() { m.m = CoMoments{} }
+func (m *statCoMoments) Merge(a *AggPartial) {
+	other := &CoMoments{}
+	if err := other.UnmarshalBinary(a.Sketch); err != nil {
+		u.Warnf("could not merge covariance %v", err)
+		return
+	}
+	m.m.Merge(other)
+}
+
+// NewCovariance corr(x, y), covar_samp(x, y), covar_pop(x, y) aggregators.
+func NewCovariance(col *rel.Column, partial, corr, sample bool) Aggregator {
+	return &statCoMoments{partial: partial, corr: corr, sample: sample}
+}
+
+type percentile struct {
+	partial    bool
+	p          float64
+	continuous bool
+	vals       []float64
+}
+
+func (m *percentile) Do(v value.Value) {
+	if v == nil || v.Nil() {
+		return
+	}
+	if f, ok := value.ValueToFloat64(v); ok && !math.IsNaN(f) {
+		m.vals = append(m.vals, f)
+	}
+}
+func (m *percentile) Result() interface{} {
+	if m.partial {
+		return &AggPartial{Ct: int64(len(m.vals)), Sketch: putFloats(m.vals...)}
+	}
+	f, ok := Percentile(m.vals, m.p, m.continuous)
+	if !ok {
+		return nil
+	}
+	return f
+}
+func (m *percentile) Reset() { m.vals = nil }
+func (m *percentile) Merge(a *AggPartial) {
+	vals, err := getFloats(a.Sketch, -1)
+	if err != nil {
+		u.Warnf("could not merge percentile %v", err)
+		return
+	}
+	m.vals = append(m.vals, vals...)
+}
+
+// NewPercentile percentile_cont(x, p), percentile_disc(x, p) and median(x)
+// aggregators, exact so they hold the values of the group.
+func NewPercentile(col *rel.Column, partial, continuous bool, median bool) (Aggregator, error) {
+	p := 0.5
+	if !median {
+		var err error
+		if p, err = aggLiteralArg(col, 1); err != nil {
+			return nil, err
+		}
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("percentile must be between 0 and 1: %s", col.Expr)
+		}
+	}
+	return &percentile{partial: partial, p: p, continuous: continuous}, nil
+}
@@ -0,0 +1,88 @@
+package exec_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/exec"
+)
+
+func TestMoments(t *testing.T) {
+	// large offset, the naive sum of squares loses all precision
+	vals := []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}
+	all := &exec.Moments{}
+	left, right := &exec.Moments{}, &exec.Moments{}
+	for i, v := range vals {
+		all.Add(v)
+		if i < 1 {
+			left.Add(v)
+		} else {
+			right.Add(v)
+		}
+	}
+	variance, ok := all.Variance(true)
+	assert.True(t, ok)
+	assert.InDelta(t, 30, variance, 1e-6)
+	variance, _ = all.Variance(false)
+	assert.InDelta(t, 22.5, variance, 1e-6)
+
+	// merged partitions are the same as one pass
+	left.Merge(right)
+	variance, _ = left.Variance(true)
+	assert.InDelta(t, 30, variance, 1e-6)
+	by, _ := left.MarshalBinary()
+	read := &exec.Moments{}
+	assert.Equal(t, nil, read.UnmarshalBinary(by))
+	assert.Equal(t, left, read)
+
+	_, ok = (&exec.Moments{N: 1}).Variance(true)
+	assert.True(t, !ok)
+
+	cm, a, b := &exec.CoMoments{}, &exec.CoMoments{}, &exec.CoMoments{}
+	for i, x := range []float64{1, 2, 3, 4, 5} {
+		y := 2*x + 1
+		cm.Add(x, y)
+		if i%2 == 0 {
+			a.Add(x, y)
+		} else {
+			b.Add(x, y)
+		}
+	}
+	a.Merge(b)
+	for _, m := range []*exec.CoMoments{cm, a} {
+		corr, ok := m.Correlation()
+		assert.True(t, ok)
+		assert.InDelta(t, 1, corr, 1e-9)
+		covar, _ := m.Covariance(true)
+		assert.InDelta(t, 5, covar, 1e-9)
+	}
+
+	p, _ := exec.Percentile([]float64{4, 1, 3, 2}, 0.5, true)
+	assert.Equal(t, 2.5, p)
+	p, _ = exec.Percentile([]float64{4, 1, 3, 2}, 0.5, false)
+	assert.Equal(t, 2.0, p)
+	p, _ = exec.Percentile([]float64{4, 1, 3, 2}, 0, false)
+	assert.Equal(t, 1.0, p)
+	_, ok = exec.Percentile(nil, 0.5, true)
+	assert.True(t, !ok)
+}
+
+func TestStatAggQuery(t *testing.T) {
+	rows, err := runSession(t, nil, `SELECT variance(price), var_pop(price), stddev(price), median(price), percentile_cont(price, 0.75), percentile_disc(price, 0.75), corr(price, item_id), covar_samp(price, item_id), covar_pop(price, item_id) FROM orders`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(rows))
+	want := []float64{75, 50, math.Sqrt(75), 22.5, 30, 37.5, 1, 5, 10.0 / 3}
+	for i, v := range want {
+		assert.InDelta(t, v, rows[0][i], 1e-9, "col %d", i)
+	}
+
+	// too few values is null
+	rows, err = runSession(t, nil, `SELECT user_id, stddev(price) FROM orders WHERE user_id = "abcabcabc" GROUP BY user_id`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"abcabcabc", nil}}, rows)
+
+	_, err = runSession(t, nil, `SELECT percentile_cont(price, 2) FROM orders`)
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*ResultStream)(nil)
+)
+
+// RowWriter is implemented by embedders that want the rows of a select
+// pushed to them as they are produced, rather than reading them with Next,
+// ie to send them to a server-sent-events or websocket UI.  Queries
+// without blocking operators (ORDER BY, GROUP BY, DISTINCT) stream end to
+// end, the first row is delivered before the scan of the source completes.
+// A RowWriter that is also a plan.DeadLetterSink receives the rows that
+// fail under the RowErrorDeadLetter policy.
+type RowWriter interface {
+	// OnColumns the column names of the rows, called once before any row.
+	OnColumns(cols []string) error
+	// OnRow called for each row as it is produced, the row is not re-used.
+	// An error stops the query, and is returned by RunStream.
+	OnRow(row []driver.Value) error
+}
+
+// ResultStream a result writer calling the RowWriter.OnRow of each result
+// row as it arrives.
+type ResultStream struct {
+	*TaskBase
+	w     RowWriter
+	cols  []string
+	rowCt int64
+}
+
+// NewResultStream create a result stream of rows of cols to w.
+func NewResultStream(ctx *plan.Context, cols []string, w RowWriter) *ResultStream {
+	return &ResultStream{
+		TaskBase: NewTaskBase(ctx),
+		w:        w,
+		cols:     cols,
+	}
+}
+
+// RowCount rows written to the RowWriter.
+func (m *ResultStream) RowCount() int64 { return atomic.LoadInt64(&m.rowCt) }
+
+// Run the stream until the input is closed, or OnRow errors.
+func (m *ResultStream) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	inCh := m.MessageIn()
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case err := <-m.ErrChan():
+			return err
+		case msg, ok := <-inCh:
+			if !ok {
+				return nil
+			}
+			if msg == nil {
+				// nil is the shutdown signal of a LIMIT upstream, not a row
+				continue
+			}
+			row := make([]driver.Value, len(m.cols))
+			if err := msgToRow(msg, m.cols, row); err != nil {
+				if !streamsRowErrors(m.Ctx) {
+					return err
+				}
+				rdr, _ := msg.(expr.ContextReader)
+				if err = rowError(m.Ctx, "result", rdr, err); err != nil {
+					return err
+				}
+				continue
+			}
+			atomic.AddInt64(&m.rowCt, 1)
+			if err := m.w.OnRow(row); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RunStream plan and run the select of ctx, writing its rows to w as they
+// are produced.  Rows that fail expression evaluation or coercion are
+// handled by the ctx RowErrors (or session stream_row_errors) policy:
+// dropped, halting the query, or sent to the ctx DeadLetters (or w).
+func RunStream(ctx *plan.Context, w RowWriter) error {
+	policy, err := ctx.StreamRowErrors()
+	if err != nil {
+		return err
+	}
+	ctx.RowErrors = policy
+	if policy == plan.RowErrorDeadLetter && ctx.DeadLetters == nil {
+		sink, ok := w.(plan.DeadLetterSink)
+		if !ok {
+			return fmt.Errorf("%s dead_letter requires a DeadLetterSink", plan.RowErrorsVar)
+		}
+		ctx.DeadLetters = sink
+	}
+
+	job, err := BuildSqlJob(ctx)
+	if err != nil {
+		return err
+	}
+	defer job.Close()
+
+	sel, ok := job.Ctx.Stmt.(*rel.SqlSelect)
+	if !ok {
+		return fmt.Errorf("RunStream requires a select got %T", job.Ctx.Stmt)
+	}
+	cols := sel.Columns.AliasedFieldNames()
+	if err = w.OnColumns(cols); err != nil {
+		return err
+	}
+	job.RootTask.Add(NewResultStream(ctx, cols, w))
+	if err = job.Setup(); err != nil {
+		return err
+	}
+	return job.Run()
+}
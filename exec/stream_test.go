@@ -0,0 +1,181 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// gatedConn a memdb conn whose scan blocks after its first row until the
+// gate is opened.
+type gatedConn struct {
+	schema.ConnAll
+	gate     chan struct{}
+	scanned  int
+	timedOut bool
+}
+
+func (m *gatedConn) Next() schema.Message {
+	if m.scanned == 1 {
+		select {
+		case <-m.gate:
+		case <-time.After(5 * time.Second):
+			m.timedOut = true
+		}
+	}
+	m.scanned++
+	return m.ConnAll.Next()
+}
+
+type gatedSource struct {
+	*memdb.MemDb
+	gate chan struct{}
+	conn *gatedConn
+}
+
+func (m *gatedSource) Open(table string) (schema.Conn, error) {
+	conn, err := m.MemDb.Open(table)
+	if err != nil {
+		return nil, err
+	}
+	m.conn = &gatedConn{ConnAll: conn.(schema.ConnAll), gate: m.gate}
+	return m.conn, nil
+}
+
+type rowRecorder struct {
+	cols  []string
+	rows  []string
+	onRow func(row []driver.Value) error
+}
+
+func (m *rowRecorder) OnColumns(cols []string) error {
+	m.cols = cols
+	return nil
+}
+func (m *rowRecorder) OnRow(row []driver.Value) error {
+	m.rows = append(m.rows, fmt.Sprint(row))
+	if m.onRow != nil {
+		return m.onRow(row)
+	}
+	return nil
+}
+
+func TestResultStream(t *testing.T) {
+	data := make([][]driver.Value, 0)
+	for i := 0; i < 10; i++ {
+		data = append(data, []driver.Value{i, fmt.Sprintf("event %d", i)})
+	}
+	db, err := memdb.NewMemDbData("stream_events", data, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	src := &gatedSource{MemDb: db, gate: make(chan struct{})}
+	tbl, _ := db.Table("stream_events")
+
+	run := func(sql string, w exec.RowWriter) error {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, src)
+		return exec.RunStream(ctx, w)
+	}
+
+	// the first row is delivered while the scan is blocked, it opens the gate
+	w := &rowRecorder{}
+	w.onRow = func(row []driver.Value) error {
+		if len(w.rows) == 1 {
+			close(src.gate)
+		}
+		return nil
+	}
+	assert.Equal(t, nil, run(`SELECT name FROM stream_events`, w))
+	assert.Equal(t, []string{"name"}, w.cols)
+	assert.Equal(t, 10, len(w.rows))
+	assert.Equal(t, "[event 0]", w.rows[0])
+	if assert.NotEqual(t, nil, src.conn) {
+		assert.True(t, !src.conn.timedOut, "first row was not streamed before the scan completed")
+	}
+
+	// an OnRow error stops the query
+	src.gate = make(chan struct{})
+	close(src.gate)
+	w = &rowRecorder{}
+	w.onRow = func(row []driver.Value) error {
+		if len(w.rows) == 3 {
+			return fmt.Errorf("client went away")
+		}
+		return nil
+	}
+	err = run(`SELECT id, name FROM stream_events`, w)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 3, len(w.rows))
+}
+
+type deadLetterRecorder struct {
+	rowRecorder
+	dead []*plan.DeadLetter
+}
+
+func (m *deadLetterRecorder) OnDeadLetter(dl *plan.DeadLetter) error {
+	m.dead = append(m.dead, dl)
+	return nil
+}
+
+func TestStreamRowErrors(t *testing.T) {
+	data := [][]driver.Value{
+		{1, "10"},
+		{2, "not a number"},
+		{3, "30"},
+	}
+	db, err := memdb.NewMemDbData("stream_payments", data, []string{"id", "amount"})
+	assert.Equal(t, nil, err)
+	tbl, _ := db.Table("stream_payments")
+
+	run := func(sql string, policy plan.RowErrorPolicy, w exec.RowWriter) error {
+		ctx := td.TestContext(sql)
+		ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+		ctx.RowErrors = policy
+		return exec.RunStream(ctx, w)
+	}
+	sql := `SELECT id FROM stream_payments WHERE toint(amount) BETWEEN 5 AND 50`
+
+	w := &rowRecorder{}
+	assert.Equal(t, nil, run(sql, plan.RowErrorDrop, w))
+	assert.Equal(t, []string{"[1]", "[3]"}, w.rows)
+
+	w = &rowRecorder{}
+	err = run(sql, plan.RowErrorHalt, w)
+	assert.NotEqual(t, nil, err)
+	assert.True(t, len(w.rows) <= 1, "rows after the failed one: %v", w.rows)
+
+	dw := &deadLetterRecorder{}
+	assert.Equal(t, nil, run(sql, plan.RowErrorDeadLetter, dw))
+	assert.Equal(t, []string{"[1]", "[3]"}, dw.rows)
+	if assert.Equal(t, 1, len(dw.dead)) {
+		assert.Equal(t, "where", dw.dead[0].Op)
+		assert.Equal(t, "not a number", dw.dead[0].Row["amount"])
+		assert.NotEqual(t, nil, dw.dead[0].Err)
+	}
+
+	// dead_letter needs a sink
+	assert.NotEqual(t, nil, run(sql, plan.RowErrorDeadLetter, &rowRecorder{}))
+
+	// from the session, failed projections
+	ctx := td.TestContext(`SELECT id, toint(amount) AS amt FROM stream_payments`)
+	ctx.Schema = mockcsv.Schema().WithTable(tbl, db)
+	ctx.Session.Put(&rel.CommandColumn{Name: plan.RowErrorsVar}, nil, value.NewStringValue("dead_letter"))
+	dw = &deadLetterRecorder{}
+	assert.Equal(t, nil, exec.RunStream(ctx, dw))
+	assert.Equal(t, []string{"[1 10]", "[3 30]"}, dw.rows)
+	if assert.Equal(t, 1, len(dw.dead)) {
+		assert.Equal(t, "projection", dw.dead[0].Op)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	u "github.com/araddon/gou"
 
@@ -32,6 +33,12 @@ type TaskBase struct {
 	errCh    ErrChan
 	sigCh    SigChan // notify of quit/stop
 	errors   []error
+
+	// Workers number of go routines running Handler, 0 or 1 runs messages
+	// in order on the Run go routine.
+	Workers int
+
+	rows int64 // messages received, see Rows
 }
 
 func NewTaskBase(ctx *plan.Context) *TaskBase {
@@ -65,6 +72,9 @@ func (m *TaskBase) MessageInSet(ch MessageChan)  { m.msgInCh = ch }
 func (m *TaskBase) MessageOutSet(ch MessageChan) { m.msgOutCh = ch }
 func (m *TaskBase) ErrChan() ErrChan             { return m.errCh }
 func (m *TaskBase) SigChan() SigChan             { return m.sigCh }
+
+// Rows the number of messages the task has received.
+func (m *TaskBase) Rows() int64 { return atomic.LoadInt64(&m.rows) }
 func (m *TaskBase) Quit() {
 	if m.hasquit {
 		return
@@ -121,6 +131,9 @@ func (m *TaskBase) Run() error {
 		u.Warnf("returning, no handler %T", m)
 		return fmt.Errorf("Must have a handler to run base runner")
 	}
+	if m.Workers > 1 {
+		return m.runWorkers()
+	}
 	ok := true
 	var err error
 	var msg schema.Message
@@ -144,6 +157,7 @@ msgLoop:
 		case msg, ok = <-m.msgInCh:
 			if ok {
 				//u.Debugf("sending to handler: %T  %+v", msg, msg)
+				atomic.AddInt64(&m.rows, 1)
 				m.Handler(m.Ctx, msg)
 			} else {
 				//u.Debugf("msg in closed shutting down")
@@ -158,6 +172,47 @@ msgLoop:
 	return err
 }
 
+// runWorkers run Workers go routines each reading messages into Handler,
+// for handlers that are safe to run concurrently (where filters).  The
+// first error stops them all.
+func (m *TaskBase) runWorkers() error {
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	done := make(chan struct{})
+	for i := 0; i < m.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer m.Ctx.Recover()
+			for {
+				select {
+				case err := <-m.errCh:
+					once.Do(func() {
+						firstErr = err
+						close(done)
+					})
+					return
+				case <-done:
+					return
+				case <-m.sigCh:
+					return
+				case msg, ok := <-m.msgInCh:
+					if !ok {
+						return
+					}
+					atomic.AddInt64(&m.rows, 1)
+					m.Handler(m.Ctx, msg)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
 // On Task stepper we don't Run it, rather use a
 //   Next() explicit call from end user
 type TaskStepper struct {
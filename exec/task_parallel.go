@@ -127,6 +127,8 @@ func (m *TaskParallel) Run() error {
 	}
 
 	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var firstErr error
 
 	// start tasks in reverse order, so that by time
 	// source starts up all downstreams have started
@@ -135,9 +137,13 @@ func (m *TaskParallel) Run() error {
 		go func(taskId int) {
 			task := m.runners[taskId]
 			//u.Infof("starting task %d-%d %T in:%p  out:%p", m.depth, taskId, task, task.MessageIn(), task.MessageOut())
-			if err := task.Run(); err != nil {
+			if err := runMeasured(task); err != nil {
 				u.Errorf("%T.Run() errored %v", task, err)
-				// TODO:  what do we do with this error?   send to error channel?
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMu.Unlock()
 			}
 			//u.Debugf("exiting taskId: %v %T", taskId, task)
 			wg.Done()
@@ -146,5 +152,5 @@ func (m *TaskParallel) Run() error {
 
 	wg.Wait()
 
-	return nil
+	return firstErr
 }
@@ -148,16 +148,16 @@ func (m *TaskSequential) Run() (err error) {
 		go func(taskId int) {
 			task := m.runners[taskId]
 			//u.Infof("starting task %d-%d %T in:%p  out:%p", m.depth, taskId, task, task.MessageIn(), task.MessageOut())
-			if taskErr := task.Run(); taskErr != nil {
+			if taskErr := runMeasured(task); taskErr != nil {
 				u.Errorf("%T.Run() errored %v", task, taskErr)
 				// TODO:  what do we do with this error?   send to error channel?
 				err = taskErr
 				m.errors = append(m.errors, taskErr)
 			}
 			//u.Debugf("%p %q exiting taskId: %p %v %T", m, m.Name, task, taskId, task)
-			wg.Done()
 			// Lets look for the last task to shutdown, the result-writer or projection
-			// will finish first on limit so we need to shutdown sources
+			// will finish first on limit so we need to shutdown sources.  Done only
+			// after, so Run doesn't return while these are still closing.
 			if len(m.runners)-1 == taskId {
 				//u.Warnf("%p got shutdown on last one, lets shutdown them all", m)
 				for i := len(m.runners) - 2; i >= 0; i-- {
@@ -166,6 +166,7 @@ func (m *TaskSequential) Run() (err error) {
 					//u.Debugf("%p after close??: %v %T", m, i, m.runners[i])
 				}
 			}
+			wg.Done()
 		}(i)
 	}
 
@@ -0,0 +1,89 @@
+package exec
+
+import (
+	"container/heap"
+	"sort"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*TopN)(nil)
+)
+
+// TopN an ORDER BY with a LIMIT, keeps only the first Limit rows in order
+// in a bounded heap instead of holding and sorting every row.
+//
+//    SELECT * FROM events ORDER BY ts DESC LIMIT 10;
+//
+type TopN struct {
+	*Order
+}
+
+// NewTopN create a TopN task for order p, p.Limit must be > 0.
+func NewTopN(ctx *plan.Context, p *plan.Order) *TopN {
+	return &TopN{Order: NewOrder(ctx, p)}
+}
+
+// Run the TopN, rows are output in order once the input is read.
+func (m *TopN) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	outCh := m.MessageOut()
+	inCh := m.MessageIn()
+
+	colIndex := m.p.Stmt.ColIndexes()
+
+	// max-heap of the kept rows, its root the last of them in order
+	top := &topHeap{NewOrderMessages(m.Ctx, m.p)}
+
+msgReadLoop:
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-inCh:
+			if !ok {
+				break msgReadLoop
+			}
+			mk, err := m.orderKey(msg, colIndex)
+			if err != nil {
+				close(m.TaskBase.sigCh)
+				return err
+			}
+			heap.Push(top, mk)
+			if top.Len() > m.p.Limit {
+				heap.Pop(top)
+			}
+		}
+	}
+
+	sort.Sort(top.OrderMessages)
+	u.Debugf("top %d of rows", top.Len())
+
+	for _, mk := range top.l {
+		outCh <- mk.msg
+	}
+
+	m.isComplete = true
+	close(m.complete)
+
+	return nil
+}
+
+// topHeap messages in a heap of reverse order.
+type topHeap struct {
+	*OrderMessages
+}
+
+func (m *topHeap) Less(i, j int) bool { return m.OrderMessages.Less(j, i) }
+func (m *topHeap) Push(x interface{}) { m.l = append(m.l, x.(*msgkey)) }
+func (m *topHeap) Pop() interface{} {
+	last := m.l[len(m.l)-1]
+	m.l = m.l[:len(m.l)-1]
+	return last
+}
@@ -0,0 +1,60 @@
+package exec_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+)
+
+func TestTopN(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "topn_events", `id,kind,score
+1,a,7
+2,b,3
+3,a,9
+4,c,
+5,b,5
+6,a,1`)
+
+	for _, q := range []struct {
+		order string
+		limit int
+	}{
+		{"score DESC", 2},
+		{"score ASC", 3},
+		{"kind ASC, score DESC", 4},
+		{"kind DESC, id ASC", 10},
+	} {
+		all, err := runSession(t, nil, fmt.Sprintf("SELECT id FROM topn_events ORDER BY %s", q.order))
+		assert.Equal(t, nil, err)
+		top, err := runSession(t, nil, fmt.Sprintf("SELECT id FROM topn_events ORDER BY %s LIMIT %d", q.order, q.limit))
+		assert.Equal(t, nil, err)
+		if q.limit < len(all) {
+			all = all[:q.limit]
+		}
+		assert.Equal(t, all, top, q.order)
+	}
+
+	topOrder := func(sql string) *plan.Order {
+		ctx := plan.NewContext(sql)
+		ctx.Schema = mockcsv.Schema()
+		stmt, err := rel.ParseSql(sql)
+		assert.Equal(t, nil, err)
+		p, err := plan.WalkStmt(ctx, stmt, plan.NewPlanner(ctx))
+		assert.Equal(t, nil, err)
+		for _, task := range p.Children() {
+			if o, ok := task.(*plan.Order); ok {
+				return o
+			}
+		}
+		return nil
+	}
+	assert.Equal(t, 2, topOrder(`SELECT id FROM topn_events ORDER BY score LIMIT 2`).Limit)
+	// distinct rows are only known after the order, so all rows are sorted
+	assert.Equal(t, 0, topOrder(`SELECT DISTINCT kind FROM topn_events ORDER BY kind LIMIT 2`).Limit)
+	assert.Equal(t, 0, topOrder(`SELECT id FROM topn_events ORDER BY score`).Limit)
+}
@@ -0,0 +1,124 @@
+package exec
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure our ValuesSource implements schema.Source
+	_ schema.Source = (*ValuesSource)(nil)
+)
+
+// ValuesSource is the inline table of literal rows of a VALUES row
+// constructor, for tests, lookups and small dimension joins without
+// creating a table.
+//
+//    SELECT * FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name)
+//
+//    id  name
+//    1   a
+//    2   b
+//
+// The row expressions are evaluated once, when the source is created, the
+// type of each column is that of its first non-nil value.
+type ValuesSource struct {
+	tbl  *schema.Table
+	rows [][]driver.Value
+}
+
+// NewValuesSource create the table name of the rows of vals.
+func NewValuesSource(name string, vals *rel.SqlValues) (*ValuesSource, error) {
+	if len(vals.Rows) == 0 {
+		return nil, fmt.Errorf("VALUES must have at least one row")
+	}
+	cols := vals.ColumnNames()
+	types := make([]value.ValueType, len(cols))
+	m := &ValuesSource{tbl: schema.NewTable(name), rows: make([][]driver.Value, 0, len(vals.Rows))}
+	for _, vrow := range vals.Rows {
+		if len(vrow) != len(cols) {
+			return nil, fmt.Errorf("VALUES rows must all have %d columns", len(cols))
+		}
+		row := make([]driver.Value, len(vrow))
+		for i, col := range vrow {
+			v := col.Value
+			if col.Expr != nil {
+				ev, ok := vm.Eval(nil, col.Expr)
+				if !ok || ev.Type() == value.ErrorType {
+					return nil, fmt.Errorf("could not evaluate VALUES %s", col.Expr)
+				}
+				v = ev
+			}
+			if v == nil || v.Nil() {
+				continue
+			}
+			row[i] = v.Value()
+			if types[i] == value.NilType {
+				types[i] = v.Type()
+			}
+		}
+		m.rows = append(m.rows, row)
+	}
+	for i, col := range cols {
+		if types[i] == value.NilType {
+			types[i] = value.UnknownType
+		}
+		m.tbl.AddFieldType(col, types[i])
+	}
+	m.tbl.SetColumnsFromFields()
+	return m, nil
+}
+
+// Init the values source.
+func (m *ValuesSource) Init() {}
+
+// Setup the values source.
+func (m *ValuesSource) Setup(*schema.Schema) error { return nil }
+
+// Close the values source.
+func (m *ValuesSource) Close() error { return nil }
+
+// Tables list, the single values table.
+func (m *ValuesSource) Tables() []string { return []string{m.tbl.Name} }
+
+// Table the values table schema.
+func (m *ValuesSource) Table(table string) (*schema.Table, error) { return m.tbl, nil }
+
+// Open a scanner of the rows.
+func (m *ValuesSource) Open(table string) (schema.Conn, error) {
+	return &rowsConn{cols: m.tbl.Columns(), colIdx: m.tbl.FieldNamesPositions(), rows: m.rows}, nil
+}
+
+// rewriteValues replace each VALUES table of the select with a
+// ValuesSource table of its rows, added to the (statement only) schema of
+// ctx, or the only table of a new schema if ctx has none.  The table keeps
+// its alias so columns qualified by it are unchanged.
+func rewriteValues(ctx *plan.Context, sel *rel.SqlSelect) error {
+	for i, from := range sel.From {
+		if from.Values == nil {
+			continue
+		}
+		if from.Alias == "" {
+			from.Alias = fmt.Sprintf("values_%d", i+1)
+		}
+		src, err := NewValuesSource(from.Alias+"_values", from.Values)
+		if err != nil {
+			return err
+		}
+		if ctx.Schema == nil {
+			ctx.Schema = schema.NewSchemaTable("values", src.tbl, src)
+		} else {
+			ctx.Schema = ctx.Schema.WithTable(src.tbl, src)
+		}
+		from.Name = src.tbl.Name
+		from.Schema = ""
+		from.Values = nil
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func runSession(t *testing.T, session expr.ContextReadWriter, sql string) ([][]interface{}, error) {
+	ctx := td.TestContext(sql)
+	ctx.Session = session
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+	assert.Equal(t, nil, job.Setup())
+	if err = job.Run(); err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, 0, len(msgs))
+	for _, msg := range msgs {
+		var row []interface{}
+		if mm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+			for _, v := range mm.Values() {
+				row = append(row, v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func TestSessionVariables(t *testing.T) {
+	s1 := datasource.NewMySqlSessionVars()
+	s2 := datasource.NewMySqlSessionVars()
+
+	// user variables in projection and where
+	_, err := runSession(t, s1, `SET @email = "bob@email.com", @n = 5`)
+	assert.Equal(t, nil, err)
+	rows, err := runSession(t, s1, `SELECT user_id, @n FROM users WHERE email = @email`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"hT2impsOPUREcVPc", int64(5)}}, rows)
+	// not visible in other sessions
+	rows, err = runSession(t, s2, `SELECT user_id FROM users WHERE email = @email`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(rows))
+
+	// session system variables are validated, and shown by SHOW VARIABLES
+	_, err = runSession(t, s1, `SET SESSION sql_mode = 'strict_all_tables'`)
+	assert.Equal(t, nil, err)
+	_, err = runSession(t, s1, `SET SESSION null_order = 'sideways'`)
+	assert.NotEqual(t, nil, err)
+	_, err = runSession(t, s1, `SET @@session.not_a_variable = 1`)
+	assert.NotEqual(t, nil, err)
+	rows, err = runSession(t, s1, `SHOW VARIABLES LIKE 'sql_mode'`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"sql_mode", "STRICT_ALL_TABLES"}}, rows)
+	rows, err = runSession(t, s2, `SHOW SESSION VARIABLES LIKE 'sql_mode'`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"sql_mode", "NO_ENGINE_SUBSTITUTION"}}, rows)
+	rows, err = runSession(t, s1, `SELECT @@sql_mode, @@global.sql_mode`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"STRICT_ALL_TABLES", "NO_ENGINE_SUBSTITUTION"}}, rows)
+
+	// strict mode rejects values that don't convert to the column type
+	_, err = runSession(t, s1, `INSERT INTO users (user_id, email, interests, reg_date, referral_count, json_data)
+		VALUES ("strict1", "s@email.com", "x", "2014-01-01", "not a number", "{}")`)
+	assert.NotEqual(t, nil, err)
+
+	// global variables
+	_, err = runSession(t, s2, `SET GLOBAL wait_timeout = 60`)
+	assert.Equal(t, nil, err)
+	defer datasource.SetGlobalVar("wait_timeout", nil)
+	rows, err = runSession(t, s1, `SHOW GLOBAL VARIABLES LIKE 'wait_timeout'`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{"wait_timeout", int64(60)}}, rows)
+	_, err = runSession(t, s1, `SET GLOBAL license = 'GPL'`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestUserVariableAssignment(t *testing.T) {
+	ses := datasource.NewMySqlSessionVars()
+
+	_, err := runSession(t, ses, `SET @row = 0`)
+	assert.Equal(t, nil, err)
+	rows, err := runSession(t, ses, `SELECT @row := @row + 1 AS rn, user_id FROM users`)
+	assert.Equal(t, nil, err)
+	assert.True(t, len(rows) > 1)
+	for i, row := range rows {
+		assert.Equal(t, int64(i+1), row[0])
+	}
+	// later columns see the assigned value
+	rows, err = runSession(t, ses, `SELECT @a := 5 AS a, @a + 1 AS b`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{int64(5), int64(6)}}, rows)
+	v, _ := ses.Get("@row")
+	assert.True(t, v.Value().(int64) > 1)
+
+	// SELECT ... INTO @var returns no rows
+	rows, err = runSession(t, ses, `SELECT email INTO @e FROM users WHERE user_id = "hT2impsOPUREcVPc"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(rows))
+	v, _ = ses.Get("@e")
+	assert.Equal(t, "bob@email.com", v.ToString())
+	rows, err = runSession(t, ses, `SELECT user_id, referral_count FROM users WHERE email = @e LIMIT 1 INTO @uid, @rc`)
+	assert.Equal(t, nil, err)
+	v, _ = ses.Get("@uid")
+	assert.Equal(t, "hT2impsOPUREcVPc", v.ToString())
+
+	_, err = runSession(t, ses, `SELECT email INTO @e FROM users`)
+	assert.Equal(t, exec.ErrIntoTooManyRows, err)
+	_, err = runSession(t, ses, `SELECT email, user_id INTO @e FROM users WHERE user_id = "hT2impsOPUREcVPc"`)
+	assert.Equal(t, exec.ErrIntoColumnCount, err)
+}
+
+func TestSessionSelectLimit(t *testing.T) {
+	ses := datasource.NewMySqlSessionVars()
+	_, err := runSession(t, ses, `SET SESSION sql_select_limit = 2`)
+	assert.Equal(t, nil, err)
+
+	run := func(sql string) ([][]driver.Value, []string) {
+		ctx := td.TestContext(sql)
+		ctx.Session = ses
+		res, err := exec.RunBatch(ctx, sql, false)
+		assert.Equal(t, nil, err)
+		return res[0].Rows, res[0].Warnings
+	}
+
+	// unbounded select is limited, with a warning
+	rows, warnings := run(`SELECT user_id FROM users`)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, 1, len(warnings))
+	// statement LIMIT overrides
+	rows, warnings = run(`SELECT user_id FROM users LIMIT 3`)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, 0, len(warnings))
+	// single row aggregates are not limited
+	rows, warnings = run(`SELECT count(*) FROM users`)
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, 0, len(warnings))
+
+	// 0 is no limit
+	_, err = runSession(t, ses, `SET SESSION sql_select_limit = 0`)
+	assert.Equal(t, nil, err)
+	rows, warnings = run(`SELECT user_id FROM users`)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, 0, len(warnings))
+}
+
+func TestSessionEncryptionKey(t *testing.T) {
+	s := datasource.NewMySqlSessionVars()
+	_, err := runSession(t, s, `SET @pii_key = "correct horse battery staple"`)
+	assert.Equal(t, nil, err)
+
+	// columns encrypted with a key only the session holds
+	rows, err := runSession(t, s, `SELECT user_id, aes_encrypt(email, @pii_key) AS email FROM users WHERE user_id = "hT2impsOPUREcVPc"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(rows))
+	sealed := rows[0][1].(string)
+	assert.NotEqual(t, "bob@email.com", sealed)
+
+	rows, err = runSession(t, s, fmt.Sprintf(`SELECT aes_decrypt(%q, @pii_key) AS email, hmac_sha256(email, @pii_key) AS email_id FROM users WHERE user_id = "hT2impsOPUREcVPc"`, sealed))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bob@email.com", rows[0][0])
+	assert.Equal(t, 64, len(rows[0][1].(string)))
+
+	// without the key nothing is decrypted
+	rows, err = runSession(t, datasource.NewMySqlSessionVars(), fmt.Sprintf(`SELECT aes_decrypt(%q, @pii_key) AS email FROM users WHERE user_id = "hT2impsOPUREcVPc"`, sealed))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]interface{}{{nil}}, rows)
+}
@@ -1,6 +1,9 @@
 package exec
 
 import (
+	"fmt"
+	"strings"
+
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource"
@@ -22,10 +25,15 @@ type Where struct {
 // NewWhere create new Where Clause
 //  filters vs final differ bc the Final does final column aliasing
 func NewWhere(ctx *plan.Context, p *plan.Where) *Where {
+	var s *Where
 	if p.Final {
-		return NewWhereFinal(ctx, p)
+		s = NewWhereFinal(ctx, p)
+	} else {
+		s = NewWhereFilter(ctx, p.Stmt)
 	}
-	return NewWhereFilter(ctx, p.Stmt)
+	// PARALLEL(n) hint, the filter is evaluated by n go routines
+	s.Workers = p.Workers
+	return s
 }
 
 func NewWhereFinal(ctx *plan.Context, p *plan.Where) *Where {
@@ -87,20 +95,40 @@ func NewHaving(ctx *plan.Context, p *plan.Having) *Where {
 func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) MessageHandler {
 	out := task.MessageOut()
 
+	// filters referencing @variables read them from the session
+	usesVars := false
+	for _, name := range expr.FindAllIdentityField(filter) {
+		if strings.HasPrefix(name, "@") {
+			usesVars = true
+		}
+	}
+	// WHERE MATCH(col) AGAINST ("terms")   filters on relevance > 0
+	fn, isFunc := filter.(*expr.FuncNode)
+	isMatch := isFunc && strings.ToLower(fn.Name) == expr.MatchAgainstFunc
+	withSession := func(ctx *plan.Context, rdr expr.ContextReader) expr.ContextReader {
+		if !usesVars || ctx.Session == nil {
+			return rdr
+		}
+		return datasource.NewNestedContextReader([]expr.ContextReader{rdr, ctx.Session}, rdr.Ts())
+	}
+
 	//u.Debugf("prepare filter %s", filter)
 	return func(ctx *plan.Context, msg schema.Message) bool {
 
 		var filterValue value.Value
 		var ok bool
+		var rdr expr.ContextReader
 		//u.Debugf("WHERE:  T:%T  body%#v", msg, msg.Body())
 		switch mt := msg.(type) {
 		case *datasource.SqlDriverMessage:
 			//u.Debugf("WHERE:  T:%T  vals:%#v", msg, mt.Vals)
 			//u.Debugf("cols:  %#v", cols)
 			msgReader := mt.ToMsgMap(cols)
-			filterValue, ok = vm.Eval(msgReader, filter)
+			rdr = msgReader
+			filterValue, ok = vm.Eval(withSession(ctx, msgReader), filter)
 		case *datasource.SqlDriverMessageMap:
-			filterValue, ok = vm.Eval(mt, filter)
+			rdr = mt
+			filterValue, ok = vm.Eval(withSession(ctx, mt), filter)
 			if !ok {
 				u.Warnf("wtf %s    %#v", filter, mt)
 			}
@@ -108,7 +136,8 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 			//u.Debugf("cols:  %#v", cols)
 		default:
 			if msgReader, isContextReader := msg.(expr.ContextReader); isContextReader {
-				filterValue, ok = vm.Eval(msgReader, filter)
+				rdr = msgReader
+				filterValue, ok = vm.Eval(withSession(ctx, msgReader), filter)
 				if !ok {
 					u.Warnf("wat? %v  filterval:%#v expr: %s", filter.String(), filterValue, filter)
 				}
@@ -120,6 +149,12 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 		//u.Infof("evaluating: ok?%v  result=%v filter expr: '%s'", ok, filterValue.ToString(), filter.String())
 		if !ok {
 			u.Debugf("could not evaluate: %T %#v", msg, msg)
+			// streaming queries drop, dead-letter or halt on the row
+			if streamsRowErrors(ctx) {
+				if err := rowError(ctx, "where", rdr, fmt.Errorf("could not evaluate %s", filter)); err != nil {
+					haltTask(task, err)
+				}
+			}
 			return false
 		}
 		switch valTyped := filterValue.(type) {
@@ -128,6 +163,10 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 				//u.Debugf("Filtering out: T:%T   v:%#v", valTyped, valTyped)
 				return true
 			}
+		case value.NumberValue:
+			if isMatch && valTyped.Val() <= 0 {
+				return true
+			}
 		case nil:
 			return false
 		default:
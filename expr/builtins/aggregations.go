@@ -155,3 +155,324 @@ func incrementEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool)
 	}
 	return value.NewIntValue(1), true
 }
+
+// CountIf count of values where the condition is true, the conditional
+// form of count(*) ie the same as count(*) FILTER (WHERE cond).
+//
+//    count_if(price > 10)  =>  1, true
+//    count_if(price < 10)  =>  nil, false
+//
+type CountIf struct{}
+
+// Type is Integer
+func (m *CountIf) Type() value.ValueType { return value.IntType }
+func (m *CountIf) IsAgg() bool           { return true }
+
+func (m *CountIf) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for count_if(cond) but got %s", n)
+	}
+	return countIfEval, nil
+}
+
+func countIfEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if !condTrue(vals[0]) {
+		return value.NewNilValue(), false
+	}
+	return value.NewIntValue(1), true
+}
+
+// SumIf sum of the values where the condition is true, the per value
+// eval is the numeric value if the condition is true.
+//
+//    sum_if(price, status = "paid")  =>  value of price, or nil, false
+//    sum_if("1.5", true)             =>  1.5
+//
+type SumIf struct{}
+
+// Type is number
+func (m *SumIf) Type() value.ValueType { return value.NumberType }
+func (m *SumIf) IsAgg() bool           { return true }
+
+func (m *SumIf) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for sum_if(arg, cond) but got %s", n)
+	}
+	return numberIfEval, nil
+}
+
+// AvgIf average of the values where the condition is true, rows the
+// condition is false for are not in the average.
+//
+//    avg_if(price, status = "paid")  =>  value of price, or nil, false
+//
+type AvgIf struct{}
+
+// Type is number
+func (m *AvgIf) Type() value.ValueType { return value.NumberType }
+func (m *AvgIf) IsAgg() bool           { return true }
+
+func (m *AvgIf) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for avg_if(arg, cond) but got %s", n)
+	}
+	return numberIfEval, nil
+}
+
+func numberIfEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if !condTrue(vals[1]) || vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return value.NewNilValue(), false
+	}
+	fv, ok := value.ValueToFloat64(vals[0])
+	if !ok || math.IsNaN(fv) {
+		return value.NewNilValue(), false
+	}
+	return value.NewNumberValue(fv), true
+}
+
+func condTrue(v value.Value) bool {
+	if v == nil || v.Err() || v.Nil() {
+		return false
+	}
+	b, ok := value.ValueToBool(v)
+	return ok && b
+}
+
+// GroupConcat concatenation of the values of a group, optionally distinct,
+// ordered and with a separator (default ",").  The group by evaluates the
+// value and order by keys of each row to order, de-duplicate and join
+// them, per row the eval is the value as a string.
+//
+//    group_concat(DISTINCT name ORDER BY name DESC SEPARATOR "; ")
+//    string_agg(name, ", " ORDER BY created)
+//
+//    string_agg("bob", ", " ORDER BY 2)  =>  "bob"
+//
+type GroupConcat struct{}
+
+// Type is string
+func (m *GroupConcat) Type() value.ValueType { return value.StringType }
+func (m *GroupConcat) IsAgg() bool           { return true }
+
+func (m *GroupConcat) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 3 || len(n.Args)%2 != 1 {
+		return nil, fmt.Errorf("Expected group_concat(expr [ORDER BY expr] [SEPARATOR sep]) but got %s", n)
+	}
+	if _, ok := n.Args[1].(*expr.StringNode); !ok {
+		return nil, fmt.Errorf("Expected string literal separator for %s", n)
+	}
+	return groupConcatEval, nil
+}
+
+func groupConcatEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return value.NewNilValue(), false
+	}
+	return value.NewStringValue(vals[0].ToString()), true
+}
+
+// Stat statistical aggregates of a numeric value, variance, stddev and
+// their _samp (sample, the default) and _pop (population) forms, median.
+// The group by holds the (mergeable) state, per value this is the value
+// as a number.
+//
+//    stddev(price)      =>  value of price
+//    var_pop("1.5")     =>  1.5
+//
+type Stat struct{}
+
+// Type is number
+func (m *Stat) Type() value.ValueType { return value.NumberType }
+func (m *Stat) IsAgg() bool           { return true }
+
+func (m *Stat) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for %s(arg) but got %s", n.Name, n)
+	}
+	return numberEval, nil
+}
+
+func numberEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return value.NewNilValue(), false
+	}
+	fv, ok := value.ValueToFloat64(vals[0])
+	if !ok || math.IsNaN(fv) {
+		return value.NewNilValue(), false
+	}
+	return value.NewNumberValue(fv), true
+}
+
+// Percentile exact percentile p (0-1) of the values, percentile_cont
+// interpolates between values, percentile_disc is the first value at or
+// above the percentile.
+//
+//    percentile_cont(price, 0.9)  =>  value of price
+//
+type Percentile struct{}
+
+// Type is number
+func (m *Percentile) Type() value.ValueType { return value.NumberType }
+func (m *Percentile) IsAgg() bool           { return true }
+
+func (m *Percentile) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for %s(arg, percentile) but got %s", n.Name, n)
+	}
+	p, ok := n.Args[1].(*expr.NumberNode)
+	if !ok || p.Float64 < 0 || p.Float64 > 1 {
+		return nil, fmt.Errorf("Expected percentile literal between 0 and 1 for %s(arg, percentile) but got %s", n.Name, n)
+	}
+	return numberEval, nil
+}
+
+// Covariance corr, covar_samp, covar_pop of pairs of numeric values, per
+// value this is the pair, nil if either isn't a number.
+//
+//    corr(price, item_count)  =>  [price, item_count]
+//
+type Covariance struct{}
+
+// Type is number
+func (m *Covariance) Type() value.ValueType { return value.NumberType }
+func (m *Covariance) IsAgg() bool           { return true }
+
+func (m *Covariance) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for %s(x, y) but got %s", n.Name, n)
+	}
+	return covarianceEval, nil
+}
+
+func covarianceEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	pair := make([]value.Value, 2)
+	for i, v := range vals {
+		x, ok := numberEval(ctx, []value.Value{v})
+		if !ok {
+			return value.NewNilValue(), false
+		}
+		pair[i] = x
+	}
+	return value.NewSliceValues(pair), true
+}
+
+// First first() and last() value of the group in input order, nulls are
+// skipped.  Per value this just passes the value through.
+//
+//    first(status)  =>  value of status
+//
+type First struct{}
+
+// Type is unknown, that of the arg
+func (m *First) Type() value.ValueType { return value.UnknownType }
+func (m *First) IsAgg() bool           { return true }
+
+func (m *First) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for %s(arg) but got %s", n.Name, n)
+	}
+	return approxValueEval, nil
+}
+
+// ArgMax arg_max(value, by) and arg_min(value, by) the value of the row
+// with the largest (smallest) by, ie the latest record per group.  Per
+// value this is the pair, nil if by is nil.
+//
+//    arg_max(status, updated)  =>  [status, updated]
+//
+type ArgMax struct{}
+
+// Type is unknown, that of the value arg
+func (m *ArgMax) Type() value.ValueType { return value.UnknownType }
+func (m *ArgMax) IsAgg() bool           { return true }
+
+func (m *ArgMax) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for %s(value, by) but got %s", n.Name, n)
+	}
+	return argMaxEval, nil
+}
+
+func argMaxEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[1] == nil || vals[1].Err() || vals[1].Nil() {
+		return value.NewNilValue(), false
+	}
+	v := vals[0]
+	if v == nil || v.Err() {
+		v = value.NewNilValue()
+	}
+	return value.NewSliceValues([]value.Value{v, vals[1]}), true
+}
+
+// ApproxCountDistinct approximate number of distinct values, the sketch
+// (HyperLogLog) is the responsibility of the group by, per value this
+// just passes the value through.
+//
+//    approx_count_distinct(user_id)  =>  value of user_id
+//
+type ApproxCountDistinct struct{}
+
+// Type is Integer
+func (m *ApproxCountDistinct) Type() value.ValueType { return value.IntType }
+func (m *ApproxCountDistinct) IsAgg() bool           { return true }
+
+func (m *ApproxCountDistinct) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for approx_count_distinct(arg) but got %s", n)
+	}
+	return approxValueEval, nil
+}
+
+// ApproxQuantile approximate value at quantile q (0-1) of the values,
+// the sketch (t-digest) is the responsibility of the group by, per value
+// this just passes the value through.
+//
+//    approx_quantile(price, 0.5)   =>  value of price
+//    approx_quantile(price, 0.99)  =>  value of price
+//
+type ApproxQuantile struct{}
+
+// Type is number
+func (m *ApproxQuantile) Type() value.ValueType { return value.NumberType }
+func (m *ApproxQuantile) IsAgg() bool           { return true }
+
+func (m *ApproxQuantile) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for approx_quantile(arg, quantile) but got %s", n)
+	}
+	q, ok := n.Args[1].(*expr.NumberNode)
+	if !ok || q.Float64 < 0 || q.Float64 > 1 {
+		return nil, fmt.Errorf("Expected quantile literal between 0 and 1 for approx_quantile(arg, quantile) but got %s", n)
+	}
+	return approxValueEval, nil
+}
+
+// ApproxTopK approximate k most frequent values, most frequent first,
+// the sketch (space-saving) is the responsibility of the group by, per
+// value this just passes the value through.
+//
+//    approx_top_k(country, 3)  =>  value of country
+//
+type ApproxTopK struct{}
+
+// Type is strings
+func (m *ApproxTopK) Type() value.ValueType { return value.StringsType }
+func (m *ApproxTopK) IsAgg() bool           { return true }
+
+func (m *ApproxTopK) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for approx_top_k(arg, k) but got %s", n)
+	}
+	k, ok := n.Args[1].(*expr.NumberNode)
+	if !ok || !k.IsInt || k.Int64 < 1 {
+		return nil, fmt.Errorf("Expected positive integer literal k for approx_top_k(arg, k) but got %s", n)
+	}
+	return approxValueEval, nil
+}
+
+func approxValueEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Err() || vals[0].Nil() {
+		return value.NewNilValue(), false
+	}
+	return vals[0], true
+}
@@ -29,6 +29,26 @@ func LoadAllBuiltins() {
 		expr.FuncAdd("count", &Count{})
 		expr.FuncAdd("avg", &Avg{})
 		expr.FuncAdd("sum", &Sum{})
+		expr.FuncAdd("count_if", &CountIf{})
+		expr.FuncAdd("sum_if", &SumIf{})
+		expr.FuncAdd("avg_if", &AvgIf{})
+		expr.FuncAdd("group_concat", &GroupConcat{})
+		expr.FuncAdd("string_agg", &GroupConcat{})
+		for _, name := range []string{"variance", "var_samp", "var_pop", "stddev", "stddev_samp", "stddev_pop", "median"} {
+			expr.FuncAdd(name, &Stat{})
+		}
+		expr.FuncAdd("percentile_cont", &Percentile{})
+		expr.FuncAdd("percentile_disc", &Percentile{})
+		expr.FuncAdd("corr", &Covariance{})
+		expr.FuncAdd("covar_samp", &Covariance{})
+		expr.FuncAdd("covar_pop", &Covariance{})
+		expr.FuncAdd("first", &First{})
+		expr.FuncAdd("last", &First{})
+		expr.FuncAdd("arg_max", &ArgMax{})
+		expr.FuncAdd("arg_min", &ArgMax{})
+		expr.FuncAdd("approx_count_distinct", &ApproxCountDistinct{})
+		expr.FuncAdd("approx_quantile", &ApproxQuantile{})
+		expr.FuncAdd("approx_top_k", &ApproxTopK{})
 
 		// logical
 		expr.FuncAdd("gt", &Gt{})
@@ -75,15 +95,31 @@ func LoadAllBuiltins() {
 		expr.FuncAdd("string.lowercase", &LowerCase{})
 		expr.FuncAdd("string.uppercase", &UpperCase{})
 		expr.FuncAdd("string.titlecase", &TitleCase{})
+		expr.FuncAdd("lower", &LowerCase{})
+		expr.FuncAdd("upper", &UpperCase{})
+		expr.FuncAdd("toupper", &UpperCase{})
+		expr.FuncAdd("reverse", &Reverse{})
+		expr.FuncAdd("substr", &Substr{})
+		expr.FuncAdd("substring", &Substr{})
+		expr.FuncAdd("normalize", &Normalize{})
 		expr.FuncAdd("split", &Split{})
+		expr.FuncAdd("tokenize", &Tokenize{})
+		expr.FuncAdd("levenshtein", &Levenshtein{})
+		expr.FuncAdd("soundex", &Soundex{})
+		expr.FuncAdd("jaro_winkler", &JaroWinkler{})
+		expr.FuncAdd("similarity", &Similarity{})
+		expr.FuncAdd(expr.MatchAgainstFunc, &MatchAgainst{})
 		expr.FuncAdd("strip", &Strip{})
 		expr.FuncAdd("replace", &Replace{})
 		expr.FuncAdd("join", &Join{})
+		expr.FuncAdd("concat", &Concat{})
 		expr.FuncAdd("hassuffix", &HasSuffix{})
 		expr.FuncAdd("hasprefix", &HasPrefix{})
 
 		// array, string
 		expr.FuncAdd("len", &Length{})
+		expr.FuncAdd("length", &ByteLength{})
+		expr.FuncAdd("octet_length", &ByteLength{})
 		expr.FuncAdd("array.index", &ArrayIndex{})
 		expr.FuncAdd("array.slice", &ArraySlice{})
 
@@ -111,11 +147,21 @@ func LoadAllBuiltins() {
 		expr.FuncAdd("urlmain", &UrlMain{})
 		expr.FuncAdd("urlminusqs", &UrlMinusQs{})
 		expr.FuncAdd("urldecode", &UrlDecode{})
+		expr.FuncAdd("url_decode", &UrlDecode{})
+		expr.FuncAdd("url_parse", &UrlParse{})
 		expr.FuncAdd("url.matchqs", &UrlWithQuery{})
 		expr.FuncAdd("useragent.map", &UserAgentMap{})
 		expr.FuncAdd("useragent", &UserAgent{})
+		expr.FuncAdd("user_agent", &UserAgentParse{})
 		expr.FuncAdd("uuid", &UuidGenerate{})
 
+		// ip address functions
+		expr.FuncAdd("inet_aton", &InetAton{})
+		expr.FuncAdd("inet_ntoa", &InetNtoa{})
+		expr.FuncAdd("is_ipv4", &IsIpv4{})
+		expr.FuncAdd("is_ipv6", &IsIpv6{})
+		expr.FuncAdd("cidr_contains", &CidrContains{})
+
 		// Hashing functions
 		expr.FuncAdd("hash", &HashSip{})
 		expr.FuncAdd("hash.sip", &HashSip{})
@@ -123,12 +169,27 @@ func LoadAllBuiltins() {
 		expr.FuncAdd("hash.sha1", &HashSha1{})
 		expr.FuncAdd("hash.sha256", &HashSha256{})
 		expr.FuncAdd("hash.sha512", &HashSha512{})
+		expr.FuncAdd("sha256", &HashSha256{})
+		expr.FuncAdd("sha512", &HashSha512{})
+		expr.FuncAdd("sha2", &HashSha2{})
+		expr.FuncAdd("hmac_sha256", &HmacSha256{})
 
 		expr.FuncAdd("encoding.b64encode", &EncodeB64Encode{})
 		expr.FuncAdd("encoding.b64decode", &EncodeB64Decode{})
+		expr.FuncAdd("to_base64", &EncodeB64Encode{})
+		expr.FuncAdd("from_base64", &EncodeB64Decode{})
+		expr.FuncAdd("hex", &Hex{})
+		expr.FuncAdd("unhex", &Unhex{})
+
+		// encryption
+		expr.FuncAdd("aes_encrypt", &AesEncrypt{})
+		expr.FuncAdd("aes_decrypt", &AesDecrypt{})
 
 		// json
 		expr.FuncAdd("json.jmespath", &JsonPath{})
+		expr.FuncAdd("jsonpath", &JsonPathQuery{})
+		expr.FuncAdd("jsonpath_exists", &JsonPathExists{})
+		expr.FuncAdd("jq", &Jq{})
 
 		// MySQL Builtins
 		expr.FuncAdd("cast", &Cast{})
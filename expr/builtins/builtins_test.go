@@ -3,6 +3,7 @@ package builtins
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"testing"
@@ -383,10 +384,31 @@ var builtinTests = []testBuiltins{
 	{`string.titlecase("android nexus")`, value.NewStringValue("Android Nexus")},
 	{`string.titlecase("android")`, value.NewStringValue("Android")},
 	{`string.titlecase(Address)`, value.ErrValue},
+	{`upper("ñandú")`, value.NewStringValue("ÑANDÚ")},
+	{`lower("ÑANDÚ ΣΟΦΙΑ")`, value.NewStringValue("ñandú σοφια")},
+	{`len("ñandú")`, value.NewIntValue(5)},
+	{`len("日本語")`, value.NewIntValue(3)},
+	{`reverse("abc")`, value.NewStringValue("cba")},
+	{`reverse("日本語")`, value.NewStringValue("語本日")},
+	{`reverse("cafe` + "\u0301" + `")`, value.NewStringValue("e\u0301fac")}, // accent stays on its e
+	{`reverse(Address)`, value.ErrValue},
+	{`substr("apples", 2)`, value.NewStringValue("pples")},
+	{`substr("apples", 2, 3)`, value.NewStringValue("ppl")},
+	{`substr("apples", -3)`, value.NewStringValue("les")},
+	{`substr("apples", 10)`, value.NewStringValue("")},
+	{`substring("日本語です", 2, 2)`, value.NewStringValue("本語")},
+	{`substr(Address, 1)`, value.ErrValue},
+	{`len(normalize("caf` + "\u00e9" + `"))`, value.NewIntValue(4)},
+	{`len(normalize("caf` + "\u00e9" + `", "NFD"))`, value.NewIntValue(5)},
+	{`normalize("cafe` + "\u0301" + `") == "caf` + "\u00e9" + `"`, value.BoolValueTrue},
+	{`normalize("ﬁ", "nfkc")`, value.NewStringValue("fi")},
 
 	{`join("apple", event, "oranges", "--")`, value.NewStringValue("apple--hello--oranges")},
 	{`join(["apple","peach"], ",")`, value.NewStringValue("apple,peach")},
 	{`join("apple","","peach",",")`, value.NewStringValue("apple,peach")},
+	{`concat("apple", "peach")`, value.NewStringValue("applepeach")},
+	{`concat("id-", 5)`, value.NewStringValue("id-5")},
+	{`concat("apple", not_a_field)`, nil},
 	{`join(split("apple,peach",","),"--")`, value.NewStringValue("apple--peach")},
 	{`join("hello",Address)`, value.ErrValue},
 	{`join(Address,"--")`, value.ErrValue},
@@ -396,6 +418,36 @@ var builtinTests = []testBuiltins{
 	{`split("",",")`, value.ErrValue},
 	{`split("hello","")`, value.ErrValue},
 
+	{`tokenize("Hello, World-2")`, value.NewStringsValue([]string{"hello", "world", "2"})},
+	{`tokenize("search", "ngram")`, value.NewStringsValue([]string{"sea", "ear", "arc", "rch"})},
+	{`tokenize("東京都 tokyo", "cjk")`, value.NewStringsValue([]string{"東京", "京都", "tokyo"})},
+	{`tokenize("Hello World", "keyword")`, value.NewStringsValue([]string{"Hello World"})},
+
+	{`levenshtein("kitten", "sitting")`, value.NewIntValue(3)},
+	{`levenshtein("abc", "abc")`, value.NewIntValue(0)},
+	{`levenshtein("", "abc")`, value.NewIntValue(3)},
+	{`levenshtein("naïve", "naive")`, value.NewIntValue(1)},
+	{`MATCH("the quick fox") AGAINST ("fox")`, value.NewNumberValue(1 / math.Sqrt(3))},
+	{`MATCH("fox and fox", "dog") AGAINST ("fox cat")`, value.NewNumberValue((1 + math.Log(2)) / 2)},
+	{`MATCH("quick dog") AGAINST ("+quick -dog")`, value.NewNumberValue(0)},
+	{`MATCH("quick dog") AGAINST ("+cat dog")`, value.NewNumberValue(0)},
+	{`MATCH("quick dog") AGAINST ("cat")`, value.NewNumberValue(0)},
+	{`soundex("Robert")`, value.NewStringValue("R163")},
+	{`soundex("Rupert")`, value.NewStringValue("R163")},
+	{`soundex("Ashcraft")`, value.NewStringValue("A261")},
+	{`soundex("Tymczak")`, value.NewStringValue("T522")},
+	{`soundex("Lee")`, value.NewStringValue("L000")},
+	{`jaro_winkler("abc", "abc")`, value.NewNumberValue(1)},
+	{`jaro_winkler("abc", "xyz")`, value.NewNumberValue(0)},
+	{`jaro_winkler("MARTHA", "MARHTA") > 0.961`, value.BoolValueTrue},
+	{`jaro_winkler("MARTHA", "MARHTA") < 0.962`, value.BoolValueTrue},
+	{`jaro_winkler("DIXON", "DICKSONX") > jaro_winkler("DIXON", "NOXID")`, value.BoolValueTrue},
+	{`similarity("word", "words")`, value.NewNumberValue(4.0 / 7)},
+	{`similarity("Word", "word")`, value.NewNumberValue(1)},
+	{`similarity("abc", "xyz")`, value.NewNumberValue(0)},
+	{`"jon smith" % "John Smith"`, value.BoolValueTrue},
+	{`"jon smith" % "mary jones"`, value.BoolValueFalse},
+
 	{`strip("apples ")`, value.NewStringValue("apples")},
 	{`strip(split("apples, oranges ",","))`, value.NewStringsValue([]string{"apples", "oranges"})},
 	{`strip(split(" apples, oranges ",","))`, value.NewStringsValue([]string{"apples", "oranges"})},
@@ -440,6 +492,35 @@ var builtinTests = []testBuiltins{
 	{`encoding.b64decode("")`, value.ErrValue},
 	{`encoding.b64decode("xx")`, value.ErrValue},
 
+	{`hex("abc")`, value.NewStringValue("616263")},
+	{`hex(255)`, value.NewStringValue("FF")},
+	{`hex(X'0a1b')`, value.NewStringValue("0A1B")},
+	{`unhex("616263")`, value.NewByteSliceValue([]byte("abc"))},
+	{`unhex("zz")`, nil},
+	{`unhex(hex("abc")) = "abc"`, value.BoolValueTrue},
+	{`X'616263' = "abc"`, value.BoolValueTrue},
+	{`X'0A1B' = X'0a1b'`, value.BoolValueTrue},
+	{`X'0A1B' < X'0A1C'`, value.BoolValueTrue},
+	{`X'0A1B' IN (X'00', X'0A1B')`, value.BoolValueTrue},
+	{`length(X'0A1B')`, value.NewIntValue(2)},
+	{`length("日本")`, value.NewIntValue(6)},
+	{`len("日本")`, value.NewIntValue(2)},
+	{`from_base64(to_base64("hello world"))`, value.NewStringValue("hello world")},
+
+	{`sha256("hello")`, value.NewStringValue("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")},
+	{`sha2("hello", 224)`, value.NewStringValue("ea09ae9cc6768c50fcee903ed054556e5bfc8347907f12598aa24193")},
+	{`sha2("hello", 0)`, value.NewStringValue("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")},
+	{`sha2("hello", 384)`, value.NewStringValue("59e1748777448c69de6b800d7a33bbfb9ff1b463e44354c3553bcdb9c666fa90125a3c79f90397bdf5f6a13de828684f")},
+	{`sha2("hello", 100)`, value.ErrValue},
+	{`hmac_sha256("The quick brown fox jumps over the lazy dog", "key")`, value.NewStringValue("f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")},
+
+	{`aes_decrypt(aes_encrypt("123-45-6789", "passphrase"), "passphrase")`, value.NewStringValue("123-45-6789")},
+	{`aes_decrypt(aes_encrypt("123-45-6789", "0123456789abcdef"), "0123456789abcdef")`, value.NewStringValue("123-45-6789")},
+	{`aes_decrypt(aes_encrypt("123-45-6789", "passphrase"), "wrong")`, value.ErrValue},
+	{`aes_encrypt("123-45-6789", "passphrase") != aes_encrypt("123-45-6789", "passphrase")`, value.BoolValueTrue},
+	{`aes_decrypt("not encrypted", "passphrase")`, value.ErrValue},
+	{`aes_encrypt("123-45-6789", "kms:no_provider")`, value.ErrValue},
+
 	// uuid
 	{`len(uuid()) > 10`, value.NewBoolValue(true)},
 
@@ -484,6 +565,20 @@ var builtinTests = []testBuiltins{
 	{`urldecode(["hello","world"])`, value.NewStringValue("hello")},
 	{`urldecode(EmptyStrings)`, value.ErrValue},
 	{`urldecode(Address)`, value.ErrValue},
+	{`url_decode("hello+world")`, value.NewStringValue("hello world")},
+
+	{`url_parse("https://www.google.com:8080/search?q=golang&Lang=en#top")`, value.NewMapStringValue(map[string]string{
+		"scheme": "https", "host": "www.google.com", "port": "8080", "path": "/search", "query": "q=golang&Lang=en",
+		"fragment": "top", "param.q": "golang", "param.Lang": "en"})},
+	{`url_parse("www.google.com/search?q=golang", "host")`, value.NewStringValue("www.google.com")},
+	{`url_parse("www.google.com/search?q=golang", "PATH")`, value.NewStringValue("/search")},
+	{`url_parse("www.google.com/search?q=golang&Lang=en", "param.q")`, value.NewStringValue("golang")},
+	{`url_parse("www.google.com/search?q=golang&Lang=en", "param.Lang")`, value.NewStringValue("en")},
+	{`url_parse(split("http://go.gl/?q=golang",","), "param.q")`, value.NewStringValue("golang")},
+	{`url_parse("www.google.com/search", "port")`, value.ErrValue},
+	{`url_parse("www.google.com/search", "param.q")`, value.ErrValue},
+	{`url_parse("http://not a url")`, value.ErrValue},
+	{`url_parse("")`, value.ErrValue},
 
 	{`domain("https://www.Google.com/search?q=golang")`, value.NewStringValue("google.com")},
 	{`domain(split("Google.com/search?q=golang,www.ign.com",","))`, value.NewStringValue("google.com")},
@@ -578,6 +673,34 @@ var builtinTests = []testBuiltins{
 	{`len(useragent.map(emptyslice()))`, value.ErrValue},
 	{`useragent.map("")`, value.ErrValue},
 
+	{`user_agent(ua)`, value.NewMapStringValue(map[string]string{"browser": "Chrome", "browser_version": "23.0.1271.97",
+		"os": "Linux x86_64", "platform": "X11", "device": "desktop"})},
+	{`user_agent("")`, value.ErrValue},
+
+	{`inet_aton("10.0.5.9")`, value.NewIntValue(167773449)},
+	{`inet_aton("255.255.255.255")`, value.NewIntValue(4294967295)},
+	{`inet_aton("::1")`, value.ErrValue},
+	{`inet_aton("not an ip")`, value.ErrValue},
+	{`inet_ntoa(167773449)`, value.NewStringValue("10.0.5.9")},
+	{`inet_ntoa(inet_aton("192.168.1.20"))`, value.NewStringValue("192.168.1.20")},
+	{`inet_ntoa(-1)`, value.ErrValue},
+	{`is_ipv4("10.0.5.9")`, value.BoolValueTrue},
+	{`is_ipv4("::ffff:10.0.5.9")`, value.BoolValueFalse},
+	{`is_ipv4("10.0.5")`, value.BoolValueFalse},
+	{`is_ipv6("::1")`, value.BoolValueTrue},
+	{`is_ipv6("2001:db8::68")`, value.BoolValueTrue},
+	{`is_ipv6("10.0.5.9")`, value.BoolValueFalse},
+	{`cidr_contains("10.0.0.0/8", "10.1.2.3")`, value.BoolValueTrue},
+	{`cidr_contains("10.0.0.0/8", "11.1.2.3")`, value.BoolValueFalse},
+	{`cidr_contains(["10.0.0.0/8", "192.168.0.0/16"], "192.168.40.1")`, value.BoolValueTrue},
+	{`cidr_contains(["10.0.0.0/8", "192.168.0.0/16"], "172.16.0.1")`, value.BoolValueFalse},
+	{`cidr_contains(["10.0.0.0/8", "10.1.0.0/16"], "10.200.0.1")`, value.BoolValueTrue},
+	{`cidr_contains(["2001:db8::/32", "172.16.0.5"], "2001:db8:1::9")`, value.BoolValueTrue},
+	{`cidr_contains(["2001:db8::/32", "172.16.0.5"], "172.16.0.5")`, value.BoolValueTrue},
+	{`cidr_contains(["2001:db8::/32", "172.16.0.5"], "172.16.0.6")`, value.BoolValueFalse},
+	{`cidr_contains(split("10.0.0.0/8,192.168.0.0/16", ","), "10.9.9.9")`, value.BoolValueTrue},
+	{`cidr_contains("10.0.0.0/8", "not an ip")`, value.ErrValue},
+
 	/*
 		Casting and type-coercion functions
 	*/
@@ -755,7 +878,25 @@ var builtinTests = []testBuiltins{
 	{`avg(split("1,2,abc", ","))`, value.ErrValue},
 	{`avg("hello")`, value.ErrValue},
 
+	{`approx_count_distinct("abc")`, value.NewStringValue("abc")},
+	{`approx_quantile(4, 0.5)`, value.NewIntValue(4)},
+	{`approx_top_k("us", 3)`, value.NewStringValue("us")},
+
 	{`count(4)`, value.NewIntValue(1)},
+	{`group_concat("bob")`, value.NewStringValue("bob")},
+	{`string_agg("bob", ", " ORDER BY 2)`, value.NewStringValue("bob")},
+	{`group_concat(5 ORDER BY 2)`, value.NewStringValue("5")},
+	{`stddev("1.5")`, value.NewNumberValue(1.5)},
+	{`percentile_cont(4, 0.9)`, value.NewNumberValue(4)},
+	{`corr(1, "2")`, value.NewSliceValues([]value.Value{value.NewNumberValue(1), value.NewNumberValue(2)})},
+	{`first("a")`, value.NewStringValue("a")},
+	{`arg_max("a", 2)`, value.NewSliceValues([]value.Value{value.NewStringValue("a"), value.NewIntValue(2)})},
+	{`count_if(4 > 2)`, value.NewIntValue(1)},
+	{`count_if(4 < 2)`, nil},
+	{`sum_if(4, 4 > 2)`, value.NewNumberValue(4)},
+	{`sum_if("2.5", true)`, value.NewNumberValue(2.5)},
+	{`sum_if(4, false)`, nil},
+	{`avg_if(1.5, true)`, value.NewNumberValue(1.5)},
 	{`count(not_a_field)`, value.ErrValue},
 	{`count(not_a_field)`, nil},
 
@@ -768,6 +909,44 @@ var builtinTests = []testBuiltins{
 	{`json.jmespath(json_field, "[?b].tags | [0 ")`, nil},
 	{`json.jmespath(json_bad, "[?b].tags | [0 ")`, value.ErrValue},
 	{`json.jmespath(json_object, "name")`, value.NewStringValue("bob")},
+
+	// JSONPath
+	{`jsonpath(json_field, "$[0].name")`, value.NewStringValue("n1")},
+	{`jsonpath(json_field, "$[-1].ct")`, value.NewNumberValue(10)},
+	{`jsonpath(json_object, "$['city']")`, value.NewStringValue("portland")},
+	{`jsonpath(json_field, "$[?(@.ct > 8)].name")`, value.NewStringsValue([]string{"n2"})},
+	{`jsonpath(json_field, "$[?(@.b == true && 'a' in @.tags)].ct")`, value.NewSliceValuesNative([]interface{}{float64(8)})},
+	{`jsonpath(json_field, "$[?(@.name =~ /N2/i || @.ct < 0)].name")`, value.NewStringsValue([]string{"n2"})},
+	{`jsonpath(json_field, "$[?(!(@.ct > 8))].name")`, value.NewStringsValue([]string{"n1"})},
+	{`jsonpath(json_field, "$..tags[1]")`, value.NewStringsValue([]string{"b", "b"})},
+	{`jsonpath(json_field, "$[*].name")`, value.NewStringsValue([]string{"n1", "n2"})},
+	{`jsonpath(json_field, "$[0:1].name")`, value.NewStringsValue([]string{"n1"})},
+	{`jsonpath(json_field, "$[0]['name','ct']")`, value.NewSliceValuesNative([]interface{}{"n1", float64(8)})},
+	{`jsonpath(json_field, "$[?(@.ct > 100)]")`, nil},
+	{`jsonpath(not_field, "$[0]")`, nil},
+	{`jsonpath(json_bad, "$[0]")`, nil},
+	{`jsonpath_exists(json_field, "$[?(@.name == 'n2')]")`, value.NewBoolValue(true)},
+	{`jsonpath_exists(json_field, "$[?(@.name == 'n3')]")`, value.NewBoolValue(false)},
+	{`jsonpath_exists(json_object, "$.city")`, value.NewBoolValue(true)},
+
+	// jq
+	{`jq(json_field, ".[0].name")`, value.NewStringValue("n1")},
+	{`jq(json_field, "[.[] | select(.ct > 8) | .name]")`, value.NewStringsValue([]string{"n2"})},
+	{`jq(json_field, ".[] | select(.b) | .ct")`, value.NewNumberValue(8)},
+	{`jq(json_field, "map(.ct) | add")`, value.NewNumberValue(18)},
+	{`jq(json_field, "map(.name) | join('-')")`, value.NewStringValue("n1-n2")},
+	{`jq(json_field, ".[0].tags | length")`, value.NewNumberValue(2)},
+	{`jq(json_field, "[.[].ct] | max")`, value.NewNumberValue(10)},
+	{`jq(json_field, "sort_by(-.ct) | .[0].name")`, value.NewStringValue("n2")},
+	{`jq(json_field, "if .[0].ct > 9 then 'big' else 'small' end")`, value.NewStringValue("small")},
+	{`jq(json_object, ".missing // 'none'")`, value.NewStringValue("none")},
+	{`jq(json_object, "keys")`, value.NewStringsValue([]string{"city", "name"})},
+	{`jq(json_object, ".name | ascii_upcase")`, value.NewStringValue("BOB")},
+	{`jq(json_object, "{who: .name, n: (.city | length)}")`, value.NewMapValue(map[string]interface{}{"who": "bob", "n": float64(8)})},
+	{`jq(json_field, ".[] | select(.ct > 100)")`, nil},
+	{`jq(json_object, ".name[0]")`, value.ErrValue},
+	{`jq(json_object, ".name[0]?")`, nil},
+	{`jq(not_field, ".")`, nil},
 }
 
 var testValidation = []string{
@@ -854,6 +1033,9 @@ var testValidation = []string{
 	`string.lowercase()`, `string.lowercase(a,b)`, // must be one arg
 	`string.uppercase()`, `string.uppercase(a,b)`, // must be one arg
 	`string.titlecase()`, `string.titlecase(a,b)`, // must be one arg
+	`reverse()`, `reverse(a,b)`, // must be one arg
+	`substr(a)`, `substr(a,1,2,3)`, // must have 2 or 3 args
+	`normalize()`, `normalize(a,"NFX")`, `normalize(a,b)`, // form must be a valid literal
 	`split()`, `split(a,",","hello")`, // must have 2 args
 	`strip()`, `strip(a,"--")`, // must have 1 arg
 	`replace(arg)`, `replace(arg,"with","replaceval","toomany")`, // must have 2 or 3 args
@@ -874,6 +1056,8 @@ var testValidation = []string{
 	`hash.sha512()`, `hash.sha512(a,b)`, // must have 1
 	`encoding.b64encode()`, `encoding.b64encode(a,b)`, // must have 1
 	`encoding.b64decode()`, `encoding.b64decode(a,b)`, // must have 1
+	`sha2(a)`, `hmac_sha256(a)`, // must have 2
+	`aes_encrypt(a)`, `aes_decrypt(a)`, // must have 2
 
 	`todatein("May 8, 2009 5:57:51 PM")`,                   // Must have 2 args
 	`todatein("May 8, 2009 5:57:51 PM","PDT")`,             // PDT must be "America/Los_Angeles" format
@@ -885,6 +1069,23 @@ var testValidation = []string{
 	`json.jmespath(json_field)`,    // Must have 2 args
 	`json.jmespath(json_field, 1)`, // Must have 2 args, 2nd must be string
 	`json.jmespath(json_bad, "")`,
+
+	`jsonpath(json_field)`,        // Must have 2 args
+	`jsonpath(json_field, 1)`,     // 2nd must be string
+	`jsonpath(json_field, "a.b")`, // must start with $
+	`jsonpath(json_field, "$[0")`, // unclosed
+	`jsonpath(json_field, "$[?(@.a >)]")`,
+	`jsonpath_exists(json_field)`,
+	`jq(json_field)`,               // Must have 2 args
+	`jq(json_field, ".[0")`,        // unclosed
+	`jq(json_field, "nosuchfunc")`, // unknown builtin
+
+	`approx_count_distinct()`, `approx_count_distinct(a,b)`, // must be 1
+	`approx_quantile(a)`, `approx_quantile(a, 1.5)`, `approx_quantile(a, b)`, // quantile literal 0-1
+	`approx_top_k(a)`, `approx_top_k(a, 0)`, `approx_top_k(a, 1.5)`, // k positive int literal
+	`count_if()`, `count_if(a, b)`, `sum_if(a)`, `avg_if(a)`, // must have cond
+	`first(a, b)`, `last()`, `arg_max(a)`, `arg_min(a, b, c)`,
+	`stddev(a, b)`, `percentile_cont(a)`, `percentile_disc(a, 2)`, `corr(a)`, // arg counts, percentile 0-1
 }
 var testValidationx = []string{
 	`tolower()`, `lower(a,b)`, // must be one arg
@@ -937,6 +1138,31 @@ func TestOneOffs(t *testing.T) {
 	assert.Equal(t, node.(*expr.FuncNode).F.CustomFunc.Type(), value.StringType)
 }
 
+func TestAesKms(t *testing.T) {
+	KmsKeyProvider = func(keyID string) ([]byte, error) {
+		if keyID != "pii" {
+			return nil, fmt.Errorf("no key %q", keyID)
+		}
+		return []byte("0123456789abcdef0123456789abcdef"), nil
+	}
+	defer func() { KmsKeyProvider = nil }()
+
+	node, err := expr.ParseExpression(`aes_encrypt("123-45-6789", "kms:pii")`)
+	assert.Equal(t, nil, err)
+	sealed, ok := vm.Eval(nil, node)
+	assert.True(t, ok)
+
+	// the kms key is the aes key itself
+	node, _ = expr.ParseExpression(fmt.Sprintf(`aes_decrypt(%q, "0123456789abcdef0123456789abcdef")`, sealed.ToString()))
+	val, ok := vm.Eval(nil, node)
+	assert.True(t, ok)
+	assert.Equal(t, "123-45-6789", val.ToString())
+
+	node, _ = expr.ParseExpression(`aes_encrypt("123-45-6789", "kms:unknown")`)
+	_, ok = vm.Eval(nil, node)
+	assert.False(t, ok)
+}
+
 func TestValidation(t *testing.T) {
 	for _, exprText := range testValidation {
 		_, err := expr.ParseExpression(exprText)
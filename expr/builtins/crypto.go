@@ -0,0 +1,132 @@
+package builtins
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+)
+
+// KmsKeyPrefix keys of aes_encrypt, aes_decrypt with this prefix name a key
+// to fetch from KmsKeyProvider rather than being the key itself.
+const KmsKeyPrefix = "kms:"
+
+// KmsKeyProvider fetches the key of a key id from a key management service,
+// for the "kms:<key id>" keys of aes_encrypt, aes_decrypt.  Nil if there is
+// no KMS, kms keys then fail to evaluate.
+//
+//     builtins.KmsKeyProvider = func(keyID string) ([]byte, error) {
+//         return kmsClient.DataKey(keyID)
+//     }
+//
+var KmsKeyProvider func(keyID string) ([]byte, error)
+
+// AesEncrypt encrypt a value with AES-GCM, returns base64 of the random
+// nonce followed by the sealed value, so it can pass through text protocols
+// and columns.  Keys of 16, 24, 32 bytes are used as AES-128, 192, 256 keys,
+// other keys (ie passphrases) are hashed with SHA256 to an AES-256 key.  The
+// key may be a session variable, or "kms:<key id>" to fetch from the
+// KmsKeyProvider.
+//
+//     aes_encrypt(ssn, @pii_key)          =>  "3q2+7w...=="
+//     aes_encrypt(ssn, "kms:pii")
+//
+type AesEncrypt struct{}
+
+// Type string
+func (m *AesEncrypt) Type() value.ValueType { return value.StringType }
+func (m *AesEncrypt) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for aes_encrypt(field, key) but got %s", n)
+	}
+	return aesEncryptEval, nil
+}
+func aesEncryptEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	if args[0] == nil || args[0].Err() || args[0].Nil() {
+		return value.EmptyStringValue, false
+	}
+	gcm, ok := aesGcm(args[1])
+	if !ok {
+		return value.EmptyStringValue, false
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return value.EmptyStringValue, false
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(args[0].ToString()), nil)
+	return value.NewStringValue(base64.StdEncoding.EncodeToString(sealed)), true
+}
+
+// AesDecrypt decrypt a value encrypted by aes_encrypt with the same key,
+// does not evaluate if the key is wrong or value was altered.
+//
+//     aes_decrypt(ssn_enc, @pii_key)      =>  "123-45-6789"
+//
+type AesDecrypt struct{}
+
+// Type string
+func (m *AesDecrypt) Type() value.ValueType { return value.StringType }
+func (m *AesDecrypt) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for aes_decrypt(field, key) but got %s", n)
+	}
+	return aesDecryptEval, nil
+}
+func aesDecryptEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	if args[0] == nil || args[0].Err() || args[0].Nil() {
+		return value.EmptyStringValue, false
+	}
+	gcm, ok := aesGcm(args[1])
+	if !ok {
+		return value.EmptyStringValue, false
+	}
+	sealed, err := base64.StdEncoding.DecodeString(args[0].ToString())
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return value.EmptyStringValue, false
+	}
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	by, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return value.EmptyStringValue, false
+	}
+	return value.NewStringValue(string(by)), true
+}
+
+// aesGcm the AES-GCM cipher of the key arg.
+func aesGcm(keyArg value.Value) (cipher.AEAD, bool) {
+	if keyArg == nil || keyArg.Err() || keyArg.Nil() {
+		return nil, false
+	}
+	key := []byte(keyArg.ToString())
+	if keyID := string(key); strings.HasPrefix(keyID, KmsKeyPrefix) {
+		if KmsKeyProvider == nil {
+			return nil, false
+		}
+		var err error
+		if key, err = KmsKeyProvider(strings.TrimPrefix(keyID, KmsKeyPrefix)); err != nil {
+			return nil, false
+		}
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		sum := sha256.Sum256(key)
+		key = sum[:]
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, false
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, false
+	}
+	return gcm, true
+}
@@ -1,6 +1,7 @@
 package builtins
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
@@ -8,6 +9,8 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
 
 	u "github.com/araddon/gou"
 	"github.com/dchest/siphash"
@@ -146,6 +149,78 @@ func hashSha512Eval(ctx expr.EvalContext, args []value.Value) (value.Value, bool
 	return value.NewStringValue(hex.EncodeToString(hasher.Sum(nil))), true
 }
 
+// HashSha2 Hash a value to hex SHA-2 string of given bit length 224, 256
+// (or 0), 384, 512, same as mysql SHA2().
+//
+//     sha2("hello", 256)  =>  2cf24dba5fb0a30e26e8...
+//
+type HashSha2 struct{}
+
+// Type string
+func (m *HashSha2) Type() value.ValueType { return value.StringType }
+func (m *HashSha2) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for sha2(field_to_hash, bits) but got %s", n)
+	}
+	return hashSha2Eval, nil
+}
+func hashSha2Eval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	if args[0] == nil || args[0].Err() || args[0].Nil() || args[1] == nil {
+		return value.EmptyStringValue, false
+	}
+	bits, ok := value.ValueToInt64(args[1])
+	if !ok {
+		return value.EmptyStringValue, false
+	}
+	var sum []byte
+	by := []byte(args[0].ToString())
+	switch bits {
+	case 224:
+		h := sha256.Sum224(by)
+		sum = h[:]
+	case 0, 256:
+		h := sha256.Sum256(by)
+		sum = h[:]
+	case 384:
+		h := sha512.Sum384(by)
+		sum = h[:]
+	case 512:
+		h := sha512.Sum512(by)
+		sum = h[:]
+	default:
+		return value.EmptyStringValue, false
+	}
+	return value.NewStringValue(hex.EncodeToString(sum)), true
+}
+
+// HmacSha256 keyed hash (HMAC) of a value using SHA256, as hex string, to
+// sign or pseudonymize values with a secret key.
+//
+//     hmac_sha256("hello", "secret_key")  =>  abc345xyz
+//     hmac_sha256(email, @pii_key)
+//
+type HmacSha256 struct{}
+
+// Type string
+func (m *HmacSha256) Type() value.ValueType { return value.StringType }
+func (m *HmacSha256) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for hmac_sha256(field, key) but got %s", n)
+	}
+	return hmacSha256Eval, nil
+}
+func hmacSha256Eval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	if args[0] == nil || args[0].Err() || args[0].Nil() {
+		return value.EmptyStringValue, false
+	}
+	if args[1] == nil || args[1].Err() || args[1].Nil() {
+		return value.EmptyStringValue, false
+	}
+	mac := hmac.New(sha256.New, []byte(args[1].ToString()))
+	mac.Write([]byte(args[0].ToString()))
+	return value.NewStringValue(hex.EncodeToString(mac.Sum(nil))), true
+}
+
 // Base 64 encoding function
 //
 //     encoding.b64encode("hello world=")  =>  aGVsbG8gd29ybGQ=
@@ -193,3 +268,59 @@ func encodeB64DecodeEval(ctx expr.EvalContext, args []value.Value) (value.Value,
 	}
 	return value.NewStringValue(string(by)), true
 }
+
+// Hex encode a value as upper case hex, binary and strings are encoded
+// byte wise, integers as the hex of the number as mysql HEX().
+//
+//     hex("abc")       =>  "616263"
+//     hex(255)         =>  "FF"
+//     hex(X'0A1B')     =>  "0A1B"
+//
+type Hex struct{}
+
+// Type string
+func (m *Hex) Type() value.ValueType { return value.StringType }
+func (m *Hex) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 args for hex(field) but got %s", n)
+	}
+	return hexEval, nil
+}
+func hexEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	if args[0] == nil || args[0].Err() || args[0].Nil() {
+		return value.EmptyStringValue, false
+	}
+	switch val := args[0].(type) {
+	case value.IntValue:
+		return value.NewStringValue(strings.ToUpper(strconv.FormatInt(val.Val(), 16))), true
+	case value.ByteSliceValue:
+		return value.NewStringValue(strings.ToUpper(hex.EncodeToString(val.Val()))), true
+	}
+	return value.NewStringValue(strings.ToUpper(hex.EncodeToString([]byte(args[0].ToString())))), true
+}
+
+// Unhex decode a hex string to its binary value, the inverse of hex().
+//
+//     unhex("616263")   =>  X'616263'
+//     unhex("zz")       =>  nil, false
+//
+type Unhex struct{}
+
+// Type []byte
+func (m *Unhex) Type() value.ValueType { return value.ByteSliceType }
+func (m *Unhex) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 args for unhex(field) but got %s", n)
+	}
+	return unhexEval, nil
+}
+func unhexEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	if args[0] == nil || args[0].Err() || args[0].Nil() {
+		return nil, false
+	}
+	by, err := hex.DecodeString(args[0].ToString())
+	if err != nil {
+		return nil, false
+	}
+	return value.NewByteSliceValue(by), true
+}
@@ -0,0 +1,271 @@
+package builtins
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+// InetAton the integer of a dotted-quad IPv4 address, as mysql INET_ATON.
+//
+//     inet_aton("10.0.5.9")   =>  167773449
+//     inet_aton("not an ip")  =>  nil, false
+//
+type InetAton struct{}
+
+// Type int
+func (m *InetAton) Type() value.ValueType { return value.IntType }
+func (m *InetAton) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for inet_aton(ip) but got %s", n)
+	}
+	return inetAtonEval, nil
+}
+func inetAtonEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	s, ok := value.ValueToString(args[0])
+	if !ok {
+		return nil, false
+	}
+	ip := net.ParseIP(strings.TrimSpace(s)).To4()
+	if ip == nil {
+		return nil, false
+	}
+	return value.NewIntValue(int64(binary.BigEndian.Uint32(ip))), true
+}
+
+// InetNtoa the dotted-quad IPv4 address of an integer, as mysql INET_NTOA.
+//
+//     inet_ntoa(167773449)  =>  "10.0.5.9"
+//
+type InetNtoa struct{}
+
+// Type string
+func (m *InetNtoa) Type() value.ValueType { return value.StringType }
+func (m *InetNtoa) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for inet_ntoa(int) but got %s", n)
+	}
+	return inetNtoaEval, nil
+}
+func inetNtoaEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	n, ok := value.ValueToInt64(args[0])
+	if !ok || n < 0 || n > 0xFFFFFFFF {
+		return nil, false
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, uint32(n))
+	return value.NewStringValue(ip.String()), true
+}
+
+// IsIpv4 is the string an IPv4 address.
+//
+//     is_ipv4("10.0.5.9")  =>  true
+//     is_ipv4("::1")       =>  false
+//
+type IsIpv4 struct{}
+
+// Type bool
+func (m *IsIpv4) Type() value.ValueType { return value.BoolType }
+func (m *IsIpv4) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for is_ipv4(ip) but got %s", n)
+	}
+	return isIpv4Eval, nil
+}
+func isIpv4Eval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	s, ok := value.ValueToString(args[0])
+	if !ok {
+		return value.BoolValueFalse, true
+	}
+	s = strings.TrimSpace(s)
+	ip := net.ParseIP(s)
+	return value.NewBoolValue(ip != nil && ip.To4() != nil && !strings.Contains(s, ":")), true
+}
+
+// IsIpv6 is the string an IPv6 address.
+//
+//     is_ipv6("::1")       =>  true
+//     is_ipv6("10.0.5.9")  =>  false
+//
+type IsIpv6 struct{}
+
+// Type bool
+func (m *IsIpv6) Type() value.ValueType { return value.BoolType }
+func (m *IsIpv6) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for is_ipv6(ip) but got %s", n)
+	}
+	return isIpv6Eval, nil
+}
+func isIpv6Eval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	s, ok := value.ValueToString(args[0])
+	if !ok {
+		return value.BoolValueFalse, true
+	}
+	s = strings.TrimSpace(s)
+	return value.NewBoolValue(net.ParseIP(s) != nil && strings.Contains(s, ":")), true
+}
+
+// CidrContains is the ip in the CIDR block, or any of a list of blocks.  A
+// literal list is compiled once into a prefix trie, so the cost per row is
+// the bits of the address not the length of the list.
+//
+//     cidr_contains("10.0.0.0/8", "10.1.2.3")                        =>  true
+//     cidr_contains(["10.0.0.0/8", "192.168.0.0/16"], "172.16.0.1")  =>  false
+//     cidr_contains(blocked_nets, src_ip)
+//
+type CidrContains struct{}
+
+// Type bool
+func (m *CidrContains) Type() value.ValueType { return value.BoolType }
+func (m *CidrContains) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected 2 args for cidr_contains(cidr, ip) but got %s", n)
+	}
+	switch n.Args[0].(type) {
+	case *expr.StringNode, *expr.ArrayNode:
+		v, ok := vm.Eval(nil, n.Args[0])
+		if !ok {
+			return nil, fmt.Errorf("Could not evaluate %s", n.Args[0])
+		}
+		trie, err := newCidrTrie(cidrStrings(v))
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+			return trie.containsValue(args[1])
+		}, nil
+	}
+	// lists read per row are usually the same list, keep the last
+	var mu sync.Mutex
+	var lastKey string
+	var last *cidrTrie
+	return func(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+		cidrs := cidrStrings(args[0])
+		if len(cidrs) == 0 {
+			return nil, false
+		}
+		key := strings.Join(cidrs, ",")
+		mu.Lock()
+		trie := last
+		if key != lastKey {
+			t, err := newCidrTrie(cidrs)
+			if err != nil {
+				mu.Unlock()
+				return nil, false
+			}
+			trie, last, lastKey = t, t, key
+		}
+		mu.Unlock()
+		return trie.containsValue(args[1])
+	}, nil
+}
+
+func cidrStrings(v value.Value) []string {
+	switch vt := v.(type) {
+	case value.StringValue:
+		if vt.Val() == "" {
+			return nil
+		}
+		return strings.Split(vt.Val(), ",")
+	case value.Slice:
+		out := make([]string, 0, vt.Len())
+		for _, sv := range vt.SliceValue() {
+			out = append(out, sv.ToString())
+		}
+		return out
+	}
+	return nil
+}
+
+// cidrTrie binary trie of the bits of CIDR prefixes, IPv4 blocks are
+// stored as IPv4-mapped IPv6 so both share one trie.
+type cidrTrie struct {
+	root cidrNode
+}
+
+type cidrNode struct {
+	child [2]*cidrNode
+	term  bool
+}
+
+func newCidrTrie(cidrs []string) (*cidrTrie, error) {
+	t := &cidrTrie{}
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			// a bare address is a single host block
+			if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %v", c, err)
+		}
+		ones, bits := ipnet.Mask.Size()
+		if bits == 32 {
+			ones += 96
+		}
+		t.insert(ipnet.IP.To16(), ones)
+	}
+	return t, nil
+}
+
+func (m *cidrTrie) insert(ip net.IP, prefix int) {
+	n := &m.root
+	for i := 0; i < prefix && !n.term; i++ {
+		b := ipBit(ip, i)
+		if n.child[b] == nil {
+			n.child[b] = &cidrNode{}
+		}
+		n = n.child[b]
+	}
+	// a shorter prefix covers everything under it
+	n.term = true
+	n.child[0], n.child[1] = nil, nil
+}
+
+func (m *cidrTrie) contains(ip net.IP) bool {
+	ip = ip.To16()
+	if ip == nil {
+		return false
+	}
+	n := &m.root
+	for i := 0; i < 128; i++ {
+		if n.term {
+			return true
+		}
+		if n = n.child[ipBit(ip, i)]; n == nil {
+			return false
+		}
+	}
+	return n.term
+}
+
+func (m *cidrTrie) containsValue(v value.Value) (value.Value, bool) {
+	s, ok := value.ValueToString(v)
+	if !ok {
+		return nil, false
+	}
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return nil, false
+	}
+	return value.NewBoolValue(m.contains(ip)), true
+}
+
+func ipBit(ip net.IP, i int) int {
+	return int(ip[i/8]>>(7-uint(i%8))) & 1
+}
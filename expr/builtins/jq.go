@@ -0,0 +1,1606 @@
+package builtins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+)
+
+// Jq transform a json document with a jq program https://stedolan.github.io/jq/
+// A program with a single output returns it, several outputs are returned
+// as a list, no output (select() that matched nothing, empty) is nil.
+//
+//     json_field = `[{"name":"n1","ct":8,"b":true, "tags":["a","b"]},{"name":"n2","ct":10,"b": false, "tags":["a","b"]}]`
+//
+//     jq(json_field, '.[0].name')                              =>  "n1"
+//     jq(json_field, '[.[] | select(.ct > 8) | .name]')        =>  ["n2"]
+//     jq(json_field, 'map(.ct) | add')                         =>  18
+//     jq(json_field, '.[0] | {name, n: (.tags | length)}')     =>  {"name":"n1","n":2}
+//
+// Supported is the core of the language: . .a .a.b .[n] .["a"] .[] .[a:b]
+// .. ? | , // literals [] {} arithmetic, comparisons, and or not,
+// if-then-elif-else-end and the builtins listed in jqFuncs.  Variables,
+// reduce, def and paths are not.
+type Jq struct{}
+
+func (m *Jq) Type() value.ValueType { return value.UnknownType }
+func (m *Jq) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf(`Expected 2 args for jq(field, program) but got %s`, n)
+	}
+	sn, ok := n.Args[1].(*expr.StringNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a string expression for jq got %T", n.Args[1])
+	}
+	prog, err := ParseJq(sn.Text)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+		doc, ok := jsonDocument(args[0])
+		if !ok {
+			return nil, false
+		}
+		out, err := prog.Run(doc)
+		if err != nil {
+			return value.NewErrorValue(err), false
+		}
+		switch len(out) {
+		case 0:
+			return nil, false
+		case 1:
+			if out[0] == nil {
+				return value.NewNilValue(), true
+			}
+			return value.NewValue(out[0]), true
+		}
+		return value.NewValue(out), true
+	}, nil
+}
+
+// JqProgram a parsed jq program.
+type JqProgram struct {
+	Program string
+	root    jqNode
+}
+
+// ParseJq parse a jq program.
+func ParseJq(program string) (*JqProgram, error) {
+	toks, err := jqLex(program)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq %q: %v", program, err)
+	}
+	p := &jqParser{toks: toks}
+	root, err := p.pipe()
+	if err == nil && p.pos < len(p.toks) {
+		err = fmt.Errorf("unexpected %q", p.toks[p.pos].v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq %q: %v", program, err)
+	}
+	return &JqProgram{Program: program, root: root}, nil
+}
+
+// Run the program against a decoded json document, returning its outputs.
+func (m *JqProgram) Run(doc interface{}) ([]interface{}, error) {
+	return m.root.eval(doc)
+}
+
+type jqTokKind int
+
+const (
+	jqTokOp jqTokKind = iota
+	jqTokIdent
+	jqTokField // .name
+	jqTokNum
+	jqTokStr
+)
+
+type jqTok struct {
+	kind jqTokKind
+	v    string
+	num  float64
+}
+
+var jqOps = []string{"..", "//", "==", "!=", "<=", ">=", "|", ",", ".", "[", "]", "{", "}",
+	"(", ")", ":", ";", "?", "<", ">", "+", "-", "*", "/", "%"}
+
+func jqLex(s string) ([]jqTok, error) {
+	toks := make([]jqTok, 0)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			// single quotes allowed as well, as programs are usually
+			// embedded in a double quoted sql string
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+					switch s[j] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(s[j])
+					}
+					continue
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unclosed string")
+			}
+			toks = append(toks, jqTok{kind: jqTokStr, v: sb.String()})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && strings.IndexByte("0123456789.eE", s[j]) >= 0 {
+				j++
+			}
+			f, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, jqTok{kind: jqTokNum, v: s[i:j], num: f})
+			i = j
+		case c == '.' && i+1 < len(s) && jqIdentStart(s[i+1]):
+			j := i + 1
+			for j < len(s) && jqIdentChar(s[j]) {
+				j++
+			}
+			toks = append(toks, jqTok{kind: jqTokField, v: s[i+1 : j]})
+			i = j
+		case jqIdentStart(c):
+			j := i
+			for j < len(s) && jqIdentChar(s[j]) {
+				j++
+			}
+			toks = append(toks, jqTok{kind: jqTokIdent, v: s[i:j]})
+			i = j
+		default:
+			found := false
+			for _, op := range jqOps {
+				if strings.HasPrefix(s[i:], op) {
+					toks = append(toks, jqTok{kind: jqTokOp, v: op})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected %q", s[i:])
+			}
+		}
+	}
+	return toks, nil
+}
+
+func jqIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+func jqIdentChar(c byte) bool { return jqIdentStart(c) || (c >= '0' && c <= '9') }
+
+type jqParser struct {
+	toks []jqTok
+	pos  int
+}
+
+func (p *jqParser) isOp(op string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == jqTokOp && p.toks[p.pos].v == op
+}
+
+func (p *jqParser) isIdent(name string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].kind == jqTokIdent && p.toks[p.pos].v == name
+}
+
+func (p *jqParser) expect(op string) error {
+	if !p.isOp(op) && !p.isIdent(op) {
+		if p.pos >= len(p.toks) {
+			return fmt.Errorf("expected %q at end", op)
+		}
+		return fmt.Errorf("expected %q got %q", op, p.toks[p.pos].v)
+	}
+	p.pos++
+	return nil
+}
+
+// pipe := comma ('|' comma)*
+func (p *jqParser) pipe() (jqNode, error) {
+	l, err := p.comma()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("|") {
+		p.pos++
+		r, err := p.comma()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqPipe{l, r}
+	}
+	return l, nil
+}
+
+// comma := alt (',' alt)*
+func (p *jqParser) comma() (jqNode, error) {
+	l, err := p.alt()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(",") {
+		p.pos++
+		r, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqComma{l, r}
+	}
+	return l, nil
+}
+
+// alt := or ('//' or)*
+func (p *jqParser) alt() (jqNode, error) {
+	l, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("//") {
+		p.pos++
+		r, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqAlt{l, r}
+	}
+	return l, nil
+}
+
+func (p *jqParser) or() (jqNode, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.isIdent("or") {
+		p.pos++
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqBinary{"or", l, r}
+	}
+	return l, nil
+}
+
+func (p *jqParser) and() (jqNode, error) {
+	l, err := p.compare()
+	if err != nil {
+		return nil, err
+	}
+	for p.isIdent("and") {
+		p.pos++
+		r, err := p.compare()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqBinary{"and", l, r}
+	}
+	return l, nil
+}
+
+func (p *jqParser) compare() (jqNode, error) {
+	l, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.isOp(op) {
+			p.pos++
+			r, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			return &jqBinary{op, l, r}, nil
+		}
+	}
+	return l, nil
+}
+
+func (p *jqParser) additive() (jqNode, error) {
+	l, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.toks[p.pos].v
+		p.pos++
+		r, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqBinary{op, l, r}
+	}
+	return l, nil
+}
+
+func (p *jqParser) multiplicative() (jqNode, error) {
+	l, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		op := p.toks[p.pos].v
+		p.pos++
+		r, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqBinary{op, l, r}
+	}
+	return l, nil
+}
+
+// postfix := primary ( .name | ."name" | [..] | ? )*
+func (p *jqParser) postfix() (jqNode, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.toks) {
+		t := p.toks[p.pos]
+		switch {
+		case t.kind == jqTokField:
+			p.pos++
+			n = &jqIndex{target: n, key: &jqLiteral{t.v}}
+		case p.isOp(".") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == jqTokStr:
+			n = &jqIndex{target: n, key: &jqLiteral{p.toks[p.pos+1].v}}
+			p.pos += 2
+		case p.isOp(".") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].v == "[":
+			p.pos++
+		case p.isOp("["):
+			if n, err = p.bracket(n); err != nil {
+				return nil, err
+			}
+		case p.isOp("?"):
+			p.pos++
+			n = &jqTry{n}
+		default:
+			return n, nil
+		}
+	}
+	return n, nil
+}
+
+// bracket [] [e] [e:e] after target
+func (p *jqParser) bracket(target jqNode) (jqNode, error) {
+	p.pos++ // [
+	if p.isOp("]") {
+		p.pos++
+		return &jqIterate{target}, nil
+	}
+	var from, to jqNode
+	var err error
+	if !p.isOp(":") {
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isOp(":") {
+		p.pos++
+		if !p.isOp("]") {
+			if to, err = p.pipe(); err != nil {
+				return nil, err
+			}
+		}
+		return &jqSlice{target, from, to}, p.expect("]")
+	}
+	return &jqIndex{target: target, key: from}, p.expect("]")
+}
+
+func (p *jqParser) primary() (jqNode, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("unexpected end")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	switch t.kind {
+	case jqTokField:
+		return &jqIndex{target: jqIdentity{}, key: &jqLiteral{t.v}}, nil
+	case jqTokNum:
+		return &jqLiteral{t.num}, nil
+	case jqTokStr:
+		return &jqLiteral{t.v}, nil
+	case jqTokIdent:
+		switch t.v {
+		case "true":
+			return &jqLiteral{true}, nil
+		case "false":
+			return &jqLiteral{false}, nil
+		case "null":
+			return &jqLiteral{nil}, nil
+		case "if":
+			return p.ifThen()
+		}
+		return p.call(t.v)
+	}
+	switch t.v {
+	case ".":
+		if p.pos < len(p.toks) && p.toks[p.pos].kind == jqTokStr {
+			p.pos++
+			return &jqIndex{target: jqIdentity{}, key: &jqLiteral{p.toks[p.pos-1].v}}, nil
+		}
+		return jqIdentity{}, nil
+	case "..":
+		return jqRecurse{}, nil
+	case "-":
+		n, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		return &jqBinary{"-", &jqLiteral{float64(0)}, n}, nil
+	case "(":
+		n, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	case "[":
+		if p.isOp("]") {
+			p.pos++
+			return &jqCollect{}, nil
+		}
+		n, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return &jqCollect{n}, p.expect("]")
+	case "{":
+		return p.object()
+	}
+	return nil, fmt.Errorf("unexpected %q", t.v)
+}
+
+func (p *jqParser) ifThen() (jqNode, error) {
+	cond, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect("then"); err != nil {
+		return nil, err
+	}
+	n := &jqIf{cond: cond}
+	if n.then, err = p.pipe(); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.isIdent("elif"):
+		p.pos++
+		n.els, err = p.ifThen()
+		return n, err
+	case p.isIdent("else"):
+		p.pos++
+		if n.els, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	return n, p.expect("end")
+}
+
+// object {a, "b": e, (e): e, c: e}
+func (p *jqParser) object() (jqNode, error) {
+	obj := &jqObject{}
+	for !p.isOp("}") {
+		if p.pos >= len(p.toks) {
+			return nil, fmt.Errorf("unclosed {")
+		}
+		var key jqNode
+		t := p.toks[p.pos]
+		switch {
+		case t.kind == jqTokIdent || t.kind == jqTokStr:
+			p.pos++
+			key = &jqLiteral{t.v}
+		case p.isOp("("):
+			p.pos++
+			k, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			key = k
+		default:
+			return nil, fmt.Errorf("unexpected %q in object", t.v)
+		}
+		var val jqNode
+		if p.isOp(":") {
+			p.pos++
+			v, err := p.objectValue()
+			if err != nil {
+				return nil, err
+			}
+			val = v
+		} else if lit, ok := key.(*jqLiteral); ok {
+			val = &jqIndex{target: jqIdentity{}, key: lit}
+		} else {
+			return nil, fmt.Errorf("expected : after object key")
+		}
+		obj.keys = append(obj.keys, key)
+		obj.vals = append(obj.vals, val)
+		if !p.isOp(",") {
+			break
+		}
+		p.pos++
+	}
+	return obj, p.expect("}")
+}
+
+// objectValue an object value is an alt expression, a pipe must be in
+// parens as , separates the fields.
+func (p *jqParser) objectValue() (jqNode, error) {
+	l, err := p.alt()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("|") {
+		p.pos++
+		r, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		l = &jqPipe{l, r}
+	}
+	return l, nil
+}
+
+func (p *jqParser) call(name string) (jqNode, error) {
+	args := make([]jqNode, 0)
+	if p.isOp("(") {
+		p.pos++
+		for {
+			a, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if !p.isOp(";") {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	fn, ok := jqFuncs[jqFuncKey{name, len(args)}]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s/%d", name, len(args))
+	}
+	return &jqCall{name: name, fn: fn, args: args}, nil
+}
+
+// jqNode a jq expression, evaluated against an input producing zero or
+// more outputs.
+type jqNode interface {
+	eval(in interface{}) ([]interface{}, error)
+}
+
+type (
+	jqIdentity struct{}
+	jqRecurse  struct{}
+	jqLiteral  struct{ v interface{} }
+	jqPipe     struct{ l, r jqNode }
+	jqComma    struct{ l, r jqNode }
+	jqAlt      struct{ l, r jqNode }
+	jqTry      struct{ n jqNode }
+	jqCollect  struct{ n jqNode }
+	jqIterate  struct{ target jqNode }
+	jqIndex    struct{ target, key jqNode }
+	jqSlice    struct{ target, from, to jqNode }
+	jqBinary   struct {
+		op   string
+		l, r jqNode
+	}
+	jqIf struct {
+		cond, then, els jqNode
+	}
+	jqObject struct {
+		keys, vals []jqNode
+	}
+	jqCall struct {
+		name string
+		fn   jqFunc
+		args []jqNode
+	}
+)
+
+func (jqIdentity) eval(in interface{}) ([]interface{}, error) { return []interface{}{in}, nil }
+func (jqRecurse) eval(in interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0)
+	jpDescend(in, func(v interface{}) { out = append(out, v) })
+	return out, nil
+}
+func (m *jqLiteral) eval(in interface{}) ([]interface{}, error) { return []interface{}{m.v}, nil }
+
+func (m *jqPipe) eval(in interface{}) ([]interface{}, error) {
+	ls, err := m.l.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, len(ls))
+	for _, l := range ls {
+		rs, err := m.r.eval(l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rs...)
+	}
+	return out, nil
+}
+
+func (m *jqComma) eval(in interface{}) ([]interface{}, error) {
+	ls, err := m.l.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := m.r.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	return append(ls, rs...), nil
+}
+
+func (m *jqAlt) eval(in interface{}) ([]interface{}, error) {
+	ls, _ := m.l.eval(in)
+	out := make([]interface{}, 0, len(ls))
+	for _, l := range ls {
+		if jsonTruthy(l) {
+			out = append(out, l)
+		}
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+	return m.r.eval(in)
+}
+
+func (m *jqTry) eval(in interface{}) ([]interface{}, error) {
+	out, err := m.n.eval(in)
+	if err != nil {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func (m *jqCollect) eval(in interface{}) ([]interface{}, error) {
+	if m.n == nil {
+		return []interface{}{[]interface{}{}}, nil
+	}
+	out, err := m.n.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{append([]interface{}{}, out...)}, nil
+}
+
+func (m *jqIterate) eval(in interface{}) ([]interface{}, error) {
+	ts, err := m.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0)
+	for _, t := range ts {
+		switch tt := t.(type) {
+		case []interface{}:
+			out = append(out, tt...)
+		case map[string]interface{}:
+			for _, k := range jpKeys(tt) {
+				out = append(out, tt[k])
+			}
+		default:
+			return nil, fmt.Errorf("cannot iterate over %s", jqType(t))
+		}
+	}
+	return out, nil
+}
+
+func (m *jqIndex) eval(in interface{}) ([]interface{}, error) {
+	ts, err := m.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := m.key.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, len(ts))
+	for _, t := range ts {
+		for _, k := range ks {
+			v, err := jqIndexOf(t, k)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func jqIndexOf(t, k interface{}) (interface{}, error) {
+	if t == nil {
+		return nil, nil
+	}
+	switch tt := t.(type) {
+	case map[string]interface{}:
+		if s, ok := k.(string); ok {
+			return tt[s], nil
+		}
+	case []interface{}:
+		if f, ok := jsonNumber(k); ok {
+			v, _ := jpAt(tt, int(math.Floor(f)))
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot index %s with %s", jqType(t), jqType(k))
+}
+
+func (m *jqSlice) eval(in interface{}) ([]interface{}, error) {
+	ts, err := m.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	bound := func(n jqNode, def int) (int, error) {
+		if n == nil {
+			return def, nil
+		}
+		vs, err := n.eval(in)
+		if err != nil || len(vs) == 0 {
+			return def, err
+		}
+		if vs[0] == nil {
+			return def, nil
+		}
+		f, ok := jsonNumber(vs[0])
+		if !ok {
+			return 0, fmt.Errorf("slice bound must be a number")
+		}
+		return int(math.Floor(f)), nil
+	}
+	out := make([]interface{}, 0, len(ts))
+	for _, t := range ts {
+		var l int
+		switch tt := t.(type) {
+		case nil:
+			out = append(out, nil)
+			continue
+		case string:
+			l = len(tt)
+		case []interface{}:
+			l = len(tt)
+		default:
+			return nil, fmt.Errorf("cannot slice %s", jqType(t))
+		}
+		from, err := bound(m.from, 0)
+		if err != nil {
+			return nil, err
+		}
+		to, err := bound(m.to, l)
+		if err != nil {
+			return nil, err
+		}
+		from, to = jpBound(from, l, 1), jpBound(to, l, 1)
+		if to < from {
+			to = from
+		}
+		switch tt := t.(type) {
+		case string:
+			out = append(out, tt[from:to])
+		case []interface{}:
+			out = append(out, append([]interface{}{}, tt[from:to]...))
+		}
+	}
+	return out, nil
+}
+
+func (m *jqIf) eval(in interface{}) ([]interface{}, error) {
+	cs, err := m.cond.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, len(cs))
+	for _, c := range cs {
+		branch := m.then
+		if !jsonTruthy(c) {
+			branch = m.els
+		}
+		if branch == nil {
+			out = append(out, in)
+			continue
+		}
+		vs, err := branch.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vs...)
+	}
+	return out, nil
+}
+
+func (m *jqObject) eval(in interface{}) ([]interface{}, error) {
+	objs := []map[string]interface{}{{}}
+	for i, kn := range m.keys {
+		ks, err := kn.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		vs, err := m.vals[i].eval(in)
+		if err != nil {
+			return nil, err
+		}
+		next := make([]map[string]interface{}, 0, len(objs)*len(ks)*len(vs))
+		for _, obj := range objs {
+			for _, k := range ks {
+				key, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("object keys must be strings")
+				}
+				for _, v := range vs {
+					o := make(map[string]interface{}, len(obj)+1)
+					for ok, ov := range obj {
+						o[ok] = ov
+					}
+					o[key] = v
+					next = append(next, o)
+				}
+			}
+		}
+		objs = next
+	}
+	out := make([]interface{}, len(objs))
+	for i, o := range objs {
+		out[i] = o
+	}
+	return out, nil
+}
+
+func (m *jqBinary) eval(in interface{}) ([]interface{}, error) {
+	ls, err := m.l.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, len(ls))
+	for _, l := range ls {
+		switch m.op {
+		case "and":
+			if !jsonTruthy(l) {
+				out = append(out, false)
+				continue
+			}
+		case "or":
+			if jsonTruthy(l) {
+				out = append(out, true)
+				continue
+			}
+		}
+		rs, err := m.r.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			v, err := jqBinaryOp(m.op, l, r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func jqBinaryOp(op string, l, r interface{}) (interface{}, error) {
+	switch op {
+	case "and", "or":
+		return jsonTruthy(r), nil
+	case "==":
+		return jsonEqual(l, r), nil
+	case "!=":
+		return !jsonEqual(l, r), nil
+	case "<":
+		return jqOrder(l, r) < 0, nil
+	case "<=":
+		return jqOrder(l, r) <= 0, nil
+	case ">":
+		return jqOrder(l, r) > 0, nil
+	case ">=":
+		return jqOrder(l, r) >= 0, nil
+	}
+	lf, lok := jsonNumber(l)
+	rf, rok := jsonNumber(r)
+	if lok && rok {
+		switch op {
+		case "+":
+			return lf + rf, nil
+		case "-":
+			return lf - rf, nil
+		case "*":
+			return lf * rf, nil
+		case "/":
+			if rf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return lf / rf, nil
+		case "%":
+			if int64(rf) == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return float64(int64(lf) % int64(rf)), nil
+		}
+	}
+	if op == "+" {
+		switch {
+		case l == nil:
+			return r, nil
+		case r == nil:
+			return l, nil
+		}
+		switch lt := l.(type) {
+		case string:
+			if rs, ok := r.(string); ok {
+				return lt + rs, nil
+			}
+		case []interface{}:
+			if ra, ok := r.([]interface{}); ok {
+				return append(append([]interface{}{}, lt...), ra...), nil
+			}
+		case map[string]interface{}:
+			if rm, ok := r.(map[string]interface{}); ok {
+				o := make(map[string]interface{}, len(lt)+len(rm))
+				for k, v := range lt {
+					o[k] = v
+				}
+				for k, v := range rm {
+					o[k] = v
+				}
+				return o, nil
+			}
+		}
+	}
+	if op == "-" {
+		la, lok := l.([]interface{})
+		ra, rok := r.([]interface{})
+		if lok && rok {
+			o := make([]interface{}, 0, len(la))
+			for _, lv := range la {
+				found := false
+				for _, rv := range ra {
+					if jsonEqual(lv, rv) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					o = append(o, lv)
+				}
+			}
+			return o, nil
+		}
+	}
+	return nil, fmt.Errorf("%s and %s cannot be combined with %s", jqType(l), jqType(r), op)
+}
+
+// jqType the jq type name of a decoded json value.
+func jqType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// jqOrder jq sort order, null < false < true < numbers < strings <
+// arrays < objects.
+func jqOrder(l, r interface{}) int {
+	rank := func(v interface{}) int {
+		switch vt := v.(type) {
+		case nil:
+			return 0
+		case bool:
+			if vt {
+				return 2
+			}
+			return 1
+		case float64, int, int64:
+			return 3
+		case string:
+			return 4
+		case []interface{}:
+			return 5
+		}
+		return 6
+	}
+	lr, rr := rank(l), rank(r)
+	if lr != rr {
+		if lr < rr {
+			return -1
+		}
+		return 1
+	}
+	switch lt := l.(type) {
+	case string:
+		return strings.Compare(lt, r.(string))
+	case []interface{}:
+		ra := r.([]interface{})
+		for i := 0; i < len(lt) && i < len(ra); i++ {
+			if c := jqOrder(lt[i], ra[i]); c != 0 {
+				return c
+			}
+		}
+		return len(lt) - len(ra)
+	case map[string]interface{}:
+		rm := r.(map[string]interface{})
+		lk, rk := jpKeys(lt), jpKeys(rm)
+		if c := jqOrder(jqStrings(lk), jqStrings(rk)); c != 0 {
+			return c
+		}
+		for _, k := range lk {
+			if c := jqOrder(lt[k], rm[k]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	if lr == 3 {
+		lf, _ := jsonNumber(l)
+		rf, _ := jsonNumber(r)
+		switch {
+		case lf < rf:
+			return -1
+		case lf > rf:
+			return 1
+		}
+	}
+	return 0
+}
+
+func jqStrings(s []string) []interface{} {
+	out := make([]interface{}, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
+
+func (m *jqCall) eval(in interface{}) ([]interface{}, error) {
+	return m.fn(in, m.args)
+}
+
+type jqFuncKey struct {
+	name  string
+	arity int
+}
+
+// jqFunc a jq builtin, args are unevaluated, evaluated against the input
+// as the builtin needs.
+type jqFunc func(in interface{}, args []jqNode) ([]interface{}, error)
+
+// jqFuncs the supported jq builtins by name/arity.
+var jqFuncs map[jqFuncKey]jqFunc
+
+func init() {
+	one := func(fn func(in interface{}) (interface{}, error)) jqFunc {
+		return func(in interface{}, args []jqNode) ([]interface{}, error) {
+			v, err := fn(in)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{v}, nil
+		}
+	}
+	// withArg call fn with each output of the single arg
+	withArg := func(fn func(in, arg interface{}) (interface{}, error)) jqFunc {
+		return func(in interface{}, args []jqNode) ([]interface{}, error) {
+			as, err := args[0].eval(in)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]interface{}, 0, len(as))
+			for _, a := range as {
+				v, err := fn(in, a)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+			return out, nil
+		}
+	}
+	jqFuncs = map[jqFuncKey]jqFunc{
+		{"empty", 0}: func(in interface{}, args []jqNode) ([]interface{}, error) { return nil, nil },
+		{"not", 0}:   one(func(in interface{}) (interface{}, error) { return !jsonTruthy(in), nil }),
+		{"type", 0}:  one(func(in interface{}) (interface{}, error) { return jqType(in), nil }),
+		{"length", 0}: one(func(in interface{}) (interface{}, error) {
+			switch vt := in.(type) {
+			case nil:
+				return float64(0), nil
+			case string:
+				return float64(utf8.RuneCountInString(vt)), nil
+			case []interface{}:
+				return float64(len(vt)), nil
+			case map[string]interface{}:
+				return float64(len(vt)), nil
+			case float64:
+				return math.Abs(vt), nil
+			}
+			return nil, fmt.Errorf("%s has no length", jqType(in))
+		}),
+		{"keys", 0}: one(func(in interface{}) (interface{}, error) {
+			switch vt := in.(type) {
+			case map[string]interface{}:
+				return jqStrings(jpKeys(vt)), nil
+			case []interface{}:
+				out := make([]interface{}, len(vt))
+				for i := range vt {
+					out[i] = float64(i)
+				}
+				return out, nil
+			}
+			return nil, fmt.Errorf("%s has no keys", jqType(in))
+		}),
+		{"add", 0}: one(func(in interface{}) (interface{}, error) {
+			vals, err := jqValues(in)
+			if err != nil {
+				return nil, err
+			}
+			var sum interface{}
+			for _, v := range vals {
+				if sum, err = jqBinaryOp("+", sum, v); err != nil {
+					return nil, err
+				}
+			}
+			return sum, nil
+		}),
+		{"any", 0}: one(func(in interface{}) (interface{}, error) {
+			vals, err := jqValues(in)
+			for _, v := range vals {
+				if jsonTruthy(v) {
+					return true, nil
+				}
+			}
+			return false, err
+		}),
+		{"all", 0}: one(func(in interface{}) (interface{}, error) {
+			vals, err := jqValues(in)
+			for _, v := range vals {
+				if !jsonTruthy(v) {
+					return false, nil
+				}
+			}
+			return true, err
+		}),
+		{"first", 0}:   one(func(in interface{}) (interface{}, error) { return jqIndexOf(in, float64(0)) }),
+		{"last", 0}:    one(func(in interface{}) (interface{}, error) { return jqIndexOf(in, float64(-1)) }),
+		{"reverse", 0}: one(jqReverse),
+		{"sort", 0}: one(func(in interface{}) (interface{}, error) {
+			return jqSortBy(in, nil)
+		}),
+		{"unique", 0}: one(func(in interface{}) (interface{}, error) {
+			return jqUniqueBy(in, nil)
+		}),
+		{"min", 0}: one(func(in interface{}) (interface{}, error) {
+			return jqExtreme(in, -1)
+		}),
+		{"max", 0}: one(func(in interface{}) (interface{}, error) {
+			return jqExtreme(in, 1)
+		}),
+		{"flatten", 0}: one(func(in interface{}) (interface{}, error) {
+			arr, ok := in.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot flatten %s", jqType(in))
+			}
+			return jqFlatten(arr, make([]interface{}, 0, len(arr))), nil
+		}),
+		{"tostring", 0}: one(func(in interface{}) (interface{}, error) {
+			if s, ok := in.(string); ok {
+				return s, nil
+			}
+			by, err := json.Marshal(in)
+			return string(by), err
+		}),
+		{"tonumber", 0}: one(func(in interface{}) (interface{}, error) {
+			switch vt := in.(type) {
+			case float64:
+				return vt, nil
+			case string:
+				return strconv.ParseFloat(strings.TrimSpace(vt), 64)
+			}
+			return nil, fmt.Errorf("cannot parse %s as number", jqType(in))
+		}),
+		{"ascii_downcase", 0}: one(func(in interface{}) (interface{}, error) {
+			s, ok := in.(string)
+			if !ok {
+				return nil, fmt.Errorf("ascii_downcase input must be a string")
+			}
+			return strings.ToLower(s), nil
+		}),
+		{"ascii_upcase", 0}: one(func(in interface{}) (interface{}, error) {
+			s, ok := in.(string)
+			if !ok {
+				return nil, fmt.Errorf("ascii_upcase input must be a string")
+			}
+			return strings.ToUpper(s), nil
+		}),
+		{"to_entries", 0}: one(func(in interface{}) (interface{}, error) {
+			m, ok := in.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s has no keys", jqType(in))
+			}
+			out := make([]interface{}, 0, len(m))
+			for _, k := range jpKeys(m) {
+				out = append(out, map[string]interface{}{"key": k, "value": m[k]})
+			}
+			return out, nil
+		}),
+		{"from_entries", 0}: one(func(in interface{}) (interface{}, error) {
+			arr, ok := in.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("from_entries input must be an array")
+			}
+			out := make(map[string]interface{}, len(arr))
+			for _, e := range arr {
+				em, ok := e.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("from_entries entries must be objects")
+				}
+				k, ok := em["key"].(string)
+				if !ok {
+					k, ok = em["name"].(string)
+				}
+				if !ok {
+					return nil, fmt.Errorf("from_entries entry must have a string key")
+				}
+				out[k] = em["value"]
+			}
+			return out, nil
+		}),
+		{"values", 0}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			if in == nil {
+				return nil, nil
+			}
+			return []interface{}{in}, nil
+		},
+		{"select", 1}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			cs, err := args[0].eval(in)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]interface{}, 0, 1)
+			for _, c := range cs {
+				if jsonTruthy(c) {
+					out = append(out, in)
+				}
+			}
+			return out, nil
+		},
+		{"map", 1}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			// map(f) is [.[] | f]
+			return (&jqCollect{&jqPipe{&jqIterate{jqIdentity{}}, args[0]}}).eval(in)
+		},
+		{"has", 1}: withArg(func(in, k interface{}) (interface{}, error) {
+			switch vt := in.(type) {
+			case map[string]interface{}:
+				if s, ok := k.(string); ok {
+					_, has := vt[s]
+					return has, nil
+				}
+			case []interface{}:
+				if f, ok := jsonNumber(k); ok {
+					return f >= 0 && int(f) < len(vt), nil
+				}
+			}
+			return nil, fmt.Errorf("cannot check whether %s has a %s key", jqType(in), jqType(k))
+		}),
+		{"contains", 1}: withArg(func(in, b interface{}) (interface{}, error) {
+			return jqContains(in, b), nil
+		}),
+		{"startswith", 1}: withArg(func(in, b interface{}) (interface{}, error) {
+			s, sok := in.(string)
+			p, pok := b.(string)
+			if !sok || !pok {
+				return nil, fmt.Errorf("startswith requires string inputs")
+			}
+			return strings.HasPrefix(s, p), nil
+		}),
+		{"endswith", 1}: withArg(func(in, b interface{}) (interface{}, error) {
+			s, sok := in.(string)
+			p, pok := b.(string)
+			if !sok || !pok {
+				return nil, fmt.Errorf("endswith requires string inputs")
+			}
+			return strings.HasSuffix(s, p), nil
+		}),
+		{"test", 1}: withArg(func(in, b interface{}) (interface{}, error) {
+			s, sok := in.(string)
+			p, pok := b.(string)
+			if !sok || !pok {
+				return nil, fmt.Errorf("test requires string inputs")
+			}
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(s), nil
+		}),
+		{"split", 1}: withArg(func(in, b interface{}) (interface{}, error) {
+			s, sok := in.(string)
+			sep, pok := b.(string)
+			if !sok || !pok {
+				return nil, fmt.Errorf("split input and separator must be strings")
+			}
+			return jqStrings(strings.Split(s, sep)), nil
+		}),
+		{"join", 1}: withArg(func(in, b interface{}) (interface{}, error) {
+			vals, err := jqValues(in)
+			if err != nil {
+				return nil, err
+			}
+			sep, ok := b.(string)
+			if !ok {
+				return nil, fmt.Errorf("join separator must be a string")
+			}
+			parts := make([]string, len(vals))
+			for i, v := range vals {
+				switch vt := v.(type) {
+				case nil:
+				case string:
+					parts[i] = vt
+				case float64, bool:
+					parts[i] = fmt.Sprint(vt)
+				default:
+					return nil, fmt.Errorf("cannot join with %s", jqType(v))
+				}
+			}
+			return strings.Join(parts, sep), nil
+		}),
+		{"sort_by", 1}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			v, err := jqSortBy(in, args[0])
+			return []interface{}{v}, err
+		},
+		{"unique_by", 1}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			v, err := jqUniqueBy(in, args[0])
+			return []interface{}{v}, err
+		},
+		{"any", 1}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			return jqMapPredicate(in, args[0], true)
+		},
+		{"all", 1}: func(in interface{}, args []jqNode) ([]interface{}, error) {
+			return jqMapPredicate(in, args[0], false)
+		},
+	}
+	// map_values(f) keeps the object/array shape, first output of f
+	jqFuncs[jqFuncKey{"map_values", 1}] = func(in interface{}, args []jqNode) ([]interface{}, error) {
+		switch vt := in.(type) {
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(vt))
+			for k, v := range vt {
+				vs, err := args[0].eval(v)
+				if err != nil {
+					return nil, err
+				}
+				if len(vs) > 0 {
+					out[k] = vs[0]
+				}
+			}
+			return []interface{}{out}, nil
+		case []interface{}:
+			out := make([]interface{}, 0, len(vt))
+			for _, v := range vt {
+				vs, err := args[0].eval(v)
+				if err != nil {
+					return nil, err
+				}
+				if len(vs) > 0 {
+					out = append(out, vs[0])
+				}
+			}
+			return []interface{}{out}, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %s", jqType(in))
+	}
+}
+
+// jqValues the elements of an array, or values of an object.
+func jqValues(in interface{}) ([]interface{}, error) {
+	switch vt := in.(type) {
+	case []interface{}:
+		return vt, nil
+	case map[string]interface{}:
+		out := make([]interface{}, 0, len(vt))
+		for _, k := range jpKeys(vt) {
+			out = append(out, vt[k])
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", jqType(in))
+}
+
+func jqReverse(in interface{}) (interface{}, error) {
+	switch vt := in.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case string:
+		r := []rune(vt)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r), nil
+	case []interface{}:
+		out := make([]interface{}, len(vt))
+		for i, v := range vt {
+			out[len(vt)-1-i] = v
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot reverse %s", jqType(in))
+}
+
+// jqKeyed array elements paired with their sort key, f(element) or the
+// element itself for a nil f.
+type jqKeyed struct {
+	key, v interface{}
+}
+
+func jqKeyedValues(in interface{}, f jqNode) ([]jqKeyed, error) {
+	arr, ok := in.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot sort %s, must be array", jqType(in))
+	}
+	out := make([]jqKeyed, len(arr))
+	for i, v := range arr {
+		out[i] = jqKeyed{v, v}
+		if f != nil {
+			ks, err := f.eval(v)
+			if err != nil {
+				return nil, err
+			}
+			out[i].key = ks
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return jqOrder(out[i].key, out[j].key) < 0 })
+	return out, nil
+}
+
+func jqSortBy(in interface{}, f jqNode) (interface{}, error) {
+	kv, err := jqKeyedValues(in, f)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(kv))
+	for i, e := range kv {
+		out[i] = e.v
+	}
+	return out, nil
+}
+
+func jqUniqueBy(in interface{}, f jqNode) (interface{}, error) {
+	kv, err := jqKeyedValues(in, f)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, 0, len(kv))
+	for i, e := range kv {
+		if i > 0 && jqOrder(kv[i-1].key, e.key) == 0 {
+			continue
+		}
+		out = append(out, e.v)
+	}
+	return out, nil
+}
+
+// jqExtreme the min (dir -1) or max (dir 1) element, null if empty.
+func jqExtreme(in interface{}, dir int) (interface{}, error) {
+	arr, ok := in.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot find min/max of %s", jqType(in))
+	}
+	var best interface{}
+	for i, v := range arr {
+		if i == 0 || jqOrder(v, best)*dir > 0 {
+			best = v
+		}
+	}
+	return best, nil
+}
+
+func jqFlatten(arr, out []interface{}) []interface{} {
+	for _, v := range arr {
+		if sub, ok := v.([]interface{}); ok {
+			out = jqFlatten(sub, out)
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// jqMapPredicate any(f) / all(f) over the input elements.
+func jqMapPredicate(in interface{}, f jqNode, any bool) ([]interface{}, error) {
+	vals, err := jqValues(in)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vals {
+		rs, err := f.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			if jsonTruthy(r) == any {
+				return []interface{}{any}, nil
+			}
+		}
+	}
+	return []interface{}{!any}, nil
+}
+
+// jqContains jq contains, substrings for strings, recursively for
+// arrays (every element of b in some element of a) and objects.
+func jqContains(a, b interface{}) bool {
+	switch at := a.(type) {
+	case string:
+		bs, ok := b.(string)
+		return ok && strings.Contains(at, bs)
+	case []interface{}:
+		ba, ok := b.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, bv := range ba {
+			found := false
+			for _, av := range at {
+				if jqContains(av, bv) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bm, ok := b.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, bv := range bm {
+			av, has := at[k]
+			if !has || !jqContains(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return jsonEqual(a, b)
+}
@@ -0,0 +1,882 @@
+package builtins
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+)
+
+// JsonPathQuery query a json document with a JSONPath expression
+// http://goessner.net/articles/JsonPath/  A path that selects one
+// element ($.a.b, $.a[0]) returns that value, paths with wildcards,
+// slices, unions, filters or recursive descent return a list of matches.
+// No match is nil.
+//
+//     json_field = `[{"name":"n1","ct":8,"b":true, "tags":["a","b"]},{"name":"n2","ct":10,"b": false, "tags":["a","b"]}]`
+//
+//     jsonpath(json_field, "$[0].name")                 =>  "n1"
+//     jsonpath(json_field, "$[?(@.ct > 8)].name")       =>  ["n2"]
+//     jsonpath(json_field, "$..tags[0]")                =>  ["a","a"]
+//
+// The document may be a json value, a string of json, or a map/slice.
+type JsonPathQuery struct{}
+
+func (m *JsonPathQuery) Type() value.ValueType { return value.UnknownType }
+func (m *JsonPathQuery) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf(`Expected 2 args for jsonpath(field, path) but got %s`, n)
+	}
+	jp, err := jsonPathArg(n)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+		doc, ok := jsonDocument(args[0])
+		if !ok {
+			return nil, false
+		}
+		matches := jp.Eval(doc)
+		if len(matches) == 0 {
+			return nil, false
+		}
+		if jp.Definite() {
+			return value.NewValue(matches[0]), true
+		}
+		return value.NewValue(matches), true
+	}, nil
+}
+
+// JsonPathExists true if the JSONPath expression matches anything in the
+// json document, for filtering on document contents.
+//
+//     jsonpath_exists(json_field, "$[?(@.b == true && 'a' in @.tags)]")  => true
+//
+type JsonPathExists struct{}
+
+func (m *JsonPathExists) Type() value.ValueType { return value.BoolType }
+func (m *JsonPathExists) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf(`Expected 2 args for jsonpath_exists(field, path) but got %s`, n)
+	}
+	jp, err := jsonPathArg(n)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+		doc, ok := jsonDocument(args[0])
+		if !ok {
+			return value.NewBoolValue(false), true
+		}
+		return value.NewBoolValue(len(jp.Eval(doc)) > 0), true
+	}, nil
+}
+
+func jsonPathArg(n *expr.FuncNode) (*JsonPathExpr, error) {
+	sn, ok := n.Args[1].(*expr.StringNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a string expression for jsonpath got %T", n.Args[1])
+	}
+	return ParseJsonPath(sn.Text)
+}
+
+// jsonDocument the decoded json (map[string]interface{}, []interface{},
+// float64, string, bool, nil) of a json value, json string or map/slice.
+func jsonDocument(v value.Value) (interface{}, bool) {
+	if v == nil || v.Err() || v.Nil() {
+		return nil, false
+	}
+	var raw []byte
+	switch vt := v.(type) {
+	case value.JsonValue:
+		raw = []byte(vt.ToString())
+	case value.StringValue:
+		raw = []byte(vt.Val())
+	case value.ByteSliceValue:
+		raw = vt.Val()
+	default:
+		by, err := json.Marshal(v.Value())
+		if err != nil {
+			return nil, false
+		}
+		raw = by
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// JsonPathExpr a parsed JSONPath expression.
+//
+//     $.store.book[?(@.price < 10 && @.tags)].title
+//
+//     $             root
+//     .name ['n']   child member
+//     [0] [-1]      array index
+//     [*] .*        all children
+//     ..name        recursive descent
+//     [0:2] [::2]   array slice
+//     [0,2] ['a','b']  union
+//     [?(expr)]     filter, @ is the current element, == != < <= > >=
+//                   =~ /regex/, in, &&, ||, ! and parens
+type JsonPathExpr struct {
+	Path  string
+	steps []jpStep
+}
+
+type jpStepKind int
+
+const (
+	jpChild jpStepKind = iota
+	jpIndex
+	jpWild
+	jpUnion
+	jpSlice
+	jpFilter
+)
+
+type jpStep struct {
+	kind    jpStepKind
+	descend bool // recursive descent (..) before this step
+	name    string
+	index   int
+	union   []interface{} // string names, int indexes
+	slice   [3]*int
+	filter  jpBool
+}
+
+// ParseJsonPath parse a JSONPath expression.
+func ParseJsonPath(path string) (*JsonPathExpr, error) {
+	p := &jpParser{s: strings.TrimSpace(path)}
+	if !p.consume("$") {
+		return nil, fmt.Errorf("jsonpath must start with $: %q", path)
+	}
+	steps, err := p.steps()
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %v", path, err)
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, fmt.Errorf("invalid jsonpath %q: unexpected %q", path, p.s[p.pos:])
+	}
+	return &JsonPathExpr{Path: path, steps: steps}, nil
+}
+
+// Definite true if the path selects at most one element.
+func (m *JsonPathExpr) Definite() bool {
+	return jpDefinite(m.steps)
+}
+
+func jpDefinite(steps []jpStep) bool {
+	for _, s := range steps {
+		if s.descend || (s.kind != jpChild && s.kind != jpIndex) {
+			return false
+		}
+	}
+	return true
+}
+
+// Eval the path against a decoded json document, returning the matches.
+func (m *JsonPathExpr) Eval(doc interface{}) []interface{} {
+	return jpEval(m.steps, doc, doc)
+}
+
+func jpEval(steps []jpStep, root, cur interface{}) []interface{} {
+	nodes := []interface{}{cur}
+	for _, s := range steps {
+		next := make([]interface{}, 0, len(nodes))
+		for _, n := range nodes {
+			if s.descend {
+				jpDescend(n, func(d interface{}) {
+					next = s.apply(root, d, next)
+				})
+				continue
+			}
+			next = s.apply(root, n, next)
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+	return nodes
+}
+
+// jpDescend call fn on n and every descendant of n.
+func jpDescend(n interface{}, fn func(interface{})) {
+	fn(n)
+	switch nt := n.(type) {
+	case map[string]interface{}:
+		for _, k := range jpKeys(nt) {
+			jpDescend(nt[k], fn)
+		}
+	case []interface{}:
+		for _, v := range nt {
+			jpDescend(v, fn)
+		}
+	}
+}
+
+// jpKeys the sorted keys of a map, for stable results.
+func jpKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *jpStep) apply(root, n interface{}, out []interface{}) []interface{} {
+	switch s.kind {
+	case jpChild:
+		if m, ok := n.(map[string]interface{}); ok {
+			if v, ok := m[s.name]; ok {
+				out = append(out, v)
+			}
+		}
+	case jpIndex:
+		if v, ok := jpAt(n, s.index); ok {
+			out = append(out, v)
+		}
+	case jpWild:
+		switch nt := n.(type) {
+		case map[string]interface{}:
+			for _, k := range jpKeys(nt) {
+				out = append(out, nt[k])
+			}
+		case []interface{}:
+			out = append(out, nt...)
+		}
+	case jpUnion:
+		for _, u := range s.union {
+			switch ut := u.(type) {
+			case string:
+				if m, ok := n.(map[string]interface{}); ok {
+					if v, ok := m[ut]; ok {
+						out = append(out, v)
+					}
+				}
+			case int:
+				if v, ok := jpAt(n, ut); ok {
+					out = append(out, v)
+				}
+			}
+		}
+	case jpSlice:
+		arr, ok := n.([]interface{})
+		if !ok {
+			break
+		}
+		start, end, step := 0, len(arr), 1
+		if s.slice[2] != nil {
+			step = *s.slice[2]
+		}
+		if step == 0 {
+			break
+		}
+		if step < 0 {
+			start, end = len(arr)-1, -len(arr)-1
+		}
+		if s.slice[0] != nil {
+			start = *s.slice[0]
+		}
+		if s.slice[1] != nil {
+			end = *s.slice[1]
+		}
+		start, end = jpBound(start, len(arr), step), jpBound(end, len(arr), step)
+		if step > 0 {
+			for i := start; i < end; i += step {
+				out = append(out, arr[i])
+			}
+		} else {
+			for i := start; i > end; i += step {
+				out = append(out, arr[i])
+			}
+		}
+	case jpFilter:
+		switch nt := n.(type) {
+		case []interface{}:
+			for _, v := range nt {
+				if s.filter.match(root, v) {
+					out = append(out, v)
+				}
+			}
+		case map[string]interface{}:
+			for _, k := range jpKeys(nt) {
+				if s.filter.match(root, nt[k]) {
+					out = append(out, nt[k])
+				}
+			}
+		}
+	}
+	return out
+}
+
+func jpAt(n interface{}, i int) (interface{}, bool) {
+	arr, ok := n.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return nil, false
+	}
+	return arr[i], true
+}
+
+// jpBound normalize a slice bound (negative counts from the end) to
+// the array of length l.
+func jpBound(i, l, step int) int {
+	if i < 0 {
+		i += l
+	}
+	lo, hi := 0, l
+	if step < 0 {
+		lo, hi = -1, l-1
+	}
+	if i < lo {
+		return lo
+	}
+	if i > hi {
+		return hi
+	}
+	return i
+}
+
+// jpBool a filter expression.
+type jpBool interface {
+	match(root, cur interface{}) bool
+}
+
+type jpAnd struct{ l, r jpBool }
+type jpOr struct{ l, r jpBool }
+type jpNot struct{ b jpBool }
+
+func (m *jpAnd) match(root, cur interface{}) bool {
+	return m.l.match(root, cur) && m.r.match(root, cur)
+}
+func (m *jpOr) match(root, cur interface{}) bool {
+	return m.l.match(root, cur) || m.r.match(root, cur)
+}
+func (m *jpNot) match(root, cur interface{}) bool { return !m.b.match(root, cur) }
+
+// jpOperand a literal, or a path relative to @ (current) or $ (root).
+type jpOperand struct {
+	literal  interface{}
+	regex    *regexp.Regexp
+	path     []jpStep
+	relative bool
+	isPath   bool
+}
+
+func (m *jpOperand) values(root, cur interface{}) []interface{} {
+	if !m.isPath {
+		return []interface{}{m.literal}
+	}
+	if m.relative {
+		return jpEval(m.path, root, cur)
+	}
+	return jpEval(m.path, root, root)
+}
+
+// jpCompare a comparison, or a single operand existence check
+// (truthy for literals).
+type jpCompare struct {
+	op   string
+	l, r *jpOperand
+}
+
+func (m *jpCompare) match(root, cur interface{}) bool {
+	lv := m.l.values(root, cur)
+	if m.op == "" {
+		if !m.l.isPath {
+			return jsonTruthy(m.l.literal)
+		}
+		return len(lv) > 0
+	}
+	if len(lv) == 0 {
+		return false
+	}
+	l := lv[0]
+	switch m.op {
+	case "=~":
+		s, ok := l.(string)
+		return ok && m.r.regex != nil && m.r.regex.MatchString(s)
+	case "in":
+		rv := m.r.values(root, cur)
+		if len(rv) == 0 {
+			return false
+		}
+		switch rt := rv[0].(type) {
+		case []interface{}:
+			for _, v := range rt {
+				if jsonEqual(l, v) {
+					return true
+				}
+			}
+		case map[string]interface{}:
+			if s, ok := l.(string); ok {
+				_, has := rt[s]
+				return has
+			}
+		}
+		return false
+	}
+	rv := m.r.values(root, cur)
+	if len(rv) == 0 {
+		return false
+	}
+	return jsonCompareOp(m.op, l, rv[0])
+}
+
+type jpParser struct {
+	s   string
+	pos int
+}
+
+func (p *jpParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *jpParser) peek(tok string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.s[p.pos:], tok)
+}
+
+func (p *jpParser) consume(tok string) bool {
+	if p.peek(tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *jpParser) expect(tok string) error {
+	if !p.consume(tok) {
+		if p.pos >= len(p.s) {
+			return fmt.Errorf("expected %q at end", tok)
+		}
+		return fmt.Errorf("expected %q at %q", tok, p.s[p.pos:])
+	}
+	return nil
+}
+
+// steps the steps following $ or @, up to the first char that is not
+// part of a path.
+func (p *jpParser) steps() ([]jpStep, error) {
+	steps := make([]jpStep, 0)
+	for p.pos < len(p.s) {
+		switch {
+		case strings.HasPrefix(p.s[p.pos:], ".."):
+			p.pos += 2
+			step, err := p.member()
+			if err != nil {
+				return nil, err
+			}
+			step.descend = true
+			steps = append(steps, step)
+		case p.s[p.pos] == '.':
+			p.pos++
+			step, err := p.member()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		case p.s[p.pos] == '[':
+			step, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		default:
+			return steps, nil
+		}
+	}
+	return steps, nil
+}
+
+// member name, * or bracket after . or ..
+func (p *jpParser) member() (jpStep, error) {
+	if p.pos < len(p.s) && p.s[p.pos] == '[' {
+		return p.bracket()
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == '*' {
+		p.pos++
+		return jpStep{kind: jpWild}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.s) && jpNameChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return jpStep{}, fmt.Errorf("expected member name at %q", p.s[start:])
+	}
+	return jpStep{kind: jpChild, name: p.s[start:p.pos]}, nil
+}
+
+func jpNameChar(c byte) bool {
+	return c == '_' || c == '-' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *jpParser) bracket() (jpStep, error) {
+	p.pos++ // [
+	switch {
+	case p.consume("*"):
+		return jpStep{kind: jpWild}, p.expect("]")
+	case p.consume("?"):
+		if err := p.expect("("); err != nil {
+			return jpStep{}, err
+		}
+		f, err := p.or()
+		if err != nil {
+			return jpStep{}, err
+		}
+		if err := p.expect(")"); err != nil {
+			return jpStep{}, err
+		}
+		return jpStep{kind: jpFilter, filter: f}, p.expect("]")
+	}
+	items := make([]interface{}, 0, 1)
+	var slice [3]*int
+	part, isSlice := 0, false
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return jpStep{}, fmt.Errorf("unclosed [")
+		}
+		c := p.s[p.pos]
+		switch {
+		case c == '\'' || c == '"':
+			str, err := p.quoted()
+			if err != nil {
+				return jpStep{}, err
+			}
+			items = append(items, str)
+		case c == ':':
+			p.pos++
+			part++
+			isSlice = true
+			if part > 2 {
+				return jpStep{}, fmt.Errorf("too many : in slice")
+			}
+			continue
+		case c == '-' || (c >= '0' && c <= '9'):
+			i, err := p.integer()
+			if err != nil {
+				return jpStep{}, err
+			}
+			if part > 0 || (p.peek(":")) {
+				slice[part] = &i
+				isSlice = true
+			} else {
+				items = append(items, i)
+			}
+		case c == ']':
+		default:
+			return jpStep{}, fmt.Errorf("unexpected %q in []", p.s[p.pos:])
+		}
+		if p.peek(":") {
+			continue
+		}
+		if p.consume(",") {
+			if isSlice {
+				return jpStep{}, fmt.Errorf("slice can not be in a union")
+			}
+			continue
+		}
+		if err := p.expect("]"); err != nil {
+			return jpStep{}, err
+		}
+		break
+	}
+	switch {
+	case isSlice:
+		return jpStep{kind: jpSlice, slice: slice}, nil
+	case len(items) == 0:
+		return jpStep{}, fmt.Errorf("empty []")
+	case len(items) > 1:
+		return jpStep{kind: jpUnion, union: items}, nil
+	}
+	if i, ok := items[0].(int); ok {
+		return jpStep{kind: jpIndex, index: i}, nil
+	}
+	return jpStep{kind: jpChild, name: items[0].(string)}, nil
+}
+
+func (p *jpParser) integer() (int, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	return strconv.Atoi(p.s[start:p.pos])
+}
+
+// quoted a single or double quoted string, with \ escapes.
+func (p *jpParser) quoted() (string, error) {
+	p.skipSpace()
+	q := p.s[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && p.pos < len(p.s):
+			sb.WriteByte(p.s[p.pos])
+			p.pos++
+		case c == q:
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unclosed string")
+}
+
+func (p *jpParser) or() (jpBool, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = &jpOr{l, r}
+	}
+	return l, nil
+}
+
+func (p *jpParser) and() (jpBool, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = &jpAnd{l, r}
+	}
+	return l, nil
+}
+
+func (p *jpParser) unary() (jpBool, error) {
+	if p.peek("!") && !p.peek("!=") {
+		p.pos++
+		b, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &jpNot{b}, nil
+	}
+	if p.consume("(") {
+		b, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return b, p.expect(")")
+	}
+	return p.comparison()
+}
+
+var jpCompareOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">", "in "}
+
+func (p *jpParser) comparison() (jpBool, error) {
+	l, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range jpCompareOps {
+		if !p.consume(op) {
+			continue
+		}
+		op = strings.TrimSpace(op)
+		if op == "=~" {
+			p.skipSpace()
+			re, err := p.regex()
+			if err != nil {
+				return nil, err
+			}
+			return &jpCompare{op: op, l: l, r: &jpOperand{regex: re}}, nil
+		}
+		r, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &jpCompare{op: op, l: l, r: r}, nil
+	}
+	return &jpCompare{l: l}, nil
+}
+
+// regex /pattern/flags or a quoted pattern, flag i is case insensitive.
+func (p *jpParser) regex() (*regexp.Regexp, error) {
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("expected regex")
+	}
+	var pattern string
+	switch p.s[p.pos] {
+	case '/':
+		end := strings.IndexByte(p.s[p.pos+1:], '/')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed regex")
+		}
+		pattern = p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		if p.pos < len(p.s) && p.s[p.pos] == 'i' {
+			pattern = "(?i)" + pattern
+			p.pos++
+		}
+	case '\'', '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		pattern = s
+	default:
+		return nil, fmt.Errorf("expected regex at %q", p.s[p.pos:])
+	}
+	return regexp.Compile(pattern)
+}
+
+func (p *jpParser) operand() (*jpOperand, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("expected operand at end")
+	}
+	c := p.s[p.pos]
+	switch {
+	case c == '@' || c == '$':
+		p.pos++
+		steps, err := p.steps()
+		if err != nil {
+			return nil, err
+		}
+		return &jpOperand{isPath: true, relative: c == '@', path: steps}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return &jpOperand{literal: s}, nil
+	case c == '[' || c == '{':
+		// json literal list/object
+		dec := json.NewDecoder(strings.NewReader(strings.Replace(p.s[p.pos:], "'", `"`, -1)))
+		var lit interface{}
+		if err := dec.Decode(&lit); err != nil {
+			return nil, err
+		}
+		p.pos += int(dec.InputOffset())
+		return &jpOperand{literal: lit}, nil
+	}
+	for _, kw := range []struct {
+		name string
+		v    interface{}
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if strings.HasPrefix(p.s[p.pos:], kw.name) {
+			p.pos += len(kw.name)
+			return &jpOperand{literal: kw.v}, nil
+		}
+	}
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.eE0123456789", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		return nil, fmt.Errorf("expected operand at %q", p.s[start:])
+	}
+	return &jpOperand{literal: f}, nil
+}
+
+// jsonEqual equality of decoded json values, numbers compared as float64.
+func jsonEqual(l, r interface{}) bool {
+	lf, lok := jsonNumber(l)
+	rf, rok := jsonNumber(r)
+	if lok && rok {
+		return lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func jsonNumber(v interface{}) (float64, bool) {
+	switch vt := v.(type) {
+	case float64:
+		return vt, true
+	case int:
+		return float64(vt), true
+	case int64:
+		return float64(vt), true
+	}
+	return 0, false
+}
+
+// jsonCompareOp compare two decoded json values, ordering is only defined
+// between two numbers or two strings.
+func jsonCompareOp(op string, l, r interface{}) bool {
+	switch op {
+	case "==":
+		return jsonEqual(l, r)
+	case "!=":
+		return !jsonEqual(l, r)
+	}
+	var c int
+	lf, lok := jsonNumber(l)
+	rf, rok := jsonNumber(r)
+	ls, lsok := l.(string)
+	rs, rsok := r.(string)
+	switch {
+	case lok && rok:
+		switch {
+		case lf < rf:
+			c = -1
+		case lf > rf:
+			c = 1
+		}
+	case lsok && rsok:
+		c = strings.Compare(ls, rs)
+	default:
+		return false
+	}
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// jsonTruthy false and null are false, everything else true.
+func jsonTruthy(v interface{}) bool {
+	switch vt := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return vt
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	u "github.com/araddon/gou"
 
@@ -16,6 +17,7 @@ var _ = u.EMPTY
 // len length of array types
 //
 //    len([1,2,3])     =>  3, true
+//    len("日本語")      =>  3, true   characters not bytes
 //    len(not_a_field)   =>  -- NilInt, false
 //
 type Length struct{}
@@ -32,7 +34,8 @@ func lenEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 
 	switch node := args[0].(type) {
 	case value.StringValue:
-		return value.NewIntValue(int64(len(node.Val()))), true
+		// characters, not bytes
+		return value.NewIntValue(int64(utf8.RuneCountInString(node.Val()))), true
 	case value.BoolValue:
 		return value.NewIntValue(0), true
 	case value.NumberValue:
@@ -61,6 +64,32 @@ func lenEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 	return value.NewIntNil(), false
 }
 
+// ByteLength the length in bytes of a binary or string value, as sql
+// LENGTH() and OCTET_LENGTH(), where len() counts characters.
+//
+//    length(X'0A1B')    =>  2, true
+//    length("日本")      =>  6, true
+//
+type ByteLength struct{}
+
+// Type is IntType
+func (m *ByteLength) Type() value.ValueType { return value.IntType }
+func (m *ByteLength) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 arg for length(arg) but got %s", n)
+	}
+	return byteLengthEval, nil
+}
+func byteLengthEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	switch val := args[0].(type) {
+	case value.ByteSliceValue:
+		return value.NewIntValue(int64(val.Len())), true
+	case nil, value.NilValue:
+		return value.NewIntNil(), false
+	}
+	return value.NewIntValue(int64(len(args[0].ToString()))), true
+}
+
 // ArrayIndex  array.index choose the nth element of an array
 //
 //     // given context input of
@@ -2,12 +2,17 @@ package builtins
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"unicode"
 
 	u "github.com/araddon/gou"
+	"golang.org/x/text/unicode/norm"
 
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
 )
 
 var _ = u.EMPTY
@@ -145,6 +150,401 @@ func splitEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 	return value.NewStringsValue(strings.Split(sv, splitBy)), true
 }
 
+// Tokenize a string into its full-text terms, by the named analyzer
+// (see schema.RegisterTokenizer), default standard.
+//
+//     tokenize("Hello, World")          => []string{"hello","world"}
+//     tokenize("search", "ngram")       => []string{"sea","ear","arc","rch"}
+//
+type Tokenize struct{}
+
+// Type is Strings
+func (m *Tokenize) Type() value.ValueType { return value.StringsType }
+func (m *Tokenize) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 2 {
+		return nil, fmt.Errorf(`Expected 1 or 2 args for tokenize(text, "analyzer") but got %s`, n)
+	}
+	if len(n.Args) == 1 {
+		t, _ := schema.TokenizerGet(schema.DefaultAnalyzer)
+		return tokenizeEval(t), nil
+	}
+	// analyzer known at validation, check it exists
+	if sn, ok := n.Args[1].(*expr.StringNode); ok {
+		t, ok := schema.TokenizerGet(sn.Text)
+		if !ok {
+			return nil, fmt.Errorf("unknown analyzer %q in %s", sn.Text, n)
+		}
+		return tokenizeEval(t), nil
+	}
+	return tokenizeEval(nil), nil
+}
+
+func tokenizeEval(t schema.Tokenizer) expr.EvaluatorFunc {
+	return func(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+		tokenizer := t
+		if tokenizer == nil {
+			name, ok := value.ValueToString(vals[1])
+			if !ok {
+				return nil, false
+			}
+			if tokenizer, ok = schema.TokenizerGet(name); !ok {
+				return nil, false
+			}
+		}
+		sv, ok := value.ValueToString(vals[0])
+		if !ok {
+			return value.NewStringsValue(make([]string, 0)), false
+		}
+		return value.NewStringsValue(tokenizer.Tokenize(sv)), true
+	}
+}
+
+// Levenshtein edit distance between two strings, the number of single
+// character inserts, deletes, substitutions to turn one into the other.
+//
+//     levenshtein("kitten", "sitting")   => 3
+//     levenshtein("abc", "abc")          => 0
+//
+type Levenshtein struct{}
+
+// Type is Int
+func (m *Levenshtein) Type() value.ValueType { return value.IntType }
+func (m *Levenshtein) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf(`Expected 2 args for levenshtein(a, b) but got %s`, n)
+	}
+	return levenshteinEval, nil
+}
+
+func levenshteinEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Type() == value.NilType || vals[1] == nil || vals[1].Type() == value.NilType {
+		return nil, false
+	}
+	a, ok := value.ValueToString(vals[0])
+	if !ok {
+		return nil, false
+	}
+	b, ok := value.ValueToString(vals[1])
+	if !ok {
+		return nil, false
+	}
+	return value.NewIntValue(int64(LevenshteinDistance(a, b))), true
+}
+
+// LevenshteinDistance edit distance of a, b by runes.
+func LevenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	// single row of the distance matrix, over the shorter string
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cur := row[j]
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			row[j] = min3(row[j]+1, row[j-1]+1, prev+cost)
+			prev = cur
+		}
+	}
+	return row[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// Soundex phonetic code of a string (4 char american soundex), names that
+// sound alike share a code.
+//
+//     soundex("Robert")   => "R163"
+//     soundex("Rupert")   => "R163"
+//
+type Soundex struct{}
+
+// Type is String
+func (m *Soundex) Type() value.ValueType { return value.StringType }
+func (m *Soundex) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf(`Expected 1 arg for soundex(arg) but got %s`, n)
+	}
+	return soundexEval, nil
+}
+
+func soundexEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if vals[0] == nil || vals[0].Type() == value.NilType {
+		return nil, false
+	}
+	sv, ok := value.ValueToString(vals[0])
+	if !ok {
+		return nil, false
+	}
+	return value.NewStringValue(SoundexCode(sv)), true
+}
+
+var soundexCodes = [26]byte{
+	//a  b    c    d    e  f    g    h  i  j    k    l    m    n    o  p    q    r    s    t    u  v    w  x    y  z
+	0, '1', '2', '3', 0, '1', '2', 0, 0, '2', '2', '4', '5', '5', 0, '1', '2', '6', '2', '3', 0, '1', 0, '2', 0, '2',
+}
+
+// SoundexCode the soundex code of s, non-letters are ignored, empty
+// if s has no letters.
+func SoundexCode(s string) string {
+	code := make([]byte, 0, 4)
+	var last byte
+	for _, r := range strings.ToUpper(s) {
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+		c := soundexCodes[r-'A']
+		if len(code) == 0 {
+			code = append(code, byte(r))
+			last = c
+			continue
+		}
+		// h, w do not separate letters of the same code, vowels do
+		if r == 'H' || r == 'W' {
+			continue
+		}
+		if c != 0 && c != last {
+			code = append(code, c)
+			if len(code) == 4 {
+				break
+			}
+		}
+		last = c
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// JaroWinkler similarity of two strings, from 0 (nothing alike) to 1
+// (equal), favoring strings that share a prefix, suited to short strings
+// such as names.
+//
+//     jaro_winkler("MARTHA", "MARHTA")   => 0.961
+//     jaro_winkler("abc", "xyz")         => 0
+//
+type JaroWinkler struct{}
+
+// Type is Number
+func (m *JaroWinkler) Type() value.ValueType { return value.NumberType }
+func (m *JaroWinkler) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf(`Expected 2 args for jaro_winkler(a, b) but got %s`, n)
+	}
+	return jaroWinklerEval, nil
+}
+
+func jaroWinklerEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	a, b, ok := stringPair(vals)
+	if !ok {
+		return nil, false
+	}
+	return value.NewNumberValue(JaroWinklerSimilarity(a, b)), true
+}
+
+// JaroWinklerSimilarity of a, b by runes, the jaro similarity boosted by
+// 0.1 for each of up to 4 leading runes in common.
+func JaroWinklerSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	// runes match if equal and no further apart than half the longer string
+	window := len(ra)
+	if len(rb) > window {
+		window = len(rb)
+	}
+	window = window/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := i-window, i+window+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(rb) {
+			hi = len(rb)
+		}
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	// matched runes out of order, halved
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions/2))/m) / 3
+
+	prefix := 0
+	for prefix < 4 && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Similarity trigram similarity of two strings, the share of their
+// distinct 3 letter sequences in common, from 0 to 1, case insensitive.
+// Strings at or above vm.SimilarityThreshold are similar for the %
+// operator.
+//
+//     similarity("word", "words")            => 0.571
+//     name % "jon smith"                     => similarity(name, "jon smith") >= 0.3
+//     ORDER BY similarity(name, "jon") DESC
+//
+type Similarity struct{}
+
+// Type is Number
+func (m *Similarity) Type() value.ValueType { return value.NumberType }
+func (m *Similarity) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf(`Expected 2 args for similarity(a, b) but got %s`, n)
+	}
+	return similarityEval, nil
+}
+
+func similarityEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	a, b, ok := stringPair(vals)
+	if !ok {
+		return nil, false
+	}
+	return value.NewNumberValue(vm.TrigramSimilarity(a, b)), true
+}
+
+// stringPair the 2 args as strings, not ok if either is nil.
+func stringPair(vals []value.Value) (string, string, bool) {
+	if vals[0] == nil || vals[0].Type() == value.NilType || vals[1] == nil || vals[1].Type() == value.NilType {
+		return "", "", false
+	}
+	a, ok := value.ValueToString(vals[0])
+	if !ok {
+		return "", "", false
+	}
+	b, ok := value.ValueToString(vals[1])
+	if !ok {
+		return "", "", false
+	}
+	return a, b, true
+}
+
+// MatchAgainst full-text relevance of the text of the columns to the
+// search terms, the in-process scorer of MATCH AGAINST for sources that
+// don't search natively (see expr.MatchAgainstFunc).
+//
+//     MATCH(title, body) AGAINST ("quick fox")   => match_against("quick fox", title, body)
+//
+// Text and terms are tokenized by the standard analyzer.  Each term found
+// adds 1 + ln(term frequency), the sum is normalized by the square root of
+// the number of terms of the text, 0 if no term is found.  Terms prefixed
+// with + must be found, - must not be, or the score is 0.
+//
+//     match_against("fox", "the quick fox")        => 0.577
+//     match_against("+quick -dog", "quick dog")    => 0
+//
+type MatchAgainst struct{}
+
+// Type is Number
+func (m *MatchAgainst) Type() value.ValueType { return value.NumberType }
+func (m *MatchAgainst) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 2 {
+		return nil, fmt.Errorf(`Expected MATCH(col, ...) AGAINST ("terms") but got %s`, n)
+	}
+	return matchAgainstEval, nil
+}
+
+func matchAgainstEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	query, ok := value.ValueToString(vals[0])
+	if !ok {
+		return value.NewNumberValue(0), true
+	}
+	var text []string
+	for _, v := range vals[1:] {
+		if v == nil || v.Nil() {
+			continue
+		}
+		if sv, ok := value.ValueToString(v); ok {
+			text = append(text, sv)
+		}
+	}
+	return value.NewNumberValue(MatchScore(query, strings.Join(text, " "))), true
+}
+
+// MatchScore the MATCH AGAINST relevance of text to the search terms of
+// query, see MatchAgainst.
+func MatchScore(query, text string) float64 {
+	terms := schema.StandardTokenize(text)
+	if len(terms) == 0 {
+		return 0
+	}
+	tf := make(map[string]int, len(terms))
+	for _, term := range terms {
+		tf[term]++
+	}
+	score := 0.0
+	seen := make(map[string]bool)
+	for _, word := range strings.Fields(query) {
+		op := word[0]
+		for _, term := range schema.StandardTokenize(word) {
+			switch {
+			case op == '+' && tf[term] == 0:
+				return 0
+			case op == '-' && tf[term] > 0:
+				return 0
+			case op == '-' || seen[term] || tf[term] == 0:
+				continue
+			}
+			seen[term] = true
+			score += 1 + math.Log(float64(tf[term]))
+		}
+	}
+	return score / math.Sqrt(float64(len(terms)))
+}
+
 // Strip a string, removing leading/trailing whitespace
 //
 //    strip(split("apples, oranges ",",")) => {"apples", "oranges"}
@@ -256,6 +656,35 @@ func joinEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 	return value.NewStringValue(strings.Join(args, sep)), true
 }
 
+// Concat strings together, as mysql CONCAT() and the sql || operator,
+// null if any argument is null.
+//
+//   concat("apples","oranges")   => "applesoranges"
+//   concat("id-", 5)             => "id-5"
+//   concat("apples", null)       => nil
+//
+type Concat struct{}
+
+// Type is string
+func (m *Concat) Type() value.ValueType { return value.StringType }
+func (m *Concat) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 {
+		return nil, fmt.Errorf(`Expected 1 or more args for Concat("apples","oranges") but got %s`, n)
+	}
+	return concatEval, nil
+}
+
+func concatEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	var buf strings.Builder
+	for _, v := range vals {
+		if v == nil || v.Nil() {
+			return nil, false
+		}
+		buf.WriteString(v.ToString())
+	}
+	return value.NewStringValue(buf.String()), true
+}
+
 // HasPrefix string evaluation to see if string begins with
 //
 //   hasprefix("apples","ap")   => true
@@ -301,3 +730,142 @@ func hasSuffixEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool)
 	}
 	return value.NewBoolValue(strings.HasSuffix(vals[0].ToString(), suffixStr)), true
 }
+
+// Reverse the characters of a string, rune (not byte) wise so multi-byte
+// characters survive, combining marks stay with their base character.
+//
+//   reverse("abc")     => "cba"
+//   reverse("añb")     => "bña"
+//
+type Reverse struct{}
+
+// Type is string
+func (m *Reverse) Type() value.ValueType { return value.StringType }
+func (m *Reverse) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf(`Expected 1 arg for reverse("apples") but got %s`, n)
+	}
+	return reverseEval, nil
+}
+func reverseEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	val, ok := value.ValueToString(vals[0])
+	if !ok {
+		return value.EmptyStringValue, false
+	}
+	return value.NewStringValue(ReverseString(val)), true
+}
+
+// ReverseString reverse s by character, keeping combining marks (accents)
+// after the character they modify.
+func ReverseString(s string) string {
+	runes := []rune(s)
+	out := make([]rune, 0, len(runes))
+	for end := len(runes); end > 0; {
+		start := end - 1
+		for start > 0 && unicode.Is(unicode.Mn, runes[start]) {
+			start--
+		}
+		out = append(out, runes[start:end]...)
+		end = start
+	}
+	return string(out)
+}
+
+// Substr the characters of a string from start (1 based, negative counts
+// back from the end) for length characters, as sql SUBSTRING.  Counts
+// runes not bytes so multi-byte characters are never split.
+//
+//   substr("apples", 2)        => "pples"
+//   substr("apples", 2, 3)     => "ppl"
+//   substr("apples", -3)       => "les"
+//   substr("日本語", 2, 1)      => "本"
+//
+type Substr struct{}
+
+// Type is string
+func (m *Substr) Type() value.ValueType { return value.StringType }
+func (m *Substr) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 2 || len(n.Args) > 3 {
+		return nil, fmt.Errorf(`Expected 2 or 3 args for substr("apples", start, length) but got %s`, n)
+	}
+	return substrEval, nil
+}
+func substrEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	val, ok := value.ValueToString(vals[0])
+	if !ok {
+		return value.EmptyStringValue, false
+	}
+	start, ok := value.ValueToInt64(vals[1])
+	if !ok {
+		return value.EmptyStringValue, false
+	}
+	runes := []rune(val)
+	switch {
+	case start > int64(len(runes)) || start < -int64(len(runes)):
+		return value.EmptyStringValue, true
+	case start > 0:
+		start--
+	case start < 0:
+		start += int64(len(runes))
+	}
+	end := int64(len(runes))
+	if len(vals) == 3 {
+		length, ok := value.ValueToInt64(vals[2])
+		if !ok {
+			return value.EmptyStringValue, false
+		}
+		if length < 0 {
+			length = 0
+		}
+		if start+length < end {
+			end = start + length
+		}
+	}
+	return value.NewStringValue(string(runes[start:end])), true
+}
+
+// Normalize a string to a unicode normal form, NFC (the default), NFD,
+// NFKC or NFKD, so strings from different sources that look the same
+// compare equal.
+//
+//   len(normalize("café"))          => 4, true   é composed, one character
+//   len(normalize("café", "NFD"))   => 5, true   e + combining accent
+//
+type Normalize struct{}
+
+// Type is string
+func (m *Normalize) Type() value.ValueType { return value.StringType }
+func (m *Normalize) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 2 {
+		return nil, fmt.Errorf(`Expected 1 or 2 args for normalize("apples", "NFC") but got %s`, n)
+	}
+	if len(n.Args) == 1 {
+		return normalizeEval(norm.NFC), nil
+	}
+	sn, ok := n.Args[1].(*expr.StringNode)
+	if !ok {
+		return nil, fmt.Errorf("Expected form of NFC, NFD, NFKC or NFKD for normalize() but got %s", n.Args[1])
+	}
+	form, ok := normForms[strings.ToUpper(sn.Text)]
+	if !ok {
+		return nil, fmt.Errorf("Expected form of NFC, NFD, NFKC or NFKD for normalize() but got %q", sn.Text)
+	}
+	return normalizeEval(form), nil
+}
+
+var normForms = map[string]norm.Form{
+	"NFC":  norm.NFC,
+	"NFD":  norm.NFD,
+	"NFKC": norm.NFKC,
+	"NFKD": norm.NFKD,
+}
+
+func normalizeEval(form norm.Form) expr.EvaluatorFunc {
+	return func(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+		val, ok := value.ValueToString(vals[0])
+		if !ok {
+			return value.EmptyStringValue, false
+		}
+		return value.NewStringValue(form.String(val)), true
+	}
+}
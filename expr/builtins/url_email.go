@@ -363,6 +363,80 @@ func urlDecodeEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool)
 	return value.NewStringValue(val), err == nil
 }
 
+// UrlParse parse a url into a map of its parts, scheme, host, port, path,
+// query, fragment and each query param as param.<name>.  With a part
+// name, only that part.
+//
+//     url_parse("https://www.lytics.io:8080/blog?utm_source=google#top")
+//          => {"scheme":"https", "host":"www.lytics.io", "port":"8080", "path":"/blog",
+//              "query":"utm_source=google", "fragment":"top", "param.utm_source":"google"}
+//     url_parse("www.lytics.io/blog", "host")                    => "www.lytics.io"
+//     url_parse("www.lytics.io/?utm_source=google", "param.utm_source") => "google"
+//
+type UrlParse struct{}
+
+// Type unknown, map or string
+func (m *UrlParse) Type() value.ValueType { return value.UnknownType }
+func (m *UrlParse) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 || len(n.Args) > 2 {
+		return nil, fmt.Errorf("Expected 1 or 2 args for url_parse(url [, part]) but got %s", n)
+	}
+	return urlParseEval, nil
+}
+func urlParseEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+
+	urlstr := ""
+	switch itemT := args[0].(type) {
+	case value.StringValue:
+		urlstr = itemT.Val()
+	case value.Slice:
+		if itemT.Len() == 0 {
+			return nil, false
+		}
+		urlstr = itemT.SliceValue()[0].ToString()
+	}
+	if urlstr == "" {
+		return nil, false
+	}
+	if !strings.Contains(urlstr, "://") && !strings.HasPrefix(urlstr, "/") {
+		urlstr = "http://" + urlstr
+	}
+	up, err := url.Parse(urlstr)
+	if err != nil || (up.Host == "" && up.Path == "") {
+		return nil, false
+	}
+	parts := map[string]string{
+		"scheme":   up.Scheme,
+		"host":     up.Hostname(),
+		"port":     up.Port(),
+		"path":     up.Path,
+		"query":    up.RawQuery,
+		"fragment": up.Fragment,
+	}
+	for k, v := range up.Query() {
+		if len(v) > 0 {
+			parts["param."+k] = v[0]
+		}
+	}
+
+	if len(args) == 1 {
+		return value.NewMapStringValue(parts), true
+	}
+	part, ok := value.ValueToString(args[1])
+	if !ok || part == "" {
+		return nil, false
+	}
+	val, ok := parts[strings.ToLower(part)]
+	if !ok && strings.HasPrefix(strings.ToLower(part), "param.") {
+		// query params are case sensitive
+		val, ok = parts["param."+part[len("param."):]]
+	}
+	if !ok || val == "" {
+		return nil, false
+	}
+	return value.NewStringValue(val), true
+}
+
 // UrlPath Extract url path from a String (must be urlish), doesn't do much/any validation
 //
 //     path("http://www.lytics.io/blog/index.html") =>  blog/index.html
@@ -855,3 +929,51 @@ func userAgentMapEval(ctx expr.EvalContext, args []value.Value) (value.Value, bo
 	out["browser_version"] = version
 	return value.NewMapStringValue(out), true
 }
+
+// UserAgentParse parse a user agent into a map of browser, browser_version,
+// os, platform and device (desktop, mobile, tablet, bot).
+//
+//     user_agent("Mozilla/5.0 (iPhone; CPU iPhone OS 10_3 like Mac OS X) ... Mobile/14E277 Safari/602.1")
+//          => {"browser":"Safari", "browser_version":"10.0", "os":"CPU iPhone OS 10_3 like Mac OS X",
+//              "platform":"iPhone", "device":"mobile"}
+//
+type UserAgentParse struct{}
+
+// Type MapString
+func (m *UserAgentParse) Type() value.ValueType { return value.MapStringType }
+
+func (m *UserAgentParse) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 1 {
+		return nil, fmt.Errorf("Expected 1 args for user_agent(user_agent) but got %s", n)
+	}
+	return userAgentParseEval, nil
+}
+
+func userAgentParseEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	val, ok := value.ValueToString(args[0])
+	if !ok || val == "" {
+		return nil, false
+	}
+	ua := user_agent.New(val)
+	out := make(map[string]string)
+	out["browser"], out["browser_version"] = ua.Browser()
+	out["os"] = ua.OS()
+	out["platform"] = ua.Platform()
+	out["device"] = userAgentDevice(ua, val)
+	return value.NewMapStringValue(out), true
+}
+
+// userAgentDevice the kind of device of the user agent.
+func userAgentDevice(ua *user_agent.UserAgent, raw string) string {
+	lc := strings.ToLower(raw)
+	switch {
+	case ua.Bot():
+		return "bot"
+	case strings.Contains(lc, "ipad") || strings.Contains(lc, "tablet") ||
+		(strings.Contains(lc, "android") && !strings.Contains(lc, "mobile")):
+		return "tablet"
+	case ua.Mobile():
+		return "mobile"
+	}
+	return "desktop"
+}
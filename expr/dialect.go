@@ -151,6 +151,8 @@ func (w *defaultDialect) WriteValue(v value.Value) {
 		w.WriteNull()
 	case value.TimeValue:
 		w.WriteLiteral(vt.Val().String())
+	case value.ByteSliceValue:
+		io.WriteString(w, HexLiteral(vt.Val()))
 	case value.Slice:
 		// If you don't want json, then over-ride this WriteValue
 		by, err := vt.MarshalJSON()
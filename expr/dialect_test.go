@@ -74,6 +74,7 @@ func TestDialectValueWriting(t *testing.T) {
 		{value.NewValue(dateparse.MustParse("2017/08/08")), "\"2017-08-08 00:00:00 +0000 UTC\""},
 		{value.NewValue(22), "22"},
 		{value.NewValue("world"), `"world"`},
+		{value.NewValue([]byte("AB")), `X'4142'`},
 		{value.NewValue(json.RawMessage(`{"name":"world"}`)), `{"name":"world"}`},
 	} {
 		dw := NewDialectWriter('"', '[')
@@ -3,6 +3,8 @@
 package expr
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -181,6 +183,13 @@ type (
 		// If op is 0, and args nil then exactly one of these should be set
 		Identity string `json:"ident,omitempty"`
 		Value    string `json:"val,omitempty"`
+
+		// Type of a literal Value: "number", "string" or "value:<valuetype>"
+		// for a value node whose Value is json, or "binary" for a binary
+		// x AND y.  If empty the type is guessed from the Value, Op.
+		Type string `json:"type,omitempty"`
+		// Paren is a parenthesized binary expression
+		Paren bool `json:"paren,omitempty"`
 		// Really would like to use these instead of un-typed guesses above
 		// if we desire serialization into string representation that is fine
 		// Int      int64
@@ -515,6 +524,31 @@ func ValueTypeFromNode(n Node) value.ValueType {
 	return value.UnknownType
 }
 
+// MatchAgainstFunc name of the function full-text search is parsed as
+//
+//    MATCH(title, body) AGAINST ("terms")   =>  match_against("terms", title, body)
+//
+// it is written back out as MATCH AGAINST so sources pushing sql down to a
+// full-text capable backend (mysql) search natively, sources translating
+// expressions (elasticsearch query_string) recognize it by name.
+const MatchAgainstFunc = "match_against"
+
+// IsOrderedAggFunc is the func an ordered aggregate, group_concat or
+// string_agg, whose args are parsed from
+//
+//     group_concat([DISTINCT] expr [ORDER BY expr [ASC|DESC], ...] [SEPARATOR sep])
+//     string_agg([DISTINCT] expr, sep [ORDER BY expr [ASC|DESC], ...])
+//
+// into (expr, sep, "distinct"|"all", [order_expr, "asc"|"desc"]...) and
+// written back out in the sql form.
+func IsOrderedAggFunc(name string) bool {
+	switch strings.ToLower(name) {
+	case "group_concat", "string_agg":
+		return true
+	}
+	return false
+}
+
 // NewFuncNode create new Function Expression Node.
 func NewFuncNode(name string, f Func) *FuncNode {
 	return &FuncNode{Name: name, F: f}
@@ -530,6 +564,23 @@ func (m *FuncNode) String() string {
 	return w.String()
 }
 func (m *FuncNode) WriteDialect(w DialectWriter) {
+	if len(m.Args) > 1 && strings.ToLower(m.Name) == MatchAgainstFunc {
+		io.WriteString(w, "MATCH(")
+		for i, arg := range m.Args[1:] {
+			if i > 0 {
+				io.WriteString(w, ", ")
+			}
+			arg.WriteDialect(w)
+		}
+		io.WriteString(w, ") AGAINST (")
+		m.Args[0].WriteDialect(w)
+		io.WriteString(w, ")")
+		return
+	}
+	if IsOrderedAggFunc(m.Name) && len(m.Args) >= 3 {
+		m.writeOrderedAgg(w)
+		return
+	}
 	io.WriteString(w, m.Name)
 	io.WriteString(w, "(")
 	for i, arg := range m.Args {
@@ -540,6 +591,37 @@ func (m *FuncNode) WriteDialect(w DialectWriter) {
 	}
 	io.WriteString(w, ")")
 }
+func (m *FuncNode) writeOrderedAgg(w DialectWriter) {
+	groupConcat := strings.ToLower(m.Name) == "group_concat"
+	io.WriteString(w, m.Name)
+	io.WriteString(w, "(")
+	if sn, ok := m.Args[2].(*StringNode); ok && sn.Text == "distinct" {
+		io.WriteString(w, "DISTINCT ")
+	}
+	m.Args[0].WriteDialect(w)
+	sep, isDefault := m.Args[1].(*StringNode)
+	isDefault = isDefault && sep.Text == ","
+	if !groupConcat {
+		io.WriteString(w, ", ")
+		m.Args[1].WriteDialect(w)
+	}
+	for i := 3; i+1 < len(m.Args); i += 2 {
+		if i == 3 {
+			io.WriteString(w, " ORDER BY ")
+		} else {
+			io.WriteString(w, ", ")
+		}
+		m.Args[i].WriteDialect(w)
+		if dir, ok := m.Args[i+1].(*StringNode); ok && dir.Text == "desc" {
+			io.WriteString(w, " DESC")
+		}
+	}
+	if groupConcat && !isDefault {
+		io.WriteString(w, " SEPARATOR ")
+		m.Args[1].WriteDialect(w)
+	}
+	io.WriteString(w, ")")
+}
 func (m *FuncNode) Validate() error {
 
 	if m.F.CustomFunc != nil {
@@ -599,10 +681,8 @@ func (m *FuncNode) FromPB(n *NodePb) Node {
 // Expr convert the FuncNode to Expr
 func (m *FuncNode) Expr() *Expr {
 	fe := &Expr{Op: lex.TokenUdfExpr.String()}
-	if len(m.Args) > 0 {
-		fe.Args = []*Expr{{Identity: m.Name}}
-		fe.Args = append(fe.Args, ExprsFromNodes(m.Args)...)
-	}
+	fe.Args = []*Expr{{Identity: m.Name}}
+	fe.Args = append(fe.Args, ExprsFromNodes(m.Args)...)
 	return fe
 }
 func (m *FuncNode) FromExpr(e *Expr) error {
@@ -733,7 +813,7 @@ func (m *NumberNode) FromPB(n *NodePb) Node {
 	return nn
 }
 func (m *NumberNode) Expr() *Expr {
-	return &Expr{Value: m.Text}
+	return &Expr{Value: m.Text, Type: exprTypeNumber}
 }
 func (m *NumberNode) FromExpr(e *Expr) error {
 	if len(e.Value) > 0 {
@@ -821,6 +901,11 @@ func (m *StringNode) FromPB(n *NodePb) Node {
 	}
 }
 func (m *StringNode) Expr() *Expr {
+	switch strings.ToLower(m.Text) {
+	case "", "true", "false", "null":
+		// would otherwise be read back as a boolean identity, null
+		return &Expr{Value: m.Text, Type: exprTypeString}
+	}
 	return &Expr{Value: m.Text}
 }
 func (m *StringNode) FromExpr(e *Expr) error {
@@ -851,6 +936,15 @@ func (m *StringNode) Equal(n Node) bool {
 func NewValueNode(val value.Value) *ValueNode {
 	return &ValueNode{Value: val, rv: reflect.ValueOf(val)}
 }
+
+// NewValueNodeHex a binary value of the hex digits of token X'ABCD'.
+func NewValueNodeHex(t lex.Token) (*ValueNode, error) {
+	by, err := hex.DecodeString(t.V)
+	if err != nil {
+		return nil, err
+	}
+	return NewValueNode(value.NewByteSliceValue(by)), nil
+}
 func (m *ValueNode) NodeType() string { return "Value" }
 func (m *ValueNode) IsArray() bool {
 	if m.Value == nil {
@@ -872,9 +966,16 @@ func (m *ValueNode) String() string {
 	case value.SliceValue:
 		vals := make([]string, vt.Len())
 		for i, v := range vt.Val() {
-			vals[i] = fmt.Sprintf("%q", v.ToString())
+			switch v.(type) {
+			case value.NumberValue, value.IntValue:
+				vals[i] = v.ToString()
+			default:
+				vals[i] = fmt.Sprintf("%q", v.ToString())
+			}
 		}
 		return fmt.Sprintf("[%s]", strings.Join(vals, ", "))
+	case value.ByteSliceValue:
+		return HexLiteral(vt.Val())
 	}
 	return m.Value.ToString()
 }
@@ -906,6 +1007,8 @@ func (m *ValueNode) WriteDialect(w DialectWriter) {
 		w.WriteNumber(vt.ToString())
 	case value.BoolValue:
 		w.WriteLiteral(vt.ToString())
+	case value.ByteSliceValue:
+		w.WriteValue(vt)
 	default:
 		u.Warnf("unsupported value-node writer: %T", vt)
 		io.WriteString(w, vt.ToString())
@@ -913,17 +1016,37 @@ func (m *ValueNode) WriteDialect(w DialectWriter) {
 }
 func (m *ValueNode) Validate() error { return nil }
 func (m *ValueNode) NodePb() *NodePb {
-	u.Errorf("Not implemented %#v", m)
-	return nil
+	by, err := json.Marshal(m.Value)
+	if err != nil {
+		u.Errorf("could not json encode value %#v err=%v", m.Value, err)
+	}
+	return &NodePb{Vn: &ValueNodePb{Valuetype: int32(m.Value.Type()), Value: by}}
 }
 func (m *ValueNode) FromPB(n *NodePb) Node {
-	u.Errorf("Not implemented %#v", n)
-	return &ValueNode{}
+	v, err := valueFromJson(value.ValueType(n.Vn.Valuetype), n.Vn.Value)
+	if err != nil {
+		u.Warnf("could not decode value %s err=%v", n.Vn.Value, err)
+		return &ValueNode{Value: value.NilValueVal}
+	}
+	return NewValueNode(v)
 }
 func (m *ValueNode) Expr() *Expr {
-	return &Expr{Value: m.Value.ToString()}
+	by, err := json.Marshal(m.Value)
+	if err != nil {
+		return &Expr{Value: m.Value.ToString()}
+	}
+	return &Expr{Value: string(by), Type: exprTypeValue + m.Value.Type().String()}
 }
 func (m *ValueNode) FromExpr(e *Expr) error {
+	if strings.HasPrefix(e.Type, exprTypeValue) {
+		vt := value.ValueFromString(strings.TrimPrefix(e.Type, exprTypeValue))
+		v, err := valueFromJson(vt, []byte(e.Value))
+		if err != nil {
+			return err
+		}
+		m.Value, m.rv = v, reflect.ValueOf(v)
+		return nil
+	}
 	if len(e.Value) > 0 {
 		m.Value = value.NewStringValue(e.Value)
 		return nil
@@ -941,10 +1064,13 @@ func (m *ValueNode) Equal(n Node) bool {
 		return false
 	}
 	if nt, ok := n.(*ValueNode); ok {
-		if m.Value.Value() != nt.Value.Value() {
+		if m.Value == nil || nt.Value == nil {
+			return m.Value == nt.Value
+		}
+		if m.Value.Type() != nt.Value.Type() {
 			return false
 		}
-		return true
+		return reflect.DeepEqual(m.Value.Value(), nt.Value.Value())
 	}
 	return false
 }
@@ -1255,7 +1381,14 @@ func (m *BinaryNode) writeToString(w DialectWriter, negate string) {
 	}
 
 	io.WriteString(w, " ")
-	m.Args[1].WriteDialect(w)
+	if _, isFilter := m.Args[1].(*BooleanNode); isFilter && m.Operator.T == lex.TokenIN {
+		// nested filter   x IN (AND ( a, b ))
+		io.WriteString(w, "(")
+		m.Args[1].WriteDialect(w)
+		io.WriteString(w, ")")
+	} else {
+		m.Args[1].WriteDialect(w)
+	}
 	if m.Paren {
 		io.WriteString(w, ")")
 	}
@@ -1344,7 +1477,12 @@ func (m *BinaryNode) FromPB(n *NodePb) Node {
 	}
 }
 func (m *BinaryNode) Expr() *Expr {
-	fe := &Expr{Op: strings.ToLower(m.Operator.V)}
+	fe := &Expr{Op: strings.ToLower(m.Operator.V), Paren: m.Paren}
+	switch m.Operator.T {
+	case lex.TokenLogicAnd, lex.TokenLogicOr:
+		// not the n-ary AND ( ... ) boolean node
+		fe.Type = exprTypeBinary
+	}
 	if len(m.Args) > 0 {
 		fe.Args = ExprsFromNodes(m.Args)
 	}
@@ -1357,7 +1495,8 @@ func (m *BinaryNode) FromExpr(e *Expr) error {
 	if e.Op == "" {
 		return fmt.Errorf("unrecognized BinaryNode")
 	}
-	m.Operator = lex.TokenFromOp(e.Op)
+	m.Operator = tokenFromOp(e.Op)
+	m.Paren = e.Paren
 	if len(e.Args) == 0 {
 		return fmt.Errorf("Invalid BinaryNode, expected args %+v", e)
 	}
@@ -1483,7 +1622,7 @@ func (m *BooleanNode) NodePb() *NodePb {
 	for _, arg := range m.Args {
 		n.Args = append(n.Args, *arg.NodePb())
 	}
-	return &NodePb{Booln: n}
+	return negatedPb(m.negated, &NodePb{Booln: n})
 }
 func (m *BooleanNode) FromPB(n *NodePb) Node {
 	return &BooleanNode{
@@ -1505,7 +1644,7 @@ func (m *BooleanNode) FromExpr(e *Expr) error {
 	if e.Op == "" {
 		return fmt.Errorf("unrecognized BooleanNode op")
 	}
-	m.Operator = lex.TokenFromOp(e.Op)
+	m.Operator = tokenFromOp(e.Op)
 	if len(e.Args) == 0 {
 		return fmt.Errorf("Invalid BooleanNode, expected args %+v", e)
 	}
@@ -1530,6 +1669,9 @@ func (m *BooleanNode) Equal(n Node) bool {
 		if nt.Operator.T != m.Operator.T {
 			return false
 		}
+		if nt.negated != m.negated {
+			return false
+		}
 		if nt.Operator.V != m.Operator.V {
 			if strings.ToLower(nt.Operator.V) != strings.ToLower(m.Operator.V) {
 				return false
@@ -1562,19 +1704,19 @@ func (m *TriNode) ReverseNegation() bool {
 }
 func (m *TriNode) String() string {
 	w := NewDefaultWriter()
-	m.writeToString(w, false)
+	m.writeToString(w, m.negated)
 	return w.String()
 }
 func (m *TriNode) StringNegate() string {
 	w := NewDefaultWriter()
-	m.writeToString(w, true)
+	m.writeToString(w, !m.negated)
 	return w.String()
 }
 func (m *TriNode) WriteNegate(w DialectWriter) {
-	m.writeToString(w, true)
+	m.writeToString(w, !m.negated) // double negation
 }
 func (m *TriNode) WriteDialect(w DialectWriter) {
-	m.writeToString(w, false)
+	m.writeToString(w, m.negated)
 }
 func (m *TriNode) writeToString(w DialectWriter, negate bool) {
 	m.Args[0].WriteDialect(w)
@@ -1610,7 +1752,7 @@ func (m *TriNode) NodePb() *NodePb {
 		n.Args[i] = *arg.NodePb()
 		//u.Debugf("TriNode NodePb: %T", arg)
 	}
-	return &NodePb{Tn: n}
+	return negatedPb(m.negated, &NodePb{Tn: n})
 }
 func (m *TriNode) FromPB(n *NodePb) Node {
 	return &TriNode{
@@ -1632,7 +1774,7 @@ func (m *TriNode) FromExpr(e *Expr) error {
 	if e.Op == "" {
 		return fmt.Errorf("unrecognized TriNode no op")
 	}
-	m.Operator = lex.TokenFromOp(e.Op)
+	m.Operator = tokenFromOp(e.Op)
 	if len(e.Args) == 0 {
 		return fmt.Errorf("Invalid TriNode, expected args %+v", e)
 	}
@@ -1660,6 +1802,9 @@ func (m *TriNode) Equal(n Node) bool {
 		if nt.Operator.T != m.Operator.T {
 			return false
 		}
+		if nt.negated != m.negated {
+			return false
+		}
 		if len(m.Args) != len(nt.Args) {
 			return false
 		}
@@ -1745,13 +1890,16 @@ func (m *UnaryNode) NodePb() *NodePb {
 	return &NodePb{Un: n}
 }
 func (m *UnaryNode) FromPB(n *NodePb) Node {
-	return &UnaryNode{
-		Operator: tokenFromInt(n.Un.Op),
-		Arg:      NodeFromNodePb(&n.Un.Arg),
+	op := tokenFromInt(n.Un.Op)
+	arg := NodeFromNodePb(&n.Un.Arg)
+	if op.T == lex.TokenNegate {
+		// negated nodes are serialized as NOT of the node
+		return NewUnary(op, arg)
 	}
+	return &UnaryNode{Operator: op, Arg: arg}
 }
 func (m *UnaryNode) Expr() *Expr {
-	fe := &Expr{Op: strings.ToLower(m.Operator.V)}
+	fe := &Expr{Op: strings.ToLower(m.Operator.T.String())}
 	fe.Args = []*Expr{m.Arg.Expr()}
 	return fe
 }
@@ -1759,7 +1907,10 @@ func (m *UnaryNode) FromExpr(e *Expr) error {
 	if e.Op == "" {
 		return fmt.Errorf("unrecognized UnaryNode no op")
 	}
-	m.Operator = lex.TokenFromOp(e.Op)
+	m.Operator = tokenFromOp(e.Op)
+	if e.Op == "!" {
+		m.Operator = lex.Token{T: lex.TokenNegate, V: e.Op}
+	}
 	if m.Operator.T == lex.TokenNil {
 		return fmt.Errorf("Unrecognized op %v", e.Op)
 	}
@@ -1870,7 +2021,7 @@ func (m *IncludeNode) FromExpr(e *Expr) error {
 	if e.Op == "" {
 		return fmt.Errorf("Invalid IncludeNode %+v", e)
 	}
-	m.Operator = lex.TokenFromOp(e.Op)
+	m.Operator = tokenFromOp(e.Op)
 	if m.Operator.T == lex.TokenNil {
 		return fmt.Errorf("Unrecognized op %v", e.Op)
 	}
@@ -1970,9 +2121,13 @@ func (m *ArrayNode) NodePb() *NodePb {
 	return &NodePb{An: n}
 }
 func (m *ArrayNode) FromPB(n *NodePb) Node {
-	return &ArrayNode{
+	an := &ArrayNode{
 		Args: NodesFromNodesPb(n.An.Args),
 	}
+	if n.An.Wrap != nil && *n.An.Wrap > 0 {
+		an.wraptype = string(rune(*n.An.Wrap))
+	}
+	return an
 }
 func (m *ArrayNode) Expr() *Expr {
 	fe := &Expr{}
@@ -2020,6 +2175,29 @@ func (m *ArrayNode) Equal(n Node) bool {
 }
 
 // Node serialization helpers
+
+// negatedPb the pb of a negated node is a NOT of the node.
+func negatedPb(negated bool, n *NodePb) *NodePb {
+	if !negated {
+		return n
+	}
+	return &NodePb{Un: &UnaryNodePb{Op: int32(lex.TokenNegate), Arg: *n}}
+}
+
+// tokenFromOp the operator token of the op of an Expr, upper-cased as parsed.
+func tokenFromOp(op string) lex.Token {
+	t := lex.TokenFromOp(strings.ToLower(op))
+	switch t.T {
+	case lex.TokenNil:
+		return t
+	case lex.TokenStar:
+		// same keyword, * is only an operator as multiply
+		t.T = lex.TokenMultiply
+	}
+	t.V = strings.ToUpper(op)
+	return t
+}
+
 func tokenFromInt(iv int32) lex.Token {
 	t, ok := lex.TokenNameMap[lex.TokenType(iv)]
 	if ok {
@@ -2104,30 +2282,26 @@ func NodesPbFromNodes(nodes []Node) []*NodePb {
 	}
 	return pbs
 }
-func NodesEqual(n1, n2 Node) bool {
-	switch n1t := n1.(type) {
-	case *BinaryNode:
-		if n2t, ok := n2.(*BinaryNode); ok {
-			return n1t.Equal(n2t)
-		}
-	case *UnaryNode:
-
-	case *FuncNode:
 
-	case *TriNode:
-
-	case *ArrayNode:
-
-	case *NumberNode:
-
-	case *ValueNode:
-
-	case *IdentityNode:
-
-	case *StringNode:
+// NodesEqual deep equality of two expression trees, they are of the same
+// node types, operators, negation and literal values.  Differences only of
+// dialect such as identity or string quoting are ignored.
+func NodesEqual(n1, n2 Node) bool {
+	if n1 == nil || n2 == nil {
+		return isNilNode(n1) && isNilNode(n2)
+	}
+	if reflect.TypeOf(n1) != reflect.TypeOf(n2) {
+		return false
+	}
+	return n1.Equal(n2)
+}
 
+func isNilNode(n Node) bool {
+	if n == nil {
+		return true
 	}
-	return false
+	rv := reflect.ValueOf(n)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
 }
 
 func ExprsFromNodes(nodes []Node) []*Expr {
@@ -2191,25 +2365,34 @@ func NodeFromExpr(e *Expr) (Node, error) {
 			n = &FuncNode{}
 		case "AND", "OR":
 			// bool
-			n = &BooleanNode{}
+			if e.Type == exprTypeBinary {
+				n = &BinaryNode{}
+			} else {
+				n = &BooleanNode{}
+			}
 		case "INCLUDE":
 			n = &IncludeNode{}
-		case "NOT":
+		case "NOT", "!":
 			// This is a special Case, it is possible its urnary
 			// but in general we can collapse it
 			n = &UnaryNode{}
-		case "EXISTS":
+		case "EXISTS", "IS":
 			n = &UnaryNode{}
 		case "BETWEEN":
 			n = &TriNode{}
 		case "=", "-", "+", "++", "+=", "/", "%", "==", "<=", "!=", ">=", ">", "<", "*",
-			"LIKE", "CONTAINS", "INTERSECTS", "IN":
+			"&&", "||", "LIKE", "CONTAINS", "INTERSECTS", "IN":
 
 			// very weird special case for FILTER * where the * is an ident not op
 			if e.Op == "*" && len(e.Args) == 0 {
 				n = &IdentityNode{Text: e.Op, right: e.Op}
 				return n, nil
 			}
+			if len(e.Args) == 1 {
+				// unary minus, plus
+				n = &UnaryNode{}
+				break
+			}
 			n = &BinaryNode{}
 		}
 		if n == nil {
@@ -2223,6 +2406,11 @@ func NodeFromExpr(e *Expr) (Node, error) {
 
 		//u.Debugf("%T  %s", n, n)
 
+		// NOT of a negateable node is collapsed into it, as the parser does
+		if un, ok := n.(*UnaryNode); ok && un.Operator.T == lex.TokenNegate {
+			return NewUnary(un.Operator, un.Arg), nil
+		}
+
 		// Negateable nodes possibly can be collapsed to simpler form
 		nn, isNegateable := n.(NegateableNode)
 		if isNegateable {
@@ -2235,10 +2423,23 @@ func NodeFromExpr(e *Expr) (Node, error) {
 		n = &IdentityNode{}
 		return n, n.FromExpr(e)
 	}
+	switch {
+	case e.Type == exprTypeNumber:
+		n = &NumberNode{}
+		return n, n.FromExpr(e)
+	case e.Type == exprTypeString:
+		n = &StringNode{}
+		return n, n.FromExpr(e)
+	case strings.HasPrefix(e.Type, exprTypeValue):
+		n = &ValueNode{}
+		return n, n.FromExpr(e)
+	}
 	if e.Value != "" {
-		switch e.Value {
+		switch strings.ToLower(e.Value) {
 		case "true", "false":
 			n = &IdentityNode{}
+		case "null":
+			n = &NullNode{}
 		default:
 			n = &StringNode{}
 		}
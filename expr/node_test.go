@@ -69,6 +69,82 @@ func TestExprRoundTrip(t *testing.T) {
 	}
 }
 
+var roundTripTests = []string{
+	`x NOT IN ("a","b")`,
+	`x IN ["a","b"]`,
+	`NOT x BETWEEN 1 AND 5`,
+	`(a + b) * 5`,
+	`NOT (a AND b)`,
+	`NOT AND (a, b)`,
+	`!eq(a,b)`,
+	`x > -5`,
+	`x = ""`,
+	`x == "NULL"`,
+	`x = null`,
+	`[1,2,"a"]`,
+	`x IN (1, "b", true, null)`,
+	`now()`,
+	`a && b`,
+	`x = X'0A1B'`,
+	`x IN (X'41', "b")`,
+}
+
+func TestNodeSerializationRoundTrip(t *testing.T) {
+	t.Parallel()
+	qls := append([]string{}, roundTripTests...)
+	for _, et := range exprTests {
+		if et.ok {
+			qls = append(qls, et.qlText)
+		}
+	}
+	for _, ql := range qls {
+		exp, err := expr.ParseExpression(ql)
+		assert.Equal(t, nil, err, ql)
+
+		// text is canonical
+		n, err := expr.ParseExpression(exp.String())
+		assert.Equal(t, nil, err, ql)
+		assert.True(t, expr.NodesEqual(exp, n), "text %s != %s", exp, n)
+		assert.Equal(t, exp.String(), n.String())
+
+		by, err := expr.NodeToJson(exp)
+		assert.Equal(t, nil, err, ql)
+		n, err = expr.NodeFromJson(by)
+		assert.Equal(t, nil, err, ql)
+		assert.True(t, expr.NodesEqual(exp, n), "json %s != %s  %s", exp, n, by)
+
+		by, err = expr.NodeToPb(exp)
+		assert.Equal(t, nil, err, ql)
+		n, err = expr.NodeFromPb(by)
+		assert.Equal(t, nil, err, ql)
+		assert.True(t, expr.NodesEqual(exp, n), "pb %s != %s", exp, n)
+	}
+
+	// json of older versions, without literal types
+	n, err := expr.NodeFromJson([]byte(`{"op":"=","args":[{"ident":"x"},{"val":"NULL"}]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "x = NULL", n.String())
+}
+
+func TestNodesEqual(t *testing.T) {
+	t.Parallel()
+	eq := func(a, b string) bool {
+		return expr.NodesEqual(expr.MustParse(a), expr.MustParse(b))
+	}
+	assert.True(t, eq(`x = 'a'`, `x = "a"`))
+	assert.True(t, eq("`x` > 5", `x > 5`))
+	assert.True(t, !eq(`x > 5`, `x > 6`))
+	assert.True(t, !eq(`x > 5`, `x >= 5`))
+	assert.True(t, !eq(`x BETWEEN 1 AND 5`, `NOT x BETWEEN 1 AND 5`))
+	assert.True(t, !eq(`AND (a, b)`, `NOT AND (a, b)`))
+	assert.True(t, !eq(`x = 5`, `x = "5"`))
+	assert.True(t, !eq(`x IN [1,2]`, `x IN [1,3]`))
+	assert.True(t, expr.NodesEqual(nil, nil))
+	var fn *expr.FuncNode
+	assert.True(t, expr.NodesEqual(nil, fn))
+	assert.True(t, !expr.NodesEqual(expr.MustParse(`x`), nil))
+}
+
 func TestNodeJson(t *testing.T) {
 	t.Parallel()
 	for _, exprText := range pbTests {
@@ -9,6 +9,7 @@ import (
 
 	u "github.com/araddon/gou"
 	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/sqlerr"
 	"github.com/araddon/qlbridge/value"
 )
 
@@ -31,6 +32,17 @@ func debugf(depth int, f string, args ...interface{}) {
 	}
 }
 
+// dateWindowUnits date math units of the relative date window units.
+var dateWindowUnits = map[string]string{
+	"second": "s",
+	"minute": "m",
+	"hour":   "h",
+	"day":    "d",
+	"week":   "w",
+	"month":  "M",
+	"year":   "y",
+}
+
 // We have a default Dialect, which is the "Language" or rule-set of ql
 var DefaultDialect *lex.Dialect = lex.LogicalExpressionDialect
 
@@ -244,8 +256,16 @@ func (t *tree) expectOneOf(expected1, expected2 lex.TokenType, context string) l
 
 // unexpected complains about the token and terminates processing.
 func (t *tree) unexpected(token lex.Token, msg string) {
-	err := token.ErrMsg(t.Lexer(), msg)
-	panic(err.Error())
+	panic(token.ErrMsg(t.Lexer(), msg))
+}
+
+// binary node of the operator, terminates processing if the operator
+// has no right hand side (ie, "SELECT 1 +").
+func (t *tree) binary(op lex.Token, lh, rh Node) Node {
+	if rh == nil {
+		t.unexpected(t.Cur(), fmt.Sprintf("expected expression after %s", op.V))
+	}
+	return NewBinaryNode(op, lh, rh)
 }
 
 // recover is the handler that turns panics into returns from the top level of Parse.
@@ -265,7 +285,15 @@ func (t *tree) recover(errp *error) {
 func (t *tree) parse() (_ Node, err error) {
 	defer func() {
 		if p := recover(); p != nil {
-			err = fmt.Errorf("parse error: %v", p)
+			perr := sqlerr.New(sqlerr.ErParse, "parse error: %v", p)
+			if e, ok := p.(*sqlerr.Error); ok && e.Line > 0 {
+				perr.At(e.Line, e.Column, e.Pos)
+			} else if cur := t.Cur(); cur.Line > 0 {
+				// ie func validation, at the token we stopped on
+				col, pos := cur.Start()
+				perr.At(cur.Line, col, pos)
+			}
+			err = perr
 		}
 	}()
 	return t.O(0), err
@@ -289,7 +317,7 @@ TODO:
  - if/else, case, for
  - call stack & vars
 --------------------------------------
-O -> A {( "||" | OR  ) A}
+O -> A {( "||" | OR  ) A} | <@identity> ":=" O
 A -> C {( "&&" | AND ) C}
 C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=" | "LIKE" | "IN" | "CONTAINS" | "INTERSECTS") P}
 P -> M {( "+" | "-" ) M}
@@ -327,7 +355,15 @@ func (t *tree) O(depth int) Node {
 		switch tok.T {
 		case lex.TokenLogicOr, lex.TokenOr:
 			t.Next()
-			n = NewBinaryNode(tok, n, t.A(depth+1))
+			n = t.binary(tok, n, t.A(depth+1))
+		case lex.TokenAssign:
+			// @var := expr   right associative, lowest precedence
+			in, ok := n.(*IdentityNode)
+			if !ok || !strings.HasPrefix(in.Text, "@") {
+				t.errorf("expected @variable on left side of := but got %s", n)
+			}
+			t.Next()
+			n = NewBinaryNode(tok, n, t.O(depth+1))
 		case lex.TokenCommentSingleLine:
 			t.Next() // consume --
 			t.Next() // consume comment after --
@@ -355,7 +391,7 @@ func (t *tree) A(depth int) Node {
 			}
 			t.Next()
 			debugf(depth, "AND pre-binary n=%s", n)
-			n = NewBinaryNode(tok, n, t.C(depth+1))
+			n = t.binary(tok, n, t.C(depth+1))
 			debugf(depth, "and post %s", n)
 		default:
 			return n
@@ -395,7 +431,16 @@ func (t *tree) cInner(n Node, depth int) Node {
 		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE,
 			lex.TokenLE, lex.TokenLT, lex.TokenLike, lex.TokenContains:
 			t.Next()
-			n = NewBinaryNode(cur, n, t.P(depth+1))
+			n = t.binary(cur, n, t.P(depth+1))
+		case lex.TokenSoundsLike:
+			// x SOUNDS LIKE y   is   soundex(x) = soundex(y)
+			t.Next()
+			eq := lex.Token{T: lex.TokenEqual, V: "=", Line: cur.Line, Column: cur.Column, Pos: cur.Pos}
+			n = NewBinaryNode(eq, t.soundex(n), t.soundex(t.P(depth+1)))
+		case lex.TokenAgainst:
+			// MATCH(title, body) AGAINST ("terms")
+			t.Next()
+			n = t.matchAgainst(cur, n, t.P(depth+1))
 		case lex.TokenBetween:
 			// weird syntax:    BETWEEN x AND y     AND is ignored essentially
 			t.Next()
@@ -407,8 +452,18 @@ func (t *tree) cInner(n Node, depth int) Node {
 			t.Next()
 			switch t.Cur().T {
 			case lex.TokenIdentity:
+				if t.Peek().T == lex.TokenInteger {
+					return t.dateWindow(cur, n)
+				}
 				ident := t.Next()
 				return NewBinaryNode(cur, n, NewIdentityNode(&ident))
+			case lex.TokenLeftParenthesis:
+				switch t.Peek().T {
+				case lex.TokenLogicAnd, lex.TokenLogicOr:
+					return NewBinaryNode(cur, n, t.nestedFilter(depth))
+				}
+				// This is a special type of Binary? its 2nd argument is a array node
+				return NewBinaryNode(cur, n, t.ArrayNode(depth))
 			case lex.TokenLeftBracket:
 				// Right side is an array of values
 				t.Next()
@@ -417,9 +472,6 @@ func (t *tree) cInner(n Node, depth int) Node {
 					t.errorf("Could not build an array: %v", err)
 				}
 				return NewBinaryNode(cur, n, NewValueNode(val))
-			case lex.TokenLeftParenthesis:
-				// This is a special type of Binary? its 2nd argument is a array node
-				return NewBinaryNode(cur, n, t.ArrayNode(depth))
 			case lex.TokenUdfExpr:
 				fn := t.Next() // consume Function Name
 				return NewBinaryNode(cur, n, t.Func(depth, fn))
@@ -461,6 +513,48 @@ func (t *tree) cInner(n Node, depth int) Node {
 	}
 }
 
+// dateWindow the relative date window right side of IN, as a BETWEEN
+// of date math.
+//
+//    created IN LAST 7 DAYS    =>  created BETWEEN "now-7d" AND "now"
+//    renewal IN NEXT 1 MONTH   =>  renewal BETWEEN "now" AND "now+1M"
+func (t *tree) dateWindow(in lex.Token, n Node) Node {
+	dir := t.Next()
+	ct := t.Next()
+	unitTok := t.expect(lex.TokenIdentity, "date window unit")
+	t.Next()
+	unit, ok := dateWindowUnits[strings.TrimSuffix(strings.ToLower(unitTok.V), "s")]
+	if !ok {
+		t.errorf("Unrecognized date window unit %q", unitTok.V)
+	}
+	between := lex.Token{T: lex.TokenBetween, V: "BETWEEN", Line: in.Line, Column: in.Column, Pos: in.Pos}
+	switch strings.ToLower(dir.V) {
+	case "last":
+		return NewTriNode(between, n, NewStringNode("now-"+ct.V+unit), NewStringNode("now"))
+	case "next":
+		return NewTriNode(between, n, NewStringNode("now"), NewStringNode("now+"+ct.V+unit))
+	}
+	t.errorf("Expected LAST or NEXT for date window but got %q", dir.V)
+	return nil
+}
+
+// nestedFilter the boolean filter right side of IN, matched against each
+// element of the left side.
+//
+//    orders IN ( AND ( total > 100, status == "paid" ) )
+func (t *tree) nestedFilter(depth int) *BooleanNode {
+	t.Next() // Consume Left Paren
+	op := t.Cur()
+	n := t.F(depth + 1)
+	t.expect(lex.TokenRightParenthesis, "nested filter")
+	t.Next()
+	if bn, ok := n.(*BooleanNode); ok {
+		return bn
+	}
+	// single argument AND/OR were collapsed
+	return NewBooleanNode(op, n)
+}
+
 func (t *tree) P(depth int) Node {
 	debugf(depth, "P pre : %v", t.Cur())
 	n := t.M(depth)
@@ -469,7 +563,11 @@ func (t *tree) P(depth int) Node {
 		switch cur := t.Cur(); cur.T {
 		case lex.TokenPlus, lex.TokenMinus:
 			t.Next()
-			n = NewBinaryNode(cur, n, t.M(depth+1))
+			n = t.binary(cur, n, t.M(depth+1))
+		case lex.TokenConcat:
+			// a || b || c   is   concat(a, b, c)
+			t.Next()
+			n = t.concat(n, t.M(depth+1))
 		default:
 			return n
 		}
@@ -484,7 +582,7 @@ func (t *tree) M(depth int) Node {
 		switch cur := t.Cur(); cur.T {
 		case lex.TokenStar, lex.TokenMultiply, lex.TokenDivide, lex.TokenModulus:
 			t.Next()
-			n = NewBinaryNode(cur, n, t.F(depth+1))
+			n = t.binary(cur, n, t.F(depth+1))
 		default:
 			return n
 		}
@@ -608,6 +706,13 @@ func (t *tree) v(depth int) Node {
 		n := NewStringNeedsEscape(cur)
 		t.Next()
 		return n
+	case lex.TokenValueHex:
+		n, err := NewValueNodeHex(cur)
+		if err != nil {
+			t.error(err)
+		}
+		t.Next()
+		return n
 	case lex.TokenIdentity:
 		n := NewIdentityNode(&cur)
 		t.Next() // Consume identity
@@ -654,6 +759,77 @@ func (t *tree) v(depth int) Node {
 	return nil
 }
 
+// matchAgainst the match_against(terms, cols...) function of the
+// MATCH(cols) AGAINST (terms) full-text search.
+func (t *tree) matchAgainst(cur lex.Token, match, terms Node) *FuncNode {
+	mfn, ok := match.(*FuncNode)
+	if !ok || strings.ToLower(mfn.Name) != "match" || len(mfn.Args) == 0 {
+		t.unexpected(cur, "AGAINST must follow MATCH(col, ...)")
+	}
+	funcImpl, ok := t.getFunction(MatchAgainstFunc)
+	if !ok {
+		if t.funcCheck {
+			t.errorf("non existent function %s for MATCH AGAINST", MatchAgainstFunc)
+		}
+		funcImpl = Func{Name: MatchAgainstFunc, Eval: EmptyEvalFunc}
+	}
+	fn := NewFuncNode(MatchAgainstFunc, funcImpl)
+	fn.Missing = !ok
+	fn.append(terms)
+	for _, col := range mfn.Args {
+		fn.append(col)
+	}
+	if err := fn.Validate(); err != nil {
+		t.error(err)
+	}
+	return fn
+}
+
+// soundex wrap arg in the soundex() function, for SOUNDS LIKE.
+func (t *tree) soundex(arg Node) *FuncNode {
+	funcImpl, ok := t.getFunction("soundex")
+	if !ok {
+		if t.funcCheck {
+			t.errorf("non existent function soundex for SOUNDS LIKE")
+		}
+		funcImpl = Func{Name: "soundex", Eval: EmptyEvalFunc}
+	}
+	fn := NewFuncNode("soundex", funcImpl)
+	fn.Missing = !ok
+	fn.append(arg)
+	if err := fn.Validate(); err != nil {
+		t.error(err)
+	}
+	return fn
+}
+
+// concat the || string concatenation of l and r, appending to l if it is
+// already a concatenation (concat is associative).
+func (t *tree) concat(l, r Node) Node {
+	if fn, ok := l.(*FuncNode); ok && fn.Name == "concat" {
+		fn.append(r)
+		if err := fn.Validate(); err != nil {
+			t.error(err)
+		}
+		return fn
+	}
+	funcImpl, ok := t.getFunction("concat")
+	if !ok {
+		if t.funcCheck {
+			t.errorf("non existent function concat for ||")
+		}
+		funcImpl = Func{Name: "concat", Eval: EmptyEvalFunc}
+	}
+	fn := NewFuncNode("concat", funcImpl)
+	fn.Missing = !ok
+	fn.append(l)
+	fn.append(r)
+	if err := fn.Validate(); err != nil {
+		t.error(err)
+	}
+	return fn
+}
+
 func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 	debugf(depth, "Func: tok: %v cur:%v peek:%v", funcTok.V, t.Cur(), t.Peek())
 	if t.Cur().T != lex.TokenLeftParenthesis {
@@ -706,6 +882,9 @@ func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 		}
 		fn.append(NewStringNodeToken(t.Next()))
 		return fn
+	case IsOrderedAggFunc(fn.Name):
+		t.orderedAggArgs(depth, fn)
+		return fn
 	default:
 		lastComma := false
 		for {
@@ -772,6 +951,62 @@ func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 	}
 }
 
+// orderedAggArgs parse the args of an ordered aggregate, see IsOrderedAggFunc,
+// the opening paren has been consumed.
+func (t *tree) orderedAggArgs(depth int, fn *FuncNode) {
+	distinct := "all"
+	if t.Cur().T == lex.TokenDistinct {
+		distinct = "distinct"
+		t.Next()
+	}
+	arg := t.O(depth + 1)
+	var sep Node = NewStringNode(",")
+	hasSep := false
+	var order []Node
+	for {
+		switch cur := t.Cur(); cur.T {
+		case lex.TokenComma, lex.TokenSeparator:
+			// string_agg(x, sep ORDER BY y), group_concat(x ORDER BY y SEPARATOR sep)
+			if hasSep || (cur.T == lex.TokenComma && len(order) > 0) {
+				t.unexpected(cur, "ordered aggregate separator")
+			}
+			t.Next()
+			sep = t.O(depth + 1)
+			hasSep = true
+		case lex.TokenOrderBy:
+			if len(order) > 0 {
+				t.unexpected(cur, "ordered aggregate ORDER BY")
+			}
+			t.Next()
+			for {
+				by := t.O(depth + 1)
+				dir := "asc"
+				switch t.Cur().T {
+				case lex.TokenAsc:
+					t.Next()
+				case lex.TokenDesc:
+					dir = "desc"
+					t.Next()
+				}
+				order = append(order, by, NewStringNode(dir))
+				if t.Cur().T != lex.TokenComma {
+					break
+				}
+				t.Next()
+			}
+		case lex.TokenRightParenthesis:
+			t.Next()
+			if arg == nil {
+				t.unexpected(cur, "ordered aggregate arg")
+			}
+			fn.Args = append([]Node{arg, sep, NewStringNode(distinct)}, order...)
+			return
+		default:
+			t.unexpected(cur, "ordered aggregate")
+		}
+	}
+}
+
 // get Function from Global function registry.
 func (t *tree) getFunction(name string) (fn Func, ok bool) {
 	if t.fr != nil {
@@ -835,6 +1070,12 @@ arrayLoop:
 		case lex.TokenValueEscaped:
 			newVal, _ := StringUnEscape('"', tok.V)
 			vals = append(vals, value.NewStringValue(newVal))
+		case lex.TokenValueHex:
+			n, err := NewValueNodeHex(tok)
+			if err != nil {
+				return value.NilValueVal, err
+			}
+			vals = append(vals, n.Value)
 		case lex.TokenInteger:
 			fv, err := strconv.ParseFloat(tok.V, 64)
 			if err == nil {
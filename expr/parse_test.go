@@ -303,6 +303,16 @@ var exprTests = []exprTest{
 		"",
 		false,
 	},
+	{
+		`match(title, body) against ("quick fox") > 0`,
+		`MATCH(title, body) AGAINST ("quick fox") > 0`,
+		true,
+	},
+	{
+		`tolower(title) AGAINST ("quick fox")`, // AGAINST only follows MATCH()
+		"",
+		false,
+	},
 	// Try a bunch of code simplification
 	{
 		`OR (x == "y")`,
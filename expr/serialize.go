@@ -0,0 +1,96 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/araddon/qlbridge/value"
+)
+
+// Every Node round-trips through each of its three serializations to a
+// tree NodesEqual to itself, so expressions (filters etc) may be shipped
+// across process boundaries:
+//
+//     text:      n.String()        ->  ParseExpression(text)
+//     json:      NodeToJson(n)     ->  NodeFromJson(data)
+//     protobuf:  NodeToPb(n)       ->  NodeFromPb(data)
+//
+// The text form is canonical, ie parsing then writing it again gives the
+// same text.
+
+const (
+	// Expr.Type of literals that are ambiguous from their Value alone
+	exprTypeNumber = "number"
+	exprTypeString = "string"
+	exprTypeValue  = "value:" // + the value.ValueType of json encoded Value
+	// Expr.Type of a binary x AND y, not the AND ( ... ) boolean node
+	exprTypeBinary = "binary"
+)
+
+// NodeToJson json encode the Expr of n.
+func NodeToJson(n Node) ([]byte, error) {
+	if n == nil {
+		return nil, fmt.Errorf("Nil expression?")
+	}
+	return json.Marshal(n.Expr())
+}
+
+// NodeFromJson decode a node from the json encoded Expr of NodeToJson.
+func NodeFromJson(data []byte) (Node, error) {
+	e := &Expr{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	return NodeFromExpr(e)
+}
+
+// NodeToPb protobuf encode n, see NodeFromPb.
+func NodeToPb(n Node) ([]byte, error) {
+	if n == nil {
+		return nil, fmt.Errorf("Nil expression?")
+	}
+	return proto.Marshal(n.NodePb())
+}
+
+// valueFromJson decode a json encoded value of type vt.
+func valueFromJson(vt value.ValueType, data []byte) (value.Value, error) {
+	switch vt {
+	case value.StringsType:
+		var sv []string
+		if err := json.Unmarshal(data, &sv); err != nil {
+			return nil, err
+		}
+		return value.NewStringsValue(sv), nil
+	case value.SliceValueType:
+		var iv []interface{}
+		if err := json.Unmarshal(data, &iv); err != nil {
+			return nil, err
+		}
+		return value.NewSliceValuesNative(iv), nil
+	case value.IntType:
+		var iv int64
+		if err := json.Unmarshal(data, &iv); err != nil {
+			return nil, err
+		}
+		return value.NewIntValue(iv), nil
+	case value.ByteSliceType:
+		var by []byte
+		if err := json.Unmarshal(data, &by); err != nil {
+			return nil, err
+		}
+		return value.NewByteSliceValue(by), nil
+	}
+	var gv interface{}
+	if err := json.Unmarshal(data, &gv); err != nil {
+		return nil, err
+	}
+	v := value.NewValue(gv)
+	if v.Type() != vt {
+		if cv, err := value.Cast(vt, v); err == nil {
+			return cv, nil
+		}
+	}
+	return v, nil
+}
@@ -2,6 +2,7 @@ package expr
 
 import (
 	"bytes"
+	"encoding/hex"
 	"io"
 	"strings"
 	"unicode"
@@ -109,13 +110,9 @@ func IdentityMaybeQuoteStrictBuf(buf *bytes.Buffer, quote byte, ident string) {
 		needsQuote = true
 	} else {
 		for _, r := range ident {
-			if !lex.IsIdentifierRune(r) {
-				needsQuote = true
-				break
-			} else if r == quoter {
-				needsQuote = true
-				break
-			} else if r == '.' {
+			// dashes, periods, spaces etc are legal in some dialects
+			// (es index names, csv headers) but not others, so are quoted
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
 				needsQuote = true
 				break
 			}
@@ -233,6 +230,14 @@ func StringUnEscape(quote rune, val string) (string, bool) {
 	return buf.String(), hasEscape
 }
 
+// HexLiteral the sql hex literal of binary value
+//
+//  HexLiteral([]byte("AB")) => "X'4142'"
+//
+func HexLiteral(by []byte) string {
+	return "X'" + strings.ToUpper(hex.EncodeToString(by)) + "'"
+}
+
 // A break, is some character such as comma, ;, whitespace
 func isBreak(r rune) bool {
 	switch r {
@@ -28,6 +28,12 @@ func TestIdentityQuoting(t *testing.T) {
 
 	assert.Equal(t, IdentityMaybeQuoteStrict('`', "_uid"), "`_uid`")
 
+	// legal un-quoted in some dialects, not others
+	assert.Equal(t, IdentityMaybeQuote('`', "my-index"), "`my-index`")
+	assert.Equal(t, IdentityMaybeQuote('`', "user.name"), "`user.name`")
+	assert.Equal(t, IdentityMaybeQuote('"', "first name"), `"first name"`)
+	assert.Equal(t, IdentityMaybeQuote('"', "naïve_1"), "naïve_1")
+
 	assert.Equal(t, IdentityMaybeQuote('`', "\xe2\x00"), "`\xe2\x00`")
 	assert.Equal(t, IdentityMaybeQuote('`', "a\xe2\x00"), "`a\xe2\x00`")
 }
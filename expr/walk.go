@@ -0,0 +1,152 @@
+package expr
+
+// WalkFunc is called by Walk for each node, return false to not descend
+// into the children of n.
+type WalkFunc func(n Node) bool
+
+// RewriteFunc is called by Rewrite for each node, after its children have
+// been rewritten, and returns the node to replace it with, or n itself to
+// keep it.
+type RewriteFunc func(n Node) Node
+
+// Walk calls fn for n, then depth first for each of its children in
+// argument order.  Include nodes are leaves, their expression is not
+// walked.
+//
+//     // find every func used
+//     expr.Walk(node, func(n expr.Node) bool {
+//         if fn, ok := n.(*expr.FuncNode); ok {
+//             names = append(names, fn.Name)
+//         }
+//         return true
+//     })
+//
+func Walk(n Node, fn WalkFunc) {
+	if n == nil || !fn(n) {
+		return
+	}
+	for _, arg := range children(n) {
+		Walk(arg, fn)
+	}
+}
+
+// Rewrite the tree of n bottom up, replacing each node with the result of
+// fn in place, ie the Args of n and its descendants are modified.  Returns
+// the new root, which is n unless fn replaced it.
+//
+//     // mask every email column
+//     node = expr.Rewrite(node, func(n expr.Node) expr.Node {
+//         if in, ok := n.(*expr.IdentityNode); ok && in.Text == "email" {
+//             return expr.NewStringNode("***")
+//         }
+//         return n
+//     })
+//
+func Rewrite(n Node, fn RewriteFunc) Node {
+	return rewrite(n, fn, false)
+}
+
+// RewriteCopy the tree of n bottom up as Rewrite, but copy-on-write, n
+// is not modified.  Nodes on the path from the root to a replaced node are
+// shallow copies, untouched sub-trees are shared with n.  Returns n itself
+// if fn replaced nothing.
+func RewriteCopy(n Node, fn RewriteFunc) Node {
+	return rewrite(n, fn, true)
+}
+
+func rewrite(n Node, fn RewriteFunc, copyOnWrite bool) Node {
+	if n == nil {
+		return nil
+	}
+	args := children(n)
+	var newArgs []Node
+	for i, arg := range args {
+		na := rewrite(arg, fn, copyOnWrite)
+		if na == arg {
+			continue
+		}
+		if !copyOnWrite {
+			setChild(n, i, na)
+			continue
+		}
+		if newArgs == nil {
+			newArgs = make([]Node, len(args))
+			copy(newArgs, args)
+		}
+		newArgs[i] = na
+	}
+	if newArgs != nil {
+		n = withChildren(n, newArgs)
+	}
+	return fn(n)
+}
+
+// children the child nodes of n.
+func children(n Node) []Node {
+	switch nt := n.(type) {
+	case *FuncNode:
+		return nt.Args
+	case *BinaryNode:
+		return nt.Args
+	case *BooleanNode:
+		return nt.Args
+	case *TriNode:
+		return nt.Args
+	case *ArrayNode:
+		return nt.Args
+	case *UnaryNode:
+		if nt.Arg != nil {
+			return []Node{nt.Arg}
+		}
+	}
+	return nil
+}
+
+// setChild replace the i'th child of n in place.
+func setChild(n Node, i int, child Node) {
+	switch nt := n.(type) {
+	case *FuncNode:
+		nt.Args[i] = child
+	case *BinaryNode:
+		nt.Args[i] = child
+	case *BooleanNode:
+		nt.Args[i] = child
+	case *TriNode:
+		nt.Args[i] = child
+	case *ArrayNode:
+		nt.Args[i] = child
+	case *UnaryNode:
+		nt.Arg = child
+	}
+}
+
+// withChildren a shallow copy of n with args as its children.
+func withChildren(n Node, args []Node) Node {
+	switch nt := n.(type) {
+	case *FuncNode:
+		c := *nt
+		c.Args = args
+		return &c
+	case *BinaryNode:
+		c := *nt
+		c.Args = args
+		return &c
+	case *BooleanNode:
+		c := *nt
+		c.Args = args
+		return &c
+	case *TriNode:
+		c := *nt
+		c.Args = args
+		return &c
+	case *ArrayNode:
+		c := *nt
+		c.Args = args
+		return &c
+	case *UnaryNode:
+		c := *nt
+		c.Arg = args[0]
+		return &c
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/expr"
+)
+
+func TestWalkRewrite(t *testing.T) {
+	n, err := expr.ParseExpression(`email = "a@b.com" AND (tolower(name) = "bob" OR NOT exists(city))`)
+	assert.Equal(t, nil, err)
+
+	idents := make([]string, 0)
+	funcs := make([]string, 0)
+	expr.Walk(n, func(n expr.Node) bool {
+		switch nt := n.(type) {
+		case *expr.IdentityNode:
+			idents = append(idents, nt.Text)
+		case *expr.FuncNode:
+			funcs = append(funcs, nt.Name)
+			// do not descend into funcs
+			return false
+		}
+		return true
+	})
+	assert.Equal(t, []string{"email"}, idents)
+	assert.Equal(t, []string{"tolower", "exists"}, funcs)
+
+	mask := func(n expr.Node) expr.Node {
+		if in, ok := n.(*expr.IdentityNode); ok && in.Text == "email" {
+			return expr.NewStringNode("***")
+		}
+		return n
+	}
+	orig := n.String()
+
+	// copy-on-write leaves n alone, shares untouched sub-trees
+	n2 := expr.RewriteCopy(n, mask)
+	assert.Equal(t, orig, n.String())
+	assert.Equal(t, `"***" = "a@b.com" AND (tolower(name) = "bob" OR NOT exists(city))`, n2.String())
+	assert.True(t, n.(*expr.BinaryNode).Args[1] == n2.(*expr.BinaryNode).Args[1])
+	assert.True(t, expr.RewriteCopy(n, func(n expr.Node) expr.Node { return n }) == n)
+
+	// in place
+	n3 := expr.Rewrite(n, mask)
+	assert.True(t, n3 == n)
+	assert.Equal(t, n2.String(), n.String())
+
+	// bottom up, the parent sees its rewritten children
+	n4 := expr.Rewrite(expr.MustParse(`1 + 2`), func(n expr.Node) expr.Node {
+		if bn, ok := n.(*expr.BinaryNode); ok {
+			if _, ok := bn.Args[0].(*expr.StringNode); ok {
+				return expr.NewStringNode("folded")
+			}
+		}
+		if _, ok := n.(*expr.NumberNode); ok {
+			return expr.NewStringNode("x")
+		}
+		return n
+	})
+	assert.Equal(t, `"folded"`, n4.String())
+}
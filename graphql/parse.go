@@ -0,0 +1,443 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type (
+	// Document a parsed GraphQL query document.
+	Document struct {
+		Operations []*Operation
+	}
+	// Operation a single query operation.
+	//
+	//	query Users($email: String = "aaron@email.com") { users(email: $email) { user_id } }
+	Operation struct {
+		Type       string // query, mutation, subscription
+		Name       string
+		Variables  []*VariableDef
+		Selections []*Field
+	}
+	// VariableDef declaration of a variable with optional default.
+	VariableDef struct {
+		Name    string
+		Type    string
+		Default interface{}
+	}
+	// Field of a selection set, fields with a selection set of their own
+	// are objects (tables, relations), else scalars (columns).
+	Field struct {
+		Alias      string
+		Name       string
+		Args       []*Argument
+		Selections []*Field
+	}
+	// Argument name: value of a field.
+	Argument struct {
+		Name  string
+		Value interface{}
+	}
+	// Variable reference $name in an argument value.
+	Variable string
+	// Enum an unquoted enum value (ASC, DESC).
+	Enum string
+	// Object an input object value {name: value}, in document order.
+	Object []*Argument
+)
+
+// Key is the response key of the field, alias if it has one.
+func (m *Field) Key() string {
+	if m.Alias != "" {
+		return m.Alias
+	}
+	return m.Name
+}
+
+// Arg the value of argument name, nil if the field has none.
+func (m *Field) Arg(name string) (interface{}, bool) {
+	for _, a := range m.Args {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Operation the named operation, or the only one if name is empty.
+func (m *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(m.Operations) != 1 {
+			return nil, fmt.Errorf("graphql: operation name required, document has %d operations", len(m.Operations))
+		}
+		return m.Operations[0], nil
+	}
+	for _, op := range m.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("graphql: unknown operation %q", name)
+}
+
+// Parse a GraphQL query document.
+func Parse(query string) (doc *Document, err error) {
+	p := &parser{src: query}
+	defer func() {
+		if r := recover(); r != nil {
+			if pe, ok := r.(parseError); ok {
+				doc, err = nil, pe
+				return
+			}
+			panic(r)
+		}
+	}()
+	p.next()
+	doc = &Document{}
+	for p.tok.t != tokEOF {
+		doc.Operations = append(doc.Operations, p.operation())
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("graphql: empty document")
+	}
+	return doc, nil
+}
+
+type parseError struct {
+	msg string
+}
+
+func (m parseError) Error() string { return m.msg }
+
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	t   tokenType
+	v   string
+	pos int
+}
+
+// parser a recursive descent parser of the executable subset of the
+// GraphQL grammar, fragments and directives are not supported.
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func (p *parser) errorf(format string, args ...interface{}) {
+	panic(parseError{fmt.Sprintf("graphql: "+format+" at position %d", append(args, p.tok.pos)...)})
+}
+
+// next lex the next token, commas are insignificant white space.
+func (p *parser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c == ',' || unicode.IsSpace(rune(c)) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{t: tokEOF, pos: start}
+		return
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '.':
+		if strings.HasPrefix(p.src[p.pos:], "...") {
+			p.pos += 3
+			p.tok = token{t: tokPunct, v: "...", pos: start}
+			return
+		}
+	case strings.IndexByte("{}()[]:=$!@|&", c) >= 0:
+		p.pos++
+		p.tok = token{t: tokPunct, v: string(c), pos: start}
+		return
+	case c == '"':
+		p.tok = token{t: tokString, v: p.lexString(), pos: start}
+		return
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{t: tokName, v: p.src[start:p.pos], pos: start}
+		return
+	case c == '-' || isDigit(c):
+		p.pos++
+		t := tokInt
+		for p.pos < len(p.src) {
+			c = p.src[p.pos]
+			if c == '.' || c == 'e' || c == 'E' || ((c == '-' || c == '+') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+				t = tokFloat
+			} else if !isDigit(c) {
+				break
+			}
+			p.pos++
+		}
+		p.tok = token{t: t, v: p.src[start:p.pos], pos: start}
+		return
+	}
+	p.tok = token{t: tokPunct, v: string(c), pos: start}
+	p.errorf("unexpected character %q", c)
+}
+
+func (p *parser) lexString() string {
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String()
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.src) {
+				break
+			}
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'u':
+				if p.pos+4 < len(p.src) {
+					if r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 32); err == nil {
+						sb.WriteRune(rune(r))
+						p.pos += 4
+						break
+					}
+				}
+				p.errorf("invalid unicode escape")
+			default:
+				sb.WriteByte(e)
+			}
+		case '\n':
+			p.errorf("unterminated string")
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	p.errorf("unterminated string")
+	return ""
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+func (p *parser) is(punct string) bool {
+	return p.tok.t == tokPunct && p.tok.v == punct
+}
+
+func (p *parser) expect(punct string) {
+	if !p.is(punct) {
+		p.errorf("expected %q got %q", punct, p.tok.v)
+	}
+	p.next()
+}
+
+func (p *parser) name() string {
+	if p.tok.t != tokName {
+		p.errorf("expected name got %q", p.tok.v)
+	}
+	n := p.tok.v
+	p.next()
+	return n
+}
+
+func (p *parser) operation() *Operation {
+	op := &Operation{Type: "query"}
+	if p.tok.t == tokName {
+		switch p.tok.v {
+		case "query", "mutation", "subscription":
+			op.Type = p.tok.v
+		case "fragment":
+			p.errorf("fragments are not supported")
+		default:
+			p.errorf("unexpected %q", p.tok.v)
+		}
+		p.next()
+		if p.tok.t == tokName {
+			op.Name = p.name()
+		}
+		if p.is("(") {
+			p.next()
+			for !p.is(")") {
+				op.Variables = append(op.Variables, p.variableDef())
+			}
+			p.next()
+		}
+	}
+	if p.is("@") {
+		p.errorf("directives are not supported")
+	}
+	op.Selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) variableDef() *VariableDef {
+	p.expect("$")
+	vd := &VariableDef{Name: p.name()}
+	p.expect(":")
+	vd.Type = p.typeRef()
+	if p.is("=") {
+		p.next()
+		vd.Default = p.value(true)
+	}
+	return vd
+}
+
+// typeRef  Name | [Type] with optional ! non-null suffix
+func (p *parser) typeRef() string {
+	var t string
+	if p.is("[") {
+		p.next()
+		t = "[" + p.typeRef() + "]"
+		p.expect("]")
+	} else {
+		t = p.name()
+	}
+	if p.is("!") {
+		p.next()
+		t += "!"
+	}
+	return t
+}
+
+func (p *parser) selectionSet() []*Field {
+	p.expect("{")
+	var fields []*Field
+	for !p.is("}") {
+		if p.tok.t == tokEOF {
+			p.errorf("unexpected end of document")
+		}
+		if p.is("...") {
+			p.errorf("fragments are not supported")
+		}
+		fields = append(fields, p.field())
+	}
+	p.next()
+	if len(fields) == 0 {
+		p.errorf("empty selection set")
+	}
+	return fields
+}
+
+func (p *parser) field() *Field {
+	f := &Field{Name: p.name()}
+	if p.is(":") {
+		p.next()
+		f.Alias, f.Name = f.Name, p.name()
+	}
+	if p.is("(") {
+		p.next()
+		for !p.is(")") {
+			a := &Argument{Name: p.name()}
+			p.expect(":")
+			a.Value = p.value(false)
+			f.Args = append(f.Args, a)
+		}
+		p.next()
+	}
+	if p.is("@") {
+		p.errorf("directives are not supported")
+	}
+	if p.is("{") {
+		f.Selections = p.selectionSet()
+	}
+	return f
+}
+
+// value a literal, variables only allowed when not const.
+func (p *parser) value(isConst bool) interface{} {
+	tok := p.tok
+	switch tok.t {
+	case tokInt:
+		p.next()
+		n, err := strconv.ParseInt(tok.v, 10, 64)
+		if err != nil {
+			p.errorf("invalid int %q", tok.v)
+		}
+		return n
+	case tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok.v, 64)
+		if err != nil {
+			p.errorf("invalid float %q", tok.v)
+		}
+		return f
+	case tokString:
+		p.next()
+		return tok.v
+	case tokName:
+		p.next()
+		switch tok.v {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return Enum(tok.v)
+	}
+	switch {
+	case p.is("$"):
+		if isConst {
+			p.errorf("variables not allowed in default values")
+		}
+		p.next()
+		return Variable(p.name())
+	case p.is("["):
+		p.next()
+		list := []interface{}{}
+		for !p.is("]") {
+			if p.tok.t == tokEOF {
+				p.errorf("unterminated list")
+			}
+			list = append(list, p.value(isConst))
+		}
+		p.next()
+		return list
+	case p.is("{"):
+		p.next()
+		obj := Object{}
+		for !p.is("}") {
+			a := &Argument{Name: p.name()}
+			p.expect(":")
+			a.Value = p.value(isConst)
+			obj = append(obj, a)
+		}
+		p.next()
+		return obj
+	}
+	p.errorf("unexpected %q", tok.v)
+	return nil
+}
@@ -0,0 +1,65 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/graphql"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := graphql.Parse(`
+	# users and their orders
+	query Users($email: String = "aaron@email.com", $n: Int!) {
+		people: users(email: $email, limit: 10, where: {price: {gt: 1.5}, ids: [1, 2]}, order_by: {email: DESC}) {
+			user_id
+			orders { order_id, price }
+		}
+	}`)
+	assert.Equal(t, nil, err)
+	op, err := doc.Operation("")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "query", op.Type)
+	assert.Equal(t, "Users", op.Name)
+	assert.Equal(t, 2, len(op.Variables))
+	assert.Equal(t, "aaron@email.com", op.Variables[0].Default)
+	assert.Equal(t, "Int!", op.Variables[1].Type)
+
+	f := op.Selections[0]
+	assert.Equal(t, "people", f.Key())
+	assert.Equal(t, "users", f.Name)
+	v, ok := f.Arg("email")
+	assert.True(t, ok)
+	assert.Equal(t, graphql.Variable("email"), v)
+	v, _ = f.Arg("limit")
+	assert.Equal(t, int64(10), v)
+	v, _ = f.Arg("where")
+	where := v.(graphql.Object)
+	assert.Equal(t, "price", where[0].Name)
+	assert.Equal(t, 1.5, where[0].Value.(graphql.Object)[0].Value)
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, where[1].Value)
+	v, _ = f.Arg("order_by")
+	assert.Equal(t, graphql.Enum("DESC"), v.(graphql.Object)[0].Value)
+	assert.Equal(t, 2, len(f.Selections))
+	assert.Equal(t, 2, len(f.Selections[1].Selections))
+
+	// shorthand query
+	doc, err = graphql.Parse(`{ users { email } }`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "users", doc.Operations[0].Selections[0].Name)
+
+	for _, q := range []string{
+		``,
+		`{ users { } }`,
+		`{ users { email }`,
+		`{ users { ...userFields } }`,
+		`{ users @include(if: true) { email } }`,
+		`fragment f on users { email }`,
+		`{ users(email: "aaron) { email } }`,
+		`query ($a: Int = $b) { users { email } }`,
+	} {
+		_, err = graphql.Parse(q)
+		assert.NotEqual(t, nil, err, q)
+	}
+}
@@ -0,0 +1,609 @@
+// Package graphql maps GraphQL queries onto rel.SqlSelect statements
+// against a qlbridge schema, so services can expose a GraphQL endpoint
+// backed by qlbridge sources.
+//
+// Each top level field is a table (and one select statement), scalar
+// fields are columns, fields with a selection set are relations joined
+// to their parent, arguments are filters, limit, offset and order_by.
+//
+//	{
+//	  users(email: "aaron@email.com", limit: 10) {
+//	    user_id
+//	    orders(where: {price: {gt: 10}}) { order_id price }
+//	  }
+//	}
+//
+//	SELECT t0.user_id, t1.order_id, t1.price
+//	FROM users AS t0 INNER JOIN orders AS t1 ON t0.user_id = t1.user_id
+//	WHERE t0.email = "aaron@email.com" AND t1.price > 10 LIMIT 10
+//
+// Result columns are positional, Query.Shape nests the flat rows into
+// the response objects.
+package graphql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// whereOps the comparison operators of where argument objects.
+	whereOps = map[string]string{
+		"eq":   "=",
+		"ne":   "!=",
+		"neq":  "!=",
+		"gt":   ">",
+		"gte":  ">=",
+		"lt":   "<",
+		"lte":  "<=",
+		"like": "LIKE",
+		"in":   "IN",
+	}
+)
+
+type (
+	// Translator translates GraphQL queries into sql against a schema.
+	//
+	// Nested fields join their parent on a configured Relation, else on
+	// the first column of the parent table if the child has it, else on
+	// a shared column ending in _id.  Joins are inner joins, parents
+	// without a related row are not returned.
+	Translator struct {
+		Schema    *schema.Schema
+		Relations []*Relation
+	}
+	// Relation how a nested field joins to its parent.
+	Relation struct {
+		Parent    string // parent table
+		Field     string // name of the nested field
+		Table     string // table of the field, defaults to Field
+		ParentKey string // column of parent table
+		ChildKey  string // column of child table
+	}
+	// Query is the translation of one top level field.
+	Query struct {
+		// Key the response key of the field.
+		Key    string
+		Sql    string
+		Select *rel.SqlSelect
+		root   *node
+	}
+	// node a table of the query, and its scalar columns positions in
+	// the select.
+	node struct {
+		key      string
+		table    string
+		alias    string
+		cols     []int
+		colKeys  []string
+		children []*node
+	}
+	// builder state of translating one top level field.
+	builder struct {
+		t       *Translator
+		vars    map[string]interface{}
+		cols    []string
+		joins   []string
+		where   []string
+		aliases int
+	}
+)
+
+// NewTranslator create a translator for schema.
+func NewTranslator(s *schema.Schema) *Translator {
+	return &Translator{Schema: s}
+}
+
+// Translate the operation (the only one if empty) of the GraphQL query
+// document into one select statement per top level field.
+func (m *Translator) Translate(query, operation string, vars map[string]interface{}) ([]*Query, error) {
+	doc, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	op, err := doc.Operation(operation)
+	if err != nil {
+		return nil, err
+	}
+	if op.Type != "query" {
+		return nil, fmt.Errorf("graphql: %s operations are not supported", op.Type)
+	}
+	allVars := make(map[string]interface{}, len(op.Variables))
+	for _, vd := range op.Variables {
+		if v, ok := vars[vd.Name]; ok {
+			allVars[vd.Name] = v
+		} else if vd.Default != nil {
+			allVars[vd.Name] = vd.Default
+		}
+	}
+	qs := make([]*Query, 0, len(op.Selections))
+	for _, f := range op.Selections {
+		q, err := m.translateField(f, allVars)
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+func (m *Translator) translateField(f *Field, vars map[string]interface{}) (*Query, error) {
+	if len(f.Selections) == 0 {
+		return nil, fmt.Errorf("graphql: top level field %q requires a selection set", f.Name)
+	}
+	b := &builder{t: m, vars: vars}
+	root, err := b.object(f, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sql := "SELECT " + strings.Join(b.cols, ", ") + " FROM " + quote(root.table) + " AS " + root.alias
+	if len(b.joins) > 0 {
+		sql += " " + strings.Join(b.joins, " ")
+	}
+	if len(b.where) > 0 {
+		sql += " WHERE " + strings.Join(b.where, " AND ")
+	}
+	tail, err := b.paging(f, root)
+	if err != nil {
+		return nil, err
+	}
+	sql += tail
+
+	sel, err := rel.ParseSqlSelect(sql)
+	if err != nil {
+		return nil, fmt.Errorf("graphql: could not translate %q: %v", f.Key(), err)
+	}
+	return &Query{Key: f.Key(), Sql: sql, Select: sel, root: root}, nil
+}
+
+// object add the columns, join and filters of an object field.
+func (m *builder) object(f *Field, parent *node, parentTable string) (*node, error) {
+	n := &node{key: f.Key(), table: f.Name, alias: "t" + strconv.Itoa(m.aliases)}
+	m.aliases++
+
+	var rel *Relation
+	if parent != nil {
+		rel = m.t.relation(parentTable, f.Name)
+		if rel != nil && rel.Table != "" {
+			n.table = rel.Table
+		}
+	}
+	tbl, err := m.t.Schema.Table(n.table)
+	if err != nil || tbl == nil {
+		return nil, fmt.Errorf("graphql: unknown table %q", n.table)
+	}
+	n.table = tbl.Name
+
+	if parent != nil {
+		pk, ck, err := m.t.joinKeys(rel, parent.table, tbl)
+		if err != nil {
+			return nil, err
+		}
+		m.joins = append(m.joins, fmt.Sprintf("INNER JOIN %s AS %s ON %s.%s = %s.%s",
+			quote(n.table), n.alias, parent.alias, quote(pk), n.alias, quote(ck)))
+	}
+
+	for _, a := range f.Args {
+		if parent == nil && isPagingArg(a.Name) {
+			continue
+		}
+		if isPagingArg(a.Name) {
+			return nil, fmt.Errorf("graphql: %s is only supported on top level fields", a.Name)
+		}
+		if err := m.filter(n, tbl, a); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sf := range f.Selections {
+		if len(sf.Selections) > 0 {
+			child, err := m.object(sf, n, n.table)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+			continue
+		}
+		if len(sf.Args) > 0 {
+			return nil, fmt.Errorf("graphql: arguments on scalar field %q are not supported", sf.Name)
+		}
+		col, ok := column(tbl, sf.Name)
+		if !ok {
+			return nil, fmt.Errorf("graphql: table %q has no column %q", n.table, sf.Name)
+		}
+		n.cols = append(n.cols, len(m.cols))
+		n.colKeys = append(n.colKeys, sf.Key())
+		m.cols = append(m.cols, n.alias+"."+quote(col))
+	}
+	return n, nil
+}
+
+// filter add the where expression of argument a, of table n.
+//
+//	email: "aaron@email.com"                     email = "aaron@email.com"
+//	user_id: ["a", "b"]                          user_id IN ("a", "b")
+//	where: {price: {gt: 10}, item_id: 1}         price > 10 AND item_id = 1
+func (m *builder) filter(n *node, tbl *schema.Table, a *Argument) error {
+	v, err := m.resolve(a.Value)
+	if err != nil {
+		return err
+	}
+	if a.Name != "where" {
+		return m.compare(n, tbl, a.Name, "", v)
+	}
+	obj, ok := v.(Object)
+	if !ok {
+		return fmt.Errorf("graphql: where must be an object")
+	}
+	for _, ca := range obj {
+		ops, isObj := ca.Value.(Object)
+		if !isObj {
+			if err := m.compare(n, tbl, ca.Name, "", ca.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, op := range ops {
+			sqlOp, ok := whereOps[strings.ToLower(op.Name)]
+			if !ok {
+				return fmt.Errorf("graphql: unknown where operator %q", op.Name)
+			}
+			if err := m.compare(n, tbl, ca.Name, sqlOp, op.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compare add   column op value   defaulting op to = (IN for lists).
+func (m *builder) compare(n *node, tbl *schema.Table, name, op string, v interface{}) error {
+	col, ok := column(tbl, name)
+	if !ok {
+		return fmt.Errorf("graphql: table %q has no column %q", n.table, name)
+	}
+	if list, isList := v.([]interface{}); isList {
+		if op != "" && op != "IN" {
+			return fmt.Errorf("graphql: list value for %q requires in", name)
+		}
+		lits := make([]string, len(list))
+		for i, lv := range list {
+			lit, err := literal(lv)
+			if err != nil {
+				return err
+			}
+			lits[i] = lit
+		}
+		m.where = append(m.where, fmt.Sprintf("%s.%s IN (%s)", n.alias, quote(col), strings.Join(lits, ", ")))
+		return nil
+	}
+	if op == "IN" {
+		return fmt.Errorf("graphql: in for %q requires a list", name)
+	}
+	if op == "" {
+		op = "="
+	}
+	lit, err := literal(v)
+	if err != nil {
+		return err
+	}
+	m.where = append(m.where, fmt.Sprintf("%s.%s %s %s", n.alias, quote(col), op, lit))
+	return nil
+}
+
+// paging the ORDER BY, LIMIT, OFFSET of the arguments of top level field.
+//
+//	order_by: {email: DESC}   order_by: "email"   order_by: [{email: ASC}, {user_id: DESC}]
+//	limit: 10 (or first)      offset: 20 (or skip)
+func (m *builder) paging(f *Field, root *node) (string, error) {
+	tbl, _ := m.t.Schema.Table(root.table)
+	var sql string
+	for _, name := range []string{"order_by", "orderBy"} {
+		v, ok := f.Arg(name)
+		if !ok {
+			continue
+		}
+		v, err := m.resolve(v)
+		if err != nil {
+			return "", err
+		}
+		var orders []string
+		var add func(v interface{}) error
+		add = func(v interface{}) error {
+			switch vt := v.(type) {
+			case []interface{}:
+				for _, lv := range vt {
+					if err := add(lv); err != nil {
+						return err
+					}
+				}
+				return nil
+			case string, Enum:
+				vt = Object{{Name: fmt.Sprint(vt), Value: Enum("ASC")}}
+				return add(vt)
+			case Object:
+				for _, oa := range vt {
+					col, ok := column(tbl, oa.Name)
+					if !ok {
+						return fmt.Errorf("graphql: table %q has no column %q", root.table, oa.Name)
+					}
+					dir := strings.ToUpper(fmt.Sprint(oa.Value))
+					if dir != "ASC" && dir != "DESC" {
+						return fmt.Errorf("graphql: order must be ASC or DESC got %v", oa.Value)
+					}
+					orders = append(orders, quote(col)+" "+dir)
+				}
+				return nil
+			}
+			return fmt.Errorf("graphql: invalid %s %v", name, v)
+		}
+		if err = add(v); err != nil {
+			return "", err
+		}
+		if len(root.children) > 0 {
+			return "", fmt.Errorf("graphql: %s is not supported with nested relations", name)
+		}
+		sql += " ORDER BY " + strings.Join(orders, ", ")
+	}
+	for _, kw := range []struct {
+		sql   string
+		names []string
+	}{{"LIMIT", []string{"limit", "first"}}, {"OFFSET", []string{"offset", "skip"}}} {
+		for _, name := range kw.names {
+			v, ok := f.Arg(name)
+			if !ok {
+				continue
+			}
+			v, err := m.resolve(v)
+			if err != nil {
+				return "", err
+			}
+			n, ok := v.(int64)
+			if !ok || n < 0 {
+				return "", fmt.Errorf("graphql: %s must be a non-negative int", name)
+			}
+			sql += fmt.Sprintf(" %s %d", kw.sql, n)
+		}
+	}
+	return sql, nil
+}
+
+// resolve replace variables in v with their values.
+func (m *builder) resolve(v interface{}) (interface{}, error) {
+	switch vt := v.(type) {
+	case Variable:
+		val, ok := m.vars[string(vt)]
+		if !ok {
+			return nil, fmt.Errorf("graphql: variable $%s is not defined", vt)
+		}
+		return normalizeVar(val), nil
+	case []interface{}:
+		out := make([]interface{}, len(vt))
+		for i, lv := range vt {
+			rv, err := m.resolve(lv)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rv
+		}
+		return out, nil
+	case Object:
+		out := make(Object, len(vt))
+		for i, a := range vt {
+			rv, err := m.resolve(a.Value)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = &Argument{Name: a.Name, Value: rv}
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// normalizeVar the go value of a variable (usually from decoded json) to
+// the parsed literal types.
+func normalizeVar(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case int:
+		return int64(vt)
+	case int32:
+		return int64(vt)
+	case float64:
+		if vt == float64(int64(vt)) {
+			return int64(vt)
+		}
+		return vt
+	case []interface{}:
+		out := make([]interface{}, len(vt))
+		for i, lv := range vt {
+			out[i] = normalizeVar(lv)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(vt))
+		for i, s := range vt {
+			out[i] = s
+		}
+		return out
+	case map[string]interface{}:
+		obj := make(Object, 0, len(vt))
+		for k, mv := range vt {
+			obj = append(obj, &Argument{Name: k, Value: normalizeVar(mv)})
+		}
+		return obj
+	}
+	return v
+}
+
+func (m *Translator) relation(parent, field string) *Relation {
+	for _, r := range m.Relations {
+		if strings.EqualFold(r.Parent, parent) && strings.EqualFold(r.Field, field) {
+			return r
+		}
+	}
+	return nil
+}
+
+// joinKeys the parent, child join columns.
+func (m *Translator) joinKeys(r *Relation, parent string, child *schema.Table) (string, string, error) {
+	if r != nil && r.ParentKey != "" && r.ChildKey != "" {
+		return r.ParentKey, r.ChildKey, nil
+	}
+	ptbl, err := m.Schema.Table(parent)
+	if err != nil || ptbl == nil {
+		return "", "", fmt.Errorf("graphql: unknown table %q", parent)
+	}
+	pcols := ptbl.Columns()
+	if len(pcols) > 0 {
+		if col, ok := column(child, pcols[0]); ok {
+			return pcols[0], col, nil
+		}
+	}
+	for _, pc := range pcols {
+		if !strings.HasSuffix(strings.ToLower(pc), "_id") {
+			continue
+		}
+		if col, ok := column(child, pc); ok {
+			return pc, col, nil
+		}
+	}
+	return "", "", fmt.Errorf("graphql: no relation from %q to %q", parent, child.Name)
+}
+
+// column the name of column of tbl, case insensitive.
+func column(tbl *schema.Table, name string) (string, bool) {
+	if tbl.HasField(name) {
+		return name, true
+	}
+	for _, col := range tbl.Columns() {
+		if strings.EqualFold(col, name) {
+			return col, true
+		}
+	}
+	return "", false
+}
+
+func isPagingArg(name string) bool {
+	switch name {
+	case "limit", "first", "offset", "skip", "order_by", "orderBy":
+		return true
+	}
+	return false
+}
+
+func quote(ident string) string {
+	return expr.IdentityMaybeQuoteStrict('`', ident)
+}
+
+// literal the sql literal of a GraphQL value.
+func literal(v interface{}) (string, error) {
+	switch vt := v.(type) {
+	case int64:
+		return strconv.FormatInt(vt, 10), nil
+	case float64:
+		return strconv.FormatFloat(vt, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(vt), nil
+	case string:
+		return expr.NewStringNode(vt).String(), nil
+	case Enum:
+		return expr.NewStringNode(string(vt)).String(), nil
+	case nil:
+		return "", fmt.Errorf("graphql: null filter values are not supported")
+	}
+	return "", fmt.Errorf("graphql: unsupported filter value %v (%T)", v, v)
+}
+
+// Shape nest the flat rows of the query (in the column order of Sql)
+// into the GraphQL response shape, a list of objects for the field.
+// Rows of a relation are grouped under their parent, duplicate objects
+// of a selection are returned once.
+func (m *Query) Shape(rows [][]driver.Value) []interface{} {
+	return m.root.shape(rows)
+}
+
+func (m *node) shape(rows [][]driver.Value) []interface{} {
+	out := make([]interface{}, 0)
+	groups := make([][][]driver.Value, 0)
+	idx := make(map[string]int)
+	for _, row := range rows {
+		vals := make([]driver.Value, len(m.cols))
+		for i, ci := range m.cols {
+			vals[i] = row[ci]
+		}
+		key := fmt.Sprintf("%#v", vals)
+		gi, ok := idx[key]
+		if !ok {
+			gi = len(out)
+			idx[key] = gi
+			obj := make(map[string]interface{}, len(m.cols)+len(m.children))
+			for i, k := range m.colKeys {
+				obj[k] = vals[i]
+			}
+			out = append(out, obj)
+			groups = append(groups, nil)
+		}
+		groups[gi] = append(groups[gi], row)
+	}
+	for gi, obj := range out {
+		for _, c := range m.children {
+			obj.(map[string]interface{})[c.key] = c.shape(groups[gi])
+		}
+	}
+	return out
+}
+
+// Do translate and run query against db, returning the GraphQL data
+// object of response key => list of objects.
+func (m *Translator) Do(db *sql.DB, query, operation string, vars map[string]interface{}) (map[string]interface{}, error) {
+	qs, err := m.Translate(query, operation, vars)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{}, len(qs))
+	for _, q := range qs {
+		rows, err := db.Query(q.Sql)
+		if err != nil {
+			return nil, err
+		}
+		cols, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var vals [][]driver.Value
+		for rows.Next() {
+			row := make([]driver.Value, len(cols))
+			dest := make([]interface{}, len(cols))
+			for i := range row {
+				dest[i] = &row[i]
+			}
+			if err = rows.Scan(dest...); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			for i, v := range row {
+				if by, ok := v.([]byte); ok {
+					row[i] = string(by)
+				}
+			}
+			vals = append(vals, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		data[q.Key] = q.Shape(vals)
+	}
+	return data, nil
+}
@@ -0,0 +1,94 @@
+package graphql_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/graphql"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+	td.LoadTestDataOnce()
+}
+
+func TestTranslate(t *testing.T) {
+	tr := graphql.NewTranslator(td.MockSchema)
+
+	qs, err := tr.Translate(`{
+		users(email: "aaron@email.com", limit: 10) {
+			user_id
+			orders(where: {price: {gt: 10}}) { order_id price }
+		}
+	}`, "", nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(qs))
+	assert.Equal(t, "users", qs[0].Key)
+	assert.Equal(t, "SELECT t0.user_id, t1.order_id, t1.price "+
+		"FROM users AS t0 INNER JOIN orders AS t1 ON t0.user_id = t1.user_id "+
+		`WHERE t0.email = "aaron@email.com" AND t1.price > 10 LIMIT 10`, qs[0].Sql)
+	assert.Equal(t, 10, qs[0].Select.Limit)
+
+	qs, err = tr.Translate(`query Q($ids: [String], $n: Int = 2) {
+		users(user_id: $ids, order_by: [{email: DESC}], first: $n, skip: 1) { email }
+	}`, "Q", map[string]interface{}{"ids": []interface{}{"a", "b"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `SELECT t0.email FROM users AS t0 WHERE t0.user_id IN ("a", "b") `+
+		"ORDER BY email DESC LIMIT 2 OFFSET 1", qs[0].Sql)
+
+	for _, q := range []string{
+		`mutation { users { email } }`,
+		`{ users }`,
+		`{ nothere { email } }`,
+		`{ users { nothere } }`,
+		`{ users(nothere: 1) { email } }`,
+		`{ users(email: null) { email } }`,
+		`{ users(email: $e) { email } }`,
+		`{ users(where: {email: {near: "x"}}) { email } }`,
+		`{ users(limit: -1) { email } }`,
+		`{ users { orders(limit: 1) { price } } }`,
+	} {
+		_, err = tr.Translate(q, "", nil)
+		assert.NotEqual(t, nil, err, q)
+	}
+}
+
+func TestTranslateDo(t *testing.T) {
+	db, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	tr := graphql.NewTranslator(td.MockSchema)
+	data, err := tr.Do(db, `{
+		aaron: users(email: $email) {
+			email
+			orders { order_id }
+		}
+		users(order_by: {email: ASC}) { email }
+	}`, "", nil)
+	// undeclared variable
+	assert.NotEqual(t, nil, err)
+
+	data, err = tr.Do(db, `query ($email: String) {
+		aaron: users(email: $email) {
+			email
+			orders { order_id }
+		}
+		users(where: {referral_count: {lt: 20}}) { email }
+	}`, "", map[string]interface{}{"email": "aaron@email.com"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"email": "aaron@email.com",
+			"orders": []interface{}{
+				map[string]interface{}{"order_id": "1"},
+				map[string]interface{}{"order_id": "2"},
+			},
+		},
+	}, data["aaron"])
+	assert.Equal(t, 2, len(data["users"].([]interface{})), "%v", data["users"])
+}
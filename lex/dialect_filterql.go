@@ -57,6 +57,8 @@ func NewFilterQLLexer(input string) *Lexer {
 //          daysago(datefield) < 100
 //          , domain(url) == "google.com"
 //          , INCLUDE name_of_filter
+//          , lastvisit_ts IN LAST 7 DAYS
+//          , orders IN (AND ( total > 100, status == "paid" ))
 //          ,
 //          , OR (
 //              momentum > 20
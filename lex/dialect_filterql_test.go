@@ -192,6 +192,30 @@ func TestFilterQLBasic(t *testing.T) {
 			tv(TokenIdentity, "my_filter_name"),
 		})
 
+	verifyFilterQLTokens(t, `FILTER AND ( created IN LAST 7 DAYS, orders IN (AND ( total > 100 )) )`,
+		[]Token{
+			tv(TokenFilter, "FILTER"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "created"),
+			tv(TokenIN, "IN"),
+			tv(TokenIdentity, "LAST"),
+			tv(TokenInteger, "7"),
+			tv(TokenIdentity, "DAYS"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "orders"),
+			tv(TokenIN, "IN"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "total"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "100"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenRightParenthesis, ")"),
+		})
+
 	// Now for a really simple naked filter
 	verifyFilterQLTokens(t, `
     FILTER x > 5
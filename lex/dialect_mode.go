@@ -0,0 +1,156 @@
+package lex
+
+import (
+	"strings"
+)
+
+// DialectMode the quirks of a sql dialect the SqlDialect lexer follows, so
+// queries written for a specific database parse as that database would.
+//
+//    l := lex.NewSqlLexerMode(`SELECT "name" || ' ' || "last" FROM "users"`, lex.ModePostgres)
+//
+// Quirks shared by all modes:
+//
+//    TRUE, FALSE            boolean literals
+//    LIMIT 10 OFFSET 20     and the ansi  OFFSET 20 ROWS FETCH FIRST 10 ROWS ONLY
+//    `name`                 back-tick quoted identities
+//
+type DialectMode struct {
+	Name string
+	// DoubleQuoteIdentity "name" is a quoted identity, as the sql standard
+	// (mysql ANSI_QUOTES), else it is a string literal as mysql.
+	DoubleQuoteIdentity bool
+	// PipesConcat a || b is string concatenation, as the sql standard
+	// (mysql PIPES_AS_CONCAT), else it is logical OR as mysql.
+	PipesConcat bool
+}
+
+var (
+	// ModeMySql mysql quirks, the default.
+	ModeMySql = &DialectMode{Name: "mysql"}
+	// ModePostgres postgres quirks.
+	ModePostgres = &DialectMode{Name: "postgres", DoubleQuoteIdentity: true, PipesConcat: true}
+	// ModeAnsi sql standard quirks.
+	ModeAnsi = &DialectMode{Name: "ansi", DoubleQuoteIdentity: true, PipesConcat: true}
+
+	dialectModes = map[string]*DialectMode{
+		"mysql":      ModeMySql,
+		"postgres":   ModePostgres,
+		"postgresql": ModePostgres,
+		"ansi":       ModeAnsi,
+	}
+)
+
+// DialectModeNamed the mode of dialect name (mysql, postgres, ansi), case
+// insensitive.
+func DialectModeNamed(name string) (*DialectMode, bool) {
+	m, ok := dialectModes[strings.ToLower(name)]
+	return m, ok
+}
+
+// NewSqlLexerMode creates a new lexer for the input string using SqlDialect
+// following the quirks of mode, nil mode is ModeMySql.
+func NewSqlLexerMode(input string, mode *DialectMode) *Lexer {
+	if mode == nil {
+		return NewSqlLexer(input)
+	}
+	if mode.DoubleQuoteIdentity {
+		input = quoteIdentities(input)
+	}
+	l := NewLexer(input, SqlDialect)
+	l.pipesConcat = mode.PipesConcat
+	return l
+}
+
+// quoteIdentities re-quote the double-quoted identities of sql with
+// back-ticks, as the lexer quotes identities, leaving string literals and
+// comments alone.  Back-ticks inside an identity are doubled.
+//
+//    SELECT "first name" FROM "users" WHERE x = 'say "hi"'
+//    SELECT `first name` FROM `users` WHERE x = 'say "hi"'
+//
+func quoteIdentities(sql string) string {
+	if strings.IndexByte(sql, '"') < 0 {
+		return sql
+	}
+	var buf strings.Builder
+	buf.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// string literal, '' and \' are escaped quotes
+			end := i + 1
+			for ; end < len(sql); end++ {
+				if sql[end] == '\\' {
+					end++
+				} else if sql[end] == '\'' {
+					if end+1 < len(sql) && sql[end+1] == '\'' {
+						end++
+						continue
+					}
+					break
+				}
+			}
+			if end >= len(sql) {
+				end = len(sql) - 1
+			}
+			buf.WriteString(sql[i : end+1])
+			i = end
+		case c == '`':
+			// already quoted identity, `` is an escaped back-tick
+			end := i + 1
+			for ; end < len(sql); end++ {
+				if sql[end] == '`' {
+					if end+1 < len(sql) && sql[end+1] == '`' {
+						end++
+						continue
+					}
+					break
+				}
+			}
+			if end >= len(sql) {
+				end = len(sql) - 1
+			}
+			buf.WriteString(sql[i : end+1])
+			i = end
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i - 1
+			}
+			buf.WriteString(sql[i : i+end+1])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 1
+			} else {
+				end += 3
+			}
+			buf.WriteString(sql[i : i+end+1])
+			i += end
+		case c == '"':
+			// "" is an escaped quote inside the identity
+			buf.WriteByte('`')
+			for i++; i < len(sql); i++ {
+				if sql[i] == '"' {
+					if i+1 < len(sql) && sql[i+1] == '"' {
+						buf.WriteByte('"')
+						i++
+						continue
+					}
+					break
+				}
+				if sql[i] == '`' {
+					buf.WriteByte('`')
+				}
+				buf.WriteByte(sql[i])
+			}
+			buf.WriteByte('`')
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,74 @@
+package lex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectMode(t *testing.T) {
+	mode, ok := DialectModeNamed("PostgreSQL")
+	assert.True(t, ok)
+	assert.Equal(t, ModePostgres, mode)
+	_, ok = DialectModeNamed("oracle")
+	assert.True(t, !ok)
+
+	// double-quoted identities, strings and comments are left alone
+	assert.Equal(t, "SELECT `first name`, `a``b` FROM `users` WHERE x = 'say \"hi\"' -- \"c\"",
+		quoteIdentities("SELECT \"first name\", \"a`b\" FROM \"users\" WHERE x = 'say \"hi\"' -- \"c\""))
+	assert.Equal(t, "SELECT `a\"b`, `it``s` /* \"x\" */", quoteIdentities("SELECT `a\"b`, \"it`s\" /* \"x\" */"))
+	assert.Equal(t, "SELECT 'it''s \"x\"'", quoteIdentities("SELECT 'it''s \"x\"'"))
+
+	verifyModeTokens := func(mode *DialectMode, sql string, tokens []Token) {
+		l := NewSqlLexerMode(sql, mode)
+		for _, want := range tokens {
+			tok := l.NextToken()
+			assert.Equal(t, want.T, tok.T, "want=%v has %v", want, tok)
+			assert.Equal(t, want.V, tok.V, "want=%v has %v", want, tok)
+		}
+	}
+	verifyModeTokens(ModePostgres, `SELECT "name" || 'x' FROM "users" WHERE "name" = 'bob'`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "name"),
+			tv(TokenConcat, "||"),
+			tv(TokenValue, "x"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "users"),
+			tv(TokenWhere, "WHERE"),
+			tv(TokenIdentity, "name"),
+			tv(TokenEqual, "="),
+			tv(TokenValue, "bob"),
+		})
+	verifyModeTokens(ModeMySql, `SELECT "name" || x FROM users`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenValue, "name"),
+			tv(TokenOr, "||"),
+			tv(TokenIdentity, "x"),
+		})
+	verifyModeTokens(nil, `SELECT x FROM users ORDER BY x OFFSET 20 ROWS FETCH FIRST 10 ROWS ONLY`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "x"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "users"),
+			tv(TokenOrderBy, "ORDER BY"),
+			tv(TokenIdentity, "x"),
+			tv(TokenOffset, "OFFSET"),
+			tv(TokenInteger, "20"),
+			tv(TokenFetch, "FETCH"),
+			tv(TokenInteger, "10"),
+			tv(TokenEOF, ""),
+		})
+	verifyModeTokens(nil, `SELECT fetch FROM users ORDER BY fetch`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "fetch"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "users"),
+			tv(TokenOrderBy, "ORDER BY"),
+			tv(TokenIdentity, "fetch"),
+			tv(TokenEOF, ""),
+		})
+}
@@ -18,6 +18,7 @@ var (
 	//
 	//    SHOW idenity;
 	//    DESCRIBE identity;
+	//    EXPLAIN {SELECT|INSERT|UPDATE|DELETE|CREATE|DROP ...}
 	//    PREPARE
 	//
 	// ddl
@@ -46,6 +47,8 @@ var (
 			{Token: TokenUse, Clauses: SqlUse},
 			{Token: TokenRollback, Clauses: SqlRollback},
 			{Token: TokenCommit, Clauses: SqlCommit},
+			{Token: TokenCall, Clauses: SqlCall},
+			{Token: TokenAnalyze, Clauses: SqlAnalyze},
 		},
 	}
 	// SqlSelect Select statement.
@@ -59,7 +62,9 @@ var (
 		{Token: TokenHaving, Lexer: LexConditionalClause, Optional: true, Name: "sqlSelect.having"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "sqlSelect.orderby"},
 		{Token: TokenLimit, Lexer: LexLimit, Optional: true, Name: "sqlSelect.limit"},
-		{Token: TokenOffset, Lexer: LexNumber, Optional: true, Name: "sqlSelect.offset"},
+		{Token: TokenOffset, Lexer: LexOffset, Optional: true, Name: "sqlSelect.offset"},
+		{Token: TokenFetch, KeywordMatcher: fetchMatch, Lexer: LexFetch, Optional: true, Name: "sqlSelect.fetch"},
+		{Token: TokenInto, Lexer: LexInto, Optional: true, Name: "sqlSelect.INTO.end"},
 		{Token: TokenWith, Lexer: LexJsonOrKeyValue, Optional: true, Name: "sqlSelect.with"},
 		{Token: TokenAlias, Lexer: LexIdentifier, Optional: true, Name: "sqlSelect.alias"},
 		{Token: TokenEOF, Lexer: LexEndOfStatement, Optional: false, Name: "sqlSelect.eos"},
@@ -73,7 +78,8 @@ var (
 		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true, Name: "fromSource.GroupBy"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "fromSource.OrderBy"},
 		{Token: TokenLimit, Lexer: LexLimit, Optional: true, Name: "fromSource.Limit"},
-		{Token: TokenOffset, Lexer: LexNumber, Optional: true, Name: "fromSource.Offset"},
+		{Token: TokenOffset, Lexer: LexOffset, Optional: true, Name: "fromSource.Offset"},
+		{Token: TokenFetch, KeywordMatcher: fetchMatch, Lexer: LexFetch, Optional: true, Name: "fromSource.Fetch"},
 		{Token: TokenRightParenthesis, Lexer: LexEndOfSubStatement, Optional: true, Name: "fromSource.EndParen"},
 		{Token: TokenAs, Lexer: LexIdentifier, Optional: true, Name: "fromSource.As"},
 		{Token: TokenOn, Lexer: LexConditionalClause, Optional: true, Name: "fromSource.On"},
@@ -87,9 +93,12 @@ var (
 		{Token: TokenGroupBy, Lexer: LexColumns, Optional: true, Name: "moreSources.GroupBy"},
 		{Token: TokenOrderBy, Lexer: LexOrderByColumn, Optional: true, Name: "moreSources.OrderBy"},
 		{Token: TokenLimit, Lexer: LexLimit, Optional: true, Name: "moreSources.Limit"},
-		{Token: TokenOffset, Lexer: LexNumber, Optional: true, Name: "moreSources.Offset"},
+		{Token: TokenOffset, Lexer: LexOffset, Optional: true, Name: "moreSources.Offset"},
+		{Token: TokenFetch, KeywordMatcher: fetchMatch, Lexer: LexFetch, Optional: true, Name: "moreSources.Fetch"},
 		{Token: TokenRightParenthesis, Lexer: LexEndOfSubStatement, Optional: false, Name: "moreSources.EndParen"},
 		{Token: TokenAs, Lexer: LexIdentifier, Optional: true, Name: "moreSources.As"},
+		{KeywordMatcher: indexHintMatch, Lexer: LexIndexHint, Optional: true, Repeat: true, Name: "moreSources.IndexHint"},
+		{KeywordMatcher: pivotMatch, Lexer: LexPivot, Optional: true, Name: "moreSources.Pivot"},
 		{Token: TokenOn, Lexer: LexConditionalClause, Optional: true, Name: "moreSources.On"},
 	}
 	whereQuery = []*Clause{
@@ -172,15 +181,15 @@ var (
 	}
 	// SqlDescribe Describe {table,database}
 	SqlDescribe = []*Clause{
-		{Token: TokenDescribe, Lexer: LexColumns},
+		{Token: TokenDescribe, Lexer: LexDescribe},
 	}
 	// SqlDescribeAlt alternate spelling of Describe
 	SqlDescribeAlt = []*Clause{
-		{Token: TokenDesc, Lexer: LexColumns},
+		{Token: TokenDesc, Lexer: LexDescribe},
 	}
 	// SqlExplain is alias of describe
 	SqlExplain = []*Clause{
-		{Token: TokenExplain, Lexer: LexColumns},
+		{Token: TokenExplain, Lexer: LexDescribe},
 	}
 	// SqlShow
 	SqlShow = []*Clause{
@@ -208,6 +217,15 @@ var (
 	SqlCommit = []*Clause{
 		{Token: TokenCommit, Lexer: LexEmpty},
 	}
+	// SqlCall   CALL name(args)
+	SqlCall = []*Clause{
+		{Token: TokenCall, Lexer: LexCall},
+	}
+	// SqlAnalyze   ANALYZE TABLE tbl [, tbl]
+	SqlAnalyze = []*Clause{
+		{Token: TokenAnalyze, Lexer: LexEmpty},
+		{Token: TokenTable, Lexer: LexTableNames},
+	}
 )
 
 // NewSqlLexer creates a new lexer for the input string using SqlDialect
@@ -233,6 +251,35 @@ func sourceMatch(c *Clause, peekWord string, l *Lexer) bool {
 	return false
 }
 
+// indexHintMatch matches the (USE | FORCE | IGNORE) (INDEX | KEY) hint
+// following a joined table.
+func indexHintMatch(c *Clause, peekWord string, l *Lexer) bool {
+	switch peekWord {
+	case "use", "force", "ignore":
+		return l.isIndexHint(peekWord)
+	}
+	return false
+}
+
+// pivotMatch matches the (PIVOT | UNPIVOT) clause following a joined table.
+func pivotMatch(c *Clause, peekWord string, l *Lexer) bool {
+	switch peekWord {
+	case "pivot", "unpivot":
+		return l.isPivot(peekWord)
+	}
+	return false
+}
+
+// fetchMatch matches the ansi FETCH {FIRST | NEXT} clause, so fetch may
+// still be an identity elsewhere.
+func fetchMatch(c *Clause, peekWord string, l *Lexer) bool {
+	if peekWord != "fetch" {
+		return false
+	}
+	fields := strings.Fields(strings.ToLower(l.PeekX(64)))
+	return len(fields) > 1 && (fields[1] == "first" || fields[1] == "next")
+}
+
 // LexEndOfSubStatement Look for end of statement defined by either
 // a semicolon or end of file.
 func LexEndOfSubStatement(l *Lexer) StateFn {
@@ -310,16 +357,30 @@ func LexShowClause(l *Lexer) StateFn {
 		return LexShowClause
 	case "columns", "global", "session", "variables", "status",
 		"engine", "engines", "procedure", "indexes", "index", "keys",
-		"function", "functions", "triggers":
+		"function", "functions", "triggers", "collation", "charset":
 		// TODO:  these should not be identities but tokens?
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenIdentity)
 		return LexShowClause
+	case "character":
+		// SHOW CHARACTER SET [like_or_where]
+		cs := strings.ToLower(l.PeekX(len("character set")))
+		if cs == "character set" {
+			l.ConsumeWord(cs)
+			l.Emit(TokenCharacterSet)
+			return LexShowClause
+		}
 	case "from":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenFrom)
 		l.Push("LexShowClause", LexShowClause)
 		return LexIdentifier
+	case "in":
+		// SHOW INDEX IN tbl_name [IN db_name]
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenIN)
+		l.Push("LexShowClause", LexShowClause)
+		return LexIdentifier
 	case "like":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenLike)
@@ -338,16 +399,46 @@ func LexShowClause(l *Lexer) StateFn {
 	return LexIdentifier
 }
 
+// LexTableNames comma separated list of table names
+//
+//    ANALYZE TABLE tbl [, tbl]
+//
+func LexTableNames(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	switch l.Peek() {
+	case ',':
+		l.Next()
+		l.Emit(TokenComma)
+		return LexTableNames
+	case ';', eof:
+		return nil
+	}
+	l.Push("LexTableNames", LexTableNames)
+	return LexIdentifierOfType(TokenTable)
+}
+
 // LexInto clause
+//
+//    INTO table
+//    INTO TEMP table
+//    INTO @var1, @var2
 func LexInto(l *Lexer) StateFn {
 
 	l.SkipWhiteSpaces()
+	if l.Peek() == '@' {
+		return LexIntoVars
+	}
 	keyWord := strings.ToLower(l.PeekWord())
 	//u.Debugf("LexInto  r= '%v'", string(keyWord))
 
 	switch keyWord {
 	case "from":
 		return l.errorf("Expected table got %v", keyWord)
+	case "temporary", "temp":
+		// SELECT ... INTO TEMP t
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenTemp)
+		return LexInto
 	default:
 		if IsValidIdentity(keyWord) {
 			l.ConsumeWord(keyWord)
@@ -358,6 +449,26 @@ func LexInto(l *Lexer) StateFn {
 	return nil
 }
 
+// LexIntoVars comma separated list of user variables of INTO
+//
+//    INTO @var1, @var2
+func LexIntoVars(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if l.Peek() != '@' {
+		return l.errorf("Expected @variable got %v", l.PeekWord())
+	}
+	l.Next()
+	l.ConsumeWord(l.PeekWord())
+	l.Emit(TokenIdentity)
+	l.SkipWhiteSpaces()
+	if l.Peek() == ',' {
+		l.Next()
+		l.Emit(TokenComma)
+		return LexIntoVars
+	}
+	return nil
+}
+
 // LexLimit clause
 //
 //    LIMIT 1000 OFFSET 100
@@ -387,16 +498,55 @@ func LexLimit(l *Lexer) StateFn {
 	return nil
 }
 
+// LexOffset clause, the ansi ROW(S) following the offset is dropped
+//
+//    OFFSET 100
+//    OFFSET 100 ROWS
+func LexOffset(l *Lexer) StateFn {
+	l.Push("lexFetchWords", lexFetchWords)
+	return LexNumber
+}
+
+// LexFetch the ansi FETCH clause, the limit, only its number is emitted
+//
+//    FETCH FIRST 10 ROWS ONLY
+//    FETCH NEXT ROW ONLY
+func LexFetch(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if isDigit(l.Peek()) {
+		l.Push("LexFetch", LexFetch)
+		return LexNumber
+	}
+	if lexFetchWords(l) == nil {
+		return nil
+	}
+	return LexFetch
+}
+
+// lexFetchWords drop the FIRST, NEXT, ROW(S), ONLY words of OFFSET and
+// FETCH, nil if there are none.
+func lexFetchWords(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "first", "next", "row", "rows", "only":
+		l.ConsumeWord(word)
+		l.ignore()
+		return lexFetchWords
+	}
+	return nil
+}
+
 // LexCreate allows us to lex the words after CREATE
 //
 //    CREATE {SCHEMA|DATABASE|SOURCE} [IF NOT EXISTS] <identity>  <WITH>
-//    CREATE {TABLE} <identity> [IF NOT EXISTS] <table_spec> [WITH]
+//    CREATE [TEMPORARY] TABLE [IF NOT EXISTS] <identity> <table_spec> [WITH]
 //    CREATE [OR REPLACE] {VIEW|CONTINUOUSVIEW} <identity> AS <select_statement> [WITH]
+//    CREATE SAMPLE TABLE <identity> AS <select_statement>
 //
 func LexCreate(l *Lexer) StateFn {
 
 	/*
-		CREATE TABLE [IF NOT EXISTS] <identity> [WITH]
+		CREATE [TEMPORARY] TABLE [IF NOT EXISTS] <identity> [WITH]
 		CREATE SOURCE [IF NOT EXISTS] <identity> [WITH]
 		CREATE [OR REPLACE] VIEW <identity> AS <select_statement> [WITH]
 	*/
@@ -409,10 +559,18 @@ func LexCreate(l *Lexer) StateFn {
 	case "or":
 		l.Push("LexCreate", LexCreate)
 		return lexOrReplace
+	case "temporary", "temp":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenTemp)
+		return LexCreate
 	case "table":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenTable)
 		l.Push("LexDdlTable", LexDdlTable)
+		l.SkipWhiteSpaces()
+		if strings.ToLower(l.PeekWord()) == "if" {
+			return lexNotExists
+		}
 		return nil
 	case "source":
 		l.ConsumeWord(keyWord)
@@ -439,6 +597,16 @@ func LexCreate(l *Lexer) StateFn {
 		l.Emit(TokenContinuousView)
 		l.Push("lexAs", lexAs)
 		return LexIdentifier
+	case "sample":
+		// SAMPLE TABLE is a single object type
+		l.ConsumeWord(keyWord)
+		l.SkipWhiteSpaces()
+		if strings.ToLower(l.PeekWord()) == "table" {
+			l.ConsumeWord("table")
+		}
+		l.Emit(TokenSample)
+		l.Push("lexAs", lexAs)
+		return LexIdentifier
 	case "if":
 		l.Push("LexCreate", LexCreate)
 		return lexNotExists
@@ -523,7 +691,7 @@ func LexDrop(l *Lexer) StateFn {
 	//u.Debugf("LexCreate  r= '%v'", string(keyWord))
 
 	switch keyWord {
-	case "temporary":
+	case "temporary", "temp":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenTemp)
 		return LexDrop
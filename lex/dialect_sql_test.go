@@ -28,6 +28,12 @@ func TestLexSqlDescribe(t *testing.T) {
 			tv(TokenDesc, "DESC"),
 			tv(TokenIdentity, "mytable"),
 		})
+	// the explained statement is parsed from the raw input
+	verifyTokens(t, `EXPLAIN DELETE FROM mytable WHERE id = 1;`,
+		[]Token{
+			tv(TokenExplain, "EXPLAIN"),
+			tv(TokenIdentity, "DELETE"),
+		})
 }
 
 func TestLexSqlShow(t *testing.T) {
@@ -90,6 +96,15 @@ func TestLexSqlShow(t *testing.T) {
 			tv(TokenLike, "LIKE"),
 			tv(TokenValue, "idx*"),
 		})
+	verifyTokens(t, `SHOW KEYS IN users IN mydb`,
+		[]Token{
+			tv(TokenShow, "SHOW"),
+			tv(TokenIdentity, "KEYS"),
+			tv(TokenIN, "IN"),
+			tv(TokenIdentity, "users"),
+			tv(TokenIN, "IN"),
+			tv(TokenIdentity, "mydb"),
+		})
 }
 
 func TestLexSqlCreate(t *testing.T) {
@@ -236,6 +251,32 @@ func TestLexSqlCreate(t *testing.T) {
 			tv(TokenEqual, "="),
 			tv(TokenValue, "hello"),
 		})
+
+	verifyTokens(t, `CREATE TEMPORARY TABLE IF NOT EXISTS scores (id int)`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenTemp, "TEMPORARY"),
+			tv(TokenTable, "TABLE"),
+			tv(TokenIf, "IF"),
+			tv(TokenNegate, "NOT"),
+			tv(TokenExists, "EXISTS"),
+			tv(TokenIdentity, "scores"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "id"),
+			tv(TokenTypeInteger, "int"),
+			tv(TokenRightParenthesis, ")"),
+		})
+
+	verifyTokens(t, `SELECT id INTO TEMP active FROM users`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "id"),
+			tv(TokenInto, "INTO"),
+			tv(TokenTemp, "TEMP"),
+			tv(TokenTable, "active"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "users"),
+		})
 }
 func TestLexSqlDrop(t *testing.T) {
 	// DROP {DATABASE | SCHEMA | SOURCE | TABLE} [IF EXISTS] db_name
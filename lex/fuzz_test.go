@@ -0,0 +1,36 @@
+//go:build go1.18
+// +build go1.18
+
+package lex
+
+import (
+	"testing"
+)
+
+// go test -run=^$ -fuzz=FuzzSqlLexer ./lex
+func FuzzSqlLexer(f *testing.F) {
+	for _, sql := range []string{
+		`SELECT a, b FROM t WHERE a > 1 ORDER BY b ASC LIMIT 10`,
+		"SELECT `a b`, count(*) AS ct FROM t GROUP BY `a b` HAVING ct > 2",
+		`SELECT /*+ PARALLEL(4) */ x FROM t1 INNER JOIN t2 ON t1.id = t2.id`,
+		`INSERT INTO t (a, b) VALUES ("x", 1), ("y", 2)`,
+		`UPDATE t SET a = "b" WHERE id IN (1, 2, 3)`,
+		`SELECT a FROM t WHERE b LIKE "%x" -- trailing comment`,
+		`SELECT "unterminated`,
+		`SELECT (((a`,
+		"",
+	} {
+		f.Add(sql)
+	}
+	f.Fuzz(func(t *testing.T, sql string) {
+		l := NewSqlLexer(sql)
+		// every token consumes input, so more tokens than bytes is a loop
+		for i := 0; i <= len(sql)*2+10; i++ {
+			tok := l.NextToken()
+			if tok.T == TokenEOF || tok.T == TokenError {
+				return
+			}
+		}
+		t.Fatalf("lexer did not terminate on %q", sql)
+	})
+}
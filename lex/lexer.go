@@ -11,6 +11,8 @@ import (
 	"unicode/utf8"
 
 	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/sqlerr"
 )
 
 var (
@@ -105,6 +107,7 @@ type Lexer struct {
 	peekedWordPos int
 	peekedWord    string
 	lastQuoteMark byte
+	pipesConcat   bool // || is string concatenation, see DialectMode
 
 	// Due to nested Expressions and evaluation this allows us to descend/ascend
 	// during lex, using push/pop to add and remove states needing evaluation
@@ -134,10 +137,18 @@ func (l *Lexer) ErrMsg(t Token, msg string) error {
 	} else {
 		raw = ""
 	}
+	line := t.Line
+	col, pos := t.Start()
+	if line == 0 {
+		// eof tokens have no position, the end of input
+		in := l.RawInput()
+		line, pos = strings.Count(in, "\n")+1, len(in)
+		col = len(in) - strings.LastIndex(in, "\n")
+	}
 	if len(msg) > 0 {
-		return fmt.Errorf("%s Got %s  near: %s", msg, t.String(), raw)
+		return sqlerr.New(sqlerr.ErParse, "%s Got %s  near: %s", msg, t.String(), raw).At(line, col, pos)
 	}
-	return fmt.Errorf("Unrecognized input at Line %v Column %v  %s", t.Line, t.Column, raw)
+	return sqlerr.New(sqlerr.ErParse, "Unrecognized input at Line %v Column %v  %s", t.Line, t.Column, raw).At(line, col, pos)
 }
 
 // NextToken returns the next token from the input.
@@ -185,6 +196,12 @@ func (l *Lexer) pop() StateFn {
 	return last.StateFn
 }
 
+// endStatement drop pending states after the end of statement ; so
+// lexing stops there, leaving the following statements as Remainder().
+func (l *Lexer) endStatement() {
+	l.stack = l.stack[:0]
+}
+
 // Next returns the next rune in the input
 func (l *Lexer) Next() (r rune) {
 	if l.pos >= len(l.input) {
@@ -232,8 +249,12 @@ func (l *Lexer) Remainder() (string, bool) {
 
 // Peek returns but does not consume the next rune in the input.
 func (l *Lexer) Peek() rune {
+	// keep the width of the last rune consumed, so a backup() after a
+	// Peek() backs up that rune, not the peeked one
+	width := l.width
 	r := l.Next()
 	l.backup()
+	l.width = width
 	return r
 }
 
@@ -390,6 +411,8 @@ func (l *Lexer) IsComment() bool {
 			return true
 		case "--":
 			return true
+		case "/*":
+			return true
 		}
 	default:
 		return false
@@ -403,7 +426,12 @@ func (l *Lexer) Emit(t TokenType) {
 	// We are going to use 1 based indexing (not 0 based) for lines
 	// because humans don't think that way
 	if l.lastQuoteMark != 0 {
-		l.lastToken = Token{T: t, V: l.input[l.start:l.pos], Quote: l.lastQuoteMark, Line: l.line + 1, Column: l.columnNumber(), Pos: l.pos}
+		v := l.input[l.start:l.pos]
+		if l.lastQuoteMark == '`' && strings.Contains(v, "``") {
+			// un-escape doubled back-ticks   `a``b`
+			v = strings.Replace(v, "``", "`", -1)
+		}
+		l.lastToken = Token{T: t, V: v, Quote: l.lastQuoteMark, Line: l.line + 1, Column: l.columnNumber(), Pos: l.pos}
 		l.lastQuoteMark = 0
 	} else {
 		l.lastToken = Token{T: t, V: l.input[l.start:l.pos], Line: l.line + 1, Column: l.columnNumber(), Pos: l.pos}
@@ -669,6 +697,10 @@ func (l *Lexer) isNextKeyword(peekWord string) bool {
 			//u.Infof("return true:  %v", strings.ToLower(l.PeekX(len(clause.fullWord))))
 			return true
 		}
+		// keyword of a clause with a matcher, as FETCH FIRST
+		if clause.Token != TokenNil && clause.KeywordMatcher != nil && clause.KeywordMatcher(clause, kwMaybe, l) {
+			return true
+		}
 		// TODO:  allow clauses to reserve keywords, or sub-clause
 		switch kwMaybe {
 		case "select", "insert", "delete", "update", "from", "inner", "outer":
@@ -690,6 +722,8 @@ func (l *Lexer) isIdentity() bool {
 	// Identity are strings not values
 	r := l.Peek()
 	switch {
+	case (r == 'x' || r == 'X') && l.isHexValue():
+		return false
 	case r == '[':
 		// This character [ is a little special
 		// as it is going to look to see if the 2nd character is
@@ -983,6 +1017,16 @@ func LexStatement(l *Lexer) StateFn {
 //Doesn't actually lex anything, used for single token clauses
 func LexEmpty(l *Lexer) StateFn { return nil }
 
+// LexCall lex the procedure name, and parenthesized args of CALL
+//
+//    CALL name(arg, arg)
+//
+func LexCall(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	l.Push("LexTableColumns", LexTableColumns)
+	return LexIdentifier
+}
+
 // lex a value:   string, integer, float
 //
 // - literal strings must be quoted
@@ -1083,6 +1127,10 @@ func LexValue(l *Lexer) StateFn {
 		//  A:   numbers
 		l.backup()
 		switch rune {
+		case 'x', 'X':
+			if l.isHexValue() {
+				return LexHexValue(l)
+			}
 		case 't', 'T', 'F', 'f':
 			// lets look for Booleans
 			boolCandiate := strings.ToLower(l.PeekWord())
@@ -1097,6 +1145,47 @@ func LexValue(l *Lexer) StateFn {
 	}
 }
 
+// LexHexValue a hex encoded binary literal, emits the hex digits without
+// the X and quotes.
+//
+//  X'ABCD'
+//  x'0a1b'
+//
+func LexHexValue(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	l.Next() // x
+	l.Next() // '
+	l.ignore()
+	digits := 0
+	for r := l.Next(); r != '\''; r = l.Next() {
+		switch {
+		case r == eof:
+			return l.errorToken("reached end without finding end for hex value")
+		case !isHexDigit(r):
+			return l.errorToken("invalid hex digit in hex value: " + string(r))
+		}
+		digits++
+	}
+	if digits%2 != 0 {
+		return l.errorToken("hex value must have an even number of digits")
+	}
+	l.backup()
+	l.Emit(TokenValueHex)
+	l.Next()
+	l.ignore()
+	return nil
+}
+
+// isHexValue is the next value a hex literal   X'ABCD'
+func (l *Lexer) isHexValue() bool {
+	p := l.PeekX(2)
+	return len(p) == 2 && (p[0] == 'x' || p[0] == 'X') && p[1] == '\''
+}
+
+func isHexDigit(r rune) bool {
+	return ('0' <= r && r <= '9') || ('a' <= r && r <= 'f') || ('A' <= r && r <= 'F')
+}
+
 // lex a regex:   first character must be a /
 //
 //  /^stats\./i
@@ -1361,6 +1450,39 @@ func LexListOfArgs(l *Lexer) StateFn {
 			l.Emit(TokenAs)
 			return LexExpressionOrIdentity
 		}
+		// ordered aggregate args
+		//    group_concat(DISTINCT x ORDER BY y DESC SEPARATOR ", ")
+		switch peekWord {
+		case "distinct":
+			if l.lastToken.T == TokenLeftParenthesis && l.peekRunePast(len(peekWord)) != '(' {
+				l.ConsumeWord(peekWord)
+				l.Emit(TokenDistinct)
+				return LexListOfArgs
+			}
+		case "order":
+			rest := strings.ToLower(strings.TrimSpace(l.input[l.pos+len(peekWord):]))
+			if strings.HasPrefix(rest, "by") && len(rest) > 2 && isWhiteSpace(rune(rest[2])) {
+				l.ConsumeWord(peekWord)
+				l.SkipWhiteSpaces()
+				l.ConsumeWord("by")
+				l.Emit(TokenOrderBy)
+				return LexListOfArgs
+			}
+		case "asc", "desc", "separator":
+			// after an arg, else it is a column of that name
+			if l.lastToken.T != TokenLeftParenthesis && l.lastToken.T != TokenComma {
+				l.ConsumeWord(peekWord)
+				switch peekWord {
+				case "asc":
+					l.Emit(TokenAsc)
+				case "desc":
+					l.Emit(TokenDesc)
+				default:
+					l.Emit(TokenSeparator)
+				}
+				return LexListOfArgs
+			}
+		}
 		if l.isNextKeyword(peekWord) {
 			//u.Warnf("found keyword while looking for arg? %v", string(r))
 			return nil
@@ -1450,7 +1572,10 @@ func lexIdentifierOfTypeNoWs(l *Lexer, shouldIgnore bool, forToken TokenType) St
 			case firstChar == '\'' && nextChar == '\'':
 				break identityForLoop
 			case firstChar == '`' && nextChar == '`':
-				if l.PeekX(2) == ".`" {
+				if l.Peek() == '`' {
+					// Escaped back-tick   `a``b`
+					l.Next()
+				} else if l.PeekX(2) == ".`" {
 					// Identity of form   `schema`.`table`
 					//u.Warnf("%s", l.RawInput())
 					l.Next()
@@ -1566,6 +1691,7 @@ func LexEndOfStatement(l *Lexer) StateFn {
 	r := l.Next()
 	if r == ';' {
 		l.Emit(TokenEOS)
+		l.endStatement()
 		return nil
 	}
 	l.SkipWhiteSpaces()
@@ -1593,6 +1719,11 @@ func LexSelectClause(l *Lexer) StateFn {
 	if l.Peek() == ')' {
 		return nil
 	}
+	if l.lastToken.T == TokenSelect && strings.HasPrefix(l.input[l.pos:], "/*+") {
+		// SELECT /*+ HASH_JOIN(a, b) */ DISTINCT ...
+		l.Push("LexSelectClause", LexSelectClause)
+		return LexOptimizerHint
+	}
 	word := strings.ToLower(l.PeekWord())
 
 	//u.Debugf("LexSelectClause  '%v'", word)
@@ -1774,7 +1905,7 @@ func LexPreparedStatement(l *Lexer) StateFn {
 //
 //     <select_list> := <select_col> [, <select_col>]*
 //
-//     <select_col> :== ( <identifier> | <expression> | '*' ) [AS <identifier>] [IF <expression>] [<comment>]
+//     <select_col> :== ( <identifier> | <expression> | '*' ) [FILTER (WHERE <expression>)] [AS <identifier>] [IF <expression>] [<comment>]
 //
 //  Note, our Columns support a non-standard IF guard at a per column basis
 //
@@ -1796,7 +1927,36 @@ func LexSelectList(l *Lexer) StateFn {
 		l.Emit(TokenIf)
 		l.Push("LexSelectList", LexSelectList)
 		return LexExpression
+	case "filter":
+		// aggregate FILTER (WHERE ...) clause, not a column named filter
+		if l.peekRunePast(len(word)) == '(' {
+			l.ConsumeWord(word)
+			l.Emit(TokenFilter)
+			l.Push("LexSelectList", LexSelectList)
+			return LexAggFilter
+		}
+	}
+	return LexExpression
+}
+
+// LexAggFilter the where clause of an aggregate column filter, FILTER
+// has already been consumed.
+//
+//     SELECT count(*) FILTER (WHERE status = "paid") AS paid FROM orders
+//
+func LexAggFilter(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if r := l.Next(); r != '(' {
+		return l.errorToken("expected ( after FILTER " + l.current())
 	}
+	l.Emit(TokenLeftParenthesis)
+	l.SkipWhiteSpaces()
+	if word := strings.ToLower(l.PeekWord()); word != "where" {
+		return l.errorToken("expected WHERE in FILTER clause " + l.current())
+	}
+	l.ConsumeWord("where")
+	l.Emit(TokenWhere)
+	l.Push("LexParenRight", LexParenRight)
 	return LexExpression
 }
 
@@ -1805,10 +1965,11 @@ func LexSelectList(l *Lexer) StateFn {
 //    SELECT ...  FROM <sources>
 //
 //    <sources>      := <source> [, <join_clause> <source>]*
-//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>]
+//    <source>       := ( <table_source> | <subselect> | <values> ) [AS <identifier>]
 //    <table_source> := <identifier>
 //    <join_clause>  := (INNER | LEFT | OUTER)? JOIN [ON <conditional_clause>]
 //    <subselect>    := '(' <select_stmt> ')'
+//    <values>       := '(' VALUES <row> [, <row>]* ')' [AS <identifier> '(' <identifier> [, <identifier>]* ')']
 //
 func LexTableReferenceFirst(l *Lexer) StateFn {
 
@@ -1832,6 +1993,7 @@ func LexTableReferenceFirst(l *Lexer) StateFn {
 	case ';':
 		l.Next()
 		l.Emit(TokenEOS)
+		l.endStatement()
 		return nil
 	case '(':
 		l.Next()
@@ -1851,12 +2013,36 @@ func LexTableReferenceFirst(l *Lexer) StateFn {
 	case "select":
 		// nice, this is what we are looking for, let dialect take over
 		return nil
+	case "values":
+		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
+		return LexValuesTable
 	case "as":
 		l.ConsumeWord("AS")
 		l.Emit(TokenAs)
 		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
 		l.Push("LexIdentifier", LexIdentifier)
 		return nil
+	case "use", "force", "ignore":
+		if !l.isIndexHint(word) {
+			break
+		}
+		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
+		return LexIndexHint
+	case "tablesample":
+		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
+		return LexTableSample
+	case "pivot", "unpivot":
+		if !l.isPivot(word) {
+			break
+		}
+		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
+		return LexPivot
+	case "sample":
+		if !l.isSample(word) {
+			break
+		}
+		l.Push("LexTableReferenceFirst", LexTableReferenceFirst)
+		return LexTableSample
 	case "in": // are there other functions besides in?
 		l.ConsumeWord(word)
 		l.Emit(TokenIN)
@@ -1890,15 +2076,296 @@ func LexTableReferenceFirst(l *Lexer) StateFn {
 	return LexExpressionOrIdentity
 }
 
+// LexValuesTable Handle an inline table of literal rows, from after its
+// opening paren through its alias and column names
+//
+//    SELECT ... FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name)
+//
+//    <values> := VALUES <col_value_row> [, <col_value_row>]* ')' [AS] <identifier> [<col_names>]
+//
+func LexValuesTable(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if word := strings.ToLower(l.PeekWord()); word == "values" {
+		l.ConsumeWord(word)
+		l.Emit(TokenValues)
+		// expressions in the rows resume lexing at the clause state, as in
+		// INSERT ... VALUES they must resume lexing rows not the FROM
+		clause := l.curClause
+		l.curClause = valuesTableClause
+		l.Push("LexValuesTable", func(l *Lexer) StateFn {
+			l.curClause = clause
+			return LexValuesTable
+		})
+		return LexValueColumns
+	}
+	// LexValueColumns stops at the closing paren of the table
+	if l.Peek() != ')' {
+		return l.errorToken("expected ) after VALUES rows " + l.current())
+	}
+	l.Next()
+	l.Emit(TokenRightParenthesis)
+	l.SkipWhiteSpaces()
+	word := strings.ToLower(l.PeekWord())
+	if word == "as" {
+		l.ConsumeWord(word)
+		l.Emit(TokenAs)
+		l.SkipWhiteSpaces()
+	} else if word == "" || l.isNextKeyword(word) {
+		return nil
+	}
+	l.Push("lexValuesTableColumns", lexValuesTableColumns)
+	return LexIdentifier
+}
+
+// valuesTableClause the clause of the rows of a VALUES table.
+var valuesTableClause = &Clause{Lexer: LexValueColumns, Name: "valuesTable.Rows"}
+
+// lexValuesTableColumns the optional column names after the alias of a
+// VALUES table.
+func lexValuesTableColumns(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if l.Peek() == '(' {
+		return LexColumnNames
+	}
+	return nil
+}
+
+// LexTableSample Handle the sample clause on a table reference
+//
+//    SELECT ... FROM big_t TABLESAMPLE (0.1 PERCENT)
+//    SELECT ... FROM big_t SAMPLE 1000 ROWS
+//
+//    <table_sample> := TABLESAMPLE '(' <number> (PERCENT | ROWS) ')'
+//                   |  SAMPLE <number> (PERCENT | ROWS)
+//
+func LexTableSample(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "tablesample":
+		l.ConsumeWord(word)
+		l.Emit(TokenTableSample)
+		return LexTableSample
+	case "sample":
+		// no parens, so only the number and unit are part of the clause
+		l.ConsumeWord(word)
+		l.Emit(TokenSample)
+		l.SkipWhiteSpaces()
+		l.Push("lexSampleUnit", lexSampleUnit)
+		return LexNumber
+	case "percent":
+		l.ConsumeWord(word)
+		l.Emit(TokenPercent)
+		return LexTableSample
+	case "rows":
+		l.ConsumeWord(word)
+		l.Emit(TokenRows)
+		return LexTableSample
+	}
+	switch r := l.Peek(); {
+	case r == '(':
+		l.Next()
+		l.Emit(TokenLeftParenthesis)
+		return LexTableSample
+	case r == ')':
+		l.Next()
+		l.Emit(TokenRightParenthesis)
+		return nil
+	case isDigit(r) || r == '.':
+		l.Push("LexTableSample", LexTableSample)
+		return LexNumber
+	}
+	return nil
+}
+
+// lexSampleUnit the PERCENT or ROWS after the number of a SAMPLE clause.
+func lexSampleUnit(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "percent":
+		l.ConsumeWord(word)
+		l.Emit(TokenPercent)
+	case "rows":
+		l.ConsumeWord(word)
+		l.Emit(TokenRows)
+	}
+	return nil
+}
+
+// isSample non-consuming check if the sample word is followed by a
+// number, ie is a SAMPLE clause not a table alias.
+func (l *Lexer) isSample(word string) bool {
+	rest := strings.TrimSpace(l.input[l.pos+len(word):])
+	return rest != "" && (isDigit(rune(rest[0])) || rest[0] == '.')
+}
+
+// isPivot non-consuming check if the (pivot|unpivot) word is followed by
+// a paren, ie is a PIVOT clause not a table alias.
+func (l *Lexer) isPivot(word string) bool {
+	rest := strings.TrimSpace(l.input[l.pos+len(word):])
+	return rest != "" && rest[0] == '('
+}
+
+// LexPivot Handle the pivot clause on a table reference
+//
+//    SELECT ... FROM sales PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2))
+//    SELECT ... FROM sales_wide UNPIVOT (amount FOR quarter IN (q1, q2))
+//
+//    <pivot>   := (PIVOT | UNPIVOT) '(' <expr> FOR <identifier> IN '(' <in_value> [, <in_value>]* ')' ')'
+//    <in_value> := (<value> | <identifier>) [AS <identifier>]
+//
+func LexPivot(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "pivot":
+		l.ConsumeWord(word)
+		l.Emit(TokenPivot)
+		return LexPivot
+	case "unpivot":
+		l.ConsumeWord(word)
+		l.Emit(TokenUnpivot)
+		return LexPivot
+	}
+	if l.Peek() != '(' {
+		return nil
+	}
+	l.Next()
+	l.Emit(TokenLeftParenthesis)
+	l.Push("lexPivotFor", lexPivotFor)
+	return LexExpressionOrIdentity
+}
+
+// lexPivotFor the FOR <identifier> IN ( of a pivot clause.
+func lexPivotFor(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "for":
+		l.ConsumeWord(word)
+		l.Emit(TokenFor)
+		l.Push("lexPivotFor", lexPivotFor)
+		return LexIdentifier
+	case "in":
+		l.ConsumeWord(word)
+		l.Emit(TokenIN)
+		return lexPivotFor
+	}
+	if l.Peek() != '(' {
+		return nil
+	}
+	l.Next()
+	l.Emit(TokenLeftParenthesis)
+	return lexPivotIn
+}
+
+// lexPivotIn the list of values, and closing parens, of a pivot clause.
+func lexPivotIn(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	if strings.ToLower(l.PeekWord()) == "as" {
+		l.ConsumeWord("as")
+		l.Emit(TokenAs)
+		l.Push("lexPivotIn", lexPivotIn)
+		return LexIdentifier
+	}
+	switch r := l.Peek(); {
+	case r == ',':
+		l.Next()
+		l.Emit(TokenComma)
+		return lexPivotIn
+	case r == ')':
+		// end of in list, then the end of the pivot
+		l.Next()
+		l.Emit(TokenRightParenthesis)
+		l.SkipWhiteSpaces()
+		if l.Peek() == ')' {
+			l.Next()
+			l.Emit(TokenRightParenthesis)
+		}
+		return nil
+	case r == '"' || r == '\'':
+		l.Push("lexPivotIn", lexPivotIn)
+		return LexValue
+	case isDigit(r) || r == '-' || r == '.':
+		l.Push("lexPivotIn", lexPivotIn)
+		return LexNumber
+	case r == eof:
+		return nil
+	}
+	l.Push("lexPivotIn", lexPivotIn)
+	return LexIdentifier
+}
+
+// isIndexHint non-consuming check if the (use|force|ignore) word is
+// followed by INDEX or KEY, ie is a table index hint.
+func (l *Lexer) isIndexHint(word string) bool {
+	rest := strings.ToLower(strings.TrimSpace(l.input[l.pos+len(word):]))
+	return strings.HasPrefix(rest, "index") || strings.HasPrefix(rest, "key")
+}
+
+// LexIndexHint Handle the index hint after (USE | FORCE | IGNORE) on a table reference
+//
+//    SELECT ... FROM users USE INDEX (idx_email)
+//
+//    <index_hint> := (USE | FORCE | IGNORE) (INDEX | KEY) [FOR (JOIN | ORDER BY | GROUP BY)] '(' <identifier> [, <identifier>]* ')'
+//
+func LexIndexHint(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	word := strings.ToLower(l.PeekWord())
+	switch word {
+	case "use":
+		l.ConsumeWord(word)
+		l.Emit(TokenUse)
+		return LexIndexHint
+	case "force":
+		l.ConsumeWord(word)
+		l.Emit(TokenForce)
+		return LexIndexHint
+	case "ignore":
+		l.ConsumeWord(word)
+		l.Emit(TokenIgnore)
+		return LexIndexHint
+	case "index", "key":
+		l.ConsumeWord(word)
+		if word == "key" {
+			l.Emit(TokenKey)
+		} else {
+			l.Emit(TokenIndex)
+		}
+		return LexIndexHint
+	case "for":
+		l.ConsumeWord(word)
+		l.Emit(TokenFor)
+		l.SkipWhiteSpaces()
+		word = strings.ToLower(l.PeekWord())
+		switch word {
+		case "join":
+			l.ConsumeWord(word)
+			l.Emit(TokenJoin)
+		case "order", "group":
+			l.ConsumeWord(word)
+			l.SkipWhiteSpaces()
+			if strings.ToLower(l.PeekWord()) == "by" {
+				l.ConsumeWord("by")
+			}
+			if word == "order" {
+				l.Emit(TokenOrderBy)
+			} else {
+				l.Emit(TokenGroupBy)
+			}
+		}
+		return LexIndexHint
+	}
+	return LexColumnNames
+}
+
 // Handle Source References ie [From table], [SubSelects], Joins
 //
 //    SELECT ...  FROM <sources>
 //
 //    <sources>      := <source> [, <join_clause> <source>]*
-//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>]
+//    <source>       := ( <table_source> | <subselect> | <values> ) [AS <identifier>]
 //    <table_source> := <identifier>
 //    <join_clause>  := (INNER | LEFT | OUTER)? JOIN [ON <conditional_clause>]
 //    <subselect>    := '(' <select_stmt> ')'
+//    <values>       := '(' VALUES <row> [, <row>]* ')' [AS <identifier> '(' <identifier> [, <identifier>]* ')']
 //
 func LexTableReferences(l *Lexer) StateFn {
 
@@ -1918,6 +2385,7 @@ func LexTableReferences(l *Lexer) StateFn {
 	case ';':
 		l.Next()
 		l.Emit(TokenEOS)
+		l.endStatement()
 		return nil
 	case '(':
 		l.Next()
@@ -1979,6 +2447,27 @@ func LexTableReferences(l *Lexer) StateFn {
 		l.Emit(TokenOn)
 		l.Push("LexTableReferences", LexTableReferences)
 		return LexConditionalClause
+	case "use", "force", "ignore":
+		if !l.isIndexHint(word) {
+			break
+		}
+		l.Push("LexTableReferences", LexTableReferences)
+		return LexIndexHint
+	case "tablesample":
+		l.Push("LexTableReferences", LexTableReferences)
+		return LexTableSample
+	case "pivot", "unpivot":
+		if !l.isPivot(word) {
+			break
+		}
+		l.Push("LexTableReferences", LexTableReferences)
+		return LexPivot
+	case "sample":
+		if !l.isSample(word) {
+			break
+		}
+		l.Push("LexTableReferences", LexTableReferences)
+		return LexTableSample
 	case "in": // what is complete list here?
 		l.ConsumeWord(word)
 		l.Emit(TokenIN)
@@ -2020,10 +2509,11 @@ func LexTableReferences(l *Lexer) StateFn {
 //    SELECT ...  FROM <sources>
 //
 //    <sources>      := <source> [, <join_clause> <source>]*
-//    <source>       := ( <table_source> | <subselect> ) [AS <identifier>]
+//    <source>       := ( <table_source> | <subselect> | <values> ) [AS <identifier>]
 //    <table_source> := <identifier>
 //    <join_clause>  := (INNER | LEFT | OUTER)? JOIN [ON <conditional_clause>]
 //    <subselect>    := '(' <select_stmt> ')'
+//    <values>       := '(' VALUES <row> [, <row>]* ')' [AS <identifier> '(' <identifier> [, <identifier>]* ')']
 //
 func LexJoinEntry(l *Lexer) StateFn {
 
@@ -2041,10 +2531,14 @@ func LexJoinEntry(l *Lexer) StateFn {
 	case ';':
 		l.Next()
 		l.Emit(TokenEOS)
+		l.endStatement()
 		return nil
 	case '(':
 		l.Next()
 		l.Emit(TokenLeftParenthesis)
+		if strings.ToLower(l.PeekWord()) == "values" {
+			return LexValuesTable
+		}
 		// subquery?
 		//l.Push("LexJoinEntry", LexJoinEntry)
 		//return LexSelectClause
@@ -2279,6 +2773,26 @@ func LexConditionalClause(l *Lexer) StateFn {
 	return LexExpression(l)
 }
 
+// LexDescribe the target of DESCRIBE/EXPLAIN, an identity or a statement
+// to explain, the statement is emitted as just its keyword (identity) and
+// parsed from the raw input by the parser.
+//
+//    EXPLAIN users
+//    EXPLAIN DELETE FROM users WHERE user_id = 1
+//
+func LexDescribe(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	word := l.PeekWord()
+	switch strings.ToLower(word) {
+	case "select", "insert", "replace", "upsert", "update", "delete",
+		"create", "drop", "alter", "extended":
+		l.ConsumeWord(word)
+		l.Emit(TokenIdentity)
+		return nil
+	}
+	return LexColumns(l)
+}
+
 // Alias for Expression
 func LexColumns(l *Lexer) StateFn {
 	return LexExpression(l)
@@ -2371,10 +2885,16 @@ func LexExpression(l *Lexer) StateFn {
 		//l.Emit(TokenRightParenthesis)
 		l.backup() // don't consume )
 		return nil
-	case '!', '=', '>', '<', ',', ';', '-', '*', '+', '%', '&', '/', '|':
+	case '!', '=', '>', '<', ',', ';', '-', '*', '+', '%', '&', '/', '|', ':':
 		foundLogical := false
 		foundOperator := false
 		switch r {
+		case ':': //  @var := expr
+			if r2 := l.Peek(); r2 == '=' {
+				l.Next()
+				l.Emit(TokenAssign)
+				foundOperator = true
+			}
 		case '-': // comment?  or minus?
 			p := l.Peek()
 			if p == '-' {
@@ -2412,7 +2932,11 @@ func LexExpression(l *Lexer) StateFn {
 		case '|':
 			if r2 := l.Peek(); r2 == '|' {
 				l.Next()
-				l.Emit(TokenOr)
+				if l.pipesConcat {
+					l.Emit(TokenConcat)
+				} else {
+					l.Emit(TokenOr)
+				}
 				foundOperator = true
 			}
 		case '&':
@@ -2475,23 +2999,33 @@ func LexExpression(l *Lexer) StateFn {
 
 	l.backup()
 	word := strings.ToLower(l.PeekWord())
+	if word == "sounds" && l.isSoundsLike() {
+		word = "sounds like"
+	}
 	// u.Debugf("LexExpression operator:  word=%q  kw?%v", word, l.isNextKeyword(word))
 	switch word {
 	case "as":
 		return nil
-	case "in", "intersects", "like", "between", "contains": // what is complete list here?
+	case "in", "intersects", "like", "between", "contains", "sounds like": // what is complete list here?
 		switch word {
 		case "in":
 			l.ConsumeWord(word)
 			l.Emit(TokenIN)
 			l.SkipWhiteSpaces()
+			if l.isDateWindow() {
+				return LexDateWindow
+			}
 			if l.PeekX(1) == "(" {
 				l.ConsumeWord("(")
 				l.Emit(TokenLeftParenthesis)
 				l.SkipWhiteSpaces()
 				word = strings.ToLower(l.PeekWord())
-				if word == "select" {
+				switch word {
+				case "select":
 					return nil
+				case "and", "or":
+					// nested filter    orders IN ( AND ( total > 100, status == "paid" ) )
+					return LexFilterClause
 				}
 				l.Push("LexParenRight", LexParenRight)
 				return LexListOfArgs
@@ -2510,6 +3044,14 @@ func LexExpression(l *Lexer) StateFn {
 			l.ConsumeWord(word)
 			l.Emit(TokenLike)
 			return LexExpressionOrIdentity
+		case "sounds like":
+			l.ConsumeWord("sounds")
+			for isWhiteSpace(l.Peek()) {
+				l.Next()
+			}
+			l.ConsumeWord("like")
+			l.Emit(TokenSoundsLike)
+			return LexExpressionOrIdentity
 		case "contains":
 			l.ConsumeWord(word)
 			if l.Peek() == '(' {
@@ -2526,6 +3068,18 @@ func LexExpression(l *Lexer) StateFn {
 			l.Push("LexExpressionOrIdentity", LexExpressionOrIdentity)
 			return nil
 		}
+	case "against":
+		// MATCH(title, body) AGAINST ("terms"), else against is an identity
+		if l.isAgainst() {
+			l.ConsumeWord(word)
+			l.Emit(TokenAgainst)
+			l.SkipWhiteSpaces()
+			if l.Peek() == '(' {
+				l.Push("LexExpression", l.clauseState())
+				return LexExpressionParens
+			}
+			return LexExpressionOrIdentity
+		}
 	case "include":
 		l.ConsumeWord(word)
 		l.Emit(TokenInclude)
@@ -2641,6 +3195,77 @@ func LexExpression(l *Lexer) StateFn {
 	return LexExpressionOrIdentity
 }
 
+// isSoundsLike is next two words SOUNDS LIKE, so sounds may still be
+// an identity elsewhere.
+func (l *Lexer) isSoundsLike() bool {
+	fields := strings.Fields(strings.ToLower(l.PeekX(64)))
+	if len(fields) < 2 || fields[0] != "sounds" || !strings.HasPrefix(fields[1], "like") {
+		return false
+	}
+	return len(fields[1]) == 4 || !isIdentCh(rune(fields[1][4]))
+}
+
+// isAgainst is the last token the closing paren of a call, as in
+// MATCH(title) AGAINST, so against may still be an identity elsewhere.
+// AGAINST after any other call is lexed too, for the parser to reject
+// as not following MATCH().
+func (l *Lexer) isAgainst() bool {
+	return l.lastToken.T == TokenRightParenthesis
+}
+
+// isNullsOrder is next two words NULLS FIRST or NULLS LAST, so nulls may
+// still be a column name in ORDER BY.
+func (l *Lexer) isNullsOrder() bool {
+	fields := strings.Fields(strings.ToLower(l.PeekX(64)))
+	if len(fields) < 2 || fields[0] != "nulls" {
+		return false
+	}
+	for _, kw := range []string{"first", "last"} {
+		if strings.HasPrefix(fields[1], kw) {
+			return len(fields[1]) == len(kw) || !isIdentCh(rune(fields[1][len(kw)]))
+		}
+	}
+	return false
+}
+
+// isDateWindow is the input a relative date window
+//
+//    LAST 7 DAYS
+func (l *Lexer) isDateWindow() bool {
+	fields := strings.Fields(strings.ToLower(l.PeekX(64)))
+	if len(fields) < 3 || (fields[0] != "last" && fields[0] != "next") {
+		return false
+	}
+	for _, r := range fields[1] {
+		if !isDigit(r) {
+			return false
+		}
+	}
+	return isAlpha(rune(fields[2][0]))
+}
+
+// LexDateWindow a relative date window on the right side of IN, emits
+// the direction, count and unit.
+//
+//    created IN LAST 7 DAYS
+//    renewal_date IN NEXT 2 WEEKS
+func LexDateWindow(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	l.ConsumeWord(l.PeekWord())
+	l.Emit(TokenIdentity)
+	l.SkipWhiteSpaces()
+	for isDigit(l.Peek()) {
+		l.Next()
+	}
+	l.Emit(TokenInteger)
+	l.SkipWhiteSpaces()
+	for isAlpha(l.Peek()) {
+		l.Next()
+	}
+	l.Emit(TokenIdentity)
+	return LexExpression
+}
+
 // Handle columnar identies with keyword appendate (ASC, DESC)
 //
 //     [ORDER BY] ( <identity> | <expr> ) [(ASC | DESC)]
@@ -2683,13 +3308,34 @@ func LexOrderByColumn(l *Lexer) StateFn {
 		l.ConsumeWord(word)
 		l.Emit(TokenDesc)
 		return LexOrderByColumn
-	default:
-		if len(l.stack) < 2 {
-			l.Push("LexOrderByColumn", LexOrderByColumn)
-			return LexExpressionOrIdentity
-		} else {
-			u.Errorf("Gracefully refusing to add more LexOrderByColumn: ")
+	case "nulls":
+		// NULLS FIRST | NULLS LAST, else nulls is a column
+		if l.isNullsOrder() {
+			l.ConsumeWord(word)
+			l.Emit(TokenNulls)
+			return LexOrderByColumn
 		}
+	case "first":
+		if l.lastToken.T == TokenNulls {
+			l.ConsumeWord(word)
+			l.Emit(TokenFirst)
+			return LexOrderByColumn
+		}
+	case "last":
+		if l.lastToken.T == TokenNulls {
+			l.ConsumeWord(word)
+			l.Emit(TokenLast)
+			return LexOrderByColumn
+		}
+	}
+
+	// the stack may hold the states of earlier clauses (a join), only
+	// refuse to recurse on ourselves
+	if n := len(l.stack); n == 0 || l.stack[n-1].Name != "LexOrderByColumn" {
+		l.Push("LexOrderByColumn", LexOrderByColumn)
+		return LexExpressionOrIdentity
+	} else {
+		u.Errorf("Gracefully refusing to add more LexOrderByColumn: ")
 	}
 
 	// Since we did Not find anything, we are in error?
@@ -2974,6 +3620,27 @@ func LexMultilineComment(l *Lexer) StateFn {
 	return nil
 }
 
+// LexOptimizerHint an optimizer hint comment immediately after SELECT,
+// emits the hints text between /*+ and */
+//
+//    SELECT /*+ HASH_JOIN(o, u) NO_PUSHDOWN(u) PARALLEL(4) */ ...
+//
+func LexOptimizerHint(l *Lexer) StateFn {
+	l.ignoreWord("/*+")
+	for {
+		if strings.HasPrefix(l.input[l.pos:], "*/") {
+			break
+		}
+		r := l.Next()
+		if eof == r {
+			return l.errorf("unexpected eof in optimizer hint: %q", l.input)
+		}
+	}
+	l.Emit(TokenOptimizerHint)
+	l.ignoreWord("*/")
+	return nil
+}
+
 // Comment beginning with //, # or --
 func LexInlineComment(l *Lexer) StateFn {
 
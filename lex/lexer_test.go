@@ -59,6 +59,8 @@ func TestLexer(t *testing.T) {
 	assert.True(t, l.IsComment())
 	l = NewExpressionLexer(`//hello`)
 	assert.True(t, l.IsComment())
+	l = NewExpressionLexer(`/*+ ROWS(10) */`)
+	assert.True(t, l.IsComment())
 	l = NewExpressionLexer(`--hello`)
 	assert.True(t, l.IsComment())
 	l.pop()
@@ -95,6 +97,8 @@ func TestLexIdentity(t *testing.T) {
 	verifyIdentity(t, "`table_name`", "table_name", true)
 	verifyIdentity(t, "`table name`", "table name", true)
 	verifyIdentity(t, "`table name`.`right side`", "table name`.`right side", true)
+	verifyIdentity(t, "`tick``name`", "tick`name", true)
+	verifyIdentity(t, "`user.name`", "user.name", true)
 	tok := token("`table_name`", LexIdentifier)
 	assert.True(t, tok.T == TokenIdentity && tok.V == "table_name", "%v", tok)
 	tok = token("`table w *&$% ^ 56 rty`", LexIdentifier)
@@ -147,6 +151,25 @@ func TestLexValue(t *testing.T) {
 	tok = token(`"Toys R"" Us"`, LexValue)
 	assert.True(t, tok.T == TokenValueEscaped, "%v", tok)
 	assert.True(t, tok.V == `Toys R"" Us`, "%v", tok.String())
+
+	// hex binary
+	tok = token(`X'0a1B'`, LexValue)
+	assert.True(t, tok.T == TokenValueHex && tok.V == "0a1B", "%v", tok)
+	tok = token(`x'0a1'`, LexValue)
+	assert.True(t, tok.T == TokenError, "%v", tok)
+	tok = token(`X'0z'`, LexValue)
+	assert.True(t, tok.T == TokenError, "%v", tok)
+	verifyTokens(t, `SELECT x FROM t WHERE x = X'ABCD'`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "x"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "t"),
+			tv(TokenWhere, "WHERE"),
+			tv(TokenIdentity, "x"),
+			tv(TokenEqual, "="),
+			tv(TokenValueHex, "ABCD"),
+		})
 }
 
 func TestLexRegex(t *testing.T) {
@@ -352,6 +375,18 @@ SELECT x FROM mytable`,
 			tv(TokenFrom, "FROM"),
 			tv(TokenIdentity, "mytable"),
 		})
+
+	// optimizer hints only immediately after SELECT
+	verifyTokens(t, `SELECT /*+ HASH_JOIN(a, b) */ DISTINCT x /*+ y */ FROM mytable`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenOptimizerHint, " HASH_JOIN(a, b) "),
+			tv(TokenDistinct, "DISTINCT"),
+			tv(TokenIdentity, "x"),
+			tv(TokenCommentML, "+ y "),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "mytable"),
+		})
 }
 
 func TestLexSqlIdentities(t *testing.T) {
@@ -506,6 +541,51 @@ func TestWhereClauses(t *testing.T) {
 			tv(TokenValue, "%bob"),
 		})
 
+	verifyTokens(t, `SELECT sounds FROM p INNER JOIN q ON p.name SOUNDS LIKE q.name`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "sounds"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "p"),
+			tv(TokenInner, "INNER"),
+			tv(TokenJoin, "JOIN"),
+			tv(TokenIdentity, "q"),
+			tv(TokenOn, "ON"),
+			tv(TokenIdentity, "p.name"),
+			tv(TokenSoundsLike, "SOUNDS LIKE"),
+			tv(TokenIdentity, "q.name"),
+		})
+
+	verifyTokens(t, `SELECT MATCH(title) AGAINST ("fox") AS score FROM p`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenUdfExpr, "MATCH"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "title"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenAgainst, "AGAINST"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenValue, "fox"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenAs, "AS"),
+			tv(TokenIdentity, "score"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "p"),
+		})
+
+	// against is only a keyword after MATCH(...)
+	verifyTokens(t, `SELECT against FROM p WHERE against > 1`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "against"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "p"),
+			tv(TokenWhere, "WHERE"),
+			tv(TokenIdentity, "against"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "1"),
+		})
+
 	verifyTokens(t, `SELECT x FROM p
 		WHERE
 			eq(name,"bob")
@@ -679,6 +759,25 @@ func TestLexSqlSubQuery(t *testing.T) {
 		})
 }
 
+func TestLexSqlValuesTable(t *testing.T) {
+	verifyTokenTypes(t, `SELECT * FROM (VALUES (1, 'a'), (-2, 'b')) AS t(id, name) WHERE id > 1`,
+		[]TokenType{TokenSelect, TokenStar, TokenFrom,
+			TokenLeftParenthesis, TokenValues,
+			TokenLeftParenthesis, TokenInteger, TokenComma, TokenValue, TokenRightParenthesis, TokenComma,
+			TokenLeftParenthesis, TokenMinus, TokenInteger, TokenComma, TokenValue, TokenRightParenthesis,
+			TokenRightParenthesis, TokenAs, TokenIdentity,
+			TokenLeftParenthesis, TokenIdentity, TokenComma, TokenIdentity, TokenRightParenthesis,
+			TokenWhere, TokenIdentity, TokenGT, TokenInteger,
+		})
+	verifyTokenTypes(t, `SELECT u.name FROM users AS u INNER JOIN (VALUES (1)) AS r(id) ON u.id = r.id`,
+		[]TokenType{TokenSelect, TokenIdentity, TokenFrom, TokenIdentity, TokenAs, TokenIdentity,
+			TokenInner, TokenJoin, TokenLeftParenthesis, TokenValues,
+			TokenLeftParenthesis, TokenInteger, TokenRightParenthesis, TokenRightParenthesis,
+			TokenAs, TokenIdentity, TokenLeftParenthesis, TokenIdentity, TokenRightParenthesis,
+			TokenOn, TokenIdentity, TokenEqual, TokenIdentity,
+		})
+}
+
 func TestLexSqlPreparedStmt(t *testing.T) {
 	verifyTokens(t, `
 		PREPARE stmt1 
@@ -33,6 +33,20 @@ func (t Token) String() string {
 		t.V, t.T.String(), t.Line, t.Column, string(t.Quote), t.Pos)
 }
 func (t Token) Err(l *Lexer) error { return t.ErrMsg(l, "") }
+
+// Start the 1 based column, and offset in the input, of the start of the
+// token, Column and Pos are of its end.
+func (t Token) Start() (column, pos int) {
+	pos = t.Pos - len(t.V)
+	column = t.Column - len(t.V) + 1
+	if pos < 0 {
+		pos = 0
+	}
+	if column < 1 {
+		column = 1
+	}
+	return column, pos
+}
 func (t Token) ErrMsg(l *Lexer, msg string) error {
 	return l.ErrMsg(t, msg)
 }
@@ -81,6 +95,7 @@ const (
 	TokenCommentSlashes    TokenType = 14 // Single Line comment:   // hello
 	TokenCommentSingleLine TokenType = 15 // Single Line comment:   -- hello
 	TokenCommentHash       TokenType = 16 // Single Line comment:  # hello
+	TokenOptimizerHint     TokenType = 17 // Optimizer hint comment:  /*+ PARALLEL(4) */
 
 	// Misc
 	TokenComma        TokenType = 20 // ,
@@ -123,6 +138,10 @@ const (
 	TokenNull             TokenType = 88 // NULL
 	TokenContains         TokenType = 89 // CONTAINS
 	TokenIntersects       TokenType = 90 // INTERSECTS
+	TokenAssign           TokenType = 91 // :=
+	TokenSoundsLike       TokenType = 92 // SOUNDS LIKE
+	TokenAgainst          TokenType = 93 // AGAINST   (MATCH(col) AGAINST ("terms"))
+	TokenConcat           TokenType = 94 // ||  string concatenation (DialectMode.PipesConcat)
 
 	// ql top-level keywords, these first keywords determine parser
 	TokenPrepare   TokenType = 200
@@ -142,6 +161,8 @@ const (
 	TokenReplace   TokenType = 214 // Insert/Replace are interchangeable on insert statements
 	TokenRollback  TokenType = 215
 	TokenCommit    TokenType = 216
+	TokenCall      TokenType = 217 // CALL stored procedure
+	TokenAnalyze   TokenType = 218 // ANALYZE TABLE statistics
 
 	// Other QL Keywords, These are clause-level keywords that mark separation between clauses
 	TokenFrom     TokenType = 300 // from
@@ -171,6 +192,7 @@ const (
 	TokenGlobal   TokenType = 324 // GLOBAL
 	TokenSession  TokenType = 325 // SESSION
 	TokenTables   TokenType = 326 // TABLES
+	TokenFetch    TokenType = 327 // FETCH (FETCH FIRST 10 ROWS ONLY)
 
 	// ddl major words
 	TokenSchema         TokenType = 400 // SCHEMA
@@ -180,6 +202,7 @@ const (
 	TokenView           TokenType = 404 // VIEW
 	TokenContinuousView TokenType = 405 // CONTINUOUSVIEW
 	TokenTemp           TokenType = 406 // TEMP or TEMPORARY
+	TokenSample         TokenType = 407 // SAMPLE TABLE
 
 	// ddl other
 	TokenChange       TokenType = 410 // change
@@ -197,11 +220,23 @@ const (
 	TokenEngine       TokenType = 422 // engine
 
 	// Other QL keywords
-	TokenSet  TokenType = 500 // set
-	TokenAs   TokenType = 501 // as
-	TokenAsc  TokenType = 502 // ascending
-	TokenDesc TokenType = 503 // descending
-	TokenUse  TokenType = 504 // use
+	TokenSet         TokenType = 500 // set
+	TokenAs          TokenType = 501 // as
+	TokenAsc         TokenType = 502 // ascending
+	TokenDesc        TokenType = 503 // descending
+	TokenUse         TokenType = 504 // use
+	TokenNulls       TokenType = 505 // nulls  (ORDER BY x NULLS FIRST)
+	TokenLast        TokenType = 506 // last
+	TokenForce       TokenType = 507 // force  (FORCE INDEX)
+	TokenIgnore      TokenType = 508 // ignore (IGNORE INDEX)
+	TokenIndex       TokenType = 509 // index
+	TokenFor         TokenType = 510 // for    (USE INDEX FOR JOIN)
+	TokenTableSample TokenType = 511 // tablesample
+	TokenPercent     TokenType = 512 // percent (TABLESAMPLE (10 PERCENT))
+	TokenRows        TokenType = 513 // rows    (TABLESAMPLE (1000 ROWS))
+	TokenPivot       TokenType = 514 // pivot
+	TokenUnpivot     TokenType = 515 // unpivot
+	TokenSeparator   TokenType = 516 // separator (GROUP_CONCAT(x SEPARATOR ", "))
 
 	// User defined function/expression
 	TokenUdfExpr TokenType = 550
@@ -212,6 +247,7 @@ const (
 	TokenValueEscaped TokenType = 602 // '' becomes ' inside the string, parser will need to replace the string
 	TokenRegex        TokenType = 603 // regex
 	TokenDuration     TokenType = 604 // 14d , 22w, 3y, 45ms, 45us, 24hr, 2h, 45m, 30s
+	TokenValueHex     TokenType = 605 // X'ABCD' hex encoded binary value
 
 	// Data Type Definitions
 	TokenTypeDef     TokenType = 999
@@ -266,6 +302,7 @@ var (
 		TokenCommentHash:       {Description: "#"},
 		TokenCommentSingleLine: {Description: "--"},
 		TokenCommentSlashes:    {Description: "//"},
+		TokenOptimizerHint:     {Description: "OptimizerHint"},
 
 		// Misc
 		TokenComma:        {Description: ","},
@@ -304,6 +341,10 @@ var (
 		TokenNull:       {Kw: "null", Description: "NULL"},
 		TokenContains:   {Kw: "contains", Description: "contains"},
 		TokenIntersects: {Kw: "intersects", Description: "intersects"},
+		TokenAssign:     {Kw: ":=", Description: "Assign :="},
+		TokenSoundsLike: {Kw: "sounds like", Description: "SOUNDS LIKE"},
+		TokenAgainst:    {Kw: "against", Description: "AGAINST"},
+		TokenConcat:     {Kw: "concat", Description: "||"},
 
 		// Identity ish bools
 		TokenTrue:  {Kw: "true", Description: "True"},
@@ -334,6 +375,8 @@ var (
 		TokenReplace:   {Description: "replace"},
 		TokenRollback:  {Description: "rollback"},
 		TokenCommit:    {Description: "commit"},
+		TokenCall:      {Description: "call"},
+		TokenAnalyze:   {Description: "analyze"},
 
 		// Top Level dml ql clause keywords
 		TokenInto:    {Description: "into"},
@@ -364,6 +407,7 @@ var (
 		TokenGlobal:   {Description: "global"},
 		TokenSession:  {Description: "session"},
 		TokenTables:   {Description: "tables"},
+		TokenFetch:    {Description: "fetch"},
 
 		// ddl keywords
 		TokenSchema:         {Description: "schema"},
@@ -373,6 +417,7 @@ var (
 		TokenView:           {Description: "view"},
 		TokenContinuousView: {Description: "continuousview"},
 		TokenTemp:           {Description: "temp"},
+		TokenSample:         {Description: "sample"},
 		// ddl other
 		TokenChange:       {Description: "change"},
 		TokenCharacterSet: {Description: "character set"},
@@ -389,11 +434,23 @@ var (
 		TokenEngine:       {Description: "engine"},
 
 		// QL Keywords, all lower-case
-		TokenSet:  {Description: "set"},
-		TokenAs:   {Description: "as"},
-		TokenAsc:  {Description: "asc"},
-		TokenDesc: {Description: "desc"},
-		TokenUse:  {Description: "use"},
+		TokenSet:         {Description: "set"},
+		TokenAs:          {Description: "as"},
+		TokenAsc:         {Description: "asc"},
+		TokenDesc:        {Description: "desc"},
+		TokenUse:         {Description: "use"},
+		TokenNulls:       {Description: "nulls"},
+		TokenLast:        {Description: "last"},
+		TokenForce:       {Description: "force"},
+		TokenIgnore:      {Description: "ignore"},
+		TokenIndex:       {Description: "index"},
+		TokenFor:         {Description: "for"},
+		TokenTableSample: {Description: "tablesample"},
+		TokenPercent:     {Description: "percent"},
+		TokenRows:        {Description: "rows"},
+		TokenPivot:       {Description: "pivot"},
+		TokenUnpivot:     {Description: "unpivot"},
+		TokenSeparator:   {Description: "separator"},
 
 		// special value types
 		TokenIdentity:     {Description: "identity"},
@@ -401,6 +458,7 @@ var (
 		TokenValueEscaped: {Description: "value-escaped"},
 		TokenRegex:        {Description: "regex"},
 		TokenDuration:     {Description: "duration"},
+		TokenValueHex:     {Description: "hex"},
 
 		// Data TYPES:  ie type system
 		TokenTypeDef:     {Description: "TypeDef"}, // Generic DataType
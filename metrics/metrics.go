@@ -0,0 +1,100 @@
+// Package metrics is the engine wide metrics interface, queries, operators
+// source connection pools and caches report to the Metrics set with Set.
+// Nothing is measured until one is set, so disabled metrics cost an
+// atomic load per report site.
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Metrics receives engine measurements, implementations must be safe
+	// for concurrent use and not block.
+	Metrics interface {
+		// QueryStarted a statement of kind (select, insert ...) started.
+		QueryStarted(kind string)
+		// QueryFinished a statement of kind finished after dur, err nil
+		// if it succeeded.
+		QueryFinished(kind string, dur time.Duration, err error)
+		// OperatorDone an operator (where, projection, source ...) of a
+		// query finished after processing rows in dur.
+		OperatorDone(op string, rows int64, dur time.Duration)
+		// PoolStats current stats of the connection pool of source.
+		PoolStats(source string, stats PoolStats)
+		// CacheAccess a lookup of cache, hit or miss.
+		CacheAccess(cache string, hit bool)
+		// Admission current running and queued queries of the admission
+		// control scheduler.
+		Admission(running, queued int)
+		// RowError a row of a streaming query failed in op (where,
+		// projection, result) and was handled by action (drop, halt,
+		// dead_letter).
+		RowError(op, action string)
+	}
+	// PoolStats of a source connection pool.
+	PoolStats struct {
+		Open         int           // open connections, idle and in use
+		Idle         int           // idle connections
+		Waits        int64         // total times a caller waited for a connection
+		WaitDuration time.Duration // total time callers waited
+	}
+	// Nop discards all metrics.
+	Nop struct{}
+)
+
+var (
+	mu      sync.RWMutex
+	current Metrics = Nop{}
+	enabled int32
+)
+
+func (Nop) QueryStarted(kind string)                                {}
+func (Nop) QueryFinished(kind string, dur time.Duration, err error) {}
+func (Nop) OperatorDone(op string, rows int64, dur time.Duration)   {}
+func (Nop) PoolStats(source string, stats PoolStats)                {}
+func (Nop) CacheAccess(cache string, hit bool)                      {}
+func (Nop) Admission(running, queued int)                           {}
+func (Nop) RowError(op, action string)                              {}
+
+// Set the engine metrics, nil disables metrics.
+func Set(m Metrics) {
+	mu.Lock()
+	defer mu.Unlock()
+	if m == nil {
+		current = Nop{}
+		atomic.StoreInt32(&enabled, 0)
+		return
+	}
+	current = m
+	atomic.StoreInt32(&enabled, 1)
+}
+
+// Get the engine metrics, Nop if disabled.
+func Get() Metrics {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Enabled has a Metrics been set, report sites check this before
+// measuring anything.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// CacheAccess report a cache lookup, if enabled.
+func CacheAccess(cache string, hit bool) {
+	if Enabled() {
+		Get().CacheAccess(cache, hit)
+	}
+}
+
+// RowError report a failed row of a streaming query, if enabled.
+func RowError(op, action string) {
+	if Enabled() {
+		Get().RowError(op, action)
+	}
+}
@@ -0,0 +1,206 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ Metrics      = (*Prometheus)(nil)
+	_ http.Handler = (*Prometheus)(nil)
+
+	// DefaultBuckets of the duration histograms, in seconds.
+	DefaultBuckets = []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 60}
+)
+
+// Prometheus collects metrics and serves them in the prometheus text
+// exposition format, so it needs no prometheus client library.
+//
+//     p := metrics.NewPrometheus("qlbridge")
+//     metrics.Set(p)
+//     http.Handle("/metrics", p)
+//
+type Prometheus struct {
+	namespace string
+	buckets   []float64
+	mu        sync.Mutex
+	families  map[string]*family
+}
+
+type family struct {
+	help   string
+	typ    string // counter, gauge, histogram
+	series map[string]*series
+}
+
+type series struct {
+	val     float64
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewPrometheus collector, metric names are prefixed by namespace_.
+func NewPrometheus(namespace string) *Prometheus {
+	return &Prometheus{
+		namespace: namespace,
+		buckets:   DefaultBuckets,
+		families:  make(map[string]*family),
+	}
+}
+
+func (m *Prometheus) QueryStarted(kind string) {
+	m.add("queries_started_total", "counter", "Statements started.", labels("kind", kind), 1)
+}
+func (m *Prometheus) QueryFinished(kind string, dur time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.add("queries_finished_total", "counter", "Statements finished.", labels("kind", kind, "status", status), 1)
+	m.observe("query_duration_seconds", "Statement durations.", labels("kind", kind), dur.Seconds())
+}
+func (m *Prometheus) OperatorDone(op string, rows int64, dur time.Duration) {
+	m.add("operator_rows_total", "counter", "Rows processed by operators.", labels("op", op), float64(rows))
+	m.observe("operator_duration_seconds", "Operator durations.", labels("op", op), dur.Seconds())
+}
+func (m *Prometheus) PoolStats(source string, stats PoolStats) {
+	l := labels("source", source)
+	m.set("pool_open_connections", "gauge", "Open source connections.", l, float64(stats.Open))
+	m.set("pool_idle_connections", "gauge", "Idle source connections.", l, float64(stats.Idle))
+	m.set("pool_waits_total", "counter", "Waits for a source connection.", l, float64(stats.Waits))
+	m.set("pool_wait_seconds_total", "counter", "Time waited for a source connection.", l, stats.WaitDuration.Seconds())
+}
+func (m *Prometheus) CacheAccess(cache string, hit bool) {
+	if hit {
+		m.add("cache_hits_total", "counter", "Cache hits.", labels("cache", cache), 1)
+	} else {
+		m.add("cache_misses_total", "counter", "Cache misses.", labels("cache", cache), 1)
+	}
+}
+func (m *Prometheus) Admission(running, queued int) {
+	m.set("admission_running_queries", "gauge", "Queries admitted and running.", "", float64(running))
+	m.set("admission_queued_queries", "gauge", "Queries queued for admission.", "", float64(queued))
+}
+func (m *Prometheus) RowError(op, action string) {
+	m.add("stream_row_errors_total", "counter", "Rows of streaming queries that failed, by operator and action.", labels("op", op, "action", action), 1)
+}
+
+// labels render label pairs k1, v1, k2, v2 ... as {k1="v1",k2="v2"}
+func labels(kv ...string) string {
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, kv[i]+`="`+escapeLabel(kv[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+func (m *Prometheus) series(name, typ, help, l string) *series {
+	f, ok := m.families[name]
+	if !ok {
+		f = &family{help: help, typ: typ, series: make(map[string]*series)}
+		m.families[name] = f
+	}
+	s, ok := f.series[l]
+	if !ok {
+		s = &series{}
+		if typ == "histogram" {
+			s.buckets = make([]uint64, len(m.buckets))
+		}
+		f.series[l] = s
+	}
+	return s
+}
+
+func (m *Prometheus) add(name, typ, help, l string, v float64) {
+	m.mu.Lock()
+	m.series(name, typ, help, l).val += v
+	m.mu.Unlock()
+}
+
+func (m *Prometheus) set(name, typ, help, l string, v float64) {
+	m.mu.Lock()
+	m.series(name, typ, help, l).val = v
+	m.mu.Unlock()
+}
+
+func (m *Prometheus) observe(name, help, l string, v float64) {
+	m.mu.Lock()
+	s := m.series(name, "histogram", help, l)
+	for i, le := range m.buckets {
+		if v <= le {
+			s.buckets[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+	m.mu.Unlock()
+}
+
+// Write the metrics in the prometheus text exposition format, sorted by
+// name and labels.
+func (m *Prometheus) Write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := m.families[name]
+		full := name
+		if m.namespace != "" {
+			full = m.namespace + "_" + name
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", full, f.help, full, f.typ)
+		keys := make([]string, 0, len(f.series))
+		for l := range f.series {
+			keys = append(keys, l)
+		}
+		sort.Strings(keys)
+		for _, l := range keys {
+			s := f.series[l]
+			if f.typ != "histogram" {
+				fmt.Fprintf(bw, "%s%s %s\n", full, l, formatFloat(s.val))
+				continue
+			}
+			inner := strings.TrimSuffix(strings.TrimPrefix(l, "{"), "}")
+			if inner != "" {
+				inner += ","
+			}
+			for i, le := range m.buckets {
+				fmt.Fprintf(bw, "%s_bucket{%sle=\"%s\"} %d\n", full, inner, formatFloat(le), s.buckets[i])
+			}
+			fmt.Fprintf(bw, "%s_bucket{%sle=\"+Inf\"} %d\n", full, inner, s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", full, l, formatFloat(s.sum))
+			fmt.Fprintf(bw, "%s_count%s %d\n", full, l, s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serve the metrics to a prometheus scrape.
+func (m *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := m.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,77 @@
+package metrics_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/metrics"
+)
+
+func TestPrometheus(t *testing.T) {
+	p := metrics.NewPrometheus("qlb")
+	p.QueryStarted("select")
+	p.QueryFinished("select", 20*time.Millisecond, nil)
+	p.QueryFinished("select", 2*time.Second, fmt.Errorf("bad"))
+	p.OperatorDone("where", 10, time.Millisecond)
+	p.OperatorDone("where", 5, time.Millisecond)
+	p.PoolStats("mysql", metrics.PoolStats{Open: 4, Idle: 1, Waits: 2, WaitDuration: 500 * time.Millisecond})
+	p.CacheAccess("zonemap", true)
+	p.CacheAccess("zonemap", false)
+	p.CacheAccess(`a"b`, true)
+	p.RowError("where", "dead_letter")
+	p.RowError("where", "dead_letter")
+
+	buf := &bytes.Buffer{}
+	assert.Equal(t, nil, p.Write(buf))
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE qlb_queries_started_total counter\nqlb_queries_started_total{kind=\"select\"} 1\n",
+		`qlb_queries_finished_total{kind="select",status="error"} 1`,
+		`qlb_queries_finished_total{kind="select",status="ok"} 1`,
+		"# TYPE qlb_query_duration_seconds histogram",
+		`qlb_query_duration_seconds_bucket{kind="select",le="0.01"} 0`,
+		`qlb_query_duration_seconds_bucket{kind="select",le="0.05"} 1`,
+		`qlb_query_duration_seconds_bucket{kind="select",le="5"} 2`,
+		`qlb_query_duration_seconds_bucket{kind="select",le="+Inf"} 2`,
+		`qlb_query_duration_seconds_sum{kind="select"} 2.02`,
+		`qlb_query_duration_seconds_count{kind="select"} 2`,
+		`qlb_operator_rows_total{op="where"} 15`,
+		`qlb_pool_open_connections{source="mysql"} 4`,
+		`qlb_pool_idle_connections{source="mysql"} 1`,
+		`qlb_pool_wait_seconds_total{source="mysql"} 0.5`,
+		`qlb_cache_hits_total{cache="zonemap"} 1`,
+		`qlb_cache_misses_total{cache="zonemap"} 1`,
+		`qlb_cache_hits_total{cache="a\"b"} 1`,
+		`qlb_stream_row_errors_total{op="where",action="dead_letter"} 2`,
+	} {
+		assert.True(t, strings.Contains(out, want), "missing %s in\n%s", want, out)
+	}
+	// sorted by name
+	assert.True(t, strings.Index(out, "qlb_cache_hits_total") < strings.Index(out, "qlb_queries_started_total"))
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, out, rec.Body.String())
+}
+
+func TestSetMetrics(t *testing.T) {
+	assert.False(t, metrics.Enabled())
+	p := metrics.NewPrometheus("")
+	metrics.Set(p)
+	assert.True(t, metrics.Enabled())
+	metrics.CacheAccess("c", true)
+	metrics.Set(nil)
+	assert.False(t, metrics.Enabled())
+	metrics.CacheAccess("c", true)
+
+	buf := &bytes.Buffer{}
+	p.Write(buf)
+	assert.True(t, strings.Contains(buf.String(), "cache_hits_total{cache=\"c\"} 1\n"), buf.String())
+}
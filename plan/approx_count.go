@@ -0,0 +1,75 @@
+package plan
+
+import (
+	"database/sql/driver"
+	"math"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// ApproxOption is the WITH option requesting approximate answers
+//
+//	SELECT COUNT(*) FROM huge_table WITH approx=true
+const ApproxOption = "approx"
+
+// isApproxCount is statement a bare COUNT(*) with the approx option, ie
+// one that may be answered from table statistics or source metadata
+// instead of a scan.
+func isApproxCount(stmt *rel.SqlSelect) bool {
+	if stmt.With == nil || !stmt.With.Bool(ApproxOption) {
+		return false
+	}
+	if len(stmt.From) != 1 || stmt.From[0].SubQuery != nil || stmt.Where != nil ||
+		len(stmt.GroupBy) > 0 || stmt.Having != nil || stmt.Distinct || len(stmt.Columns) != 1 {
+		return false
+	}
+	fn, ok := stmt.Columns[0].Expr.(*expr.FuncNode)
+	if !ok || strings.ToLower(fn.Name) != "count" || len(fn.Args) != 1 {
+		return false
+	}
+	switch arg := fn.Args[0].(type) {
+	case *expr.StringNode:
+		return arg.Text == "*"
+	case *expr.NumberNode:
+		return true
+	}
+	return false
+}
+
+// approxRowCount the row count of source from the sources metadata, or
+// the cardinality estimator, false if neither knows.
+func approxRowCount(ctx *Context, p *Source) (int64, bool) {
+	if rc, ok := p.DataSource.(schema.SourceRowCounter); ok {
+		if rows, ok := rc.RowCount(p.Stmt.SourceName()); ok {
+			return rows, true
+		}
+	}
+	if est := ctx.Estimator(); est != nil && p.Tbl != nil {
+		if rows, ok := est.TableRows(p.Tbl); ok && rows >= 0 {
+			return int64(math.Round(rows)), true
+		}
+	}
+	return 0, false
+}
+
+// walkApproxCount plan an approximate COUNT(*) as a static single row
+// source of the row count.
+func (m *PlannerDefault) walkApproxCount(p *Select, rows int64) error {
+	col := p.Stmt.Columns[0]
+	as := col.As
+	if as == "" {
+		as = col.Expr.String()
+	}
+	proj := rel.NewProjection()
+	proj.AddColumnShort(as, value.IntType)
+	m.Ctx.Projection = NewProjectionStatic(proj)
+	src := NewSourceStaticPlan(m.Ctx)
+	src.Static = []driver.Value{rows}
+	src.Cols = []string{as}
+	p.Add(src)
+	return nil
+}
@@ -0,0 +1,142 @@
+package plan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// BudgetMaxRowsAttr client attribute, max rows a query may return.
+	BudgetMaxRowsAttr = "qlb_max_rows"
+	// BudgetMaxBytesAttr client attribute, max (approximate) bytes of row
+	// values a query may return.
+	BudgetMaxBytesAttr = "qlb_max_bytes"
+	// BudgetTimeoutAttr client attribute, max milliseconds a query may run.
+	BudgetTimeoutAttr = "qlb_timeout_ms"
+	// BudgetDeadlineAttr client attribute, RFC3339 time after which
+	// queries are interrupted.
+	BudgetDeadlineAttr = "qlb_deadline"
+	// BudgetMaxMemoryAttr client attribute, max (approximate) bytes the
+	// sort, aggregate and join operators of a query may hold.
+	BudgetMaxMemoryAttr = "qlb_max_memory"
+)
+
+// Budget is the limits a client asks for its queries, so applications can
+// self-limit without server wide settings.  They are passed as mysql
+// connection attributes
+//
+//     mysql --connect-attrs  qlb_max_rows=1000,qlb_timeout_ms=500
+//
+// or on the go context of the database/sql driver
+//
+//     ctx := plan.WithBudget(ctx, &plan.Budget{MaxRows: 1000})
+//     rows, err := db.QueryContext(ctx, "SELECT * FROM users")
+//
+// A query returning more rows or bytes than allowed fails, instead of
+// being truncated, as would a LIMIT.
+type Budget struct {
+	MaxRows   int64         // max rows returned, 0 for no limit
+	MaxBytes  int64         // max approximate bytes of values returned, 0 for no limit
+	Timeout   time.Duration // max time per query, 0 for no limit
+	Deadline  time.Time     // queries are interrupted after, zero for none
+	MaxMemory int64         // max approximate bytes held by sort, aggregate, join, 0 for no limit
+}
+
+// ParseBudget read a budget from client attributes (BudgetMaxRowsAttr etc),
+// other attributes are ignored.  Returns nil if attrs has no budget.
+func ParseBudget(attrs map[string]string) (*Budget, error) {
+	b := &Budget{}
+	found := false
+	for k, v := range attrs {
+		v = strings.TrimSpace(v)
+		var err error
+		switch strings.ToLower(k) {
+		case BudgetMaxRowsAttr:
+			b.MaxRows, err = parseBudgetInt(k, v)
+		case BudgetMaxBytesAttr:
+			b.MaxBytes, err = parseBudgetInt(k, v)
+		case BudgetTimeoutAttr:
+			var ms int64
+			ms, err = parseBudgetInt(k, v)
+			b.Timeout = time.Duration(ms) * time.Millisecond
+		case BudgetMaxMemoryAttr:
+			b.MaxMemory, err = parseBudgetInt(k, v)
+		case BudgetDeadlineAttr:
+			b.Deadline, err = time.Parse(time.RFC3339, v)
+			if err != nil {
+				err = fmt.Errorf("invalid %s %q: must be RFC3339", k, v)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return b, nil
+}
+
+func parseBudgetInt(k, v string) (int64, error) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", k, v)
+	}
+	return n, nil
+}
+
+// QueryDeadline the time a query started at @start must finish by, the
+// earliest of the budget Deadline and Timeout, false if none.
+func (m *Budget) QueryDeadline(start time.Time) (time.Time, bool) {
+	if m == nil {
+		return time.Time{}, false
+	}
+	deadline := m.Deadline
+	if m.Timeout > 0 {
+		if d := start.Add(m.Timeout); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+	return deadline, !deadline.IsZero()
+}
+
+type budgetKey struct{}
+
+// WithBudget a copy of ctx carrying budget b.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// BudgetFromContext the budget of ctx, nil if none.
+func BudgetFromContext(ctx context.Context) *Budget {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(budgetKey{}).(*Budget)
+	return b
+}
+
+// QueryDeadline the time a statement started at @start must finish by, the
+// earliest of session max_execution_time, the client budget and the go
+// context deadline, false if none.
+func (m *Context) QueryDeadline(start time.Time) (time.Time, bool) {
+	deadline, ok := m.Budget.QueryDeadline(start)
+	if d := m.MaxExecutionTime(); d > 0 {
+		if t := start.Add(d); !ok || t.Before(deadline) {
+			deadline, ok = t, true
+		}
+	}
+	if m.Context != nil {
+		if t, has := m.Context.Deadline(); has && (!ok || t.Before(deadline)) {
+			deadline, ok = t, true
+		}
+	}
+	return deadline, ok
+}
@@ -0,0 +1,267 @@
+package plan
+
+import (
+	"math"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// DefaultEstimator is the CardinalityEstimator used when the plan Context
+	// doesn't have one.  Nil means only the built in heuristics are used.
+	DefaultEstimator CardinalityEstimator
+
+	// DefaultTableRows is the row count assumed for tables without an estimate.
+	DefaultTableRows float64 = 1000
+)
+
+// Heuristic selectivity of predicates with no estimate, these are the
+// classic System-R magic numbers.
+const (
+	selEqual   = 0.1
+	selRange   = 1.0 / 3
+	selBetween = 0.25
+	selLike    = 0.1
+	selNull    = 0.05
+	selDefault = 0.5
+	selInMax   = 0.5
+)
+
+// CardinalityEstimator supplies the row count and predicate selectivity
+// estimates the planner uses to cost plans.  Embedders with an external
+// statistics system (or learned estimator) set one on the plan Context
+// or as the DefaultEstimator.  Either method may return false for
+// tables/predicates it has no estimate for, the planner then falls back
+// to its own heuristics.
+type CardinalityEstimator interface {
+	// TableRows estimated number of rows in table.
+	TableRows(tbl *schema.Table) (float64, bool)
+	// Selectivity estimated fraction [0,1] of the rows of table matching
+	// predicate.  AND, OR and NOT of predicates without their own estimate
+	// are combined from their arguments estimates.
+	Selectivity(tbl *schema.Table, n expr.Node) (float64, bool)
+}
+
+// Estimators is a CardinalityEstimator that asks each estimator in order,
+// using the first estimate found.
+type Estimators []CardinalityEstimator
+
+// TableRows from first estimator that has an estimate.
+func (m Estimators) TableRows(tbl *schema.Table) (float64, bool) {
+	for _, e := range m {
+		if rows, ok := e.TableRows(tbl); ok {
+			return rows, true
+		}
+	}
+	return 0, false
+}
+
+// Selectivity from first estimator that has an estimate.
+func (m Estimators) Selectivity(tbl *schema.Table, n expr.Node) (float64, bool) {
+	for _, e := range m {
+		if sel, ok := e.Selectivity(tbl, n); ok {
+			return sel, true
+		}
+	}
+	return 0, false
+}
+
+// StatsHintEstimator a CardinalityEstimator using the inline statistics
+// hint of a table reference, falling back to est for anything not hinted.
+//
+//    FROM big_table /*+ ROWS(2e9) NDV(user_id, 5e7) */
+//
+func StatsHintEstimator(hint *rel.SqlStatsHint, est CardinalityEstimator) CardinalityEstimator {
+	if hint == nil {
+		return est
+	}
+	if est == nil {
+		return statsHint{hint}
+	}
+	return Estimators{statsHint{hint}, est}
+}
+
+// statsHint estimates from a /*+ ROWS(n) NDV(col, n) */ table hint
+type statsHint struct {
+	hint *rel.SqlStatsHint
+}
+
+// TableRows the hinted ROWS(n).
+func (m statsHint) TableRows(tbl *schema.Table) (float64, bool) {
+	return m.hint.Rows, m.hint.Rows > 0
+}
+
+// Selectivity of =, != and IN on a column with an NDV(col, n) hint,
+// assuming values are uniformly distributed.
+func (m statsHint) Selectivity(tbl *schema.Table, n expr.Node) (float64, bool) {
+	bn, ok := n.(*expr.BinaryNode)
+	if !ok || len(bn.Args) != 2 {
+		return 0, false
+	}
+	in, ok := bn.Args[0].(*expr.IdentityNode)
+	if !ok {
+		if in, ok = bn.Args[1].(*expr.IdentityNode); !ok || bn.Operator.T == lex.TokenIN {
+			return 0, false
+		}
+	}
+	_, col, _ := in.LeftRight()
+	ndv, ok := m.hint.Ndv[strings.ToLower(col)]
+	if !ok {
+		return 0, false
+	}
+	switch bn.Operator.T {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return 1 / ndv, true
+	case lex.TokenNE:
+		return 1 - 1/ndv, true
+	case lex.TokenIN:
+		if arr, ok := bn.Args[1].(*expr.ArrayNode); ok {
+			return math.Min(float64(len(arr.Args))/ndv, 1), true
+		}
+	}
+	return 0, false
+}
+
+// Estimator the cardinality estimator for this plan, the Contexts or the
+// DefaultEstimator, falling back to the analyzed table statistics.
+func (m *Context) Estimator() CardinalityEstimator {
+	est := DefaultEstimator
+	if m != nil && m.CardinalityEstimator != nil {
+		est = m.CardinalityEstimator
+	}
+	if est == nil {
+		return TableStatsEstimator
+	}
+	return Estimators{est, TableStatsEstimator}
+}
+
+// EstimateTableRows estimated row count of table using est, or
+// DefaultTableRows if est has no estimate.
+func EstimateTableRows(est CardinalityEstimator, tbl *schema.Table) float64 {
+	if est != nil && tbl != nil {
+		if rows, ok := est.TableRows(tbl); ok && rows >= 0 {
+			return rows
+		}
+	}
+	return DefaultTableRows
+}
+
+// EstimateSelectivity estimated fraction [0,1] of rows of table matching
+// predicate n.  Uses est for the predicate, or if it has no estimate
+// combines estimates of AND/OR/NOT arguments, falling back to heuristics
+// per operator.  A nil predicate matches all rows.
+func EstimateSelectivity(est CardinalityEstimator, tbl *schema.Table, n expr.Node) float64 {
+	if n == nil {
+		return 1
+	}
+	if est != nil {
+		if sel, ok := est.Selectivity(tbl, n); ok {
+			return clampSelectivity(sel)
+		}
+	}
+
+	sel := 0.0
+	switch n := n.(type) {
+	case *expr.BooleanNode:
+		sel = combineSelectivity(est, tbl, n.Operator.T, n.Args)
+		if n.Negated() {
+			sel = 1 - sel
+		}
+	case *expr.BinaryNode:
+		switch n.Operator.T {
+		case lex.TokenLogicAnd, lex.TokenAnd, lex.TokenLogicOr, lex.TokenOr:
+			sel = combineSelectivity(est, tbl, n.Operator.T, n.Args)
+		default:
+			sel = binarySelectivity(n)
+		}
+	case *expr.UnaryNode:
+		switch n.Operator.T {
+		case lex.TokenNegate:
+			sel = 1 - EstimateSelectivity(est, tbl, n.Arg)
+		case lex.TokenExists:
+			sel = 1 - selNull
+		default:
+			sel = selDefault
+		}
+	case *expr.TriNode:
+		sel = selBetween
+		if n.Negated() {
+			sel = 1 - sel
+		}
+	case *expr.IdentityNode:
+		switch {
+		case !n.IsBooleanIdentity():
+			sel = selDefault
+		case n.Bool():
+			sel = 1
+		}
+	default:
+		sel = selDefault
+	}
+	return clampSelectivity(sel)
+}
+
+func combineSelectivity(est CardinalityEstimator, tbl *schema.Table, op lex.TokenType, args []expr.Node) float64 {
+	switch op {
+	case lex.TokenLogicOr, lex.TokenOr:
+		// P(a or b) = P(a) + P(b) - P(a)P(b), assuming independence
+		sel := 0.0
+		for _, arg := range args {
+			s := EstimateSelectivity(est, tbl, arg)
+			sel = sel + s - sel*s
+		}
+		return sel
+	}
+	sel := 1.0
+	for _, arg := range args {
+		sel *= EstimateSelectivity(est, tbl, arg)
+	}
+	return sel
+}
+
+func binarySelectivity(n *expr.BinaryNode) float64 {
+	isNull := false
+	for _, arg := range n.Args {
+		if _, ok := arg.(*expr.NullNode); ok {
+			isNull = true
+		}
+	}
+	switch n.Operator.T {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		if isNull {
+			return selNull
+		}
+		return selEqual
+	case lex.TokenNE:
+		if isNull {
+			return 1 - selNull
+		}
+		return 1 - selEqual
+	case lex.TokenLT, lex.TokenLE, lex.TokenGT, lex.TokenGE:
+		return selRange
+	case lex.TokenLike:
+		return selLike
+	case lex.TokenIN:
+		if len(n.Args) == 2 {
+			if arr, ok := n.Args[1].(*expr.ArrayNode); ok {
+				return math.Min(selEqual*float64(len(arr.Args)), selInMax)
+			}
+		}
+		return selInMax
+	}
+	return selDefault
+}
+
+func clampSelectivity(sel float64) float64 {
+	switch {
+	case math.IsNaN(sel), sel < 0:
+		return 0
+	case sel > 1:
+		return 1
+	}
+	return sel
+}
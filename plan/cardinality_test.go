@@ -0,0 +1,97 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// statsEstimator knows the users row count, and user_id selectivity
+type statsEstimator struct{}
+
+func (statsEstimator) TableRows(tbl *schema.Table) (float64, bool) {
+	if tbl.Name == "users" {
+		return 1e6, true
+	}
+	return 0, false
+}
+func (statsEstimator) Selectivity(tbl *schema.Table, n expr.Node) (float64, bool) {
+	if bn, ok := n.(*expr.BinaryNode); ok && bn.Args[0].String() == "user_id" {
+		return 1e-6, true
+	}
+	return 0, false
+}
+
+func TestEstimateSelectivity(t *testing.T) {
+	for _, tc := range []struct {
+		exp string
+		sel float64
+	}{
+		{`email = "bob@email.com"`, 0.1},
+		{`referral_count > 5`, 1.0 / 3},
+		{`email = "a" AND referral_count > 5`, 0.1 / 3},
+		{`email = "a" OR email = "b"`, 0.19},
+		{`NOT (email = "a")`, 0.9},
+		{`email IN ("a","b","c")`, 0.3},
+		{`email NOT IN ("a","b","c")`, 0.7},
+		{`referral_count BETWEEN 1 AND 5`, 0.25},
+		{`user_id = "abc" AND email = "a"`, 1e-7},
+	} {
+		sel := plan.EstimateSelectivity(statsEstimator{}, nil, expr.MustParse(tc.exp))
+		assert.InDelta(t, tc.sel, sel, 1e-9, tc.exp)
+	}
+	assert.Equal(t, 1.0, plan.EstimateSelectivity(nil, nil, nil))
+}
+
+func TestCardinalityEstimator(t *testing.T) {
+	ctx := td.TestContext(`SELECT user_id FROM users WHERE user_id = "abc" AND referral_count > 5`)
+	p := selectPlan(t, ctx)
+	assert.Equal(t, 1, len(p.From))
+	assert.InDelta(t, plan.DefaultTableRows*0.1/3, p.From[0].EstimatedRows, 1e-9)
+
+	ctx = td.TestContext(`SELECT user_id FROM users WHERE user_id = "abc" AND referral_count > 5`)
+	ctx.CardinalityEstimator = plan.Estimators{statsEstimator{}}
+	p = selectPlan(t, ctx)
+	assert.InDelta(t, 1e6*1e-6/3, p.From[0].EstimatedRows, 1e-9)
+}
+
+func TestStatsHintEstimator(t *testing.T) {
+	ctx := td.TestContext(`SELECT user_id FROM users /*+ ROWS(2e9) NDV(user_id, 5e7) */ WHERE user_id = "abc"`)
+	p := selectPlan(t, ctx)
+	assert.InDelta(t, 2e9/5e7, p.From[0].EstimatedRows, 1e-6)
+
+	// columns without an ndv hint use the heuristics, or estimator
+	ctx = td.TestContext(`SELECT user_id FROM users /*+ ROWS(2e9) */ WHERE email IN ("a","b")`)
+	p = selectPlan(t, ctx)
+	assert.InDelta(t, 2e9*0.2, p.From[0].EstimatedRows, 1e-6)
+
+	ctx = td.TestContext(`SELECT user_id FROM users /*+ NDV(email, 100) */ WHERE email IN ("a","b") AND user_id = "abc"`)
+	ctx.CardinalityEstimator = plan.Estimators{statsEstimator{}}
+	p = selectPlan(t, ctx)
+	assert.InDelta(t, 1e6*0.02*1e-6, p.From[0].EstimatedRows, 1e-9)
+}
+
+func TestApproxCount(t *testing.T) {
+	ctx := td.TestContext(`SELECT COUNT(*) FROM users WITH approx=true`)
+	p := selectPlan(t, ctx)
+	assert.Equal(t, 0, len(p.From))
+	assert.Equal(t, 1, len(p.Children()))
+	src, ok := p.Children()[0].(*plan.Source)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(src.Static))
+
+	// without approx, or with a filter, the table is scanned
+	for _, sql := range []string{
+		`SELECT COUNT(*) FROM users`,
+		`SELECT COUNT(*) FROM users WHERE referral_count > 5 WITH approx=true`,
+		`SELECT email, COUNT(*) FROM users GROUP BY email WITH approx=true`,
+	} {
+		p = selectPlan(t, td.TestContext(sql))
+		assert.Equal(t, 1, len(p.From), sql)
+	}
+}
@@ -0,0 +1,250 @@
+package plan
+
+import (
+	"database/sql/driver"
+
+	u "github.com/araddon/gou"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+// Clone deep copy of this select plan, its child tasks, sources and
+// statement, so the copy can be modified (predicate injection, aliasing)
+// without changing the shared plan.  The Context, Schema, Table and
+// DataSource references are shared, source connections are not copied.
+func (m *Select) Clone() *Select {
+	return newCloner().task(m).(*Select)
+}
+
+// CloneTask deep copy of a plan task and its children, see Select.Clone.
+// Non-select statements (insert, update, ddl) are shared not copied as
+// they are not rewritten during planning.
+func CloneTask(t Task) Task {
+	return newCloner().task(t)
+}
+
+// cloner keeps track of already cloned tasks, statements so that
+// references between tasks in the dag (projection to its select,
+// join-merge to its children) point into the cloned dag.
+type cloner struct {
+	tasks   map[Task]Task
+	sels    map[*rel.SqlSelect]*rel.SqlSelect
+	sources map[*rel.SqlSource]*rel.SqlSource
+}
+
+func newCloner() *cloner {
+	return &cloner{
+		tasks:   make(map[Task]Task),
+		sels:    make(map[*rel.SqlSelect]*rel.SqlSelect),
+		sources: make(map[*rel.SqlSource]*rel.SqlSource),
+	}
+}
+
+func (c *cloner) sel(m *rel.SqlSelect) *rel.SqlSelect {
+	if m == nil {
+		return nil
+	}
+	if s, ok := c.sels[m]; ok {
+		return s
+	}
+	s := m.Clone()
+	c.sels[m] = s
+	for i, from := range m.From {
+		if i < len(s.From) {
+			c.sources[from] = s.From[i]
+			if from.Source != nil {
+				c.sels[from.Source] = s.From[i].Source
+			}
+		}
+	}
+	return s
+}
+
+func (c *cloner) source(m *rel.SqlSource) *rel.SqlSource {
+	if m == nil {
+		return nil
+	}
+	if s, ok := c.sources[m]; ok {
+		return s
+	}
+	s := m.Clone()
+	c.sources[m] = s
+	if _, ok := c.sels[m.Source]; m.Source != nil && !ok {
+		c.sels[m.Source] = s.Source
+	}
+	return s
+}
+
+// base copy of PlanBase, children are added by task() once
+// the parent has been registered.
+func (c *cloner) base(m *PlanBase) *PlanBase {
+	if m == nil {
+		return nil
+	}
+	return &PlanBase{parallel: m.parallel, tasks: make([]Task, 0, len(m.tasks))}
+}
+
+func (c *cloner) children(from, to *PlanBase) {
+	if from == nil || to == nil {
+		return
+	}
+	for _, t := range from.tasks {
+		to.tasks = append(to.tasks, c.task(t))
+	}
+	if from.RootTask != nil {
+		to.RootTask = c.task(from.RootTask)
+	}
+}
+
+func (c *cloner) task(t Task) Task {
+	if t == nil {
+		return nil
+	}
+	if ct, ok := c.tasks[t]; ok {
+		return ct
+	}
+	var ct Task
+	var from, to *PlanBase
+	switch m := t.(type) {
+	case *Select:
+		n := &Select{PlanBase: c.base(m.PlanBase), Ctx: m.Ctx, ChildDag: m.ChildDag}
+		c.tasks[t] = n
+		n.Stmt = c.sel(m.Stmt)
+		if len(m.From) > 0 {
+			n.From = make([]*Source, len(m.From))
+			for i, src := range m.From {
+				n.From[i] = c.task(src).(*Source)
+			}
+		}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Source:
+		n := &Source{
+			PlanBase:   c.base(m.PlanBase),
+			ExecPlan:   m.ExecPlan,
+			ctx:        m.ctx,
+			DataSource: m.DataSource,
+			Schema:     m.Schema,
+			Tbl:        m.Tbl,
+			Shard:      m.Shard,
+			PartialAgg: m.PartialAgg,
+
+			EstimatedRows: m.EstimatedRows,
+			Costs:         m.Costs,
+			Cost:          m.Cost,
+		}
+		c.tasks[t] = n
+		if m.SourcePb != nil {
+			n.SourcePb = proto.Clone(m.SourcePb).(*SourcePb)
+		}
+		n.Stmt = c.source(m.Stmt)
+		if m.Proj != nil {
+			n.Proj = m.Proj.Clone()
+		}
+		if m.Custom != nil {
+			n.Custom = make(u.JsonHelper, len(m.Custom))
+			for k, v := range m.Custom {
+				n.Custom[k] = v
+			}
+		}
+		if m.Static != nil {
+			n.Static = append(make([]driver.Value, 0, len(m.Static)), m.Static...)
+		}
+		if m.Cols != nil {
+			n.Cols = append(make([]string, 0, len(m.Cols)), m.Cols...)
+		}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Projection:
+		n := &Projection{PlanBase: c.base(m.PlanBase), Final: m.Final}
+		c.tasks[t] = n
+		n.Stmt = c.sel(m.Stmt)
+		if m.P != nil {
+			n.P = c.task(m.P).(*Select)
+		}
+		if m.Proj != nil {
+			n.Proj = m.Proj.Clone()
+		}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Where:
+		n := &Where{PlanBase: c.base(m.PlanBase), Final: m.Final, Stmt: c.sel(m.Stmt)}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Having:
+		n := &Having{PlanBase: c.base(m.PlanBase), Stmt: c.sel(m.Stmt)}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *GroupBy:
+		n := &GroupBy{PlanBase: c.base(m.PlanBase), Partial: m.Partial, Merge: m.Merge, Stmt: c.sel(m.Stmt)}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Order:
+		n := &Order{PlanBase: c.base(m.PlanBase), Stmt: c.sel(m.Stmt), Limit: m.Limit}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *JoinMerge:
+		n := &JoinMerge{PlanBase: c.base(m.PlanBase)}
+		c.tasks[t] = n
+		n.Left = c.task(m.Left)
+		n.Right = c.task(m.Right)
+		n.LeftFrom = c.source(m.LeftFrom)
+		n.RightFrom = c.source(m.RightFrom)
+		n.Similarity = m.Similarity
+		n.Lookup = m.Lookup
+		if m.ColIndex != nil {
+			n.ColIndex = make(map[string]int, len(m.ColIndex))
+			for k, v := range m.ColIndex {
+				n.ColIndex[k] = v
+			}
+		}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Scatter:
+		n := &Scatter{PlanBase: c.base(m.PlanBase), Stmt: c.sel(m.Stmt)}
+		c.tasks[t] = n
+		n.Shards = make([]*Source, len(m.Shards))
+		for i, shard := range m.Shards {
+			n.Shards[i] = c.task(shard).(*Source)
+		}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *JoinKey:
+		n := &JoinKey{PlanBase: c.base(m.PlanBase)}
+		c.tasks[t] = n
+		if m.Source != nil {
+			n.Source = c.task(m.Source).(*Source)
+		}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Into:
+		n := &Into{PlanBase: c.base(m.PlanBase), Stmt: m.Stmt}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *PreparedStatement:
+		n := &PreparedStatement{PlanBase: c.base(m.PlanBase), Stmt: m.Stmt}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Insert:
+		n := &Insert{PlanBase: c.base(m.PlanBase), Stmt: m.Stmt, Source: m.Source}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Upsert:
+		n := &Upsert{PlanBase: c.base(m.PlanBase), Stmt: m.Stmt, Source: m.Source}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Update:
+		n := &Update{PlanBase: c.base(m.PlanBase), Stmt: m.Stmt, Source: m.Source}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Delete:
+		n := &Delete{PlanBase: c.base(m.PlanBase), Stmt: m.Stmt, Source: m.Source}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Command:
+		n := &Command{PlanBase: c.base(m.PlanBase), Ctx: m.Ctx, Stmt: m.Stmt}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Create:
+		n := &Create{PlanBase: c.base(m.PlanBase), Ctx: m.Ctx, Stmt: m.Stmt}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Drop:
+		n := &Drop{PlanBase: c.base(m.PlanBase), Ctx: m.Ctx, Stmt: m.Stmt}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	case *Alter:
+		n := &Alter{PlanBase: c.base(m.PlanBase), Ctx: m.Ctx, Stmt: m.Stmt}
+		ct, from, to = n, m.PlanBase, n.PlanBase
+	default:
+		// unknown (ie source specific) task, share it
+		u.Warnf("plan.Clone not implemented for %T, sharing", t)
+		c.tasks[t] = t
+		return t
+	}
+	c.tasks[t] = ct
+	c.children(from, to)
+	return ct
+}
@@ -2,11 +2,13 @@ package plan
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
 
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -47,16 +49,40 @@ type Context struct {
 	Projection      *Projection      // Projection for this context optional
 
 	// Local in-memory helpers not transported across network
+	Conn    *Session               // Session of the connection, optional
 	Session expr.ContextReadWriter // Session for this connection
+	User    string                 // User running this statement
 	Schema  *schema.Schema         // this schema for this connection
 	Funcs   expr.FuncResolver      // Local/Dialect specific functions
+	Dialect *lex.DialectMode       // sql dialect quirks Raw is parsed with, nil mysql
+	Temp    *TempTables            // temporary tables of the session, shared by its statements
+
+	// CardinalityEstimator row count, selectivity estimates for this plan
+	// if nil uses DefaultEstimator, then analyzed table statistics.
+	CardinalityEstimator CardinalityEstimator
+
+	// Budget row, byte, time limits the client asked for, optional.
+	Budget *Budget
+
+	// Memory held by the sort, aggregate and join operators of this
+	// statement, the executor creates it when run.
+	Memory *MemoryAccount
+
+	// RowErrors how a streaming query handles rows failing expression
+	// evaluation or coercion, and DeadLetters where RowErrorDeadLetter
+	// sends them.  Unset RunStream reads the session stream_row_errors.
+	RowErrors   RowErrorPolicy
+	DeadLetters DeadLetterSink
 
 	// From configuration
 	DisableRecover bool
 
 	// Local State
 	Errors     []error
+	Warnings   []string // non-fatal notes about this statement, ie answered from a sample
 	errRecover interface{}
+	snapMu     sync.Mutex
+	snapshots  map[interface{}]interface{} // read snapshots of sources, see Snapshot
 }
 
 // NewContext plan context
@@ -67,6 +93,17 @@ func NewContextFromPb(pb *ContextPb) *Context {
 	return &Context{id: pb.Id, fingerprint: pb.Fingerprint, SchemaName: pb.Schema}
 }
 
+// Close the session of this context, dropping its temporary tables.
+func (m *Context) Close() error {
+	if m == nil {
+		return nil
+	}
+	if m.Conn != nil {
+		return m.Conn.Close()
+	}
+	return m.Temp.Close()
+}
+
 // called by go routines/tasks to ensure any recovery panics are captured
 func (m *Context) Recover() {
 	if m == nil {
@@ -85,6 +122,12 @@ func (m *Context) init() {
 	}
 }
 
+// Id the unique id of this statement.
+func (m *Context) Id() uint64 {
+	m.init()
+	return m.id
+}
+
 // called by go routines/tasks to ensure any recovery panics are captured
 func (m *Context) ToPB() *ContextPb {
 	m.init()
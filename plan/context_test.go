@@ -2,8 +2,13 @@ package plan
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
 	//"github.com/araddon/qlbridge/plan"
 )
 
@@ -30,3 +35,63 @@ func TestContext(t *testing.T) {
 	c1FromPb.fingerprint = 88 //
 	assert.Equal(t, false, c1.Equal(c1FromPb))
 }
+
+func TestParseBudget(t *testing.T) {
+	b, err := ParseBudget(map[string]string{"_client_name": "libmysql"})
+	assert.Equal(t, nil, err)
+	assert.True(t, b == nil)
+
+	b, err = ParseBudget(map[string]string{
+		"_client_name":     "libmysql",
+		BudgetMaxRowsAttr:  "100",
+		BudgetMaxBytesAttr: "4096",
+		BudgetTimeoutAttr:  "250",
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Budget{MaxRows: 100, MaxBytes: 4096, Timeout: 250 * time.Millisecond}, b)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	deadline, ok := b.QueryDeadline(start)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(250*time.Millisecond), deadline)
+
+	// the earliest of deadline, timeout
+	b, err = ParseBudget(map[string]string{BudgetDeadlineAttr: "2020-01-01T00:00:00.1Z", BudgetTimeoutAttr: "250"})
+	assert.Equal(t, nil, err)
+	deadline, _ = b.QueryDeadline(start)
+	assert.Equal(t, start.Add(100*time.Millisecond), deadline)
+
+	for _, v := range []string{"-1", "lots", ""} {
+		_, err = ParseBudget(map[string]string{BudgetMaxRowsAttr: v})
+		assert.NotEqual(t, nil, err, v)
+	}
+	_, err = ParseBudget(map[string]string{BudgetDeadlineAttr: "tomorrow"})
+	assert.NotEqual(t, nil, err)
+
+	ctx := WithBudget(context.Background(), &Budget{MaxRows: 5})
+	assert.Equal(t, int64(5), BudgetFromContext(ctx).MaxRows)
+	assert.True(t, BudgetFromContext(context.Background()) == nil)
+}
+
+func TestSession(t *testing.T) {
+	ses := NewSession(nil)
+	ses.User = "bob"
+	ses.SetSchema(schema.NewSchema("mysess"))
+	ctx := ses.NewContext("SELECT 1")
+	assert.True(t, ctx.Conn == ses)
+	assert.Equal(t, "bob", ctx.User)
+	assert.Equal(t, "mysess", ctx.Schema.Name)
+	assert.True(t, ctx.Temp == ses.Temp)
+
+	ses.Prepare(&rel.PreparedStatement{Alias: "Stmt1"})
+	_, ok := ses.Prepared("stmt1")
+	assert.True(t, ok)
+	assert.True(t, ses.Deallocate("STMT1"))
+	assert.Equal(t, false, ses.Deallocate("stmt1"))
+
+	assert.NotEqual(t, nil, ses.Use("not_a_schema"))
+	ses.Prepare(&rel.PreparedStatement{Alias: "stmt2"})
+	assert.Equal(t, nil, ctx.Close())
+	_, ok = ses.Prepared("stmt2")
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,86 @@
+package plan
+
+import (
+	"math"
+	"sort"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+// Cost factor keys in a sources schema.ConfigSource Settings, ie an
+// elasticsearch source whose scans are 10x those of memdb:
+//
+//	{"name": "es", "type": "elasticsearch", "settings": {"scan_cost": 10, "network_cost": 2}}
+const (
+	SettingScanCost    = "scan_cost"
+	SettingLookupCost  = "lookup_cost"
+	SettingNetworkCost = "network_cost"
+)
+
+// DefaultCostFactors are the cost factors for sources that don't declare
+// them, an in-process source.
+var DefaultCostFactors = CostFactors{Scan: 1, Lookup: 1, Network: 1}
+
+// CostFactors relative costs of operations against a source, used by the
+// planner to compare plans across heterogeneous backends.  They are unit
+// less multipliers relative to DefaultCostFactors.
+type CostFactors struct {
+	Scan    float64 // per row read by a scan
+	Lookup  float64 // per keyed lookup (seek)
+	Network float64 // per row returned from source to qlbridge
+}
+
+// SourceCostFactors the cost factors declared in schema config Settings,
+// DefaultCostFactors for any not declared.
+func SourceCostFactors(s *schema.Schema) CostFactors {
+	cf := DefaultCostFactors
+	if s == nil || s.Conf == nil || s.Conf.Settings == nil {
+		return cf
+	}
+	set := func(key string, f *float64) {
+		if v, ok := s.Conf.Settings.Float64Safe(key); ok && v >= 0 && !math.IsNaN(v) {
+			*f = v
+		}
+	}
+	set(SettingScanCost, &cf.Scan)
+	set(SettingLookupCost, &cf.Lookup)
+	set(SettingNetworkCost, &cf.Network)
+	return cf
+}
+
+// ScanCost cost of scanning tableRows to return rows.  If the source
+// evaluates the filter (pushdown) only matching rows cross the network,
+// otherwise every row is returned to be filtered in qlbridge.
+func (m CostFactors) ScanCost(tableRows, rows float64, pushdown bool) float64 {
+	if pushdown {
+		return tableRows*m.Scan + rows*m.Network
+	}
+	return tableRows * (m.Scan + m.Network)
+}
+
+// LookupCost cost of seeking keys, returning a row for each.
+func (m CostFactors) LookupCost(keys float64) float64 {
+	return keys * (m.Lookup + m.Network)
+}
+
+// joinOrder order the sources of a join by estimated cost, cheapest first,
+// so intermediate results are smallest.  Sources are only re-ordered for
+// inner joins, outer (left/right) joins keep their statement order.
+func joinOrder(sources []*Source) []*Source {
+	for _, src := range sources {
+		if src.Stmt != nil && src.Stmt.LeftOrRight != 0 {
+			return sources
+		}
+	}
+	ordered := append(make([]*Source, 0, len(sources)), sources...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Cost < ordered[j].Cost
+	})
+	return ordered
+}
+
+// joinSeekable should the joined source be read by keyed lookups from
+// the rows already joined, rather than a full scan.
+func joinSeekable(joinedRows float64, src *Source) bool {
+	return src.Costs.LookupCost(joinedRows) < src.Cost
+}
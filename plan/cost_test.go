@@ -0,0 +1,53 @@
+package plan_test
+
+import (
+	"testing"
+
+	u "github.com/araddon/gou"
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestSourceCostFactors(t *testing.T) {
+	assert.Equal(t, plan.DefaultCostFactors, plan.SourceCostFactors(nil))
+
+	s := schema.NewSchema("es")
+	s.Conf = &schema.ConfigSource{Settings: u.JsonHelper{"scan_cost": 10.0, "network_cost": 2, "lookup_cost": -1}}
+	cf := plan.SourceCostFactors(s)
+	assert.Equal(t, plan.CostFactors{Scan: 10, Lookup: 1, Network: 2}, cf)
+
+	// pushdown only pays network for matching rows
+	assert.Equal(t, 1000*10+10*2.0, cf.ScanCost(1000, 10, true))
+	assert.Equal(t, 1000*12.0, cf.ScanCost(1000, 10, false))
+	assert.Equal(t, 30.0, cf.LookupCost(10))
+}
+
+func TestJoinOrder(t *testing.T) {
+	q := `SELECT u.user_id, o.item_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id`
+
+	// users is much larger than orders, so orders is read first
+	ctx := td.TestContext(q)
+	ctx.CardinalityEstimator = statsEstimator{}
+	p := selectPlan(t, ctx)
+	jm, ok := p.Children()[0].(*plan.JoinMerge)
+	assert.True(t, ok, "expected join merge got %T", p.Children()[0])
+	if !ok {
+		return
+	}
+	assert.Equal(t, "orders", jm.Left.(*plan.Source).Stmt.Name)
+	users := jm.Right.(*plan.Source)
+	assert.Equal(t, "users", users.Stmt.Name)
+	assert.Equal(t, 1e6, users.EstimatedRows)
+	// 1000 order lookups are cheaper than scanning 1e6 users
+	assert.True(t, users.Stmt.Seekable)
+
+	// statement order for equal costs
+	ctx = td.TestContext(q)
+	p = selectPlan(t, ctx)
+	jm = p.Children()[0].(*plan.JoinMerge)
+	assert.Equal(t, "users", jm.Left.(*plan.Source).Stmt.Name)
+	assert.False(t, jm.Right.(*plan.Source).Stmt.Seekable)
+}
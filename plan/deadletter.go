@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// RowErrorPolicy how a streaming query (exec.RunStream) handles a row whose
+// expressions fail to evaluate, or whose values can't be coerced, ie a
+// continuous query over events that shouldn't stop on one malformed event.
+type RowErrorPolicy uint8
+
+const (
+	// RowErrorDefault not set, rows failing a filter are skipped and
+	// columns failing to evaluate are null, as for non-streaming
+	// statements, uncounted.
+	RowErrorDefault RowErrorPolicy = iota
+	// RowErrorDrop drop the row, counted, and carry on.
+	RowErrorDrop
+	// RowErrorHalt stop the query, returning the error.
+	RowErrorHalt
+	// RowErrorDeadLetter send the row and its error to the DeadLetterSink
+	// and carry on.
+	RowErrorDeadLetter
+)
+
+// ParseRowErrorPolicy drop, halt or dead_letter.
+func ParseRowErrorPolicy(s string) (RowErrorPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "drop":
+		return RowErrorDrop, nil
+	case "halt":
+		return RowErrorHalt, nil
+	case "dead_letter", "deadletter":
+		return RowErrorDeadLetter, nil
+	}
+	return RowErrorDefault, fmt.Errorf("invalid %s %q, expected drop, halt or dead_letter", RowErrorsVar, s)
+}
+
+func (m RowErrorPolicy) String() string {
+	switch m {
+	case RowErrorDrop:
+		return "drop"
+	case RowErrorHalt:
+		return "halt"
+	case RowErrorDeadLetter:
+		return "dead_letter"
+	}
+	return "default"
+}
+
+// DeadLetter a row a streaming query could not process.
+type DeadLetter struct {
+	Op  string                  // operator the row failed in: where, projection, result
+	Row map[string]driver.Value // the row as it arrived at the operator
+	Err error
+}
+
+// DeadLetterSink receives the rows of a streaming query under the
+// RowErrorDeadLetter policy, ie to write them to a dead-letter topic or
+// table for inspection and replay.  An error halts the query.
+type DeadLetterSink interface {
+	OnDeadLetter(dl *DeadLetter) error
+}
+
+// StreamRowErrors the row error policy of a streaming query, RowErrors if
+// set, else the session stream_row_errors, else RowErrorDefault.
+func (m *Context) StreamRowErrors() (RowErrorPolicy, error) {
+	if m.RowErrors != RowErrorDefault {
+		return m.RowErrors, nil
+	}
+	if s := m.sessionString(RowErrorsVar); s != "" {
+		return ParseRowErrorPolicy(s)
+	}
+	return RowErrorDefault, nil
+}
@@ -0,0 +1,135 @@
+package plan
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// Estimate the estimated output cardinality and cost of a statement.
+type Estimate struct {
+	// Rows estimated number of rows the statement returns.
+	Rows float64
+	// Cost estimated cost of reading its sources, see CostFactors.
+	Cost float64
+}
+
+// EstimateRows plan the select stmt against schema s, without running it,
+// and estimate the rows it returns and its cost from the planner's
+// cardinality estimates (DefaultEstimator, statistics hints), so callers
+// can refuse or queue expensive queries before executing them.
+//
+//    est, err := plan.EstimateRows(stmt, schema)
+//    if est.Cost > maxCost { ... }
+//
+// stmt is not modified, a clone of it is planned.
+func EstimateRows(stmt rel.SqlStatement, s *schema.Schema) (*Estimate, error) {
+	sel, ok := stmt.(*rel.SqlSelect)
+	if !ok {
+		return nil, fmt.Errorf("EstimateRows requires a select got %T", stmt)
+	}
+	sel = sel.Clone()
+	ctx := NewContext(sel.String())
+	ctx.Schema = s
+	ctx.Stmt = sel
+	p, err := WalkStmt(ctx, sel, NewPlanner(ctx))
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := p.(*Select)
+	if !ok {
+		return nil, fmt.Errorf("EstimateRows expected select plan got %T", p)
+	}
+	est := &Estimate{}
+	for _, t := range sp.Children() {
+		if rows, ok := est.task(t); ok {
+			est.Rows = rows
+		}
+	}
+	est.statement(ctx, sp)
+	return est, nil
+}
+
+// task the rows output by plan task t, adding the cost of its sources,
+// false if t is not a source of rows.  The connections the planner opened
+// to estimate are released.
+func (m *Estimate) task(t Task) (float64, bool) {
+	switch p := t.(type) {
+	case *Source:
+		if p.Conn != nil {
+			p.ReleaseConn(p.Conn, nil)
+			p.Conn = nil
+		}
+		if len(p.Static) > 0 {
+			return 1, true
+		}
+		m.Cost += p.Cost
+		return p.EstimatedRows, true
+	case *JoinMerge:
+		// as the planner, a join is assumed to return the rows of its
+		// largest side
+		l, _ := m.task(p.Left)
+		r, _ := m.task(p.Right)
+		return math.Max(l, r), true
+	case *Scatter:
+		rows := 0.0
+		for _, shard := range p.Shards {
+			sr, _ := m.task(shard)
+			rows += sr
+		}
+		return rows, true
+	}
+	return 0, false
+}
+
+// statement the rows of the select, from the rows of its sources, after
+// grouping, having and limit.
+func (m *Estimate) statement(ctx *Context, p *Select) {
+	stmt := p.Stmt
+	if len(stmt.From) == 0 {
+		m.Rows = 1
+	}
+	var (
+		est CardinalityEstimator = ctx.Estimator()
+		tbl *schema.Table
+	)
+	if len(p.From) == 1 {
+		est = StatsHintEstimator(p.From[0].Stmt.Stats, est)
+		tbl = p.From[0].Tbl
+	}
+	switch {
+	case stmt.IsAggQuery() && len(stmt.GroupBy) == 0:
+		m.Rows = math.Min(m.Rows, 1)
+	case len(stmt.GroupBy) > 0:
+		m.Rows = groupRows(est, tbl, m.Rows, stmt.GroupBy)
+	case stmt.Distinct:
+		m.Rows = groupRows(est, tbl, m.Rows, stmt.Columns)
+	}
+	if stmt.Having != nil {
+		m.Rows *= EstimateSelectivity(est, tbl, stmt.Having)
+	}
+	if stmt.Limit > 0 {
+		m.Rows = math.Min(m.Rows, float64(stmt.Limit))
+	}
+}
+
+// groupRows estimated distinct groups of rows, the product of the number
+// of distinct values of each column (the inverse of the selectivity of
+// col = value), at most rows.
+func groupRows(est CardinalityEstimator, tbl *schema.Table, rows float64, cols rel.Columns) float64 {
+	groups := 1.0
+	for _, col := range cols {
+		if col.Expr == nil {
+			continue
+		}
+		eq := expr.NewBinaryNode(lex.Token{T: lex.TokenEqual, V: "="}, col.Expr, expr.NewStringNode("?"))
+		if sel := EstimateSelectivity(est, tbl, eq); sel > 0 {
+			groups *= 1 / sel
+		}
+	}
+	return math.Min(rows, groups)
+}
@@ -0,0 +1,51 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+)
+
+func TestEstimateRows(t *testing.T) {
+	estimate := func(sql string) *plan.Estimate {
+		stmt, err := rel.ParseSql(sql)
+		assert.Equal(t, nil, err)
+		est, err := plan.EstimateRows(stmt, td.MockSchema)
+		assert.Equal(t, nil, err, sql)
+		if est == nil {
+			return &plan.Estimate{}
+		}
+		return est
+	}
+
+	est := estimate(`SELECT user_id FROM users /*+ ROWS(1e4) NDV(user_id, 100) */ WHERE user_id = "abc"`)
+	assert.InDelta(t, 100, est.Rows, 1e-9)
+	// mockcsv doesn't push down the where, every row is scanned and returned
+	assert.InDelta(t, 2e4, est.Cost, 1e-9)
+
+	est = estimate(`SELECT user_id FROM users /*+ ROWS(1e4) */ LIMIT 10`)
+	assert.Equal(t, 10.0, est.Rows)
+
+	est = estimate(`SELECT count(*) AS ct FROM users /*+ ROWS(1e4) */`)
+	assert.Equal(t, 1.0, est.Rows)
+
+	// groups from the number of distinct values
+	est = estimate(`SELECT user_id, count(*) AS ct FROM users /*+ ROWS(1e4) NDV(user_id, 50) */ GROUP BY user_id`)
+	assert.InDelta(t, 50, est.Rows, 1e-9)
+
+	// a join is estimated at its largest side, its cost the sum of its sources
+	est = estimate(`SELECT u.user_id, o.item_id FROM users AS u /*+ ROWS(1e4) */
+		INNER JOIN orders AS o /*+ ROWS(500) */ ON u.user_id = o.user_id`)
+	assert.InDelta(t, 1e4, est.Rows, 1e-9)
+	assert.True(t, est.Cost > 1e4, "cost %v", est.Cost)
+
+	est = estimate(`SELECT 1 AS one`)
+	assert.Equal(t, 1.0, est.Rows)
+
+	_, err := plan.EstimateRows(&rel.SqlDelete{Table: "users"}, td.MockSchema)
+	assert.NotEqual(t, nil, err)
+}
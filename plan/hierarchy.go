@@ -0,0 +1,61 @@
+package plan
+
+import (
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// parentChildJoins add the join condition to JOINs without an ON between
+// a child table of a hierarchical source and its parent, from the child
+// tables ParentKeys
+//
+//     SELECT u.name, p.city FROM users AS u INNER JOIN users_profile AS p
+//     -- joins ON u.id = p.user_id
+//
+func parentChildJoins(ctx *Context, stmt *rel.SqlSelect) {
+	if ctx.Schema == nil {
+		return
+	}
+	tables := make([]*schema.Table, len(stmt.From))
+	for i, from := range stmt.From {
+		if from.SubQuery != nil {
+			continue
+		}
+		tables[i], _ = ctx.Schema.Table(from.SourceName())
+	}
+	for i, from := range stmt.From {
+		if i == 0 || from.JoinExpr != nil || tables[i] == nil {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if tables[j] == nil {
+				continue
+			}
+			parent, child := j, i
+			if tables[j].Parent == tables[i].Name {
+				parent, child = i, j
+			} else if tables[i].Parent != tables[j].Name {
+				continue
+			}
+			parentKey, childKey, ok := tables[child].ParentKeys()
+			if !ok {
+				continue
+			}
+			from.JoinExpr = expr.NewBinaryNode(lex.Token{T: lex.TokenEqual, V: "="},
+				expr.NewIdentityNodeVal(sourceAlias(stmt.From[parent])+"."+parentKey),
+				expr.NewIdentityNodeVal(sourceAlias(stmt.From[child])+"."+childKey))
+			from.Op = lex.TokenOn
+			break
+		}
+	}
+}
+
+// sourceAlias the name columns of this source are qualified by.
+func sourceAlias(from *rel.SqlSource) string {
+	if from.Alias != "" {
+		return from.Alias
+	}
+	return from.SourceName()
+}
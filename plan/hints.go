@@ -0,0 +1,50 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+// checkHints warn of the optimizer hints of the select the planner does
+// not know, or whose tables are not in the select, they are ignored.
+func checkHints(ctx *Context, sel *rel.SqlSelect) {
+	for _, h := range sel.Hints {
+		switch h.Name {
+		case rel.HintHashJoin, rel.HintLookupJoin, rel.HintNoPushdown:
+			for _, arg := range h.Args {
+				found := false
+				for _, from := range sel.From {
+					found = found || from.IsNamed(arg)
+				}
+				if !found {
+					ctx.Warnings = append(ctx.Warnings, fmt.Sprintf(
+						"%s hint table %q is not in the query, ignored", h.Name, arg))
+				}
+			}
+		case rel.HintParallel:
+		default:
+			ctx.Warnings = append(ctx.Warnings, fmt.Sprintf("unknown hint %s ignored", h.Name))
+		}
+	}
+}
+
+// hintSeekable should the joined source be read by keyed lookups, as
+// joinSeekable unless a HASH_JOIN or LOOKUP_JOIN hint names the source.
+func hintSeekable(hints rel.SqlHints, joinedRows float64, src *Source) bool {
+	switch {
+	case hints.HasTable(rel.HintHashJoin, src.Stmt):
+		return false
+	case hints.HasTable(rel.HintLookupJoin, src.Stmt):
+		return true
+	}
+	return joinSeekable(joinedRows, src)
+}
+
+// hintWorkers the number of where filter workers of a PARALLEL(n) hint.
+func hintWorkers(hints rel.SqlHints) int {
+	if h := hints.Hint(rel.HintParallel); h != nil {
+		return h.Int()
+	}
+	return 0
+}
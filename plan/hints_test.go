@@ -0,0 +1,54 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/plan"
+)
+
+func findWhere(t plan.Task) *plan.Where {
+	if w, ok := t.(*plan.Where); ok {
+		return w
+	}
+	for _, c := range t.Children() {
+		if w := findWhere(c); w != nil {
+			return w
+		}
+	}
+	return nil
+}
+
+func TestOptimizerHints(t *testing.T) {
+	// users would be read by lookups, the hint forces a scan
+	ctx := td.TestContext(`SELECT /*+ HASH_JOIN(u) */ u.user_id, o.item_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id`)
+	ctx.CardinalityEstimator = statsEstimator{}
+	p := selectPlan(t, ctx)
+	jm := p.Children()[0].(*plan.JoinMerge)
+	users := jm.Right.(*plan.Source)
+	assert.Equal(t, "users", users.Stmt.Name)
+	assert.False(t, users.Stmt.Seekable)
+	assert.Equal(t, 0, len(ctx.Warnings))
+
+	// orders would be scanned, the hint asks for lookups but it has no index
+	ctx = td.TestContext(`SELECT /*+ LOOKUP_JOIN(orders) NO_SUCH_HINT */ u.user_id, o.item_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id`)
+	p = selectPlan(t, ctx)
+	jm = p.Children()[0].(*plan.JoinMerge)
+	assert.True(t, jm.Right.(*plan.Source).Stmt.Seekable)
+	assert.Equal(t, (*plan.LookupJoin)(nil), jm.Lookup)
+	assert.Equal(t, []string{
+		"unknown hint NO_SUCH_HINT ignored",
+		"LOOKUP_JOIN(orders) ignored, no index of its join condition",
+	}, ctx.Warnings)
+
+	ctx = td.TestContext(`SELECT /*+ PARALLEL(4) NO_PUSHDOWN(nope) */ user_id FROM users WHERE user_id != "abc" ORDER BY user_id ASC`)
+	p = selectPlan(t, ctx)
+	where := findWhere(p)
+	assert.NotEqual(t, nil, where)
+	if where != nil {
+		assert.Equal(t, 4, where.Workers)
+	}
+	assert.Equal(t, []string{`NO_PUSHDOWN hint table "nope" is not in the query, ignored`}, ctx.Warnings)
+}
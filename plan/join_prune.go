@@ -0,0 +1,181 @@
+package plan
+
+import (
+	"strings"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// pruneJoins remove joined sources which provably do not change the
+// results of the select.  Generated BI sql often joins dimension tables
+// none of whose columns are used, ie dim_users here
+//
+//     SELECT o.price FROM orders AS o
+//       INNER JOIN dim_users AS u ON o.user_id = u.user_id
+//
+// A joined (not the first) source is pruned when
+//  - none of its columns are used outside of its own ON expression
+//  - its ON is equality of a unique key of its table to the columns of
+//    one other source, so each row joins at most one of its rows
+//  - it is a LEFT join, or the other sources columns are a NOT NULL
+//    foreign key referencing that unique key, so each row joins at
+//    least one of its rows.
+func pruneJoins(ctx *Context, stmt *rel.SqlSelect) {
+	if ctx.Schema == nil || len(stmt.From) < 2 || (stmt.Where != nil && stmt.Where.Source != nil) {
+		return
+	}
+	for _, col := range stmt.Columns {
+		if col.Star {
+			return
+		}
+	}
+	for pruned := true; pruned && len(stmt.From) > 1; {
+		pruned = false
+		for i := len(stmt.From) - 1; i > 0; i-- {
+			if canPruneJoin(ctx, stmt, i) {
+				u.Debugf("pruning join of unused %s", stmt.From[i].SourceName())
+				stmt.From = append(stmt.From[:i], stmt.From[i+1:]...)
+				pruned = true
+			}
+		}
+	}
+	if len(stmt.From) == 1 {
+		stmt.From[0].JoinExpr = nil
+		stmt.From[0].Op = 0
+		stmt.From[0].LeftOrRight = 0
+		stmt.From[0].JoinType = 0
+	}
+}
+
+// canPruneJoin can From[i] of stmt be removed, see pruneJoins.
+func canPruneJoin(ctx *Context, stmt *rel.SqlSelect, i int) bool {
+	from := stmt.From[i]
+	if from.SubQuery != nil || from.JoinExpr == nil {
+		return false
+	}
+	tbl, err := ctx.Schema.Table(from.SourceName())
+	if err != nil || tbl == nil {
+		return false
+	}
+	alias := sourceAlias(from)
+
+	// columns used anywhere but its own ON
+	nodes := make([]expr.Node, 0, len(stmt.Columns)+4)
+	for _, col := range stmt.Columns {
+		nodes = append(nodes, col.Expr, col.Guard, col.Filter)
+	}
+	if stmt.Where != nil {
+		nodes = append(nodes, stmt.Where.Expr)
+	}
+	for _, col := range stmt.GroupBy {
+		nodes = append(nodes, col.Expr)
+	}
+	nodes = append(nodes, stmt.Having)
+	for _, col := range stmt.OrderBy {
+		nodes = append(nodes, col.Expr)
+	}
+	for j, other := range stmt.From {
+		if j != i {
+			nodes = append(nodes, other.JoinExpr)
+		}
+	}
+	for _, n := range nodes {
+		if n != nil && usesSource(n, alias, tbl) {
+			return false
+		}
+	}
+
+	// ON must be equalities of its key to columns of one other source
+	keys, otherKeys, other := joinKeyColumns(from.JoinExpr, alias)
+	if len(keys) == 0 || other == "" || !tbl.IsUniqueKey(keys...) {
+		return false
+	}
+	if from.LeftOrRight == lex.TokenLeft {
+		return true
+	}
+	var otherTbl *schema.Table
+	for j, of := range stmt.From {
+		if j != i && of.SubQuery == nil && strings.EqualFold(sourceAlias(of), other) {
+			otherTbl, _ = ctx.Schema.Table(of.SourceName())
+		}
+	}
+	if otherTbl == nil || otherTbl.ForeignKey(otherKeys, tbl.Name, keys) == nil {
+		return false
+	}
+	for _, key := range otherKeys {
+		if fld, ok := otherTbl.Field(key); !ok || !fld.NoNulls {
+			return false
+		}
+	}
+	return true
+}
+
+// usesSource does node use a column of the source aliased alias, columns
+// not qualified by a source are used if tbl has a field of that name.
+func usesSource(n expr.Node, alias string, tbl *schema.Table) bool {
+	for _, in := range expr.FindAllIdentities(n) {
+		left, right, hasLeft := in.LeftRight()
+		if hasLeft {
+			if strings.EqualFold(left, alias) {
+				return true
+			}
+			continue
+		}
+		if tbl.HasField(right) || tbl.HasField(in.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinKeyColumns the columns of source alias, and columns of the other
+// source they equal, for ON expressions of (AND of) col = col equalities,
+// other is "" if the ON is anything else or uses more than one other source.
+func joinKeyColumns(n expr.Node, alias string) (keys, otherKeys []string, other string) {
+	var walk func(n expr.Node) bool
+	walk = func(n expr.Node) bool {
+		bn, ok := n.(*expr.BinaryNode)
+		if !ok || len(bn.Args) != 2 {
+			return false
+		}
+		switch bn.Operator.T {
+		case lex.TokenLogicAnd:
+			return walk(bn.Args[0]) && walk(bn.Args[1])
+		case lex.TokenEqual, lex.TokenEqualEqual:
+		default:
+			return false
+		}
+		l, lok := bn.Args[0].(*expr.IdentityNode)
+		r, rok := bn.Args[1].(*expr.IdentityNode)
+		if !lok || !rok {
+			return false
+		}
+		ll, lr, lhas := l.LeftRight()
+		rl, rr, rhas := r.LeftRight()
+		if !lhas || !rhas {
+			return false
+		}
+		if strings.EqualFold(rl, alias) {
+			ll, lr, rl, rr = rl, rr, ll, lr
+		}
+		if !strings.EqualFold(ll, alias) || strings.EqualFold(rl, alias) {
+			return false
+		}
+		if other != "" && !strings.EqualFold(other, rl) {
+			return false
+		}
+		other = rl
+		keys = append(keys, lr)
+		otherKeys = append(otherKeys, rr)
+		return true
+	}
+	if !walk(n) {
+		return nil, nil, ""
+	}
+	return keys, otherKeys, other
+}
@@ -0,0 +1,60 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/mockcsv"
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestPruneJoins(t *testing.T) {
+	mockcsv.LoadTable(mockcsv.SchemaName, "prune_users", `user_id,name
+1,bob
+2,alice`)
+	mockcsv.LoadTable(mockcsv.SchemaName, "prune_orders", `order_id,user_id,price
+1,1,10.5
+2,2,20.5`)
+	sch := mockcsv.Schema()
+	users, err := sch.Table("prune_users")
+	assert.Equal(t, nil, err)
+	orders, err := sch.Table("prune_orders")
+	assert.Equal(t, nil, err)
+
+	fromCt := func(sql string) int {
+		ctx := td.TestContext(sql)
+		ctx.Schema = sch
+		selectPlan(t, ctx)
+		return len(ctx.Stmt.(*rel.SqlSelect).From)
+	}
+	join := `SELECT o.price FROM prune_orders AS o INNER JOIN prune_users AS u ON o.user_id = u.user_id`
+
+	// without keys the join may filter or duplicate rows
+	assert.Equal(t, 2, fromCt(join))
+	users.Indexes = append(users.Indexes, &schema.Index{Name: "pk", Fields: []string{"user_id"}, PrimaryKey: true})
+	assert.True(t, users.IsUniqueKey("user_id"))
+	assert.False(t, orders.IsUniqueKey("user_id"))
+	assert.Equal(t, 2, fromCt(join))
+
+	orders.AddForeignKey([]string{"user_id"}, "prune_users", []string{"user_id"})
+	assert.NotEqual(t, nil, orders.ForeignKey([]string{"user_id"}, "prune_users", []string{"user_id"}))
+	assert.True(t, orders.ForeignKey([]string{"price"}, "prune_users", []string{"user_id"}) == nil)
+	// nullable foreign key, orders without a user are dropped by the join
+	assert.Equal(t, 2, fromCt(join))
+	fld, _ := orders.Field("user_id")
+	fld.NoNulls = true
+	assert.Equal(t, 1, fromCt(join))
+	assert.Equal(t, 1, fromCt(`SELECT count(*) FROM prune_orders AS o INNER JOIN prune_users AS u ON u.user_id = o.user_id WHERE o.price > 1`))
+
+	// used columns of the joined table keep the join
+	assert.Equal(t, 2, fromCt(`SELECT o.price, u.name FROM prune_orders AS o INNER JOIN prune_users AS u ON o.user_id = u.user_id`))
+	assert.Equal(t, 2, fromCt(join+` WHERE u.name = "bob"`))
+	assert.Equal(t, 2, fromCt(`SELECT o.price FROM prune_orders AS o INNER JOIN prune_users AS u ON o.user_id = u.user_id WHERE name = "bob"`))
+	assert.Equal(t, 2, fromCt(`SELECT * FROM prune_orders AS o INNER JOIN prune_users AS u ON o.user_id = u.user_id`))
+	// not joined on its key
+	assert.Equal(t, 2, fromCt(`SELECT o.price FROM prune_orders AS o INNER JOIN prune_users AS u ON o.order_id = u.user_id`))
+	assert.Equal(t, 2, fromCt(`SELECT o.price FROM prune_orders AS o INNER JOIN prune_users AS u ON o.user_id = u.user_id AND u.name = "bob"`))
+}
@@ -0,0 +1,29 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+// ApplySelectLimit add the session sql_select_limit as the LIMIT of a
+// top level SELECT without one, with a warning on the context, returns
+// true if it was limited.  Selects into variables, literal selects and
+// aggregates without a GROUP BY (a single row) are not limited.
+//
+//    SET SESSION sql_select_limit = 1000;
+//    SELECT * FROM events;              -- LIMIT 1000, warning
+//    SELECT * FROM events LIMIT 50000;  -- statement LIMIT wins
+func ApplySelectLimit(ctx *Context, stmt *rel.SqlSelect) bool {
+	limit := ctx.SelectLimit()
+	if limit <= 0 || stmt.Limit > 0 || stmt.Into != nil || stmt.IsLiteral() {
+		return false
+	}
+	if stmt.IsAggQuery() && len(stmt.GroupBy) == 0 {
+		return false
+	}
+	stmt.Limit = limit
+	ctx.Warnings = append(ctx.Warnings, fmt.Sprintf(
+		"SELECT without LIMIT, results limited to %d rows by sql_select_limit, add a LIMIT to override", limit))
+	return true
+}
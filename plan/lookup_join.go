@@ -0,0 +1,65 @@
+package plan
+
+import (
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// LookupJoin is an index lookup join condition of a JoinMerge
+//
+//     ON tolower(a.email) = tolower(b.email)
+//
+// where the right source has an index on its side of the condition (see
+// schema.Table.AddExprIndex), rather than scan the right source each left
+// row is looked up in that index.
+type LookupJoin struct {
+	// Index name of the right sources index.
+	Index string
+	// Left, Right the key expression of each side, alias removed, to
+	// evaluate against that sides source rows.
+	Left  expr.Node
+	Right expr.Node
+}
+
+// newLookupJoin finds an equality join condition between the two sources
+// whose right side expression is indexed by the right source, nil if
+// there is none or the source can't seek its indexes.
+func newLookupJoin(lf *rel.SqlSource, right *Source) *LookupJoin {
+	if right.Tbl == nil || right.Stmt == nil {
+		return nil
+	}
+	if _, ok := right.Conn.(schema.ConnIndexSeeker); !ok {
+		return nil
+	}
+	rf := right.Stmt
+	for _, from := range []*rel.SqlSource{rf, lf} {
+		if from.JoinExpr == nil {
+			continue
+		}
+		bn, ok := from.JoinExpr.(*expr.BinaryNode)
+		if !ok || len(bn.Args) != 2 {
+			continue
+		}
+		switch bn.Operator.T {
+		case lex.TokenEqual, lex.TokenEqualEqual:
+		default:
+			continue
+		}
+		for _, args := range [][]expr.Node{{bn.Args[0], bn.Args[1]}, {bn.Args[1], bn.Args[0]}} {
+			l, ok := unaliasNode(lf.Alias, args[0])
+			if !ok {
+				continue
+			}
+			r, ok := unaliasNode(rf.Alias, args[1])
+			if !ok {
+				continue
+			}
+			if idx := right.Tbl.ExprIndex(r); idx != nil {
+				return &LookupJoin{Index: idx.Name, Left: l, Right: r}
+			}
+		}
+	}
+	return nil
+}
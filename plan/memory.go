@@ -0,0 +1,103 @@
+package plan
+
+import (
+	"sync/atomic"
+
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// ErrMemoryLimit the sort, aggregate and join operators of a query held
+	// more memory than its limit allows.
+	ErrMemoryLimit = sqlerr.New(sqlerr.ErOutOfMemory, "Query execution was interrupted, query memory limit exceeded")
+)
+
+// MemoryAccount tracks the (approximate) bytes held by the sort, aggregate
+// and join operators of a query, failing a Grow past its limit.  Accounts
+// may have a parent (the engine) that every Grow, Shrink is also charged
+// to, and whose limit applies as well.  A nil account tracks nothing.
+type MemoryAccount struct {
+	limit  int64
+	used   int64
+	peak   int64
+	parent *MemoryAccount
+}
+
+// NewMemoryAccount an account limited to @limit bytes (0 for no limit),
+// charging its parent (optional).
+func NewMemoryAccount(limit int64, parent *MemoryAccount) *MemoryAccount {
+	return &MemoryAccount{limit: limit, parent: parent}
+}
+
+// Grow reserve n more bytes, ErrMemoryLimit if it exceeds the limit of
+// this account or its parent, in which case nothing is reserved.
+func (m *MemoryAccount) Grow(n int64) error {
+	if m == nil || n <= 0 {
+		return nil
+	}
+	used := atomic.AddInt64(&m.used, n)
+	if m.limit > 0 && used > m.limit {
+		atomic.AddInt64(&m.used, -n)
+		return ErrMemoryLimit
+	}
+	if err := m.parent.Grow(n); err != nil {
+		atomic.AddInt64(&m.used, -n)
+		return err
+	}
+	for {
+		peak := atomic.LoadInt64(&m.peak)
+		if used <= peak || atomic.CompareAndSwapInt64(&m.peak, peak, used) {
+			return nil
+		}
+	}
+}
+
+// Shrink release n bytes.
+func (m *MemoryAccount) Shrink(n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	atomic.AddInt64(&m.used, -n)
+	m.parent.Shrink(n)
+}
+
+// Used bytes currently held.
+func (m *MemoryAccount) Used() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.used)
+}
+
+// Peak most bytes held at once.
+func (m *MemoryAccount) Peak() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.peak)
+}
+
+// Limit bytes this account may hold, 0 for no limit.
+func (m *MemoryAccount) Limit() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.limit
+}
+
+// QueryMemoryLimit the bytes the operators of the statement may hold, the
+// smaller of the session query_memory_limit and the client budget
+// MaxMemory, 0 for no limit.
+func (m *Context) QueryMemoryLimit() int64 {
+	limit := int64(0)
+	if m.Budget != nil {
+		limit = m.Budget.MaxMemory
+	}
+	if v, ok := m.SessionVar(QueryMemoryLimitVar); ok {
+		if n, ok := value.ValueToInt64(v); ok && n > 0 && (limit == 0 || n < limit) {
+			limit = n
+		}
+	}
+	return limit
+}
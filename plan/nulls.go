@@ -0,0 +1,59 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+const (
+	// NullOrderVar session variable for default NULL ordering of
+	// ORDER BY columns without explicit NULLS {FIRST | LAST}.
+	//    SET @@null_order = 'last';
+	NullOrderVar = "@@null_order"
+	// NullGroupingVar session variable for how NULL values are grouped
+	// in GROUP BY and SELECT DISTINCT.
+	//    SET @@null_grouping = 'legacy';
+	NullGroupingVar = "@@null_grouping"
+
+	// NullsFirst sort NULL values ahead of all non-null values.
+	NullsFirst = "first"
+	// NullsLast sort NULL values after all non-null values.
+	NullsLast = "last"
+
+	// NullGroupStrict is the sql-standard grouping, all NULL values group
+	// together and are distinct from every non-null value (including '').
+	NullGroupStrict = "strict"
+	// NullGroupLegacy groups NULL values together with empty string values.
+	NullGroupLegacy = "legacy"
+)
+
+// NullsFirst determine if NULL values sort ahead of non-null values
+// for this order by column.  Explicit NULLS FIRST/LAST on the column wins,
+// then the session @@null_order, otherwise NULL is the lowest value
+// (mysql semantics) so it is first for ascending sorts.
+func (m *Context) NullsFirst(col *rel.Column) bool {
+	switch strings.ToLower(col.Nulls) {
+	case NullsFirst:
+		return true
+	case NullsLast:
+		return false
+	}
+	switch m.sessionString(NullOrderVar) {
+	case NullsFirst:
+		return true
+	case NullsLast:
+		return false
+	}
+	return col.Asc()
+}
+
+// NullGrouping the NULL grouping mode for GROUP BY and DISTINCT
+// for this session, defaults to NullGroupLegacy.
+func (m *Context) NullGrouping() string {
+	switch m.sessionString(NullGroupingVar) {
+	case NullGroupStrict:
+		return NullGroupStrict
+	}
+	return NullGroupLegacy
+}
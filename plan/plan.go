@@ -14,15 +14,16 @@ import (
 
 	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
 )
 
 var (
 	// ErrNotImplemented is plan specific error for not implemented
-	ErrNotImplemented = fmt.Errorf("QLBridge.plan: not implemented")
+	ErrNotImplemented = sqlerr.New(sqlerr.ErNotSupported, "QLBridge.plan: not implemented")
 	// ErrNoDataSource no datasource/type found
-	ErrNoDataSource = fmt.Errorf("QLBridge.plan: No datasource found")
+	ErrNoDataSource = sqlerr.New(sqlerr.ErNoSuchTable, "QLBridge.plan: No datasource found")
 	// ErrNoPlan no plan
-	ErrNoPlan = fmt.Errorf("No Plan")
+	ErrNoPlan = sqlerr.New(sqlerr.ErUnknown, "No Plan")
 
 	// Ensure our tasks implement Task Interface
 	_ Task = (*PreparedStatement)(nil)
@@ -42,6 +43,7 @@ var (
 	_ Task = (*Order)(nil)
 	_ Task = (*JoinMerge)(nil)
 	_ Task = (*JoinKey)(nil)
+	_ Task = (*Scatter)(nil)
 
 	// Force any plan that participates in a Select to implement Proto
 	//  which allows us to serialize and distribute to multiple nodes.
@@ -120,6 +122,23 @@ type (
 		// given our request statement, turn that into a plan.Task.
 		WalkSourceSelect(pl Planner, s *Source) (Task, error)
 	}
+
+	// SourceIndexHinter is an optional interface for source connections with
+	// index capability, they are given the USE/FORCE/IGNORE INDEX hints from
+	// the table reference before planning.  An error aborts the query, ie
+	// sources may reject hints for indexes that don't exist.
+	SourceIndexHinter interface {
+		IndexHints(hints []*rel.IndexHint) error
+	}
+
+	// SourceTableSampler is an optional interface for source connections
+	// that sample rows natively (BigQuery TABLESAMPLE, elasticsearch
+	// random_score), they are given the TABLESAMPLE clause of the table
+	// reference before planning and return true if they apply it, in
+	// which case rows are not sampled again by the executor.
+	SourceTableSampler interface {
+		TableSample(sample *rel.SqlSample) bool
+	}
 )
 
 type (
@@ -202,6 +221,28 @@ type (
 		Tbl        *schema.Table  // Table schema for this From
 		Static     []driver.Value // this is static data source
 		Cols       []string
+
+		// Shard the node of a sharded source this source reads, nil unsharded.
+		Shard *schema.ConfigNode
+
+		// PartialAgg the source (a SourcePlanner) filters, groups and
+		// aggregates the select itself, returning a row of partial
+		// aggregates per group followed by its group key, so only the
+		// merge of the partials (GroupBy.Merge) is left to do.
+		PartialAgg bool
+
+		// SampleNative the TABLESAMPLE of this source is applied by the source
+		// (SourceTableSampler) not the executor.
+		SampleNative bool
+
+		// Hints optimizer hints of the select this source is part of.
+		Hints rel.SqlHints
+
+		// Cost model, estimated rows from this source after its where filter,
+		// the sources cost factors and the estimated cost to read them.
+		EstimatedRows float64
+		Costs         CostFactors
+		Cost          float64
 	}
 	// Into Select INTO table
 	Into struct {
@@ -213,17 +254,26 @@ type (
 		*PlanBase
 		Stmt    *rel.SqlSelect
 		Partial bool
+		// Merge re-aggregates the Partial group-by results of the shards
+		// of a Scatter.
+		Merge bool
 	}
 	// Order By clause
 	Order struct {
 		*PlanBase
 		Stmt *rel.SqlSelect
+		// Limit TopN, only the first Limit rows in order are kept (LIMIT
+		// plus OFFSET of the select), 0 all rows are sorted.
+		Limit int
 	}
 	// Where pre-aggregation filter
 	Where struct {
 		*PlanBase
 		Final bool
 		Stmt  *rel.SqlSelect
+		// Workers number of go routines evaluating the filter, PARALLEL(n)
+		// hint, 0 is one.
+		Workers int
 	}
 	// Having post-aggregation filter plan.
 	Having struct {
@@ -238,6 +288,22 @@ type (
 		LeftFrom  *rel.SqlSource
 		RightFrom *rel.SqlSource
 		ColIndex  map[string]int
+		// Similarity fuzzy string join condition, nil for key equality join.
+		Similarity *SimilarityJoin
+		// Lookup index lookup join of the right source, nil to scan it.
+		Lookup *LookupJoin
+	}
+	// Scatter the same sub-query sent to every shard (node) of a sharded
+	// source, the results of the shards gathered into one stream.
+	//
+	//   shard0 source -> where -> partial group-by  \
+	//   shard1 source -> where -> partial group-by  -- gather -->
+	//   shardn source -> where -> partial group-by  /
+	//
+	Scatter struct {
+		*PlanBase
+		Stmt   *rel.SqlSelect
+		Shards []*Source
 	}
 	// JoinKey plan
 	JoinKey struct {
@@ -514,7 +580,7 @@ func (m *Select) IsSchemaQuery() bool {
 	if len(m.From) == 1 {
 		//u.Debugf("schema:%q name:%q", m.From[0].Stmt.Schema, m.From[0].Stmt.Name)
 		schemaName := strings.ToLower(m.From[0].Stmt.Schema)
-		if schemaName == "context" || schemaName == "schema" {
+		if schemaName == "context" || schemaName == "schema" || schemaName == "information_schema" {
 			return true
 		}
 	}
@@ -661,7 +727,7 @@ func (m *Source) LoadConn() error {
 			return nil
 		}
 	}
-	source, err := m.DataSource.Open(m.Stmt.SourceName())
+	source, err := m.OpenConn()
 	if err != nil {
 		u.Debugf("no source? %T for source %q", m.DataSource, m.Stmt.SourceName())
 		return err
@@ -669,11 +735,80 @@ func (m *Source) LoadConn() error {
 	m.Conn = source
 	return nil
 }
+
+// OpenConn open a new conn to the source, from the pool of its schema if
+// configured, retried per the schema's retry policy.  A shard source opens
+// a conn to its Shard node.
+func (m *Source) OpenConn() (schema.Conn, error) {
+	name := m.Stmt.SourceName()
+	pool := m.Pool()
+	return m.Retry().Open(func() (schema.Conn, error) {
+		if m.Shard != nil {
+			return m.DataSource.(schema.SourceSharded).ShardSource(m.Shard, name)
+		}
+		if pool != nil {
+			return pool.Get(name)
+		}
+		return m.DataSource.Open(name)
+	}, func(conn schema.Conn) {
+		m.ReleaseConn(conn, schema.ErrSourceTimeout)
+	})
+}
+
+// ReleaseConn a conn from OpenConn, back to the pool if pooled else
+// closed.  err is the error of its use if any.
+func (m *Source) ReleaseConn(conn schema.Conn, err error) error {
+	if pool := m.Pool(); pool != nil {
+		return pool.Release(conn, err)
+	}
+	return conn.Close()
+}
+
+// Pool the connection pool of the source's schema, nil if its conns are
+// not pooled.  Pooled conns are released by the exec Source task.
+func (m *Source) Pool() *schema.ConnPool {
+	if m == nil || m.Schema == nil || m.Schema.DS != m.DataSource || m.Shard != nil {
+		return nil
+	}
+	return m.Schema.Pool
+}
+
+// Shards the nodes of the source's schema if it is sharded, ie its
+// ConfigSource lists more than one node and its DataSource implements
+// schema.SourceSharded, else nil.
+func (m *Source) Shards() []*schema.ConfigNode {
+	if m == nil || m.Schema == nil || m.Schema.Conf == nil || len(m.Schema.Conf.Nodes) < 2 {
+		return nil
+	}
+	if m.Schema.DS != m.DataSource {
+		return nil
+	}
+	if _, ok := m.DataSource.(schema.SourceSharded); !ok {
+		return nil
+	}
+	return m.Schema.Conf.Nodes
+}
+
+// Retry the retry policy of the source's schema, nil if none.
+func (m *Source) Retry() *schema.RetryPolicy {
+	if m == nil || m.Schema == nil || m.Schema.DS != m.DataSource {
+		return nil
+	}
+	return m.Schema.Retry
+}
+
+// Limiter the concurrency limits of the source's schema, nil if none.
+func (m *Source) Limiter() *schema.SourceLimiter {
+	if m == nil || m.Schema == nil || m.Schema.DS != m.DataSource {
+		return nil
+	}
+	return m.Schema.Limiter
+}
 func (m *Source) IsSchemaQuery() bool {
 	if m.Stmt != nil && len(m.Stmt.Schema) > 0 {
 		//u.Debugf("schema:%q name:%q", m.Stmt.Schema, m.Stmt.Name)
 		schemaName := strings.ToLower(m.Stmt.Schema)
-		if schemaName == "context" || schemaName == "schema" {
+		if schemaName == "context" || schemaName == "schema" || schemaName == "information_schema" {
 			return true
 		}
 	}
@@ -759,7 +894,7 @@ func (m *Source) load() error {
 	}
 	if m.ctx.Schema == nil {
 		u.Errorf("missing schema in *plan.Source load() from:%q", fromName)
-		return fmt.Errorf("Missing schema for %v", fromName)
+		return sqlerr.New(sqlerr.ErNoDB, "Missing schema for %v", fromName)
 	}
 
 	ss, err := m.ctx.Schema.SchemaForTable(fromName)
@@ -780,7 +915,7 @@ func (m *Source) load() error {
 		return err
 	}
 	if tbl == nil {
-		return fmt.Errorf("No table found for %q", fromName)
+		return sqlerr.New(sqlerr.ErNoSuchTable, "No table found for %q", fromName)
 	}
 	m.Tbl = tbl
 
@@ -876,6 +1011,13 @@ func NewJoinMerge(l, r Task, lf, rf *rel.SqlSource) *JoinMerge {
 	return m
 }
 
+// NewScatter a parallel scatter of stmt to shards.
+func NewScatter(stmt *rel.SqlSelect, shards []*Source) *Scatter {
+	m := &Scatter{Stmt: stmt, Shards: shards, PlanBase: NewPlanBase(false)}
+	m.SetParallel()
+	return m
+}
+
 // NewJoinKey creates JoinKey from Source.
 func NewJoinKey(s *Source) *JoinKey {
 	return &JoinKey{Source: s, PlanBase: NewPlanBase(false)}
@@ -903,7 +1045,17 @@ func NewGroupBy(stmt *rel.SqlSelect) *GroupBy {
 
 // NewOrder from SqlSelect statement.
 func NewOrder(stmt *rel.SqlSelect) *Order {
-	return &Order{Stmt: stmt, PlanBase: NewPlanBase(false)}
+	return &Order{Stmt: stmt, Limit: orderLimit(stmt), PlanBase: NewPlanBase(false)}
+}
+
+// orderLimit the rows an ORDER BY of stmt must keep, its LIMIT plus
+// OFFSET, 0 all of them if it has no LIMIT.  A SELECT DISTINCT de-duplicates
+// after ordering, so keeps all of them.
+func orderLimit(stmt *rel.SqlSelect) int {
+	if stmt.Limit <= 0 || stmt.Distinct {
+		return 0
+	}
+	return stmt.Limit + stmt.Offset
 }
 
 // Equal compares equality of two tasks.
@@ -1068,6 +1220,7 @@ func OrderFromPB(pb *PlanPb) *Order {
 	m := Order{
 		Stmt: rel.SqlSelectFromPb(pb.Order.Select),
 	}
+	m.Limit = orderLimit(m.Stmt)
 	m.PlanBase = NewPlanBase(pb.Parallel)
 	return &m
 }
@@ -1092,6 +1245,28 @@ func (m *JoinMerge) Equal(t Task) bool {
 	}
 	return true
 }
+func (m *Scatter) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*Scatter)
+	if !ok {
+		return false
+	}
+	if len(m.Shards) != len(s.Shards) {
+		return false
+	}
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	return true
+}
 func (m *JoinKey) Equal(t Task) bool {
 	if m == nil && t == nil {
 		return true
@@ -132,6 +132,36 @@ func TestSelectSerialization(t *testing.T) {
 	}
 }
 
+func TestSelectClone(t *testing.T) {
+	for _, sqlStatement := range sqlStatements {
+		ctx := td.TestContext(sqlStatement)
+		p := selectPlan(t, ctx)
+		assert.True(t, p != nil)
+		sql := p.Stmt.String()
+
+		p2 := p.Clone()
+		assert.True(t, p.Equal(p2), "Should be equal plans %s", sqlStatement)
+		assert.True(t, p.Stmt != p2.Stmt, "must not share stmt")
+		assert.Equal(t, len(p.Children()), len(p2.Children()))
+		for i, child := range p.Children() {
+			assert.True(t, child != p2.Children()[i], "must not share tasks")
+		}
+		assert.Equal(t, len(p.From), len(p2.From))
+		for i, src := range p.From {
+			assert.True(t, src.Stmt != p2.From[i].Stmt)
+		}
+
+		// modify the clone, original is untouched
+		p2.Stmt.Limit = 7
+		p2.Stmt.Where = &rel.SqlWhere{Expr: expr.MustParse(`tenant_id = "abc"`)}
+		if len(p2.From) > 0 {
+			p2.From[0].Stmt.Alias = "tenant_alias"
+		}
+		assert.Equal(t, sql, p.Stmt.String())
+		assert.NotEqual(t, sql, p2.Stmt.String())
+	}
+}
+
 var (
 	_ = u.EMPTY
 
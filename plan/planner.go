@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/lex"
 )
 
 var (
@@ -33,10 +35,13 @@ func NewPlanner(ctx *Context) *PlannerDefault {
 	return p
 }
 
-// WalkPreparedStatement not implemented
+// WalkPreparedStatement PREPARE saves the statement to the session.
 func (m *PlannerDefault) WalkPreparedStatement(p *PreparedStatement) error {
 	u.Debugf("WalkPreparedStatement %+v", p.Stmt)
-	return ErrNotImplemented
+	if p.Stmt.Statement == nil {
+		return fmt.Errorf("PREPARE <identity> FROM <statement>")
+	}
+	return nil
 }
 
 // WalkCommand walks the command statement
@@ -54,6 +59,18 @@ func (m *PlannerDefault) WalkDrop(p *Drop) error {
 // WalkCreate walk a Create Plan to create the dag of tasks for Create.
 func (m *PlannerDefault) WalkCreate(p *Create) error {
 	u.Debugf("WalkCreate %#v", p)
+	if p.Stmt.Tok.T == lex.TokenSample {
+		if p.Stmt.Select == nil {
+			return fmt.Errorf("CREATE SAMPLE TABLE <identity> AS <select_stmt>")
+		}
+		return nil
+	}
+	if p.Stmt.Temp {
+		if len(p.Stmt.Cols) == 0 {
+			return fmt.Errorf("CREATE TEMPORARY TABLE <identity> (<columns>)")
+		}
+		return nil
+	}
 	if len(p.Stmt.With) == 0 {
 		return fmt.Errorf("CREATE {SCHEMA|SOURCE|DATABASE}")
 	}
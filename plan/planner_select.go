@@ -2,6 +2,7 @@ package plan
 
 import (
 	"fmt"
+	"math"
 
 	u "github.com/araddon/gou"
 
@@ -29,6 +30,15 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 	// u.Debugf("VisitSelect ctx:%p  %+v", p.Ctx, p.Stmt)
 
 	needsFinalProject := true
+	partialAgg := false
+
+	useSampleTables(m.Ctx, p.Stmt)
+	checkHints(m.Ctx, p.Stmt)
+
+	if len(p.Stmt.From) > 1 {
+		parentChildJoins(m.Ctx, p.Stmt)
+		pruneJoins(m.Ctx, p.Stmt)
+	}
 
 	if len(p.Stmt.From) == 0 {
 
@@ -42,6 +52,21 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 		if err != nil {
 			return err
 		}
+		srcPlan.Hints = p.Stmt.Hints
+		if shards := srcPlan.Shards(); len(shards) > 0 {
+			p.From = append(p.From, srcPlan)
+			scatter, err := m.walkScatter(p, shards)
+			if err != nil {
+				return err
+			}
+			p.Add(scatter)
+			return m.walkScatterFinal(p)
+		}
+		if isApproxCount(p.Stmt) {
+			if rows, ok := approxRowCount(m.Ctx, srcPlan); ok {
+				return m.walkApproxCount(p, rows)
+			}
+		}
 		p.From = append(p.From, srcPlan)
 		p.Add(srcPlan)
 
@@ -49,6 +74,7 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 		if err != nil {
 			return err
 		}
+		partialAgg = srcPlan.PartialAgg
 
 		if srcPlan.Complete && !needsFinalProjection(p.Stmt) {
 			goto finalProjection
@@ -59,7 +85,8 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 		var prevSource *Source
 		var prevTask Task
 
-		for i, from := range p.Stmt.From {
+		sources := make([]*Source, 0, len(p.Stmt.From))
+		for _, from := range p.Stmt.From {
 
 			// Need to rewrite the From statement to ensure all fields necessary to support
 			//  joins, wheres, etc exist but is standalone query
@@ -68,20 +95,37 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 			if err != nil {
 				return nil
 			}
+			srcPlan.Hints = p.Stmt.Hints
 			err = m.Planner.WalkSourceSelect(srcPlan)
 			if err != nil {
 				u.Errorf("Could not visitsubselect %v  %s", err, from)
 				return err
 			}
+			sources = append(sources, srcPlan)
+		}
 
-			// now fold into previous task
+		// cheapest sources first, then fold each into the previous
+		joinedRows := 0.0
+		for i, srcPlan := range joinOrder(sources) {
 			if i != 0 {
-				from.Seekable = true
+				srcPlan.Stmt.Seekable = hintSeekable(p.Stmt.Hints, joinedRows, srcPlan)
 				// fold this source into previous
 				curMergeTask := NewJoinMerge(prevTask, srcPlan, prevSource.Stmt, srcPlan.Stmt)
+				if _, isSource := prevTask.(*Source); isSource {
+					curMergeTask.Similarity = newSimilarityJoin(prevSource.Stmt, srcPlan.Stmt)
+					if curMergeTask.Similarity == nil && srcPlan.Stmt.Seekable {
+						curMergeTask.Lookup = newLookupJoin(prevSource.Stmt, srcPlan)
+					}
+				}
+				if curMergeTask.Lookup == nil && p.Stmt.Hints.HasTable(rel.HintLookupJoin, srcPlan.Stmt) {
+					m.Ctx.Warnings = append(m.Ctx.Warnings, fmt.Sprintf(
+						"LOOKUP_JOIN(%s) ignored, no index of its join condition", srcPlan.Stmt.SourceName()))
+				}
 				prevTask = curMergeTask
+				joinedRows = math.Max(joinedRows, srcPlan.EstimatedRows)
 			} else {
 				prevTask = srcPlan
+				joinedRows = srcPlan.EstimatedRows
 			}
 			prevSource = srcPlan
 			//u.Debugf("got task: %T", lastSource)
@@ -90,14 +134,16 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 
 	}
 
-	if p.Stmt.Where != nil {
+	if p.Stmt.Where != nil && !partialAgg {
 		switch {
 		case p.Stmt.Where.Source != nil:
 			// SELECT id from article WHERE id in (select article_id from comments where comment_ct > 50);
 			u.Warnf("Found un-supported subquery: %#v", p.Stmt.Where)
 			return ErrNotImplemented
 		case p.Stmt.Where.Expr != nil:
-			p.Add(NewWhere(p.Stmt))
+			where := NewWhere(p.Stmt)
+			where.Workers = hintWorkers(p.Stmt.Hints)
+			p.Add(where)
 		default:
 			u.Warnf("Found un-supported where type: %#v", p.Stmt.Where)
 			return fmt.Errorf("Unsupported Where Type")
@@ -106,7 +152,10 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 
 	if p.Stmt.IsAggQuery() {
 		//u.Debugf("Adding aggregate/group by? %#v", m.Planner)
-		p.Add(NewGroupBy(p.Stmt))
+		gb := NewGroupBy(p.Stmt)
+		// the source aggregated, only its partials are merged
+		gb.Merge = partialAgg
+		p.Add(gb)
 		needsFinalProject = false
 	}
 
@@ -140,6 +189,61 @@ finalProjection:
 	return nil
 }
 
+// walkScatter plan the select on each shard of a sharded source, each
+// shard filters its rows and, for aggregate queries, partially aggregates
+// them so only one row per group per shard is gathered.
+func (m *PlannerDefault) walkScatter(p *Select, shards []*schema.ConfigNode) (*Scatter, error) {
+	sources := make([]*Source, 0, len(shards))
+	for _, node := range shards {
+		shard, err := NewSource(m.Ctx, p.Stmt.From[0], true)
+		if err != nil {
+			return nil, err
+		}
+		shard.Hints = p.Stmt.Hints
+		shard.Shard = node
+		if err = m.Planner.WalkSourceSelect(shard); err != nil {
+			return nil, err
+		}
+		if p.Stmt.IsAggQuery() {
+			gb := NewGroupBy(p.Stmt)
+			gb.Partial = true
+			shard.Add(gb)
+		}
+		sources = append(sources, shard)
+	}
+	return NewScatter(p.Stmt, sources), nil
+}
+
+// walkScatterFinal the tasks after the gather of a scatter, the merge of
+// the partial aggregates then the same having, order and projection as
+// an unsharded select.
+func (m *PlannerDefault) walkScatterFinal(p *Select) error {
+	if p.Stmt.IsAggQuery() {
+		gb := NewGroupBy(p.Stmt)
+		gb.Merge = true
+		p.Add(gb)
+	}
+	if p.Stmt.Having != nil {
+		p.Add(NewHaving(p.Stmt))
+	}
+	if len(p.Stmt.OrderBy) > 0 {
+		p.Add(NewOrder(p.Stmt))
+	}
+	if !p.Stmt.IsAggQuery() {
+		if err := m.WalkProjectionFinal(p); err != nil {
+			return err
+		}
+	}
+	if m.Ctx.Projection == nil {
+		proj, err := NewProjectionFinal(m.Ctx, p)
+		if err != nil {
+			return err
+		}
+		m.Ctx.Projection = proj
+	}
+	return nil
+}
+
 // WalkProjectionFinal walk the select plan to create final projection.
 func (m *PlannerDefault) WalkProjectionFinal(p *Select) error {
 	// Add a Final Projection to choose the columns for results
@@ -205,7 +309,37 @@ func (m *PlannerDefault) WalkSourceSelect(p *Source) error {
 		}
 	}
 
-	if sourcePlanner, hasSourcePlanner := p.Conn.(SourcePlanner); hasSourcePlanner {
+	// Estimated rows this source will produce, used to cost the plan
+	est := StatsHintEstimator(p.Stmt.Stats, m.Ctx.Estimator())
+	tableRows := EstimateTableRows(est, p.Tbl)
+	p.EstimatedRows = tableRows
+	if p.Stmt.Source != nil && p.Stmt.Source.Where != nil {
+		p.EstimatedRows *= EstimateSelectivity(est, p.Tbl, p.Stmt.Source.Where.Expr)
+	}
+	if sample := p.Stmt.Sample; sample != nil {
+		if sample.Rows > 0 {
+			p.EstimatedRows = math.Min(p.EstimatedRows, float64(sample.Rows))
+		} else {
+			p.EstimatedRows *= sample.Percent / 100
+		}
+	}
+	_, pushdown := p.Conn.(SourcePlanner)
+	// shards are planned here, so their partial aggregates can be merged
+	noPushdown := p.Hints.HasTable(rel.HintNoPushdown, p.Stmt) || p.Shard != nil
+	pushdown = pushdown && !noPushdown
+	p.Costs = SourceCostFactors(p.Schema)
+	p.Cost = p.Costs.ScanCost(tableRows, p.EstimatedRows, pushdown)
+
+	if hinter, ok := p.Conn.(SourceIndexHinter); ok && len(p.Stmt.IndexHints) > 0 {
+		if err := hinter.IndexHints(p.Stmt.IndexHints); err != nil {
+			return err
+		}
+	}
+	if sampler, ok := p.Conn.(SourceTableSampler); ok && p.Stmt.Sample != nil && !noPushdown {
+		p.SampleNative = sampler.TableSample(p.Stmt.Sample)
+	}
+
+	if sourcePlanner, hasSourcePlanner := p.Conn.(SourcePlanner); hasSourcePlanner && !noPushdown {
 		// Can do our own planning
 		t, err := sourcePlanner.WalkSourceSelect(m.Planner, p)
 		if err != nil {
@@ -228,7 +362,9 @@ func (m *PlannerDefault) WalkSourceSelect(p *Source) error {
 		if p.Stmt.Source != nil && p.Stmt.Source.Where != nil {
 			switch {
 			case p.Stmt.Source.Where.Expr != nil:
-				p.Add(NewWhere(p.Stmt.Source))
+				where := NewWhere(p.Stmt.Source)
+				where.Workers = hintWorkers(p.Hints)
+				p.Add(where)
 			default:
 				u.Warnf("Found un-supported where type: %#v", p.Stmt.Source)
 				return fmt.Errorf("Unsupported Where clause:  %q", p.Stmt)
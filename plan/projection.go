@@ -9,6 +9,7 @@ import (
 
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/sqlerr"
 	"github.com/araddon/qlbridge/value"
 )
 
@@ -102,7 +103,7 @@ func (m *Projection) loadFinal(ctx *Context, isFinal bool) error {
 			return err
 		} else if tbl == nil {
 			u.Errorf("unexepcted nil table? %v", from.Name)
-			return fmt.Errorf("Table not found %q", from.Name)
+			return sqlerr.New(sqlerr.ErNoSuchTable, "Table not found %q", from.Name)
 		} else {
 
 			//u.Debugf("getting cols? %v   cols=%v", from.ColumnPositions())
@@ -110,11 +111,11 @@ func (m *Projection) loadFinal(ctx *Context, isFinal bool) error {
 				//_, right, _ := col.LeftRight()
 				//u.Infof("col %s", col)
 				if col.Star {
-					for _, f := range tbl.Fields {
+					for _, f := range tbl.FieldList() {
 						m.Proj.AddColumnShort(f.Name, f.ValueType())
 					}
 				} else {
-					if schemaCol, ok := tbl.FieldMap[col.SourceField]; ok {
+					if schemaCol, ok := tbl.Field(col.SourceField); ok {
 						if isFinal {
 							if col.InFinalProjection() {
 								//u.Debugf("in plan final %s", col.As)
@@ -165,7 +166,7 @@ func projectionForSourcePlan(plan *Source) error {
 			} else {
 				plan.Proj.AddColumn(col, value.StringType)
 			}
-		} else if schemaCol, ok := plan.Tbl.FieldMap[col.SourceField]; ok {
+		} else if schemaCol, ok := plan.Tbl.Field(col.SourceField); ok {
 			if plan.Final {
 				if col.InFinalProjection() {
 					//u.Infof("col add %v for %s", schemaCol.Type.String(), col)
@@ -182,7 +183,7 @@ func projectionForSourcePlan(plan *Source) error {
 				u.Warnf("no table?? %v", plan)
 			} else {
 				//u.Infof("star cols? %v fields: %v", plan.Tbl.FieldPositions, plan.Tbl.Fields)
-				for _, f := range plan.Tbl.Fields {
+				for _, f := range plan.Tbl.FieldList() {
 					//u.Infof("  add col %v  %+v", f.Name, f)
 					plan.Proj.AddColumnShort(f.Name, f.ValueType())
 				}
@@ -0,0 +1,60 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// sampleTable find the sample table (CREATE SAMPLE TABLE) of table in
+// schema, preferring the largest sample, false if there is none.
+func sampleTable(s *schema.Schema, table string) (string, float64, bool) {
+	if s == nil || table == "" {
+		return "", 0, false
+	}
+	name, best := "", 0.0
+	for _, tbl := range s.Tables() {
+		ss, err := s.SchemaForTable(tbl)
+		if err != nil || ss == nil {
+			continue
+		}
+		sampler, ok := ss.DS.(schema.SourceSampler)
+		if !ok {
+			continue
+		}
+		of, pct := sampler.SampleOf()
+		if strings.EqualFold(of, table) && pct > best {
+			name, best = tbl, pct
+		}
+	}
+	return name, best, name != ""
+}
+
+// useSampleTables rewrite the table sources of stmt to read from their
+// sample tables, when the session sample_mode is on, adding an accuracy
+// warning to the context for each.
+func useSampleTables(ctx *Context, stmt *rel.SqlSelect) {
+	if !ctx.SampleMode() || stmt.Into != nil {
+		return
+	}
+	for _, from := range stmt.From {
+		if from.SubQuery != nil || from.Sample != nil {
+			continue
+		}
+		table := from.SourceName()
+		sample, pct, ok := sampleTable(ctx.Schema, table)
+		if !ok || strings.EqualFold(sample, table) {
+			continue
+		}
+		if from.Alias == "" {
+			from.Alias = table
+		}
+		from.Name = sample
+		from.Schema = ""
+		ctx.Warnings = append(ctx.Warnings, fmt.Sprintf(
+			"results for %q are approximate, answered from sample table %q of %v%% of rows",
+			table, sample, pct))
+	}
+}
@@ -0,0 +1,257 @@
+package plan
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	// SqlModeVar session variable of comma separated sql modes, the
+	// STRICT_TRANS_TABLES or STRICT_ALL_TABLES modes reject inserts of
+	// values that don't convert to the column type.
+	//    SET SESSION sql_mode = 'STRICT_ALL_TABLES';
+	SqlModeVar = "@@sql_mode"
+	// MaxExecutionTimeVar session variable for the maximum milliseconds
+	// a SELECT may run before it is interrupted, 0 is no limit.
+	//    SET SESSION max_execution_time = 1000;
+	MaxExecutionTimeVar = "@@max_execution_time"
+	// SampleModeVar session variable, when on SELECTs on a table with a
+	// sample table (CREATE SAMPLE TABLE) are answered from the sample.
+	//    SET SESSION sample_mode = 1;
+	SampleModeVar = "@@sample_mode"
+	// WaitTimeoutVar session variable for the seconds a connection may
+	// sit idle between statements before the server closes it.
+	//    SET SESSION wait_timeout = 600;
+	WaitTimeoutVar = "@@wait_timeout"
+	// SelectLimitVar session variable, the LIMIT added to SELECTs that
+	// have none, protecting sources from accidental full exports by
+	// interactive sessions, 0 is no limit.  A LIMIT in the statement
+	// overrides it.
+	//    SET SESSION sql_select_limit = 1000;
+	SelectLimitVar = "@@sql_select_limit"
+	// SpoolQuotaVar session variable, bytes of result rows a SELECT may
+	// spool to local disk when the client reads them slower than the
+	// sources produce them, letting the sources finish and release their
+	// connections early, 0 disables spooling.
+	//    SET SESSION result_spool_quota = 104857600;
+	SpoolQuotaVar = "@@result_spool_quota"
+	// QueryMemoryLimitVar session variable, approximate bytes the sort,
+	// aggregate and join operators of a statement may hold before it
+	// fails, 0 is no limit.
+	//    SET SESSION query_memory_limit = 268435456;
+	QueryMemoryLimitVar = "@@query_memory_limit"
+	// RowErrorsVar session variable, how streaming queries handle rows
+	// that fail expression evaluation or coercion: drop, halt or
+	// dead_letter, see RowErrorPolicy.
+	//    SET SESSION stream_row_errors = 'halt';
+	RowErrorsVar = "@@stream_row_errors"
+)
+
+// Session the state of a client connection, shared by all of its
+// statements: variables, temporary tables, the current schema (USE), the
+// user and prepared statements.  Frontends (database/sql driver, mysql
+// server) create one per connection and the Context of each statement
+// from it, closing it with the connection.
+//
+//    ses := plan.NewSession(datasource.NewMySqlSessionVars())
+//    ses.User = "bob"
+//    ses.SetSchema(s)
+//    ctx := ses.NewContext("SELECT * FROM users")
+//    ...
+//    ses.Close()
+//
+type Session struct {
+	ID      uint64                 // unique id of this session
+	User    string                 // user of the connection
+	Vars    expr.ContextReadWriter // @@session and @user variables
+	Temp    *TempTables            // temporary tables of the session
+	Budget  *Budget                // limits the client asked for, optional
+	Dialect *lex.DialectMode       // sql dialect quirks, nil mysql
+
+	mu       sync.Mutex
+	schema   *schema.Schema // current schema, USE
+	prepared map[string]*rel.PreparedStatement
+}
+
+// NewSession create the session of a new connection with variables vars.
+func NewSession(vars expr.ContextReadWriter) *Session {
+	return &Session{
+		ID:       NextId(),
+		Vars:     vars,
+		Temp:     NewTempTables(),
+		prepared: make(map[string]*rel.PreparedStatement),
+	}
+}
+
+// NewContext the plan context of statement sql run by this session.
+func (m *Session) NewContext(sql string) *Context {
+	ctx := NewContext(sql)
+	ctx.Conn = m
+	ctx.Session = m.Vars
+	ctx.User = m.User
+	ctx.Schema = m.Schema()
+	ctx.Temp = m.Temp
+	ctx.Budget = m.Budget
+	ctx.Dialect = m.Dialect
+	return ctx
+}
+
+// Schema the current schema of the session, the default schema of the
+// registry if none was chosen with USE, nil if there is neither.
+func (m *Session) Schema() *schema.Schema {
+	m.mu.Lock()
+	s := m.schema
+	m.mu.Unlock()
+	if s == nil {
+		s, _ = schema.DefaultRegistry().DefaultSchema()
+	}
+	return s
+}
+
+// SetSchema make s the current schema of the session.
+func (m *Session) SetSchema(s *schema.Schema) {
+	m.mu.Lock()
+	m.schema = s
+	m.mu.Unlock()
+}
+
+// Use make the registered schema name the current schema of the session.
+func (m *Session) Use(name string) error {
+	s, ok := schema.DefaultRegistry().Schema(strings.ToLower(name))
+	if !ok || s == nil {
+		return sqlerr.New(sqlerr.ErBadDB, "Unknown database '%s'", name)
+	}
+	m.SetSchema(s)
+	return nil
+}
+
+// Prepare save prepared statement p of the session, replacing any of the
+// same name.
+//
+//    PREPARE stmt1 FROM 'SELECT * FROM users WHERE user_id = ?'
+//
+func (m *Session) Prepare(p *rel.PreparedStatement) {
+	m.mu.Lock()
+	m.prepared[strings.ToLower(p.Alias)] = p
+	m.mu.Unlock()
+}
+
+// Prepared the prepared statement name, false if there is none.
+func (m *Session) Prepared(name string) (*rel.PreparedStatement, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.prepared[strings.ToLower(name)]
+	return p, ok
+}
+
+// Deallocate drop prepared statement name, false if there is none.
+func (m *Session) Deallocate(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.prepared[strings.ToLower(name)]
+	delete(m.prepared, strings.ToLower(name))
+	return ok
+}
+
+// Close the session, dropping its temporary tables and prepared
+// statements.
+func (m *Session) Close() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	m.prepared = make(map[string]*rel.PreparedStatement)
+	m.mu.Unlock()
+	return m.Temp.Close()
+}
+
+// SessionVar read a session variable, allowing either the bare @@name
+// or the @@session.name form.
+func (m *Context) SessionVar(name string) (value.Value, bool) {
+	if m == nil || m.Session == nil {
+		return nil, false
+	}
+	keys := []string{name, strings.Replace(name, "@@", "@@session.", 1)}
+	for _, key := range keys {
+		if v, ok := m.Session.Get(key); ok && v != nil && !v.Nil() {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// sessionString read a string session variable, lower cased.
+func (m *Context) sessionString(name string) string {
+	if v, ok := m.SessionVar(name); ok {
+		return strings.ToLower(strings.TrimSpace(v.ToString()))
+	}
+	return ""
+}
+
+// StrictMode is the session sql_mode strict.
+func (m *Context) StrictMode() bool {
+	for _, mode := range strings.Split(m.sessionString(SqlModeVar), ",") {
+		switch strings.TrimSpace(mode) {
+		case "strict_trans_tables", "strict_all_tables", "traditional":
+			return true
+		}
+	}
+	return false
+}
+
+// MaxExecutionTime the session max_execution_time, 0 for no limit.
+func (m *Context) MaxExecutionTime() time.Duration {
+	if v, ok := m.SessionVar(MaxExecutionTimeVar); ok {
+		if ms, ok := value.ValueToInt64(v); ok && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	return 0
+}
+
+// WaitTimeout the session wait_timeout, 0 for no limit.
+func (m *Context) WaitTimeout() time.Duration {
+	if v, ok := m.SessionVar(WaitTimeoutVar); ok {
+		if secs, ok := value.ValueToInt64(v); ok && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return 0
+}
+
+// SelectLimit the session sql_select_limit, 0 for no limit.
+func (m *Context) SelectLimit() int {
+	if v, ok := m.SessionVar(SelectLimitVar); ok {
+		if n, ok := value.ValueToInt64(v); ok && n > 0 {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// SpoolQuota the session result_spool_quota in bytes, 0 for no spooling.
+func (m *Context) SpoolQuota() int64 {
+	if v, ok := m.SessionVar(SpoolQuotaVar); ok {
+		if n, ok := value.ValueToInt64(v); ok && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// SampleMode is the session sample_mode on.
+func (m *Context) SampleMode() bool {
+	if v, ok := m.SessionVar(SampleModeVar); ok {
+		on, ok := value.ValueToBool(v)
+		return ok && on
+	}
+	return false
+}
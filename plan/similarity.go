@@ -0,0 +1,116 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+)
+
+// SimilarityQGram length of the q-grams similarity joins block on, shorter
+// grams find more candidates for short strings at more cost.
+var SimilarityQGram = 2
+
+// SimilarityJoin is a fuzzy string join condition of a JoinMerge
+//
+//     ON levenshtein(a.name, b.name) < 3
+//
+// instead of a cross product, the right rows are indexed by their q-grams
+// and each left row only compared to rows sharing enough q-grams to be
+// within MaxDistance edits.
+type SimilarityJoin struct {
+	// Left, Right the string expression of each side, alias removed, to
+	// evaluate against that sides source rows.
+	Left  expr.Node
+	Right expr.Node
+	// Distance func, evaluated with (left, right) values of a candidate pair.
+	Distance *expr.FuncNode
+	// MaxDistance the largest distance that joins.
+	MaxDistance int
+	// Q q-gram length.
+	Q int
+}
+
+// newSimilarityJoin finds a levenshtein distance join condition between
+// the two sources, nil if the join is not one.
+func newSimilarityJoin(lf, rf *rel.SqlSource) *SimilarityJoin {
+	for _, from := range []*rel.SqlSource{rf, lf} {
+		if from.JoinExpr == nil {
+			continue
+		}
+		bn, ok := from.JoinExpr.(*expr.BinaryNode)
+		if !ok || len(bn.Args) != 2 {
+			continue
+		}
+		// levenshtein(x, y) < k,  or flipped  k > levenshtein(x, y)
+		fn, fok := bn.Args[0].(*expr.FuncNode)
+		nn, nok := bn.Args[1].(*expr.NumberNode)
+		op := bn.Operator.T
+		if !fok || !nok {
+			fn, fok = bn.Args[1].(*expr.FuncNode)
+			nn, nok = bn.Args[0].(*expr.NumberNode)
+			switch op {
+			case lex.TokenGT:
+				op = lex.TokenLT
+			case lex.TokenGE:
+				op = lex.TokenLE
+			default:
+				continue
+			}
+		}
+		if !fok || !nok || !nn.IsInt || strings.ToLower(fn.Name) != "levenshtein" || len(fn.Args) != 2 || fn.Eval == nil {
+			continue
+		}
+		maxDist := int(nn.Int64)
+		switch op {
+		case lex.TokenLT:
+			maxDist--
+		case lex.TokenLE:
+		default:
+			continue
+		}
+		if maxDist < 0 {
+			continue
+		}
+		sj := &SimilarityJoin{Distance: fn, MaxDistance: maxDist, Q: SimilarityQGram}
+		if l, ok := unaliasNode(lf.Alias, fn.Args[0]); ok {
+			if r, ok := unaliasNode(rf.Alias, fn.Args[1]); ok {
+				sj.Left, sj.Right = l, r
+				return sj
+			}
+		}
+		if l, ok := unaliasNode(lf.Alias, fn.Args[1]); ok {
+			if r, ok := unaliasNode(rf.Alias, fn.Args[0]); ok {
+				sj.Left, sj.Right = l, r
+				return sj
+			}
+		}
+	}
+	return nil
+}
+
+// unaliasNode copy of n with alias.col identities as col, false if n
+// refers to any other source.
+func unaliasNode(alias string, n expr.Node) (expr.Node, bool) {
+	switch nt := n.(type) {
+	case *expr.IdentityNode:
+		if left, right, ok := nt.LeftRight(); ok && left == alias {
+			return &expr.IdentityNode{Text: right}, true
+		}
+	case *expr.StringNode, *expr.NumberNode:
+		return n, true
+	case *expr.FuncNode:
+		fn := expr.NewFuncNode(nt.Name, nt.F)
+		fn.Eval = nt.Eval
+		for _, arg := range nt.Args {
+			a, ok := unaliasNode(alias, arg)
+			if !ok {
+				return nil, false
+			}
+			fn.Args = append(fn.Args, a)
+		}
+		return fn, true
+	}
+	return nil, false
+}
@@ -0,0 +1,20 @@
+package plan
+
+// Snapshot the read snapshot of source key for the statement, opened the
+// first time it is asked for, so every scan and seek of the source in the
+// statement (self joins, lookup joins) reads the same consistent version
+// of its rows, whatever writes happen meanwhile.  Sources that keep
+// versions of their rows (memdb) use it from their Conn's SetContext.
+func (m *Context) Snapshot(key interface{}, open func() interface{}) interface{} {
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
+	if snap, ok := m.snapshots[key]; ok {
+		return snap
+	}
+	if m.snapshots == nil {
+		m.snapshots = make(map[interface{}]interface{})
+	}
+	snap := open()
+	m.snapshots[key] = snap
+	return snap
+}
@@ -56,6 +56,7 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 	showType := strings.ToLower(stmt.ShowType)
 	u.Debugf("showType=%q create=%q from=%q rewrite: %s", showType, stmt.CreateWhat, stmt.From, raw)
 	sqlStatement := ""
+	var tableFilter expr.Node
 	from := "tables"
 	if stmt.Db != "" {
 		from = fmt.Sprintf("%s.%s", stmt.Db, expr.IdentityMaybeQuote('`', from))
@@ -130,7 +131,11 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 			+-------+------------+----------+--------------+-------------+-----------+-------------+----------+--------+------+------------+---------+---------------+
 
 		*/
-		sqlStatement = fmt.Sprintf("select Table, Non_unique, Key_name, Seq_in_index, Column_name, Collation, Cardinality, Sub_part, Packed, `Null`, Index_type, Index_comment from `schema`.`indexes`;")
+		sqlStatement = "select Table, Non_unique, Key_name, Seq_in_index, Column_name, Collation, Cardinality, Sub_part, Packed, `Null`, Index_type, Comment, Index_comment from `schema`.`indexes`;"
+		if stmt.Identity != "" {
+			tableFilter = expr.NewBinaryNode(lex.Token{T: lex.TokenEqual, V: "="},
+				expr.NewIdentityNodeVal("Table"), expr.NewStringNode(stmt.Identity))
+		}
 
 	case "variables":
 		// SHOW [GLOBAL | SESSION] VARIABLES [like_or_where]
@@ -155,12 +160,8 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 		// http://dev.mysql.com/doc/refman/5.7/en/server-status-variables.html
 
 		// SHOW [GLOBAL | SESSION | SLAVE ] STATUS [like_or_where]
-		scope := stmt.Scope
-		switch scope {
-		case "session", "":
-			scope = "session"
-		}
-		sqlStatement = fmt.Sprintf("select Variable_name, Value from `context`.`%s_variables`;", scope)
+		// the engine counters, the same in every scope
+		sqlStatement = "select Variable_name, Value from `context`.`status`;"
 		/*
 			mysql> show global status;
 			+--------------------------------+-----------------+
@@ -190,6 +191,12 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 			| FEDERATED          | NO      | Federated MySQL storage engine                                             | NULL         | NULL | NULL       |
 			+--------------------+---------+----------------------------------------------------------------------------+--------------+------+------------+
 		*/
+	case "collation":
+		// SHOW COLLATION [like_or_where]
+		sqlStatement = "select Collation, Charset, Id, Default, Compiled, Sortlen from `schema`.`collations`;"
+	case "charset":
+		// SHOW {CHARACTER SET | CHARSET} [like_or_where]
+		sqlStatement = "select Charset, Description, `Default collation`, Maxlen from `schema`.`character_sets`;"
 	case "procedure", "function":
 		/*
 			show procuedure status;
@@ -226,6 +233,14 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 		//u.Debugf("add where: %s", stmt.Where)
 		sel.Where = &rel.SqlWhere{Expr: stmt.Where}
 	}
+	if tableFilter != nil {
+		// SHOW INDEX FROM tbl_name, only the rows of that table
+		if sel.Where == nil {
+			sel.Where = &rel.SqlWhere{Expr: tableFilter}
+		} else {
+			sel.Where.Expr = expr.NewBinaryNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, tableFilter, sel.Where.Expr)
+		}
+	}
 	if ctx.Schema == nil {
 		u.Warnf("missing schema for %s", stmt.Raw)
 		return nil, fmt.Errorf("Must have schema")
@@ -0,0 +1,467 @@
+package plan
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// TableStatsEstimator a CardinalityEstimator using the statistics ANALYZE
+// TABLE computed into the schema.DefaultStatsStore, tables that have not
+// been analyzed have no estimate.
+var TableStatsEstimator CardinalityEstimator = tableStats{}
+
+// tableStats estimates from analyzed table statistics
+type tableStats struct{}
+
+// TableRows the analyzed row count of table.
+func (tableStats) TableRows(tbl *schema.Table) (float64, bool) {
+	if tbl == nil {
+		return 0, false
+	}
+	ts, ok := tbl.Stats()
+	if !ok {
+		return 0, false
+	}
+	return float64(ts.Rows), true
+}
+
+// Selectivity of =, !=, IN, IS NULL, range and BETWEEN comparisons of a
+// column to literals, from the columns histogram or else its distinct
+// values and min, max.  And of AND of equalities on the columns of an
+// analyzed column group.
+func (tableStats) Selectivity(tbl *schema.Table, n expr.Node) (float64, bool) {
+	if tbl == nil {
+		return 0, false
+	}
+	ts, ok := tbl.Stats()
+	if !ok || ts.Rows == 0 {
+		return 0, false
+	}
+	switch n := n.(type) {
+	case *expr.TriNode:
+		return betweenSelectivity(ts, n)
+	case *expr.BooleanNode:
+		if !n.Negated() && isAnd(n.Operator.T) {
+			return conjunctionSelectivity(tbl, ts, n.Args)
+		}
+		return 0, false
+	}
+	bn, ok := n.(*expr.BinaryNode)
+	if !ok || len(bn.Args) != 2 {
+		return 0, false
+	}
+	op := bn.Operator.T
+	if isAnd(op) {
+		return conjunctionSelectivity(tbl, ts, bn.Args)
+	}
+	in, arg := bn.Args[0], bn.Args[1]
+	if _, ok := in.(*expr.IdentityNode); !ok && op != lex.TokenIN {
+		in, arg = arg, in
+		// 5 < x  is  x > 5
+		switch op {
+		case lex.TokenLT:
+			op = lex.TokenGT
+		case lex.TokenLE:
+			op = lex.TokenGE
+		case lex.TokenGT:
+			op = lex.TokenLT
+		case lex.TokenGE:
+			op = lex.TokenLE
+		}
+	}
+	cs := columnStats(ts, in)
+	if cs == nil {
+		return 0, false
+	}
+	nullFrac := float64(cs.Nulls) / float64(ts.Rows)
+	if _, isNull := arg.(*expr.NullNode); isNull {
+		switch op {
+		case lex.TokenEqual, lex.TokenEqualEqual:
+			return nullFrac, true
+		case lex.TokenNE:
+			return 1 - nullFrac, true
+		}
+		return 0, false
+	}
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return equalSelectivity(cs, nullFrac, arg), true
+	case lex.TokenNE:
+		return 1 - nullFrac - equalSelectivity(cs, nullFrac, arg), true
+	case lex.TokenIN:
+		arr, ok := arg.(*expr.ArrayNode)
+		if !ok {
+			return 0, false
+		}
+		sel := 0.0
+		for _, a := range arr.Args {
+			sel += equalSelectivity(cs, nullFrac, a)
+		}
+		return math.Min(sel, 1-nullFrac), true
+	case lex.TokenLT, lex.TokenLE:
+		if lit, ok := literalValue(arg); ok {
+			return rangeSelectivity(cs, nullFrac, nil, lit, false, op == lex.TokenLE)
+		}
+	case lex.TokenGT, lex.TokenGE:
+		if lit, ok := literalValue(arg); ok {
+			return rangeSelectivity(cs, nullFrac, lit, nil, op == lex.TokenGE, false)
+		}
+	}
+	return 0, false
+}
+
+// betweenSelectivity of  x [NOT] BETWEEN lower AND upper
+func betweenSelectivity(ts *schema.TableStats, n *expr.TriNode) (float64, bool) {
+	if n.Operator.T != lex.TokenBetween || len(n.Args) != 3 {
+		return 0, false
+	}
+	cs := columnStats(ts, n.Args[0])
+	if cs == nil {
+		return 0, false
+	}
+	lo, ok := literalValue(n.Args[1])
+	if !ok {
+		return 0, false
+	}
+	hi, ok := literalValue(n.Args[2])
+	if !ok {
+		return 0, false
+	}
+	nullFrac := float64(cs.Nulls) / float64(ts.Rows)
+	sel, ok := rangeSelectivity(cs, nullFrac, lo, hi, true, true)
+	if ok && n.Negated() {
+		sel = 1 - nullFrac - sel
+	}
+	return sel, ok
+}
+
+// conjunctionSelectivity of AND of predicates where equalities on all the
+// columns of a column group are among them, their combined selectivity is
+// estimated from the group statistics rather than assuming the columns
+// are independent (city = "Portland" AND state = "OR").  False if no group
+// applies.
+func conjunctionSelectivity(tbl *schema.Table, ts *schema.TableStats, args []expr.Node) (float64, bool) {
+	if len(ts.Groups) == 0 {
+		return 0, false
+	}
+	sel := 1.0
+	eq := make(map[string]float64)
+	for _, arg := range conjuncts(args) {
+		s := EstimateSelectivity(TableStatsEstimator, tbl, arg)
+		if col, ok := equalityColumn(arg); ok {
+			if _, dup := eq[col]; !dup {
+				eq[col] = s
+				continue
+			}
+		}
+		sel *= s
+	}
+	// the groups overlap, apply the ones correcting the independence
+	// assumption the most first
+	applied := false
+	for {
+		var best *schema.ColumnGroupStats
+		bestSel, bestRatio := 0.0, 0.0
+		for _, g := range ts.Groups {
+			if !groupCovered(g, eq) {
+				continue
+			}
+			gs := groupSelectivity(g, eq)
+			independent := 1.0
+			for _, col := range g.Columns {
+				independent *= eq[col]
+			}
+			if ratio := gs / independent; best == nil || ratio > bestRatio {
+				best, bestSel, bestRatio = g, gs, ratio
+			}
+		}
+		if best == nil {
+			break
+		}
+		sel *= bestSel
+		for _, col := range best.Columns {
+			delete(eq, col)
+		}
+		applied = true
+	}
+	if !applied {
+		return 0, false
+	}
+	for _, s := range eq {
+		sel *= s
+	}
+	return sel, true
+}
+
+// groupSelectivity of equalities on all the columns of group g, given
+// their selectivities each.  Each column determined by another (state by
+// city) only contributes the part of its selectivity not explained by the
+// dependency, never less than assuming independence nor more than the
+// most selective column.
+func groupSelectivity(g *schema.ColumnGroupStats, eq map[string]float64) float64 {
+	independent, most := 1.0, 1.0
+	factors := make(map[string]float64, len(g.Columns))
+	for _, col := range g.Columns {
+		factors[col] = eq[col]
+		independent *= eq[col]
+		most = math.Min(most, eq[col])
+	}
+	deps := append([]*schema.Dependency(nil), g.Dependencies...)
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].Degree > deps[j].Degree })
+	determined := make(map[string]bool)
+	for _, d := range deps {
+		if determined[d.To] || determined[d.From] {
+			continue
+		}
+		determined[d.To] = true
+		factors[d.To] = d.Degree + (1-d.Degree)*factors[d.To]
+	}
+	sel := 1.0
+	for _, f := range factors {
+		sel *= f
+	}
+	if g.Ndv > 0 {
+		sel = math.Max(sel, math.Min(most, 1/float64(g.Ndv)))
+	}
+	return math.Min(math.Max(sel, independent), most)
+}
+
+// groupCovered are there equalities on all the columns of group g.
+func groupCovered(g *schema.ColumnGroupStats, eq map[string]float64) bool {
+	if len(g.Columns) == 0 {
+		return false
+	}
+	for _, col := range g.Columns {
+		if _, ok := eq[col]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// conjuncts the predicates of nested ANDs.
+func conjuncts(args []expr.Node) []expr.Node {
+	all := make([]expr.Node, 0, len(args))
+	for _, arg := range args {
+		switch n := arg.(type) {
+		case *expr.BooleanNode:
+			if !n.Negated() && isAnd(n.Operator.T) {
+				all = append(all, conjuncts(n.Args)...)
+				continue
+			}
+		case *expr.BinaryNode:
+			if isAnd(n.Operator.T) {
+				all = append(all, conjuncts(n.Args)...)
+				continue
+			}
+		}
+		all = append(all, arg)
+	}
+	return all
+}
+
+// equalityColumn the lower case column of  column = literal  predicate n.
+func equalityColumn(n expr.Node) (string, bool) {
+	bn, ok := n.(*expr.BinaryNode)
+	if !ok || len(bn.Args) != 2 {
+		return "", false
+	}
+	switch bn.Operator.T {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+	default:
+		return "", false
+	}
+	in, arg := bn.Args[0], bn.Args[1]
+	if _, ok := in.(*expr.IdentityNode); !ok {
+		in, arg = arg, in
+	}
+	id, ok := in.(*expr.IdentityNode)
+	if !ok {
+		return "", false
+	}
+	if _, ok := literalValue(arg); !ok {
+		return "", false
+	}
+	_, col, _ := id.LeftRight()
+	return strings.ToLower(col), true
+}
+
+func isAnd(op lex.TokenType) bool {
+	return op == lex.TokenLogicAnd || op == lex.TokenAnd
+}
+
+// columnStats the statistics of the column of identity n.
+func columnStats(ts *schema.TableStats, n expr.Node) *schema.ColumnStats {
+	id, ok := n.(*expr.IdentityNode)
+	if !ok {
+		return nil
+	}
+	_, col, _ := id.LeftRight()
+	return ts.Column(col)
+}
+
+// rangeSelectivity fraction of rows whose column lies between lo and hi
+// (nil for no bound), summing the frequency of the histogram buckets in
+// the range, interpolating linearly within the ones partially in it.
+// Without a histogram the values are assumed uniform from min to max.
+func rangeSelectivity(cs *schema.ColumnStats, nullFrac float64, lo, hi value.Value, loInc, hiInc bool) (float64, bool) {
+	if cs.Ndv == 0 {
+		return 0, true
+	}
+	if cs.Histogram == nil {
+		if cs.Min == nil || cs.Max == nil {
+			return 0, false
+		}
+		min, max := value.NewValue(cs.Min), value.NewValue(cs.Max)
+		if !inRange(min, max, lo, hi, loInc, hiInc) {
+			return 0, true
+		}
+		frac, ok := spanFraction(min, max, lo, hi)
+		if !ok {
+			return 0, false
+		}
+		return frac * (1 - nullFrac), true
+	}
+	sel := 0.0
+	for _, b := range cs.Histogram.Buckets {
+		lower, upper := value.NewValue(b.Lower), value.NewValue(b.Upper)
+		if !inRange(lower, upper, lo, hi, loInc, hiInc) {
+			continue
+		}
+		aboveLo := lo == nil || compareLiterals(lower, lo) > 0 || (loInc && compareLiterals(lower, lo) == 0)
+		belowHi := hi == nil || compareLiterals(upper, hi) < 0 || (hiInc && compareLiterals(upper, hi) == 0)
+		if aboveLo && belowHi {
+			sel += b.Freq
+			continue
+		}
+		frac, ok := spanFraction(lower, upper, lo, hi)
+		if !ok {
+			frac = 0.5
+		}
+		sel += b.Freq * frac
+	}
+	return math.Min(sel, 1) * (1 - nullFrac), true
+}
+
+// inRange are any of the values from lower to upper between lo and hi.
+func inRange(lower, upper, lo, hi value.Value, loInc, hiInc bool) bool {
+	if lo != nil {
+		c := compareLiterals(upper, lo)
+		if c < 0 || (c == 0 && !loInc) {
+			return false
+		}
+	}
+	if hi != nil {
+		c := compareLiterals(lower, hi)
+		if c > 0 || (c == 0 && !hiInc) {
+			return false
+		}
+	}
+	return true
+}
+
+// spanFraction fraction of the span from lower to upper that lies between
+// lo and hi, false for values that are neither numbers nor times.
+func spanFraction(lower, upper, lo, hi value.Value) (float64, bool) {
+	l, ok := literalFloat(lower)
+	if !ok {
+		return 0, false
+	}
+	u, ok := literalFloat(upper)
+	if !ok {
+		return 0, false
+	}
+	if u <= l {
+		return 1, true
+	}
+	from, to := l, u
+	if lo != nil {
+		f, ok := literalFloat(lo)
+		if !ok {
+			return 0, false
+		}
+		from = math.Max(from, f)
+	}
+	if hi != nil {
+		f, ok := literalFloat(hi)
+		if !ok {
+			return 0, false
+		}
+		to = math.Min(to, f)
+	}
+	return clampSelectivity((to - from) / (u - l)), true
+}
+
+// literalFloat position of a number or time value on a line.
+func literalFloat(v value.Value) (float64, bool) {
+	if tv, ok := v.(value.TimeValue); ok {
+		return float64(tv.Val().UnixNano()), true
+	}
+	return value.ValueToFloat64(v)
+}
+
+// compareLiterals order of values, as times, numbers or else strings.
+func compareLiterals(l, r value.Value) int {
+	if lt, ok := l.(value.TimeValue); ok {
+		if rt, ok := value.ValueToTime(r); ok {
+			switch {
+			case lt.Val().Before(rt):
+				return -1
+			case lt.Val().After(rt):
+				return 1
+			}
+			return 0
+		}
+	}
+	if lf, ok := value.ValueToFloat64(l); ok {
+		if rf, ok := value.ValueToFloat64(r); ok {
+			switch {
+			case lf < rf:
+				return -1
+			case lf > rf:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(l.ToString(), r.ToString())
+}
+
+// equalSelectivity fraction of rows whose column equals literal n, the
+// frequency of its histogram bucket, or the non-null rows evenly divided
+// among the distinct values.
+func equalSelectivity(cs *schema.ColumnStats, nullFrac float64, n expr.Node) float64 {
+	if cs.Ndv == 0 {
+		return 0
+	}
+	if lit, ok := literalValue(n); ok && cs.Histogram != nil && cs.Histogram.Type == schema.HistogramSingleton {
+		for _, b := range cs.Histogram.Buckets {
+			if eq, _ := value.Equal(value.NewValue(b.Lower), lit); eq {
+				return b.Freq * (1 - nullFrac)
+			}
+		}
+	}
+	return (1 - nullFrac) / float64(cs.Ndv)
+}
+
+// literalValue the value of literal node n.
+func literalValue(n expr.Node) (value.Value, bool) {
+	switch n := n.(type) {
+	case *expr.StringNode:
+		return value.NewStringValue(n.Text), true
+	case *expr.NumberNode:
+		if n.IsInt {
+			return value.NewIntValue(n.Int64), true
+		}
+		return value.NewNumberValue(n.Float64), true
+	case *expr.ValueNode:
+		return n.Value, n.Value != nil
+	}
+	return nil, false
+}
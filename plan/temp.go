@@ -0,0 +1,129 @@
+package plan
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+// TempSource creates the source of temporary table name of cols holding
+// rows, keyed by its first column.  Set by the memdb datasource, which
+// must be imported for temporary tables.
+var TempSource func(name string, cols []string, rows [][]driver.Value) (schema.Source, error)
+
+// TempTables are the temporary tables of a session, created by
+//
+//    CREATE TEMPORARY TABLE t (id int, name varchar(255))
+//    SELECT user_id, count(*) AS ct INTO TEMP t FROM orders GROUP BY user_id
+//
+// Only the statements of the session, whose Context shares these
+// TempTables, see them.  They shadow schema tables of the same name, and
+// are dropped when the session (Context.Close) closes.
+type TempTables struct {
+	mu     sync.RWMutex
+	tables map[string]*tempTable // lower case name
+}
+
+type tempTable struct {
+	tbl *schema.Table
+	src schema.Source
+}
+
+// NewTempTables create an empty set of session temporary tables.
+func NewTempTables() *TempTables {
+	return &TempTables{tables: make(map[string]*tempTable)}
+}
+
+// Add temporary table tbl read from source src, an error if the session
+// already has a temporary table of that name.
+func (m *TempTables) Add(tbl *schema.Table, src schema.Source) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := strings.ToLower(tbl.Name)
+	if _, exists := m.tables[name]; exists {
+		return sqlerr.New(sqlerr.ErTableExists, "Table '%s' already exists", tbl.Name)
+	}
+	m.tables[name] = &tempTable{tbl: tbl, src: src}
+	return nil
+}
+
+// Table the temporary table name, false if there is none.
+func (m *TempTables) Table(name string) (*schema.Table, bool) {
+	if m == nil {
+		return nil, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tt, ok := m.tables[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return tt.tbl, true
+}
+
+// Names of the temporary tables, sorted.
+func (m *TempTables) Names() []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.tables))
+	for _, tt := range m.tables {
+		names = append(names, tt.tbl.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Drop temporary table name, closing its source, false if there is none.
+func (m *TempTables) Drop(name string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	tt, ok := m.tables[strings.ToLower(name)]
+	delete(m.tables, strings.ToLower(name))
+	m.mu.Unlock()
+	if ok {
+		tt.src.Close()
+	}
+	return ok
+}
+
+// Schema s plus the temporary tables, which shadow tables of s of the
+// same name, s if there are none.
+func (m *TempTables) Schema(s *schema.Schema) *schema.Schema {
+	if m == nil {
+		return s
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, tt := range m.tables {
+		if s == nil {
+			s = schema.NewSchemaTable("temp", tt.tbl, tt.src)
+			continue
+		}
+		s = s.WithTable(tt.tbl, tt.src)
+	}
+	return s
+}
+
+// Close drop all the temporary tables.
+func (m *TempTables) Close() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	tables := m.tables
+	m.tables = make(map[string]*tempTable)
+	m.mu.Unlock()
+	for _, tt := range tables {
+		tt.src.Close()
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+// Visitor visits the tasks of a plan dag depth first, see VisitTasks.
+type Visitor interface {
+	// VisitTask called for each task before its children, depth of the
+	// root is 0.  Return false to not visit the children of t.
+	VisitTask(t Task, depth int) bool
+	// LeaveTask called for each task after its children.
+	LeaveTask(t Task, depth int)
+}
+
+// VisitTasks walk the dag of tasks rooted at t with the visitor.
+func VisitTasks(t Task, v Visitor) {
+	visitTask(t, 0, v)
+}
+func visitTask(t Task, depth int, v Visitor) {
+	if t == nil {
+		return
+	}
+	if v.VisitTask(t, depth) {
+		for _, c := range TaskInputs(t) {
+			visitTask(c, depth+1, v)
+		}
+	}
+	v.LeaveTask(t, depth)
+}
+
+// TaskInputs the child tasks of t, for a JoinMerge its left and right
+// inputs followed by any children, for a Scatter its shards.
+func TaskInputs(t Task) []Task {
+	if sc, ok := t.(*Scatter); ok {
+		inputs := make([]Task, 0, len(sc.Shards)+len(sc.Children()))
+		for _, shard := range sc.Shards {
+			inputs = append(inputs, shard)
+		}
+		return append(inputs, sc.Children()...)
+	}
+	if jm, ok := t.(*JoinMerge); ok {
+		inputs := make([]Task, 0, 2+len(jm.Children()))
+		for _, in := range []Task{jm.Left, jm.Right} {
+			if in != nil {
+				inputs = append(inputs, in)
+			}
+		}
+		return append(inputs, jm.Children()...)
+	}
+	return t.Children()
+}
+
+// PlanNode structured description of a task of a physical plan, for
+// rendering plans in a UI and diffing them in tests.
+type PlanNode struct {
+	Id         int               `json:"id"`
+	Op         string            `json:"op"`
+	Source     string            `json:"source,omitempty"`
+	Exprs      []string          `json:"exprs,omitempty"`
+	Partitions []string          `json:"partitions,omitempty"`
+	Props      map[string]string `json:"props,omitempty"`
+	Children   []*PlanNode       `json:"children,omitempty"`
+}
+
+func (m *PlanNode) prop(key, val string) {
+	if m.Props == nil {
+		m.Props = make(map[string]string)
+	}
+	m.Props[key] = val
+}
+
+// DescribeTask the operator, source, expressions and properties of a
+// single task, without its children.
+func DescribeTask(t Task) *PlanNode {
+	n := &PlanNode{}
+	switch p := t.(type) {
+	case *Select:
+		n.Op = "select"
+		if p.Stmt != nil {
+			names := make([]string, 0, len(p.Stmt.From))
+			for _, from := range p.Stmt.From {
+				names = append(names, from.SourceName())
+			}
+			n.Source = strings.Join(names, ", ")
+			if len(p.Stmt.Hints) > 0 {
+				n.prop("hints", p.Stmt.Hints.String())
+			}
+		}
+	case *Source:
+		n.Op = "scan"
+		if p.Stmt != nil {
+			n.Source = p.Stmt.SourceName()
+			if p.Stmt.Sample != nil {
+				n.prop("sample", p.Stmt.Sample.String())
+			}
+		}
+		if len(p.Static) > 0 {
+			n.prop("static", "true")
+			break
+		}
+		if p.Conn != nil {
+			n.prop("conn", fmt.Sprintf("%T", p.Conn))
+		}
+		if p.Shard != nil {
+			n.prop("shard", p.Shard.Name)
+		} else if _, ok := p.Conn.(SourcePlanner); ok && !p.Hints.HasTable(rel.HintNoPushdown, p.Stmt) {
+			n.prop("pushdown", "true")
+		}
+		if p.PartialAgg {
+			n.prop("partial_agg", "true")
+		}
+		n.prop("rows", strconv.FormatFloat(p.EstimatedRows, 'f', 0, 64))
+		n.prop("cost", strconv.FormatFloat(p.Cost, 'f', 2, 64))
+		if p.Tbl != nil && p.Tbl.Partition != nil {
+			for _, part := range p.Tbl.Partition.Partitions {
+				desc := part.Id
+				if part.Left != "" || part.Right != "" {
+					desc = fmt.Sprintf("%s [%s, %s)", part.Id, part.Left, part.Right)
+				}
+				n.Partitions = append(n.Partitions, desc)
+			}
+		} else if p.Tbl != nil && p.Tbl.PartitionCt > 0 {
+			n.prop("partitions", strconv.Itoa(int(p.Tbl.PartitionCt)))
+		}
+	case *Where:
+		n.Op = "where"
+		if p.Stmt != nil && p.Stmt.Where != nil {
+			n.Exprs = []string{p.Stmt.Where.String()}
+		}
+		if p.Final {
+			n.prop("final", "true")
+		}
+		if p.Workers > 1 {
+			n.prop("workers", strconv.Itoa(p.Workers))
+		}
+	case *Having:
+		n.Op = "having"
+		if p.Stmt != nil && p.Stmt.Having != nil {
+			n.Exprs = []string{p.Stmt.Having.String()}
+		}
+	case *GroupBy:
+		n.Op = "group by"
+		if p.Stmt != nil {
+			n.Exprs = columnStrings(p.Stmt.GroupBy)
+		}
+		if p.Partial {
+			n.prop("partial", "true")
+		}
+		if p.Merge {
+			n.prop("merge", "true")
+		}
+	case *Order:
+		n.Op = "order by"
+		if p.Stmt != nil {
+			n.Exprs = columnStrings(p.Stmt.OrderBy)
+		}
+		if p.Limit > 0 {
+			n.prop("top", strconv.Itoa(p.Limit))
+		}
+	case *Projection:
+		n.Op = "projection"
+		if p.Stmt != nil {
+			n.Exprs = columnStrings(p.Stmt.Columns)
+		}
+		if p.Final {
+			n.prop("final", "true")
+		}
+	case *JoinMerge:
+		n.Op = "join"
+		names := make([]string, 0, 2)
+		for _, from := range []*rel.SqlSource{p.LeftFrom, p.RightFrom} {
+			if from == nil {
+				continue
+			}
+			names = append(names, from.SourceName())
+			if from.JoinExpr != nil {
+				n.Exprs = append(n.Exprs, from.JoinExpr.String())
+			}
+		}
+		n.Source = strings.Join(names, ", ")
+		switch {
+		case p.Lookup != nil:
+			n.prop("strategy", "lookup")
+			n.prop("index", p.Lookup.Index)
+		case p.Similarity != nil:
+			n.prop("strategy", "similarity")
+		default:
+			n.prop("strategy", "key")
+		}
+	case *Scatter:
+		n.Op = "scatter"
+		if p.Stmt != nil && len(p.Stmt.From) == 1 {
+			n.Source = p.Stmt.From[0].SourceName()
+		}
+		n.prop("shards", strconv.Itoa(len(p.Shards)))
+	case *JoinKey:
+		n.Op = "join key"
+		if p.Source != nil && p.Source.Stmt != nil {
+			n.Source = p.Source.Stmt.SourceName()
+			for _, jn := range p.Source.Stmt.JoinNodes() {
+				n.Exprs = append(n.Exprs, jn.String())
+			}
+		}
+	case *Into:
+		n.Op = "into"
+		if p.Stmt != nil {
+			n.Source = p.Stmt.Table
+		}
+	case *Insert:
+		n.Op, n.Source = "insert", p.Stmt.Table
+	case *Upsert:
+		n.Op, n.Source = "upsert", p.Stmt.Table
+	case *Update:
+		n.Op, n.Source = "update", p.Stmt.Table
+		if p.Stmt.Where != nil {
+			n.Exprs = []string{p.Stmt.Where.String()}
+		}
+	case *Delete:
+		n.Op, n.Source = "delete", p.Stmt.Table
+		if p.Stmt.Where != nil {
+			n.Exprs = []string{p.Stmt.Where.String()}
+		}
+	default:
+		n.Op = strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", t), "*plan."))
+	}
+	if t.IsParallel() {
+		n.prop("parallel", "true")
+	}
+	return n
+}
+
+func columnStrings(cols rel.Columns) []string {
+	exprs := make([]string, 0, len(cols))
+	for _, col := range cols {
+		exprs = append(exprs, col.String())
+	}
+	return exprs
+}
+
+// JsonVisitor builds the PlanNode tree of a plan, nodes are numbered in
+// the order visited.
+type JsonVisitor struct {
+	Root  *PlanNode
+	ct    int
+	stack []*PlanNode
+}
+
+// NewJsonVisitor create a visitor building the PlanNode tree.
+func NewJsonVisitor() *JsonVisitor {
+	return &JsonVisitor{}
+}
+func (m *JsonVisitor) VisitTask(t Task, depth int) bool {
+	m.ct++
+	n := DescribeTask(t)
+	n.Id = m.ct
+	if len(m.stack) == 0 {
+		m.Root = n
+	} else {
+		parent := m.stack[len(m.stack)-1]
+		parent.Children = append(parent.Children, n)
+	}
+	m.stack = append(m.stack, n)
+	return true
+}
+func (m *JsonVisitor) LeaveTask(t Task, depth int) {
+	m.stack = m.stack[:len(m.stack)-1]
+}
+
+// PlanTree the PlanNode tree of the plan rooted at t.
+func PlanTree(t Task) *PlanNode {
+	v := NewJsonVisitor()
+	VisitTasks(t, v)
+	return v.Root
+}
+
+// PlanJson the plan rooted at t as indented json of its PlanNode tree.
+func PlanJson(t Task) ([]byte, error) {
+	return json.MarshalIndent(PlanTree(t), "", "  ")
+}
+
+// DotVisitor writes the plan as a Graphviz DOT digraph, an edge from each
+// task to its inputs.
+//
+//    digraph plan {
+//      node [shape=box];
+//      n1 [label="select\nusers"];
+//      n2 [label="scan\nusers\nrows=3"];
+//      n1 -> n2;
+//    }
+//
+type DotVisitor struct {
+	buf   bytes.Buffer
+	ct    int
+	stack []int
+}
+
+// NewDotVisitor create a visitor writing DOT.
+func NewDotVisitor() *DotVisitor {
+	m := &DotVisitor{}
+	m.buf.WriteString("digraph plan {\n  node [shape=box];\n")
+	return m
+}
+func (m *DotVisitor) VisitTask(t Task, depth int) bool {
+	m.ct++
+	id := m.ct
+	n := DescribeTask(t)
+	lines := []string{n.Op}
+	if n.Source != "" {
+		lines = append(lines, n.Source)
+	}
+	lines = append(lines, n.Exprs...)
+	if len(n.Partitions) > 0 {
+		lines = append(lines, "partitions: "+strings.Join(n.Partitions, ", "))
+	}
+	keys := make([]string, 0, len(n.Props))
+	for k := range n.Props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+"="+n.Props[k])
+	}
+	for i, line := range lines {
+		lines[i] = dotEscape(line)
+	}
+	fmt.Fprintf(&m.buf, "  n%d [label=\"%s\"];\n", id, strings.Join(lines, `\n`))
+	if len(m.stack) > 0 {
+		fmt.Fprintf(&m.buf, "  n%d -> n%d;\n", m.stack[len(m.stack)-1], id)
+	}
+	m.stack = append(m.stack, id)
+	return true
+}
+func (m *DotVisitor) LeaveTask(t Task, depth int) {
+	m.stack = m.stack[:len(m.stack)-1]
+}
+
+// String the DOT digraph of the tasks visited.
+func (m *DotVisitor) String() string {
+	return m.buf.String() + "}\n"
+}
+
+func dotEscape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
+
+// PlanDot the plan rooted at t as a Graphviz DOT digraph.
+func PlanDot(t Task) string {
+	v := NewDotVisitor()
+	VisitTasks(t, v)
+	return v.String()
+}
@@ -0,0 +1,73 @@
+package plan_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/plan"
+)
+
+type opVisitor struct {
+	ops []string
+}
+
+func (m *opVisitor) VisitTask(t plan.Task, depth int) bool {
+	m.ops = append(m.ops, strings.Repeat(" ", depth)+plan.DescribeTask(t).Op)
+	return true
+}
+func (m *opVisitor) LeaveTask(t plan.Task, depth int) {}
+
+func TestPlanVisualize(t *testing.T) {
+	ctx := td.TestContext(`SELECT u.user_id, o.item_id FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id WHERE o.price > 10`)
+	p := selectPlan(t, ctx)
+
+	v := &opVisitor{}
+	plan.VisitTasks(p, v)
+	assert.True(t, len(v.ops) > 3, "ops %v", v.ops)
+	assert.Equal(t, "select", v.ops[0])
+	assert.Contains(t, v.ops, " join")
+	assert.Contains(t, v.ops, "  scan")
+
+	tree := plan.PlanTree(p)
+	assert.Equal(t, 1, tree.Id)
+	assert.Equal(t, "select", tree.Op)
+	assert.Equal(t, "users, orders", tree.Source)
+
+	by, err := plan.PlanJson(p)
+	assert.Equal(t, nil, err)
+	var decoded plan.PlanNode
+	assert.Equal(t, nil, json.Unmarshal(by, &decoded))
+	assert.Equal(t, tree, &decoded)
+
+	var join *plan.PlanNode
+	var walk func(n *plan.PlanNode)
+	walk = func(n *plan.PlanNode) {
+		if n.Op == "join" {
+			join = n
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(tree)
+	assert.NotEqual(t, (*plan.PlanNode)(nil), join)
+	assert.Equal(t, 2, len(join.Children))
+	assert.Equal(t, "scan", join.Children[0].Op)
+	assert.NotEqual(t, "", join.Props["strategy"])
+
+	dot := plan.PlanDot(p)
+	assert.True(t, strings.HasPrefix(dot, "digraph plan {\n"), dot)
+	assert.True(t, strings.HasSuffix(dot, "}\n"), dot)
+	assert.Contains(t, dot, `n1 [label="select\nusers, orders`)
+	assert.Contains(t, dot, "n1 -> n2;")
+	assert.Equal(t, len(v.ops)-1, strings.Count(dot, " -> "))
+
+	// a plan with no children still renders
+	ctx = td.TestContext(`SELECT 1`)
+	tree = plan.PlanTree(planStmt(t, ctx))
+	assert.Equal(t, 1, tree.Id)
+}
@@ -0,0 +1,39 @@
+//go:build go1.18
+// +build go1.18
+
+package rel_test
+
+import (
+	"testing"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+// go test -run=^$ -fuzz=FuzzParseSql ./rel
+func FuzzParseSql(f *testing.F) {
+	for _, sql := range []string{
+		`SELECT a, b FROM t WHERE a > 1 ORDER BY b ASC LIMIT 10`,
+		`SELECT DISTINCT a, count(*) AS ct FROM t GROUP BY a HAVING ct > 2`,
+		`SELECT t1.x FROM t1 INNER JOIN t2 ON t1.id = t2.id ORDER BY t1.x DESC`,
+		`SELECT a FROM (SELECT a FROM t WHERE b = "c") AS sub`,
+		`INSERT INTO t (a, b) VALUES ("x", 1), ("y", 2)`,
+		`UPDATE t SET a = "b" WHERE id IN (1, 2, 3)`,
+		`DELETE FROM t WHERE a IS NULL`,
+		`SHOW TABLES`,
+		`SELECT FROM`,
+		`SELECT a FROM t ORDER BY`,
+		`SELECT AS A`,
+		`SELECT 1%`,
+		`INSERT INTO A( )VALUES()`,
+	} {
+		f.Add(sql)
+	}
+	f.Fuzz(func(t *testing.T, sql string) {
+		stmt, err := rel.ParseSql(sql)
+		if err != nil {
+			return
+		}
+		// a parsed statement must be writable
+		_ = stmt.String()
+	})
+}
@@ -0,0 +1,159 @@
+package rel
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/qlbridge/lex"
+)
+
+// NormalizeSql lex the sql statement replacing literal values (strings,
+// numbers) with ? placeholders returning the normalized text and the
+// extracted literals, in order.  Statements that differ only in their
+// literals, whitespace, keyword case or comments normalize to the same
+// text, so it may be used to aggregate query logs or key plan caches.
+// As it only lexes the statement it works on sql the parser rejects.
+//
+//	SELECT name FROM users   WHERE id = 12 and email = 'bob@email.com' -- find bob
+//	=> SELECT name FROM users WHERE id = ? AND email = ?    [12, "bob@email.com"]
+func NormalizeSql(sql string) (string, []driver.Value, error) {
+
+	l := lex.NewSqlLexer(sql)
+	var toks []lex.Token
+	var texts []string
+	var spaced []bool
+	end := 0
+	for {
+		tok := l.NextToken()
+		if tok.T == lex.TokenError {
+			return "", nil, fmt.Errorf("QLBridge.NormalizeSql: %s", tok.V)
+		}
+		if tok.T == lex.TokenEOF || tok.T == lex.TokenEOS {
+			break
+		}
+		// tokens Pos is their end, text from previous token end is
+		// whitespace then the tokens original text
+		start, pos := end, tok.Pos
+		if pos < start || pos > len(sql) {
+			pos = start
+		}
+		end = pos
+		raw := sql[start:pos]
+		if n := len(toks); n > 0 && toks[n-1].Quote != 0 {
+			// the lexer may emit quoted tokens before consuming the closing quote
+			raw = strings.TrimPrefix(raw, closeQuote(toks[n-1].Quote))
+		}
+		text := strings.TrimLeft(raw, " \t\r\n")
+		switch tok.T {
+		case lex.TokenCommentSingleLine, lex.TokenComment, lex.TokenCommentML,
+			lex.TokenCommentHash, lex.TokenCommentSlashes, lex.TokenCommentStart, lex.TokenCommentEnd,
+			lex.TokenOptimizerHint:
+			continue
+		}
+		if tok.Quote != 0 {
+			text = string(tok.Quote) + tok.V + closeQuote(tok.Quote)
+		} else if text == "" {
+			text = tok.V
+		}
+		toks = append(toks, tok)
+		texts = append(texts, text)
+		spaced = append(spaced, len(text) < len(raw))
+	}
+
+	var params []driver.Value
+	w := &strings.Builder{}
+	write := func(i int, text string) {
+		if w.Len() > 0 {
+			switch {
+			case text == "," || text == ")":
+			case text == "(":
+				// function calls keep their paren adjacent
+				if spaced[i] {
+					w.WriteByte(' ')
+				}
+			case strings.HasSuffix(w.String(), "("):
+			default:
+				w.WriteByte(' ')
+			}
+		}
+		w.WriteString(text)
+	}
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		switch tok.T {
+		case lex.TokenValue, lex.TokenValueEscaped, lex.TokenInteger, lex.TokenFloat:
+			params = append(params, literalValue(tok, false))
+			write(i, "?")
+			continue
+		case lex.TokenMinus:
+			// unary minus of a number literal
+			if i+1 < len(toks) && isNumberToken(toks[i+1].T) && (i == 0 || !isOperandToken(toks[i-1].T)) {
+				params = append(params, literalValue(toks[i+1], true))
+				write(i, "?")
+				i++
+				continue
+			}
+		}
+		text := texts[i]
+		if isKeyword(tok, text) {
+			text = strings.ToUpper(text)
+		}
+		write(i, text)
+	}
+	return w.String(), params, nil
+}
+
+func closeQuote(q byte) string {
+	if q == '[' {
+		return "]"
+	}
+	return string(q)
+}
+
+func isNumberToken(t lex.TokenType) bool {
+	return t == lex.TokenInteger || t == lex.TokenFloat
+}
+
+// isOperandToken a token a following minus would be subtracted from.
+func isOperandToken(t lex.TokenType) bool {
+	switch t {
+	case lex.TokenIdentity, lex.TokenValue, lex.TokenValueEscaped, lex.TokenInteger, lex.TokenFloat,
+		lex.TokenRightParenthesis:
+		return true
+	}
+	return false
+}
+
+func isKeyword(tok lex.Token, text string) bool {
+	if tok.Quote != 0 || tok.T == lex.TokenIdentity {
+		return false
+	}
+	kw := tok.T.String()
+	return strings.EqualFold(strings.Join(strings.Fields(text), " "), kw)
+}
+
+func literalValue(tok lex.Token, negate bool) driver.Value {
+	v := tok.V
+	if negate {
+		v = "-" + v
+	}
+	switch tok.T {
+	case lex.TokenInteger:
+		if iv, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return iv
+		}
+		if fv, err := strconv.ParseFloat(v, 64); err == nil {
+			return fv
+		}
+	case lex.TokenFloat:
+		if fv, err := strconv.ParseFloat(v, 64); err == nil {
+			return fv
+		}
+	case lex.TokenValueEscaped:
+		q := string(tok.Quote)
+		return strings.NewReplacer(q+q, q, `\`+q, q, `\\`, `\`).Replace(v)
+	}
+	return v
+}
@@ -0,0 +1,50 @@
+package rel_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/rel"
+)
+
+func TestNormalizeSql(t *testing.T) {
+	for _, tc := range []struct {
+		sql    string
+		norm   string
+		params []driver.Value
+	}{
+		{
+			"SELECT name FROM users   WHERE id = 12 and email = 'bob@email.com' -- find bob",
+			"SELECT name FROM users WHERE id = ? AND email = ?",
+			[]driver.Value{int64(12), "bob@email.com"},
+		},
+		{
+			"select count(*), `first name` from t where x='it''s' AND y > -5.5 AND z IN (1,2) LIMIT 10",
+			"SELECT count(*), `first name` FROM t WHERE x = ? AND y > ? AND z IN (?, ?) LIMIT ?",
+			[]driver.Value{"it's", -5.5, int64(1), int64(2), int64(10)},
+		},
+		{
+			"INSERT INTO t (a,b) VALUES (1, \"x\"), (2, 'y');",
+			"INSERT INTO t (a, b) VALUES (?, ?), (?, ?)",
+			[]driver.Value{int64(1), "x", int64(2), "y"},
+		},
+		{
+			// parser rejects this, still normalizes
+			"SELECT FROM users WHERE a - 3 > 1",
+			"SELECT FROM users WHERE a - ? > ?",
+			[]driver.Value{int64(3), int64(1)},
+		},
+	} {
+		norm, params, err := rel.NormalizeSql(tc.sql)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tc.norm, norm)
+		assert.Equal(t, tc.params, params, tc.sql)
+	}
+
+	// differing literals, spacing and case share normalized text
+	n1, _, _ := rel.NormalizeSql(`SELECT a FROM t WHERE b = 1`)
+	n2, _, _ := rel.NormalizeSql("select a\n from t\twhere b=\"two\"")
+	assert.Equal(t, n1, n2)
+}
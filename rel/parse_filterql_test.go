@@ -29,6 +29,10 @@ var FilterTests = []string{
 	`FILTER AND ( NOT INCLUDE abcd, (lastvisit_ts > "now-1M") ) FROM user`,
 	`FILTER COMPANY IN ("Toys R"" Us", "Toys R' Us, Inc.")`,
 	`FILTER *`,
+	`FILTER lastvisit_ts IN LAST 7 DAYS`,
+	`FILTER AND ( renewal_date IN NEXT 1 MONTH, score BETWEEN 5 AND 10 )`,
+	`FILTER orders IN (AND ( total > 100, status == "paid" ))`,
+	`FILTER AND ( EXISTS email, orders IN (OR ( status == "refunded" )) )`,
 	`
 	FILTER AND (
         a IN ("Analyst")
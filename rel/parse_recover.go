@@ -0,0 +1,297 @@
+package rel
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+// SyntaxErrors every syntax error ParseSqlRecover found in a sql text, in
+// order of position.
+type SyntaxErrors struct {
+	Sql    string
+	Errors []*sqlerr.Error
+}
+
+// Error each error with its line, column and caret annotated snippet.
+func (m *SyntaxErrors) Error() string {
+	var buf bytes.Buffer
+	for i, e := range m.Errors {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "line %d column %d: %s", e.Line, e.Column, e.Message)
+		if snip := e.Snippet(m.Sql); snip != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(snip)
+		}
+	}
+	return buf.String()
+}
+
+// Unwrap the errors, so sqlerr.As finds the first.
+func (m *SyntaxErrors) Unwrap() []error {
+	errs := make([]error, len(m.Errors))
+	for i, e := range m.Errors {
+		errs[i] = e
+	}
+	return errs
+}
+
+// ParseSqlRecover parse all statements of sqlQuery as ParseSqlStatements,
+// but rather than stop at the first syntax error, it synchronizes at the
+// next statement (;) or clause (WHERE, GROUP BY, HAVING, ORDER BY, LIMIT ...)
+// and keeps parsing, so every error is reported at once.  Returns the
+// statements that parsed without error, and a *SyntaxErrors if any failed.
+//
+//     stmts, err := rel.ParseSqlRecover("SELECT a,, b FROM t WHERE x = = 1; SELEC 1")
+//     // err has 3 errors, stmts is empty
+//
+func ParseSqlRecover(sqlQuery string) ([]SqlStatement, error) {
+	errs := &SyntaxErrors{Sql: sqlQuery}
+	stmts := make([]SqlStatement, 0)
+	for _, span := range splitStatementSpans(sqlQuery) {
+		// blank out the text before this statement, so positions are
+		// of the whole sqlQuery
+		src := []byte(sqlQuery[:span[1]])
+		blankSql(src, 0, span[0])
+		stmt, serrs := parseRecoverStatement(src, span[0])
+		if len(serrs) > 0 {
+			errs.Errors = append(errs.Errors, serrs...)
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	if len(errs.Errors) > 0 {
+		return stmts, errs
+	}
+	return stmts, nil
+}
+
+// parseRecoverStatement parse the statement starting at start, blanking
+// each clause with an error and re-parsing until it parses, or the error
+// can not be isolated to a clause.
+func parseRecoverStatement(src []byte, start int) (SqlStatement, []*sqlerr.Error) {
+	clauses := sqlClauses(src, start)
+	blanked := make(map[int]bool)
+	var errs []*sqlerr.Error
+	for {
+		stmt, err := ParseSql(string(src))
+		if err == nil {
+			if len(errs) == 0 {
+				return stmt, nil
+			}
+			break
+		}
+		e := syntaxError(err, src, start)
+		errs = append(errs, e)
+		ci := clauseAt(clauses, e.Pos)
+		if ci < 0 || blanked[ci] || !blankClause(src, clauses, ci) {
+			break
+		}
+		blanked[ci] = true
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Pos < errs[j].Pos })
+	return nil, errs
+}
+
+// syntaxError the positioned error of err, errors without a position are
+// at the start of the statement.
+func syntaxError(err error, src []byte, start int) *sqlerr.Error {
+	e, ok := sqlerr.As(err)
+	if !ok {
+		e = sqlerr.Wrap(sqlerr.ErParse, err)
+	}
+	if e.Line > 0 {
+		return e
+	}
+	// copy, do not position the shared error vars
+	pe := *e
+	for start < len(src) && (src[start] == ' ' || src[start] == '\t' || src[start] == '\n' || src[start] == '\r') {
+		start++
+	}
+	line, col := 1, 1
+	for _, c := range src[:start] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return pe.At(line, col, start)
+}
+
+// sqlClause a top level clause of a statement, its keyword and position.
+type sqlClause struct {
+	kw    string
+	start int
+	end   int
+}
+
+// clauseKeywords keywords that start a clause errors may be isolated to.
+var clauseKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "group": true, "having": true,
+	"order": true, "limit": true, "offset": true, "with": true,
+}
+
+// sqlClauses the top level (not in parens, quotes) clauses of the statement
+// in src from start.
+func sqlClauses(src []byte, start int) []sqlClause {
+	// unclosed parens are a typo, not a nesting, ignore them
+	var open []int
+	matched := make(map[int]bool)
+	scanSql(src, start, func(i int, c byte) int {
+		switch c {
+		case '(':
+			open = append(open, i)
+		case ')':
+			if len(open) > 0 {
+				matched[open[len(open)-1]] = true
+				matched[i] = true
+				open = open[:len(open)-1]
+			}
+		}
+		return i + 1
+	})
+	var clauses []sqlClause
+	depth := 0
+	scanSql(src, start, func(i int, c byte) int {
+		switch {
+		case (c == '(' || c == ')') && !matched[i]:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case isWordByte(c):
+			end := i
+			for end < len(src) && (isWordByte(src[end]) || src[end] == '.') {
+				end++
+			}
+			if i > 0 && (isWordByte(src[i-1]) || src[i-1] == '.' || src[i-1] == '@') {
+				return end
+			}
+			kw := strings.ToLower(string(src[i:end]))
+			if depth == 0 && clauseKeywords[kw] {
+				if len(clauses) > 0 {
+					clauses[len(clauses)-1].end = i
+				}
+				clauses = append(clauses, sqlClause{kw: kw, start: i, end: len(src)})
+			}
+			return end
+		}
+		return i + 1
+	})
+	return clauses
+}
+
+// clauseAt the index of the clause with the error at pos, an error on a
+// clause keyword is the previous clause ending too soon.
+func clauseAt(clauses []sqlClause, pos int) int {
+	for i := len(clauses) - 1; i >= 0; i-- {
+		c := clauses[i]
+		if pos >= c.start && pos < c.start+len(c.kw) && i > 0 {
+			return i - 1
+		}
+		if pos >= c.start {
+			return i
+		}
+	}
+	return -1
+}
+
+// blankClause remove the clause from src, false if the statement can not
+// be parsed without it.
+func blankClause(src []byte, clauses []sqlClause, ci int) bool {
+	c := clauses[ci]
+	switch c.kw {
+	case "select":
+		// keep SELECT, with columns *
+		listStart := c.start + len("select")
+		blankSql(src, listStart, c.end)
+		for i := listStart + 1; i < c.end; i++ {
+			if src[i] == ' ' {
+				src[i] = '*'
+				return true
+			}
+		}
+		return false
+	case "from":
+		return false
+	}
+	blankSql(src, c.start, c.end)
+	return true
+}
+
+// blankSql replace src[start:end] with spaces, keeping newlines so lines
+// and columns after it are unchanged.
+func blankSql(src []byte, start, end int) {
+	if end > len(src) {
+		end = len(src)
+	}
+	for i := start; i < end; i++ {
+		if src[i] != '\n' {
+			src[i] = ' '
+		}
+	}
+}
+
+// splitStatementSpans the [start, end) of each ; separated statement of
+// sql, skipping empty ones.
+func splitStatementSpans(sql string) [][2]int {
+	src := []byte(sql)
+	var spans [][2]int
+	start := 0
+	add := func(end int) {
+		if strings.TrimSpace(sql[start:end]) != "" {
+			spans = append(spans, [2]int{start, end})
+		}
+	}
+	scanSql(src, 0, func(i int, c byte) int {
+		if c == ';' {
+			add(i)
+			start = i + 1
+		}
+		return i + 1
+	})
+	add(len(sql))
+	return spans
+}
+
+// scanSql call fn with each byte of src from start that is not in a quoted
+// string, identity or comment, fn returns the position to continue at.
+func scanSql(src []byte, start int, fn func(i int, c byte) int) {
+	for i := start; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' && c != '`' {
+					i++
+				}
+				i++
+			}
+			i++
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return
+			}
+			i += end + 4
+		default:
+			i = fn(i, c)
+		}
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -24,13 +24,26 @@ type ParseError struct {
 	error
 }
 
+// Unwrap the underlying parse error.
+func (m *ParseError) Unwrap() error { return m.error }
+
 // ParseSql Parses SqlStatement and returns a statement or error
 // does not parse more than one statement
 func ParseSql(sqlQuery string) (SqlStatement, error) {
 	return parseSqlResolver(sqlQuery, nil)
 }
+
+// ParseSqlMode Parses SqlStatement following the quirks of a sql dialect,
+// ie the double-quoted identities and || concatenation of lex.ModePostgres,
+// nil mode is lex.ModeMySql.
+func ParseSqlMode(sqlQuery string, mode *lex.DialectMode) (SqlStatement, error) {
+	return parseSqlModeResolver(sqlQuery, mode, nil)
+}
 func parseSqlResolver(sqlQuery string, fr expr.FuncResolver) (SqlStatement, error) {
-	l := lex.NewSqlLexer(sqlQuery)
+	return parseSqlModeResolver(sqlQuery, nil, fr)
+}
+func parseSqlModeResolver(sqlQuery string, mode *lex.DialectMode, fr expr.FuncResolver) (SqlStatement, error) {
+	l := lex.NewSqlLexerMode(sqlQuery, mode)
 	m := Sqlbridge{l: l, SqlTokenPager: NewSqlTokenPager(l), funcs: fr}
 	s, err := m.parse()
 	if err != nil {
@@ -67,23 +80,42 @@ func ParseSqlSelectResolver(sqlQuery string, fr expr.FuncResolver) (*SqlSelect,
 
 // ParseSqlStatements into array of SQL Statements
 func ParseSqlStatements(sqlQuery string) ([]SqlStatement, error) {
-	l := lex.NewSqlLexer(sqlQuery)
+	_, stmts, err := SplitSqlStatements(sqlQuery)
+	return stmts, err
+}
+
+// SplitSqlStatements parses a multi-statement sql string same as
+// ParseSqlStatements, but also returns the raw sql text of each statement.
+func SplitSqlStatements(sqlQuery string) ([]string, []SqlStatement, error) {
+	return SplitSqlStatementsMode(sqlQuery, nil)
+}
+
+// SplitSqlStatementsMode same as SplitSqlStatements following the quirks
+// of a sql dialect, see ParseSqlMode.  The raw sql of statements has its
+// identities back-tick quoted.
+func SplitSqlStatementsMode(sqlQuery string, mode *lex.DialectMode) ([]string, []SqlStatement, error) {
+	l := lex.NewSqlLexerMode(sqlQuery, mode)
 	m := Sqlbridge{l: l, SqlTokenPager: NewSqlTokenPager(l)}
+	raws := make([]string, 0)
 	stmts := make([]SqlStatement, 0)
+	remaining := l.RawInput()
 	for {
 		stmt, err := m.parse()
 		if err != nil {
-			return nil, &ParseError{err}
+			return nil, nil, &ParseError{err}
 		}
 		stmts = append(stmts, stmt)
 		sqlRemaining, hasMore := l.Remainder()
+		raw := remaining[:len(remaining)-len(sqlRemaining)]
+		raws = append(raws, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(raw), ";")))
 		if !hasMore {
 			break
 		}
-		l = lex.NewSqlLexer(sqlRemaining)
+		remaining = sqlRemaining
+		l = lex.NewSqlLexerMode(sqlRemaining, mode)
 		m = Sqlbridge{l: l, SqlTokenPager: NewSqlTokenPager(l)}
 	}
-	return stmts, nil
+	return raws, stmts, nil
 }
 
 // Sqlbridge generic SQL parser evaluates should be sufficient for most
@@ -121,12 +153,16 @@ func (m *Sqlbridge) parse() (SqlStatement, error) {
 		return m.parseCommand()
 	case lex.TokenRollback, lex.TokenCommit:
 		return m.parseTransaction()
+	case lex.TokenCall:
+		return m.parseCall()
+	case lex.TokenAnalyze:
+		return m.parseAnalyze()
 	case lex.TokenCreate:
 		return m.parseCreate()
 	case lex.TokenDrop:
 		return m.parseDrop()
 	}
-	return nil, fmt.Errorf("Unrecognized request type: %v", m.l.PeekWord())
+	return nil, m.firstToken.ErrMsg(m.l, "Unrecognized request type")
 }
 
 func readComment(p expr.TokenPager) string {
@@ -173,6 +209,16 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 	req.Raw = m.l.RawInput()
 	m.Next() // Consume Select?
 
+	// Optional /*+ hints */ always immediately after SELECT KW
+	for m.Cur().T == lex.TokenOptimizerHint {
+		hints, err := ParseSqlHints(m.Cur().V)
+		if err != nil {
+			return nil, m.ErrMsg(err.Error())
+		}
+		req.Hints = append(req.Hints, hints...)
+		m.Next()
+	}
+
 	// Optional DISTINCT keyword always immediately after SELECT KW
 	if m.Cur().T == lex.TokenDistinct {
 		m.Next()
@@ -249,6 +295,20 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 		return nil, err
 	}
 
+	// FETCH
+	discardComments(m)
+	if err := m.parseFetch(req); err != nil {
+		return nil, err
+	}
+
+	// INTO @var may also follow the select
+	discardComments(m)
+	if req.Into == nil {
+		if err := m.parseInto(req); err != nil {
+			return nil, err
+		}
+	}
+
 	// WITH
 	discardComments(m)
 	with, err := ParseWith(m.SqlTokenPager)
@@ -274,7 +334,7 @@ func (m *Sqlbridge) parseSqlSelect() (*SqlSelect, error) {
 	}
 
 	u.Debugf("Could not complete parsing, return error: %v %v", m.Cur(), m.l.PeekWord())
-	return nil, fmt.Errorf("Did not complete parsing input: %v", m.LexTokenPager.Cur().V)
+	return nil, m.Cur().ErrMsg(m.l, "Did not complete parsing input")
 }
 
 // First keyword was INSERT, REPLACE
@@ -287,7 +347,7 @@ func (m *Sqlbridge) parseSqlInsert() (*SqlInsert, error) {
 
 	// INTO
 	if m.Cur().T != lex.TokenInto {
-		return nil, fmt.Errorf("expected INTO but got: %v", m.Cur())
+		return nil, m.Cur().ErrMsg(m.l, "expected INTO")
 	}
 	m.Next() // Consume INTO
 
@@ -297,7 +357,7 @@ func (m *Sqlbridge) parseSqlInsert() (*SqlInsert, error) {
 		req.Table = m.Cur().V
 		m.Next()
 	default:
-		return nil, fmt.Errorf("expected table name but got : %v", m.Cur().V)
+		return nil, m.Cur().ErrMsg(m.l, "expected table name")
 	}
 
 	// list of fields
@@ -314,6 +374,9 @@ func (m *Sqlbridge) parseSqlInsert() (*SqlInsert, error) {
 	case lex.TokenSelect:
 		sel, err := m.parseSqlSelect()
 		if err != nil {
+			if strings.ToLower(m.Cur().V) == "on" && strings.ToLower(m.Peek().V) == "error" {
+				return nil, m.ErrMsg("ON ERROR is only supported for INSERT ... VALUES, not INSERT ... SELECT")
+			}
 			return nil, err
 		}
 		if len(sel.From) == 0 {
@@ -330,6 +393,23 @@ func (m *Sqlbridge) parseSqlInsert() (*SqlInsert, error) {
 		return nil, err
 	}
 	req.Rows = colVals
+
+	// ON ERROR {SKIP | ABORT}
+	if strings.ToLower(m.Cur().V) == "on" {
+		m.Next()
+		if strings.ToLower(m.Cur().V) != "error" {
+			return nil, m.ErrMsg("expected ON ERROR {SKIP | ABORT}")
+		}
+		m.Next()
+		switch strings.ToLower(m.Cur().V) {
+		case "skip":
+			req.OnErrorSkip = true
+		case "abort":
+		default:
+			return nil, m.ErrMsg("expected ON ERROR {SKIP | ABORT}")
+		}
+		m.Next()
+	}
 	return req, nil
 }
 
@@ -344,11 +424,11 @@ func (m *Sqlbridge) parseSqlUpdate() (*SqlUpdate, error) {
 	case lex.TokenTable, lex.TokenIdentity:
 		req.Table = m.Cur().V
 	default:
-		return nil, fmt.Errorf("expected table name but got : %v", m.Cur().V)
+		return nil, m.Cur().ErrMsg(m.l, "expected table name")
 	}
 	m.Next()
 	if m.Cur().T != lex.TokenSet {
-		return nil, fmt.Errorf("expected SET after table name but got : %v", m.Cur().V)
+		return nil, m.Cur().ErrMsg(m.l, "expected SET after table name")
 	}
 
 	// list of name=value pairs
@@ -385,7 +465,7 @@ func (m *Sqlbridge) parseSqlUpsert() (*SqlUpsert, error) {
 		req.Table = m.Cur().V
 		m.Next()
 	default:
-		return nil, fmt.Errorf("expected table name but got : %v", m.Cur().V)
+		return nil, m.Cur().ErrMsg(m.l, "expected table name")
 	}
 
 	switch m.Cur().T {
@@ -511,16 +591,17 @@ func (m *Sqlbridge) parseDescribe() (SqlStatement, error) {
 
 	//u.Debugf("token:  %v", m.Cur())
 	switch nextWord := strings.ToLower(m.Cur().V); nextWord {
-	case "select":
+	case "select", "insert", "replace", "upsert", "update", "delete", "create", "drop", "alter":
 		// TODO:  make the lexer handle this
 		sqlText := strings.Replace(m.l.RawInput(), req.Tok.V, "", 1)
-		sqlSel, err := ParseSql(sqlText)
+		stmt, err := ParseSql(sqlText)
 		if err != nil {
 			return nil, err
 		}
-		req.Stmt = sqlSel
+		req.Stmt = stmt
 		return req, nil
-	case "extended":
+	case "extended", "analyze":
+		req.Analyze = nextWord == "analyze"
 		sqlText := strings.Replace(m.l.RawInput(), req.Tok.V, "", 1)
 		sqlText = strings.Replace(sqlText, m.Cur().V, "", 1)
 		sqlSel, err := ParseSql(sqlText)
@@ -548,6 +629,8 @@ func (m *Sqlbridge) parseShow() (*SqlShow, error) {
 		don't currently support all these
 		http://dev.mysql.com/doc/refman/5.7/en/show.html
 
+		SHOW CHARACTER SET [like_or_where]
+		SHOW COLLATION [like_or_where]
 		SHOW [FULL] COLUMNS FROM tbl_name [FROM db_name] [like_or_where]
 		SHOW CREATE DATABASE db_name
 		SHOW CREATE TABLE tbl_name
@@ -597,9 +680,16 @@ func (m *Sqlbridge) parseShow() (*SqlShow, error) {
 	case "databases":
 		req.ShowType = "databases"
 		m.Next()
-	case "indexes", "keys":
+	case "index", "indexes", "keys":
+		m.Next() // consume {INDEX | INDEXES | KEYS}
 		req.ShowType = "indexes"
-		m.Next()
+		// SHOW {INDEX | INDEXES | KEYS} {FROM | IN} tbl_name [{FROM | IN} db_name] [WHERE expr]
+		if err := m.parseShowFromTable(req); err != nil {
+			return nil, err
+		}
+		if err := m.parseShowFromDatabase(req); err != nil {
+			return nil, err
+		}
 	case "variables":
 		req.ShowType = "variables"
 		likeLhs = "Variable_name"
@@ -620,6 +710,14 @@ func (m *Sqlbridge) parseShow() (*SqlShow, error) {
 		req.ShowType = objectType
 		likeLhs = "Name"
 		m.Next()
+	case "collation":
+		req.ShowType = "collation"
+		likeLhs = "Collation"
+		m.Next()
+	case "character set", "charset":
+		req.ShowType = "charset"
+		likeLhs = "Charset"
+		m.Next()
 	case "columns":
 		m.Next() // consume columns
 		likeLhs = "Field"
@@ -716,14 +814,22 @@ func (m *Sqlbridge) parseCreate() (*SqlCreate, error) {
 		}
 		req.OrReplace = true
 	}
-	// CREATE {DATABASE|SCHEMA|TABLE|VIEW|SOURCE|CONTINUOUSVIEW} <identity>
+	// CREATE TEMPORARY TABLE
+	if m.Cur().T == lex.TokenTemp {
+		m.Next()
+		req.Temp = true
+		if m.Cur().T != lex.TokenTable {
+			return nil, m.ErrMsg("Expected CREATE TEMPORARY TABLE")
+		}
+	}
+	// CREATE {DATABASE|SCHEMA|TABLE|VIEW|SOURCE|CONTINUOUSVIEW|SAMPLE TABLE} <identity>
 	switch m.Cur().T {
 	case lex.TokenTable, lex.TokenSource, lex.TokenDatabase, lex.TokenSchema:
 		req.Tok = m.Next()
-	case lex.TokenView, lex.TokenContinuousView:
+	case lex.TokenView, lex.TokenContinuousView, lex.TokenSample:
 		req.Tok = m.Next()
 		if m.Cur().T != lex.TokenIdentity {
-			return nil, m.ErrMsg("Expected CREATE [OR REPLACE] {VIEW|CONTINIOUSVIEW} <identity> AS <select_stmt>")
+			return nil, m.ErrMsg("Expected CREATE [OR REPLACE] {VIEW|CONTINIOUSVIEW|SAMPLE TABLE} <identity> AS <select_stmt>")
 		}
 		req.Identity = m.Next().V
 
@@ -782,12 +888,15 @@ func (m *Sqlbridge) parseCreate() (*SqlCreate, error) {
 		}
 		req.Cols = cols
 
-		// ENGINE
+		// ENGINE, temporary tables are always in memory
 		discardComments(m)
 		if strings.ToLower(m.Cur().V) != "engine" {
-			return nil, m.ErrMsg("Expected (cols) ENGINE ... ")
+			if !req.Temp {
+				return nil, m.ErrMsg("Expected (cols) ENGINE ... ")
+			}
+			break
 		}
-		engine, err := ParseWith(m.SqlTokenPager)
+		engine, err := m.parseCreateEngine()
 		if err != nil {
 			return nil, err
 		}
@@ -873,6 +982,60 @@ func (m *Sqlbridge) parseTransaction() (*SqlCommand, error) {
 	return req, nil
 }
 
+// parseAnalyze   ANALYZE TABLE tbl [, tbl]
+func (m *Sqlbridge) parseAnalyze() (*SqlCommand, error) {
+
+	req := &SqlCommand{Columns: make(CommandColumns, 0)}
+	req.kw = m.Next().T // Consume ANALYZE
+	if m.Cur().T != lex.TokenTable {
+		return nil, m.ErrMsg("expected TABLE for ANALYZE TABLE")
+	}
+	m.Next() // Consume TABLE
+	for {
+		switch m.Cur().T {
+		case lex.TokenTable:
+			req.Columns = append(req.Columns, &CommandColumn{Name: m.Next().V})
+		case lex.TokenComma:
+			m.Next()
+		case lex.TokenEOF, lex.TokenEOS:
+			if len(req.Columns) == 0 {
+				return nil, m.ErrMsg("expected table name for ANALYZE TABLE")
+			}
+			return req, nil
+		default:
+			return nil, m.ErrMsg("expected table name for ANALYZE TABLE")
+		}
+	}
+}
+
+// parseCall   CALL name(arg, arg)
+func (m *Sqlbridge) parseCall() (*SqlCall, error) {
+
+	m.Next() // Consume CALL
+	if m.Cur().T != lex.TokenIdentity {
+		return nil, m.ErrMsg("expected procedure name")
+	}
+	req := &SqlCall{Name: m.Next().V}
+	switch m.Cur().T {
+	case lex.TokenEOF, lex.TokenEOS:
+		return req, nil
+	case lex.TokenLeftParenthesis:
+	default:
+		return nil, m.ErrMsg("expected ( after procedure name")
+	}
+	rows, err := m.parseValueList()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 1 {
+		return nil, m.ErrMsg("expected a single argument list")
+	}
+	if len(rows) == 1 {
+		req.Args = rows[0]
+	}
+	return req, nil
+}
+
 func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement) error {
 
 	var col *Column
@@ -945,7 +1108,7 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 				return err
 			}
 			col.Expr = exprNode
-		case lex.TokenValue, lex.TokenInteger:
+		case lex.TokenValue, lex.TokenInteger, lex.TokenValueHex:
 			// Value Literal
 			col = NewColumnValue(m.Cur())
 			exprNode, err := expr.ParseExprWithFuncs(m, fr)
@@ -955,6 +1118,9 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 			col.Expr = exprNode
 		}
 		//u.Debugf("after colstart?:   %v  ", m.Cur())
+		if col == nil {
+			return m.ErrMsg("Expected Column Expression")
+		}
 		comment += readComment(m)
 
 		// since we can loop inside switch statement
@@ -985,6 +1151,30 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 			col.Guard = exprNode
 			// Hm, we need to backup here?  Parse Node went to deep?
 			continue
+		case lex.TokenFilter:
+			// aggregate FILTER (WHERE <expr>)
+			if col == nil || !col.Agg {
+				return m.ErrMsg("FILTER is only allowed on aggregate functions")
+			}
+			m.Next()
+			if m.Cur().T != lex.TokenLeftParenthesis {
+				return m.ErrMsg("expected ( after FILTER")
+			}
+			m.Next()
+			if m.Cur().T != lex.TokenWhere {
+				return m.ErrMsg("expected WHERE in FILTER")
+			}
+			m.Next()
+			exprNode, err := expr.ParseExprWithFuncs(m, fr)
+			if err != nil {
+				return err
+			}
+			col.Filter = exprNode
+			if m.Cur().T != lex.TokenRightParenthesis {
+				return m.ErrMsg("expected ) to end FILTER")
+			}
+			m.Next()
+			continue
 		case lex.TokenRightParenthesis:
 			// loop on my friend
 		case lex.TokenComma:
@@ -1020,6 +1210,9 @@ func (m *Sqlbridge) parseFieldList() (Columns, error) {
 			m.Next()
 		}
 		//u.Debugf("after colstart?:   %v  ", m.Cur())
+		if col == nil {
+			return nil, m.ErrMsg("expected column but")
+		}
 
 		// since we can loop inside switch statement
 		switch m.Cur().T {
@@ -1029,6 +1222,7 @@ func (m *Sqlbridge) parseFieldList() (Columns, error) {
 			return cols, nil
 		case lex.TokenComma:
 			cols = append(cols, col)
+			col = nil
 		default:
 			return nil, m.ErrMsg("expected column but")
 		}
@@ -1096,6 +1290,7 @@ func (m *Sqlbridge) parseValueList() ([][]*ValueColumn, error) {
 			row = make([]*ValueColumn, 0)
 		case lex.TokenRightParenthesis:
 			values = append(values, row)
+			row = nil
 		case lex.TokenFrom, lex.TokenInto, lex.TokenLimit, lex.TokenEOS, lex.TokenEOF:
 			if len(row) > 0 {
 				values = append(values, row)
@@ -1103,6 +1298,12 @@ func (m *Sqlbridge) parseValueList() ([][]*ValueColumn, error) {
 			return values, nil
 		case lex.TokenValue:
 			row = append(row, &ValueColumn{Value: value.NewStringValue(m.Cur().V)})
+		case lex.TokenValueHex:
+			n, err := expr.NewValueNodeHex(m.Cur())
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, &ValueColumn{Value: n.Value})
 		case lex.TokenInteger:
 			iv, err := strconv.ParseInt(m.Cur().V, 10, 64)
 			if err != nil {
@@ -1122,6 +1323,13 @@ func (m *Sqlbridge) parseValueList() ([][]*ValueColumn, error) {
 			}
 			row = append(row, &ValueColumn{Value: value.NewBoolValue(bv)})
 		case lex.TokenIdentity:
+			if strings.ToLower(m.Cur().V) == "on" {
+				// INSERT ... VALUES (...) ON ERROR SKIP
+				if len(row) > 0 {
+					values = append(values, row)
+				}
+				return values, nil
+			}
 			// TODO:  this is a bug in lexer
 			lv := m.Cur().V
 			if bv, err := strconv.ParseBool(lv); err == nil {
@@ -1195,12 +1403,15 @@ func (m *Sqlbridge) parseSources(req *SqlSelect) error {
 				return err
 			}
 		case lex.TokenEOF, lex.TokenEOS, lex.TokenWhere, lex.TokenGroupBy, lex.TokenLimit,
-			lex.TokenOffset, lex.TokenWith, lex.TokenAlias, lex.TokenOrderBy:
+			lex.TokenOffset, lex.TokenFetch, lex.TokenWith, lex.TokenAlias, lex.TokenOrderBy:
 			return nil
 		default:
 			return m.ErrMsg("unexpected token")
 		}
 
+		if err := m.parseStatsHint(src); err != nil {
+			return err
+		}
 		discardComments(m)
 
 		switch m.Cur().T {
@@ -1213,6 +1424,21 @@ func (m *Sqlbridge) parseSources(req *SqlSelect) error {
 			src.Alias = m.Cur().V
 			m.Next()
 		}
+		if err := m.parseValuesColumns(src); err != nil {
+			return err
+		}
+		if err := m.parseStatsHint(src); err != nil {
+			return err
+		}
+		if err := m.parseIndexHints(src); err != nil {
+			return err
+		}
+		if err := m.parseTableSample(src); err != nil {
+			return err
+		}
+		if err := m.parsePivot(src); err != nil {
+			return err
+		}
 		if m.Cur().T == lex.TokenOn {
 			src.Op = m.Cur().T
 			m.Next()
@@ -1230,6 +1456,10 @@ func (m *Sqlbridge) parseSourceSubQuery(src *SqlSource) error {
 
 	m.Next() // page forward off of (
 
+	if m.Cur().T == lex.TokenValues {
+		return m.parseSourceValues(src)
+	}
+
 	// SELECT * FROM (SELECT 1, 2, 3) AS t1;
 	subQuery, err := m.parseSqlSelect()
 	if err != nil {
@@ -1245,6 +1475,77 @@ func (m *Sqlbridge) parseSourceSubQuery(src *SqlSource) error {
 	return nil
 }
 
+// parseSourceValues parse the literal rows of an inline VALUES table
+//
+//    FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name)
+//
+func (m *Sqlbridge) parseSourceValues(src *SqlSource) error {
+	m.Next() // consume VALUES
+	vals := &SqlValues{}
+	for {
+		if m.Next().T != lex.TokenLeftParenthesis {
+			return m.ErrMsg("expected ( VALUES row")
+		}
+		var row []*ValueColumn
+		for {
+			exprNode, err := expr.ParseExprWithFuncs(m, m.funcs)
+			if err != nil {
+				return err
+			}
+			row = append(row, &ValueColumn{Expr: exprNode})
+			if m.Cur().T != lex.TokenComma {
+				break
+			}
+			m.Next()
+		}
+		if m.Next().T != lex.TokenRightParenthesis {
+			return m.ErrMsg("expected ) after VALUES row")
+		}
+		if len(vals.Rows) > 0 && len(row) != len(vals.Rows[0]) {
+			return m.ErrMsg("VALUES rows must all have the same number of columns")
+		}
+		vals.Rows = append(vals.Rows, row)
+		if m.Cur().T != lex.TokenComma {
+			break
+		}
+		m.Next()
+	}
+	if m.Next().T != lex.TokenRightParenthesis {
+		return m.ErrMsg("expected right paren ) after VALUES")
+	}
+	src.Values = vals
+	return nil
+}
+
+// parseValuesColumns parse the optional column names after the alias of
+// a VALUES table
+//
+//    FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name)
+//
+func (m *Sqlbridge) parseValuesColumns(src *SqlSource) error {
+	if src.Values == nil || src.Alias == "" || m.Cur().T != lex.TokenLeftParenthesis {
+		return nil
+	}
+	m.Next() // consume (
+	for {
+		if m.Cur().T != lex.TokenIdentity {
+			return m.ErrMsg("expected VALUES column name")
+		}
+		src.Values.Columns = append(src.Values.Columns, m.Next().V)
+		if m.Cur().T != lex.TokenComma {
+			break
+		}
+		m.Next()
+	}
+	if m.Next().T != lex.TokenRightParenthesis {
+		return m.ErrMsg("expected ) after VALUES column names")
+	}
+	if len(src.Values.Columns) != len(src.Values.Rows[0]) {
+		return m.ErrMsg("VALUES column names must match the number of columns")
+	}
+	return nil
+}
+
 func (m *Sqlbridge) parseSourceTable(req *SqlSelect) error {
 
 	if m.Cur().T != lex.TokenIdentity {
@@ -1254,13 +1555,220 @@ func (m *Sqlbridge) parseSourceTable(req *SqlSelect) error {
 	src := SqlSource{}
 	req.From = append(req.From, &src)
 	src.Schema, src.Name, _ = expr.LeftRight(m.Next().V)
+	if err := m.parseStatsHint(&src); err != nil {
+		return err
+	}
 	if m.Cur().T == lex.TokenAs {
 		m.Next() // Skip over "AS", we don't need it
 		src.Alias = m.Next().V
 	}
+	if err := m.parseStatsHint(&src); err != nil {
+		return err
+	}
+	if err := m.parseIndexHints(&src); err != nil {
+		return err
+	}
+	if err := m.parseTableSample(&src); err != nil {
+		return err
+	}
+	return m.parsePivot(&src)
+}
+
+// parseStatsHint parse optional statistics hint comment after a table reference
+//
+//    FROM big_table /*+ ROWS(2e9) NDV(user_id, 5e7) */
+//
+func (m *Sqlbridge) parseStatsHint(src *SqlSource) error {
+	for m.Cur().T == lex.TokenCommentML && strings.HasPrefix(strings.TrimSpace(m.Cur().V), "+") {
+		stats, err := ParseSqlStatsHint("/*" + m.Cur().V + "*/")
+		if err != nil {
+			return m.ErrMsg(err.Error())
+		}
+		if stats != nil {
+			src.Stats = stats
+		}
+		m.Next()
+	}
 	return nil
 }
 
+// parseTableSample parse optional sample clause after a table reference
+//
+//    FROM big_t TABLESAMPLE (0.1 PERCENT)
+//    FROM big_t TABLESAMPLE (1000 ROWS)
+//    FROM big_t SAMPLE 10 PERCENT
+//
+func (m *Sqlbridge) parseTableSample(src *SqlSource) error {
+	parens := false
+	switch m.Cur().T {
+	case lex.TokenTableSample:
+		parens = true
+	case lex.TokenSample:
+	default:
+		return nil
+	}
+	m.Next() // consume TABLESAMPLE | SAMPLE
+	if parens && m.Next().T != lex.TokenLeftParenthesis {
+		return m.ErrMsg("expected ( after TABLESAMPLE")
+	}
+	switch m.Cur().T {
+	case lex.TokenInteger, lex.TokenFloat:
+	default:
+		return m.ErrMsg("expected TABLESAMPLE (<number> PERCENT | ROWS)")
+	}
+	n, err := strconv.ParseFloat(m.Next().V, 64)
+	if err != nil {
+		return m.ErrMsg("invalid TABLESAMPLE number")
+	}
+	switch m.Next().T {
+	case lex.TokenPercent:
+		if n <= 0 || n > 100 {
+			return m.ErrMsg("TABLESAMPLE percent must be greater than 0 and at most 100")
+		}
+		src.Sample = NewSqlSample(n)
+	case lex.TokenRows:
+		if n < 1 || n != float64(int64(n)) {
+			return m.ErrMsg("TABLESAMPLE rows must be a positive integer")
+		}
+		src.Sample = NewSqlSampleRows(int64(n))
+	default:
+		return m.ErrMsg("expected PERCENT or ROWS in TABLESAMPLE")
+	}
+	if parens && m.Next().T != lex.TokenRightParenthesis {
+		return m.ErrMsg("expected ) after TABLESAMPLE")
+	}
+	return nil
+}
+
+// parsePivot parse optional PIVOT or UNPIVOT after a table reference
+//
+//    FROM sales PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2))
+//    FROM sales_wide UNPIVOT (amount FOR quarter IN (q1, q2 AS second))
+//
+func (m *Sqlbridge) parsePivot(src *SqlSource) error {
+	pv := &SqlPivot{}
+	switch m.Cur().T {
+	case lex.TokenPivot:
+	case lex.TokenUnpivot:
+		pv.Unpivot = true
+	default:
+		return nil
+	}
+	m.Next() // consume PIVOT | UNPIVOT
+	if m.Next().T != lex.TokenLeftParenthesis {
+		return m.ErrMsg("expected ( after PIVOT")
+	}
+	if pv.Unpivot {
+		if m.Cur().T != lex.TokenIdentity {
+			return m.ErrMsg("expected UNPIVOT (<value_column> FOR ...")
+		}
+		pv.Value = m.Next().V
+	} else {
+		agg, err := expr.ParseExprWithFuncs(m, m.funcs)
+		if err != nil {
+			return err
+		}
+		if _, ok := agg.(*expr.FuncNode); !ok {
+			return m.ErrMsg("expected PIVOT (<aggregate> FOR ...")
+		}
+		pv.Agg = agg
+	}
+	if m.Next().T != lex.TokenFor || m.Cur().T != lex.TokenIdentity {
+		return m.ErrMsg("expected FOR <column> in PIVOT")
+	}
+	pv.For = m.Next().V
+	if m.Next().T != lex.TokenIN || m.Next().T != lex.TokenLeftParenthesis {
+		return m.ErrMsg("expected IN (...) in PIVOT")
+	}
+	for {
+		in := &SqlPivotIn{}
+		tok := m.Next()
+		switch {
+		case tok.T == lex.TokenIdentity && pv.Unpivot:
+			in.Value = expr.NewIdentityNode(&tok)
+		case tok.T == lex.TokenValue && !pv.Unpivot:
+			in.Value = expr.NewStringNodeToken(tok)
+		case (tok.T == lex.TokenInteger || tok.T == lex.TokenFloat) && !pv.Unpivot:
+			n, err := expr.NewNumberStr(tok.V)
+			if err != nil {
+				return err
+			}
+			in.Value = n
+		default:
+			if pv.Unpivot {
+				return m.ErrMsg("expected column in UNPIVOT IN list")
+			}
+			return m.ErrMsg("expected value in PIVOT IN list")
+		}
+		if m.Cur().T == lex.TokenAs {
+			m.Next()
+			if m.Cur().T != lex.TokenIdentity {
+				return m.ErrMsg("expected identity after AS in PIVOT")
+			}
+			in.As = m.Next().V
+		}
+		pv.In = append(pv.In, in)
+		if m.Cur().T != lex.TokenComma {
+			break
+		}
+		m.Next()
+	}
+	if m.Next().T != lex.TokenRightParenthesis || m.Next().T != lex.TokenRightParenthesis {
+		return m.ErrMsg("expected )) to end PIVOT")
+	}
+	src.Pivot = pv
+	return nil
+}
+
+// parseIndexHints parse optional index hints after a table reference
+//
+//    FROM users USE INDEX (idx_a, idx_b) FORCE KEY FOR ORDER BY (idx_c)
+//
+func (m *Sqlbridge) parseIndexHints(src *SqlSource) error {
+	for {
+		switch m.Cur().T {
+		case lex.TokenUse, lex.TokenForce, lex.TokenIgnore:
+		default:
+			return nil
+		}
+		hint := &IndexHint{Type: m.Next().T}
+		switch m.Cur().T {
+		case lex.TokenIndex, lex.TokenKey:
+			m.Next()
+		default:
+			return m.ErrMsg("expected INDEX or KEY in index hint")
+		}
+		if m.Cur().T == lex.TokenFor {
+			m.Next()
+			switch m.Cur().T {
+			case lex.TokenJoin, lex.TokenOrderBy, lex.TokenGroupBy:
+				hint.For = m.Next().T
+			default:
+				return m.ErrMsg("expected JOIN, ORDER BY or GROUP BY in index hint")
+			}
+		}
+		if m.Cur().T != lex.TokenLeftParenthesis {
+			return m.ErrMsg("expected ( index list in index hint")
+		}
+		m.Next()
+		for m.Cur().T != lex.TokenRightParenthesis {
+			switch m.Cur().T {
+			case lex.TokenIdentity:
+				hint.Indexes = append(hint.Indexes, m.Next().V)
+			case lex.TokenComma:
+				m.Next()
+			default:
+				return m.ErrMsg("expected index name in index hint")
+			}
+		}
+		m.Next() // discard right paren
+		if hint.Type != lex.TokenUse && len(hint.Indexes) == 0 {
+			return m.ErrMsg("index hint requires at least one index name")
+		}
+		src.IndexHints = append(src.IndexHints, hint)
+	}
+}
+
 func (m *Sqlbridge) parseSourceJoin(src *SqlSource) error {
 
 	switch m.Cur().T {
@@ -1305,13 +1813,36 @@ func (m *Sqlbridge) parseInto(req *SqlSelect) error {
 		return nil
 	}
 	m.Next() // Consume Into token
+	if m.Cur().T == lex.TokenIdentity {
+		// INTO @var1, @var2
+		into := &SqlInto{}
+		for {
+			if !strings.HasPrefix(m.Cur().V, "@") {
+				return m.ErrMsg("expected @variable")
+			}
+			into.Vars = append(into.Vars, m.Next().V)
+			if m.Cur().T != lex.TokenComma {
+				break
+			}
+			m.Next() // consume ,
+		}
+		req.Into = into
+		return nil
+	}
+	into := &SqlInto{}
+	if m.Cur().T == lex.TokenTemp {
+		// INTO TEMP table
+		m.Next()
+		into.Temp = true
+	}
 	if m.Cur().T != lex.TokenTable {
 		return m.ErrMsg("expected table")
 	}
 	if strings.ToLower(m.Cur().V) == "FROM" {
 		return m.ErrMsg("expected table")
 	}
-	req.Into = &SqlInto{Table: m.Cur().V}
+	into.Table = m.Cur().V
+	req.Into = into
 	m.Next()
 	return nil
 }
@@ -1468,6 +1999,9 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 			col.Expr = exprNode
 		}
 		//u.Debugf("GroupBy after colstart?:   %v  ", m.Cur())
+		if col == nil {
+			return m.ErrMsg("expected column of group by ")
+		}
 
 		// since we can loop inside switch statement
 		switch m.Cur().T {
@@ -1484,7 +2018,7 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 			}
 			return m.ErrMsg("expected identity")
 		case lex.TokenFrom, lex.TokenOrderBy, lex.TokenInto, lex.TokenLimit, lex.TokenHaving,
-			lex.TokenWith, lex.TokenEOS, lex.TokenEOF:
+			lex.TokenOffset, lex.TokenFetch, lex.TokenWith, lex.TokenEOS, lex.TokenEOF:
 
 			// This indicates we have come to the End of the columns
 			req.GroupBy = append(req.GroupBy, col)
@@ -1504,6 +2038,7 @@ func (m *Sqlbridge) parseGroupBy(req *SqlSelect) (err error) {
 			// loop on my friend
 		case lex.TokenComma:
 			req.GroupBy = append(req.GroupBy, col)
+			col = nil
 		default:
 			return m.ErrMsg("expected column of group by ")
 		}
@@ -1578,14 +2113,26 @@ func (m *Sqlbridge) parseOrderBy(req *SqlSelect) (err error) {
 			}
 			col.Expr = exprNode
 		}
+		if col == nil {
+			return m.ErrMsg("expected order by column")
+		}
 		//u.Debugf("OrderBy after colstart?:   %v  ", m.Cur())
 
 		// since we can loop inside switch statement
 		switch m.Cur().T {
 		case lex.TokenAsc, lex.TokenDesc:
 			col.Order = strings.ToUpper(m.Cur().V)
-
-		case lex.TokenInto, lex.TokenLimit, lex.TokenEOS, lex.TokenEOF:
+			if m.Peek().T == lex.TokenNulls {
+				m.Next()
+				if err := m.parseOrderByNulls(col); err != nil {
+					return err
+				}
+			}
+		case lex.TokenNulls:
+			if err := m.parseOrderByNulls(col); err != nil {
+				return err
+			}
+		case lex.TokenInto, lex.TokenLimit, lex.TokenOffset, lex.TokenFetch, lex.TokenEOS, lex.TokenEOF:
 			// This indicates we have come to the End of the columns
 			req.OrderBy = append(req.OrderBy, col)
 			return nil
@@ -1596,6 +2143,7 @@ func (m *Sqlbridge) parseOrderBy(req *SqlSelect) (err error) {
 			// loop on my friend
 		case lex.TokenComma:
 			req.OrderBy = append(req.OrderBy, col)
+			col = nil
 		default:
 			return m.ErrMsg("expected order by column")
 		}
@@ -1603,6 +2151,18 @@ func (m *Sqlbridge) parseOrderBy(req *SqlSelect) (err error) {
 	}
 }
 
+// parseOrderByNulls parses the NULLS {FIRST | LAST} suffix of an order by column
+// current token must be NULLS.
+func (m *Sqlbridge) parseOrderByNulls(col *Column) error {
+	switch m.Peek().T {
+	case lex.TokenFirst, lex.TokenLast:
+		m.Next()
+		col.Nulls = strings.ToUpper(m.Cur().V)
+		return nil
+	}
+	return m.ErrMsg("expected NULLS FIRST or NULLS LAST")
+}
+
 func (m *Sqlbridge) parseWhereDelete(req *SqlDelete) error {
 	if m.Cur().T != lex.TokenWhere {
 		return nil
@@ -1626,6 +2186,17 @@ func (m *Sqlbridge) parseCommandColumns(req *SqlCommand) (err error) {
 		switch m.Cur().T {
 		case lex.TokenIdentity:
 
+			// SET GLOBAL x = 1  is  SET @@global.x = 1
+			scope := ""
+			switch strings.ToLower(m.Cur().V) {
+			case "global", "session", "local":
+				if m.Peek().T == lex.TokenIdentity {
+					scope = strings.ToLower(m.Next().V)
+					if scope == "local" {
+						scope = "session"
+					}
+				}
+			}
 			col = &CommandColumn{Name: m.Cur().V}
 			exprNode, err := expr.ParseExprWithFuncs(m, m.funcs)
 			if err != nil {
@@ -1633,6 +2204,12 @@ func (m *Sqlbridge) parseCommandColumns(req *SqlCommand) (err error) {
 			}
 			col.Expr = exprNode
 			convertIdentityToValue(col.Expr)
+			if scope != "" {
+				col.Name = fmt.Sprintf("@@%s.%s", scope, col.Name)
+				if bn, ok := col.Expr.(*expr.BinaryNode); ok {
+					bn.Args[0] = expr.NewIdentityNodeVal(col.Name)
+				}
+			}
 
 		default:
 			return m.ErrMsg("expected idenity")
@@ -1653,6 +2230,41 @@ func (m *Sqlbridge) parseCommandColumns(req *SqlCommand) (err error) {
 	}
 }
 
+// parseCreateEngine parses the table storage options after the create cols
+// up to WITH or the end of the statement, keys are lower-cased, integer
+// values int64, and the optional DEFAULT keyword skipped.
+//
+//    ENGINE=InnoDB AUTO_INCREMENT=4080 DEFAULT CHARSET=utf8
+//
+func (m *Sqlbridge) parseCreateEngine() (map[string]interface{}, error) {
+	jh := make(map[string]interface{})
+	for {
+		switch m.Cur().T {
+		case lex.TokenWith, lex.TokenEOS, lex.TokenEOF:
+			return jh, nil
+		case lex.TokenDefault:
+			m.Next()
+			continue
+		}
+		key := strings.ToLower(m.Next().V)
+		if m.Cur().T != lex.TokenEqual {
+			return nil, m.ErrMsg("Expected ENGINE key=value")
+		}
+		m.Next() // consume =
+		switch m.Cur().T {
+		case lex.TokenInteger:
+			iv, err := strconv.ParseInt(m.Cur().V, 10, 64)
+			if err != nil {
+				return nil, m.ErrMsg("Expected integer")
+			}
+			jh[key] = iv
+		default:
+			jh[key] = m.Cur().V
+		}
+		m.Next()
+	}
+}
+
 func (m *Sqlbridge) parseCreateCols() ([]*DdlColumn, error) {
 
 	cols := make([]*DdlColumn, 0)
@@ -2032,6 +2644,25 @@ func (m *Sqlbridge) parseOffset(req *SqlSelect) error {
 	req.Offset = iv
 	return nil
 }
+
+// parseFetch the ansi FETCH (FIRST | NEXT) [n] (ROW | ROWS) ONLY, same as
+// LIMIT n, n defaults to 1.
+func (m *Sqlbridge) parseFetch(req *SqlSelect) error {
+	if m.Cur().T != lex.TokenFetch {
+		return nil
+	}
+	m.Next() // Consume "FETCH"
+	req.Limit = 1
+	if m.Cur().T != lex.TokenInteger {
+		return nil
+	}
+	iv, err := strconv.Atoi(m.Next().V)
+	if err != nil {
+		return m.ErrMsg("Could not convert fetch to integer")
+	}
+	req.Limit = iv
+	return nil
+}
 func (m *Sqlbridge) parseAlias(req *SqlSelect) error {
 	if m.Cur().T != lex.TokenAlias {
 		return nil
@@ -2335,7 +2966,7 @@ func (m *SqlTokenPager) ClauseEnd() bool {
 	tok := m.Cur()
 	switch tok.T {
 	case lex.TokenEOF, lex.TokenEOS, lex.TokenFrom, lex.TokenHaving, lex.TokenComma,
-		lex.TokenIf, lex.TokenAs, lex.TokenLimit, lex.TokenSelect:
+		lex.TokenIf, lex.TokenFilter, lex.TokenAs, lex.TokenLimit, lex.TokenSelect:
 		return true
 	}
 	return false
@@ -2,6 +2,7 @@ package rel_test
 
 import (
 	"flag"
+	"strings"
 	"testing"
 
 	u "github.com/araddon/gou"
@@ -57,6 +58,27 @@ func TestSqlKeywordEscape(t *testing.T) {
 	parseSqlTest(t, sel.String())
 }
 
+func TestSqlSpecialIdentities(t *testing.T) {
+	t.Parallel()
+	sql := "SELECT `first name`, `my-field`, `tick``name`, `select`, `t`.`last name` FROM `my-index` AS t WHERE `my-field` > 1"
+	req, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "my-index", req.From[0].Name)
+	cols := req.Columns
+	assert.Equal(t, 5, len(cols))
+	assert.Equal(t, "first name", cols[0].As)
+	assert.Equal(t, "my-field", cols[1].As)
+	assert.Equal(t, "tick`name", cols[2].As)
+	assert.Equal(t, "select", cols[3].As)
+	assert.Equal(t, "t.last name", cols[4].As)
+	assert.Equal(t, "last name", cols[4].SourceField)
+
+	// writing it back out quotes them again, so it re-parses the same
+	out := req.String()
+	assert.Equal(t, "SELECT `first name`, `my-field`, `tick``name`, `select`, t.`last name` FROM `my-index` AS t WHERE `my-field` > 1", out)
+	parseSqlTest(t, out)
+}
+
 func TestSqlParseFail(t *testing.T) {
 	tests := []string{
 		`--hello
@@ -157,6 +179,7 @@ func TestSqlParseOnly(t *testing.T) {
 	parseSqlError(t, "SELECT x FROM user GROUP BY ex(a,b")
 	parseSqlError(t, "SELECT x FROM user GROUP BY x HAVING ct > count(x,;")
 	parseSqlError(t, "SELECT x FROM user ORDER BY ex(a,;")
+	parseSqlError(t, "SELECT x FROM user ORDER BY x NULLS;")
 	parseSqlError(t, `SELECT x FROM user OFFSET "hello";`)
 	parseSqlError(t, `SELECT x FROM user WITH "hello";`)
 	parseSqlError(t, `SELECT x FROM user ALIAS 12;`)
@@ -165,8 +188,16 @@ func TestSqlParseOnly(t *testing.T) {
 	parseSqlError(t, "SELECT a, b INTO FROM user;")
 	//parseSqlError(t, "SELECT a FROM user WHERE x")
 	parseSqlError(t, "SELECT hash(join(, \", \")) AS id, `x`, `y`, `z` FROM nothing;")
+	// found by fuzzing, these used to panic
+	parseSqlError(t, "SELECT FROM user")
+	parseSqlError(t, "SELECT AS a")
+	parseSqlError(t, "SELECT 1 +")
+	parseSqlError(t, "SELECT x FROM user ORDER BY")
+	parseSqlError(t, "SELECT x FROM user GROUP BY x,")
+	parseSqlError(t, "INSERT INTO user () VALUES ()")
 
 	parseSqlTest(t, "SELECT COUNT(*) AS count FROM providers WHERE (`providers._id` != NULL)")
+	parseSqlTest(t, "SELECT u.name FROM users AS u INNER JOIN orders AS o ON u.id = o.user_id ORDER BY u.name ASC")
 
 	parseSqlTest(t, "select title from article WITH distributed=true, node_ct=10")
 	parseSqlTest(t, "SELECT `appearances`.`G_ph` AS `field` FROM `appearances` ORDER BY `appearances`.`G_ph` ASC LIMIT 500 OFFSET 0")
@@ -261,6 +292,7 @@ func TestSqlParseOnly(t *testing.T) {
 	parseSqlTest(t, `SHOW GLOBAL VARIABLES like '%'`)
 	parseSqlTest(t, "show keys from `appearances` from `baseball`")
 	parseSqlTest(t, "show indexes from `appearances` from `baseball`")
+	parseSqlTest(t, "SHOW INDEX IN `appearances` IN `baseball`")
 	//parseSqlTest(t, `SHOW VARIABLES where `)
 
 	parseSqlTest(t, `select *, @@var_name from movies`)
@@ -366,6 +398,36 @@ func TestSqlParseAstCheck(t *testing.T) {
 	assert.True(t, sel.OrderBy[0].Order == "ASC", "%v", sel.OrderBy[0].String())
 	assert.True(t, sel.OrderBy[1].Order == "DESC", "%v", sel.OrderBy[1].String())
 
+	sql = "select name from `github_public` ORDER BY name ASC NULLS LAST, `stars` NULLS FIRST limit 10;"
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	sel = req.(*rel.SqlSelect)
+	assert.True(t, len(sel.OrderBy) == 2, "want 2 orderby but has %v", len(sel.OrderBy))
+	assert.Equal(t, "LAST", sel.OrderBy[0].Nulls)
+	assert.Equal(t, "FIRST", sel.OrderBy[1].Nulls)
+	assert.Equal(t, "name ASC NULLS LAST", sel.OrderBy[0].String())
+	assert.True(t, sel.Limit == 10, "want limit = 10 but have %v", sel.Limit)
+
+	// against is still a column name outside of MATCH(...) AGAINST
+	sql = "SELECT against FROM t WHERE against > 1"
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+
+	// nulls, first, last are still column names outside of NULLS FIRST|LAST
+	for sql, want := range map[string]string{
+		"SELECT last, first FROM users ORDER BY last":      "last",
+		"SELECT first FROM users ORDER BY first DESC":      "first DESC",
+		"SELECT nulls FROM users ORDER BY nulls":           "nulls",
+		"SELECT last FROM users ORDER BY last NULLS FIRST": "last NULLS FIRST",
+	} {
+		req, err = rel.ParseSql(sql)
+		assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+		if sel, ok = req.(*rel.SqlSelect); assert.True(t, ok, "is SqlSelect: %T", req) {
+			assert.Equal(t, 1, len(sel.OrderBy), sql)
+			assert.Equal(t, want, sel.OrderBy[0].String(), sql)
+		}
+	}
+
 	sql = "select name from `github_public` limit 0, 100;"
 	req, err = rel.ParseSql(sql)
 	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
@@ -455,8 +517,31 @@ func TestSqlParseAstCheck(t *testing.T) {
 	assert.True(t, ok, "is SqlDescribe: %T", req)
 	sel, ok = desc.Stmt.(*rel.SqlSelect)
 	assert.True(t, ok, "is SqlSelect: %T", req)
+	assert.False(t, desc.Analyze)
 	u.Info(sel.Where.String())
 
+	req, err = rel.ParseSql(`EXPLAIN ANALYZE SELECT user_id FROM users ORDER BY user_id`)
+	assert.Equal(t, nil, err)
+	desc, ok = req.(*rel.SqlDescribe)
+	assert.True(t, ok, "is SqlDescribe: %T", req)
+	assert.True(t, desc.Analyze)
+	sel, ok = desc.Stmt.(*rel.SqlSelect)
+	assert.True(t, ok, "is SqlSelect: %T", desc.Stmt)
+	assert.Equal(t, 1, len(sel.OrderBy))
+
+	for _, sql := range []string{
+		`EXPLAIN DELETE FROM users WHERE user_id = "abc"`,
+		`EXPLAIN UPDATE users SET email = "x" WHERE user_id = "abc"`,
+		`EXPLAIN INSERT INTO users (user_id, email) VALUES ("abc", "x")`,
+		`EXPLAIN DROP TABLE users`,
+	} {
+		req, err = rel.ParseSql(sql)
+		assert.Equal(t, nil, err, sql)
+		desc, ok = req.(*rel.SqlDescribe)
+		assert.True(t, ok, "is SqlDescribe: %T", req)
+		assert.NotEqual(t, nil, desc.Stmt, sql)
+	}
+
 	// Where In Sub-Query Clause
 	sql = `select user_id, email
 				FROM mockcsv.users
@@ -530,6 +615,111 @@ func TestSqlParseFromTypes(t *testing.T) {
 	u.Info(sel.String())
 }
 
+func TestSqlIndexHints(t *testing.T) {
+	t.Parallel()
+	sql := `SELECT u.name, o.total
+		FROM users AS u USE INDEX (idx_email, idx_name) IGNORE KEY FOR ORDER BY (idx_created)
+		INNER JOIN orders AS o FORCE INDEX FOR JOIN (idx_user_id) ON o.user_id = u.user_id
+		WHERE u.email = "bob@email.com"`
+	parseSqlTest(t, sql)
+	req, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(req.From))
+
+	users := req.From[0]
+	assert.Equal(t, 2, len(users.IndexHints))
+	assert.Equal(t, lex.TokenUse, users.IndexHints[0].Type)
+	assert.Equal(t, lex.TokenType(0), users.IndexHints[0].For)
+	assert.Equal(t, []string{"idx_email", "idx_name"}, users.IndexHints[0].Indexes)
+	assert.Equal(t, lex.TokenIgnore, users.IndexHints[1].Type)
+	assert.Equal(t, lex.TokenOrderBy, users.IndexHints[1].For)
+
+	orders := req.From[1]
+	assert.Equal(t, 1, len(orders.IndexHints))
+	assert.Equal(t, lex.TokenForce, orders.IndexHints[0].Type)
+	assert.Equal(t, lex.TokenJoin, orders.IndexHints[0].For)
+	assert.Equal(t, []string{"idx_user_id"}, orders.IndexHints[0].Indexes)
+	assert.NotEqual(t, nil, orders.JoinExpr)
+
+	assert.Equal(t, "users AS u USE INDEX (idx_email, idx_name) IGNORE INDEX FOR ORDER BY (idx_created)", users.String())
+
+	// hints survive the per-source rewrite
+	req.Rewrite()
+	assert.Equal(t, 2, len(users.Source.From[0].IndexHints))
+
+	req, err = rel.ParseSqlSelect("SELECT name FROM users USE INDEX () WHERE x = 1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(req.From[0].IndexHints[0].Indexes))
+	assert.NotEqual(t, nil, req.Where)
+
+	parseSqlError(t, "SELECT name FROM users FORCE INDEX () WHERE x = 1")
+	parseSqlError(t, "SELECT name FROM users USE INDEX FOR SELECT (idx) WHERE x = 1")
+}
+
+func TestSqlOptimizerHints(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT /*+ HASH_JOIN(u, o) PARALLEL(4) */ u.name FROM users AS u INNER JOIN orders AS o ON o.user_id = u.user_id`)
+	parseSqlTest(t, `SELECT /*+ NO_PUSHDOWN(users) */ DISTINCT name FROM users /*+ ROWS(10) */ WHERE x = 1`)
+
+	sql := "SELECT /*+ hash_join(`u`,o)  NO_PUSHDOWN( o ) parallel(2) SOMETHING_ELSE */ DISTINCT u.name FROM users AS u"
+	req, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.True(t, req.Distinct)
+	assert.Equal(t, 4, len(req.Hints))
+	assert.Equal(t, &rel.SqlHint{Name: "HASH_JOIN", Args: []string{"u", "o"}}, req.Hints[0])
+	assert.Equal(t, 2, req.Hints.Hint(rel.HintParallel).Int())
+	assert.Equal(t, "SOMETHING_ELSE", req.Hints[3].Name)
+	assert.True(t, req.Hints.HasTable(rel.HintHashJoin, req.From[0]))
+	assert.True(t, !req.Hints.HasTable(rel.HintNoPushdown, req.From[0]))
+	assert.Equal(t, "SELECT /*+ HASH_JOIN(u, o) NO_PUSHDOWN(o) PARALLEL(2) SOMETHING_ELSE */ DISTINCT u.name FROM users AS u", req.String())
+
+	// only a hint immediately after SELECT
+	req, err = rel.ParseSqlSelect("SELECT name /*+ PARALLEL(2) */ FROM users")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(req.Hints))
+
+	parseSqlError(t, "SELECT /*+ PARALLEL(0) */ name FROM users")
+	parseSqlError(t, "SELECT /*+ PARALLEL(a) */ name FROM users")
+	parseSqlError(t, "SELECT /*+ HASH_JOIN() */ name FROM users")
+	parseSqlError(t, "SELECT /*+ HASH_JOIN(a */ name FROM users")
+}
+
+func TestSqlStatsHints(t *testing.T) {
+	t.Parallel()
+	sql := `SELECT u.name, o.total
+		FROM users /*+ ROWS(2e9) NDV(user_id, 5e7) */ AS u
+		INNER JOIN orders AS o /*+ ROWS(1000) */ ON o.user_id = u.user_id
+		WHERE u.email = "bob@email.com"`
+	parseSqlTest(t, sql)
+	req, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(req.From))
+
+	users := req.From[0]
+	assert.Equal(t, "u", users.Alias)
+	assert.Equal(t, 2e9, users.Stats.Rows)
+	assert.Equal(t, map[string]float64{"user_id": 5e7}, users.Stats.Ndv)
+	assert.Equal(t, "users AS u /*+ ROWS(2e+09) NDV(user_id, 5e+07) */", users.String())
+
+	orders := req.From[1]
+	assert.Equal(t, 1000.0, orders.Stats.Rows)
+	assert.Equal(t, 0, len(orders.Stats.Ndv))
+	assert.NotEqual(t, nil, orders.JoinExpr)
+
+	// other hints, and plain comments are ignored
+	req, err = rel.ParseSqlSelect("SELECT name FROM users /*+ NO_INDEX(x) */ WHERE x = 1")
+	assert.Equal(t, nil, err)
+	assert.True(t, req.From[0].Stats == nil)
+	req, err = rel.ParseSqlSelect("SELECT name FROM users /* ROWS(10) */ WHERE x = 1")
+	assert.Equal(t, nil, err)
+	assert.True(t, req.From[0].Stats == nil)
+	assert.NotEqual(t, nil, req.Where)
+
+	parseSqlError(t, "SELECT name FROM users /*+ ROWS(abc) */ WHERE x = 1")
+	parseSqlError(t, "SELECT name FROM users /*+ NDV(user_id) */ WHERE x = 1")
+	parseSqlError(t, "SELECT name FROM users /*+ NDV(user_id, 0) */ WHERE x = 1")
+}
+
 func TestSqlShowAst(t *testing.T) {
 	t.Parallel()
 	/*
@@ -553,6 +743,39 @@ func TestSqlShowAst(t *testing.T) {
 	assert.True(t, show.Db == "dbx", "has SHOW db: %q", show.Db)
 	assert.True(t, show.Identity == "tablex", "has identity: %q", show.Identity)
 	assert.True(t, show.Like.String() == "Field LIKE \"%\"", "has Like? %q", show.Like.String())
+
+	sql = "SHOW COLLATION LIKE 'utf8%'"
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	show = req.(*rel.SqlShow)
+	assert.Equal(t, "collation", show.ShowType)
+	assert.Equal(t, `Collation LIKE "utf8%"`, show.Like.String())
+
+	for _, sql = range []string{"SHOW CHARACTER SET WHERE Charset = 'utf8'", "SHOW CHARSET WHERE Charset = 'utf8'"} {
+		req, err = rel.ParseSql(sql)
+		assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+		show = req.(*rel.SqlShow)
+		assert.Equal(t, "charset", show.ShowType)
+		assert.Equal(t, `Charset = "utf8"`, show.Where.String())
+	}
+
+	for _, sql = range []string{"SHOW INDEX FROM `users` FROM `dbx`", "SHOW KEYS IN users IN dbx", "show indexes from users in dbx"} {
+		req, err = rel.ParseSql(sql)
+		assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+		show = req.(*rel.SqlShow)
+		assert.Equal(t, "indexes", show.ShowType)
+		assert.Equal(t, "users", show.Identity)
+		assert.Equal(t, "dbx", show.Db)
+	}
+	sql = "SHOW INDEX FROM users WHERE Key_name = 'PRIMARY'"
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	show = req.(*rel.SqlShow)
+	assert.Equal(t, "users", show.Identity)
+	assert.Equal(t, `Key_name = "PRIMARY"`, show.Where.String())
+
+	_, err = rel.ParseSql("SHOW INDEX")
+	assert.NotEqual(t, nil, err)
 }
 
 func TestSqlCommands(t *testing.T) {
@@ -574,6 +797,13 @@ func TestSqlCommands(t *testing.T) {
 	assert.True(t, cmd.Keyword() == lex.TokenSet, "has SET kw: %#v", cmd)
 	assert.True(t, len(cmd.Columns) == 1 && cmd.Columns[0].Name == "@@local.sort_buffer_size", "has autocommit: %#v", cmd.Columns)
 
+	sql = `SET GLOBAL slow_query_threshold = 500`
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	cmd = req.(*rel.SqlCommand)
+	assert.True(t, len(cmd.Columns) == 1 && cmd.Columns[0].Name == "@@global.slow_query_threshold", "has global: %#v", cmd.Columns)
+	assert.Equal(t, "@@global.slow_query_threshold = 500", cmd.Columns[0].String())
+
 	sql = "USE `myschema`;"
 	req, err = rel.ParseSql(sql)
 	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
@@ -581,6 +811,21 @@ func TestSqlCommands(t *testing.T) {
 	assert.True(t, ok, "is SqlCommand: %T", req)
 	assert.True(t, cmd.Keyword() == lex.TokenUse, "has USE kw: %#v", cmd)
 	assert.True(t, cmd.Identity == "myschema", "has myschema: %#v", cmd.Identity)
+
+	sql = "ANALYZE TABLE users, `mydb.orders`;"
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	cmd, ok = req.(*rel.SqlCommand)
+	assert.True(t, ok, "is SqlCommand: %T", req)
+	assert.Equal(t, lex.TokenAnalyze, cmd.Keyword())
+	assert.Equal(t, 2, len(cmd.Columns))
+	assert.Equal(t, "users", cmd.Columns[0].Name)
+	assert.Equal(t, "mydb.orders", cmd.Columns[1].Name)
+
+	_, err = rel.ParseSql("ANALYZE TABLE")
+	assert.NotEqual(t, nil, err)
+	_, err = rel.ParseSql("ANALYZE users")
+	assert.NotEqual(t, nil, err)
 }
 
 func TestSqlAlias(t *testing.T) {
@@ -599,6 +844,33 @@ func TestSqlAlias(t *testing.T) {
 	assert.True(t, sel.Alias == "user_query", "has alias: %v", sel.Alias)
 }
 
+func TestSqlUserVariables(t *testing.T) {
+	t.Parallel()
+	sql := `SELECT @row := @row + 1 AS rn, user_id FROM users`
+	req, err := rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	sel := req.(*rel.SqlSelect)
+	assert.Equal(t, "rn", sel.Columns[0].As)
+	bn, ok := sel.Columns[0].Expr.(*expr.BinaryNode)
+	assert.True(t, ok)
+	assert.Equal(t, lex.TokenAssign, bn.Operator.T)
+	assert.Equal(t, "@row + 1", bn.Args[1].String())
+
+	for _, sql := range []string{
+		`SELECT email, user_id INTO @e, @u FROM users WHERE user_id = "abc"`,
+		`SELECT email, user_id FROM users WHERE user_id = "abc" INTO @e, @u`,
+	} {
+		req, err = rel.ParseSql(sql)
+		assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+		sel = req.(*rel.SqlSelect)
+		assert.Equal(t, []string{"@e", "@u"}, sel.Into.Vars, sql)
+		assert.Equal(t, 1, len(sel.From), sql)
+	}
+
+	_, err = rel.ParseSql(`SELECT user_id := 1 FROM users`)
+	assert.NotEqual(t, nil, err)
+}
+
 func TestSqlUpsert(t *testing.T) {
 	t.Parallel()
 	// This is obviously not exactly sql standard
@@ -612,6 +884,60 @@ func TestSqlUpsert(t *testing.T) {
 	//assert.True(t, sel.Alias == "user_query", "has alias: %v", sel.Alias)
 }
 
+func TestSqlInsertValueRows(t *testing.T) {
+	t.Parallel()
+	// each (...) is one row, the last is not repeated at the end
+	for _, sql := range []string{
+		`INSERT INTO users (id, str) VALUES (0, "a"), (1, "b")`,
+		`INSERT INTO users (id, str) VALUES (0, "a"), (1, "b");`,
+		`INSERT INTO users (id, str) VALUES (0, "a"),(1, "b")  `,
+	} {
+		req, err := rel.ParseSql(sql)
+		assert.Equal(t, nil, err, sql)
+		ins := req.(*rel.SqlInsert)
+		assert.Equal(t, 2, len(ins.Rows), sql)
+		assert.Equal(t, int64(1), ins.Rows[1][0].Value.Value(), sql)
+		assert.Equal(t, "b", ins.Rows[1][1].Value.Value(), sql)
+	}
+
+	stmts, err := rel.ParseSqlStatements(`INSERT INTO users (id, str) VALUES (0, "a"); SELECT id FROM users`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(stmts))
+	assert.Equal(t, 1, len(stmts[0].(*rel.SqlInsert).Rows))
+}
+
+func TestSqlInsertOnError(t *testing.T) {
+	t.Parallel()
+	sql := `INSERT INTO users (id, str) VALUES (0, "a"), (1, tolower("B")) ON ERROR SKIP`
+	req, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	ins, ok := req.(*rel.SqlInsert)
+	assert.True(t, ok, "is SqlInsert: %T", req)
+	assert.True(t, ins.OnErrorSkip)
+	assert.Equal(t, 2, len(ins.Rows))
+	assert.True(t, strings.HasSuffix(ins.String(), " ON ERROR SKIP"), ins.String())
+
+	req, err = rel.ParseSql(`INSERT INTO users (id, str) VALUES (0, "a") ON ERROR ABORT`)
+	assert.Equal(t, nil, err)
+	assert.False(t, req.(*rel.SqlInsert).OnErrorSkip)
+	assert.Equal(t, 1, len(req.(*rel.SqlInsert).Rows))
+
+	parseSqlError(t, `INSERT INTO users (id, str) VALUES (0, "a") ON ERROR IGNORE`)
+	parseSqlError(t, `INSERT INTO users (id, str) VALUES (0, "a") ON SKIP`)
+
+	// the rows of a select are not skipped
+	for _, sql := range []string{
+		`INSERT INTO users (id, str) SELECT id, str FROM archive ON ERROR SKIP`,
+		`INSERT INTO users (id, str) SELECT id, str FROM archive WHERE id > 1 ON ERROR SKIP`,
+	} {
+		_, err = rel.ParseSql(sql)
+		assert.NotEqual(t, nil, err, sql)
+		if err != nil {
+			assert.True(t, strings.Contains(err.Error(), "only supported for INSERT ... VALUES"), err.Error())
+		}
+	}
+}
+
 func TestSqlMultiStatement(t *testing.T) {
 	t.Parallel()
 	sql := `SET @var1 = "hello"; select a, b from accounts where name = @var1;`
@@ -626,6 +952,12 @@ func TestSqlMultiStatement(t *testing.T) {
 	assert.True(t, ok, "wanted *SqlUpdate but got %T", stmts[1])
 	assert.True(t, sel.From[0].Name == "accounts", "has accounts: %v", sel.From[0])
 	assert.True(t, len(sel.Columns) == 2, "want 2 cols has %v", len(sel.Columns))
+
+	// statements following a FROM clause
+	raws, stmts, err := rel.SplitSqlStatements("SELECT a FROM t;\nSELECT @a + 1 AS b; SELECT x FROM y")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(stmts))
+	assert.Equal(t, []string{"SELECT a FROM t", "SELECT @a + 1 AS b", "SELECT x FROM y"}, raws)
 }
 
 func TestSqlUpdate(t *testing.T) {
@@ -665,6 +997,62 @@ func TestSqlCreate(t *testing.T) {
 	assert.Equal(t, "email hello", c2.Comment, "%+v", c2)
 	assert.Equal(t, "char", c2.DataType, "%+v", c2)
 	assert.Equal(t, 150, c2.DataTypeSize, "%+v", c2)
+	assert.Equal(t, "hello", cs.With.String("stuff"))
+
+	// session temporary tables need no ENGINE
+	req, err = rel.ParseSql(`CREATE TEMPORARY TABLE IF NOT EXISTS scores (id int, name varchar(255))`)
+	assert.Equal(t, nil, err)
+	cs = req.(*rel.SqlCreate)
+	assert.True(t, cs.Temp)
+	assert.True(t, cs.IfNotExists)
+	assert.Equal(t, "scores", cs.Identity)
+	assert.Equal(t, 2, len(cs.Cols))
+
+	for _, sql := range []string{
+		`SELECT user_id INTO TEMP active FROM users`,
+		`SELECT user_id INTO TEMPORARY active FROM users`,
+	} {
+		req, err = rel.ParseSql(sql)
+		assert.Equal(t, nil, err, sql)
+		sel := req.(*rel.SqlSelect)
+		assert.True(t, sel.Into.Temp, sql)
+		assert.Equal(t, "active", sel.Into.Table, sql)
+	}
+	_, err = rel.ParseSql(`CREATE TEMP VIEW v AS SELECT * FROM users`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSqlCreateEngine(t *testing.T) {
+	t.Parallel()
+	req, err := rel.ParseSql(`CREATE TABLE a (id bigint) ENGINE=InnoDB AUTO_INCREMENT=4080 DEFAULT CHARSET=utf8 WITH stuff = "hello"`)
+	assert.Equal(t, nil, err)
+	cs := req.(*rel.SqlCreate)
+	assert.Equal(t, map[string]interface{}{"engine": "InnoDB", "auto_increment": int64(4080), "charset": "utf8"}, cs.Engine)
+	assert.Equal(t, "hello", cs.With.String("stuff"))
+
+	req, err = rel.ParseSql(`CREATE TABLE a (id bigint) ENGINE=memdb VERSION_COLUMN=version;`)
+	assert.Equal(t, nil, err)
+	cs = req.(*rel.SqlCreate)
+	assert.Equal(t, map[string]interface{}{"engine": "memdb", "version_column": "version"}, cs.Engine)
+	assert.Equal(t, 0, len(cs.With))
+
+	_, err = rel.ParseSql(`CREATE TABLE a (id bigint) ENGINE InnoDB`)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSplitSqlStatements(t *testing.T) {
+	t.Parallel()
+	raws, stmts, err := rel.SplitSqlStatements(`CREATE TABLE a (id bigint) ENGINE=InnoDB; DROP TABLE a;`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(stmts))
+	assert.Equal(t, []string{"CREATE TABLE a (id bigint) ENGINE=InnoDB", "DROP TABLE a"}, raws)
+
+	stmts2, err := rel.ParseSqlStatements(`CREATE TABLE a (id bigint) ENGINE=InnoDB; DROP TABLE a;`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, stmts, stmts2)
+
+	_, _, err = rel.SplitSqlStatements(`DROP TABLE a; SELEC 1;`)
+	assert.NotEqual(t, nil, err)
 }
 
 func TestSqlDrop(t *testing.T) {
@@ -737,3 +1125,253 @@ func TestWithJson(t *testing.T) {
 	assert.Equal(t, 2, len(sel.With.Helper("keyobj")))
 	u.Infof("sel.With:  \n%s", sel.With.PrettyJson())
 }
+
+func TestSqlTableSample(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT a FROM big_t AS b TABLESAMPLE (0.1 PERCENT) WHERE a > 1`)
+	parseSqlTest(t, `SELECT a FROM big_t TABLESAMPLE(10 percent) INNER JOIN c ON c.x = big_t.x`)
+	parseSqlTest(t, `CREATE SAMPLE TABLE s AS SELECT * FROM big_t TABLESAMPLE(10 PERCENT)`)
+	parseSqlError(t, `SELECT a FROM big_t TABLESAMPLE (0 PERCENT)`)
+	parseSqlError(t, `SELECT a FROM big_t TABLESAMPLE (101 PERCENT)`)
+	parseSqlError(t, `SELECT a FROM big_t TABLESAMPLE (10)`)
+	parseSqlTest(t, `SELECT a FROM big_t TABLESAMPLE (100 ROWS) WHERE a > 1`)
+	parseSqlError(t, `SELECT a FROM big_t TABLESAMPLE (0 ROWS)`)
+	parseSqlError(t, `SELECT a FROM big_t TABLESAMPLE (1.5 ROWS)`)
+
+	// SAMPLE n PERCENT|ROWS is short for TABLESAMPLE, sample is still an alias
+	req, err := rel.ParseSql(`SELECT a FROM big_t SAMPLE 5 ROWS WHERE a > 1`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, rel.NewSqlSampleRows(5), req.(*rel.SqlSelect).From[0].Sample)
+	assert.Equal(t, "SELECT a FROM big_t TABLESAMPLE (5 ROWS) WHERE a > 1", req.String())
+	req, err = rel.ParseSql(`SELECT a FROM big_t sample 10 percent`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, rel.NewSqlSample(10), req.(*rel.SqlSelect).From[0].Sample)
+	req, err = rel.ParseSql(`SELECT sample.a FROM big_t AS sample WHERE sample.a > 1`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "sample", req.(*rel.SqlSelect).From[0].Alias)
+	assert.True(t, req.(*rel.SqlSelect).From[0].Sample == nil)
+
+	sql := `SELECT a FROM big_t AS b TABLESAMPLE (0.1 PERCENT) WHERE a > 1`
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	sel := req.(*rel.SqlSelect)
+	assert.Equal(t, rel.NewSqlSample(0.1), sel.From[0].Sample)
+	assert.Equal(t, "b", sel.From[0].Alias)
+	assert.Equal(t, sql, sel.String())
+
+	req, err = rel.ParseSql(`CREATE SAMPLE TABLE s AS SELECT * FROM big_t TABLESAMPLE(10 PERCENT)`)
+	assert.Equal(t, nil, err)
+	cs := req.(*rel.SqlCreate)
+	assert.Equal(t, lex.TokenSample, cs.Tok.T)
+	assert.Equal(t, "s", cs.Identity)
+	assert.Equal(t, rel.NewSqlSample(10), cs.Select.From[0].Sample)
+}
+
+func TestSqlAggFilter(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT count(*) FILTER (WHERE price > 10) AS n, sum(price) FROM orders`)
+	parseSqlTest(t, `SELECT user_id, avg(price) FILTER (WHERE status = "paid" AND price > 0) FROM orders GROUP BY user_id`)
+	parseSqlTest(t, `SELECT count_if(price > 10), sum_if(price, status = "paid") FROM orders`)
+	parseSqlError(t, `SELECT price FILTER (WHERE price > 10) FROM orders`)
+	parseSqlError(t, `SELECT count(*) FILTER (price > 10) FROM orders`)
+
+	sql := `SELECT count(*) FILTER (WHERE price > 10) AS n FROM orders`
+	req, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	sel := req.(*rel.SqlSelect)
+	assert.Equal(t, "n", sel.Columns[0].As)
+	assert.Equal(t, "price > 10", sel.Columns[0].Filter.String())
+	assert.Equal(t, sql, sel.String())
+	assert.True(t, !sel.CountStar())
+
+	// filter is still usable as a column name
+	req, err = rel.ParseSql(`SELECT filter, a FROM t`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(req.(*rel.SqlSelect).Columns))
+}
+
+func TestSqlGroupConcat(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT user_id, group_concat(DISTINCT email ORDER BY email DESC, created SEPARATOR "; ") AS e FROM users GROUP BY user_id`)
+	parseSqlTest(t, `SELECT string_agg(email, ", " ORDER BY tolower(email)) FROM users`)
+	parseSqlTest(t, `SELECT group_concat(email) FROM users`)
+	parseSqlError(t, `SELECT group_concat(email ORDER BY a ORDER BY b) FROM users`)
+	parseSqlError(t, `SELECT group_concat(email ORDER BY a SEPARATOR ",", "x") FROM users`)
+
+	for _, sql := range []string{
+		`SELECT group_concat(DISTINCT email ORDER BY email DESC, created SEPARATOR "; ") AS e FROM users`,
+		`SELECT string_agg(email, ", " ORDER BY tolower(email)) FROM users`,
+		`SELECT group_concat(email) FROM users`,
+	} {
+		req, err := rel.ParseSql(sql)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, sql, req.String())
+	}
+
+	// separator, order, distinct are still column names
+	parseSqlTest(t, `SELECT tolower(separator), tolower(desc) FROM t`)
+}
+
+func TestSqlPivot(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT * FROM sales PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2, 3)) WHERE region = "west"`)
+	parseSqlTest(t, `SELECT region, q FROM sales_wide AS w UNPIVOT (amount FOR quarter IN (q1, q2 AS second))`)
+	parseSqlTest(t, `SELECT * FROM a AS a INNER JOIN sales AS s PIVOT (count(amount) FOR quarter IN ("Q1")) ON a.x = s.x`)
+	parseSqlError(t, `SELECT * FROM sales PIVOT (amount FOR quarter IN ("Q1"))`)
+	parseSqlError(t, `SELECT * FROM sales PIVOT (sum(amount) FOR quarter IN (q1))`)
+	parseSqlError(t, `SELECT * FROM sales UNPIVOT (amount FOR quarter IN ("Q1"))`)
+
+	sql := `SELECT * FROM sales PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2)) WHERE region = "west"`
+	req, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	sel := req.(*rel.SqlSelect)
+	pv := sel.From[0].Pivot
+	assert.NotEqual(t, nil, pv)
+	assert.False(t, pv.Unpivot)
+	assert.Equal(t, "sum(amount)", pv.Agg.String())
+	assert.Equal(t, "quarter", pv.For)
+	assert.Equal(t, 2, len(pv.In))
+	assert.Equal(t, "Q1", pv.In[0].Name())
+	assert.Equal(t, "q2", pv.In[1].Name())
+	assert.Equal(t, sql, sel.String())
+
+	pv2, err := rel.ParseSqlPivot(pv.String())
+	assert.Equal(t, nil, err)
+	assert.True(t, pv.Equal(pv2))
+
+	req, err = rel.ParseSql(`SELECT * FROM sales_wide UNPIVOT (amount FOR quarter IN (q1, q2 AS second))`)
+	assert.Equal(t, nil, err)
+	pv = req.(*rel.SqlSelect).From[0].Pivot
+	assert.True(t, pv.Unpivot)
+	assert.Equal(t, "amount", pv.Value)
+	assert.Equal(t, "q1", pv.In[0].Name())
+	assert.Equal(t, "second", pv.In[1].Name())
+}
+
+func TestSqlValues(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `SELECT * FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name) WHERE id > 1`)
+	parseSqlTest(t, `SELECT * FROM (VALUES (1, now()), (-2, NULL)) t`)
+	parseSqlTest(t, `SELECT u.name, r.label FROM users AS u INNER JOIN (VALUES (1, "gold")) AS r(id, label) ON u.id = r.id`)
+	parseSqlError(t, `SELECT * FROM (VALUES (1, 'a'), (2)) AS t`)
+	parseSqlError(t, `SELECT * FROM (VALUES (1, 'a')) AS t(id)`)
+
+	sql := `SELECT * FROM (VALUES (1, "a"), (2, "b")) AS t(id, name) WHERE id > 1`
+	req, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	sel := req.(*rel.SqlSelect)
+	vals := sel.From[0].Values
+	assert.NotEqual(t, nil, vals)
+	assert.Equal(t, "t", sel.From[0].Alias)
+	assert.Equal(t, []string{"id", "name"}, vals.ColumnNames())
+	assert.Equal(t, 2, len(vals.Rows))
+	assert.Equal(t, sql, sel.String())
+
+	vals2, err := rel.ParseSqlValues(vals.String())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `VALUES (1, "a"), (2, "b")`, vals2.String())
+
+	req, err = rel.ParseSql(`SELECT * FROM (VALUES (1, "a")) AS t`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"column1", "column2"}, req.(*rel.SqlSelect).From[0].Values.ColumnNames())
+}
+
+func TestSqlCall(t *testing.T) {
+	t.Parallel()
+	parseSqlTest(t, `CALL refresh_stats`)
+	parseSqlTest(t, `CALL refresh_stats()`)
+	parseSqlTest(t, `CALL top_users(10, "bob", 2.5, now());`)
+
+	req, err := rel.ParseSql(`CALL top_users(10, "bob", now())`)
+	assert.Equal(t, nil, err)
+	call, ok := req.(*rel.SqlCall)
+	assert.True(t, ok, "wanted SqlCall got %T", req)
+	assert.Equal(t, lex.TokenCall, call.Keyword())
+	assert.Equal(t, "top_users", call.Name)
+	assert.Equal(t, 3, len(call.Args))
+	assert.Equal(t, int64(10), call.Args[0].Value.Value())
+	assert.Equal(t, "bob", call.Args[1].Value.Value())
+	assert.NotEqual(t, nil, call.Args[2].Expr)
+	assert.Equal(t, "CALL top_users(10, \"bob\", now())", call.String())
+
+	_, stmts, err := rel.SplitSqlStatements(`CALL refresh_stats(); SELECT 1`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(stmts))
+
+	parseSqlError(t, `CALL`)
+	parseSqlError(t, `CALL top_users 10`)
+	parseSqlError(t, `CALL top_users(10)(20)`)
+}
+
+func TestParseSqlMode(t *testing.T) {
+	t.Parallel()
+	// double quotes are identities, || concatenation
+	req, err := rel.ParseSqlMode(`SELECT "first name" || ' ' || last AS name FROM "users" AS u
+		WHERE "u"."id" = 'abc' OR active = TRUE`, lex.ModePostgres)
+	assert.Equal(t, nil, err)
+	sel := req.(*rel.SqlSelect)
+	assert.Equal(t, "users", sel.From[0].Name)
+	assert.Equal(t, "concat(`first name`, \" \", last)", sel.Columns[0].Expr.String())
+	assert.Equal(t, "u.id = \"abc\" OR active = TRUE", sel.Where.Expr.String())
+
+	// the same query as mysql, double quotes are strings, || is OR
+	req, err = rel.ParseSqlMode(`SELECT "first name" || last AS name FROM users`, lex.ModeMySql)
+	assert.Equal(t, nil, err)
+	sel = req.(*rel.SqlSelect)
+	bn, ok := sel.Columns[0].Expr.(*expr.BinaryNode)
+	assert.True(t, ok, "wanted BinaryNode got %T", sel.Columns[0].Expr)
+	assert.Equal(t, lex.TokenOr, bn.Operator.T)
+
+	// ansi FETCH FIRST is a limit
+	req, err = rel.ParseSqlMode(`SELECT a FROM "t" ORDER BY a OFFSET 20 ROWS FETCH FIRST 10 ROWS ONLY`, lex.ModeAnsi)
+	assert.Equal(t, nil, err)
+	sel = req.(*rel.SqlSelect)
+	assert.Equal(t, 10, sel.Limit)
+	assert.Equal(t, 20, sel.Offset)
+	assert.Equal(t, 1, len(sel.OrderBy))
+	sel, err = rel.ParseSqlSelect(`SELECT a FROM t FETCH NEXT ROW ONLY`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, sel.Limit)
+	sel, err = rel.ParseSqlSelect(`SELECT a FROM t ORDER BY a FETCH FIRST 5 ROWS ONLY`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, sel.Limit)
+
+	// fetch is still a column name outside of FETCH FIRST|NEXT
+	sel, err = rel.ParseSqlSelect(`SELECT fetch FROM t WHERE fetch > 1 ORDER BY fetch`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fetch", sel.Columns[0].As)
+	assert.Equal(t, 0, sel.Limit)
+	assert.Equal(t, 1, len(sel.OrderBy))
+
+	raws, stmts, err := rel.SplitSqlStatementsMode(`SELECT "a" FROM t; SELECT 'b' || "c" FROM t`, lex.ModePostgres)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(stmts))
+	assert.Equal(t, []string{"SELECT `a` FROM t", "SELECT 'b' || `c` FROM t"}, raws)
+}
+
+func TestParseSqlRecover(t *testing.T) {
+	t.Parallel()
+
+	sql := "SELECT count( FROM t WHERE x = = 1 LIMIT y;\n" +
+		"SELECT 'a;b' FROM t; -- c;\n" +
+		"SELEC a FROM t;\n" +
+		"SELECT a FROM t\nWHERE x >\nGROUP BY a"
+	stmts, err := rel.ParseSqlRecover(sql)
+	assert.Equal(t, 1, len(stmts))
+	serr, ok := err.(*rel.SyntaxErrors)
+	assert.True(t, ok, "wanted SyntaxErrors got %T", err)
+	type at struct{ line, col int }
+	var got []at
+	for _, e := range serr.Errors {
+		got = append(got, at{e.Line, e.Column})
+	}
+	// one error per clause: select list, where, limit, request type, where
+	assert.Equal(t, []at{{1, 16}, {1, 32}, {1, 43}, {3, 1}, {6, 1}}, got)
+	assert.Equal(t, "SELECT count( FROM t WHERE x = = 1 LIMIT y;\n"+
+		"                               ^", serr.Errors[1].Snippet(sql))
+	assert.Contains(t, err.Error(), "line 6 column 1: ")
+
+	stmts, err = rel.ParseSqlRecover("SELECT a FROM t; SELECT b FROM u")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(stmts))
+}
@@ -9,6 +9,7 @@ import (
 	"hash/fnv"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 
 	u "github.com/araddon/gou"
@@ -29,6 +30,7 @@ var (
 	_ SqlStatement = (*SqlShow)(nil)
 	_ SqlStatement = (*SqlDescribe)(nil)
 	_ SqlStatement = (*SqlCommand)(nil)
+	_ SqlStatement = (*SqlCall)(nil)
 	_ SqlStatement = (*SqlInto)(nil)
 
 	// sub-query statements
@@ -96,6 +98,7 @@ type (
 		OrderBy   Columns
 		Limit     int
 		Offset    int
+		Hints     SqlHints     // optional /*+ ... */ optimizer hints
 		Alias     string       // Non-Standard sql, alias/name of sql another way of expression Prepared Statement
 		With      u.JsonHelper // Non-Standard SQL for properties/config info, similar to Cassandra with, purse json
 		proj      *Projection  // Projected fields
@@ -128,12 +131,77 @@ type (
 		JoinType    lex.TokenType      // INNER, OUTER
 		JoinExpr    expr.Node          // Join expression       x.y = q.y
 		SubQuery    *SqlSelect         // optional, Join/SubSelect statement
+		IndexHints  []*IndexHint       // optional USE/FORCE/IGNORE INDEX hints for this table
+		Sample      *SqlSample         // optional TABLESAMPLE clause for this table
+		Stats       *SqlStatsHint      // optional /*+ ROWS(n) NDV(col, n) */ statistics hint
+		Pivot       *SqlPivot          // optional PIVOT | UNPIVOT of this table
+		Values      *SqlValues         // optional VALUES rows of an inline table
 
 		// Plan Hints, move to a dedicated planner
 		Seekable bool
 		// Memoized sql, we assume this is an immuteable struct so if this is populated use it
 		pb *SqlSourcePb
 	}
+	// IndexHint is a mysql style index hint on a table reference
+	// - FROM users USE INDEX (idx_email)
+	// - JOIN orders FORCE INDEX FOR JOIN (idx_user_id)
+	// - FROM users IGNORE KEY FOR ORDER BY (idx_created)
+	IndexHint struct {
+		Type    lex.TokenType // (USE | FORCE | IGNORE)
+		For     lex.TokenType // optional (JOIN | ORDER BY | GROUP BY), 0 = all
+		Indexes []string      // index names, may be empty for USE INDEX ()
+	}
+	// SqlSample is a TABLESAMPLE clause on a table reference, reading a
+	// random sample of rows, either a (Bernoulli) percent of rows or a
+	// (reservoir) fixed number of rows
+	// - FROM big_t TABLESAMPLE (0.1 PERCENT)
+	// - FROM big_t TABLESAMPLE (1000 ROWS)
+	// - FROM big_t SAMPLE 10 PERCENT
+	SqlSample struct {
+		Percent float64 // percent of rows to sample, (0, 100], 0 for a rows sample
+		Rows    int64   // number of rows to sample, 0 for a percent sample
+	}
+	// SqlPivot is a PIVOT or UNPIVOT operator on a table reference.  PIVOT
+	// turns values of the For column into columns, aggregating Agg per
+	// group of the remaining columns, UNPIVOT turns the In columns into
+	// rows of (For, Value) name, value pairs
+	// - FROM sales PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2))
+	// - FROM sales_wide UNPIVOT (amount FOR quarter IN (q1, q2))
+	SqlPivot struct {
+		Unpivot bool          // UNPIVOT instead of PIVOT
+		Agg     expr.Node     // PIVOT aggregate, ie sum(amount)
+		Value   string        // UNPIVOT output value column name
+		For     string        // PIVOT column whose values become columns, UNPIVOT output name column
+		In      []*SqlPivotIn // PIVOT values, UNPIVOT columns
+	}
+	// SqlPivotIn is one value (PIVOT) or column (UNPIVOT) of the IN list of
+	// a pivot, optionally aliased
+	SqlPivotIn struct {
+		Value expr.Node // literal value (PIVOT) or identity (UNPIVOT)
+		As    string    // optional alias
+	}
+	// SqlValues is a VALUES row constructor used as an inline table of
+	// literal rows, its columns are named column1, column2 ... unless named
+	// after its alias
+	// - FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name)
+	SqlValues struct {
+		Columns []string         // optional column names
+		Rows    [][]*ValueColumn // rows of literal expressions
+	}
+	// SqlStatsHint is an inline statistics hint on a table reference, for
+	// the cost model when the table hasn't been analyzed or can't be sampled
+	// - FROM big_table /*+ ROWS(2e9) NDV(user_id, 5e7) */
+	SqlStatsHint struct {
+		Rows float64            // ROWS(n) estimated row count, 0 if not hinted
+		Ndv  map[string]float64 // NDV(col, n) estimated distinct values per column
+	}
+	// SqlHint is an optimizer hint of a select, an escape hatch for when the
+	// planner picks a bad strategy
+	// - SELECT /*+ HASH_JOIN(o, u) NO_PUSHDOWN(u) PARALLEL(4) */ ...
+	SqlHint struct {
+		Name string   // upper case hint name, HASH_JOIN
+		Args []string // table names, or values
+	}
 	// SqlWhere WHERE is select stmt, or set of expressions
 	// - WHERE x in (select name from q)
 	// - WHERE x = y
@@ -149,11 +217,12 @@ type (
 	}
 	// SqlInsert SQL Insert Statement
 	SqlInsert struct {
-		kw      lex.TokenType    // Insert, Replace
-		Table   string           // table name
-		Columns Columns          // Column Names
-		Rows    [][]*ValueColumn // Values to insert
-		Select  *SqlSelect       //
+		kw          lex.TokenType    // Insert, Replace
+		Table       string           // table name
+		Columns     Columns          // Column Names
+		Rows        [][]*ValueColumn // Values to insert
+		Select      *SqlSelect       //
+		OnErrorSkip bool             // VALUES ... ON ERROR SKIP, failed rows are skipped not failing the insert
 	}
 	// SqlUpsert SQL Upsert Statement
 	SqlUpsert struct {
@@ -195,10 +264,13 @@ type (
 		Identity string    // Describe
 		Tok      lex.Token // Explain, Describe, Desc
 		Stmt     SqlStatement
+		Analyze  bool // EXPLAIN ANALYZE, run the statement reporting its actual rows, time, memory
 	}
 	// SqlInto   INTO statement   (select a,b,c from y INTO z)
 	SqlInto struct {
 		Table string
+		Temp  bool     // SELECT ... INTO TEMP table, a session temporary table
+		Vars  []string // SELECT ... INTO @var1, @var2
 	}
 	// SqlCommand is admin command such as "SET", "USE"
 	SqlCommand struct {
@@ -207,12 +279,19 @@ type (
 		Identity string         //
 		Value    expr.Node      //
 	}
+	// SqlCall CALL of a stored procedure
+	//    CALL name(arg, arg)
+	SqlCall struct {
+		Name string         // procedure name
+		Args []*ValueColumn // literal or expression args
+	}
 	// SqlCreate SQL CREATE statement
 	SqlCreate struct {
 		Raw         string       // full original raw statement
 		Identity    string       // identity of table, view, etc
 		Tok         lex.Token    // CREATE [TABLE,VIEW,CONTINUOUSVIEW,TRIGGER] etc
 		OrReplace   bool         // OR REPLACE
+		Temp        bool         // CREATE TEMPORARY TABLE, session scoped
 		IfNotExists bool         // IF NOT EXISTS
 		Cols        []*DdlColumn // columns
 		Engine      map[string]interface{}
@@ -253,10 +332,12 @@ type (
 		As              string    // As field, auto-populate the Field Name if exists
 		Comment         string    // optional in-line comments
 		Order           string    // (ASC | DESC)
+		Nulls           string    // (FIRST | LAST) ORDER BY col NULLS FIRST, empty if not specified
 		Star            bool      // *
 		Agg             bool      // aggregate function column?   count(*), avg(x) etc
 		Expr            expr.Node // Expression, optional, often Identity.Node
 		Guard           expr.Node // column If guard, non-standard sql column guard
+		Filter          expr.Node // aggregate FILTER (WHERE <expr>), rows it is not true for are not aggregated
 	}
 	// ValueColumn List of Value columns in INSERT into TABLE (colnames) VALUES (valuecolumns)
 	ValueColumn struct {
@@ -370,13 +451,15 @@ func NewSqlWhere(where expr.Node) *SqlWhere {
 	return &SqlWhere{Expr: where}
 }
 func NewColumnFromToken(tok lex.Token) *Column {
-	_, r, _ := expr.LeftRight(tok.V)
-	v := tok.V
-	if tok.Quote != 0 {
-		//v = expr.IdentityMaybeQuote(tok.Quote, v)
+	// quoted identities may have periods, `user.name` is one field while
+	// `user`.`name` is field name of user
+	l, r, _ := expr.NewIdentityNode(&tok).LeftRight()
+	v, as := tok.V, tok.V
+	if tok.Quote != 0 && l != "" {
+		as = l + "." + r
 	}
 	return &Column{
-		As:              tok.V,
+		As:              as,
 		sourceQuoteByte: tok.Quote,
 		asQuoteByte:     tok.Quote,
 		SourceField:     r,
@@ -530,6 +613,13 @@ func (m *Projection) ToPB() *ProjectionPb {
 	}
 	return m.pb
 }
+
+// Clone deep copy of this projection, shared nothing.
+func (m *Projection) Clone() *Projection {
+	p := ProjectionFromPb(projectionToPb(m))
+	p.Final = m.Final
+	return p
+}
 func ProjectionFromPb(pb *ProjectionPb) *Projection {
 	s := Projection{}
 	s.Distinct = pb.GetDistinct()
@@ -656,6 +746,11 @@ func (m *Column) WriteDialect(w expr.DialectWriter) {
 			exprStr = w.String()[start:]
 		}
 	}
+	if m.Filter != nil {
+		io.WriteString(w, " FILTER (WHERE ")
+		m.Filter.WriteDialect(w)
+		io.WriteString(w, ")")
+	}
 
 	if m.asQuoteByte != 0 && m.originalAs != "" {
 		io.WriteString(w, " AS ")
@@ -674,6 +769,10 @@ func (m *Column) WriteDialect(w expr.DialectWriter) {
 		io.WriteString(w, " ")
 		io.WriteString(w, m.Order)
 	}
+	if m.Nulls != "" {
+		io.WriteString(w, " NULLS ")
+		io.WriteString(w, m.Nulls)
+	}
 }
 
 // Is this a select count(*) column
@@ -705,7 +804,7 @@ func (m *Column) IsLiteral() bool {
 		if len(idents) > 0 {
 			return false
 		}
-		if m.Agg && m.CountStar() {
+		if m.Agg && m.Filter == nil && m.CountStar() {
 			return true
 		}
 	case *expr.IdentityNode:
@@ -736,7 +835,11 @@ func (m *Column) IsLiteralOrFunc() bool {
 		if n.IsBooleanIdentity() {
 			return true
 		}
-		return false
+		// @var, @@sysvar are session variables not source columns
+		return strings.HasPrefix(n.Text, "@")
+	case *expr.BinaryNode:
+		// @var := expr
+		return n.Operator.T == lex.TokenAssign
 	case *expr.StringNode, *expr.NumberNode, *expr.ValueNode:
 		return true
 	}
@@ -792,6 +895,9 @@ func (m *Column) Equal(c *Column) bool {
 	if m.Order != c.Order {
 		return false
 	}
+	if m.Nulls != c.Nulls {
+		return false
+	}
 	if m.Star != c.Star {
 		return false
 	}
@@ -805,6 +911,12 @@ func (m *Column) Equal(c *Column) bool {
 			return false
 		}
 	}
+	if (m.Filter == nil) != (c.Filter == nil) {
+		return false
+	}
+	if m.Filter != nil && !m.Filter.Equal(c.Filter) {
+		return false
+	}
 	return true
 }
 
@@ -838,9 +950,11 @@ func (m *Column) Copy() *Column {
 		As:              m.right,
 		Comment:         m.Comment,
 		Order:           m.Order,
+		Nulls:           m.Nulls,
 		Star:            m.Star,
 		Expr:            m.Expr,
 		Guard:           m.Guard,
+		Filter:          m.Filter,
 	}
 }
 func (m *Column) ToPB() *ColumnPb {
@@ -878,6 +992,9 @@ func (m *Column) ToPB() *ColumnPb {
 	if m.Guard != nil {
 		n.Guard = m.Guard.NodePb()
 	}
+	if m.Filter != nil {
+		n.Filter = m.Filter.NodePb()
+	}
 	return &n
 }
 func columnFromPb(c *ColumnPb) *Column {
@@ -896,6 +1013,7 @@ func columnFromPb(c *ColumnPb) *Column {
 		Star:            c.GetStar(),
 		Expr:            expr.NodeFromNodePb(c.GetExpr()),
 		Guard:           expr.NodeFromNodePb(c.GetGuard()),
+		Filter:          expr.NodeFromNodePb(c.GetFilter()),
 	}
 }
 
@@ -943,6 +1061,26 @@ func (m *SqlSelect) Copy() *SqlSelect {
 	return selCopy
 }
 
+// Clone deep copy of this select, including rewritten From sources.  Unlike
+// Copy it does not use the memoized pb, so reflects any modifications made
+// since it was serialized.
+func (m *SqlSelect) Clone() *SqlSelect {
+	sel := SqlSelectFromPb(SqlSelectToPb(m))
+	for i, from := range m.From {
+		if i >= len(sel.From) {
+			break
+		}
+		switch {
+		case from.Source == m:
+			// single source selects use the select itself as source
+			sel.From[i].Source = sel
+		case from.Source != nil:
+			sel.From[i].Source = from.Source.Clone()
+		}
+	}
+	return sel
+}
+
 // SqlSelectToPb Given a select statement lets convert it into a PB statement
 func SqlSelectToPb(m *SqlSelect) *SqlSelectPb {
 	return sqlSelectToPbDepth(m, 0)
@@ -997,6 +1135,10 @@ func sqlSelectToPbDepth(m *SqlSelect, depth int) *SqlSelectPb {
 	if m.Into != nil {
 		s.Into = &m.Into.Table
 	}
+	if len(m.Hints) > 0 {
+		hints := m.Hints.String()
+		s.Hints = &hints
+	}
 	return &s
 }
 func (m *SqlSelect) Equal(ss SqlStatement) bool {
@@ -1046,6 +1188,14 @@ func (m *SqlSelect) Equal(ss SqlStatement) bool {
 	if !m.Into.Equal(s.Into) {
 		return false
 	}
+	if len(m.Hints) != len(s.Hints) {
+		return false
+	}
+	for i, h := range m.Hints {
+		if !h.Equal(s.Hints[i]) {
+			return false
+		}
+	}
 	if m.Where != nil && !m.Where.Equal(s.Where) {
 		return false
 	}
@@ -1106,7 +1256,10 @@ func SqlSelectFromPb(pb *SqlSelectPb) *SqlSelect {
 		schemaqry: pb.GetSchemaqry(),
 	}
 	if pb.Into != nil {
-		ss.Into = &SqlInto{pb.GetInto()}
+		ss.Into = &SqlInto{Table: pb.GetInto()}
+	}
+	if pb.Hints != nil {
+		ss.Hints, _ = ParseSqlHints(pb.GetHints())
 	}
 	if pb.Where != nil {
 		ss.Where = SqlWhereFromPb(pb.GetWhere())
@@ -1152,13 +1305,17 @@ func (m *SqlSelect) String() string {
 func (m *SqlSelect) writeDialectDepth(depth int, w expr.DialectWriter) {
 
 	io.WriteString(w, "SELECT ")
+	if len(m.Hints) > 0 {
+		m.Hints.WriteDialect(w)
+		io.WriteString(w, " ")
+	}
 	if m.Distinct {
 		io.WriteString(w, "DISTINCT ")
 	}
 	m.Columns.WriteDialect(w)
 	if m.Into != nil {
 		io.WriteString(w, " INTO ")
-		w.WriteIdentity(m.Into.Table)
+		m.Into.WriteDialect(w)
 	}
 	if m.From != nil {
 		io.WriteString(w, " FROM")
@@ -1280,7 +1437,7 @@ func (m *SqlSelect) CountStar() bool {
 		return false
 	}
 	col := m.Columns[0]
-	if col.Expr == nil {
+	if col.Expr == nil || col.Filter != nil {
 		return false
 	}
 	if f, ok := col.Expr.(*expr.FuncNode); ok {
@@ -1308,6 +1465,12 @@ func (m *SqlSelect) RewriteAsRawSelect() {
 }
 
 func (m *SqlSource) IsLiteral() bool        { return len(m.Name) == 0 }
+
+// IsNamed is name (case insensitive) the alias or table name of this source.
+func (m *SqlSource) IsNamed(name string) bool {
+	return strings.EqualFold(name, m.Alias) || strings.EqualFold(name, m.Name) ||
+		strings.EqualFold(name, m.SourceName())
+}
 func (m *SqlSource) Keyword() lex.TokenType { return m.Op }
 func (m *SqlSource) SourceName() string {
 	if m == nil {
@@ -1337,10 +1500,16 @@ func (m *SqlSource) WriteDialect(w expr.DialectWriter) {
 func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 
 	if int(m.Op) == 0 && int(m.LeftOrRight) == 0 && int(m.JoinType) == 0 {
+		if m.Values != nil {
+			m.writeValues(w)
+			m.writeIndexHints(w)
+			return
+		}
 		if m.Alias != "" {
 			w.WriteIdentity(m.Name)
 			io.WriteString(w, " AS ")
 			w.WriteIdentity(m.Alias)
+			m.writeIndexHints(w)
 			return
 		}
 		if m.Schema == "" {
@@ -1350,6 +1519,7 @@ func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 			io.WriteString(w, ".")
 			w.WriteIdentity(m.Name)
 		}
+		m.writeIndexHints(w)
 		return
 	}
 
@@ -1361,7 +1531,9 @@ func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 	}
 	io.WriteString(w, "JOIN ")
 
-	if m.SubQuery != nil {
+	if m.Values != nil {
+		m.writeValues(w)
+	} else if m.SubQuery != nil {
 		io.WriteString(w, "(\n"+strings.Repeat("\t", depth+1))
 		m.SubQuery.writeDialectDepth(depth+1, w)
 		io.WriteString(w, "\n"+strings.Repeat("\t", depth)+")")
@@ -1375,10 +1547,11 @@ func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 		}
 
 	}
-	if m.Alias != "" {
+	if m.Alias != "" && m.Values == nil {
 		io.WriteString(w, " AS ")
 		w.WriteIdentity(m.Alias)
 	}
+	m.writeIndexHints(w)
 
 	io.WriteString(w, " ")
 	io.WriteString(w, strings.ToTitle(m.Op.String()))
@@ -1389,6 +1562,48 @@ func (m *SqlSource) writeDialectDepth(depth int, w expr.DialectWriter) {
 	}
 }
 
+// writeValues write the inline VALUES table, its alias and column names
+//
+//    (VALUES (1, 'a'), (2, 'b')) AS t(id, name)
+//
+func (m *SqlSource) writeValues(w expr.DialectWriter) {
+	io.WriteString(w, "(")
+	m.Values.WriteDialect(w)
+	io.WriteString(w, ")")
+	if m.Alias == "" {
+		return
+	}
+	io.WriteString(w, " AS ")
+	w.WriteIdentity(m.Alias)
+	if len(m.Values.Columns) > 0 {
+		io.WriteString(w, "(")
+		for i, col := range m.Values.Columns {
+			if i != 0 {
+				io.WriteString(w, ", ")
+			}
+			w.WriteIdentity(col)
+		}
+		io.WriteString(w, ")")
+	}
+}
+func (m *SqlSource) writeIndexHints(w expr.DialectWriter) {
+	for _, hint := range m.IndexHints {
+		io.WriteString(w, " ")
+		hint.WriteDialect(w)
+	}
+	if m.Sample != nil {
+		io.WriteString(w, " ")
+		m.Sample.WriteDialect(w)
+	}
+	if m.Stats != nil {
+		io.WriteString(w, " ")
+		m.Stats.WriteDialect(w)
+	}
+	if m.Pivot != nil {
+		io.WriteString(w, " ")
+		m.Pivot.WriteDialect(w)
+	}
+}
 func (m *SqlSource) BuildColIndex(colNames []string) error {
 	if len(m.colIndex) == 0 {
 		m.colIndex = make(map[string]int, len(colNames))
@@ -1533,6 +1748,15 @@ func (m *SqlSource) Finalize() error {
 func (m *SqlSource) FromPB(n *SqlSourcePb) *SqlSource {
 	return SqlSourceFromPb(n)
 }
+
+// Clone deep copy of this source, including rewritten source select.
+func (m *SqlSource) Clone() *SqlSource {
+	src := SqlSourceFromPb(sqlSourceToPb(m))
+	if m.Source != nil {
+		src.Source = m.Source.Clone()
+	}
+	return src
+}
 func (m *SqlSource) ToPB() *SqlSourcePb {
 	if m.pb == nil {
 		m.pb = sqlSourceToPb(m)
@@ -1577,6 +1801,26 @@ func (m *SqlSource) Equal(s *SqlSource) bool {
 	if m.Seekable != s.Seekable {
 		return false
 	}
+	if len(m.IndexHints) != len(s.IndexHints) {
+		return false
+	}
+	for i, hint := range m.IndexHints {
+		if !hint.Equal(s.IndexHints[i]) {
+			return false
+		}
+	}
+	if !m.Sample.Equal(s.Sample) {
+		return false
+	}
+	if !m.Stats.Equal(s.Stats) {
+		return false
+	}
+	if !m.Pivot.Equal(s.Pivot) {
+		return false
+	}
+	if !m.Values.Equal(s.Values) {
+		return false
+	}
 	if m.JoinExpr != nil && !m.JoinExpr.Equal(s.JoinExpr) {
 		return false
 	}
@@ -1656,9 +1900,534 @@ func sqlSourceToPb(m *SqlSource) *SqlSourcePb {
 	if m.JoinExpr != nil {
 		s.JoinExpr = m.JoinExpr.NodePb()
 	}
+	if len(m.IndexHints) > 0 {
+		s.IndexHints = make([]*IndexHintPb, len(m.IndexHints))
+		for i, hint := range m.IndexHints {
+			s.IndexHints[i] = hint.ToPB()
+		}
+	}
+	if m.Sample != nil && m.Sample.Rows > 0 {
+		rows := m.Sample.Rows
+		s.SampleRows = &rows
+	} else if m.Sample != nil {
+		pct := m.Sample.Percent
+		s.SamplePercent = &pct
+	}
+	if m.Stats != nil {
+		hint := m.Stats.String()
+		s.StatsHint = &hint
+	}
+	if m.Pivot != nil {
+		pivot := m.Pivot.String()
+		s.Pivot = &pivot
+	}
+	if m.Values != nil {
+		values := m.Values.String()
+		s.Values = &values
+		s.ValuesColumns = m.Values.Columns
+	}
 
 	return &s
 }
+
+// NewIndexHint create an index hint of type (USE | FORCE | IGNORE).
+func NewIndexHint(hintType lex.TokenType, indexes ...string) *IndexHint {
+	return &IndexHint{Type: hintType, Indexes: indexes}
+}
+func (m *IndexHint) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *IndexHint) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, strings.ToUpper(m.Type.String()))
+	io.WriteString(w, " INDEX ")
+	if m.For != 0 {
+		io.WriteString(w, "FOR ")
+		io.WriteString(w, strings.ToUpper(m.For.String()))
+		io.WriteString(w, " ")
+	}
+	io.WriteString(w, "(")
+	for i, idx := range m.Indexes {
+		if i > 0 {
+			io.WriteString(w, ", ")
+		}
+		w.WriteIdentity(idx)
+	}
+	io.WriteString(w, ")")
+}
+func (m *IndexHint) Equal(s *IndexHint) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if m.Type != s.Type || m.For != s.For || len(m.Indexes) != len(s.Indexes) {
+		return false
+	}
+	for i, idx := range m.Indexes {
+		if idx != s.Indexes[i] {
+			return false
+		}
+	}
+	return true
+}
+func (m *IndexHint) ToPB() *IndexHintPb {
+	return &IndexHintPb{Type: int32(m.Type), For: int32(m.For), Indexes: m.Indexes}
+}
+func indexHintFromPb(pb *IndexHintPb) *IndexHint {
+	return &IndexHint{
+		Type:    lex.TokenType(pb.GetType()),
+		For:     lex.TokenType(pb.GetFor()),
+		Indexes: pb.GetIndexes(),
+	}
+}
+
+// NewSqlSample create a TABLESAMPLE clause sampling percent of rows.
+func NewSqlSample(percent float64) *SqlSample {
+	return &SqlSample{Percent: percent}
+}
+
+// NewSqlSampleRows create a TABLESAMPLE clause sampling a fixed number of rows.
+func NewSqlSampleRows(rows int64) *SqlSample {
+	return &SqlSample{Rows: rows}
+}
+func (m *SqlSample) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlSample) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, "TABLESAMPLE (")
+	if m.Rows > 0 {
+		io.WriteString(w, strconv.FormatInt(m.Rows, 10))
+		io.WriteString(w, " ROWS)")
+		return
+	}
+	io.WriteString(w, strconv.FormatFloat(m.Percent, 'f', -1, 64))
+	io.WriteString(w, " PERCENT)")
+}
+func (m *SqlSample) Equal(s *SqlSample) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	return m.Percent == s.Percent && m.Rows == s.Rows
+}
+
+// ParseSqlPivot parse a PIVOT or UNPIVOT clause as written by SqlPivot.String().
+//
+//    PIVOT (sum(amount) FOR quarter IN ("Q1", "Q2" AS q2))
+//
+func ParseSqlPivot(pivot string) (*SqlPivot, error) {
+	sel, err := ParseSqlSelect("SELECT * FROM pivot_source " + pivot)
+	if err != nil {
+		return nil, err
+	}
+	if len(sel.From) != 1 || sel.From[0].Pivot == nil {
+		return nil, fmt.Errorf("expected PIVOT or UNPIVOT clause: %q", pivot)
+	}
+	return sel.From[0].Pivot, nil
+}
+func (m *SqlPivot) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlPivot) WriteDialect(w expr.DialectWriter) {
+	if m.Unpivot {
+		io.WriteString(w, "UNPIVOT (")
+		w.WriteIdentity(m.Value)
+	} else {
+		io.WriteString(w, "PIVOT (")
+		m.Agg.WriteDialect(w)
+	}
+	io.WriteString(w, " FOR ")
+	w.WriteIdentity(m.For)
+	io.WriteString(w, " IN (")
+	for i, in := range m.In {
+		if i != 0 {
+			io.WriteString(w, ", ")
+		}
+		in.Value.WriteDialect(w)
+		if in.As != "" {
+			io.WriteString(w, " AS ")
+			w.WriteIdentity(in.As)
+		}
+	}
+	io.WriteString(w, "))")
+}
+func (m *SqlPivot) Equal(s *SqlPivot) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if m.Unpivot != s.Unpivot || m.Value != s.Value || m.For != s.For || len(m.In) != len(s.In) {
+		return false
+	}
+	if (m.Agg == nil) != (s.Agg == nil) || (m.Agg != nil && !m.Agg.Equal(s.Agg)) {
+		return false
+	}
+	for i, in := range m.In {
+		if in.As != s.In[i].As || !in.Value.Equal(s.In[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSqlValues parse the rows of a VALUES table as written by
+// SqlValues.String().
+//
+//    VALUES (1, 'a'), (2, 'b')
+//
+func ParseSqlValues(values string) (*SqlValues, error) {
+	sel, err := ParseSqlSelect("SELECT * FROM (" + values + ") AS v")
+	if err != nil {
+		return nil, err
+	}
+	if len(sel.From) != 1 || sel.From[0].Values == nil {
+		return nil, fmt.Errorf("expected VALUES rows: %q", values)
+	}
+	return sel.From[0].Values, nil
+}
+func (m *SqlValues) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+
+// WriteDialect write the rows, not the column names which follow the alias
+// of the table.
+func (m *SqlValues) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, "VALUES ")
+	for i, row := range m.Rows {
+		if i != 0 {
+			io.WriteString(w, ", ")
+		}
+		io.WriteString(w, "(")
+		for vi, val := range row {
+			if vi != 0 {
+				io.WriteString(w, ", ")
+			}
+			if val.Expr != nil {
+				val.Expr.WriteDialect(w)
+			} else {
+				w.WriteValue(val.Value)
+			}
+		}
+		io.WriteString(w, ")")
+	}
+}
+
+// ColumnNames the column names, column1, column2 ... for those not named.
+func (m *SqlValues) ColumnNames() []string {
+	n := len(m.Columns)
+	if len(m.Rows) > 0 && len(m.Rows[0]) > n {
+		n = len(m.Rows[0])
+	}
+	cols := make([]string, n)
+	for i := range cols {
+		if i < len(m.Columns) {
+			cols[i] = m.Columns[i]
+		} else {
+			cols[i] = fmt.Sprintf("column%d", i+1)
+		}
+	}
+	return cols
+}
+func (m *SqlValues) Equal(s *SqlValues) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if len(m.Columns) != len(s.Columns) {
+		return false
+	}
+	for i, col := range m.Columns {
+		if col != s.Columns[i] {
+			return false
+		}
+	}
+	return m.String() == s.String()
+}
+
+// Name the column name of this IN value, the alias if it has one else the
+// un-quoted text of the value.
+func (m *SqlPivotIn) Name() string {
+	if m.As != "" {
+		return m.As
+	}
+	switch n := m.Value.(type) {
+	case *expr.StringNode:
+		return n.Text
+	case *expr.NumberNode:
+		return n.Text
+	case *expr.IdentityNode:
+		return n.Text
+	}
+	return m.Value.String()
+}
+
+// NewSqlStatsHint create an empty statistics hint.
+func NewSqlStatsHint() *SqlStatsHint {
+	return &SqlStatsHint{Ndv: make(map[string]float64)}
+}
+
+// ParseSqlStatsHint parse the body of a /*+ ... */ hint comment on a
+// table reference.  ROWS and NDV are statistics hints, other hints are
+// ignored (as mysql does), returns nil hint if there are no statistics.
+//
+//    ROWS(2e9) NDV(user_id, 5e7) NDV(`email`, 1.9e9)
+//
+func ParseSqlStatsHint(hint string) (*SqlStatsHint, error) {
+	body := strings.TrimSpace(hint)
+	body = strings.TrimPrefix(body, "/*")
+	body = strings.TrimSuffix(body, "*/")
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "+") {
+		return nil, nil
+	}
+	body = strings.TrimSpace(body[1:])
+	var stats *SqlStatsHint
+	for body != "" {
+		start := strings.IndexByte(body, '(')
+		end := strings.IndexByte(body, ')')
+		if start <= 0 || end < start {
+			return nil, fmt.Errorf("invalid hint %q, expected NAME(args)", body)
+		}
+		name := strings.ToUpper(strings.TrimSpace(body[:start]))
+		args := strings.Split(body[start+1:end], ",")
+		for i, arg := range args {
+			args[i] = strings.TrimSpace(arg)
+		}
+		body = strings.TrimSpace(body[end+1:])
+		switch name {
+		case "ROWS":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("ROWS hint expects ROWS(<number>)")
+			}
+			rows, err := strconv.ParseFloat(args[0], 64)
+			if err != nil || rows < 0 {
+				return nil, fmt.Errorf("ROWS hint expects a non negative number got %q", args[0])
+			}
+			if stats == nil {
+				stats = NewSqlStatsHint()
+			}
+			stats.Rows = rows
+		case "NDV":
+			if len(args) != 2 || args[0] == "" {
+				return nil, fmt.Errorf("NDV hint expects NDV(<column>, <number>)")
+			}
+			ndv, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || ndv < 1 {
+				return nil, fmt.Errorf("NDV hint expects a number >= 1 got %q", args[1])
+			}
+			if stats == nil {
+				stats = NewSqlStatsHint()
+			}
+			stats.Ndv[strings.ToLower(strings.Trim(args[0], "`"))] = ndv
+		}
+	}
+	return stats, nil
+}
+func (m *SqlStatsHint) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlStatsHint) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, "/*+")
+	if m.Rows > 0 {
+		io.WriteString(w, " ROWS(")
+		io.WriteString(w, strconv.FormatFloat(m.Rows, 'g', -1, 64))
+		io.WriteString(w, ")")
+	}
+	cols := make([]string, 0, len(m.Ndv))
+	for col := range m.Ndv {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	for _, col := range cols {
+		io.WriteString(w, " NDV(")
+		w.WriteIdentity(col)
+		io.WriteString(w, ", ")
+		io.WriteString(w, strconv.FormatFloat(m.Ndv[col], 'g', -1, 64))
+		io.WriteString(w, ")")
+	}
+	io.WriteString(w, " */")
+}
+// SqlHints the optimizer hints of a select.
+type SqlHints []*SqlHint
+
+// Optimizer hint names the planner understands, others are ignored.
+const (
+	// HintHashJoin HASH_JOIN(t1, t2) join the tables by hashing join keys,
+	// never by index lookups.
+	HintHashJoin = "HASH_JOIN"
+	// HintLookupJoin LOOKUP_JOIN(t2) join the table by index lookups of the
+	// rows already joined, whatever the cost estimate.
+	HintLookupJoin = "LOOKUP_JOIN"
+	// HintNoPushdown NO_PUSHDOWN(t3) don't push the filter, projection
+	// down to the source, evaluate them in qlbridge.
+	HintNoPushdown = "NO_PUSHDOWN"
+	// HintParallel PARALLEL(4) evaluate the where filter with n workers.
+	HintParallel = "PARALLEL"
+)
+
+// ParseSqlHints parse the body of a /*+ ... */ optimizer hint comment of
+// a select, hints are NAME or NAME(args).  The hints the planner knows
+// have their args checked, others are kept but ignored by the planner.
+//
+//    HASH_JOIN(o, u) NO_PUSHDOWN(u) PARALLEL(4)
+//
+func ParseSqlHints(hint string) ([]*SqlHint, error) {
+	body := strings.TrimSpace(hint)
+	body = strings.TrimPrefix(body, "/*+")
+	body = strings.TrimSuffix(body, "*/")
+	var hints []*SqlHint
+	for body = strings.TrimSpace(body); body != ""; body = strings.TrimSpace(body) {
+		end := strings.IndexAny(body, " \t\r\n(")
+		if end < 0 {
+			end = len(body)
+		}
+		h := &SqlHint{Name: strings.ToUpper(body[:end])}
+		if h.Name == "" {
+			return nil, fmt.Errorf("invalid hint %q, expected NAME(args)", body)
+		}
+		body = strings.TrimSpace(body[end:])
+		if strings.HasPrefix(body, "(") {
+			end = strings.IndexByte(body, ')')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid hint %q, expected NAME(args)", body)
+			}
+			for _, arg := range strings.Split(body[1:end], ",") {
+				if arg = strings.Trim(strings.TrimSpace(arg), "`"); arg != "" {
+					h.Args = append(h.Args, arg)
+				}
+			}
+			body = body[end+1:]
+		}
+		switch h.Name {
+		case HintHashJoin, HintLookupJoin, HintNoPushdown:
+			if len(h.Args) == 0 {
+				return nil, fmt.Errorf("%s hint expects %s(<table>, ...)", h.Name, h.Name)
+			}
+		case HintParallel:
+			if n, err := strconv.Atoi(strings.Join(h.Args, ",")); err != nil || n < 1 {
+				return nil, fmt.Errorf("PARALLEL hint expects PARALLEL(<n>) n >= 1 got %q", strings.Join(h.Args, ","))
+			}
+		}
+		hints = append(hints, h)
+	}
+	return hints, nil
+}
+
+// Hint the first hint of name, nil if not hinted.
+func (m SqlHints) Hint(name string) *SqlHint {
+	for _, h := range m {
+		if h.Name == name {
+			return h
+		}
+	}
+	return nil
+}
+
+// HasTable is there a hint of name for the table (its name or alias).
+func (m SqlHints) HasTable(name string, from *SqlSource) bool {
+	for _, h := range m {
+		if h.Name == name && h.HasTable(from) {
+			return true
+		}
+	}
+	return false
+}
+func (m SqlHints) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m SqlHints) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, "/*+")
+	for _, h := range m {
+		io.WriteString(w, " ")
+		h.WriteDialect(w)
+	}
+	io.WriteString(w, " */")
+}
+
+// HasTable is the source one of the tables of this hint.
+func (m *SqlHint) HasTable(from *SqlSource) bool {
+	if from == nil {
+		return false
+	}
+	for _, arg := range m.Args {
+		if from.IsNamed(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Int the first arg as an int, ie PARALLEL(4)
+func (m *SqlHint) Int() int {
+	if len(m.Args) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(m.Args[0])
+	return n
+}
+func (m *SqlHint) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *SqlHint) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, m.Name)
+	if len(m.Args) > 0 {
+		io.WriteString(w, "(")
+		io.WriteString(w, strings.Join(m.Args, ", "))
+		io.WriteString(w, ")")
+	}
+}
+func (m *SqlHint) Equal(s *SqlHint) bool {
+	if m == nil || s == nil {
+		return m == s
+	}
+	if m.Name != s.Name || len(m.Args) != len(s.Args) {
+		return false
+	}
+	for i, arg := range m.Args {
+		if s.Args[i] != arg {
+			return false
+		}
+	}
+	return true
+}
+func (m *SqlStatsHint) Equal(s *SqlStatsHint) bool {
+	if m == nil && s == nil {
+		return true
+	}
+	if m == nil || s == nil {
+		return false
+	}
+	if m.Rows != s.Rows || len(m.Ndv) != len(s.Ndv) {
+		return false
+	}
+	for col, ndv := range m.Ndv {
+		if s.Ndv[col] != ndv {
+			return false
+		}
+	}
+	return true
+}
 func SqlSourceFromPb(pb *SqlSourcePb) *SqlSource {
 	s := SqlSource{
 		final:       pb.GetFinal(),
@@ -1682,6 +2451,30 @@ func SqlSourceFromPb(pb *SqlSourcePb) *SqlSource {
 	if pb.SubQuery != nil {
 		s.SubQuery = SqlSelectFromPb(pb.SubQuery)
 	}
+	if len(pb.IndexHints) > 0 {
+		s.IndexHints = make([]*IndexHint, len(pb.IndexHints))
+		for i, hpb := range pb.IndexHints {
+			s.IndexHints[i] = indexHintFromPb(hpb)
+		}
+	}
+	if pb.SamplePercent != nil {
+		s.Sample = NewSqlSample(pb.GetSamplePercent())
+	}
+	if pb.SampleRows != nil {
+		s.Sample = NewSqlSampleRows(pb.GetSampleRows())
+	}
+	if pb.StatsHint != nil {
+		s.Stats, _ = ParseSqlStatsHint(pb.GetStatsHint())
+	}
+	if pb.Pivot != nil {
+		s.Pivot, _ = ParseSqlPivot(pb.GetPivot())
+	}
+	if pb.Values != nil {
+		s.Values, _ = ParseSqlValues(pb.GetValues())
+		if s.Values != nil {
+			s.Values.Columns = pb.GetValuesColumns()
+		}
+	}
 	if len(pb.Columns) > 0 {
 		s.cols = make(map[string]*Column, len(pb.Columns))
 		for _, pbc := range pb.Columns {
@@ -1764,9 +2557,23 @@ func SqlWhereFromPb(pb *SqlWherePb) *SqlWhere {
 	return &w
 }
 
-func (m *SqlInto) Keyword() lex.TokenType            { return lex.TokenInto }
-func (m *SqlInto) String() string                    { return fmt.Sprintf("%s", m.Table) }
-func (m *SqlInto) WriteDialect(w expr.DialectWriter) {}
+func (m *SqlInto) Keyword() lex.TokenType { return lex.TokenInto }
+func (m *SqlInto) String() string {
+	if len(m.Vars) > 0 {
+		return strings.Join(m.Vars, ", ")
+	}
+	return m.Table
+}
+func (m *SqlInto) WriteDialect(w expr.DialectWriter) {
+	if len(m.Vars) > 0 {
+		io.WriteString(w, strings.Join(m.Vars, ", "))
+		return
+	}
+	if m.Temp {
+		io.WriteString(w, "TEMP ")
+	}
+	w.WriteIdentity(m.Table)
+}
 func (m *SqlInto) Equal(s *SqlInto) bool {
 	if m == nil && s == nil {
 		return true
@@ -1777,9 +2584,14 @@ func (m *SqlInto) Equal(s *SqlInto) bool {
 	if m != nil && s == nil {
 		return false
 	}
-	if m.Table != s.Table {
+	if m.Table != s.Table || m.Temp != s.Temp || len(m.Vars) != len(s.Vars) {
 		return false
 	}
+	for i, v := range m.Vars {
+		if v != s.Vars[i] {
+			return false
+		}
+	}
 	return true
 }
 
@@ -1815,6 +2627,9 @@ func (m *SqlInsert) WriteDialect(w expr.DialectWriter) {
 		}
 		w.Write([]byte{')'})
 	}
+	if m.OnErrorSkip {
+		io.WriteString(w, " ON ERROR SKIP")
+	}
 }
 func (m *SqlInsert) String() string {
 	w := expr.NewDefaultWriter()
@@ -1852,6 +2667,9 @@ func (m *SqlInsert) RewriteAsPrepareable(maxRows int, mark byte) string {
 		}
 		buf.WriteByte(')')
 	}
+	if m.OnErrorSkip {
+		buf.WriteString(" ON ERROR SKIP")
+	}
 	return buf.String()
 }
 func (m *SqlInsert) ColumnNames() []string {
@@ -1957,6 +2775,30 @@ func (m *SqlCommand) FingerPrint(r rune) string         { return m.String() }
 func (m *SqlCommand) String() string                    { return fmt.Sprintf("%s %s", m.Keyword(), m.Columns.String()) }
 func (m *SqlCommand) WriteDialect(w expr.DialectWriter) {}
 
+func (m *SqlCall) Keyword() lex.TokenType    { return lex.TokenCall }
+func (m *SqlCall) FingerPrint(r rune) string { return m.String() }
+func (m *SqlCall) WriteDialect(w expr.DialectWriter) {
+	io.WriteString(w, "CALL ")
+	w.WriteIdentity(m.Name)
+	io.WriteString(w, "(")
+	for i, arg := range m.Args {
+		if i > 0 {
+			io.WriteString(w, ", ")
+		}
+		if arg.Expr != nil {
+			arg.Expr.WriteDialect(w)
+		} else {
+			w.WriteValue(arg.Value)
+		}
+	}
+	io.WriteString(w, ")")
+}
+func (m *SqlCall) String() string {
+	w := expr.NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+
 func (m *SqlCreate) Keyword() lex.TokenType            { return lex.TokenCreate }
 func (m *SqlCreate) FingerPrint(r rune) string         { return m.String() }
 func (m *SqlCreate) String() string                    { return fmt.Sprintf("not-implemented") }
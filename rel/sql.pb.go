@@ -18,6 +18,7 @@
 		KvInt
 		ColumnPb
 		CommandColumnPb
+		IndexHintPb
 */
 package rel
 
@@ -93,6 +94,7 @@ type SqlSelectPb struct {
 	Finalized        bool           `protobuf:"varint,17,req,name=finalized" json:"finalized"`
 	Schemaqry        bool           `protobuf:"varint,18,req,name=schemaqry" json:"schemaqry"`
 	With             []byte         `protobuf:"bytes,19,opt,name=with" json:"with,omitempty"`
+	Hints            *string        `protobuf:"bytes,20,opt,name=hints" json:"hints,omitempty"`
 	XXX_unrecognized []byte         `json:"-"`
 }
 
@@ -234,6 +236,13 @@ func (m *SqlSelectPb) GetWith() []byte {
 	return nil
 }
 
+func (m *SqlSelectPb) GetHints() string {
+	if m != nil && m.Hints != nil {
+		return *m.Hints
+	}
+	return ""
+}
+
 type SqlSourcePb struct {
 	Final            bool           `protobuf:"varint,1,opt,name=final" json:"final"`
 	AliasInner       *string        `protobuf:"bytes,2,opt,name=aliasInner" json:"aliasInner,omitempty"`
@@ -250,6 +259,13 @@ type SqlSourcePb struct {
 	JoinExpr         *expr.NodePb   `protobuf:"bytes,13,opt,name=joinExpr" json:"joinExpr,omitempty"`
 	SubQuery         *SqlSelectPb   `protobuf:"bytes,14,opt,name=subQuery" json:"subQuery,omitempty"`
 	Seekable         bool           `protobuf:"varint,15,opt,name=seekable" json:"seekable"`
+	IndexHints       []*IndexHintPb `protobuf:"bytes,16,rep,name=indexHints" json:"indexHints,omitempty"`
+	SamplePercent    *float64       `protobuf:"fixed64,17,opt,name=samplePercent" json:"samplePercent,omitempty"`
+	StatsHint        *string        `protobuf:"bytes,18,opt,name=statsHint" json:"statsHint,omitempty"`
+	SampleRows       *int64         `protobuf:"varint,19,opt,name=sampleRows" json:"sampleRows,omitempty"`
+	Pivot            *string        `protobuf:"bytes,20,opt,name=pivot" json:"pivot,omitempty"`
+	Values           *string        `protobuf:"bytes,21,opt,name=values" json:"values,omitempty"`
+	ValuesColumns    []string       `protobuf:"bytes,22,rep,name=valuesColumns" json:"valuesColumns,omitempty"`
 	XXX_unrecognized []byte         `json:"-"`
 }
 
@@ -363,6 +379,55 @@ func (m *SqlSourcePb) GetSeekable() bool {
 	return false
 }
 
+func (m *SqlSourcePb) GetIndexHints() []*IndexHintPb {
+	if m != nil {
+		return m.IndexHints
+	}
+	return nil
+}
+
+func (m *SqlSourcePb) GetSamplePercent() float64 {
+	if m != nil && m.SamplePercent != nil {
+		return *m.SamplePercent
+	}
+	return 0
+}
+
+func (m *SqlSourcePb) GetStatsHint() string {
+	if m != nil && m.StatsHint != nil {
+		return *m.StatsHint
+	}
+	return ""
+}
+
+func (m *SqlSourcePb) GetSampleRows() int64 {
+	if m != nil && m.SampleRows != nil {
+		return *m.SampleRows
+	}
+	return 0
+}
+
+func (m *SqlSourcePb) GetPivot() string {
+	if m != nil && m.Pivot != nil {
+		return *m.Pivot
+	}
+	return ""
+}
+
+func (m *SqlSourcePb) GetValues() string {
+	if m != nil && m.Values != nil {
+		return *m.Values
+	}
+	return ""
+}
+
+func (m *SqlSourcePb) GetValuesColumns() []string {
+	if m != nil {
+		return m.ValuesColumns
+	}
+	return nil
+}
+
 type SqlWherePb struct {
 	Op               int32        `protobuf:"varint,1,req,name=op" json:"op"`
 	Source           *SqlSelectPb `protobuf:"bytes,2,opt,name=source" json:"source,omitempty"`
@@ -544,6 +609,7 @@ type ColumnPb struct {
 	Agg              bool         `protobuf:"varint,15,opt,name=agg" json:"agg"`
 	Expr             *expr.NodePb `protobuf:"bytes,16,opt,name=Expr,json=expr" json:"Expr,omitempty"`
 	Guard            *expr.NodePb `protobuf:"bytes,17,opt,name=Guard,json=guard" json:"Guard,omitempty"`
+	Filter           *expr.NodePb `protobuf:"bytes,18,opt,name=Filter,json=filter" json:"Filter,omitempty"`
 	XXX_unrecognized []byte       `json:"-"`
 }
 
@@ -664,6 +730,13 @@ func (m *ColumnPb) GetGuard() *expr.NodePb {
 	return nil
 }
 
+func (m *ColumnPb) GetFilter() *expr.NodePb {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
 type CommandColumnPb struct {
 	Expr             *expr.NodePb `protobuf:"bytes,1,opt,name=Expr,json=expr" json:"Expr,omitempty"`
 	Name             string       `protobuf:"bytes,2,req,name=name" json:"name"`
@@ -689,6 +762,39 @@ func (m *CommandColumnPb) GetName() string {
 	return ""
 }
 
+type IndexHintPb struct {
+	Type             int32    `protobuf:"varint,1,req,name=type" json:"type"`
+	For              int32    `protobuf:"varint,2,opt,name=for" json:"for"`
+	Indexes          []string `protobuf:"bytes,3,rep,name=indexes" json:"indexes,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *IndexHintPb) Reset()                    { *m = IndexHintPb{} }
+func (m *IndexHintPb) String() string            { return proto.CompactTextString(m) }
+func (*IndexHintPb) ProtoMessage()               {}
+func (*IndexHintPb) Descriptor() ([]byte, []int) { return fileDescriptorSql, []int{9} }
+
+func (m *IndexHintPb) GetType() int32 {
+	if m != nil {
+		return m.Type
+	}
+	return 0
+}
+
+func (m *IndexHintPb) GetFor() int32 {
+	if m != nil {
+		return m.For
+	}
+	return 0
+}
+
+func (m *IndexHintPb) GetIndexes() []string {
+	if m != nil {
+		return m.Indexes
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*SqlStatementPb)(nil), "rel.SqlStatementPb")
 	proto.RegisterType((*SqlSelectPb)(nil), "rel.SqlSelectPb")
@@ -699,6 +805,7 @@ func init() {
 	proto.RegisterType((*KvInt)(nil), "rel.KvInt")
 	proto.RegisterType((*ColumnPb)(nil), "rel.ColumnPb")
 	proto.RegisterType((*CommandColumnPb)(nil), "rel.CommandColumnPb")
+	proto.RegisterType((*IndexHintPb)(nil), "rel.IndexHintPb")
 }
 func (m *SqlStatementPb) Marshal() (data []byte, err error) {
 	size := m.Size()
@@ -924,6 +1031,14 @@ func (m *SqlSelectPb) MarshalTo(data []byte) (int, error) {
 		i = encodeVarintSql(data, i, uint64(len(m.With)))
 		i += copy(data[i:], m.With)
 	}
+	if m.Hints != nil {
+		data[i] = 0xa2
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(len(*m.Hints)))
+		i += copy(data[i:], *m.Hints)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -1054,6 +1169,75 @@ func (m *SqlSourcePb) MarshalTo(data []byte) (int, error) {
 		data[i] = 0
 	}
 	i++
+	if len(m.IndexHints) > 0 {
+		for _, msg := range m.IndexHints {
+			data[i] = 0x82
+			i++
+			data[i] = 0x1
+			i++
+			i = encodeVarintSql(data, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(data[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.SamplePercent != nil {
+		data[i] = 0x89
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeFixed64Sql(data, i, uint64(math.Float64bits(float64(*m.SamplePercent))))
+	}
+	if m.StatsHint != nil {
+		data[i] = 0x92
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(len(*m.StatsHint)))
+		i += copy(data[i:], *m.StatsHint)
+	}
+	if m.SampleRows != nil {
+		data[i] = 0x98
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(*m.SampleRows))
+	}
+	if m.Pivot != nil {
+		data[i] = 0xa2
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(len(*m.Pivot)))
+		i += copy(data[i:], *m.Pivot)
+	}
+	if m.Values != nil {
+		data[i] = 0xaa
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(len(*m.Values)))
+		i += copy(data[i:], *m.Values)
+	}
+	if len(m.ValuesColumns) > 0 {
+		for _, s := range m.ValuesColumns {
+			data[i] = 0xb2
+			i++
+			data[i] = 0x1
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				data[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			data[i] = uint8(l)
+			i++
+			i += copy(data[i:], s)
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -1379,6 +1563,18 @@ func (m *ColumnPb) MarshalTo(data []byte) (int, error) {
 		}
 		i += n14
 	}
+	if m.Filter != nil {
+		data[i] = 0x92
+		i++
+		data[i] = 0x1
+		i++
+		i = encodeVarintSql(data, i, uint64(m.Filter.Size()))
+		nf, err := m.Filter.MarshalTo(data[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += nf
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -1420,6 +1616,48 @@ func (m *CommandColumnPb) MarshalTo(data []byte) (int, error) {
 	return i, nil
 }
 
+func (m *IndexHintPb) Marshal() (data []byte, err error) {
+	size := m.Size()
+	data = make([]byte, size)
+	n, err := m.MarshalTo(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (m *IndexHintPb) MarshalTo(data []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	data[i] = 0x8
+	i++
+	i = encodeVarintSql(data, i, uint64(m.Type))
+	data[i] = 0x10
+	i++
+	i = encodeVarintSql(data, i, uint64(m.For))
+	if len(m.Indexes) > 0 {
+		for _, s := range m.Indexes {
+			data[i] = 0x1a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				data[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			data[i] = uint8(l)
+			i++
+			i += copy(data[i:], s)
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(data[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
 func encodeFixed64Sql(data []byte, offset int, v uint64) int {
 	data[offset] = uint8(v)
 	data[offset+1] = uint8(v >> 8)
@@ -1530,6 +1768,10 @@ func (m *SqlSelectPb) Size() (n int) {
 		l = len(m.With)
 		n += 2 + l + sovSql(uint64(l))
 	}
+	if m.Hints != nil {
+		l = len(*m.Hints)
+		n += 2 + l + sovSql(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1584,6 +1826,36 @@ func (m *SqlSourcePb) Size() (n int) {
 		n += 1 + l + sovSql(uint64(l))
 	}
 	n += 2
+	if len(m.IndexHints) > 0 {
+		for _, e := range m.IndexHints {
+			l = e.Size()
+			n += 2 + l + sovSql(uint64(l))
+		}
+	}
+	if m.SamplePercent != nil {
+		n += 10
+	}
+	if m.StatsHint != nil {
+		l = len(*m.StatsHint)
+		n += 2 + l + sovSql(uint64(l))
+	}
+	if m.SampleRows != nil {
+		n += 2 + sovSql(uint64(*m.SampleRows))
+	}
+	if m.Pivot != nil {
+		l = len(*m.Pivot)
+		n += 2 + l + sovSql(uint64(l))
+	}
+	if m.Values != nil {
+		l = len(*m.Values)
+		n += 2 + l + sovSql(uint64(l))
+	}
+	if len(m.ValuesColumns) > 0 {
+		for _, s := range m.ValuesColumns {
+			l = len(s)
+			n += 2 + l + sovSql(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1720,6 +1992,10 @@ func (m *ColumnPb) Size() (n int) {
 		l = m.Guard.Size()
 		n += 2 + l + sovSql(uint64(l))
 	}
+	if m.Filter != nil {
+		l = m.Filter.Size()
+		n += 2 + l + sovSql(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1741,6 +2017,23 @@ func (m *CommandColumnPb) Size() (n int) {
 	return n
 }
 
+func (m *IndexHintPb) Size() (n int) {
+	var l int
+	_ = l
+	n += 1 + sovSql(uint64(m.Type))
+	n += 1 + sovSql(uint64(m.For))
+	if len(m.Indexes) > 0 {
+		for _, s := range m.Indexes {
+			l = len(s)
+			n += 1 + l + sovSql(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovSql(x uint64) (n int) {
 	for {
 		n++
@@ -2451,6 +2744,36 @@ func (m *SqlSelectPb) Unmarshal(data []byte) error {
 				m.With = []byte{}
 			}
 			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			s := string(data[iNdEx:postIndex])
+			m.Hints = &s
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSql(data[iNdEx:])
@@ -2933,6 +3256,195 @@ func (m *SqlSourcePb) Unmarshal(data []byte) error {
 				}
 			}
 			m.Seekable = bool(v != 0)
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IndexHints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.IndexHints = append(m.IndexHints, &IndexHintPb{})
+			if err := m.IndexHints[len(m.IndexHints)-1].Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 17:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplePercent", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			v = uint64(data[iNdEx-8])
+			v |= uint64(data[iNdEx-7]) << 8
+			v |= uint64(data[iNdEx-6]) << 16
+			v |= uint64(data[iNdEx-5]) << 24
+			v |= uint64(data[iNdEx-4]) << 32
+			v |= uint64(data[iNdEx-3]) << 40
+			v |= uint64(data[iNdEx-2]) << 48
+			v |= uint64(data[iNdEx-1]) << 56
+			v2 := float64(math.Float64frombits(v))
+			m.SamplePercent = &v2
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StatsHint", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			s := string(data[iNdEx:postIndex])
+			m.StatsHint = &s
+			iNdEx = postIndex
+		case 19:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SampleRows", wireType)
+			}
+			var v int64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				v |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SampleRows = &v
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Pivot", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			s := string(data[iNdEx:postIndex])
+			m.Pivot = &s
+			iNdEx = postIndex
+		case 21:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			s := string(data[iNdEx:postIndex])
+			m.Values = &s
+			iNdEx = postIndex
+		case 22:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValuesColumns", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ValuesColumns = append(m.ValuesColumns, string(data[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSql(data[iNdEx:])
@@ -4076,6 +4588,39 @@ func (m *ColumnPb) Unmarshal(data []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filter", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Filter == nil {
+				m.Filter = &expr.NodePb{}
+			}
+			if err := m.Filter.Unmarshal(data[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSql(data[iNdEx:])
@@ -4216,6 +4761,129 @@ func (m *CommandColumnPb) Unmarshal(data []byte) error {
 	}
 	return nil
 }
+func (m *IndexHintPb) Unmarshal(data []byte) error {
+	var hasFields [1]uint64
+	l := len(data)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSql
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := data[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IndexHintPb: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IndexHintPb: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.Type |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			hasFields[0] |= uint64(0x00000001)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field For", wireType)
+			}
+			m.For = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				m.For |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Indexes", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSql
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSql
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Indexes = append(m.Indexes, string(data[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSql(data[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSql
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, data[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+	if hasFields[0]&uint64(0x00000001) == 0 {
+		return new(github_com_golang_protobuf_proto.RequiredNotSetError)
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipSql(data []byte) (n int, err error) {
 	l := len(data)
 	iNdEx := 0
@@ -4322,72 +4990,74 @@ var (
 )
 
 var fileDescriptorSql = []byte{
-	// 1067 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x56, 0xcd, 0x6e, 0xdb, 0x46,
-	0x10, 0x0e, 0x25, 0x51, 0x96, 0x56, 0x8e, 0xed, 0x6c, 0x82, 0x62, 0x61, 0x14, 0xae, 0x41, 0x14,
-	0x81, 0xd1, 0x34, 0x52, 0x91, 0x1e, 0x7a, 0x8e, 0x83, 0xb6, 0x30, 0x0a, 0xa4, 0x8e, 0x5c, 0xa0,
-	0x67, 0x4a, 0x5c, 0x51, 0x8c, 0x29, 0xae, 0xbc, 0x24, 0xe5, 0x38, 0x4f, 0xd2, 0x4b, 0x81, 0x5e,
-	0x7b, 0xeb, 0x33, 0xf4, 0xe4, 0x63, 0x9f, 0xa0, 0xe8, 0x0f, 0xfa, 0x1e, 0x9d, 0x99, 0x25, 0x97,
-	0x6b, 0x47, 0x72, 0x72, 0x10, 0xc0, 0xfd, 0xe6, 0xdb, 0xdd, 0x99, 0xd9, 0x6f, 0x66, 0xc4, 0xfa,
-	0xf9, 0x45, 0x3a, 0x5c, 0x6a, 0x55, 0x28, 0xde, 0xd6, 0x32, 0xdd, 0x7f, 0x12, 0x27, 0xc5, 0xbc,
-	0x9c, 0x0c, 0xa7, 0x6a, 0x31, 0x0a, 0x75, 0x18, 0x45, 0x2a, 0x1b, 0x5d, 0xa4, 0x13, 0x9d, 0x44,
-	0xb1, 0x1c, 0xc9, 0x37, 0x4b, 0x3d, 0xca, 0x54, 0x24, 0xcd, 0x8e, 0xfd, 0xa7, 0x0e, 0x39, 0x56,
-	0xb1, 0x1a, 0x11, 0x3c, 0x29, 0x67, 0xb4, 0xa2, 0x05, 0x7d, 0x19, 0x7a, 0xf0, 0xab, 0xc7, 0x76,
-	0xce, 0x2e, 0xd2, 0xb3, 0x22, 0x2c, 0xe4, 0x42, 0x66, 0xc5, 0xe9, 0x84, 0x0f, 0x59, 0x37, 0x97,
-	0xa9, 0x9c, 0x16, 0xc2, 0x3b, 0xf4, 0x8e, 0x06, 0xcf, 0xf6, 0x86, 0xe0, 0xc4, 0x10, 0x49, 0x84,
-	0x9e, 0x4e, 0x8e, 0x3b, 0xd7, 0x7f, 0x7e, 0xe2, 0x8d, 0x2b, 0x16, 0xf1, 0x55, 0xa9, 0xa7, 0x52,
-	0xb4, 0x6e, 0xf1, 0x09, 0x75, 0xf8, 0xb4, 0xe6, 0x5f, 0x31, 0x06, 0x77, 0xbf, 0x86, 0xad, 0x89,
-	0xca, 0x44, 0x87, 0xf6, 0x3c, 0xa0, 0x3d, 0xa7, 0x16, 0xb6, 0x9b, 0x1c, 0x6a, 0xf0, 0x9b, 0xcf,
-	0x06, 0x8e, 0x1b, 0xfc, 0x11, 0x6b, 0x45, 0x13, 0x70, 0xb2, 0x75, 0xd4, 0x27, 0xf6, 0xbd, 0x31,
-	0xac, 0xf9, 0x47, 0xac, 0xad, 0xc3, 0x4b, 0xf0, 0xa5, 0x81, 0x11, 0xe0, 0x82, 0x75, 0xf2, 0x22,
-	0xd4, 0xa2, 0x0d, 0x86, 0x5e, 0x65, 0x20, 0x84, 0x1f, 0xb2, 0x5e, 0x94, 0xe4, 0x45, 0x92, 0x41,
-	0xc8, 0x1d, 0xc7, 0x6a, 0x51, 0xfe, 0x94, 0x6d, 0x4d, 0x55, 0x5a, 0x2e, 0xb2, 0x5c, 0xf8, 0x87,
-	0x6d, 0xf0, 0xf7, 0x3e, 0xf9, 0xfb, 0x82, 0x30, 0xeb, 0x6b, 0xcd, 0xe1, 0x9f, 0xb1, 0xce, 0x4c,
-	0xab, 0x85, 0xe8, 0x12, 0x77, 0x53, 0x3e, 0x88, 0x83, 0x6e, 0x25, 0x59, 0xa1, 0xc4, 0x16, 0xe4,
-	0xa1, 0x5f, 0x5b, 0x10, 0xe1, 0x4f, 0x98, 0x7f, 0x39, 0x97, 0x5a, 0x8a, 0x1e, 0xa5, 0x68, 0xb7,
-	0x3e, 0xe6, 0x47, 0x04, 0xed, 0x29, 0x86, 0x03, 0x57, 0x76, 0xe7, 0xe1, 0x2a, 0xc9, 0x62, 0xd1,
-	0x27, 0xf6, 0xf6, 0x10, 0x85, 0x31, 0x7c, 0x09, 0xc2, 0x68, 0x1e, 0xc0, 0x30, 0x30, 0x1a, 0xa5,
-	0x23, 0xa9, 0x8f, 0xaf, 0x04, 0xbb, 0x23, 0x9a, 0x8a, 0x83, 0xf4, 0x58, 0xab, 0x72, 0x09, 0xf4,
-	0xc1, 0x1d, 0xf4, 0x8a, 0xc3, 0xf7, 0x99, 0x9f, 0x26, 0x8b, 0xa4, 0x10, 0xdb, 0xe0, 0x88, 0x5f,
-	0xa5, 0xd2, 0x40, 0xfc, 0x63, 0xd6, 0x55, 0xb3, 0x59, 0x2e, 0x0b, 0x71, 0xdf, 0x31, 0x56, 0x18,
-	0xee, 0x0c, 0xd3, 0x24, 0xcc, 0xc5, 0x8e, 0x93, 0x0b, 0x03, 0xdd, 0x12, 0xcd, 0xee, 0x07, 0x8b,
-	0x06, 0x0f, 0x4d, 0xf2, 0xe7, 0x71, 0x2c, 0xf6, 0x9c, 0x97, 0x35, 0x10, 0x0f, 0x58, 0x7f, 0x96,
-	0x64, 0x70, 0xc1, 0x5b, 0x19, 0x89, 0x07, 0x8e, 0xbd, 0x81, 0x91, 0x93, 0x4f, 0xe7, 0x72, 0x11,
-	0x5e, 0xe8, 0x2b, 0xc1, 0x5d, 0x8e, 0x85, 0xf1, 0x0d, 0x2f, 0xa1, 0xea, 0xc4, 0x43, 0x70, 0x6b,
-	0xbb, 0x7e, 0x43, 0x44, 0x82, 0xdf, 0x3b, 0x46, 0xb2, 0xd5, 0xcb, 0xa3, 0x37, 0x74, 0x34, 0x95,
-	0x96, 0xf5, 0x86, 0x20, 0xfe, 0x29, 0x63, 0x14, 0xeb, 0x49, 0x96, 0x49, 0x4d, 0xb5, 0x54, 0xe7,
-	0xc0, 0xc1, 0x5d, 0x29, 0xb6, 0x3f, 0x40, 0x8a, 0x9f, 0xb3, 0x1e, 0x7c, 0x9e, 0x64, 0x91, 0x7c,
-	0x03, 0xda, 0x46, 0x3e, 0x23, 0xfe, 0x77, 0xab, 0x93, 0xac, 0xa8, 0x75, 0x5e, 0x33, 0xf8, 0x17,
-	0xac, 0xff, 0x5a, 0x25, 0x19, 0xaa, 0xa6, 0x56, 0xfa, 0x3a, 0x21, 0x35, 0x24, 0xa7, 0xf8, 0xbb,
-	0xef, 0x69, 0x16, 0xa6, 0xf8, 0xab, 0xea, 0x6c, 0xd4, 0xde, 0x54, 0x67, 0x16, 0x2e, 0x8c, 0xd6,
-	0x6b, 0x03, 0x21, 0x8d, 0x2a, 0xfa, 0x8e, 0xa9, 0x52, 0x05, 0x74, 0x00, 0xb5, 0x04, 0x11, 0xb7,
-	0xac, 0x96, 0x60, 0xcd, 0x1f, 0xb3, 0x41, 0x2a, 0x67, 0xc5, 0xf7, 0x7a, 0x9c, 0xc4, 0xf3, 0x02,
-	0x44, 0xdb, 0x98, 0x5d, 0x03, 0xd6, 0x3d, 0x06, 0xf2, 0xc3, 0xd5, 0x52, 0x82, 0x58, 0x1b, 0x92,
-	0x45, 0x21, 0x3a, 0xfa, 0xfe, 0x1a, 0x32, 0x40, 0x8a, 0x5d, 0x9f, 0x0e, 0xcb, 0xe1, 0xcf, 0x58,
-	0x2f, 0x2f, 0x27, 0xaf, 0x4a, 0x09, 0x5a, 0xd9, 0xb9, 0x33, 0x1f, 0x96, 0x87, 0x5e, 0xe4, 0x52,
-	0x9e, 0x87, 0x93, 0x54, 0x92, 0xae, 0x6d, 0xf7, 0xa9, 0xd1, 0xe0, 0x2d, 0x63, 0x4d, 0xd9, 0x57,
-	0x31, 0x7b, 0xb7, 0x62, 0xde, 0xdc, 0x84, 0xd7, 0xbf, 0xc3, 0x63, 0xd6, 0xa1, 0xa8, 0xda, 0x1b,
-	0xa3, 0xea, 0x20, 0x14, 0xfc, 0xec, 0xb1, 0x6d, 0xb7, 0xc2, 0x6e, 0x34, 0x4b, 0x6f, 0x6d, 0xb3,
-	0xb4, 0x1a, 0x6f, 0xb9, 0x15, 0x67, 0x34, 0xbe, 0x4f, 0x72, 0x7c, 0x09, 0xef, 0x6a, 0xe4, 0xdb,
-	0x1f, 0xdb, 0x35, 0xff, 0xb2, 0x51, 0xb6, 0x51, 0xea, 0x43, 0x8a, 0x61, 0x2c, 0xf3, 0x32, 0x2d,
-	0x36, 0xe8, 0x3b, 0xf8, 0x0f, 0xe6, 0xd7, 0x4d, 0xc6, 0xba, 0x1a, 0xf3, 0xea, 0xfb, 0x6b, 0x99,
-	0xb9, 0xd3, 0xc1, 0xc8, 0x0c, 0x5a, 0x13, 0x9c, 0x79, 0xaa, 0x72, 0x1a, 0x10, 0xb6, 0x35, 0x19,
-	0x0c, 0x7a, 0x71, 0xd7, 0xdc, 0x58, 0xcd, 0xab, 0xb5, 0x45, 0x57, 0x51, 0xec, 0xa4, 0xf1, 0x9d,
-	0xfb, 0xcd, 0xa4, 0x81, 0xb7, 0x03, 0x21, 0x77, 0xdd, 0x89, 0x05, 0x2a, 0x86, 0x16, 0xb3, 0x0a,
-	0xd3, 0x52, 0x92, 0x10, 0xb7, 0x9c, 0xdb, 0x1b, 0x38, 0x18, 0x31, 0x9f, 0x4a, 0x96, 0x73, 0xe6,
-	0x9d, 0xdf, 0x98, 0x79, 0xde, 0x39, 0x62, 0x2b, 0x0a, 0xa9, 0xde, 0xe8, 0xad, 0x82, 0x5f, 0x3a,
-	0xac, 0x67, 0x53, 0x02, 0x15, 0x61, 0xde, 0xfd, 0x55, 0xa9, 0x0a, 0x49, 0x89, 0xa9, 0xfb, 0x94,
-	0x6b, 0x40, 0x5e, 0x98, 0xd3, 0xe7, 0xf1, 0x55, 0x61, 0xa4, 0x64, 0x79, 0x8e, 0x01, 0x5b, 0x95,
-	0xd2, 0x49, 0x8c, 0x29, 0x7d, 0x9e, 0x93, 0x86, 0x6c, 0xab, 0x6a, 0x70, 0xcc, 0x03, 0x96, 0x1b,
-	0xa5, 0xcc, 0x8e, 0x36, 0x44, 0xf0, 0x89, 0x34, 0xd5, 0xa6, 0xef, 0x76, 0x7a, 0x82, 0xd0, 0x87,
-	0x65, 0xa8, 0xe1, 0xaf, 0x88, 0x69, 0x5a, 0x5d, 0x67, 0x50, 0xb8, 0x06, 0x6a, 0xec, 0xc4, 0xd8,
-	0x72, 0xe7, 0x0c, 0x41, 0x4d, 0xbc, 0xe6, 0x8c, 0x9e, 0x7b, 0x86, 0x63, 0x68, 0x78, 0xdf, 0x24,
-	0x32, 0x8d, 0x9c, 0x0e, 0x63, 0xf3, 0x42, 0x86, 0xea, 0xdd, 0x06, 0x4e, 0x03, 0xc2, 0x77, 0x3b,
-	0x40, 0xc1, 0x2e, 0xf0, 0x5f, 0x13, 0xcd, 0xba, 0x7e, 0xa3, 0x4d, 0x02, 0xd1, 0x43, 0x9a, 0xa1,
-	0xd4, 0x3a, 0x6c, 0x94, 0x04, 0x59, 0x8d, 0xec, 0xbc, 0xa3, 0x11, 0xe8, 0x90, 0x21, 0x8c, 0x2b,
-	0xb7, 0x15, 0x20, 0x60, 0x2b, 0x76, 0xef, 0xee, 0x8a, 0xe5, 0x47, 0xcc, 0xff, 0xb6, 0x0c, 0x35,
-	0x0e, 0xb4, 0x4d, 0x44, 0x3f, 0x46, 0x42, 0x70, 0xc6, 0x76, 0x5f, 0x80, 0xab, 0x61, 0x16, 0x39,
-	0x42, 0x31, 0x97, 0x78, 0xef, 0xb9, 0x64, 0x63, 0x1d, 0x1d, 0x3f, 0xba, 0xfe, 0xfb, 0xc0, 0xbb,
-	0xfe, 0xe7, 0xc0, 0xfb, 0x03, 0x7e, 0x7f, 0xc1, 0xef, 0xa7, 0x7f, 0x0f, 0xee, 0xfd, 0x1f, 0x00,
-	0x00, 0xff, 0xff, 0xa8, 0x40, 0xb5, 0x23, 0xd3, 0x0a, 0x00, 0x00,
+	// 1104 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0xcd, 0x6e, 0x23, 0x45,
+	0x10, 0xde, 0xb6, 0xc7, 0x8e, 0x5d, 0x76, 0xfe, 0x7a, 0x57, 0xab, 0x56, 0x84, 0x8c, 0x65, 0xa1,
+	0xc8, 0x4a, 0x58, 0x1b, 0x85, 0x03, 0xe7, 0xcd, 0x6a, 0x81, 0x08, 0x69, 0xc9, 0x3a, 0x48, 0x9c,
+	0xc7, 0x9e, 0xf6, 0x78, 0x36, 0x33, 0xd3, 0x4e, 0x4f, 0x4f, 0x12, 0xef, 0x93, 0x70, 0x41, 0xe2,
+	0xca, 0x8d, 0xc7, 0xc8, 0x91, 0x27, 0x40, 0x10, 0xc4, 0x95, 0x67, 0x40, 0x5d, 0xf3, 0x57, 0x09,
+	0x4e, 0x76, 0x6f, 0x9e, 0xaf, 0xbe, 0xfe, 0xab, 0xfa, 0xbe, 0x2a, 0x43, 0x3b, 0xb9, 0x08, 0x47,
+	0x4b, 0xad, 0x8c, 0xe2, 0x75, 0x2d, 0xc3, 0xbd, 0x43, 0x3f, 0x30, 0x8b, 0x74, 0x3a, 0x9a, 0xa9,
+	0x68, 0xec, 0x6a, 0xd7, 0xf3, 0x54, 0x3c, 0xbe, 0x08, 0xa7, 0x3a, 0xf0, 0x7c, 0x39, 0x96, 0xd7,
+	0x4b, 0x3d, 0x8e, 0x95, 0x27, 0xb3, 0x15, 0x7b, 0x2f, 0x08, 0xd9, 0x57, 0xbe, 0x1a, 0x23, 0x3c,
+	0x4d, 0xe7, 0xf8, 0x85, 0x1f, 0xf8, 0x2b, 0xa3, 0x0f, 0x7e, 0x65, 0xb0, 0x75, 0x76, 0x11, 0x9e,
+	0x19, 0xd7, 0xc8, 0x48, 0xc6, 0xe6, 0x74, 0xca, 0x47, 0xd0, 0x4c, 0x64, 0x28, 0x67, 0x46, 0xb0,
+	0x3e, 0x1b, 0x76, 0x8e, 0x76, 0x46, 0x5a, 0x86, 0x23, 0x4b, 0x42, 0xf4, 0x74, 0x7a, 0xec, 0xdc,
+	0xfc, 0xf1, 0x29, 0x9b, 0xe4, 0x2c, 0xe4, 0xab, 0x54, 0xcf, 0xa4, 0xa8, 0xdd, 0xe3, 0x23, 0x4a,
+	0xf8, 0xf8, 0xcd, 0xbf, 0x02, 0x58, 0x6a, 0xf5, 0x4e, 0xce, 0x4c, 0xa0, 0x62, 0xe1, 0xe0, 0x9a,
+	0x5d, 0x5c, 0x73, 0x5a, 0xc2, 0xe5, 0x22, 0x42, 0x1d, 0xfc, 0xd6, 0x80, 0x0e, 0xb9, 0x06, 0x7f,
+	0x06, 0x35, 0x6f, 0x2a, 0x58, 0xbf, 0x36, 0x6c, 0x23, 0xfb, 0xc9, 0xa4, 0xe6, 0x4d, 0xf9, 0x73,
+	0xa8, 0x6b, 0xf7, 0x4a, 0xd4, 0x08, 0x6c, 0x01, 0x2e, 0xc0, 0x49, 0x8c, 0xab, 0x45, 0xbd, 0x5f,
+	0x1b, 0xb6, 0xf2, 0x00, 0x22, 0xbc, 0x0f, 0x2d, 0x2f, 0x48, 0x4c, 0x10, 0xcf, 0x8c, 0x70, 0x48,
+	0xb4, 0x44, 0xf9, 0x0b, 0xd8, 0x98, 0xa9, 0x30, 0x8d, 0xe2, 0x44, 0x34, 0xfa, 0xf5, 0x61, 0xe7,
+	0x68, 0x13, 0xef, 0xfb, 0x0a, 0xb1, 0xf2, 0xae, 0x05, 0x87, 0x1f, 0x80, 0x33, 0xd7, 0x2a, 0x12,
+	0xcd, 0x7e, 0xfd, 0x91, 0x7c, 0x20, 0xc7, 0x5e, 0x2b, 0x88, 0x8d, 0x12, 0x1b, 0x7d, 0x96, 0xdf,
+	0x97, 0x4d, 0x10, 0xe1, 0x87, 0xd0, 0xb8, 0x5a, 0x48, 0x2d, 0x45, 0x0b, 0x53, 0xb4, 0x5d, 0x6c,
+	0xf3, 0xa3, 0x05, 0xcb, 0x5d, 0x32, 0x0e, 0x3f, 0x80, 0xe6, 0xc2, 0xbd, 0x0c, 0x62, 0x5f, 0xb4,
+	0x91, 0xdd, 0x1d, 0x59, 0x61, 0x8c, 0xde, 0x28, 0x8f, 0x14, 0x20, 0x63, 0xd8, 0xd7, 0x28, 0xed,
+	0x49, 0x7d, 0xbc, 0x12, 0xf0, 0xc8, 0x6b, 0x72, 0x8e, 0xa5, 0xfb, 0x5a, 0xa5, 0xcb, 0xe3, 0x95,
+	0xe8, 0x3c, 0x42, 0xcf, 0x39, 0x7c, 0x0f, 0x1a, 0x61, 0x10, 0x05, 0x46, 0x74, 0xfb, 0x6c, 0xd8,
+	0xc8, 0x53, 0x99, 0x41, 0xfc, 0x13, 0x68, 0xaa, 0xf9, 0x3c, 0x91, 0x46, 0x6c, 0x92, 0x60, 0x8e,
+	0xd9, 0x95, 0x6e, 0x18, 0xb8, 0x89, 0xd8, 0x22, 0xb9, 0xc8, 0xa0, 0x7b, 0xa2, 0xd9, 0xfe, 0x68,
+	0xd1, 0xd8, 0x4d, 0x83, 0xe4, 0xa5, 0xef, 0x8b, 0x1d, 0x52, 0xd9, 0x0c, 0xe2, 0x03, 0x68, 0xcf,
+	0x83, 0xd8, 0x0d, 0x83, 0xf7, 0xd2, 0x13, 0xbb, 0x24, 0x5e, 0xc1, 0x96, 0x93, 0xcc, 0x16, 0x32,
+	0x72, 0x2f, 0xf4, 0x4a, 0x70, 0xca, 0x29, 0x61, 0x5b, 0xc3, 0xab, 0xc0, 0x2c, 0xc4, 0xd3, 0x3e,
+	0x1b, 0x76, 0x8b, 0x1a, 0x5a, 0x64, 0xf0, 0xaf, 0x03, 0x1d, 0x52, 0x79, 0x7b, 0x1b, 0xdc, 0x1a,
+	0xad, 0x55, 0xde, 0x06, 0x21, 0xfe, 0x19, 0x00, 0xbe, 0xf5, 0x24, 0x8e, 0xa5, 0x16, 0x35, 0x92,
+	0x03, 0x82, 0x53, 0x29, 0xd6, 0x3f, 0x42, 0x8a, 0x9f, 0x43, 0x6b, 0xa6, 0xc2, 0x93, 0xd8, 0x93,
+	0xd7, 0xc2, 0x41, 0x3e, 0x20, 0xff, 0xbb, 0xcb, 0x93, 0xd8, 0x14, 0x3a, 0x2f, 0x18, 0xfc, 0x0b,
+	0x68, 0xbf, 0x53, 0x41, 0x6c, 0x55, 0x53, 0x28, 0x7d, 0x9d, 0x90, 0x2a, 0x12, 0x31, 0x7f, 0xf3,
+	0x03, 0xcd, 0x22, 0x33, 0x7f, 0xee, 0xce, 0x4a, 0xed, 0x95, 0x3b, 0x63, 0x37, 0xca, 0xb4, 0x5e,
+	0x04, 0x10, 0xa9, 0x54, 0xd1, 0x26, 0xa1, 0x0c, 0xb2, 0x1d, 0x40, 0x2d, 0x05, 0xf4, 0x6b, 0xa5,
+	0x96, 0x6a, 0x6a, 0xc9, 0xf7, 0xa1, 0x13, 0xca, 0xb9, 0xf9, 0x5e, 0x4f, 0x02, 0x7f, 0x61, 0x44,
+	0x87, 0x84, 0x69, 0xc0, 0xfa, 0xde, 0x3e, 0xe4, 0x87, 0xd5, 0x52, 0x8a, 0x2e, 0x21, 0x95, 0x28,
+	0x1f, 0x65, 0x8c, 0xd7, 0xd7, 0x4b, 0x8d, 0x8a, 0x5d, 0x9f, 0x8e, 0x92, 0xc3, 0x8f, 0xa0, 0x95,
+	0xa4, 0xd3, 0xb7, 0xa9, 0xd4, 0x2b, 0xb1, 0xf5, 0x68, 0x3e, 0x4a, 0x9e, 0xbd, 0x45, 0x22, 0xe5,
+	0xb9, 0x3b, 0x0d, 0xa5, 0xd8, 0x26, 0xaa, 0x28, 0x51, 0x7e, 0x00, 0x10, 0xd8, 0xf2, 0x7c, 0x1b,
+	0xc4, 0x26, 0x11, 0x3b, 0xa4, 0xa9, 0x9c, 0x14, 0x70, 0xb1, 0xef, 0xe0, 0x3d, 0x40, 0xd5, 0x22,
+	0xf2, 0xfc, 0xb0, 0x7b, 0xf9, 0x79, 0xb8, 0x61, 0xaf, 0xaf, 0xd9, 0x3e, 0x38, 0x98, 0x81, 0xfa,
+	0x83, 0x19, 0x70, 0x2c, 0x34, 0xf8, 0x99, 0x41, 0x97, 0xba, 0xf1, 0x4e, 0x63, 0x65, 0x6b, 0x1b,
+	0x6b, 0xe9, 0x87, 0x1a, 0x75, 0x27, 0x42, 0x7c, 0x0f, 0xa5, 0xfb, 0xc6, 0x8d, 0x64, 0x26, 0xf5,
+	0xf6, 0xa4, 0xfc, 0xe6, 0x5f, 0x56, 0x2e, 0xc8, 0x54, 0xfd, 0x14, 0xdf, 0x30, 0x91, 0x49, 0x1a,
+	0x9a, 0x07, 0xbc, 0x30, 0xf8, 0x87, 0xc1, 0xd6, 0x5d, 0xc6, 0x3a, 0x3f, 0xb2, 0xe2, 0xfc, 0x42,
+	0x92, 0x74, 0x92, 0x20, 0x62, 0xdb, 0xd8, 0x4c, 0x85, 0xa7, 0x2a, 0x11, 0x75, 0x92, 0xda, 0x1c,
+	0xe3, 0x87, 0x18, 0x4d, 0xa3, 0x62, 0xb6, 0xad, 0x35, 0x68, 0x4e, 0x29, 0xa7, 0x52, 0x83, 0x9c,
+	0x8f, 0x88, 0xad, 0x9d, 0x9b, 0x88, 0x26, 0x9d, 0x6e, 0x6e, 0x62, 0xdb, 0xd1, 0xa5, 0x1b, 0xa6,
+	0x12, 0x45, 0xbb, 0x41, 0x4e, 0xaf, 0xe0, 0xc1, 0x18, 0x1a, 0x68, 0x6f, 0xce, 0x81, 0x9d, 0xdf,
+	0x99, 0x8f, 0xec, 0xdc, 0x62, 0x97, 0xa2, 0x46, 0x16, 0xb2, 0xcb, 0xc1, 0x2f, 0x0e, 0xb4, 0xca,
+	0x94, 0xec, 0x43, 0x27, 0xab, 0xfb, 0xdb, 0x54, 0x19, 0x29, 0x18, 0xe9, 0x69, 0x34, 0x60, 0x79,
+	0x6e, 0x82, 0x3f, 0x8f, 0x57, 0x26, 0x93, 0x52, 0xc9, 0x23, 0x01, 0xdb, 0xd6, 0x94, 0x0e, 0x7c,
+	0x9b, 0xd2, 0x97, 0x09, 0x6a, 0xa8, 0x6c, 0x6b, 0x15, 0x6e, 0xf3, 0x60, 0xad, 0x29, 0x1c, 0x12,
+	0x47, 0xc4, 0x96, 0x48, 0xa3, 0x8f, 0x1b, 0x24, 0x94, 0x41, 0xf6, 0x0e, 0x4b, 0x57, 0xcb, 0xd8,
+	0x64, 0x0d, 0xae, 0x49, 0x86, 0x0a, 0x0d, 0xe0, 0x10, 0x40, 0xc6, 0x06, 0x9d, 0x49, 0x08, 0x55,
+	0xef, 0xcd, 0xf6, 0x68, 0xd1, 0x3d, 0x48, 0xa0, 0xe2, 0x7d, 0x1d, 0xc8, 0xd0, 0x23, 0xdd, 0x88,
+	0x4d, 0x68, 0x20, 0xaf, 0x5b, 0xa7, 0xcf, 0xee, 0xd4, 0xad, 0x67, 0x05, 0x1b, 0xd9, 0x7f, 0x58,
+	0xa2, 0x5b, 0x86, 0xd8, 0xa4, 0x00, 0xed, 0x0d, 0x71, 0xde, 0x8a, 0x4d, 0x12, 0xcd, 0xa0, 0x52,
+	0x23, 0x5b, 0xff, 0xd3, 0xc8, 0x73, 0xa8, 0xbb, 0xbe, 0x7f, 0xa7, 0x6d, 0x58, 0xa0, 0x74, 0xec,
+	0xce, 0xe3, 0x8e, 0xe5, 0x43, 0x68, 0x7c, 0x93, 0xba, 0xda, 0x0e, 0xbf, 0x87, 0x88, 0x0d, 0xdf,
+	0x12, 0x06, 0x67, 0xb0, 0xfd, 0x4a, 0x45, 0x91, 0x1b, 0x7b, 0x44, 0x28, 0xd9, 0x21, 0xec, 0x03,
+	0x87, 0x3c, 0xe8, 0xa3, 0xc1, 0x6b, 0xe8, 0x90, 0x0e, 0xc6, 0x39, 0x38, 0xc6, 0xca, 0x9a, 0xf4,
+	0x2b, 0xbe, 0x0b, 0xf5, 0xb9, 0xca, 0xa6, 0x61, 0x01, 0x6d, 0xc3, 0x06, 0x56, 0xae, 0x68, 0x0b,
+	0xc7, 0xcf, 0x6e, 0xfe, 0xea, 0xb1, 0x9b, 0xdb, 0x1e, 0xfb, 0xfd, 0xb6, 0xc7, 0xfe, 0xbc, 0xed,
+	0xb1, 0x9f, 0xfe, 0xee, 0x3d, 0xf9, 0x6f, 0x00, 0xe4, 0x12, 0x96, 0x9f, 0x46, 0x0b, 0x00, 0x00,
 }
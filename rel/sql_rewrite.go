@@ -70,7 +70,7 @@ func RewriteSqlSource(m *SqlSource, parentStmt *SqlSelect) *SqlSelect {
 			sql2.From = append(sql2.From, &SqlSource{Name: m.SubQuery.From[0].Name})
 		}
 	} else {
-		sql2.From = append(sql2.From, &SqlSource{Name: m.Name})
+		sql2.From = append(sql2.From, &SqlSource{Name: m.Name, IndexHints: m.IndexHints})
 	}
 
 	for _, from := range parentStmt.From {
@@ -132,6 +132,12 @@ func rewriteIntoProjection(sel *SqlSelect, m Columns) {
 		default:
 			u.Warnf("unhandled column? %T  %s", n, n)
 		}
+		if c.Filter != nil {
+			for _, in := range expr.FindAllIdentities(c.Filter) {
+				_, r, _ := in.LeftRight()
+				colsToAdd = append(colsToAdd, r)
+			}
+		}
 	}
 	addIntoProjection(sel, colsToAdd)
 }
@@ -178,6 +184,18 @@ func rewriteWhere(stmt *SqlSelect, from *SqlSource, node expr.Node, cols Columns
 		}
 	case *expr.NumberNode, *expr.NullNode, *expr.StringNode:
 		return nt, cols
+	case *expr.FuncNode:
+		args := make([]expr.Node, len(nt.Args))
+		for i, arg := range nt.Args {
+			args[i], cols = rewriteWhere(stmt, from, arg, cols)
+			if args[i] == nil {
+				return nil, cols
+			}
+		}
+		fn := expr.NewFuncNode(nt.Name, nt.F)
+		fn.Eval = nt.Eval
+		fn.Args = args
+		return fn, cols
 	case *expr.BinaryNode:
 		//u.Infof("binaryNode  T:%v", nt.Operator.T.String())
 		switch nt.Operator.T {
@@ -255,6 +273,7 @@ func joinNodesForFrom(stmt *SqlSelect, from *SqlSource, node expr.Node, depth in
 			}
 		}
 		fn := expr.NewFuncNode(nt.Name, nt.F)
+		fn.Eval = nt.Eval
 		fn.Args = args
 		if depth == 1 {
 			//u.Infof("adding func: %s", fn.String())
@@ -351,6 +370,8 @@ func columnsFromJoin(from *SqlSource, node expr.Node, cols Columns) Columns {
 				}
 			}
 		}
+	case *expr.NumberNode, *expr.NullNode, *expr.StringNode, *expr.ValueNode:
+		// literals need no columns
 	case *expr.FuncNode:
 		//u.Warnf("columnsFromJoin func node: %s", nt.String())
 		for _, arg := range nt.Args {
@@ -361,7 +382,8 @@ func columnsFromJoin(from *SqlSource, node expr.Node, cols Columns) Columns {
 		case lex.TokenAnd, lex.TokenLogicAnd, lex.TokenLogicOr:
 			cols = columnsFromJoin(from, nt.Args[0], cols)
 			cols = columnsFromJoin(from, nt.Args[1], cols)
-		case lex.TokenEqual, lex.TokenEqualEqual:
+		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE,
+			lex.TokenLT, lex.TokenLE:
 			cols = columnsFromJoin(from, nt.Args[0], cols)
 			cols = columnsFromJoin(from, nt.Args[1], cols)
 		default:
@@ -410,6 +432,7 @@ func rewriteNode(from *SqlSource, node expr.Node) expr.Node {
 		}
 	case *expr.FuncNode:
 		fn := expr.NewFuncNode(nt.Name, nt.F)
+		fn.Eval = nt.Eval
 		fn.Args = make([]expr.Node, len(nt.Args))
 		for i, arg := range nt.Args {
 			fn.Args[i] = rewriteNode(from, arg)
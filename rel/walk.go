@@ -0,0 +1,349 @@
+package rel
+
+import (
+	"github.com/araddon/qlbridge/expr"
+)
+
+// Walk calls expr.Walk with fn for every expression of stmt: select
+// columns, join conditions, where, group by, having, order by, insert and
+// update values, show filters and command values, including those of
+// sub-queries.
+//
+//     // every table column referenced by a query
+//     rel.Walk(stmt, func(n expr.Node) bool {
+//         if in, ok := n.(*expr.IdentityNode); ok {
+//             cols = append(cols, in.Text)
+//         }
+//         return true
+//     })
+//
+func Walk(stmt SqlStatement, fn expr.WalkFunc) {
+	walkStatement(stmt, func(n expr.Node) {
+		if n != nil {
+			expr.Walk(n, fn)
+		}
+	})
+}
+
+// Rewrite every expression of stmt with expr.Rewrite in place, ie stmt is
+// modified, for query rewriting middleware and security filters such as
+//
+//     // only the current tenants rows
+//     rel.Rewrite(stmt, func(n expr.Node) expr.Node { ... })
+//
+func Rewrite(stmt SqlStatement, fn expr.RewriteFunc) {
+	walkRewriteStatement(stmt, func(n expr.Node) expr.Node {
+		return expr.Rewrite(n, fn)
+	}, rewriteInPlace)
+}
+
+// RewriteCopy a copy of stmt with every expression rewritten with
+// expr.RewriteCopy, stmt is not modified.  The statement, its columns,
+// sources and clauses are copied, expressions fn does not replace are
+// shared with stmt.
+func RewriteCopy(stmt SqlStatement, fn expr.RewriteFunc) SqlStatement {
+	return walkRewriteStatement(stmt, func(n expr.Node) expr.Node {
+		return expr.RewriteCopy(n, fn)
+	}, rewriteCopy)
+}
+
+type rewriteMode int
+
+const (
+	rewriteInPlace rewriteMode = iota // the statement is modified
+	rewriteCopy                       // the statement is copied, then modified
+)
+
+// walkStatement call visit with each expression of stmt, read only.
+func walkStatement(stmt SqlStatement, visit func(expr.Node)) {
+	switch st := stmt.(type) {
+	case *SqlSelect:
+		walkSelect(st, visit)
+	case *PreparedStatement:
+		walkStatement(st.Statement, visit)
+	case *SqlInsert:
+		walkColumns(st.Columns, visit)
+		walkRows(st.Rows, visit)
+		if st.Select != nil {
+			walkSelect(st.Select, visit)
+		}
+	case *SqlUpsert:
+		walkColumns(st.Columns, visit)
+		walkRows(st.Rows, visit)
+		for _, vc := range st.Values {
+			visit(vc.Expr)
+		}
+		walkWhere(st.Where, visit)
+	case *SqlUpdate:
+		for _, vc := range st.Values {
+			visit(vc.Expr)
+		}
+		walkWhere(st.Where, visit)
+	case *SqlDelete:
+		walkWhere(st.Where, visit)
+	case *SqlShow:
+		visit(st.Where)
+		visit(st.Like)
+	case *SqlCommand:
+		for _, col := range st.Columns {
+			visit(col.Expr)
+		}
+		visit(st.Value)
+	case *SqlCall:
+		for _, vc := range st.Args {
+			visit(vc.Expr)
+		}
+	case *SqlCreate:
+		if st.Select != nil {
+			walkSelect(st.Select, visit)
+		}
+	}
+}
+
+func walkSelect(m *SqlSelect, visit func(expr.Node)) {
+	walkColumns(m.Columns, visit)
+	for _, from := range m.From {
+		visit(from.JoinExpr)
+		if from.SubQuery != nil {
+			walkSelect(from.SubQuery, visit)
+		}
+	}
+	walkWhere(m.Where, visit)
+	walkColumns(m.GroupBy, visit)
+	visit(m.Having)
+	walkColumns(m.OrderBy, visit)
+}
+
+func walkWhere(m *SqlWhere, visit func(expr.Node)) {
+	if m == nil {
+		return
+	}
+	visit(m.Expr)
+	if m.Source != nil {
+		walkSelect(m.Source, visit)
+	}
+}
+
+func walkColumns(cols Columns, visit func(expr.Node)) {
+	for _, col := range cols {
+		visit(col.Expr)
+		visit(col.Guard)
+		visit(col.Filter)
+	}
+}
+
+func walkRows(rows [][]*ValueColumn, visit func(expr.Node)) {
+	for _, row := range rows {
+		for _, vc := range row {
+			visit(vc.Expr)
+		}
+	}
+}
+
+// walkRewriteStatement replace each expression of stmt with the result of fn,
+// copying the statement structs first for rewriteCopy.
+func walkRewriteStatement(stmt SqlStatement, fn func(expr.Node) expr.Node, mode rewriteMode) SqlStatement {
+	switch st := stmt.(type) {
+	case *SqlSelect:
+		return walkRewriteSelect(st, fn, mode)
+	case *PreparedStatement:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Statement = walkRewriteStatement(st.Statement, fn, mode)
+		return st
+	case *SqlInsert:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Columns = walkRewriteColumns(st.Columns, fn, mode)
+		st.Rows = walkRewriteRows(st.Rows, fn, mode)
+		if st.Select != nil {
+			st.Select = walkRewriteSelect(st.Select, fn, mode)
+		}
+		return st
+	case *SqlUpsert:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Columns = walkRewriteColumns(st.Columns, fn, mode)
+		st.Rows = walkRewriteRows(st.Rows, fn, mode)
+		st.Values = walkRewriteValueMap(st.Values, fn, mode)
+		st.Where = walkRewriteWhere(st.Where, fn, mode)
+		return st
+	case *SqlUpdate:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Values = walkRewriteValueMap(st.Values, fn, mode)
+		st.Where = walkRewriteWhere(st.Where, fn, mode)
+		return st
+	case *SqlDelete:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Where = walkRewriteWhere(st.Where, fn, mode)
+		return st
+	case *SqlShow:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Where = walkRewriteNode(st.Where, fn)
+		st.Like = walkRewriteNode(st.Like, fn)
+		return st
+	case *SqlCommand:
+		if mode == rewriteCopy {
+			c := *st
+			cols := c.Columns
+			st = &c
+			st.Columns = make(CommandColumns, len(cols))
+			for i, col := range cols {
+				cc := *col
+				st.Columns[i] = &cc
+			}
+		}
+		for _, col := range st.Columns {
+			col.Expr = walkRewriteNode(col.Expr, fn)
+		}
+		st.Value = walkRewriteNode(st.Value, fn)
+		return st
+	case *SqlCall:
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+			st.Args = copyValueColumns(c.Args)
+		}
+		for _, vc := range st.Args {
+			vc.Expr = walkRewriteNode(vc.Expr, fn)
+		}
+		return st
+	case *SqlCreate:
+		if st.Select == nil {
+			return st
+		}
+		if mode == rewriteCopy {
+			c := *st
+			st = &c
+		}
+		st.Select = walkRewriteSelect(st.Select, fn, mode)
+		return st
+	}
+	return stmt
+}
+
+func walkRewriteSelect(m *SqlSelect, fn func(expr.Node) expr.Node, mode rewriteMode) *SqlSelect {
+	if mode == rewriteCopy {
+		c := *m
+		froms := c.From
+		m = &c
+		m.From = make([]*SqlSource, len(froms))
+		for i, from := range froms {
+			fc := *from
+			m.From[i] = &fc
+		}
+	}
+	// the memoized pb, fingerprint are of the statement before rewrite
+	m.pb = nil
+	m.fingerprintid = 0
+
+	m.Columns = walkRewriteColumns(m.Columns, fn, mode)
+	for _, from := range m.From {
+		from.JoinExpr = walkRewriteNode(from.JoinExpr, fn)
+		if from.SubQuery != nil {
+			from.SubQuery = walkRewriteSelect(from.SubQuery, fn, mode)
+		}
+	}
+	m.Where = walkRewriteWhere(m.Where, fn, mode)
+	m.GroupBy = walkRewriteColumns(m.GroupBy, fn, mode)
+	m.Having = walkRewriteNode(m.Having, fn)
+	m.OrderBy = walkRewriteColumns(m.OrderBy, fn, mode)
+	return m
+}
+
+func walkRewriteWhere(m *SqlWhere, fn func(expr.Node) expr.Node, mode rewriteMode) *SqlWhere {
+	if m == nil {
+		return nil
+	}
+	if mode == rewriteCopy {
+		c := *m
+		m = &c
+	}
+	m.Expr = walkRewriteNode(m.Expr, fn)
+	if m.Source != nil {
+		m.Source = walkRewriteSelect(m.Source, fn, mode)
+	}
+	return m
+}
+
+func walkRewriteColumns(cols Columns, fn func(expr.Node) expr.Node, mode rewriteMode) Columns {
+	if mode == rewriteCopy && cols != nil {
+		cc := make(Columns, len(cols))
+		for i, col := range cols {
+			c := *col
+			cc[i] = &c
+		}
+		cols = cc
+	}
+	for _, col := range cols {
+		col.Expr = walkRewriteNode(col.Expr, fn)
+		col.Guard = walkRewriteNode(col.Guard, fn)
+		col.Filter = walkRewriteNode(col.Filter, fn)
+	}
+	return cols
+}
+
+func walkRewriteRows(rows [][]*ValueColumn, fn func(expr.Node) expr.Node, mode rewriteMode) [][]*ValueColumn {
+	if mode == rewriteCopy && rows != nil {
+		rc := make([][]*ValueColumn, len(rows))
+		for i, row := range rows {
+			rc[i] = copyValueColumns(row)
+		}
+		rows = rc
+	}
+	for _, row := range rows {
+		for _, vc := range row {
+			vc.Expr = walkRewriteNode(vc.Expr, fn)
+		}
+	}
+	return rows
+}
+
+func walkRewriteValueMap(vals map[string]*ValueColumn, fn func(expr.Node) expr.Node, mode rewriteMode) map[string]*ValueColumn {
+	if mode == rewriteCopy && vals != nil {
+		vm := make(map[string]*ValueColumn, len(vals))
+		for k, vc := range vals {
+			c := *vc
+			vm[k] = &c
+		}
+		vals = vm
+	}
+	for _, vc := range vals {
+		vc.Expr = walkRewriteNode(vc.Expr, fn)
+	}
+	return vals
+}
+
+func copyValueColumns(vcs []*ValueColumn) []*ValueColumn {
+	if vcs == nil {
+		return nil
+	}
+	out := make([]*ValueColumn, len(vcs))
+	for i, vc := range vcs {
+		c := *vc
+		out[i] = &c
+	}
+	return out
+}
+
+func walkRewriteNode(n expr.Node, fn func(expr.Node) expr.Node) expr.Node {
+	if n == nil {
+		return nil
+	}
+	return fn(n)
+}
@@ -0,0 +1,49 @@
+package rel_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+)
+
+func TestWalkRewriteStatement(t *testing.T) {
+	sql := `SELECT u.name, count(o.id) AS ct FROM users AS u
+		INNER JOIN (SELECT id, user_id FROM orders WHERE tenant = "acme") AS o ON u.id = o.user_id
+		WHERE u.tenant = "acme" GROUP BY u.name HAVING ct > 1`
+	stmt, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+
+	idents := make([]string, 0)
+	rel.Walk(stmt, func(n expr.Node) bool {
+		if in, ok := n.(*expr.IdentityNode); ok {
+			idents = append(idents, in.Text)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"u.name", "o.id", "u.id", "o.user_id", "id", "user_id", "tenant", "u.tenant", "u.name", "ct"}, idents)
+
+	tenant := func(n expr.Node) expr.Node {
+		if sn, ok := n.(*expr.StringNode); ok && sn.Text == "acme" {
+			return expr.NewStringNode("globex")
+		}
+		return n
+	}
+	before := stmt.String()
+	stmt2 := rel.RewriteCopy(stmt, tenant)
+	assert.Equal(t, before, stmt.String())
+	assert.Contains(t, stmt2.String(), `u.tenant = "globex"`)
+	assert.Contains(t, stmt2.String(), `WHERE tenant = "globex"`)
+	assert.NotContains(t, stmt2.String(), `"acme"`)
+
+	rel.Rewrite(stmt, tenant)
+	assert.Equal(t, stmt2.String(), stmt.String())
+
+	upd, err := rel.ParseSql(`UPDATE users SET name = "x" WHERE tenant = "acme"`)
+	assert.Equal(t, nil, err)
+	upd2 := rel.RewriteCopy(upd, tenant)
+	assert.Equal(t, `tenant = "acme"`, upd.(*rel.SqlUpdate).Where.Expr.String())
+	assert.Equal(t, `tenant = "globex"`, upd2.(*rel.SqlUpdate).Where.Expr.String())
+}
@@ -68,6 +68,7 @@ func (m *InMemApplyer) AddOrUpdateOnSchema(s *Schema, v interface{}) error {
 		s.InfoSchema.DS.Init() // Wipe out cache, it is invalid
 		s.mu.Lock()
 		s.addTable(v)
+		s.publishUnlocked()
 		s.mu.Unlock()
 		s.InfoSchema.refreshSchemaUnlocked()
 	case *Schema:
@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// Clone deep copy of this schema, its tables and child schemas, so that
+// the copy may be modified (add/alter tables, aliases) without affecting
+// this one.  The underlying Source (DS) and config are shared, they are
+// connections not definitions.  The clone is not registered, its
+// InfoSchema is created when it is added to a Registry.
+func (m *Schema) Clone() *Schema {
+	return m.clone(nil)
+}
+
+func (m *Schema) clone(parent *Schema) *Schema {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := NewSchemaSource(m.Name, m.DS)
+	s.Conf = m.Conf
+	s.Pool = m.Pool
+	s.Retry = m.Retry
+	s.Limiter = m.Limiter
+	s.SchemaRef = m.SchemaRef
+	s.parent = parent
+	s.lastRefreshed = m.lastRefreshed
+
+	// owner of each table, this or child schema, mapped to its clone
+	owners := map[*Schema]*Schema{m: s}
+	for name, child := range m.schemas {
+		cs := child.clone(s)
+		s.schemas[name] = cs
+		owners[child] = cs
+	}
+	for name, tbl := range m.tableMap {
+		owner, ok := owners[m.tableSchemas[name]]
+		switch {
+		case !ok:
+			// some other schema's table, keep the reference
+			owner = m.tableSchemas[name]
+		case owner == s:
+			tbl = tbl.Clone()
+			tbl.Schema = s
+		default:
+			// owned by a cloned child, share its copy
+			if ct := owner.snapshot().tableMap[name]; ct != nil {
+				tbl = ct
+			}
+		}
+		s.tableMap[name] = tbl
+		s.tableSchemas[name] = owner
+	}
+	s.tableNames = append(s.tableNames, m.tableNames...)
+	s.publishUnlocked()
+	return s
+}
+
+// Clone deep copy of this table, its fields, indexes, columns and context.
+// The Schema and Source it belongs to are shared, values stored in
+// Context maps are copied by reference.
+func (m *Table) Clone() *Table {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t := &Table{
+		TablePb:       *proto.Clone(&m.TablePb).(*TablePb),
+		Schema:        m.Schema,
+		Source:        m.Source,
+		tblID:         m.tblID,
+		lastRefreshed: m.lastRefreshed,
+	}
+	t.Fields = make([]*Field, len(m.Fields))
+	fieldClones := make(map[*Field]*Field, len(m.Fields))
+	for i, f := range m.Fields {
+		t.Fields[i] = f.Clone()
+		fieldClones[f] = t.Fields[i]
+	}
+	t.FieldMap = make(map[string]*Field, len(m.FieldMap))
+	for name, f := range m.FieldMap {
+		if cf, ok := fieldClones[f]; ok {
+			t.FieldMap[name] = cf
+		} else {
+			t.FieldMap[name] = f.Clone()
+		}
+	}
+	if m.FieldPositions != nil {
+		t.FieldPositions = make(map[string]int, len(m.FieldPositions))
+		for name, pos := range m.FieldPositions {
+			t.FieldPositions[name] = pos
+		}
+	}
+	if m.cols != nil {
+		t.cols = append(make([]string, 0, len(m.cols)), m.cols...)
+	}
+	t.Context = cloneContext(m.Context)
+	return t
+}
+
+// Clone deep copy of this field.
+func (m *Field) Clone() *Field {
+	return &Field{
+		idx:     m.idx,
+		FieldPb: *proto.Clone(&m.FieldPb).(*FieldPb),
+		Context: cloneContext(m.Context),
+	}
+}
+
+func cloneContext(ctx map[string]interface{}) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(ctx))
+	for k, v := range ctx {
+		c[k] = v
+	}
+	return c
+}
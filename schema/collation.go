@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	collationMu sync.RWMutex
+	collations  = make(map[string]*Collation)
+	charsets    = make(map[string]*Charset)
+)
+
+func init() {
+	for _, cs := range []*Charset{
+		{Name: "ascii", Description: "US ASCII", DefaultCollation: "ascii_general_ci", Maxlen: 1},
+		{Name: "binary", Description: "Binary pseudo charset", DefaultCollation: "binary", Maxlen: 1},
+		{Name: "latin1", Description: "cp1252 West European", DefaultCollation: "latin1_swedish_ci", Maxlen: 1},
+		{Name: "utf8", Description: "UTF-8 Unicode", DefaultCollation: "utf8_general_ci", Maxlen: 3},
+		{Name: "utf8mb4", Description: "UTF-8 Unicode", DefaultCollation: "utf8mb4_general_ci", Maxlen: 4},
+	} {
+		RegisterCharset(cs)
+	}
+	for _, c := range []*Collation{
+		{Name: "latin1_swedish_ci", Charset: "latin1", ID: 8, Sortlen: 1},
+		{Name: "ascii_general_ci", Charset: "ascii", ID: 11, Sortlen: 1},
+		{Name: "utf8_general_ci", Charset: "utf8", ID: 33, Sortlen: 1},
+		{Name: "utf8mb4_general_ci", Charset: "utf8mb4", ID: 45, Sortlen: 1},
+		{Name: "utf8mb4_bin", Charset: "utf8mb4", ID: 46, Sortlen: 1},
+		{Name: "latin1_bin", Charset: "latin1", ID: 47, Sortlen: 1},
+		{Name: "binary", Charset: "binary", ID: 63, Sortlen: 1},
+		{Name: "ascii_bin", Charset: "ascii", ID: 65, Sortlen: 1},
+		{Name: "utf8_bin", Charset: "utf8", ID: 83, Sortlen: 1},
+		{Name: "utf8_unicode_ci", Charset: "utf8", ID: 192, Sortlen: 8},
+		{Name: "utf8mb4_unicode_ci", Charset: "utf8mb4", ID: 224, Sortlen: 8},
+	} {
+		RegisterCollation(c)
+	}
+}
+
+type (
+	// Collation a named ordering/comparison of a character set, the mysql
+	// protocol identifies these by ID (which clients such as JDBC map back
+	// to a charset via SHOW COLLATION).
+	Collation struct {
+		Name    string
+		Charset string
+		ID      int
+		Sortlen int
+	}
+	// Charset a character set and its default collation.
+	Charset struct {
+		Name             string
+		Description      string
+		DefaultCollation string
+		Maxlen           int
+	}
+)
+
+// IsDefault is this the default collation of its character set.
+func (m *Collation) IsDefault() bool {
+	cs, ok := CharsetByName(m.Charset)
+	return ok && strings.EqualFold(cs.DefaultCollation, m.Name)
+}
+
+// RegisterCollation add (or replace) a collation in the registry listed
+// by SHOW COLLATION.
+func RegisterCollation(c *Collation) {
+	collationMu.Lock()
+	collations[strings.ToLower(c.Name)] = c
+	collationMu.Unlock()
+}
+
+// RegisterCharset add (or replace) a character set in the registry listed
+// by SHOW CHARACTER SET.
+func RegisterCharset(cs *Charset) {
+	collationMu.Lock()
+	charsets[strings.ToLower(cs.Name)] = cs
+	collationMu.Unlock()
+}
+
+// CollationByName find a registered collation, case-insensitive.
+func CollationByName(name string) (*Collation, bool) {
+	collationMu.RLock()
+	defer collationMu.RUnlock()
+	c, ok := collations[strings.ToLower(name)]
+	return c, ok
+}
+
+// CharsetByName find a registered character set, case-insensitive.
+func CharsetByName(name string) (*Charset, bool) {
+	collationMu.RLock()
+	defer collationMu.RUnlock()
+	cs, ok := charsets[strings.ToLower(name)]
+	return cs, ok
+}
+
+// Collations list of all registered collations, ordered by name.
+func Collations() []*Collation {
+	collationMu.RLock()
+	list := make([]*Collation, 0, len(collations))
+	for _, c := range collations {
+		list = append(list, c)
+	}
+	collationMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Charsets list of all registered character sets, ordered by name.
+func Charsets() []*Charset {
+	collationMu.RLock()
+	list := make([]*Charset, 0, len(charsets))
+	for _, cs := range charsets {
+		list = append(list, cs)
+	}
+	collationMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -2,19 +2,19 @@ package schema
 
 import (
 	"database/sql/driver"
-	"fmt"
 
 	"golang.org/x/net/context"
 
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/sqlerr"
 	"github.com/araddon/qlbridge/value"
 )
 
 var (
 	// ErrNotFound is error expressing sought item was not found.
-	ErrNotFound = fmt.Errorf("Not Found")
+	ErrNotFound = sqlerr.New(sqlerr.ErUnknown, "Not Found")
 	// ErrNotImplemented this feature is not implemented for this source.
-	ErrNotImplemented = fmt.Errorf("Not Implemented")
+	ErrNotImplemented = sqlerr.New(sqlerr.ErNotSupported, "Not Implemented")
 )
 
 type (
@@ -61,6 +61,55 @@ type (
 		Partitions() []*Partition
 		PartitionSource(p *Partition) (Conn, error)
 	}
+	// SourceReplicated is an optional interface for a partitioned source whose
+	// partitions are each held on more than one node.  If a scan of a partition
+	// fails part way, the partition is re-issued on another replica, and the
+	// rows already read are skipped, rather than failing the whole query.
+	// Replicas must return a partitions rows in the same order.
+	SourceReplicated interface {
+		SourcePartitionable
+		// PartitionReplicas number of replicas of the partition.
+		PartitionReplicas(p *Partition) int
+		// ReplicaSource open a conn to replica (0 to PartitionReplicas-1) of partition.
+		ReplicaSource(p *Partition, replica int) (Conn, error)
+	}
+	// SourceSharded is an optional interface for a source whose ConfigSource
+	// lists more than one Node, each holding a horizontal shard of the same
+	// tables.  Selects are scattered to every node and their results
+	// gathered, with aggregates computed per shard and re-aggregated.
+	SourceSharded interface {
+		// ShardSource open a conn to table on node.
+		ShardSource(node *ConfigNode, table string) (Conn, error)
+	}
+	// SourceRowCounter is an optional interface for sources that know the
+	// (possibly approximate) row count of their tables from metadata, so an
+	// approximate COUNT(*) can be answered without a scan.
+	SourceRowCounter interface {
+		// RowCount of table, false if not known.
+		RowCount(table string) (int64, bool)
+	}
+	// SourceSampler is an optional interface for a source holding a
+	// materialized sample of another table, ie CREATE SAMPLE TABLE, so
+	// exploratory queries may be answered from the sample instead.
+	SourceSampler interface {
+		// SampleOf the name of the sampled table, and the sample percent.
+		SampleOf() (table string, percent float64)
+	}
+	// SourceTableOptions is an optional interface for a source accepting the
+	// table options of a CREATE TABLE, ie ENGINE=memdb TTL="10m".
+	SourceTableOptions interface {
+		// SetTableOptions of table, an error if an option is invalid.
+		SetTableOptions(table string, opts map[string]interface{}) error
+	}
+	// SourceHierarchy is an optional interface for hierarchical sources, ie
+	// the column families of a Bigtable/HBase table, or nested objects of an
+	// Elasticsearch document, whose tables have child tables.  Tables lists
+	// only the top level tables, children are discovered recursively and
+	// their Table has Parent, and ParentKeys set.
+	SourceHierarchy interface {
+		// ChildTables the names of the direct child tables of table.
+		ChildTables(table string) []string
+	}
 	// SourceTableColumn is a partial source that just provides access to
 	// Column schema info, used in Generators.
 	SourceTableColumn interface {
@@ -124,11 +173,35 @@ type (
 		// Next returns the next message.  If none remain, returns nil.
 		Next() Message
 	}
+	// IteratorErr is an optional interface for an Iterator to report the error
+	// that ended iteration, Next returning nil with a nil Err is end of data.
+	IteratorErr interface {
+		Err() error
+	}
 	// ConnSeeker is a conn that is Key-Value store, allows relational
 	// implementation to be faster for Seeking row values instead of scanning
 	ConnSeeker interface {
 		Get(key driver.Value) (Message, error)
 	}
+	// ConnIndexSeeker is a conn that can seek the rows whose value of an
+	// index is key, including expression indexes of its table (see
+	// Table.AddExprIndex), so joins on them are keyed lookups.
+	ConnIndexSeeker interface {
+		GetIndex(index string, key driver.Value) ([]Message, error)
+	}
+	// ConnKeyFilter is a conn on the probe side of a join that can skip the
+	// rows whose join key is not in the KeyFilter of the joins build side,
+	// a runtime filter pushed into its scan before the first Next.  The key
+	// of a row is the string values of its join nodes, NUL separated.
+	ConnKeyFilter interface {
+		SetKeyFilter(nodes []expr.Node, f KeyFilter)
+	}
+	// KeyFilter a filter of keys, ie a bloom filter, it may have false
+	// positives but never false negatives.
+	KeyFilter interface {
+		// Has returns false if key is definitely not in filter.
+		Has(key string) bool
+	}
 	// ConnMutation creates a Mutator connection similar to Open() connection for select
 	// - accepts the plan context used in this upsert/insert/update
 	// - returns a connection which must be closed
@@ -146,11 +219,31 @@ type (
 		Put(ctx context.Context, key Key, value interface{}) (Key, error)
 		PutMulti(ctx context.Context, keys []Key, src interface{}) ([]Key, error)
 	}
+	// ConnPutBatch is an optional interface for a conn writing a batch of
+	// rows with an outcome per row, a failed row doesn't fail the batch,
+	// ie INSERT ... ON ERROR SKIP.  See PutBatch for the polyfill of conns
+	// that only Put.
+	ConnPutBatch interface {
+		PutBatch(ctx context.Context, rows [][]driver.Value) ([]*PutResult, error)
+	}
 	// ConnPatchWhere pass through where expression to underlying datasource
 	// Used for update statements WHERE x = y
 	ConnPatchWhere interface {
 		PatchWhere(ctx context.Context, where expr.Node, patch interface{}) (int64, error)
 	}
+	// ConnCompareAndSwap is an optional interface for a conn that patches
+	// the rows of an update where atomically with reading them, so the
+	// optimistic concurrency pattern
+	//
+	//    UPDATE accounts SET balance = 10 WHERE id = 7 AND version = 3
+	//
+	// only changes the row if no other writer changed it since it was read.
+	// The VersionColumn of the table (if set, and not patched) of each row
+	// changed is incremented.  Returns the rows where matched, and those
+	// the patch changed (not already holding its values).
+	ConnCompareAndSwap interface {
+		CompareAndSwap(ctx context.Context, where expr.Node, patch map[string]driver.Value) (matched, changed int64, err error)
+	}
 	// ConnDeletion deletion interface for data sources
 	ConnDeletion interface {
 		// Delete using this key
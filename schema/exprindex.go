@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+)
+
+const (
+	// ExprIndexesContextKey the Table Context key of its []*ExprIndex.
+	ExprIndexesContextKey = "expr_indexes"
+)
+
+// ExprIndex an index of a table on the value of an expression of its
+// columns, rather than of a column, ie lower(email).  The planner reads a
+// source joined on an indexed expression by keyed lookups of the index,
+// sources declaring them must implement ConnIndexSeeker.
+type ExprIndex struct {
+	Name string
+	Expr expr.Node // expression of un-qualified columns of the table
+}
+
+// AddExprIndex declare the index name of this table on expression n.
+//
+//     users.AddExprIndex("idx_email_lower", lowerEmailNode)
+//
+func (m *Table) AddExprIndex(name string, n expr.Node) {
+	idx := &ExprIndex{Name: name, Expr: n}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Context == nil {
+		m.Context = make(map[string]interface{})
+	}
+	idxs, _ := m.Context[ExprIndexesContextKey].([]*ExprIndex)
+	m.Context[ExprIndexesContextKey] = append(idxs, idx)
+}
+
+// ExprIndexes declared on this table.
+func (m *Table) ExprIndexes() []*ExprIndex {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	idxs, _ := m.Context[ExprIndexesContextKey].([]*ExprIndex)
+	return idxs
+}
+
+// ExprIndex the expression index of this table on n (un-qualified
+// columns), nil if none.
+func (m *Table) ExprIndex(n expr.Node) *ExprIndex {
+	if n == nil {
+		return nil
+	}
+	for _, idx := range m.ExprIndexes() {
+		if strings.EqualFold(idx.Expr.String(), n.String()) {
+			return idx
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"sort"
+)
+
+const (
+	// ParentKeyContextKey the Table Context key naming the field of the
+	// parent table child rows belong to, ie the row key of a column family.
+	ParentKeyContextKey = "parent_key"
+	// ChildKeyContextKey the Table Context key naming the field of a child
+	// table holding the ParentKeyContextKey value of its parent row.
+	ChildKeyContextKey = "child_key"
+)
+
+// SetParent make this table a child of parent, its rows belong to the
+// parent row whose parentKey equals their childKey, so a join of the two
+// without an ON condition is
+//
+//     SELECT u.name, p.city FROM users AS u INNER JOIN users_profile AS p
+//     -- ON u.id = p.user_id
+//
+//     profile.SetParent("users", "id", "user_id")
+//
+func (m *Table) SetParent(parent, parentKey, childKey string) {
+	m.AddContext(ParentKeyContextKey, parentKey)
+	m.AddContext(ChildKeyContextKey, childKey)
+	m.mu.Lock()
+	m.Parent = parent
+	m.mu.Unlock()
+}
+
+// ParentKeys the parent table field, and this tables field child rows are
+// joined to their parent on, false if this is not a child table or the
+// keys are not known.
+func (m *Table) ParentKeys() (parentKey, childKey string, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.Parent == "" {
+		return "", "", false
+	}
+	parentKey, _ = m.Context[ParentKeyContextKey].(string)
+	childKey, _ = m.Context[ChildKeyContextKey].(string)
+	return parentKey, childKey, parentKey != "" && childKey != ""
+}
+
+// ChildTables the tables of this schema whose parent is table, by name.
+func (m *Schema) ChildTables(table string) []*Table {
+	snap := m.snapshot()
+	children := make([]*Table, 0)
+	for _, tbl := range snap.tableMap {
+		if tbl != nil && tbl.Parent != "" && tbl.Parent == table {
+			children = append(children, tbl)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children
+}
+
+// addChildTablesUnlocked recursively discover the child tables of parent
+// from a hierarchical source, visited guards against cycles.
+func (m *Schema) addChildTablesUnlocked(hs SourceHierarchy, parent string, visited map[string]bool) {
+	if visited[parent] {
+		return
+	}
+	visited[parent] = true
+	for _, child := range hs.ChildTables(parent) {
+		if visited[child] {
+			continue
+		}
+		m.addschemaForTableUnlocked(child, m)
+		if tbl := m.tableMap[child]; tbl != nil && tbl.Parent == "" {
+			tbl.mu.Lock()
+			tbl.Parent = parent
+			tbl.mu.Unlock()
+		}
+		m.addChildTablesUnlocked(hs, child, visited)
+	}
+}
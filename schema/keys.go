@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"strings"
+)
+
+const (
+	// ForeignKeysContextKey the Table Context key of its []*ForeignKey.
+	ForeignKeysContextKey = "foreign_keys"
+)
+
+// ForeignKey Fields of a table whose values reference the unique key
+// RefFields of RefTable, every (non null) value has a row in RefTable.
+// Sources rarely enforce these, they are declared so the planner may
+// drop joins which can not change results.
+type ForeignKey struct {
+	Fields    []string
+	RefTable  string
+	RefFields []string
+}
+
+// AddForeignKey declare fields of this table reference the unique key
+// refFields of refTable.
+//
+//     orders.AddForeignKey([]string{"user_id"}, "users", []string{"user_id"})
+//
+func (m *Table) AddForeignKey(fields []string, refTable string, refFields []string) {
+	fk := &ForeignKey{Fields: fields, RefTable: refTable, RefFields: refFields}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Context == nil {
+		m.Context = make(map[string]interface{})
+	}
+	fks, _ := m.Context[ForeignKeysContextKey].([]*ForeignKey)
+	m.Context[ForeignKeysContextKey] = append(fks, fk)
+}
+
+// ForeignKeys declared on this table.
+func (m *Table) ForeignKeys() []*ForeignKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fks, _ := m.Context[ForeignKeysContextKey].([]*ForeignKey)
+	return fks
+}
+
+// ForeignKey the foreign key of this table whose fields reference
+// refFields of refTable pairwise (in any order), nil if none.
+func (m *Table) ForeignKey(fields []string, refTable string, refFields []string) *ForeignKey {
+	if len(fields) != len(refFields) {
+		return nil
+	}
+	pairs := make(map[string]string, len(fields))
+	for i, f := range fields {
+		pairs[strings.ToLower(f)] = strings.ToLower(refFields[i])
+	}
+fkLoop:
+	for _, fk := range m.ForeignKeys() {
+		if !strings.EqualFold(fk.RefTable, refTable) || len(fk.Fields) != len(pairs) || len(fk.RefFields) != len(fk.Fields) {
+			continue
+		}
+		for i, f := range fk.Fields {
+			if ref, ok := pairs[strings.ToLower(f)]; !ok || ref != strings.ToLower(fk.RefFields[i]) {
+				continue fkLoop
+			}
+		}
+		return fk
+	}
+	return nil
+}
+
+// IsUniqueKey true if fields include all fields of the primary key index,
+// or a field whose Key is PRI or UNI, so at most one row of the table has
+// any given values of fields.
+func (m *Table) IsUniqueKey(fields ...string) bool {
+	has := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		has[strings.ToLower(f)] = true
+	}
+	for _, idx := range m.Indexes {
+		if !idx.PrimaryKey || len(idx.Fields) == 0 {
+			continue
+		}
+		covered := true
+		for _, f := range idx.Fields {
+			covered = covered && has[strings.ToLower(f)]
+		}
+		if covered {
+			return true
+		}
+	}
+	for _, f := range fields {
+		if fld, ok := m.Field(f); ok && (fld.Key == "PRI" || fld.Key == "UNI") {
+			return true
+		}
+	}
+	return false
+}
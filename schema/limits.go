@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+const (
+	// SettingMaxScans source setting, max scans in flight at once against
+	// the source, 0 or missing no limit.
+	SettingMaxScans = "max_scans"
+	// SettingMaxWrites source setting, max writes (insert, update, delete)
+	// in flight at once against the source, 0 or missing no limit.
+	SettingMaxWrites = "max_writes"
+	// SettingLimitPolicy source setting, what an operation over the limit
+	// does, LimitBlock (the default) or LimitError.
+	SettingLimitPolicy = "limit_policy"
+	// SettingLimitTimeout source setting, max wait of a blocked operation
+	// ie "5s", after which it fails with ErrSourceBusy, missing waits forever.
+	SettingLimitTimeout = "limit_timeout"
+
+	// LimitBlock operations over the limit wait for a slot.
+	LimitBlock = "block"
+	// LimitError operations over the limit fail with ErrSourceBusy.
+	LimitError = "error"
+)
+
+var (
+	// ErrSourceBusy an operation was rejected, the source is at its
+	// limit of concurrent scans or writes.
+	ErrSourceBusy = sqlerr.New(sqlerr.ErConCount, "Too many concurrent operations on source")
+)
+
+// SourceLimiter caps the scans and writes in flight against a source, so
+// federated queries don't overwhelm small backends.  Configured from the
+// Settings of a ConfigSource:
+//
+//     {"name":"users", "type":"mysql", "settings":{"max_scans":4, "max_writes":1, "limit_policy":"block", "limit_timeout":"5s"}}
+//
+// A nil limiter has no limits.
+type SourceLimiter struct {
+	Block   bool          // wait for a slot, else fail with ErrSourceBusy
+	Timeout time.Duration // max wait for a slot if Block, 0 forever
+	scans   chan struct{}
+	writes  chan struct{}
+}
+
+// NewSourceLimiter a limiter of maxScans and maxWrites in flight, < 1
+// no limit.
+func NewSourceLimiter(maxScans, maxWrites int) *SourceLimiter {
+	m := &SourceLimiter{Block: true}
+	if maxScans > 0 {
+		m.scans = make(chan struct{}, maxScans)
+	}
+	if maxWrites > 0 {
+		m.writes = make(chan struct{}, maxWrites)
+	}
+	return m
+}
+
+// NewSourceLimiterConf the limiter of the settings of conf, nil if it
+// sets no limits.
+func NewSourceLimiterConf(conf *ConfigSource) (*SourceLimiter, error) {
+	if conf == nil || conf.Settings == nil {
+		return nil, nil
+	}
+	maxScans, maxWrites := conf.Settings.Int(SettingMaxScans), conf.Settings.Int(SettingMaxWrites)
+	if maxScans <= 0 && maxWrites <= 0 {
+		return nil, nil
+	}
+	m := NewSourceLimiter(maxScans, maxWrites)
+	switch policy := conf.Settings.String(SettingLimitPolicy); policy {
+	case "", LimitBlock:
+	case LimitError:
+		m.Block = false
+	default:
+		return nil, fmt.Errorf("invalid %s %q, expected %q or %q", SettingLimitPolicy, policy, LimitBlock, LimitError)
+	}
+	if timeout := conf.Settings.String(SettingLimitTimeout); timeout != "" {
+		dur, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", SettingLimitTimeout, timeout, err)
+		}
+		m.Timeout = dur
+	}
+	return m, nil
+}
+
+// AcquireScan a scan slot, release must be called once the scan is done.
+// Waits per the policy, giving up with context.Canceled if done is closed.
+func (m *SourceLimiter) AcquireScan(done <-chan struct{}) (release func(), err error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	return m.acquire(m.scans, done)
+}
+
+// AcquireWrite a write slot, release must be called once the write is
+// done.  Waits per the policy, giving up with context.Canceled if done is
+// closed.
+func (m *SourceLimiter) AcquireWrite(done <-chan struct{}) (release func(), err error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	return m.acquire(m.writes, done)
+}
+
+// InFlight the scans and writes holding a slot.
+func (m *SourceLimiter) InFlight() (scans, writes int) {
+	if m == nil {
+		return 0, 0
+	}
+	return len(m.scans), len(m.writes)
+}
+
+func (m *SourceLimiter) acquire(slots chan struct{}, done <-chan struct{}) (func(), error) {
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return releaser(slots), nil
+	default:
+	}
+	if !m.Block {
+		return nil, ErrSourceBusy
+	}
+	var timeout <-chan time.Time
+	if m.Timeout > 0 {
+		timer := time.NewTimer(m.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case slots <- struct{}{}:
+		return releaser(slots), nil
+	case <-timeout:
+		return nil, ErrSourceBusy
+	case <-done:
+		return nil, context.Canceled
+	}
+}
+
+func releaser(slots chan struct{}) func() {
+	released := false
+	return func() {
+		if !released {
+			released = true
+			<-slots
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	u "github.com/araddon/gou"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestSourceLimiter(t *testing.T) {
+	conf := func(settings u.JsonHelper) *schema.ConfigSource {
+		return &schema.ConfigSource{Name: "users", Settings: settings}
+	}
+	l, err := schema.NewSourceLimiterConf(conf(nil))
+	assert.Equal(t, nil, err)
+	assert.True(t, l == nil)
+	_, err = schema.NewSourceLimiterConf(conf(u.JsonHelper{"max_scans": 1, "limit_policy": "drop"}))
+	assert.NotEqual(t, nil, err)
+	_, err = schema.NewSourceLimiterConf(conf(u.JsonHelper{"max_scans": 1, "limit_timeout": "soon"}))
+	assert.NotEqual(t, nil, err)
+
+	// no limits
+	var none *schema.SourceLimiter
+	release, err := none.AcquireScan(nil)
+	assert.Equal(t, nil, err)
+	release()
+
+	// over the limit, rejected
+	l, err = schema.NewSourceLimiterConf(conf(u.JsonHelper{"max_scans": 2, "limit_policy": "error"}))
+	assert.Equal(t, nil, err)
+	r1, err := l.AcquireScan(nil)
+	assert.Equal(t, nil, err)
+	r2, err := l.AcquireScan(nil)
+	assert.Equal(t, nil, err)
+	_, err = l.AcquireScan(nil)
+	assert.Equal(t, schema.ErrSourceBusy, err)
+	scans, writes := l.InFlight()
+	assert.Equal(t, 2, scans)
+	assert.Equal(t, 0, writes)
+	// writes are not limited
+	w, err := l.AcquireWrite(nil)
+	assert.Equal(t, nil, err)
+	w()
+	r1()
+	r1()
+	r2()
+	scans, _ = l.InFlight()
+	assert.Equal(t, 0, scans)
+
+	// over the limit, blocked until a slot is released, timed out or done
+	l, err = schema.NewSourceLimiterConf(conf(u.JsonHelper{"max_writes": 1, "limit_timeout": "20ms"}))
+	assert.Equal(t, nil, err)
+	assert.True(t, l.Block)
+	w, err = l.AcquireWrite(nil)
+	assert.Equal(t, nil, err)
+	start := time.Now()
+	_, err = l.AcquireWrite(nil)
+	assert.Equal(t, schema.ErrSourceBusy, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	l.Timeout = 0
+	done := make(chan struct{})
+	close(done)
+	_, err = l.AcquireWrite(done)
+	assert.Equal(t, context.Canceled, err)
+
+	acquired := make(chan error, 1)
+	go func() {
+		release, err := l.AcquireWrite(nil)
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	w()
+	select {
+	case err = <-acquired:
+		assert.Equal(t, nil, err)
+	case <-time.After(time.Second):
+		t.Fatalf("blocked write was not given the released slot")
+	}
+}
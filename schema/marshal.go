@@ -0,0 +1,545 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	u "github.com/araddon/gou"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/araddon/qlbridge/value"
+)
+
+// Schema, Table, Field and Index marshal to json, yaml and protobuf, so a
+// schema can be kept in a config repo, diffed, and loaded at startup instead
+// of being discovered from its sources.  json and yaml share the document
+// shape below, protobuf uses the SchemaPb, TablePb and FieldPb messages
+// with Context as json bytes.
+type (
+	schemaDoc struct {
+		Name   string      `json:"name" yaml:"name"`
+		Tables []*tableDoc `json:"tables,omitempty" yaml:"tables,omitempty"`
+	}
+	tableDoc struct {
+		Name        string                 `json:"name" yaml:"name"`
+		Parent      string                 `json:"parent,omitempty" yaml:"parent,omitempty"`
+		Charset     uint32                 `json:"charset,omitempty" yaml:"charset,omitempty"`
+		Partition   *TablePartition        `json:"partition,omitempty" yaml:"partition,omitempty"`
+		PartitionCt uint32                 `json:"partitionCt,omitempty" yaml:"partitionCt,omitempty"`
+		Indexes     []*Index               `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+		Context     map[string]interface{} `json:"context,omitempty" yaml:"context,omitempty"`
+		Fields      []*fieldDoc            `json:"fields,omitempty" yaml:"fields,omitempty"`
+	}
+	fieldDoc struct {
+		Name        string                 `json:"name" yaml:"name"`
+		Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+		Key         string                 `json:"key,omitempty" yaml:"key,omitempty"`
+		Extra       string                 `json:"extra,omitempty" yaml:"extra,omitempty"`
+		Data        string                 `json:"data,omitempty" yaml:"data,omitempty"`
+		Length      uint32                 `json:"length,omitempty" yaml:"length,omitempty"`
+		Type        string                 `json:"type" yaml:"type"`
+		NativeType  uint32                 `json:"nativeType,omitempty" yaml:"nativeType,omitempty"`
+		DefLength   uint64                 `json:"defLength,omitempty" yaml:"defLength,omitempty"`
+		DefVal      interface{}            `json:"defVal,omitempty" yaml:"defVal,omitempty"`
+		Indexed     bool                   `json:"indexed,omitempty" yaml:"indexed,omitempty"`
+		NoNulls     bool                   `json:"noNulls,omitempty" yaml:"noNulls,omitempty"`
+		Collation   string                 `json:"collation,omitempty" yaml:"collation,omitempty"`
+		Roles       []string               `json:"roles,omitempty" yaml:"roles,omitempty"`
+		Indexes     []*Index               `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+		Context     map[string]interface{} `json:"context,omitempty" yaml:"context,omitempty"`
+	}
+	indexDoc struct {
+		Name          string   `yaml:"name"`
+		Fields        []string `yaml:"fields,omitempty"`
+		PrimaryKey    bool     `yaml:"primaryKey,omitempty"`
+		HashPartition []string `yaml:"hashPartition,omitempty"`
+		PartitionSize int32    `yaml:"partitionSize,omitempty"`
+	}
+)
+
+// Marshal the schema as protobuf SchemaPb bytes, its name and each of its
+// tables with their fields, indexes and context, see Unmarshal.
+func (m *Schema) Marshal() ([]byte, error) {
+	return proto.Marshal(m.toPb())
+}
+
+// Unmarshal protobuf SchemaPb bytes, as written by Marshal, into this
+// schema.  The name is replaced if set, the tables are added as described
+// without loading them from the underlying source.
+func (m *Schema) Unmarshal(data []byte) error {
+	spb := &SchemaPb{}
+	if err := proto.Unmarshal(data, spb); err != nil {
+		return err
+	}
+	if spb.Name != "" {
+		m.Name = spb.Name
+	}
+	tables := make([]*Table, len(spb.Tables))
+	for i, tpb := range spb.Tables {
+		tables[i] = &Table{TablePb: *tpb}
+		if err := tables[i].initFromPb(); err != nil {
+			return err
+		}
+	}
+	m.addTables(tables)
+	return nil
+}
+
+// MarshalJSON the schema name and tables as json.
+func (m *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.toDoc())
+}
+
+// UnmarshalJSON json schema document into this schema, adding its tables.
+func (m *Schema) UnmarshalJSON(data []byte) error {
+	doc := &schemaDoc{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return err
+	}
+	return m.fromDoc(doc)
+}
+
+// MarshalYAML the schema name and tables as yaml.
+func (m *Schema) MarshalYAML() (interface{}, error) {
+	return m.toDoc(), nil
+}
+
+// UnmarshalYAML yaml schema document into this schema, adding its tables.
+func (m *Schema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc := &schemaDoc{}
+	if err := unmarshal(doc); err != nil {
+		return err
+	}
+	return m.fromDoc(doc)
+}
+
+// schemaTables list of tables owned by this schema, in name order.
+func (m *Schema) schemaTables() []*Table {
+	ss := m.snapshot()
+	tables := make([]*Table, 0, len(ss.tableNames))
+	for _, name := range ss.tableNames {
+		if tbl := ss.tableMap[name]; tbl != nil {
+			tables = append(tables, tbl)
+		}
+	}
+	return tables
+}
+
+// addTables add already described tables to this schema without loading
+// them from the underlying source.
+func (m *Schema) addTables(tables []*Table) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tableMap == nil {
+		m.tableMap = make(map[string]*Table)
+	}
+	if m.tableSchemas == nil {
+		m.tableSchemas = make(map[string]*Schema)
+	}
+	if m.schemas == nil {
+		m.schemas = make(map[string]*Schema)
+	}
+	for _, tbl := range tables {
+		tbl.Schema = m
+		tbl.Source = m.DS
+		m.addTable(tbl)
+		m.tableSchemas[tbl.Name] = m
+	}
+	m.publishUnlocked()
+}
+
+func (m *Schema) toPb() *SchemaPb {
+	spb := &SchemaPb{Name: m.Name}
+	for _, tbl := range m.schemaTables() {
+		spb.Tables = append(spb.Tables, tbl.toPb())
+	}
+	return spb
+}
+
+func (m *Schema) toDoc() *schemaDoc {
+	doc := &schemaDoc{Name: m.Name}
+	for _, tbl := range m.schemaTables() {
+		doc.Tables = append(doc.Tables, tbl.toDoc())
+	}
+	return doc
+}
+
+func (m *Schema) fromDoc(doc *schemaDoc) error {
+	if doc.Name != "" {
+		m.Name = doc.Name
+	}
+	tables := make([]*Table, len(doc.Tables))
+	for i, td := range doc.Tables {
+		tables[i] = &Table{}
+		if err := tables[i].fromDoc(td); err != nil {
+			return err
+		}
+	}
+	m.addTables(tables)
+	return nil
+}
+
+// Unmarshal protobuf TablePb bytes, as written by Marshal, into this table
+// replacing its fields, context and columns.
+func (m *Table) Unmarshal(data []byte) error {
+	tpb := TablePb{}
+	if err := proto.Unmarshal(data, &tpb); err != nil {
+		return err
+	}
+	m.TablePb = tpb
+	return m.initFromPb()
+}
+
+// MarshalJSON the table, its fields, indexes and context as json.
+func (m *Table) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.toDoc())
+}
+
+// UnmarshalJSON json table document into this table.
+func (m *Table) UnmarshalJSON(data []byte) error {
+	doc := &tableDoc{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return err
+	}
+	return m.fromDoc(doc)
+}
+
+// MarshalYAML the table, its fields, indexes and context as yaml.
+func (m *Table) MarshalYAML() (interface{}, error) {
+	return m.toDoc(), nil
+}
+
+// UnmarshalYAML yaml table document into this table.
+func (m *Table) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc := &tableDoc{}
+	if err := unmarshal(doc); err != nil {
+		return err
+	}
+	return m.fromDoc(doc)
+}
+
+// toPb a copy of the embedded TablePb with the Fields and Context, the
+// table itself is not modified.
+func (m *Table) toPb() *TablePb {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tpb := proto.Clone(&m.TablePb).(*TablePb)
+	tpb.Fieldpbs = make([]*FieldPb, len(m.Fields))
+	for i, f := range m.Fields {
+		tpb.Fieldpbs[i] = f.toPb()
+	}
+	tpb.ContextJson = contextToJson(m.Context)
+	return tpb
+}
+
+// initFromPb build the Fields, Context and column positions
+// from the embedded TablePb.
+func (m *Table) initFromPb() error {
+	if m.NameOriginal == "" {
+		m.NameOriginal = m.Name
+	}
+	ctx, err := contextFromJson(m.ContextJson)
+	if err != nil {
+		return err
+	}
+	fields := make([]*Field, len(m.Fieldpbs))
+	cols := make([]string, len(m.Fieldpbs))
+	for i, fpb := range m.Fieldpbs {
+		f := &Field{FieldPb: *fpb}
+		if f.Context, err = contextFromJson(f.ContextJson); err != nil {
+			return err
+		}
+		fields[i] = f
+		cols[i] = f.Name
+	}
+	m.mu.Lock()
+	m.Fields = make([]*Field, 0, len(fields))
+	m.FieldMap = make(map[string]*Field, len(fields))
+	m.Context = ctx
+	m.mu.Unlock()
+	m.AddFields(fields...)
+	m.SetColumns(cols)
+	return nil
+}
+
+func (m *Table) toDoc() *tableDoc {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	doc := &tableDoc{
+		Name:        m.NameOriginal,
+		Parent:      m.Parent,
+		Charset:     m.Charset,
+		Partition:   m.Partition,
+		PartitionCt: m.PartitionCt,
+		Indexes:     m.Indexes,
+		Context:     m.Context,
+		Fields:      make([]*fieldDoc, len(m.Fields)),
+	}
+	if doc.Name == "" {
+		doc.Name = m.Name
+	}
+	for i, f := range m.Fields {
+		doc.Fields[i] = f.toDoc()
+	}
+	return doc
+}
+
+func (m *Table) fromDoc(doc *tableDoc) error {
+	tpb := TablePb{
+		Name:         strings.ToLower(doc.Name),
+		NameOriginal: doc.Name,
+		Parent:       doc.Parent,
+		Charset:      doc.Charset,
+		Partition:    doc.Partition,
+		PartitionCt:  doc.PartitionCt,
+		Indexes:      doc.Indexes,
+	}
+	ctx, err := contextToJsonStrict(doc.Context)
+	if err != nil {
+		return err
+	}
+	tpb.ContextJson = ctx
+	for _, fd := range doc.Fields {
+		fpb, err := fd.toPb()
+		if err != nil {
+			return err
+		}
+		tpb.Fieldpbs = append(tpb.Fieldpbs, fpb)
+	}
+	m.TablePb = tpb
+	return m.initFromPb()
+}
+
+// Marshal the field as protobuf FieldPb.
+func (m *Field) Marshal() ([]byte, error) {
+	return proto.Marshal(m.toPb())
+}
+
+// Unmarshal protobuf FieldPb bytes into this field.
+func (m *Field) Unmarshal(data []byte) error {
+	fpb := FieldPb{}
+	if err := proto.Unmarshal(data, &fpb); err != nil {
+		return err
+	}
+	ctx, err := contextFromJson(fpb.ContextJson)
+	if err != nil {
+		return err
+	}
+	m.FieldPb = fpb
+	m.Context = ctx
+	return nil
+}
+
+// MarshalJSON the field as json.
+func (m *Field) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.toDoc())
+}
+
+// UnmarshalJSON json field document into this field.
+func (m *Field) UnmarshalJSON(data []byte) error {
+	doc := &fieldDoc{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return err
+	}
+	return m.fromDoc(doc)
+}
+
+// MarshalYAML the field as yaml.
+func (m *Field) MarshalYAML() (interface{}, error) {
+	return m.toDoc(), nil
+}
+
+// UnmarshalYAML yaml field document into this field.
+func (m *Field) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc := &fieldDoc{}
+	if err := unmarshal(doc); err != nil {
+		return err
+	}
+	return m.fromDoc(doc)
+}
+
+// toPb a copy of the embedded FieldPb with the Context.
+func (m *Field) toPb() *FieldPb {
+	fpb := proto.Clone(&m.FieldPb).(*FieldPb)
+	fpb.ContextJson = contextToJson(m.Context)
+	return fpb
+}
+
+func (m *Field) toDoc() *fieldDoc {
+	doc := &fieldDoc{
+		Name:        m.Name,
+		Description: m.Description,
+		Key:         m.Key,
+		Extra:       m.Extra,
+		Data:        m.Data,
+		Length:      m.Length,
+		Type:        value.ValueType(m.Type).String(),
+		DefLength:   m.DefLength,
+		Indexed:     m.Indexed,
+		NoNulls:     m.NoNulls,
+		Collation:   m.Collation,
+		Roles:       m.Roles,
+		Indexes:     m.FieldPb.Indexes,
+		Context:     m.Context,
+	}
+	if m.NativeType != m.Type {
+		doc.NativeType = m.NativeType
+	}
+	if len(m.DefVal) > 0 {
+		var dv interface{}
+		if err := json.Unmarshal(m.DefVal, &dv); err == nil {
+			doc.DefVal = dv
+		} else {
+			doc.DefVal = string(m.DefVal)
+		}
+	}
+	return doc
+}
+
+func (m *Field) fromDoc(doc *fieldDoc) error {
+	fpb, err := doc.toPb()
+	if err != nil {
+		return err
+	}
+	ctx, err := contextFromJson(fpb.ContextJson)
+	if err != nil {
+		return err
+	}
+	m.FieldPb = *fpb
+	m.Context = ctx
+	return nil
+}
+
+func (m *fieldDoc) toPb() (*FieldPb, error) {
+	vt := value.ValueFromString(m.Type)
+	if vt == value.UnknownType && m.Type != "unknown" && m.Type != "" {
+		return nil, fmt.Errorf("QLBridge.schema: unrecognized type %q for field %q", m.Type, m.Name)
+	}
+	fpb := &FieldPb{
+		Name:        m.Name,
+		Description: m.Description,
+		Key:         m.Key,
+		Extra:       m.Extra,
+		Data:        m.Data,
+		Length:      m.Length,
+		Type:        uint32(vt),
+		NativeType:  m.NativeType,
+		DefLength:   m.DefLength,
+		Indexed:     m.Indexed,
+		NoNulls:     m.NoNulls,
+		Collation:   m.Collation,
+		Roles:       m.Roles,
+		Indexes:     m.Indexes,
+	}
+	if fpb.NativeType == 0 {
+		fpb.NativeType = fpb.Type
+	}
+	if m.DefVal != nil {
+		dv, err := json.Marshal(jsonValue(m.DefVal))
+		if err != nil {
+			return nil, err
+		}
+		fpb.DefVal = dv
+	}
+	ctx, err := contextToJsonStrict(m.Context)
+	if err != nil {
+		return nil, err
+	}
+	fpb.ContextJson = ctx
+	return fpb, nil
+}
+
+// MarshalYAML the index as yaml.
+func (m *Index) MarshalYAML() (interface{}, error) {
+	return &indexDoc{
+		Name:          m.Name,
+		Fields:        m.Fields,
+		PrimaryKey:    m.PrimaryKey,
+		HashPartition: m.HashPartition,
+		PartitionSize: m.PartitionSize,
+	}, nil
+}
+
+// UnmarshalYAML yaml index document into this index.
+func (m *Index) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc := &indexDoc{}
+	if err := unmarshal(doc); err != nil {
+		return err
+	}
+	*m = Index{
+		Name:          doc.Name,
+		Fields:        doc.Fields,
+		PrimaryKey:    doc.PrimaryKey,
+		HashPartition: doc.HashPartition,
+		PartitionSize: doc.PartitionSize,
+	}
+	return nil
+}
+
+// contextToJson the json of the context, context is arbitrary source
+// metadata so un-serializeable entries are dropped from the export
+// instead of failing it, the rest are kept.
+func contextToJson(ctx map[string]interface{}) []byte {
+	if len(ctx) == 0 {
+		return nil
+	}
+	entries := make(map[string]json.RawMessage, len(ctx))
+	for k, v := range ctx {
+		jb, err := json.Marshal(jsonValue(v))
+		if err != nil {
+			u.Warnf("dropping context %q from export err=%v", k, err)
+			continue
+		}
+		entries[k] = jb
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	jb, _ := json.Marshal(entries)
+	return jb
+}
+
+func contextToJsonStrict(ctx map[string]interface{}) ([]byte, error) {
+	if len(ctx) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(jsonValue(ctx))
+}
+
+func contextFromJson(jb []byte) (map[string]interface{}, error) {
+	if len(jb) == 0 {
+		return nil, nil
+	}
+	ctx := make(map[string]interface{})
+	if err := json.Unmarshal(jb, &ctx); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// jsonValue convert yaml decoded values (map[interface{}]interface{})
+// into json encodeable values.
+func jsonValue(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vt))
+		for k, mv := range vt {
+			m[fmt.Sprintf("%v", k)] = jsonValue(mv)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vt))
+		for k, mv := range vt {
+			m[k] = jsonValue(mv)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(vt))
+		for i, lv := range vt {
+			l[i] = jsonValue(lv)
+		}
+		return l
+	}
+	return v
+}
@@ -0,0 +1,183 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+func marshalTestSchema() *schema.Schema {
+	tbl := schema.NewTable("Users")
+	tbl.AddContext("index", "users_v2")
+	tbl.AddContext("shards", map[string]interface{}{"count": float64(3)})
+	tbl.Indexes = []*schema.Index{{Name: "pk", Fields: []string{"user_id"}, PrimaryKey: true}}
+	tbl.AddField(schema.NewField("user_id", value.StringType, 64, schema.NoNulls, nil, "PRI", "", "id of user"))
+	email := schema.NewFieldBase("email", value.StringType, 255, "email of user")
+	email.AddContext("analyzer", "keyword")
+	tbl.AddField(email)
+	tbl.AddField(schema.NewField("visits", value.IntType, 8, schema.AllowNulls, 0, "", "", ""))
+	tbl.SetColumnsFromFields()
+
+	// build the schema from protobuf table/schema messages
+	by, err := tbl.Marshal()
+	if err != nil {
+		panic(err.Error())
+	}
+	tpb := &schema.TablePb{}
+	if err = proto.Unmarshal(by, tpb); err != nil {
+		panic(err.Error())
+	}
+	by, _ = proto.Marshal(&schema.SchemaPb{Name: "marshal_test", Tables: []*schema.TablePb{tpb}})
+	s := schema.NewSchema("")
+	if err = s.Unmarshal(by); err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+func assertMarshalSchema(t *testing.T, s *schema.Schema) {
+	assert.Equal(t, "marshal_test", s.Name)
+	assert.Equal(t, []string{"users"}, s.Tables())
+	tbl, err := s.Table("users")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Users", tbl.NameOriginal)
+	assert.Equal(t, s, tbl.Schema)
+	assert.Equal(t, "users_v2", tbl.Context["index"])
+	assert.Equal(t, float64(3), tbl.Context["shards"].(map[string]interface{})["count"])
+	assert.Equal(t, 1, len(tbl.Indexes))
+	assert.Equal(t, true, tbl.Indexes[0].PrimaryKey)
+	assert.Equal(t, []string{"user_id", "email", "visits"}, tbl.Columns())
+	assert.Equal(t, 1, tbl.FieldPositions["email"])
+
+	f := tbl.FieldMap["user_id"]
+	assert.Equal(t, value.StringType, f.ValueType())
+	assert.Equal(t, true, f.NoNulls)
+	assert.Equal(t, "PRI", f.Key)
+	assert.Equal(t, uint32(64), f.Length)
+	assert.Equal(t, "keyword", tbl.FieldMap["email"].Context["analyzer"])
+	assert.Equal(t, "email of user", tbl.FieldMap["email"].Description)
+	assert.Equal(t, value.IntType, tbl.FieldMap["visits"].ValueType())
+	assert.Equal(t, "0", string(tbl.FieldMap["visits"].DefVal))
+}
+
+func TestSchemaMarshal(t *testing.T) {
+
+	orig := marshalTestSchema()
+	assertMarshalSchema(t, orig)
+
+	// protobuf
+	by, err := orig.Marshal()
+	assert.Equal(t, nil, err)
+	s := schema.NewSchema("")
+	err = s.Unmarshal(by)
+	assert.Equal(t, nil, err)
+	assertMarshalSchema(t, s)
+
+	// marshal doesn't modify the table
+	tbl := schema.NewTable("visits")
+	tbl.AddContext("index", "visits_v1")
+	tbl.AddField(schema.NewFieldBase("visit_id", value.StringType, 64, "id of visit"))
+	_, err = tbl.Marshal()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(tbl.Fieldpbs))
+	assert.Equal(t, 0, len(tbl.ContextJson))
+
+	// context that can't be marshalled is dropped, the rest is kept
+	tbl.AddContext("conn", make(chan bool))
+	by, err = tbl.Marshal()
+	assert.Equal(t, nil, err)
+	tbl2 := &schema.Table{}
+	assert.Equal(t, nil, tbl2.Unmarshal(by))
+	assert.Equal(t, "visits_v1", tbl2.Context["index"])
+	_, hasConn := tbl2.Context["conn"]
+	assert.False(t, hasConn)
+
+	// json
+	by, err = json.MarshalIndent(orig, "", "  ")
+	assert.Equal(t, nil, err)
+	s = schema.NewSchema("")
+	err = json.Unmarshal(by, s)
+	assert.Equal(t, nil, err, string(by))
+	assertMarshalSchema(t, s)
+
+	// yaml
+	by, err = yaml.Marshal(orig)
+	assert.Equal(t, nil, err)
+	s = schema.NewSchema("")
+	err = yaml.Unmarshal(by, s)
+	assert.Equal(t, nil, err, string(by))
+	assertMarshalSchema(t, s)
+
+	// exports are stable so they may be diffed
+	by2, err := yaml.Marshal(s)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(by), string(by2))
+
+	yamlSchema := `
+name: marshal_test
+tables:
+- name: Users
+  context:
+    index: users_v2
+    shards:
+      count: 3
+  indexes:
+  - name: pk
+    fields: [user_id]
+    primaryKey: true
+  fields:
+  - name: user_id
+    type: string
+    key: PRI
+    length: 64
+    noNulls: true
+  - name: email
+    description: email of user
+    type: string
+    length: 255
+    context:
+      analyzer: keyword
+  - name: visits
+    type: int
+    defVal: 0
+`
+	s = schema.NewSchema("")
+	err = yaml.Unmarshal([]byte(yamlSchema), s)
+	assert.Equal(t, nil, err)
+	assertMarshalSchema(t, s)
+
+	err = yaml.Unmarshal([]byte("name: bad\ntables:\n- name: t\n  fields:\n  - name: f\n    type: nope\n"), schema.NewSchema(""))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestFieldIndexMarshal(t *testing.T) {
+	f := schema.NewFieldBase("created", value.TimeType, 8, "create date")
+	f.AddContext("format", "2006-01-02")
+
+	by, err := f.Marshal()
+	assert.Equal(t, nil, err)
+	f2 := &schema.Field{}
+	assert.Equal(t, nil, f2.Unmarshal(by))
+	assert.Equal(t, value.TimeType, f2.ValueType())
+	assert.Equal(t, "2006-01-02", f2.Context["format"])
+
+	by, err = json.Marshal(f)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"name":"created","description":"create date","length":8,"type":"time","context":{"format":"2006-01-02"}}`, string(by))
+	f2 = &schema.Field{}
+	assert.Equal(t, nil, json.Unmarshal(by, f2))
+	assert.Equal(t, "create date", f2.Description)
+
+	idx := &schema.Index{Name: "by_created", Fields: []string{"created"}, HashPartition: []string{"created"}, PartitionSize: 4}
+	by, err = yaml.Marshal(idx)
+	assert.Equal(t, nil, err)
+	idx2 := &schema.Index{}
+	assert.Equal(t, nil, yaml.Unmarshal(by, idx2))
+	assert.Equal(t, idx, idx2)
+}
@@ -0,0 +1,341 @@
+// Package migrate is a versioned schema migration engine.  Migrations are
+// either ddl sql scripts or go functions, applied in version order per
+// source, with applied versions tracked in a version table.
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	_ = u.EMPTY
+
+	// ErrDuplicateVersion a migration with this version already registered.
+	ErrDuplicateVersion = fmt.Errorf("QLBridge.migrate: duplicate migration version")
+	// ErrNoDown a migration has no down step so can not be reverted.
+	ErrNoDown = fmt.Errorf("QLBridge.migrate: migration has no down step")
+	// ErrUnknownVersion the target version is not a registered migration.
+	ErrUnknownVersion = fmt.Errorf("QLBridge.migrate: unknown migration version")
+)
+
+type (
+	// Func is a go function migration step.
+	Func func(s *schema.Schema) error
+
+	// StatementRunner executes a single ddl statement against a schema.
+	StatementRunner func(s *schema.Schema, sql string) error
+
+	// Migration is a single versioned schema change.  Up/Down are ddl
+	// scripts which may contain multiple semi-colon separated statements,
+	// UpFunc/DownFunc are go functions run after the script (if any).
+	Migration struct {
+		Version  uint64
+		Name     string
+		Up       string
+		Down     string
+		UpFunc   Func
+		DownFunc Func
+	}
+
+	// Migrator applies registered migrations to a source schema.
+	Migrator struct {
+		Schema *schema.Schema
+		Store  VersionStore
+		// Writer is the dialect writer used for dry-run ddl output,
+		// defaults to the first registered datasource.DialectWriters.
+		Writer schema.DialectWriter
+		// Runner executes statements, defaults to the qlbridge exec engine.
+		Runner     StatementRunner
+		mu         sync.Mutex
+		migrations []*Migration
+	}
+)
+
+// New create a migrator for schema, tracking versions in store.
+func New(s *schema.Schema, store VersionStore) *Migrator {
+	m := &Migrator{
+		Schema: s,
+		Store:  store,
+		Runner: ExecStatement,
+	}
+	if len(datasource.DialectWriters) > 0 {
+		m.Writer = datasource.DialectWriters[0]
+	}
+	return m
+}
+
+// Add register migrations.
+func (m *Migrator) Add(migrations ...*Migration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mg := range migrations {
+		for _, existing := range m.migrations {
+			if existing.Version == mg.Version {
+				return ErrDuplicateVersion
+			}
+		}
+		m.migrations = append(m.migrations, mg)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return nil
+}
+
+// Migrations list of registered migrations in version order.
+func (m *Migrator) Migrations() []*Migration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Migration(nil), m.migrations...)
+}
+
+// Version the highest applied version for this schema, 0 if none.
+func (m *Migrator) Version() (uint64, error) {
+	applied, err := m.Store.Applied(m.Schema.Name)
+	if err != nil {
+		return 0, err
+	}
+	v := uint64(0)
+	for _, av := range applied {
+		if av > v {
+			v = av
+		}
+	}
+	return v, nil
+}
+
+// Pending migrations that will be applied by Up(target), a target
+// of 0 means latest.
+func (m *Migrator) Pending(target uint64) ([]*Migration, error) {
+	applied, err := m.appliedSet()
+	if err != nil {
+		return nil, err
+	}
+	if target > 0 && m.find(target) == nil {
+		return nil, ErrUnknownVersion
+	}
+	pending := make([]*Migration, 0)
+	for _, mg := range m.Migrations() {
+		if target > 0 && mg.Version > target {
+			break
+		}
+		if !applied[mg.Version] {
+			pending = append(pending, mg)
+		}
+	}
+	return pending, nil
+}
+
+// Reverts migrations that will be reverted by Down(target), newest first.
+// All applied versions greater than target are reverted.
+func (m *Migrator) Reverts(target uint64) ([]*Migration, error) {
+	applied, err := m.appliedSet()
+	if err != nil {
+		return nil, err
+	}
+	migrations := m.Migrations()
+	reverts := make([]*Migration, 0)
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mg := migrations[i]
+		if mg.Version <= target {
+			break
+		}
+		if applied[mg.Version] {
+			reverts = append(reverts, mg)
+		}
+	}
+	return reverts, nil
+}
+
+// Up apply pending migrations up to and including target version, 0 = latest.
+func (m *Migrator) Up(target uint64) ([]*Migration, error) {
+	pending, err := m.Pending(target)
+	if err != nil {
+		return nil, err
+	}
+	done := make([]*Migration, 0, len(pending))
+	for _, mg := range pending {
+		if err := m.run(mg.Up, mg.UpFunc); err != nil {
+			return done, fmt.Errorf("migration %d %q up failed: %v", mg.Version, mg.Name, err)
+		}
+		if err := m.Store.Record(m.Schema.Name, mg); err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Down revert applied migrations with version greater than target.
+func (m *Migrator) Down(target uint64) ([]*Migration, error) {
+	reverts, err := m.Reverts(target)
+	if err != nil {
+		return nil, err
+	}
+	for _, mg := range reverts {
+		if mg.Down == "" && mg.DownFunc == nil {
+			return nil, ErrNoDown
+		}
+	}
+	done := make([]*Migration, 0, len(reverts))
+	for _, mg := range reverts {
+		if err := m.run(mg.Down, mg.DownFunc); err != nil {
+			return done, fmt.Errorf("migration %d %q down failed: %v", mg.Version, mg.Name, err)
+		}
+		if err := m.Store.Remove(m.Schema.Name, mg.Version); err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// DryRun write the ddl that would be sent for Up(target) (or Down(target)
+// if up is false) to w without applying anything.  CREATE TABLE statements
+// are written through the DialectWriter.
+func (m *Migrator) DryRun(w io.Writer, up bool, target uint64) error {
+	var migrations []*Migration
+	var err error
+	if up {
+		migrations, err = m.Pending(target)
+	} else {
+		migrations, err = m.Reverts(target)
+	}
+	if err != nil {
+		return err
+	}
+	for _, mg := range migrations {
+		script, fn := mg.Up, mg.UpFunc
+		if !up {
+			script, fn = mg.Down, mg.DownFunc
+		}
+		fmt.Fprintf(w, "-- migration %d %s\n", mg.Version, mg.Name)
+		if script != "" {
+			raws, stmts, err := rel.SplitSqlStatements(script)
+			if err != nil {
+				return err
+			}
+			for i, stmt := range stmts {
+				fmt.Fprintf(w, "%s\n", m.dialectStatement(raws[i], stmt))
+			}
+		}
+		if fn != nil {
+			fmt.Fprintf(w, "-- go func\n")
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) dialectStatement(raw string, stmt rel.SqlStatement) string {
+	cs, ok := stmt.(*rel.SqlCreate)
+	if !ok || m.Writer == nil || cs.Tok.T != lex.TokenTable {
+		return raw + ";"
+	}
+	return m.Writer.Table(TableFromDdl(cs.Identity, cs.Cols))
+}
+
+func (m *Migrator) run(script string, fn Func) error {
+	if script != "" {
+		raws, _, err := rel.SplitSqlStatements(script)
+		if err != nil {
+			return err
+		}
+		for _, raw := range raws {
+			if err := m.Runner(m.Schema, raw); err != nil {
+				return err
+			}
+		}
+	}
+	if fn != nil {
+		return fn(m.Schema)
+	}
+	return nil
+}
+
+func (m *Migrator) find(version uint64) *Migration {
+	for _, mg := range m.Migrations() {
+		if mg.Version == version {
+			return mg
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) appliedSet() (map[uint64]bool, error) {
+	applied, err := m.Store.Applied(m.Schema.Name)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[uint64]bool, len(applied))
+	for _, v := range applied {
+		set[v] = true
+	}
+	return set, nil
+}
+
+// ExecStatement the default StatementRunner, runs sql through the
+// qlbridge exec engine against schema.
+func ExecStatement(s *schema.Schema, sql string) error {
+	ctx := plan.NewContext(sql)
+	ctx.Schema = s
+	ctx.Session = datasource.NewMySqlSessionVars()
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		return err
+	}
+	defer job.Close()
+	job.RootTask.Add(exec.NewResultExecWriter(ctx))
+	if err = job.Setup(); err != nil {
+		return err
+	}
+	return job.Run()
+}
+
+// TableFromDdl create a schema Table from CREATE TABLE column definitions.
+func TableFromDdl(name string, cols []*rel.DdlColumn) *schema.Table {
+	tbl := schema.NewTable(name)
+	for _, col := range cols {
+		if col.Kw != lex.TokenIdentity || col.Name == "" {
+			continue
+		}
+		vt := DdlValueType(col.DataType)
+		f := schema.NewFieldBase(col.Name, vt, col.DataTypeSize, col.Comment)
+		f.NoNulls = !col.Null
+		tbl.AddField(f)
+	}
+	tbl.SetColumnsFromFields()
+	return tbl
+}
+
+// DdlValueType convert a sql ddl data type (varchar, bigint etc) to value type.
+func DdlValueType(dataType string) value.ValueType {
+	switch strings.ToLower(dataType) {
+	case "int", "integer", "bigint", "smallint", "tinyint", "mediumint", "long":
+		return value.IntType
+	case "float", "double", "real", "decimal", "numeric":
+		return value.NumberType
+	case "bool", "boolean":
+		return value.BoolType
+	case "datetime", "timestamp", "date", "time":
+		return value.TimeType
+	case "json":
+		return value.JsonType
+	case "blob", "binary", "varbinary":
+		return value.ByteSliceType
+	}
+	return value.StringType
+}
@@ -0,0 +1,143 @@
+package migrate_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/schema/migrate"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+func TestMigrateUpDown(t *testing.T) {
+
+	store, err := migrate.NewMemStore()
+	assert.Equal(t, nil, err)
+
+	s := schema.NewSchema("migrate_test")
+	m := migrate.New(s, store)
+
+	ran := make([]string, 0)
+	m.Runner = func(s *schema.Schema, sql string) error {
+		ran = append(ran, sql)
+		return nil
+	}
+	goFuncRan := 0
+	err = m.Add(
+		&migrate.Migration{
+			Version: 2,
+			Name:    "go func",
+			UpFunc: func(s *schema.Schema) error {
+				goFuncRan++
+				return nil
+			},
+			DownFunc: func(s *schema.Schema) error {
+				goFuncRan--
+				return nil
+			},
+		},
+		&migrate.Migration{
+			Version: 1,
+			Name:    "create users",
+			Up: `CREATE TABLE users (
+				user_id varchar(64) NOT NULL,
+				email varchar(255)
+			) ENGINE=InnoDB;
+			CREATE TABLE orders (order_id bigint, user_id varchar(64)) ENGINE=InnoDB;`,
+			Down: `DROP TABLE orders; DROP TABLE users;`,
+		},
+	)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, migrate.ErrDuplicateVersion, m.Add(&migrate.Migration{Version: 1}))
+
+	// dry run shows ddl through dialect writer, without applying
+	var buf bytes.Buffer
+	err = m.DryRun(&buf, true, 0)
+	assert.Equal(t, nil, err)
+	out := buf.String()
+	assert.True(t, strings.Contains(out, "CREATE TABLE `users`"), out)
+	assert.True(t, strings.Contains(out, "`user_id` varchar(64)"), out)
+	assert.True(t, strings.Contains(out, "-- migration 2 go func"), out)
+	assert.Equal(t, 0, len(ran))
+	v, err := m.Version()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(0), v)
+
+	done, err := m.Up(1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(done))
+	assert.Equal(t, 2, len(ran))
+	assert.True(t, strings.HasPrefix(ran[1], "CREATE TABLE orders"), ran[1])
+	assert.Equal(t, 0, goFuncRan)
+
+	done, err = m.Up(0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(done))
+	assert.Equal(t, 1, goFuncRan)
+	v, _ = m.Version()
+	assert.Equal(t, uint64(2), v)
+
+	// nothing left to apply
+	done, err = m.Up(0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(done))
+
+	buf.Reset()
+	err = m.DryRun(&buf, false, 0)
+	assert.Equal(t, nil, err)
+	assert.True(t, strings.Contains(buf.String(), "DROP TABLE orders;"), buf.String())
+
+	done, err = m.Down(0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(done))
+	assert.Equal(t, uint64(2), done[0].Version)
+	assert.Equal(t, 0, goFuncRan)
+	assert.Equal(t, "DROP TABLE users", ran[len(ran)-1])
+	v, _ = m.Version()
+	assert.Equal(t, uint64(0), v)
+
+	_, err = m.Up(7)
+	assert.Equal(t, migrate.ErrUnknownVersion, err)
+}
+
+func TestMigrateNoDown(t *testing.T) {
+	store, err := migrate.NewMemStore()
+	assert.Equal(t, nil, err)
+	m := migrate.New(schema.NewSchema("migrate_nodown"), store)
+	m.Runner = func(s *schema.Schema, sql string) error { return nil }
+	m.Add(&migrate.Migration{Version: 1, Up: "DROP TABLE x"})
+	_, err = m.Up(0)
+	assert.Equal(t, nil, err)
+	_, err = m.Down(0)
+	assert.Equal(t, migrate.ErrNoDown, err)
+}
+
+func TestTableStoreVersions(t *testing.T) {
+	// version tables written by other tools hold other numeric types
+	db, err := memdb.NewMemDbData(migrate.VersionTableName, [][]driver.Value{
+		{"app/3", "app", "3", "third", nil},
+		{"app/1", "app", 1, "first", nil},
+		{"app/2", "app", float64(2), "second", nil},
+		{"other/9", "other", int64(9), "other", nil},
+	}, migrate.VersionTableCols)
+	assert.Equal(t, nil, err)
+	store := migrate.NewTableStore(db, migrate.VersionTableName)
+	versions, err := store.Applied("app")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []uint64{1, 2, 3}, versions)
+
+	conn, _ := db.Open(migrate.VersionTableName)
+	_, err = conn.(schema.ConnUpsert).Put(nil, nil, []driver.Value{"app/x", "app", "x", "bad", nil})
+	assert.Equal(t, nil, err)
+	_, err = store.Applied("app")
+	assert.NotEqual(t, nil, err)
+}
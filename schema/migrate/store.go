@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	// VersionTableName default name of the migration version table.
+	VersionTableName = "schema_migrations"
+)
+
+var (
+	// VersionTableCols columns of the version table.
+	VersionTableCols = []string{"id", "source", "version", "name", "applied"}
+
+	// Ensure we implement VersionStore
+	_ VersionStore = (*TableStore)(nil)
+)
+
+// VersionStore persists the applied migration versions per source.
+type VersionStore interface {
+	// Applied list of applied versions for source.
+	Applied(source string) ([]uint64, error)
+	// Record migration as applied for source.
+	Record(source string, mg *Migration) error
+	// Remove version from applied for source.
+	Remove(source string, version uint64) error
+}
+
+// TableStore is a VersionStore that tracks versions as rows in a table of
+// a schema.Source which supports scanning, upserts and deletes.
+type TableStore struct {
+	mu    sync.Mutex
+	src   schema.Source
+	table string
+}
+
+// NewTableStore create a version store using table in source.
+func NewTableStore(src schema.Source, table string) *TableStore {
+	return &TableStore{src: src, table: table}
+}
+
+// NewMemStore create an in-memory (memdb) version table store.  The
+// returned store Source() may be registered as a schema to query it.
+func NewMemStore() (*TableStore, error) {
+	db, err := memdb.NewMemDbData(VersionTableName, nil, VersionTableCols)
+	if err != nil {
+		return nil, err
+	}
+	return NewTableStore(db, VersionTableName), nil
+}
+
+// Source the underlying source holding the version table.
+func (m *TableStore) Source() schema.Source { return m.src }
+
+func versionID(source string, version uint64) string {
+	return fmt.Sprintf("%s/%d", source, version)
+}
+
+// Applied list of applied versions for source, ascending.
+func (m *TableStore) Applied(source string) ([]uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conn, err := m.src.Open(m.table)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	scanner, ok := conn.(schema.ConnScanner)
+	if !ok {
+		return nil, fmt.Errorf("version table %q must support scanning", m.table)
+	}
+	versions := make([]uint64, 0)
+	for {
+		msg := scanner.Next()
+		if msg == nil {
+			break
+		}
+		sdm, ok := msg.(*datasource.SqlDriverMessageMap)
+		if !ok {
+			continue
+		}
+		row := sdm.Values()
+		if len(row) != len(VersionTableCols) {
+			continue
+		}
+		if src, _ := row[1].(string); src != source {
+			continue
+		}
+		// sources may hand back the version as any numeric type, or string
+		v, ok := value.ValueToInt64(value.NewValue(row[2]))
+		if !ok || v < 0 {
+			return nil, fmt.Errorf("invalid version %v in version table %q", row[2], m.table)
+		}
+		versions = append(versions, uint64(v))
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// Record migration as applied for source.
+func (m *TableStore) Record(source string, mg *Migration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conn, err := m.src.Open(m.table)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	upsert, ok := conn.(schema.ConnUpsert)
+	if !ok {
+		return fmt.Errorf("version table %q must support upsert", m.table)
+	}
+	row := []driver.Value{versionID(source, mg.Version), source, int64(mg.Version), mg.Name, time.Now()}
+	_, err = upsert.Put(nil, nil, row)
+	return err
+}
+
+// Remove version from applied for source.
+func (m *TableStore) Remove(source string, version uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conn, err := m.src.Open(m.table)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deleter, ok := conn.(schema.ConnDeletion)
+	if !ok {
+		return fmt.Errorf("version table %q must support delete", m.table)
+	}
+	_, err = deleter.Delete(versionID(source, version))
+	return err
+}
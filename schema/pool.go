@@ -0,0 +1,272 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/araddon/qlbridge/metrics"
+)
+
+var (
+	// ErrPoolClosed the connection pool has been closed.
+	ErrPoolClosed = fmt.Errorf("qlbridge: connection pool closed")
+
+	// errNotResetter the conn can't clear its query state to be re-used.
+	errNotResetter = fmt.Errorf("qlbridge: conn is not a ConnResetter")
+)
+
+type (
+	// ConfigPool connection pool settings of a ConfigSource, a source
+	// configured with a pool re-uses connections across queries instead of
+	// opening one per query.
+	//
+	//     {"name":"users", "type":"mysql", "pool":{"max_open":20, "max_idle":5, "max_lifetime":"10m"}}
+	//
+	ConfigPool struct {
+		MaxOpen     int    `json:"max_open"`     // max open connections, idle and in use, 0 unlimited
+		MaxIdle     int    `json:"max_idle"`     // max idle connections kept, 0 defaults to 2
+		MaxLifetime string `json:"max_lifetime"` // max age of a connection ie "10m", empty forever
+	}
+
+	// ConnPinger is an optional interface for a Conn that can validate it
+	// is still healthy, pooled connections are pinged on checkout and
+	// discarded if it fails.
+	ConnPinger interface {
+		Ping() error
+	}
+
+	// ConnResetter is an optional interface for a Conn that may be re-used
+	// by another query.  Reset clears its per-query state, ie scan position,
+	// read snapshot, index hints, join key filter.  Only conns implementing
+	// it are kept by a ConnPool, others are closed on Release.
+	ConnResetter interface {
+		Reset() error
+	}
+
+	// ConnPool a pool of connections to the tables of a Source.  Connections
+	// are per table, so idle connections are kept per table, while MaxOpen
+	// limits connections to all tables.  Pooled connections must be
+	// comparable (pointers), implement ConnResetter, and are returned to the
+	// pool with Release instead of Close.
+	ConnPool struct {
+		name     string
+		src      Source
+		maxOpen  int
+		maxIdle  int
+		lifetime time.Duration
+
+		mu      sync.Mutex
+		cond    *sync.Cond
+		closed  bool
+		open    int
+		idleCt  int
+		idle    map[string][]*pooledConn
+		inUse   map[Conn]*pooledConn
+		waits   int64
+		waitDur time.Duration
+	}
+	pooledConn struct {
+		conn    Conn
+		table   string
+		created time.Time
+	}
+)
+
+// NewConnPool create a pool of connections to src, name is the name the
+// pool reports its stats to metrics with.
+func NewConnPool(name string, src Source, conf *ConfigPool) (*ConnPool, error) {
+	m := &ConnPool{
+		name:    name,
+		src:     src,
+		maxIdle: 2,
+		idle:    make(map[string][]*pooledConn),
+		inUse:   make(map[Conn]*pooledConn),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	if conf != nil {
+		m.maxOpen = conf.MaxOpen
+		if conf.MaxIdle > 0 {
+			m.maxIdle = conf.MaxIdle
+		}
+		if conf.MaxLifetime != "" {
+			dur, err := time.ParseDuration(conf.MaxLifetime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pool max_lifetime %q: %v", conf.MaxLifetime, err)
+			}
+			m.lifetime = dur
+		}
+	}
+	if m.maxOpen > 0 && m.maxIdle > m.maxOpen {
+		m.maxIdle = m.maxOpen
+	}
+	return m, nil
+}
+
+// Get a connection to table, an idle one if there is a healthy one, else
+// a new one.  If MaxOpen connections are open waits for one to be released.
+func (m *ConnPool) Get(table string) (Conn, error) {
+	table = strings.ToLower(table)
+	for {
+		pc, err := m.checkout(table)
+		if err != nil {
+			return nil, err
+		}
+		if pc == nil {
+			// a slot was reserved for a new connection
+			conn, err := m.src.Open(table)
+			if err == nil && conn == nil {
+				err = fmt.Errorf("Could not establish a connection for %v", table)
+			}
+			if err != nil {
+				m.discard()
+				return nil, err
+			}
+			pc = &pooledConn{conn: conn, table: table, created: time.Now()}
+		} else if !m.healthy(pc) {
+			pc.conn.Close()
+			m.discard()
+			continue
+		}
+		m.mu.Lock()
+		m.inUse[pc.conn] = pc
+		m.mu.Unlock()
+		m.report()
+		return pc.conn, nil
+	}
+}
+
+// checkout an idle connection of table, or nil if a slot for a new one
+// was reserved.
+func (m *ConnPool) checkout(table string) (*pooledConn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		if m.closed {
+			return nil, ErrPoolClosed
+		}
+		if idle := m.idle[table]; len(idle) > 0 {
+			pc := idle[len(idle)-1]
+			m.idle[table] = idle[:len(idle)-1]
+			m.idleCt--
+			return pc, nil
+		}
+		if m.maxOpen <= 0 || m.open < m.maxOpen {
+			m.open++
+			return nil, nil
+		}
+		// make room by closing an idle connection of another table
+		if m.idleCt > 0 {
+			for t, idle := range m.idle {
+				if len(idle) > 0 {
+					idle[0].conn.Close()
+					m.idle[t] = idle[1:]
+					m.idleCt--
+					return nil, nil
+				}
+			}
+		}
+		m.waits++
+		start := time.Now()
+		m.cond.Wait()
+		m.waitDur += time.Since(start)
+	}
+}
+
+// discard the slot of a connection that was closed or failed to open.
+func (m *ConnPool) discard() {
+	m.mu.Lock()
+	m.open--
+	m.cond.Signal()
+	m.mu.Unlock()
+	m.report()
+}
+
+func (m *ConnPool) healthy(pc *pooledConn) bool {
+	if m.expired(pc) {
+		return false
+	}
+	if p, ok := pc.conn.(ConnPinger); ok {
+		return p.Ping() == nil
+	}
+	return true
+}
+
+func (m *ConnPool) expired(pc *pooledConn) bool {
+	return m.lifetime > 0 && time.Since(pc.created) > m.lifetime
+}
+
+// Release a connection from Get back to the pool, err is the error of
+// its use if any, in which case it is closed instead of kept.  Conns are
+// Reset before they are kept, those that are not ConnResetter, or fail
+// to reset, are closed, as are conns not from this pool.
+func (m *ConnPool) Release(conn Conn, err error) error {
+	if err == nil {
+		if r, ok := conn.(ConnResetter); ok {
+			err = r.Reset()
+		} else {
+			err = errNotResetter
+		}
+	}
+	m.mu.Lock()
+	pc, ok := m.inUse[conn]
+	if !ok {
+		m.mu.Unlock()
+		return conn.Close()
+	}
+	delete(m.inUse, conn)
+	if err != nil || m.closed || m.idleCt >= m.maxIdle || m.expired(pc) {
+		m.open--
+		m.cond.Signal()
+		m.mu.Unlock()
+		m.report()
+		return conn.Close()
+	}
+	m.idle[pc.table] = append(m.idle[pc.table], pc)
+	m.idleCt++
+	m.cond.Signal()
+	m.mu.Unlock()
+	m.report()
+	return nil
+}
+
+// Stats of the pool.
+func (m *ConnPool) Stats() metrics.PoolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return metrics.PoolStats{
+		Open:         m.open,
+		Idle:         m.idleCt,
+		Waits:        m.waits,
+		WaitDuration: m.waitDur,
+	}
+}
+
+func (m *ConnPool) report() {
+	if metrics.Enabled() {
+		metrics.Get().PoolStats(m.name, m.Stats())
+	}
+}
+
+// Close the pool, idle connections are closed, connections in use are
+// closed when released.
+func (m *ConnPool) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	var firstErr error
+	for t, idle := range m.idle {
+		for _, pc := range idle {
+			if err := pc.conn.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			m.open--
+		}
+		delete(m.idle, t)
+	}
+	m.idleCt = 0
+	m.cond.Broadcast()
+	m.mu.Unlock()
+	m.report()
+	return firstErr
+}
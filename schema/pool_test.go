@@ -0,0 +1,175 @@
+package schema_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+type poolSource struct {
+	mu    sync.Mutex
+	opens int
+	fail  error
+	plain bool // open conns that can't be reset
+}
+
+func (m *poolSource) Init()                                     {}
+func (m *poolSource) Setup(*schema.Schema) error                { return nil }
+func (m *poolSource) Close() error                              { return nil }
+func (m *poolSource) Tables() []string                          { return []string{"t1", "t2"} }
+func (m *poolSource) Table(table string) (*schema.Table, error) { return nil, schema.ErrNotFound }
+func (m *poolSource) Open(table string) (schema.Conn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail != nil {
+		return nil, m.fail
+	}
+	m.opens++
+	if m.plain {
+		return &plainConn{}, nil
+	}
+	return &poolConn{table: table, id: m.opens}, nil
+}
+
+type poolConn struct {
+	table    string
+	id       int
+	closed   bool
+	resets   int
+	pingErr  error
+	resetErr error
+}
+
+func (m *poolConn) Close() error { m.closed = true; return nil }
+func (m *poolConn) Ping() error  { return m.pingErr }
+func (m *poolConn) Reset() error { m.resets++; return m.resetErr }
+
+type plainConn struct {
+	closed bool
+}
+
+func (m *plainConn) Close() error { m.closed = true; return nil }
+
+func TestConnPool(t *testing.T) {
+	src := &poolSource{}
+	pool, err := schema.NewConnPool("test", src, &schema.ConfigPool{MaxOpen: 2, MaxIdle: 1})
+	assert.Equal(t, nil, err)
+
+	c1, err := pool.Get("T1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "t1", c1.(*poolConn).table)
+	assert.Equal(t, nil, pool.Release(c1, nil))
+	assert.False(t, c1.(*poolConn).closed)
+	assert.Equal(t, 1, pool.Stats().Idle)
+
+	// idle conn is re-used
+	c2, err := pool.Get("t1")
+	assert.Equal(t, nil, err)
+	assert.True(t, c1 == c2)
+	assert.Equal(t, 1, src.opens)
+
+	// over max idle is closed
+	c3, err := pool.Get("t1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, pool.Stats().Open)
+	pool.Release(c2, nil)
+	pool.Release(c3, nil)
+	assert.True(t, c3.(*poolConn).closed)
+	assert.Equal(t, 1, pool.Stats().Open)
+
+	// failed ping is discarded on checkout
+	c2.(*poolConn).pingErr = fmt.Errorf("gone")
+	c4, err := pool.Get("t1")
+	assert.Equal(t, nil, err)
+	assert.True(t, c2.(*poolConn).closed)
+	assert.Equal(t, 3, c4.(*poolConn).id)
+
+	// released with an error is closed
+	pool.Release(c4, fmt.Errorf("scan failed"))
+	assert.True(t, c4.(*poolConn).closed)
+	assert.Equal(t, 0, pool.Stats().Open)
+
+	// at max open, waits for a release
+	c5, _ := pool.Get("t1")
+	c6, _ := pool.Get("t2")
+	got := make(chan schema.Conn)
+	go func() {
+		c, _ := pool.Get("t2")
+		got <- c
+	}()
+	select {
+	case <-got:
+		t.Fatalf("should wait for a conn")
+	case <-time.After(20 * time.Millisecond):
+	}
+	pool.Release(c6, nil)
+	assert.True(t, c6 == <-got)
+	assert.Equal(t, int64(1), pool.Stats().Waits)
+
+	// an idle conn of another table is closed to make room
+	pool.Release(c5, nil)
+	c7, err := pool.Get("t2")
+	assert.Equal(t, nil, err)
+	assert.True(t, c5.(*poolConn).closed)
+	assert.Equal(t, "t2", c7.(*poolConn).table)
+
+	// open errors free the slot
+	pool.Release(c7, nil)
+	src.fail = fmt.Errorf("refused")
+	_, err = pool.Get("t1")
+	assert.Equal(t, src.fail, err)
+	src.fail = nil
+
+	assert.Equal(t, nil, pool.Close())
+	_, err = pool.Get("t1")
+	assert.Equal(t, schema.ErrPoolClosed, err)
+}
+
+func TestConnPoolLifetime(t *testing.T) {
+	_, err := schema.NewConnPool("test", &poolSource{}, &schema.ConfigPool{MaxLifetime: "bad"})
+	assert.NotEqual(t, nil, err)
+
+	pool, err := schema.NewConnPool("test", &poolSource{}, &schema.ConfigPool{MaxLifetime: "10ms"})
+	assert.Equal(t, nil, err)
+	c1, _ := pool.Get("t1")
+	pool.Release(c1, nil)
+	time.Sleep(20 * time.Millisecond)
+	c2, _ := pool.Get("t1")
+	assert.True(t, c1 != c2)
+	assert.True(t, c1.(*poolConn).closed)
+}
+
+func TestConnPoolReset(t *testing.T) {
+	src := &poolSource{}
+	pool, err := schema.NewConnPool("test", src, &schema.ConfigPool{MaxOpen: 2, MaxIdle: 2})
+	assert.Equal(t, nil, err)
+
+	// released conns are reset before they are kept
+	c1, _ := pool.Get("t1")
+	assert.Equal(t, nil, pool.Release(c1, nil))
+	assert.Equal(t, 1, c1.(*poolConn).resets)
+	assert.False(t, c1.(*poolConn).closed)
+	assert.Equal(t, 1, pool.Stats().Idle)
+
+	// failed reset is closed
+	c1, _ = pool.Get("t1")
+	c1.(*poolConn).resetErr = fmt.Errorf("stuck")
+	pool.Release(c1, nil)
+	assert.True(t, c1.(*poolConn).closed)
+	assert.Equal(t, 0, pool.Stats().Idle)
+
+	// conns that can't be reset are never kept
+	src.plain = true
+	c2, err := pool.Get("t2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, pool.Release(c2, nil))
+	assert.True(t, c2.(*plainConn).closed)
+	stats := pool.Stats()
+	assert.Equal(t, 0, stats.Open)
+	assert.Equal(t, 0, stats.Idle)
+}
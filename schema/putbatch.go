@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"database/sql/driver"
+
+	"golang.org/x/net/context"
+)
+
+// PutOutcome of writing one row of a batch.
+type PutOutcome int
+
+const (
+	// PutInserted the row was new.
+	PutInserted PutOutcome = iota
+	// PutUpdated the row replaced an existing row of its key.
+	PutUpdated
+	// PutFailed the row was not written, see PutResult.Err.
+	PutFailed
+)
+
+func (m PutOutcome) String() string {
+	switch m {
+	case PutInserted:
+		return "inserted"
+	case PutUpdated:
+		return "updated"
+	}
+	return "failed"
+}
+
+// PutResult outcome of writing one row of a batch.
+type PutResult struct {
+	Key     Key        // key of the row written, nil if failed
+	Outcome PutOutcome // inserted, updated, failed
+	Err     error      // why the row failed
+}
+
+// PutBatch write rows to conn with an outcome per row, in a single batch
+// if conn is a ConnPutBatch, else a Put per row.  Rows Put are reported
+// inserted, a plain Put doesn't tell an insert from an update.  The error
+// is only for a failure of the whole batch.
+func PutBatch(ctx context.Context, conn ConnUpsert, rows [][]driver.Value) ([]*PutResult, error) {
+	if pb, ok := conn.(ConnPutBatch); ok {
+		return pb.PutBatch(ctx, rows)
+	}
+	results := make([]*PutResult, len(rows))
+	for i, row := range rows {
+		key, err := conn.Put(ctx, nil, row)
+		if err != nil {
+			results[i] = &PutResult{Outcome: PutFailed, Err: err}
+			continue
+		}
+		results[i] = &PutResult{Key: key, Outcome: PutInserted}
+	}
+	return results, nil
+}
@@ -2,11 +2,13 @@ package schema
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
 	u "github.com/araddon/gou"
 	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/sqlerr"
 )
 
 var (
@@ -32,6 +34,7 @@ type (
 		sources     map[string]Source
 		schemas     map[string]*Schema
 		schemaNames []string
+		defSchema   string // schema of sessions that have not chosen one with USE
 		mu          sync.RWMutex
 	}
 )
@@ -49,7 +52,7 @@ func OpenConn(schemaName, table string) (Conn, error) {
 	defer registry.mu.Unlock()
 	schema, ok := registry.schemas[strings.ToLower(schemaName)]
 	if !ok {
-		return nil, fmt.Errorf("unknown schema %q", schemaName)
+		return nil, sqlerr.New(sqlerr.ErBadDB, "unknown schema %q", schemaName)
 	}
 	return schema.OpenConn(table)
 }
@@ -185,6 +188,27 @@ func (m *Registry) SchemaAddFromConfig(conf *ConfigSource) error {
 	s := NewSchema(conf.Name)
 	s.Conf = conf
 	s.DS = source
+	if conf.Pool != nil {
+		if s.Pool, err = NewConnPool(conf.Name, source, conf.Pool); err != nil {
+			return err
+		}
+	}
+	if conf.Retry != nil {
+		if s.Retry, err = NewRetryPolicy(conf.Retry); err != nil {
+			return err
+		}
+	}
+	if s.Limiter, err = NewSourceLimiterConf(conf); err != nil {
+		return err
+	}
+	if conf.SchemaFile != "" {
+		decl, err := LoadSchemaFile(conf.SchemaFile)
+		if err != nil {
+			u.Errorf("could not load schema file for %+v  err=%v", conf, err)
+			return err
+		}
+		s.DeclareTables(decl)
+	}
 	if err := s.DS.Setup(s); err != nil {
 		u.Errorf("Error setuping up %+v  err=%v", conf, err)
 		return err
@@ -218,6 +242,42 @@ func (m *Registry) Schema(schemaName string) (*Schema, bool) {
 	return s, ok
 }
 
+// SetDefaultSchema make schema name the default of sessions, the schema
+// their unqualified table names resolve in when they have not chosen one
+// with USE, or the table isn't in the one they chose.
+func (m *Registry) SetDefaultSchema(name string) {
+	m.mu.Lock()
+	m.defSchema = strings.ToLower(name)
+	m.mu.Unlock()
+}
+
+// DefaultSchema the default schema of sessions, false if none is set or
+// it isn't registered.
+func (m *Registry) DefaultSchema() (*Schema, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.defSchema == "" {
+		return nil, false
+	}
+	s, ok := m.schemas[m.defSchema]
+	return s, ok && s != nil
+}
+
+// SchemasWithTable names of the registered schemas having table, sorted,
+// the candidates of an unqualified table name that didn't resolve.
+func (m *Registry) SchemasWithTable(table string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0)
+	for name, s := range m.schemas {
+		if tbl, err := s.Table(table); err == nil && tbl != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // SchemaAdd Add a new Schema
 func (m *Registry) SchemaAdd(s *Schema) error {
 
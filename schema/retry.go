@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+var (
+	// ErrSourceTimeout an operation on a source took longer than the
+	// Timeout of its RetryPolicy.
+	ErrSourceTimeout = fmt.Errorf("qlbridge: source operation timed out")
+)
+
+type (
+	// ConfigRetry retry and timeout settings of a ConfigSource, durations
+	// are strings ie "100ms".
+	//
+	//     {"name":"users", "type":"mysql", "retry":{"max_attempts":3, "backoff":"100ms", "timeout":"30s"}}
+	//
+	ConfigRetry struct {
+		MaxAttempts int    `json:"max_attempts"` // attempts of each operation including the first
+		Backoff     string `json:"backoff"`      // wait before the first retry, doubled each retry
+		MaxBackoff  string `json:"max_backoff"`  // cap of the wait between retries
+		Timeout     string `json:"timeout"`      // timeout of each attempt, empty none
+		RetryWrites bool   `json:"retry_writes"` // retry timed out writes that aren't idempotent
+	}
+
+	// RetryPolicy how operations (open, scan, write) on a source are
+	// retried and timed out, so one slow or flaky backend doesn't fail or
+	// hang a federated query.  A nil policy runs operations once, without
+	// a timeout.
+	RetryPolicy struct {
+		MaxAttempts int                  // attempts including the first, < 2 no retries
+		Backoff     time.Duration        // wait before the first retry, doubled each retry
+		MaxBackoff  time.Duration        // cap of the wait, 0 uncapped
+		Timeout     time.Duration        // timeout of each attempt, 0 none
+		Retryable   func(err error) bool // which errors are retried, nil DefaultRetryable
+		// RetryWrites retry writes that timed out even if they are not
+		// idempotent (inserts), for sources that de-duplicate them.
+		RetryWrites bool
+	}
+)
+
+// NewRetryPolicy create a policy from its config.
+func NewRetryPolicy(conf *ConfigRetry) (*RetryPolicy, error) {
+	m := &RetryPolicy{MaxAttempts: conf.MaxAttempts, RetryWrites: conf.RetryWrites}
+	for _, d := range []struct {
+		name string
+		val  string
+		dur  *time.Duration
+	}{
+		{"backoff", conf.Backoff, &m.Backoff},
+		{"max_backoff", conf.MaxBackoff, &m.MaxBackoff},
+		{"timeout", conf.Timeout, &m.Timeout},
+	} {
+		if d.val == "" {
+			continue
+		}
+		dur, err := time.ParseDuration(d.val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry %s %q: %v", d.name, d.val, err)
+		}
+		*d.dur = dur
+	}
+	return m, nil
+}
+
+// DefaultRetryable errors are retried unless they are qlbridge errors
+// (sqlerr), which are errors of the statement not the backend.
+func DefaultRetryable(err error) bool {
+	if err == nil || err == ErrPoolClosed {
+		return false
+	}
+	_, isSqlErr := err.(*sqlerr.Error)
+	return !isSqlErr
+}
+
+// IsRetryable should err be retried.
+func (m *RetryPolicy) IsRetryable(err error) bool {
+	if m == nil {
+		return false
+	}
+	if m.Retryable != nil {
+		return m.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+// Attempts of each operation, including the first.
+func (m *RetryPolicy) Attempts() int {
+	if m == nil || m.MaxAttempts < 1 {
+		return 1
+	}
+	return m.MaxAttempts
+}
+
+// Wait before retry n (1 is the first retry).
+func (m *RetryPolicy) Wait(n int) time.Duration {
+	if m == nil || m.Backoff <= 0 {
+		return 0
+	}
+	wait := m.Backoff
+	for i := 1; i < n; i++ {
+		wait *= 2
+		if m.MaxBackoff > 0 && wait >= m.MaxBackoff {
+			return m.MaxBackoff
+		}
+	}
+	if m.MaxBackoff > 0 && wait > m.MaxBackoff {
+		return m.MaxBackoff
+	}
+	return wait
+}
+
+// Do op, retrying it while it fails with retryable errors, each attempt
+// timed out after Timeout.  A timed out attempt is abandoned, not
+// stopped, so op must not share state with later attempts.
+func (m *RetryPolicy) Do(op func() error) error {
+	return m.retry(func() error { return m.call(op) }, m.IsRetryable)
+}
+
+// DoWrite write op as Do, except an attempt that timed out, and so may
+// still complete, is only retried if the write is idempotent (a Put by
+// key, a patch or delete by where) or the policy RetryWrites.  Retrying
+// a timed out insert could write its rows twice.
+func (m *RetryPolicy) DoWrite(idempotent bool, op func() error) error {
+	if m == nil || idempotent || m.RetryWrites {
+		return m.Do(op)
+	}
+	return m.retry(func() error { return m.call(op) }, func(err error) bool {
+		return err != ErrSourceTimeout && m.IsRetryable(err)
+	})
+}
+
+// retry op while it fails with errors retryable says are.
+func (m *RetryPolicy) retry(op func() error, retryable func(err error) bool) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if attempt >= m.Attempts() || !retryable(err) {
+			return err
+		}
+		time.Sleep(m.Wait(attempt))
+	}
+}
+
+func (m *RetryPolicy) call(op func() error) error {
+	if m == nil || m.Timeout <= 0 {
+		return op()
+	}
+	done := make(chan error, 1)
+	go func() { done <- op() }()
+	timer := time.NewTimer(m.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return ErrSourceTimeout
+	}
+}
+
+// Open a conn with open, retried and timed out as Do.  A conn opened by
+// a timed out attempt is passed to discard once it arrives.
+func (m *RetryPolicy) Open(open func() (Conn, error), discard func(Conn)) (Conn, error) {
+	var conn Conn
+	err := m.retry(func() error {
+		type opened struct {
+			conn Conn
+			err  error
+		}
+		if m == nil || m.Timeout <= 0 {
+			c, err := open()
+			conn = c
+			return err
+		}
+		done := make(chan opened, 1)
+		go func() {
+			c, err := open()
+			done <- opened{c, err}
+		}()
+		timer := time.NewTimer(m.Timeout)
+		defer timer.Stop()
+		select {
+		case o := <-done:
+			conn = o.conn
+			return o.err
+		case <-timer.C:
+			go func() {
+				if o := <-done; o.conn != nil {
+					discard(o.conn)
+				}
+			}()
+			return ErrSourceTimeout
+		}
+	}, m.IsRetryable)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,130 @@
+package schema_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	_, err := schema.NewRetryPolicy(&schema.ConfigRetry{Backoff: "soon"})
+	assert.NotEqual(t, nil, err)
+	p, err := schema.NewRetryPolicy(&schema.ConfigRetry{MaxAttempts: 3, Backoff: "1ms", MaxBackoff: "3ms", Timeout: "20ms"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, time.Millisecond, p.Backoff)
+	assert.Equal(t, 20*time.Millisecond, p.Timeout)
+
+	assert.Equal(t, time.Millisecond, p.Wait(1))
+	assert.Equal(t, 2*time.Millisecond, p.Wait(2))
+	assert.Equal(t, 3*time.Millisecond, p.Wait(3))
+	assert.Equal(t, 3*time.Millisecond, p.Wait(10))
+
+	// retried until it succeeds
+	calls := 0
+	err = p.Do(func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("flaky")
+		}
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, calls)
+
+	// attempts used up
+	calls = 0
+	err = p.Do(func() error {
+		calls++
+		return fmt.Errorf("down")
+	})
+	assert.Equal(t, "down", err.Error())
+	assert.Equal(t, 3, calls)
+
+	// statement errors are not retried
+	calls = 0
+	err = p.Do(func() error {
+		calls++
+		return sqlerr.New(sqlerr.ErNoSuchTable, "no table")
+	})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 1, calls)
+
+	// custom classifier
+	p.Retryable = func(err error) bool { return false }
+	calls = 0
+	p.Do(func() error {
+		calls++
+		return fmt.Errorf("down")
+	})
+	assert.Equal(t, 1, calls)
+	p.Retryable = nil
+
+	// each attempt is timed out
+	var started int32
+	err = p.Do(func() error {
+		atomic.AddInt32(&started, 1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	assert.Equal(t, schema.ErrSourceTimeout, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&started))
+
+	// timed out writes that aren't idempotent are not retried, they may
+	// still complete, unless the source opts in
+	slowWrite := func() error {
+		atomic.AddInt32(&started, 1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	atomic.StoreInt32(&started, 0)
+	assert.Equal(t, schema.ErrSourceTimeout, p.DoWrite(false, slowWrite))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&started))
+	atomic.StoreInt32(&started, 0)
+	assert.Equal(t, schema.ErrSourceTimeout, p.DoWrite(true, slowWrite))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&started))
+	calls = 0
+	p.DoWrite(false, func() error {
+		calls++
+		return fmt.Errorf("down")
+	})
+	assert.Equal(t, 3, calls)
+	p.RetryWrites = true
+	atomic.StoreInt32(&started, 0)
+	assert.Equal(t, schema.ErrSourceTimeout, p.DoWrite(false, slowWrite))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&started))
+	p.RetryWrites = false
+
+	// nil policy runs once
+	var np *schema.RetryPolicy
+	calls = 0
+	np.Do(func() error {
+		calls++
+		return fmt.Errorf("down")
+	})
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyOpen(t *testing.T) {
+	p := &schema.RetryPolicy{MaxAttempts: 2, Timeout: 20 * time.Millisecond}
+	discarded := make(chan schema.Conn, 1)
+	slow := &poolConn{id: 1}
+	fast := &poolConn{id: 2}
+	var calls int32
+	conn, err := p.Open(func() (schema.Conn, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+			return slow, nil
+		}
+		return fast, nil
+	}, func(c schema.Conn) { discarded <- c })
+	assert.Equal(t, nil, err)
+	assert.True(t, conn == fast)
+	// the conn of the timed out attempt is discarded when it arrives
+	assert.True(t, <-discarded == slow)
+}
@@ -10,12 +10,14 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	u "github.com/araddon/gou"
 	"github.com/golang/protobuf/proto"
 
 	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/sqlerr"
 	"github.com/araddon/qlbridge/value"
 )
 
@@ -34,7 +36,10 @@ var (
 	ShowVariablesColumns = []string{"Variable_name", "Value"}
 	ShowDatabasesColumns = []string{"Database"}
 	ShowTableColumnMap   = map[string]int{"Table": 0}
-	ShowIndexCols        = []string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Collation", "Cardinality", "Sub_part", "Packed", "Null", "Index_type", "Index_comment"}
+	ShowIndexCols        = []string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Collation", "Cardinality", "Sub_part", "Packed", "Null", "Index_type", "Comment", "Index_comment"}
+	ShowCollationCols    = []string{"Collation", "Charset", "Id", "Default", "Compiled", "Sortlen"}
+	ShowCharsetCols      = []string{"Charset", "Description", "Default collation", "Maxlen"}
+	StatisticsCols       = []string{"TABLE_SCHEMA", "TABLE_NAME", "COLUMN_NAME", "TABLE_ROWS", "CARDINALITY", "NULLS", "MIN_VALUE", "MAX_VALUE", "HISTOGRAM", "LAST_ANALYZED"}
 	DescribeFullHeaders  = NewDescribeFullHeaders()
 	DescribeHeaders      = NewDescribeHeaders()
 
@@ -82,10 +87,17 @@ type (
 	// - Multiple DataSource(s) (each may be discrete source type such as mysql, elasticsearch, etc)
 	// - each schema supplies tables to the virtual table pool
 	// - each table name across schemas must be unique (or aliased)
+	//
+	// Lookups (Tables, Table, Schema, SchemaForTable, OpenConn) read an immutable
+	// snapshot that is atomically swapped after each change, so they never take
+	// the lock.  The lock only serializes writers (Applyer, refresh).
 	Schema struct {
 		Name          string             // Name of schema
 		Conf          *ConfigSource      // source configuration
 		DS            Source             // This datasource Interface
+		Pool          *ConnPool          // connection pool of DS if configured, else nil
+		Retry         *RetryPolicy       // retry and timeout policy of DS operations, nil none
+		Limiter       *SourceLimiter     // limits of concurrent scans and writes of DS, nil none
 		InfoSchema    *Schema            // represent this Schema as sql schema like "information_schema"
 		SchemaRef     *Schema            // IF this is infoschema, the schema it refers to
 		parent        *Schema            // parent schema (optional) if nested.
@@ -95,30 +107,56 @@ type (
 		tableNames    []string           // List Table names, flattened all schemas into one list
 		lastRefreshed time.Time          // Last time we refreshed this schema
 		mu            sync.RWMutex       // lock for schema mods
+		snap          atomic.Value       // *schemaSnapshot of above maps for lock-free reads
+	}
+
+	// schemaSnapshot is a read-only copy of the schema's table/child maps,
+	// never modified after being published.
+	schemaSnapshot struct {
+		schemas      map[string]*Schema
+		tableSchemas map[string]*Schema
+		tableMap     map[string]*Table
+		tableNames   []string
 	}
 
 	// Table represents traditional definition of Database Table.  It belongs to a Schema
 	// and can be used to create a Datasource used to read this table.
+	//
+	// Table is read by concurrent queries (show, describe, planning) while
+	// schema refresh may be adding fields, so all mutation must go through
+	// the methods (AddField, SetColumns, etc) which are locked and copy-on-write,
+	// ie Fields, FieldMap, FieldPositions are replaced not modified in place,
+	// and reads go through FieldList, Field, HasField.
 	Table struct {
 		TablePb
-		Fields         []*Field               // List of Fields, in order
+		// Fields List of Fields, in order.
+		//
+		// Deprecated: read with FieldList, written by AddField under the
+		// table lock, an unlocked read races with schema refresh.
+		Fields []*Field
+		// FieldMap Map of Field-name -> Field.
+		//
+		// Deprecated: read with Field or HasField, written by AddField
+		// under the table lock, an unlocked read races with schema refresh.
+		FieldMap       map[string]*Field
 		Context        map[string]interface{} // During schema discovery of underlying source, may need to store additional info
 		FieldPositions map[string]int         // Maps name of column to ordinal position in array of []driver.Value's
-		FieldMap       map[string]*Field      // Map of Field-name -> Field
 		Schema         *Schema                // The schema this is member of
 		Source         Source                 // The source
+		ColumnGroups   [][]string             // Groups of correlated columns ANALYZE TABLE computes combined statistics of
+		VersionColumn  string                 // optimistic concurrency version column, see ConnCompareAndSwap
 		tblID          uint64                 // internal tableid, hash of table name + schema?
 		cols           []string               // array of column names
 		lastRefreshed  time.Time              // Last time we refreshed this schema
-		rows           [][]driver.Value
+		rows           [][]driver.Value       // memoized describe rows
+		mu             sync.RWMutex           // lock for field/column mutation
 	}
 
 	// Field Describes the column info, name, data type, defaults, index, null
 	// - dialects (mysql, mongo, cassandra) have their own descriptors for these,
 	//   so this is generic meant to be converted to Frontend at runtime
 	Field struct {
-		idx uint64         // Positional index in array of fields
-		row []driver.Value // memoized values of this fields descriptors for describe
+		idx uint64 // Positional index in array of fields
 		FieldPb
 		Context map[string]interface{} // During schema discovery of underlying source, may need to store additional info
 	}
@@ -149,6 +187,9 @@ type (
 		Settings     u.JsonHelper      `json:"settings"`        // Arbitrary settings specific to each source type
 		Partitions   []*TablePartition `json:"partitions"`      // List of partitions per table (optional)
 		PartitionCt  uint32            `json:"partition_count"` // Instead of array of per table partitions, raw partition count
+		SchemaFile   string            `json:"schema_file"`     // Declarative table definitions file (.json, .yaml) used instead of source introspection
+		Pool         *ConfigPool       `json:"pool"`            // Connection pool settings, nil opens a connection per query
+		Retry        *ConfigRetry      `json:"retry"`           // Retry and timeout of source operations, nil none
 	}
 
 	// ConfigNode are Servers/Services, ie a running instance of said Source
@@ -186,9 +227,88 @@ func NewSchemaSource(schemaName string, ds Source) *Schema {
 		tableNames:   make([]string, 0),
 		DS:           ds,
 	}
+	m.publishUnlocked()
+	return m
+}
+
+// NewSchemaTable create an unregistered schema of the single table tbl
+// read from source ds, for sources that only live for one statement, ie
+// the result rows of a procedure CALL.
+func NewSchemaTable(schemaName string, tbl *Table, ds Source) *Schema {
+	m := NewSchemaSource(schemaName, ds)
+	m.addTable(tbl)
+	m.tableSchemas[tbl.Name] = m
+	m.publishUnlocked()
 	return m
 }
 
+// WithTable create an unregistered copy of this schema plus the table tbl
+// read from source ds, for tables that only live for one statement, ie
+// the result of a PIVOT.  A table of the same name in this schema is
+// shadowed, all other tables are shared.
+func (m *Schema) WithTable(tbl *Table, ds Source) *Schema {
+	ss := m.snapshot()
+	c := NewSchemaSource(m.Name, m.DS)
+	c.Conf = m.Conf
+	c.Pool = m.Pool
+	c.Retry = m.Retry
+	c.Limiter = m.Limiter
+	c.InfoSchema = m.InfoSchema
+	c.SchemaRef = m.SchemaRef
+	for k, v := range ss.schemas {
+		c.schemas[k] = v
+	}
+	for k, v := range ss.tableSchemas {
+		c.tableSchemas[k] = v
+	}
+	for k, v := range ss.tableMap {
+		c.tableMap[k] = v
+	}
+	c.tableNames = append(c.tableNames, ss.tableNames...)
+	ts := NewSchemaTable(m.Name, tbl, ds)
+	if _, exists := c.tableMap[tbl.Name]; !exists {
+		c.tableNames = append(c.tableNames, tbl.Name)
+		sort.Strings(c.tableNames)
+	}
+	c.tableMap[tbl.Name] = tbl
+	c.tableSchemas[tbl.Name] = ts
+	c.publishUnlocked()
+	return c
+}
+
+var emptySnapshot = &schemaSnapshot{}
+
+// snapshot the current published read-only view of this schema.
+func (m *Schema) snapshot() *schemaSnapshot {
+	if ss, ok := m.snap.Load().(*schemaSnapshot); ok {
+		return ss
+	}
+	return emptySnapshot
+}
+
+// publishUnlocked copy the current table/child maps into a new snapshot and
+// swap it in for readers.  Callers must hold the write lock (or own the
+// schema exclusively, ie during construction).
+func (m *Schema) publishUnlocked() {
+	ss := &schemaSnapshot{
+		schemas:      make(map[string]*Schema, len(m.schemas)),
+		tableSchemas: make(map[string]*Schema, len(m.tableSchemas)),
+		tableMap:     make(map[string]*Table, len(m.tableMap)),
+		tableNames:   make([]string, len(m.tableNames)),
+	}
+	for k, v := range m.schemas {
+		ss.schemas[k] = v
+	}
+	for k, v := range m.tableSchemas {
+		ss.tableSchemas[k] = v
+	}
+	for k, v := range m.tableMap {
+		ss.tableMap[k] = v
+	}
+	copy(ss.tableNames, m.tableNames)
+	m.snap.Store(ss)
+}
+
 // Since Is this schema object been refreshed within time window described by @dur time ago ?
 func (m *Schema) Since(dur time.Duration) bool {
 	if m.lastRefreshed.IsZero() {
@@ -204,19 +324,18 @@ func (m *Schema) Since(dur time.Duration) bool {
 func (m *Schema) Current() bool { return m.Since(SchemaRefreshInterval) }
 
 // Tables gets list of all tables for this schema.
-func (m *Schema) Tables() []string { return m.tableNames }
+func (m *Schema) Tables() []string { return m.snapshot().tableNames }
 
 // Table gets Table definition for given table name
 func (m *Schema) Table(tableIn string) (*Table, error) {
 
 	tableName := strings.ToLower(tableIn)
 
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	ss := m.snapshot()
 
 	// u.Debugf("%p looking up %q", m, tableName)
 
-	tbl, ok := m.tableMap[tableName]
+	tbl, ok := ss.tableMap[tableName]
 	if ok && tbl != nil {
 		return tbl, nil
 	}
@@ -224,7 +343,7 @@ func (m *Schema) Table(tableIn string) (*Table, error) {
 	// Lets see if it is   `schema`.`table` format
 	_, tableName, ok = expr.LeftRight(tableName)
 	if ok {
-		tbl, ok = m.tableMap[tableName]
+		tbl, ok = ss.tableMap[tableName]
 		if ok && tbl != nil {
 			return tbl, nil
 		}
@@ -233,15 +352,13 @@ func (m *Schema) Table(tableIn string) (*Table, error) {
 	if m.SchemaRef != nil {
 		return m.SchemaRef.Table(tableIn)
 	}
-	return nil, fmt.Errorf("Could not find that table: %v", tableIn)
+	return nil, sqlerr.New(sqlerr.ErNoSuchTable, "Could not find that table: %v", tableIn)
 }
 
 // OpenConn get a connection from this schema by table name.
 func (m *Schema) OpenConn(tableName string) (Conn, error) {
 	tableName = strings.ToLower(tableName)
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	sch, ok := m.tableSchemas[tableName]
+	sch, ok := m.snapshot().tableSchemas[tableName]
 	if !ok || sch == nil || sch.DS == nil {
 		return nil, fmt.Errorf("Could not find a DataSource for that table %q", tableName)
 	}
@@ -260,13 +377,11 @@ func (m *Schema) OpenConn(tableName string) (Conn, error) {
 func (m *Schema) Schema(schemaName string) (*Schema, error) {
 	// We always lower-case schema names
 	schemaName = strings.ToLower(schemaName)
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	child, ok := m.schemas[schemaName]
+	child, ok := m.snapshot().schemas[schemaName]
 	if ok && child != nil && child.DS != nil {
 		return child, nil
 	}
-	return nil, fmt.Errorf("Could not find a Schema by that name %q", schemaName)
+	return nil, sqlerr.New(sqlerr.ErBadDB, "Could not find a Schema by that name %q", schemaName)
 }
 
 // SchemaForTable Find a Schema for given Table
@@ -279,9 +394,7 @@ func (m *Schema) SchemaForTable(tableName string) (*Schema, error) {
 		return m, nil
 	}
 
-	m.mu.RLock()
-	ss, ok := m.tableSchemas[tableName]
-	m.mu.RUnlock()
+	ss, ok := m.snapshot().tableSchemas[tableName]
 	if ok && ss != nil && ss.DS != nil {
 		return ss, nil
 	}
@@ -299,12 +412,11 @@ func (m *Schema) addChildSchema(child *Schema) {
 	defer m.mu.Unlock()
 	m.schemas[child.Name] = child
 	child.parent = m
-	child.mu.RLock()
-	defer child.mu.RUnlock()
-	for tableName, tbl := range child.tableMap {
+	for tableName, tbl := range child.snapshot().tableMap {
 		m.tableSchemas[tableName] = child
 		m.tableMap[tableName] = tbl
 	}
+	m.publishUnlocked()
 }
 
 /*
@@ -324,6 +436,12 @@ func (m *Schema) refreshSchemaUnlocked() {
 			//u.Debugf("%p:%s  DS T:%T table name %s", m, m.Name, m.DS, tableName)
 			m.addschemaForTableUnlocked(tableName, m)
 		}
+		if hs, ok := m.DS.(SourceHierarchy); ok {
+			visited := make(map[string]bool)
+			for _, tableName := range m.DS.Tables() {
+				m.addChildTablesUnlocked(hs, tableName, visited)
+			}
+		}
 	}
 
 	for _, ss := range m.schemas {
@@ -335,6 +453,7 @@ func (m *Schema) refreshSchemaUnlocked() {
 			m.addschemaForTableUnlocked(tableName, ss)
 		}
 	}
+	m.publishUnlocked()
 }
 
 func (m *Schema) dropTable(tbl *Table) error {
@@ -397,6 +516,19 @@ func (m *Schema) addTable(tbl *Table) error {
 	//u.Infof("add table: %v partitionct:%v conf:%+v", tbl.Name, tbl.PartitionCt, m.Conf)
 	tbl.init(m)
 
+	// push the tokenizers of full-text fields to sources with their own index
+	if ts, ok := m.DS.(SourceTokenizers); ok {
+		fields, err := tbl.FieldTokenizers()
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			if err = ts.SetTokenizers(tbl.Name, fields); err != nil {
+				return err
+			}
+		}
+	}
+
 	m.tableMap[tbl.Name] = tbl
 
 	m.addschemaForTableUnlocked(tbl.Name, tbl.Schema)
@@ -489,17 +621,34 @@ func (m *Table) init(s *Schema) {
 
 // HasField does this table have given field/column?
 func (m *Table) HasField(name string) bool {
-	if _, ok := m.FieldMap[name]; ok {
-		return true
-	}
-	return false
+	m.mu.RLock()
+	_, ok := m.FieldMap[name]
+	m.mu.RUnlock()
+	return ok
+}
+
+// Field get field by name.
+func (m *Table) Field(name string) (*Field, bool) {
+	m.mu.RLock()
+	f, ok := m.FieldMap[name]
+	m.mu.RUnlock()
+	return f, ok
+}
+
+// FieldList the current list of fields, in order.  Slice is a snapshot
+// and must not be modified, use AddField.
+func (m *Table) FieldList() []*Field {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Fields
 }
 
 // FieldsAsMessages get list of all fields as interface Message
 // used in schema as sql "describe table"
 func (m *Table) FieldsAsMessages() []Message {
-	msgs := make([]Message, len(m.Fields))
-	for i, f := range m.Fields {
+	fields := m.FieldList()
+	msgs := make([]Message, len(fields))
+	for i, f := range fields {
 		msgs[i] = f
 	}
 	return msgs
@@ -511,21 +660,44 @@ func (m *Table) Id() uint64 { return m.tblID }
 // Body satisifies Message Interface
 func (m *Table) Body() interface{} { return m }
 
-// AddField register a new field
+// AddField register a new field, replacing existing field of same name.
 func (m *Table) AddField(fld *Field) {
-	found := false
-	for i, curFld := range m.Fields {
-		if curFld.Name == fld.Name {
-			found = true
-			m.Fields[i] = fld
-			break
+	m.AddFields(fld)
+}
+
+// AddFields register new fields under a single lock, safe to call
+// while the table is being read by other queries.
+func (m *Table) AddFields(flds ...*Field) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// copy-on-write so readers holding previous Fields/FieldMap are unaffected
+	fields := make([]*Field, len(m.Fields), len(m.Fields)+len(flds))
+	copy(fields, m.Fields)
+	fieldMap := make(map[string]*Field, len(m.FieldMap)+len(flds))
+	for k, f := range m.FieldMap {
+		fieldMap[k] = f
+	}
+	for _, fld := range flds {
+		found := false
+		for i, curFld := range fields {
+			if curFld.Name == fld.Name {
+				found = true
+				fld.idx = curFld.idx
+				fields[i] = fld
+				break
+			}
 		}
+		if !found {
+			fld.idx = uint64(len(fields))
+			fields = append(fields, fld)
+		}
+		fieldMap[fld.Name] = fld
 	}
-	if !found {
-		fld.idx = uint64(len(m.Fields))
-		m.Fields = append(m.Fields, fld)
-	}
-	m.FieldMap[fld.Name] = fld
+	m.Fields = fields
+	m.FieldMap = fieldMap
+	// describe rows are now stale
+	m.rows = nil
 }
 
 // AddFieldType describe and register a new column
@@ -535,11 +707,11 @@ func (m *Table) AddFieldType(name string, valType value.ValueType) {
 
 // Column get the Underlying data type.
 func (m *Table) Column(col string) (value.ValueType, bool) {
-	f, ok := m.FieldMap[col]
+	f, ok := m.Field(col)
 	if ok {
 		return f.ValueType(), true
 	}
-	f, ok = m.FieldMap[strings.ToLower(col)]
+	f, ok = m.Field(strings.ToLower(col))
 	if ok {
 		return f.ValueType(), true
 	}
@@ -548,59 +720,89 @@ func (m *Table) Column(col string) (value.ValueType, bool) {
 
 // SetColumns Explicityly set column names.
 func (m *Table) SetColumns(cols []string) {
-	m.FieldPositions = make(map[string]int, len(cols))
+	positions := make(map[string]int, len(cols))
 	for idx, col := range cols {
-		//col = strings.ToLower(col)
-		m.FieldPositions[col] = idx
-		cols[idx] = col
+		positions[col] = idx
 	}
+	m.mu.Lock()
+	m.FieldPositions = positions
 	m.cols = cols
+	m.mu.Unlock()
 }
 
 // SetColumnsFromFields Explicityly set column names from fields.
 func (m *Table) SetColumnsFromFields() {
-	m.FieldPositions = make(map[string]int, len(m.Fields))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	positions := make(map[string]int, len(m.Fields))
 	cols := make([]string, len(m.Fields))
 	for idx, f := range m.Fields {
 		col := strings.ToLower(f.Name)
-		m.FieldPositions[col] = idx
+		positions[col] = idx
 		cols[idx] = col
 	}
+	m.FieldPositions = positions
 	m.cols = cols
 }
 
 // Columns list of all column names.
-func (m *Table) Columns() []string { return m.cols }
+func (m *Table) Columns() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cols
+}
 
 // AsRows return all fields suiteable as list of values for Describe/Show statements.
 func (m *Table) AsRows() [][]driver.Value {
+	m.mu.RLock()
+	rows := m.rows
+	m.mu.RUnlock()
+	if len(rows) > 0 {
+		return rows
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if len(m.rows) > 0 {
 		return m.rows
 	}
-	m.rows = make([][]driver.Value, len(m.Fields))
+	rows = make([][]driver.Value, len(m.Fields))
 	for i, f := range m.Fields {
-		m.rows[i] = f.AsRow()
+		rows[i] = f.AsRow()
 	}
-	return m.rows
+	m.rows = rows
+	return rows
 }
 
 // SetRows set rows aka values for this table.  Used for schema/testing.
 func (m *Table) SetRows(rows [][]driver.Value) {
+	m.mu.Lock()
 	m.rows = rows
+	m.mu.Unlock()
 }
 
 // FieldNamesPositions List of Field Names and ordinal position in Column list
-func (m *Table) FieldNamesPositions() map[string]int { return m.FieldPositions }
+func (m *Table) FieldNamesPositions() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.FieldPositions
+}
 
 // Current Is this schema object current?  ie, have we refreshed it from
 // source since refresh interval.
 func (m *Table) Current() bool { return m.Since(SchemaRefreshInterval) }
 
 // SetRefreshed update the refreshed date to now.
-func (m *Table) SetRefreshed() { m.lastRefreshed = time.Now() }
+func (m *Table) SetRefreshed() {
+	m.mu.Lock()
+	m.lastRefreshed = time.Now()
+	m.mu.Unlock()
+}
 
 // Since Is this schema object within time window described by @dur time ago ?
 func (m *Table) Since(dur time.Duration) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	if m.lastRefreshed.IsZero() {
 		return false
 	}
@@ -612,14 +814,19 @@ func (m *Table) Since(dur time.Duration) bool {
 
 // AddContext add key/value pairs to context (settings, metatadata).
 func (m *Table) AddContext(key string, value interface{}) {
-	if len(m.Context) == 0 {
-		m.Context = make(map[string]interface{})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ctx := make(map[string]interface{}, len(m.Context)+1)
+	for k, v := range m.Context {
+		ctx[k] = v
 	}
-	m.Context[key] = value
+	ctx[key] = value
+	m.Context = ctx
 }
 
+// Marshal the table, its fields, indexes and context as protobuf TablePb.
 func (m *Table) Marshal() ([]byte, error) {
-	return proto.Marshal(&m.TablePb)
+	return proto.Marshal(m.toPb())
 }
 
 func NewFieldBase(name string, valType value.ValueType, size int, desc string) *Field {
@@ -653,22 +860,37 @@ func NewField(name string, valType value.ValueType, size int, allowNulls bool, d
 func (m *Field) ValueType() value.ValueType { return value.ValueType(m.Type) }
 func (m *Field) Id() uint64                 { return m.idx }
 func (m *Field) Body() interface{}          { return m }
+// AsRow values of this fields descriptors for describe, memoized per
+// table in Table.AsRows().
 func (m *Field) AsRow() []driver.Value {
-	if len(m.row) > 0 {
-		return m.row
-	}
-	m.row = make([]driver.Value, len(DescribeFullCols))
+	row := make([]driver.Value, len(DescribeFullCols))
 	// []string{"Field", "Type", "Collation", "Null", "Key", "Default", "Extra", "Privileges", "Comment"}
-	m.row[0] = m.Name
-	m.row[1] = value.ValueType(m.Type).String() // should we send this through a dialect-writer?  bc dialect specific?
-	m.row[2] = m.Collation
-	m.row[3] = ""
-	m.row[4] = ""
-	m.row[5] = ""
-	m.row[6] = m.Extra
-	m.row[7] = ""
-	m.row[8] = m.Description // should we put native type in here?
-	return m.row
+	row[0] = m.Name
+	row[1] = value.ValueType(m.Type).String() // should we send this through a dialect-writer?  bc dialect specific?
+	row[2] = m.Collation
+	row[3] = ""
+	row[4] = m.Key
+	row[5] = m.defaultString()
+	row[6] = m.Extra
+	row[7] = strings.Join(m.Roles, ",")
+	row[8] = m.Description // should we put native type in here?
+	return row
+}
+
+// defaultString the default value of this field as shown by describe,
+// empty if it has none.
+func (m *Field) defaultString() string {
+	if len(m.DefVal) == 0 {
+		return ""
+	}
+	var dv interface{}
+	if err := json.Unmarshal(m.DefVal, &dv); err != nil {
+		return string(m.DefVal)
+	}
+	if dv == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", dv)
 }
 func (m *Field) AddContext(key string, value interface{}) {
 	if len(m.Context) == 0 {
@@ -13,6 +13,7 @@ It has these top-level messages:
 	TablePb
 	FieldPb
 	Index
+	SchemaPb
 */
 package schema
 
@@ -376,51 +377,80 @@ func (m *Index) GetPartitionSize() int32 {
 	return 0
 }
 
+// SchemaPb is the serializable form of a Schema, its name and tables.
+type SchemaPb struct {
+	// Name of schema
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// List of tables in this schema
+	Tables []*TablePb `protobuf:"bytes,2,rep,name=tables" json:"tables,omitempty"`
+}
+
+func (m *SchemaPb) Reset()                    { *m = SchemaPb{} }
+func (m *SchemaPb) String() string            { return proto.CompactTextString(m) }
+func (*SchemaPb) ProtoMessage()               {}
+func (*SchemaPb) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *SchemaPb) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SchemaPb) GetTables() []*TablePb {
+	if m != nil {
+		return m.Tables
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*TablePartition)(nil), "schema.TablePartition")
 	proto.RegisterType((*Partition)(nil), "schema.Partition")
 	proto.RegisterType((*TablePb)(nil), "schema.TablePb")
 	proto.RegisterType((*FieldPb)(nil), "schema.FieldPb")
 	proto.RegisterType((*Index)(nil), "schema.Index")
+	proto.RegisterType((*SchemaPb)(nil), "schema.SchemaPb")
 }
 
 func init() { proto.RegisterFile("schema.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 546 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x5b, 0x8a, 0xdb, 0x30,
-	0x14, 0xc5, 0x71, 0x12, 0xc7, 0x37, 0x8f, 0x99, 0x11, 0x25, 0xe8, 0xa3, 0x14, 0x63, 0x0a, 0x35,
-	0x14, 0x06, 0x3a, 0xed, 0x0e, 0x86, 0x16, 0xfa, 0xa0, 0x1d, 0xd4, 0xa1, 0xff, 0x72, 0xac, 0xc4,
-	0x62, 0x14, 0xdb, 0x58, 0x6a, 0x49, 0xba, 0x99, 0x6e, 0xa1, 0x7b, 0xe8, 0xc6, 0x8a, 0xae, 0x25,
-	0xc7, 0xa1, 0xf3, 0xd3, 0xaf, 0xdc, 0x73, 0x74, 0x75, 0xae, 0x74, 0x8e, 0x1c, 0x58, 0xe8, 0x4d,
-	0x29, 0xf6, 0xfc, 0xba, 0x69, 0x6b, 0x53, 0x93, 0x69, 0x87, 0xd2, 0x3d, 0xac, 0xee, 0x79, 0xae,
-	0xc4, 0x1d, 0x6f, 0x8d, 0x34, 0xb2, 0xae, 0xc8, 0x13, 0x98, 0x18, 0xcb, 0xd0, 0x20, 0x09, 0xb2,
-	0x98, 0x75, 0x80, 0x10, 0x18, 0x3f, 0x88, 0xa3, 0xa6, 0xa3, 0x24, 0xcc, 0x62, 0x86, 0x35, 0x79,
-	0x05, 0xd0, 0xf8, 0x6d, 0x9a, 0x86, 0x49, 0x98, 0xcd, 0x6f, 0xae, 0xae, 0xdd, 0x98, 0x5e, 0x90,
-	0x0d, 0x9a, 0xd2, 0xb7, 0x10, 0x9f, 0x26, 0xad, 0x60, 0x24, 0x0b, 0x37, 0x66, 0x24, 0x0b, 0x3b,
-	0x43, 0x89, 0xad, 0xa1, 0x23, 0x64, 0xb0, 0xb6, 0xa7, 0x69, 0xe5, 0xae, 0x34, 0x34, 0xec, 0x4e,
-	0x83, 0x20, 0xfd, 0x33, 0x82, 0xa8, 0x3b, 0x76, 0x6e, 0x77, 0x55, 0x7c, 0xef, 0x8f, 0x8b, 0x35,
-	0x49, 0x61, 0x61, 0x7f, 0xbf, 0xb4, 0x72, 0x27, 0x2b, 0xae, 0x9c, 0xe2, 0x19, 0x47, 0xd6, 0x30,
-	0x6d, 0x78, 0x2b, 0x2a, 0x2f, 0xed, 0x10, 0xa1, 0x10, 0xdd, 0x96, 0xbc, 0xd5, 0xc2, 0xd0, 0x71,
-	0x12, 0x64, 0x4b, 0xe6, 0x21, 0x79, 0x03, 0x71, 0x7f, 0x15, 0x3a, 0x49, 0x82, 0x6c, 0x7e, 0xb3,
-	0xf6, 0xd7, 0x3d, 0x37, 0x91, 0x9d, 0x1a, 0x49, 0x02, 0xf3, 0x9e, 0xbf, 0x35, 0x74, 0x8a, 0x9a,
-	0x43, 0x8a, 0xbc, 0x80, 0x48, 0x56, 0x85, 0x38, 0x08, 0x4d, 0x23, 0x34, 0x71, 0xe9, 0x55, 0xdf,
-	0x5b, 0x9a, 0xf9, 0x55, 0x2b, 0xb5, 0xa9, 0x2b, 0x23, 0x0e, 0xe6, 0x83, 0xae, 0x2b, 0x3a, 0x4b,
-	0x82, 0x6c, 0xc1, 0x86, 0x14, 0x79, 0x09, 0xb3, 0xad, 0x14, 0xaa, 0x68, 0x72, 0x4d, 0x63, 0xd4,
-	0xba, 0xf0, 0x5a, 0xef, 0x2c, 0x7f, 0x97, 0xb3, 0xbe, 0x21, 0xfd, 0x1d, 0x42, 0xe4, 0xd8, 0x47,
-	0x5d, 0x4c, 0x60, 0x5e, 0x08, 0xbd, 0x69, 0x65, 0x83, 0x37, 0xee, 0x4c, 0x1c, 0x52, 0xe4, 0x12,
-	0xc2, 0x07, 0x71, 0x74, 0x06, 0xda, 0xd2, 0xe6, 0x25, 0x0e, 0xa6, 0xe5, 0xe8, 0x5d, 0xcc, 0x3a,
-	0x60, 0xd5, 0x0b, 0x6e, 0x38, 0x9a, 0x16, 0x33, 0xac, 0xad, 0xff, 0x4a, 0x54, 0x3b, 0x53, 0x3a,
-	0x4b, 0x1c, 0xb2, 0xbd, 0xe6, 0xd8, 0x08, 0x1a, 0x21, 0x8b, 0x35, 0x79, 0x06, 0x50, 0x71, 0x23,
-	0x7f, 0x88, 0x7b, 0xbb, 0x32, 0xc3, 0x95, 0x01, 0x43, 0x9e, 0x42, 0x5c, 0x88, 0xed, 0xa7, 0x4e,
-	0x2e, 0x4e, 0x82, 0x6c, 0xcc, 0x4e, 0x84, 0x9d, 0x54, 0x88, 0xed, 0x37, 0xae, 0xe8, 0x1c, 0x1d,
-	0x73, 0xc8, 0x26, 0xdd, 0x39, 0x5b, 0xd0, 0x65, 0x12, 0x64, 0x33, 0x6f, 0x74, 0x61, 0x57, 0xaa,
-	0xfa, 0xf3, 0x77, 0xa5, 0x34, 0x5d, 0x75, 0x2b, 0x0e, 0xda, 0x49, 0x9b, 0x5a, 0x29, 0x8e, 0x8e,
-	0x5c, 0xe0, 0x75, 0x4e, 0x04, 0xbe, 0xd6, 0x5a, 0x09, 0x4d, 0x2f, 0xf1, 0x33, 0xe9, 0xc0, 0x30,
-	0xdf, 0xab, 0xff, 0xc9, 0x97, 0xfc, 0x93, 0x6f, 0xfa, 0x2b, 0x80, 0x09, 0x6e, 0x7a, 0x34, 0xb0,
-	0x35, 0x4c, 0x31, 0x5c, 0xff, 0x99, 0x3a, 0x64, 0xed, 0x6b, 0x5a, 0xb9, 0xe7, 0xed, 0xf1, 0xa3,
-	0x4b, 0x6b, 0xc6, 0x06, 0x0c, 0x79, 0x0e, 0xcb, 0x92, 0xeb, 0xb2, 0x7f, 0x93, 0x74, 0x8c, 0xdb,
-	0xcf, 0x49, 0xdb, 0xd5, 0xbf, 0xea, 0xaf, 0xf2, 0xa7, 0xc0, 0x34, 0x27, 0xec, 0x9c, 0xcc, 0xa7,
-	0xf8, 0xff, 0xf2, 0xfa, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xb8, 0x29, 0x27, 0x0d, 0x6f, 0x04,
-	0x00, 0x00,
+	// 566 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xdb, 0x8a, 0xdb, 0x30,
+	0x10, 0xc5, 0x71, 0xe2, 0xcb, 0xe4, 0xb2, 0xbb, 0xa2, 0x04, 0x3d, 0x94, 0x62, 0x4c, 0x61, 0x0d,
+	0x85, 0x85, 0x6e, 0xfb, 0x07, 0x4b, 0x5b, 0x7a, 0xa1, 0x5d, 0xb4, 0x4b, 0xdf, 0x95, 0x58, 0x89,
+	0xc5, 0x3a, 0xb6, 0xb1, 0xd4, 0x92, 0xf4, 0x67, 0xfa, 0x0b, 0xfd, 0x87, 0xfe, 0x58, 0xd1, 0x48,
+	0x76, 0x1c, 0xba, 0x7d, 0xe8, 0x93, 0xe7, 0x9c, 0x91, 0xce, 0x48, 0x67, 0x46, 0x86, 0x99, 0x5a,
+	0x17, 0x62, 0xc7, 0xaf, 0x9a, 0xb6, 0xd6, 0x35, 0x09, 0x2c, 0x4a, 0x77, 0xb0, 0xb8, 0xe7, 0xab,
+	0x52, 0xdc, 0xf2, 0x56, 0x4b, 0x2d, 0xeb, 0x8a, 0x3c, 0x81, 0x89, 0x36, 0x0c, 0xf5, 0x12, 0x2f,
+	0x8b, 0x99, 0x05, 0x84, 0xc0, 0xf8, 0x41, 0x1c, 0x14, 0x1d, 0x25, 0x7e, 0x16, 0x33, 0x8c, 0xc9,
+	0x4b, 0x80, 0xa6, 0xdb, 0xa6, 0xa8, 0x9f, 0xf8, 0xd9, 0xf4, 0xfa, 0xe2, 0xca, 0x95, 0xe9, 0x05,
+	0xd9, 0x60, 0x51, 0xfa, 0x06, 0xe2, 0x63, 0xa5, 0x05, 0x8c, 0x64, 0xee, 0xca, 0x8c, 0x64, 0x6e,
+	0x6a, 0x94, 0x62, 0xa3, 0xe9, 0x08, 0x19, 0x8c, 0xcd, 0x69, 0x5a, 0xb9, 0x2d, 0x34, 0xf5, 0xed,
+	0x69, 0x10, 0xa4, 0xbf, 0x47, 0x10, 0xda, 0x63, 0xaf, 0xcc, 0xae, 0x8a, 0xef, 0xba, 0xe3, 0x62,
+	0x4c, 0x52, 0x98, 0x99, 0xef, 0x97, 0x56, 0x6e, 0x65, 0xc5, 0x4b, 0xa7, 0x78, 0xc2, 0x91, 0x25,
+	0x04, 0x0d, 0x6f, 0x45, 0xd5, 0x49, 0x3b, 0x44, 0x28, 0x84, 0x37, 0x05, 0x6f, 0x95, 0xd0, 0x74,
+	0x9c, 0x78, 0xd9, 0x9c, 0x75, 0x90, 0xbc, 0x86, 0xb8, 0xbf, 0x0a, 0x9d, 0x24, 0x5e, 0x36, 0xbd,
+	0x5e, 0x76, 0xd7, 0x3d, 0x35, 0x91, 0x1d, 0x17, 0x92, 0x04, 0xa6, 0x3d, 0x7f, 0xa3, 0x69, 0x80,
+	0x9a, 0x43, 0x8a, 0x5c, 0x42, 0x28, 0xab, 0x5c, 0xec, 0x85, 0xa2, 0x21, 0x9a, 0x38, 0xef, 0x54,
+	0xdf, 0x1b, 0x9a, 0x75, 0x59, 0x23, 0xb5, 0xae, 0x2b, 0x2d, 0xf6, 0xfa, 0x83, 0xaa, 0x2b, 0x1a,
+	0x25, 0x5e, 0x36, 0x63, 0x43, 0x8a, 0xbc, 0x80, 0x68, 0x23, 0x45, 0x99, 0x37, 0x2b, 0x45, 0x63,
+	0xd4, 0x3a, 0xeb, 0xb4, 0xde, 0x1a, 0xfe, 0x76, 0xc5, 0xfa, 0x05, 0xe9, 0x2f, 0x1f, 0x42, 0xc7,
+	0x3e, 0xea, 0x62, 0x02, 0xd3, 0x5c, 0xa8, 0x75, 0x2b, 0x1b, 0xbc, 0xb1, 0x35, 0x71, 0x48, 0x91,
+	0x73, 0xf0, 0x1f, 0xc4, 0xc1, 0x19, 0x68, 0x42, 0xd3, 0x2f, 0xb1, 0xd7, 0x2d, 0x47, 0xef, 0x62,
+	0x66, 0x81, 0x51, 0xcf, 0xb9, 0xe6, 0x68, 0x5a, 0xcc, 0x30, 0x36, 0xfe, 0x97, 0xa2, 0xda, 0xea,
+	0xc2, 0x59, 0xe2, 0x90, 0x59, 0xab, 0x0f, 0x8d, 0xa0, 0x21, 0xb2, 0x18, 0x93, 0x67, 0x00, 0x15,
+	0xd7, 0xf2, 0xbb, 0xb8, 0x37, 0x99, 0x08, 0x33, 0x03, 0x86, 0x3c, 0x85, 0x38, 0x17, 0x9b, 0x4f,
+	0x56, 0x2e, 0x4e, 0xbc, 0x6c, 0xcc, 0x8e, 0x84, 0xa9, 0x94, 0x8b, 0xcd, 0x57, 0x5e, 0xd2, 0x29,
+	0x3a, 0xe6, 0x90, 0xe9, 0xb4, 0x75, 0x36, 0xa7, 0xf3, 0xc4, 0xcb, 0xa2, 0xce, 0xe8, 0xdc, 0x64,
+	0xaa, 0xfa, 0xf3, 0xb7, 0xb2, 0x54, 0x74, 0x61, 0x33, 0x0e, 0x9a, 0x4a, 0xeb, 0xba, 0x2c, 0x39,
+	0x3a, 0x72, 0x86, 0xd7, 0x39, 0x12, 0x38, 0xad, 0x75, 0x29, 0x14, 0x3d, 0xc7, 0x67, 0x62, 0xc1,
+	0xb0, 0xbf, 0x17, 0xff, 0xd3, 0x5f, 0xf2, 0x57, 0x7f, 0xd3, 0x9f, 0x1e, 0x4c, 0x70, 0xd3, 0xa3,
+	0x0d, 0x5b, 0x42, 0x80, 0xcd, 0xed, 0x9e, 0xa9, 0x43, 0xc6, 0xbe, 0xa6, 0x95, 0x3b, 0xde, 0x1e,
+	0x3e, 0xba, 0x6e, 0x45, 0x6c, 0xc0, 0x90, 0xe7, 0x30, 0x2f, 0xb8, 0x2a, 0xfa, 0x99, 0xa4, 0x63,
+	0xdc, 0x7e, 0x4a, 0x9a, 0x55, 0xfd, 0x54, 0xdf, 0xc9, 0x1f, 0x02, 0xbb, 0x39, 0x61, 0xa7, 0x64,
+	0xfa, 0x0e, 0xa2, 0x3b, 0xbc, 0xdc, 0x3f, 0x86, 0xea, 0x12, 0x02, 0xfc, 0xa3, 0xd8, 0x33, 0x0e,
+	0xe6, 0xd3, 0xbd, 0x67, 0xe6, 0xd2, 0xab, 0x00, 0x7f, 0x54, 0xaf, 0xfe, 0x0c, 0x00, 0x6d, 0x25,
+	0x38, 0x4b, 0xb8, 0x04, 0x00, 0x00,
 }
@@ -3,7 +3,9 @@ package schema_test
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -183,6 +185,99 @@ func TestSchema(t *testing.T) {
 	_, err = s.SchemaForTable("not_a_table")
 	assert.NotEqual(t, nil, err)
 }
+func TestSchemaConcurrent(t *testing.T) {
+	a := schema.NewApplyer(func(s *schema.Schema) schema.Source {
+		sdb := datasource.NewSchemaDb(s)
+		s.InfoSchema.DS = sdb
+		return sdb
+	})
+	reg := schema.NewRegistry(a)
+	a.Init(reg)
+
+	db, err := memdb.NewMemDbData("users", [][]driver.Value{{122, "bob"}}, []string{"user_id", "name"})
+	assert.Equal(t, nil, err)
+	s := schema.NewSchema("concurrent_schema")
+	s.DS = db
+	assert.Equal(t, nil, reg.SchemaAdd(s))
+
+	// lookups while tables are being added
+	names := s.Tables()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				tables := s.Tables()
+				assert.True(t, sort.StringsAreSorted(tables))
+				for _, name := range tables {
+					tbl, err := s.Table(name)
+					assert.Equal(t, nil, err)
+					assert.NotEqual(t, nil, tbl)
+				}
+				_, err := s.SchemaForTable("users")
+				assert.Equal(t, nil, err)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		tbl := schema.NewTable(fmt.Sprintf("tbl_%03d", i))
+		assert.Equal(t, nil, a.AddOrUpdateOnSchema(s, tbl))
+	}
+	wg.Wait()
+
+	assert.Equal(t, 101, len(s.Tables()))
+	tbl, err := s.Table("tbl_050")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "tbl_050", tbl.Name)
+	// previously read table list is unchanged
+	assert.Equal(t, []string{"users"}, names)
+}
+func TestSchemaClone(t *testing.T) {
+	a := schema.NewApplyer(func(s *schema.Schema) schema.Source {
+		sdb := datasource.NewSchemaDb(s)
+		s.InfoSchema.DS = sdb
+		return sdb
+	})
+	reg := schema.NewRegistry(a)
+	a.Init(reg)
+
+	db, err := memdb.NewMemDbData("users", [][]driver.Value{{122, "bob"}}, []string{"user_id", "name"})
+	assert.Equal(t, nil, err)
+	s := schema.NewSchema("clone_schema")
+	s.DS = db
+	assert.Equal(t, nil, reg.SchemaAdd(s))
+	tbl, err := s.Table("users")
+	assert.Equal(t, nil, err)
+	tbl.AddContext("tenant", "shared")
+
+	s2 := s.Clone()
+	assert.Equal(t, s.Name, s2.Name)
+	assert.Equal(t, s.Tables(), s2.Tables())
+	tbl2, err := s2.Table("users")
+	assert.Equal(t, nil, err)
+	assert.True(t, tbl != tbl2, "must be copy of table")
+	assert.Equal(t, tbl.Columns(), tbl2.Columns())
+	assert.Equal(t, len(tbl.Fields), len(tbl2.Fields))
+	ss, err := s2.SchemaForTable("users")
+	assert.Equal(t, nil, err)
+	assert.True(t, ss == s2)
+
+	// modifications of the clone are not seen in original
+	tbl2.AddField(schema.NewFieldBase("tenant_id", value.StringType, 64, "string"))
+	tbl2.SetColumnsFromFields()
+	tbl2.AddContext("tenant", "tenant1")
+	tbl2.Fields[0].Description = "changed"
+	assert.Equal(t, nil, a.AddOrUpdateOnSchema(s2, schema.NewTable("tenant_only")))
+	assert.Equal(t, false, tbl.HasField("tenant_id"))
+	assert.Equal(t, 2, len(tbl.Columns()))
+	assert.Equal(t, "shared", tbl.Context["tenant"])
+	assert.NotEqual(t, "changed", tbl.Fields[0].Description)
+	assert.Equal(t, []string{"users"}, s.Tables())
+	assert.Equal(t, []string{"tenant_only", "users"}, s2.Tables())
+	_, err = s.Table("tenant_only")
+	assert.NotEqual(t, nil, err)
+}
 func TestTable(t *testing.T) {
 	tbl := schema.NewTable("users")
 
@@ -230,6 +325,45 @@ func TestTable(t *testing.T) {
 
 	assert.NotEqual(t, nil, tbl.Body())
 	assert.Equal(t, uint64(0), tbl.Id())
+
+	// describe rows are refreshed after fields change
+	tbl.AddField(schema.NewFieldBase("age", value.IntType, 8, "int"))
+	assert.Equal(t, 3, len(tbl.AsRows()))
+}
+func TestTableConcurrent(t *testing.T) {
+	tbl := schema.NewTable("events")
+	tbl.AddField(schema.NewFieldBase("id", value.StringType, 64, "string"))
+	tbl.SetColumnsFromFields()
+
+	// readers (show, describe) while a schema refresh adds fields
+	fields := tbl.FieldList()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				assert.True(t, len(tbl.AsRows()) >= 1)
+				assert.True(t, len(tbl.Columns()) >= 1)
+				assert.True(t, tbl.HasField("id"))
+				for _, f := range tbl.FieldList() {
+					tbl.Column(f.Name)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		tbl.AddField(schema.NewFieldBase(fmt.Sprintf("col_%d", i), value.StringType, 64, "string"))
+		tbl.SetColumnsFromFields()
+		tbl.AddContext("refreshed", i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 201, len(tbl.FieldList()))
+	assert.Equal(t, 201, len(tbl.AsRows()))
+	assert.Equal(t, 201, len(tbl.Columns()))
+	// previously read snapshot is unchanged
+	assert.Equal(t, 1, len(fields))
 }
 func TestFields(t *testing.T) {
 	f := schema.NewFieldBase("Field", value.StringType, 64, "string")
@@ -245,14 +379,155 @@ func TestFields(t *testing.T) {
 
 	// NewField(name string, valType value.ValueType, size int, allowNulls bool, defaultVal driver.Value, key, collation, description string)
 	f = schema.NewField("Field", value.StringType, 64, false, "world", "Key", "utf-8", "this is a description")
+	f.Roles = []string{"select", "insert"}
 	r = f.AsRow()
 	assert.Equal(t, 9, len(r))
+	// Field, Type, Collation, Null, Key, Default, Extra, Privileges, Comment
+	assert.Equal(t, "Key", r[4])
+	assert.Equal(t, "world", r[5])
+	assert.Equal(t, "select,insert", r[7])
+	assert.Equal(t, "this is a description", r[8])
+	assert.Equal(t, "", schema.NewField("f", value.IntType, 8, true, nil, "", "", "").AsRow()[5])
 	assert.Equal(t, value.StringType, f.ValueType())
 	assert.NotEqual(t, nil, f.Body())
 	assert.Equal(t, uint64(0), f.Id())
 }
+
+type tokenizedSource struct {
+	*memdb.MemDb
+	fields map[string]schema.Tokenizer
+}
+
+func (m *tokenizedSource) SetTokenizers(table string, fields map[string]schema.Tokenizer) error {
+	m.fields = fields
+	return nil
+}
+
+func TestTokenizers(t *testing.T) {
+	st, _ := schema.TokenizerGet("standard")
+	assert.Equal(t, []string{"hello", "world", "2"}, st.Tokenize("Hello, World-2"))
+	ng, _ := schema.TokenizerGet("ngram")
+	assert.Equal(t, []string{"sea", "ear", "arc", "rch", "go"}, ng.Tokenize("search go"))
+	assert.Equal(t, []string{"東京", "京都", "tokyo", "大", "x"}, schema.CJKTokenize("東京都 tokyo 大x"))
+
+	schema.RegisterTokenizer("bigram", schema.NewNGramTokenizer(2, 2))
+	assert.Contains(t, schema.TokenizerNames(), "bigram")
+
+	f := schema.NewFieldBase("title", value.StringType, 64, "string")
+	assert.Equal(t, schema.DefaultAnalyzer, f.Analyzer())
+	f.AddContext(schema.AnalyzerContextKey, "bigram")
+	tk, err := f.Tokenizer()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"ab", "bc"}, tk.Tokenize("abc"))
+
+	// analyzed fields tokenizers are pushed to capable sources
+	db, err := memdb.NewMemDbData("docs", [][]driver.Value{{1, "abc"}}, []string{"id", "title"})
+	assert.Equal(t, nil, err)
+	tbl := schema.NewTable("docs")
+	tbl.AddField(schema.NewFieldBase("id", value.IntType, 64, "int"))
+	tbl.AddField(f)
+	src := &tokenizedSource{MemDb: db}
+	schema.NewSchemaTable("tokenized", tbl, src)
+	assert.Equal(t, 1, len(src.fields))
+	assert.True(t, src.fields["title"] != nil)
+
+	f.AddContext(schema.AnalyzerContextKey, "not_an_analyzer")
+	_, err = f.Tokenizer()
+	assert.NotEqual(t, nil, err)
+}
+
+// hierSource a source of bigtable like tables with nested column families.
+type hierSource struct {
+	*memdb.MemDb
+	tables   map[string]*schema.Table
+	children map[string][]string
+}
+
+func (m *hierSource) Tables() []string { return []string{"users"} }
+func (m *hierSource) Table(table string) (*schema.Table, error) {
+	if tbl, ok := m.tables[table]; ok {
+		return tbl, nil
+	}
+	return nil, schema.ErrNotFound
+}
+func (m *hierSource) ChildTables(table string) []string { return m.children[table] }
+
+func TestHierarchyDiscovery(t *testing.T) {
+	db, err := memdb.NewMemDbData("users", [][]driver.Value{{1, "bob"}}, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	src := &hierSource{MemDb: db, tables: make(map[string]*schema.Table), children: map[string][]string{
+		"users":         {"users_profile", "users_events"},
+		"users_profile": {"users_address"},
+		// cycles are not followed
+		"users_address": {"users"},
+	}}
+	for _, name := range []string{"users", "users_profile", "users_events", "users_address"} {
+		src.tables[name] = schema.NewTable(name)
+	}
+	src.tables["users_profile"].SetParent("users", "id", "user_id")
+
+	err = schema.RegisterSourceAsSchema("hier_test", src)
+	assert.Equal(t, nil, err)
+	s, ok := schema.DefaultRegistry().Schema("hier_test")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"users", "users_address", "users_events", "users_profile"}, s.Tables())
+
+	profile, err := s.Table("users_profile")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "users", profile.Parent)
+	parentKey, childKey, ok := profile.ParentKeys()
+	assert.True(t, ok)
+	assert.Equal(t, "id", parentKey)
+	assert.Equal(t, "user_id", childKey)
+
+	// discovered children get their parent, but without keys
+	addr, err := s.Table("users_address")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "users_profile", addr.Parent)
+	_, _, ok = addr.ParentKeys()
+	assert.False(t, ok)
+
+	names := make([]string, 0)
+	for _, tbl := range s.ChildTables("users") {
+		names = append(names, tbl.Name)
+	}
+	assert.Equal(t, []string{"users_events", "users_profile"}, names)
+	users, _ := s.Table("users")
+	assert.Equal(t, "", users.Parent)
+}
+
 func TestConfig(t *testing.T) {
 	c := schema.NewSourceConfig("test", "test")
 	assert.NotEqual(t, nil, c)
 	assert.NotEqual(t, "", c.String())
 }
+
+func TestTimeFormat(t *testing.T) {
+	want := time.Date(2012, 10, 17, 17, 29, 39, 0, time.UTC)
+	tf, err := schema.NewTimeFormat("", "")
+	assert.Equal(t, nil, err)
+	for _, v := range []driver.Value{int64(1350494979), 1350494979.0, "1350494979", "2012-10-17T17:29:39Z", want} {
+		got, ok := tf.Convert(v)
+		assert.True(t, ok, "%v", v)
+		assert.Equal(t, want, got, "%v", v)
+	}
+	_, ok := tf.Convert("not a time")
+	assert.False(t, ok)
+
+	tf, err = schema.NewTimeFormat("America/Denver", "us")
+	assert.Equal(t, nil, err)
+	got, _ := tf.Convert(int64(1350494979000000))
+	assert.Equal(t, want, got)
+	got, _ = tf.Convert("2012-10-17 11:29:39")
+	assert.Equal(t, want, got)
+	got, _ = tf.Convert(time.Date(2012, 10, 17, 11, 29, 39, 0, time.UTC))
+	assert.Equal(t, want, got)
+	// strings with a zone keep it
+	got, _ = tf.Convert("2012-10-17T17:29:39Z")
+	assert.Equal(t, want, got)
+
+	_, err = schema.NewTimeFormat("Not/AZone", "")
+	assert.NotEqual(t, nil, err)
+	_, err = schema.NewTimeFormat("", "fortnights")
+	assert.NotEqual(t, nil, err)
+}
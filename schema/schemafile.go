@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadSchemaFile read a declarative schema file (.json, .yaml, .yml) of
+// complete table definitions (columns, types, keys).  Used to bootstrap
+// tables for sources that cannot describe themselves (raw csv endpoints,
+// message queues) instead of relying on runtime inference.
+func LoadSchemaFile(path string) (*Schema, error) {
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSchema("")
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(by, s)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(by, s)
+	default:
+		return nil, fmt.Errorf("QLBridge.schema: unrecognized schema file type %q, expected .json, .yaml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("QLBridge.schema: could not read schema file %q: %v", path, err)
+	}
+	return s, nil
+}
+
+// DeclareTables add the tables of a declared schema to this schema.  Declared
+// tables are used as-is, the underlying source is not asked to describe them.
+func (m *Schema) DeclareTables(decl *Schema) {
+	m.addTables(decl.schemaTables())
+}
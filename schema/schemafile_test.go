@@ -0,0 +1,82 @@
+package schema_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// blindSource is a source that cannot describe its own tables.
+type blindSource struct{}
+
+func (m *blindSource) Init()                               {}
+func (m *blindSource) Setup(*schema.Schema) error          { return nil }
+func (m *blindSource) Close() error                        { return nil }
+func (m *blindSource) Open(string) (schema.Conn, error)    { return nil, schema.ErrNotFound }
+func (m *blindSource) Tables() []string                    { return nil }
+func (m *blindSource) Table(string) (*schema.Table, error) { return nil, schema.ErrNotFound }
+
+func TestSchemaFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "qlbridge_schemafile")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	sf := filepath.Join(dir, "events.yaml")
+	err = ioutil.WriteFile(sf, []byte(`
+name: events
+tables:
+- name: clicks
+  fields:
+  - name: event_id
+    type: string
+    key: PRI
+    noNulls: true
+  - name: user_id
+    type: string
+  - name: ts
+    type: time
+  - name: amount
+    type: number
+`), 0644)
+	assert.Equal(t, nil, err)
+
+	schema.RegisterSourceType("blind_queue", &blindSource{})
+	reg := schema.DefaultRegistry()
+
+	err = reg.SchemaAddFromConfig(&schema.ConfigSource{Name: "declared_events", SourceType: "blind_queue", SchemaFile: sf})
+	assert.Equal(t, nil, err)
+
+	s, ok := reg.Schema("declared_events")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"clicks"}, s.Tables())
+
+	tbl, err := s.Table("clicks")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"event_id", "user_id", "ts", "amount"}, tbl.Columns())
+	assert.Equal(t, value.TimeType, tbl.FieldMap["ts"].ValueType())
+	assert.Equal(t, value.NumberType, tbl.FieldMap["amount"].ValueType())
+	assert.Equal(t, "PRI", tbl.FieldMap["event_id"].Key)
+	assert.Equal(t, true, tbl.FieldMap["event_id"].NoNulls)
+
+	// bad files fail the source add
+	err = reg.SchemaAddFromConfig(&schema.ConfigSource{Name: "declared_missing", SourceType: "blind_queue", SchemaFile: filepath.Join(dir, "missing.yaml")})
+	assert.NotEqual(t, nil, err)
+
+	badExt := filepath.Join(dir, "events.txt")
+	assert.Equal(t, nil, ioutil.WriteFile(badExt, []byte("name: events"), 0644))
+	_, err = schema.LoadSchemaFile(badExt)
+	assert.NotEqual(t, nil, err)
+
+	jf := filepath.Join(dir, "events.json")
+	assert.Equal(t, nil, ioutil.WriteFile(jf, []byte(`{"name":"events","tables":[{"name":"views","fields":[{"name":"url","type":"string"}]}]}`), 0644))
+	decl, err := schema.LoadSchemaFile(jf)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"views"}, decl.Tables())
+}
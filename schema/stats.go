@@ -0,0 +1,169 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HistogramSingleton a histogram with a bucket per distinct value.
+	HistogramSingleton = "singleton"
+	// HistogramEquiHeight a histogram whose buckets each hold about the same
+	// number of values.
+	HistogramEquiHeight = "equi-height"
+)
+
+var (
+	// DefaultStatsStore the StatsStore ANALYZE TABLE writes table statistics
+	// to, and the planner reads them from.
+	DefaultStatsStore StatsStore = NewStatsStore()
+
+	// Ensure our memory stats store implements StatsStore
+	_ StatsStore = (*statsStore)(nil)
+)
+
+type (
+	// StatsStore stores the statistics of tables by schema and table name,
+	// embedders may replace the DefaultStatsStore to persist them.
+	StatsStore interface {
+		// TableStats statistics of table, false if it has not been analyzed.
+		TableStats(schema, table string) (*TableStats, bool)
+		// SetTableStats store statistics, replacing existing of the table.
+		SetTableStats(ts *TableStats)
+		// DropTableStats remove the statistics of table.
+		DropTableStats(schema, table string)
+		// AllTableStats statistics of all analyzed tables.
+		AllTableStats() []*TableStats
+	}
+
+	// TableStats statistics of the rows of a table computed by ANALYZE TABLE,
+	// the planner uses them to estimate the rows its predicates match.
+	TableStats struct {
+		Schema   string                  // schema name
+		Table    string                  // table name
+		Rows     int64                   // row count
+		Columns  map[string]*ColumnStats // per column by lower case name
+		Groups   []*ColumnGroupStats     // correlated column groups, largest first
+		Analyzed time.Time               // when the statistics were computed
+	}
+
+	// ColumnStats statistics of the values of a column.
+	ColumnStats struct {
+		Name      string       // column name
+		Ndv       int64        // number of distinct non-null values
+		Nulls     int64        // number of null values
+		Min       driver.Value // smallest non-null value
+		Max       driver.Value // largest non-null value
+		Histogram *Histogram   // distribution of the values, nil if none
+	}
+
+	// ColumnGroupStats statistics of the combined values of a group of
+	// correlated columns (city, state), so the planner doesn't assume
+	// predicates on each of them are independent.
+	ColumnGroupStats struct {
+		Columns      []string      // lower case column names
+		Ndv          int64         // distinct combinations of non-null values
+		Dependencies []*Dependency // functional dependencies between the columns
+	}
+
+	// Dependency functional dependency between two columns, the value of
+	// From determines the value of To in Degree [0,1] of the rows.
+	Dependency struct {
+		From   string
+		To     string
+		Degree float64
+	}
+
+	// Histogram distribution of the non-null values of a column, as buckets
+	// of values in ascending order.
+	Histogram struct {
+		Type    string             `json:"type"`    // HistogramSingleton, HistogramEquiHeight
+		Buckets []*HistogramBucket `json:"buckets"` // in ascending order of value
+	}
+
+	// HistogramBucket values of a column from Lower to Upper inclusive,
+	// Freq is the fraction of the non-null values of the column in it.
+	HistogramBucket struct {
+		Lower driver.Value `json:"lower"`
+		Upper driver.Value `json:"upper"`
+		Freq  float64      `json:"freq"`
+		Ndv   int64        `json:"ndv"`
+	}
+
+	// statsStore in memory StatsStore.
+	statsStore struct {
+		mu     sync.RWMutex
+		tables map[string]*TableStats
+	}
+)
+
+// NewStatsStore create an in memory StatsStore.
+func NewStatsStore() StatsStore {
+	return &statsStore{tables: make(map[string]*TableStats)}
+}
+
+func statsKey(schema, table string) string {
+	return strings.ToLower(schema) + "." + strings.ToLower(table)
+}
+
+// TableStats statistics of table.
+func (m *statsStore) TableStats(schema, table string) (*TableStats, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ts, ok := m.tables[statsKey(schema, table)]
+	return ts, ok
+}
+
+// SetTableStats store statistics of ts.Table.
+func (m *statsStore) SetTableStats(ts *TableStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tables[statsKey(ts.Schema, ts.Table)] = ts
+}
+
+// DropTableStats remove statistics of table.
+func (m *statsStore) DropTableStats(schema, table string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tables, statsKey(schema, table))
+}
+
+// AllTableStats statistics of all tables ordered by schema, table.
+func (m *statsStore) AllTableStats() []*TableStats {
+	m.mu.RLock()
+	all := make([]*TableStats, 0, len(m.tables))
+	for _, ts := range m.tables {
+		all = append(all, ts)
+	}
+	m.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return statsKey(all[i].Schema, all[i].Table) < statsKey(all[j].Schema, all[j].Table)
+	})
+	return all
+}
+
+// Column statistics of column, nil if none.
+func (m *TableStats) Column(name string) *ColumnStats {
+	if m == nil {
+		return nil
+	}
+	return m.Columns[strings.ToLower(name)]
+}
+
+// Stats the statistics of this table in the DefaultStatsStore, false if
+// it has not been analyzed.
+func (m *Table) Stats() (*TableStats, bool) {
+	return DefaultStatsStore.TableStats(m.SchemaName(), m.Name)
+}
+
+// SchemaName name of the schema of this table, empty if it doesn't belong
+// to one.
+func (m *Table) SchemaName() string {
+	if m.Schema == nil {
+		return ""
+	}
+	return m.Schema.Name
+}
@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+
+	"github.com/araddon/qlbridge/value"
+)
+
+// Source Settings for how the time columns of a source are stored, applied
+// when the source is scanned so times compare, join correctly across
+// sources.  The time_zone is the location of local-time (no zone) strings,
+// the time_unit the unit of numeric epoch times.  They apply to the columns
+// of type time, time_columns declares them per column (or table.column)
+// and makes the column a time column whatever its type.
+//
+//	{"name": "events", "type": "sqlite", "settings": {
+//	    "time_zone": "America/Denver",
+//	    "time_columns": {"created": {"time_unit": "ms"}, "logins.ts": {"time_unit": "s"}}
+//	}}
+const (
+	SettingTimeZone    = "time_zone"
+	SettingTimeUnit    = "time_unit"
+	SettingTimeColumns = "time_columns"
+)
+
+// TimeFormat how a source stores a time column.
+type TimeFormat struct {
+	Loc  *time.Location // of local-time strings, UTC if nil
+	Unit time.Duration  // of numeric epoch times, 0 for seconds
+}
+
+// NewTimeFormat parse a time_zone (IANA name) and time_unit (s, ms, us, ns).
+func NewTimeFormat(zone, unit string) (*TimeFormat, error) {
+	tf := &TimeFormat{}
+	if zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("QLBridge.schema: invalid %s %q: %v", SettingTimeZone, zone, err)
+		}
+		tf.Loc = loc
+	}
+	switch strings.ToLower(unit) {
+	case "", "s", "sec", "seconds":
+		tf.Unit = time.Second
+	case "ms", "millis", "milliseconds":
+		tf.Unit = time.Millisecond
+	case "us", "micros", "microseconds":
+		tf.Unit = time.Microsecond
+	case "ns", "nanos", "nanoseconds":
+		tf.Unit = time.Nanosecond
+	default:
+		return nil, fmt.Errorf("QLBridge.schema: invalid %s %q, expected s, ms, us, ns", SettingTimeUnit, unit)
+	}
+	return tf, nil
+}
+
+// Convert a stored value to a UTC time, false if it isn't a time.
+func (m *TimeFormat) Convert(v driver.Value) (time.Time, bool) {
+	switch vt := v.(type) {
+	case time.Time:
+		if m.Loc != nil && vt.Location() == time.UTC {
+			// zone-less times read as UTC are wall clock times in Loc
+			vt = time.Date(vt.Year(), vt.Month(), vt.Day(), vt.Hour(), vt.Minute(),
+				vt.Second(), vt.Nanosecond(), m.Loc)
+		}
+		return vt.UTC(), true
+	case int64:
+		return m.epoch(float64(vt), vt), true
+	case int:
+		return m.epoch(float64(vt), int64(vt)), true
+	case float64:
+		if math.IsNaN(vt) || math.IsInf(vt, 0) {
+			return time.Time{}, false
+		}
+		return m.epoch(vt, 0), true
+	case []byte:
+		return m.Convert(string(vt))
+	case string:
+		s := strings.TrimSpace(vt)
+		if s == "" {
+			return time.Time{}, false
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return m.epoch(float64(n), n), true
+		}
+		loc := m.Loc
+		if loc == nil {
+			loc = time.UTC
+		}
+		t, err := dateparse.ParseIn(s, loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+func (m *TimeFormat) epoch(f float64, n int64) time.Time {
+	unit := m.Unit
+	if unit == 0 {
+		unit = time.Second
+	}
+	if float64(n) == f {
+		return time.Unix(0, 0).Add(time.Duration(n) * unit).UTC()
+	}
+	return time.Unix(0, int64(f*float64(unit))).UTC()
+}
+
+// TimeFormats the time columns of the table in the source settings and how
+// they are stored, nil if the source has no time settings.
+func (m *Schema) TimeFormats(tbl *Table) (map[string]*TimeFormat, error) {
+	if m == nil || m.Conf == nil || m.Conf.Settings == nil || tbl == nil {
+		return nil, nil
+	}
+	conf := m.Conf.Settings
+	zone, _ := conf.StringSafe(SettingTimeZone)
+	unit, _ := conf.StringSafe(SettingTimeUnit)
+	cols := conf.Helper(SettingTimeColumns)
+	if zone == "" && unit == "" && len(cols) == 0 {
+		return nil, nil
+	}
+
+	fields := tbl.FieldList()
+	formats := make(map[string]*TimeFormat)
+	if zone != "" || unit != "" {
+		tf, err := NewTimeFormat(zone, unit)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if f.ValueType() == value.TimeType {
+				formats[f.Name] = tf
+			}
+		}
+	}
+	// column keys, then table.column keys which take precedence
+	prefix := strings.ToLower(tbl.Name) + "."
+	for _, qualified := range []bool{false, true} {
+		for _, key := range cols.Keys() {
+			col := strings.ToLower(key)
+			if strings.Contains(col, ".") != qualified {
+				continue
+			}
+			if qualified {
+				if !strings.HasPrefix(col, prefix) {
+					continue
+				}
+				col = col[len(prefix):]
+			}
+			cc := cols.Helper(key)
+			czone, ok := cc.StringSafe(SettingTimeZone)
+			if !ok {
+				czone = zone
+			}
+			cunit, ok := cc.StringSafe(SettingTimeUnit)
+			if !ok {
+				cunit = unit
+			}
+			tf, err := NewTimeFormat(czone, cunit)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range fields {
+				if strings.ToLower(f.Name) == col {
+					col = f.Name
+					break
+				}
+			}
+			formats[col] = tf
+		}
+	}
+	return formats, nil
+}
@@ -0,0 +1,207 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// AnalyzerContextKey the Field Context key naming the tokenizer of a
+	// full-text field.
+	AnalyzerContextKey = "analyzer"
+	// DefaultAnalyzer tokenizer of fields without an analyzer.
+	DefaultAnalyzer = "standard"
+)
+
+var (
+	tokenizerMu sync.RWMutex
+	tokenizers  = map[string]Tokenizer{
+		"standard":   TokenizerFunc(StandardTokenize),
+		"keyword":    TokenizerFunc(KeywordTokenize),
+		"whitespace": TokenizerFunc(strings.Fields),
+		"ngram":      NewNGramTokenizer(3, 3),
+		"cjk":        TokenizerFunc(CJKTokenize),
+	}
+)
+
+type (
+	// Tokenizer splits text into the terms of a full-text index, text
+	// that is indexed and text that is searched for must be tokenized by
+	// the same Tokenizer.
+	Tokenizer interface {
+		Tokenize(text string) []string
+	}
+	// TokenizerFunc a func as a Tokenizer.
+	TokenizerFunc func(text string) []string
+	// SourceTokenizers is an optional interface for sources with their own
+	// full-text index, when a table is added they are pushed the tokenizers
+	// of its analyzed fields (those with an analyzer context), so they may
+	// map the analyzer to a native one, or tokenize text as the engine does.
+	SourceTokenizers interface {
+		SetTokenizers(table string, fields map[string]Tokenizer) error
+	}
+)
+
+// Tokenize the text.
+func (f TokenizerFunc) Tokenize(text string) []string { return f(text) }
+
+// RegisterTokenizer register a named tokenizer for fields with that
+// analyzer, replacing any existing one of that name.
+//
+//    schema.RegisterTokenizer("bigram", schema.NewNGramTokenizer(2, 2))
+//    field.AddContext(schema.AnalyzerContextKey, "bigram")
+func RegisterTokenizer(name string, t Tokenizer) {
+	tokenizerMu.Lock()
+	defer tokenizerMu.Unlock()
+	tokenizers[strings.ToLower(name)] = t
+}
+
+// TokenizerGet get a tokenizer by name.
+func TokenizerGet(name string) (Tokenizer, bool) {
+	tokenizerMu.RLock()
+	defer tokenizerMu.RUnlock()
+	t, ok := tokenizers[strings.ToLower(name)]
+	return t, ok
+}
+
+// TokenizerNames the names of the registered tokenizers.
+func TokenizerNames() []string {
+	tokenizerMu.RLock()
+	defer tokenizerMu.RUnlock()
+	names := make([]string, 0, len(tokenizers))
+	for name := range tokenizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Analyzer name of the tokenizer of this field, DefaultAnalyzer if none.
+func (m *Field) Analyzer() string {
+	if name, ok := m.Context[AnalyzerContextKey].(string); ok && name != "" {
+		return name
+	}
+	return DefaultAnalyzer
+}
+
+// Tokenizer of this field, error if its analyzer is not registered.
+func (m *Field) Tokenizer() (Tokenizer, error) {
+	t, ok := TokenizerGet(m.Analyzer())
+	if !ok {
+		return nil, fmt.Errorf("unknown analyzer %q for field %q", m.Analyzer(), m.Name)
+	}
+	return t, nil
+}
+
+// FieldTokenizers the tokenizers of the fields with an analyzer context.
+func (m *Table) FieldTokenizers() (map[string]Tokenizer, error) {
+	fields := make(map[string]Tokenizer)
+	for _, f := range m.FieldList() {
+		if _, ok := f.Context[AnalyzerContextKey]; !ok {
+			continue
+		}
+		t, err := f.Tokenizer()
+		if err != nil {
+			return nil, err
+		}
+		fields[f.Name] = t
+	}
+	return fields, nil
+}
+
+// StandardTokenize split text on anything but letters and digits into
+// lower cased terms.
+//
+//    "Hello, World-2" => ["hello","world","2"]
+func StandardTokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// KeywordTokenize the whole text as a single term.
+func KeywordTokenize(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return []string{text}
+}
+
+// NewNGramTokenizer a tokenizer of the character n-grams, of min to max
+// length, of each standard term.  Terms shorter than min are kept whole.
+//
+//    NewNGramTokenizer(3, 3)  "search" => ["sea","ear","arc","rch"]
+func NewNGramTokenizer(min, max int) Tokenizer {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return TokenizerFunc(func(text string) []string {
+		var terms []string
+		for _, term := range StandardTokenize(text) {
+			rs := []rune(term)
+			if len(rs) < min {
+				terms = append(terms, term)
+				continue
+			}
+			for n := min; n <= max && n <= len(rs); n++ {
+				for i := 0; i+n <= len(rs); i++ {
+					terms = append(terms, string(rs[i:i+n]))
+				}
+			}
+		}
+		return terms
+	})
+}
+
+// CJKTokenize standard tokenize, except runs of Chinese, Japanese, Korean
+// characters (which are not space separated) are split into overlapping
+// bigrams.
+//
+//    "東京都 tokyo" => ["東京","京都","tokyo"]
+func CJKTokenize(text string) []string {
+	var terms []string
+	for _, term := range StandardTokenize(text) {
+		var run []rune
+		flush := func() {
+			if len(run) == 1 {
+				terms = append(terms, string(run))
+			}
+			for i := 0; i+2 <= len(run); i++ {
+				terms = append(terms, string(run[i:i+2]))
+			}
+			run = run[:0]
+		}
+		start := 0
+		rs := []rune(term)
+		for i, r := range rs {
+			if isCJK(r) {
+				if i > start {
+					terms = append(terms, string(rs[start:i]))
+				}
+				run = append(run, r)
+				start = i + 1
+				continue
+			}
+			if len(run) > 0 {
+				flush()
+			}
+		}
+		if len(run) > 0 {
+			flush()
+		}
+		if start < len(rs) {
+			terms = append(terms, string(rs[start:]))
+		}
+	}
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,92 @@
+package flightsql
+
+import (
+	"database/sql/driver"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/araddon/qlbridge/exec/columnar"
+)
+
+// Client minimal Flight SQL client, decodes arrow results into rows.
+type Client struct {
+	FlightServiceClient
+}
+
+// Rows decoded result of a Flight SQL command.
+type Rows struct {
+	Columns []string
+	Rows    [][]driver.Value
+}
+
+// NewClient create a Flight SQL client on grpc connection.
+func NewClient(cc *grpc.ClientConn) *Client {
+	return &Client{FlightServiceClient: NewFlightServiceClient(cc)}
+}
+
+// Query run sql statement and fetch all results.
+func (m *Client) Query(ctx context.Context, sql string) (*Rows, error) {
+	return m.Execute(ctx, &CommandStatementQuery{Query: sql})
+}
+
+// Execute a Flight SQL command (CommandGetTables, CommandStatementQuery
+// etc), fetching all results of its endpoints.
+func (m *Client) Execute(ctx context.Context, cmd proto.Message, opts ...grpc.CallOption) (*Rows, error) {
+	by, err := packCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	info, err := m.GetFlightInfo(ctx, &FlightDescriptor{Type: FlightDescriptor_CMD, Cmd: by}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res := &Rows{}
+	fields, err := columnar.ReadSchema(info.Schema)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		res.Columns = append(res.Columns, f.Name)
+	}
+	for _, ep := range info.Endpoint {
+		rows, err := m.Fetch(ctx, ep.Ticket, opts...)
+		if err != nil {
+			return nil, err
+		}
+		res.Rows = append(res.Rows, rows...)
+	}
+	return res, nil
+}
+
+// Fetch DoGet the ticket and decode its record batches.
+func (m *Client) Fetch(ctx context.Context, ticket *Ticket, opts ...grpc.CallOption) ([][]driver.Value, error) {
+	stream, err := m.DoGet(ctx, ticket, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var fields []*columnar.Field
+	var rows [][]driver.Value
+	for {
+		fd, err := stream.Recv()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			if fields, err = columnar.ReadSchema(fd.DataHeader); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		batch, err := columnar.ReadRecordBatch(fields, fd.DataHeader, fd.DataBody)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, batch...)
+	}
+}
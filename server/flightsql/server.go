@@ -0,0 +1,481 @@
+// Package flightsql is an Apache Arrow Flight SQL service exposing the
+// qlbridge virtual schemas, catalog queries (schemas, tables, table types)
+// and sql statements with results streamed as Arrow record batches.
+//
+// Supports the Flight SQL catalog commands and CommandStatementQuery,
+// prepared statements, updates and transactions are not supported.  The
+// schema to run statements against is the "schema" request header, or
+// the Server default.
+package flightsql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net"
+	"sort"
+	"strings"
+
+	u "github.com/araddon/gou"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/exec/columnar"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// Ensure we implement the generated service interface
+	_ FlightServiceServer = (*Server)(nil)
+
+	// DefaultBatchSize is the default number of rows per record batch
+	DefaultBatchSize = 1024
+)
+
+const (
+	// SchemaHeader is the grpc metadata key for the schema to query.
+	SchemaHeader = "schema"
+
+	tableType = "TABLE"
+)
+
+// Result schemas of the catalog commands, defined by Flight SQL.
+var (
+	dbSchemasFields = []*columnar.Field{
+		{Name: "catalog_name", Type: columnar.ArrowUtf8, Nullable: true},
+		{Name: "db_schema_name", Type: columnar.ArrowUtf8},
+	}
+	catalogsFields   = []*columnar.Field{{Name: "catalog_name", Type: columnar.ArrowUtf8}}
+	tableTypesFields = []*columnar.Field{{Name: "table_type", Type: columnar.ArrowUtf8}}
+)
+
+func tablesFields(includeSchema bool) []*columnar.Field {
+	fields := []*columnar.Field{
+		{Name: "catalog_name", Type: columnar.ArrowUtf8, Nullable: true},
+		{Name: "db_schema_name", Type: columnar.ArrowUtf8, Nullable: true},
+		{Name: "table_name", Type: columnar.ArrowUtf8},
+		{Name: "table_type", Type: columnar.ArrowUtf8},
+	}
+	if includeSchema {
+		fields = append(fields, &columnar.Field{Name: "table_schema", Type: columnar.ArrowBinary})
+	}
+	return fields
+}
+
+// Server is the Flight SQL service, queries run through the qlbridge
+// plan/exec against schemas in the registry.
+//
+//	srv := flightsql.NewServer()
+//	srv.Schema = "mydb"
+//	gs := grpc.NewServer()
+//	flightsql.RegisterFlightServiceServer(gs, srv)
+//	gs.Serve(listener)
+type Server struct {
+	// Schema default schema name if request doesn't have SchemaHeader
+	Schema string
+	// BatchSize max rows per record batch
+	BatchSize int
+}
+
+// NewServer create a new Flight SQL service.
+func NewServer() *Server {
+	return &Server{BatchSize: DefaultBatchSize}
+}
+
+// ListenAndServe convenience to create a grpc server with this Flight
+// service registered and serve on given tcp address.
+func ListenAndServe(address, schemaName string, opts ...grpc.ServerOption) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	srv := NewServer()
+	srv.Schema = schemaName
+	gs := grpc.NewServer(opts...)
+	RegisterFlightServiceServer(gs, srv)
+	return gs.Serve(lis)
+}
+
+// statementHandle is the opaque handle in TicketStatementQuery
+type statementHandle struct {
+	Schema string `json:"schema"`
+	Sql    string `json:"sql"`
+}
+
+// Handshake no authentication, echo the client payload.
+func (m *Server) Handshake(stream FlightService_HandshakeServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = stream.Send(&HandshakeResponse{ProtocolVersion: req.ProtocolVersion, Payload: req.Payload}); err != nil {
+			return err
+		}
+	}
+}
+
+// GetFlightInfo for a Flight SQL command, the result schema and ticket
+// to DoGet the results with.
+func (m *Server) GetFlightInfo(ctx context.Context, desc *FlightDescriptor) (*FlightInfo, error) {
+	cmd, err := unpackCommand(desc)
+	if err != nil {
+		return nil, err
+	}
+	fields, ticket, err := m.prepare(ctx, cmd, desc.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &FlightInfo{
+		Schema:           columnar.Encapsulate(columnar.SchemaMessage(fields)),
+		FlightDescriptor: desc,
+		Endpoint:         []*FlightEndpoint{{Ticket: &Ticket{Ticket: ticket}}},
+		TotalRecords:     -1,
+		TotalBytes:       -1,
+	}, nil
+}
+
+// GetSchema the result schema of a Flight SQL command.
+func (m *Server) GetSchema(ctx context.Context, desc *FlightDescriptor) (*SchemaResult, error) {
+	cmd, err := unpackCommand(desc)
+	if err != nil {
+		return nil, err
+	}
+	fields, _, err := m.prepare(ctx, cmd, desc.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaResult{Schema: columnar.Encapsulate(columnar.SchemaMessage(fields))}, nil
+}
+
+// prepare result fields, and ticket for command.  Catalog commands
+// use the command itself as ticket.
+func (m *Server) prepare(ctx context.Context, cmd proto.Message, raw []byte) ([]*columnar.Field, []byte, error) {
+	switch cmd := cmd.(type) {
+	case *CommandGetCatalogs:
+		return catalogsFields, raw, nil
+	case *CommandGetDbSchemas:
+		return dbSchemasFields, raw, nil
+	case *CommandGetTables:
+		return tablesFields(cmd.IncludeSchema), raw, nil
+	case *CommandGetTableTypes:
+		return tableTypesFields, raw, nil
+	case *CommandStatementQuery:
+		h := &statementHandle{Schema: m.schemaName(ctx), Sql: cmd.Query}
+		job, sel, err := m.plan(h)
+		if err != nil {
+			return nil, nil, err
+		}
+		job.Close()
+		handle, _ := json.Marshal(h)
+		ticket, err := packCommand(&TicketStatementQuery{StatementHandle: handle})
+		if err != nil {
+			return nil, nil, err
+		}
+		return exec.ResultFields(job.Ctx, sel), ticket, nil
+	}
+	return nil, nil, status.Errorf(codes.Unimplemented, "flightsql: command %T not supported", cmd)
+}
+
+// DoGet stream the results for a ticket from GetFlightInfo.
+func (m *Server) DoGet(ticket *Ticket, stream FlightService_DoGetServer) error {
+	a := &any.Any{}
+	if err := proto.Unmarshal(ticket.Ticket, a); err != nil {
+		return status.Errorf(codes.InvalidArgument, "flightsql: invalid ticket: %v", err)
+	}
+	cmd, err := unpackAny(a)
+	if err != nil {
+		return err
+	}
+	switch cmd := cmd.(type) {
+	case *TicketStatementQuery:
+		h := &statementHandle{}
+		if err := json.Unmarshal(cmd.StatementHandle, h); err != nil {
+			return status.Errorf(codes.InvalidArgument, "flightsql: invalid statement handle: %v", err)
+		}
+		return m.query(h, stream)
+	case *CommandGetCatalogs:
+		// the virtual schemas have no catalog
+		return m.sendRows(stream, catalogsFields, nil)
+	case *CommandGetDbSchemas:
+		var rows [][]driver.Value
+		for _, name := range schemaNames(cmd.Catalog, cmd.DbSchemaFilterPattern) {
+			rows = append(rows, []driver.Value{nil, name})
+		}
+		return m.sendRows(stream, dbSchemasFields, rows)
+	case *CommandGetTables:
+		return m.sendRows(stream, tablesFields(cmd.IncludeSchema), tableRows(cmd))
+	case *CommandGetTableTypes:
+		return m.sendRows(stream, tableTypesFields, [][]driver.Value{{tableType}})
+	}
+	return status.Errorf(codes.Unimplemented, "flightsql: ticket %T not supported", cmd)
+}
+
+// ListFlights not supported, flights are defined by commands.
+func (m *Server) ListFlights(*Criteria, FlightService_ListFlightsServer) error {
+	return status.Error(codes.Unimplemented, "flightsql: ListFlights not supported")
+}
+
+// DoPut not supported, qlbridge flight is read only.
+func (m *Server) DoPut(FlightService_DoPutServer) error {
+	return status.Error(codes.Unimplemented, "flightsql: DoPut not supported")
+}
+
+// DoExchange not supported.
+func (m *Server) DoExchange(FlightService_DoExchangeServer) error {
+	return status.Error(codes.Unimplemented, "flightsql: DoExchange not supported")
+}
+
+// DoAction not supported, ie prepared statements.
+func (m *Server) DoAction(*Action, FlightService_DoActionServer) error {
+	return status.Error(codes.Unimplemented, "flightsql: DoAction not supported")
+}
+
+// ListActions there are no actions.
+func (m *Server) ListActions(*Empty, FlightService_ListActionsServer) error {
+	return nil
+}
+
+func (m *Server) schemaName(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(SchemaHeader); len(vals) > 0 && vals[0] != "" {
+			return vals[0]
+		}
+	}
+	return m.Schema
+}
+
+// plan the statement, must be a row returning statement.
+func (m *Server) plan(h *statementHandle) (*exec.JobExecutor, *rel.SqlSelect, error) {
+	if h.Schema == "" {
+		return nil, nil, status.Error(codes.FailedPrecondition, "flightsql: no schema, set the schema header")
+	}
+	s, ok := schema.DefaultRegistry().Schema(h.Schema)
+	if !ok {
+		return nil, nil, status.Errorf(codes.NotFound, "flightsql: schema %q not found", h.Schema)
+	}
+	if _, err := rel.ParseSql(h.Sql); err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "flightsql: %v", err)
+	}
+
+	ctx := plan.NewContext(h.Sql)
+	ctx.Schema = s
+	ctx.Session = datasource.NewMySqlSessionVars()
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "flightsql: %v", err)
+	}
+	sel, ok := job.Ctx.Stmt.(*rel.SqlSelect)
+	if !ok {
+		job.Close()
+		return nil, nil, status.Errorf(codes.InvalidArgument, "flightsql: only queries are supported got %T", job.Ctx.Stmt)
+	}
+	return job, sel, nil
+}
+
+// query run the statement streaming results as record batches.
+func (m *Server) query(h *statementHandle, stream FlightService_DoGetServer) error {
+
+	job, sel, err := m.plan(h)
+	if err != nil {
+		return err
+	}
+	defer job.Close()
+
+	fields := exec.ResultFields(job.Ctx, sel)
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	rows := exec.NewResultRows(job.Ctx, names)
+	job.RootTask.Add(rows)
+	job.Setup()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- job.Run()
+	}()
+
+	err = m.send(stream, fields, func(dest []driver.Value) error {
+		return rows.Next(dest)
+	})
+	// result writer runs until signaled to stop
+	job.Close()
+	if rerr := <-runErr; rerr != nil && err == nil {
+		err = rerr
+	}
+	if err != nil {
+		u.Warnf("error running %q err=%v", h.Sql, err)
+		return status.Errorf(codes.Internal, "flightsql: %v", err)
+	}
+	return nil
+}
+
+func (m *Server) sendRows(stream FlightService_DoGetServer, fields []*columnar.Field, rows [][]driver.Value) error {
+	return m.send(stream, fields, func(dest []driver.Value) error {
+		if len(rows) == 0 {
+			return io.EOF
+		}
+		copy(dest, rows[0])
+		rows = rows[1:]
+		return nil
+	})
+}
+
+// send the schema, then record batches of rows read from next until io.EOF.
+func (m *Server) send(stream FlightService_DoGetServer, fields []*columnar.Field, next func([]driver.Value) error) error {
+	if err := stream.Send(&FlightData{DataHeader: columnar.SchemaMessage(fields)}); err != nil {
+		return err
+	}
+	batchSize := m.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	var batch [][]driver.Value
+	flush := func() error {
+		header, body := columnar.RecordBatch(fields, batch)
+		batch = batch[:0]
+		return stream.Send(&FlightData{DataHeader: header, DataBody: body})
+	}
+	for {
+		dest := make([]driver.Value, len(fields))
+		err := next(dest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch = append(batch, dest)
+		if len(batch) >= batchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// schemaNames registry schemas matching sql LIKE pattern, the virtual
+// schemas have no catalog so any catalog filter matches nothing.
+func schemaNames(catalog, pattern string) []string {
+	if catalog != "" {
+		return nil
+	}
+	var names []string
+	for _, name := range schema.DefaultRegistry().Schemas() {
+		if like(name, pattern) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tableRows(cmd *CommandGetTables) [][]driver.Value {
+	if len(cmd.TableTypes) > 0 {
+		found := false
+		for _, tt := range cmd.TableTypes {
+			if strings.EqualFold(tt, tableType) {
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	var rows [][]driver.Value
+	for _, sn := range schemaNames(cmd.Catalog, cmd.DbSchemaFilterPattern) {
+		s, ok := schema.DefaultRegistry().Schema(sn)
+		if !ok {
+			continue
+		}
+		for _, tn := range s.Tables() {
+			if !like(tn, cmd.TableNameFilterPattern) {
+				continue
+			}
+			row := []driver.Value{nil, sn, tn, tableType}
+			if cmd.IncludeSchema {
+				var fields []*columnar.Field
+				if tbl, err := s.Table(tn); err == nil {
+					for _, f := range tbl.FieldList() {
+						fields = append(fields, &columnar.Field{Name: f.Name, Type: columnar.ArrowTypeOf(f.ValueType()), Nullable: !f.NoNulls})
+					}
+				}
+				row = append(row, columnar.Encapsulate(columnar.SchemaMessage(fields)))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// like sql LIKE pattern match, empty pattern matches all.
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	match, ok := vm.LikeCompare(s, pattern)
+	return ok && match.Val()
+}
+
+func unpackCommand(desc *FlightDescriptor) (proto.Message, error) {
+	if desc == nil || desc.Type != FlightDescriptor_CMD {
+		return nil, status.Error(codes.InvalidArgument, "flightsql: descriptor must be a command")
+	}
+	a := &any.Any{}
+	if err := proto.Unmarshal(desc.Cmd, a); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "flightsql: invalid command: %v", err)
+	}
+	return unpackAny(a)
+}
+
+func unpackAny(a *any.Any) (proto.Message, error) {
+	var cmd proto.Message
+	switch a.TypeUrl[strings.LastIndex(a.TypeUrl, "/")+1:] {
+	case "arrow.flight.protocol.sql.CommandGetCatalogs":
+		cmd = &CommandGetCatalogs{}
+	case "arrow.flight.protocol.sql.CommandGetDbSchemas":
+		cmd = &CommandGetDbSchemas{}
+	case "arrow.flight.protocol.sql.CommandGetTables":
+		cmd = &CommandGetTables{}
+	case "arrow.flight.protocol.sql.CommandGetTableTypes":
+		cmd = &CommandGetTableTypes{}
+	case "arrow.flight.protocol.sql.CommandStatementQuery":
+		cmd = &CommandStatementQuery{}
+	case "arrow.flight.protocol.sql.TicketStatementQuery":
+		cmd = &TicketStatementQuery{}
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "flightsql: command %q not supported", a.TypeUrl)
+	}
+	if err := proto.Unmarshal(a.Value, cmd); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "flightsql: invalid command: %v", err)
+	}
+	return cmd, nil
+}
+
+// packCommand serialize command as an Any, as used in descriptors and tickets.
+func packCommand(cmd proto.Message) ([]byte, error) {
+	a, err := ptypes.MarshalAny(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(a)
+}
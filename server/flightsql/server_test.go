@@ -0,0 +1,116 @@
+package flightsql_test
+
+import (
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/araddon/qlbridge/server/flightsql"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+func startServer(t *testing.T, srv *flightsql.Server) (*flightsql.Client, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	gs := grpc.NewServer()
+	flightsql.RegisterFlightServiceServer(gs, srv)
+	go gs.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Equal(t, nil, err)
+	return flightsql.NewClient(conn), func() {
+		conn.Close()
+		gs.Stop()
+	}
+}
+
+func TestFlightQuery(t *testing.T) {
+	srv := flightsql.NewServer()
+	srv.Schema = "mockcsv"
+	srv.BatchSize = 2
+	client, stop := startServer(t, srv)
+	defer stop()
+
+	res, err := client.Query(context.Background(), "SELECT user_id, email, referral_count FROM users")
+	assert.Equal(t, nil, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, []string{"user_id", "email", "referral_count"}, res.Columns)
+	assert.Equal(t, 3, len(res.Rows))
+	emails := make([]string, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		emails = append(emails, row[1].(string))
+		_, isInt := row[2].(int64)
+		assert.True(t, isInt, "%T", row[2])
+	}
+	sort.Strings(emails)
+	assert.Equal(t, []string{"aaron@email.com", "bob@email.com", "not_an_email_2"}, emails)
+
+	// schema from request header
+	srv.Schema = ""
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(flightsql.SchemaHeader, "mockcsv"))
+	res, err = client.Query(ctx, "SELECT email FROM users WHERE user_id = \"hT2impsOPUREcVPc\"")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(res.Rows))
+
+	_, err = client.Query(context.Background(), "SELECT email FROM users")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.Query(ctx, "SELECTX email FROM users")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFlightCatalog(t *testing.T) {
+	client, stop := startServer(t, flightsql.NewServer())
+	defer stop()
+	ctx := context.Background()
+
+	res, err := client.Execute(ctx, &flightsql.CommandGetDbSchemas{DbSchemaFilterPattern: "mock%"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"catalog_name", "db_schema_name"}, res.Columns)
+	found := false
+	for _, row := range res.Rows {
+		if row[1] == "mockcsv" {
+			found = true
+		}
+	}
+	assert.True(t, found, "%v", res.Rows)
+
+	res, err = client.Execute(ctx, &flightsql.CommandGetTables{
+		DbSchemaFilterPattern:  "mockcsv",
+		TableNameFilterPattern: "users",
+		IncludeSchema:          true,
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, len(res.Columns))
+	assert.Equal(t, 1, len(res.Rows))
+	if len(res.Rows) == 1 {
+		assert.Equal(t, "users", res.Rows[0][2])
+		assert.Equal(t, "TABLE", res.Rows[0][3])
+		assert.NotEqual(t, 0, len(res.Rows[0][4].([]byte)))
+	}
+
+	res, err = client.Execute(ctx, &flightsql.CommandGetTableTypes{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(res.Rows))
+
+	res, err = client.Execute(ctx, &flightsql.CommandGetCatalogs{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(res.Rows))
+
+	// not a flight sql command
+	_, err = client.Execute(ctx, &flightsql.Criteria{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
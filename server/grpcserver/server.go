@@ -0,0 +1,199 @@
+package grpcserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	u "github.com/araddon/gou"
+	"google.golang.org/grpc"
+
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// Ensure we implement the generated service interface
+	_ QueryServer = (*Server)(nil)
+
+	// DefaultBatchSize is the default number of rows per streamed response
+	DefaultBatchSize = 100
+)
+
+// Server is a gRPC Query service that runs sql statements through the
+// qlbridge plan/exec against schemas in the registry, and streams the
+// result rows back in batches.
+//
+//    srv := grpcserver.NewServer()
+//    gs := grpc.NewServer()
+//    grpcserver.RegisterQueryServer(gs, srv)
+//    gs.Serve(listener)
+//
+type Server struct {
+	// BatchSize max rows per response if the request doesn't specify one
+	BatchSize int
+}
+
+// NewServer create a new Query service.
+func NewServer() *Server {
+	exec.RegisterSqlDriver()
+	return &Server{BatchSize: DefaultBatchSize}
+}
+
+// ListenAndServe convenience to create a grpc server with this Query
+// service registered and serve on given tcp address.
+func ListenAndServe(address string, opts ...grpc.ServerOption) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer(opts...)
+	RegisterQueryServer(gs, NewServer())
+	return gs.Serve(lis)
+}
+
+// Query run the sql statement, streaming result rows back to client.
+func (m *Server) Query(req *QueryRequest, stream Query_QueryServer) error {
+
+	stmt, err := rel.ParseSql(req.Sql)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("qlbridge", req.Schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch stmt.(type) {
+	case *rel.SqlSelect, *rel.SqlShow, *rel.SqlDescribe:
+		// these return rows
+	default:
+		result, err := db.ExecContext(stream.Context(), req.Sql)
+		if err != nil {
+			return err
+		}
+		affected, _ := result.RowsAffected()
+		return stream.Send(&QueryResponse{RowsAffected: affected})
+	}
+
+	rows, err := db.QueryContext(stream.Context(), req.Sql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	batchSize := int(req.BatchSize)
+	if batchSize <= 0 {
+		batchSize = m.BatchSize
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	resp := &QueryResponse{Columns: cols}
+	vals := make([]interface{}, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+		row := &RowPb{Values: make([]*ValuePb, len(vals))}
+		for i, v := range vals {
+			row.Values[i] = ValueToPb(v)
+		}
+		resp.Rows = append(resp.Rows, row)
+		if len(resp.Rows) >= batchSize {
+			if err = stream.Send(resp); err != nil {
+				return err
+			}
+			resp = &QueryResponse{}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		u.Warnf("error reading rows %v", err)
+		return err
+	}
+	// always send the last batch, even if empty so that
+	// we get columns on empty result
+	if len(resp.Rows) > 0 || len(resp.Columns) > 0 {
+		return stream.Send(resp)
+	}
+	return nil
+}
+
+// ValueToPb convert a go/driver value into a protobuf value.
+func ValueToPb(v interface{}) *ValuePb {
+	switch vt := v.(type) {
+	case nil:
+		return &ValuePb{Type: int32(value.NilType)}
+	case string:
+		return &ValuePb{Type: int32(value.StringType), Str: vt}
+	case []byte:
+		return &ValuePb{Type: int32(value.ByteSliceType), Bytes: vt}
+	case int:
+		return &ValuePb{Type: int32(value.IntType), Int: int64(vt)}
+	case int32:
+		return &ValuePb{Type: int32(value.IntType), Int: int64(vt)}
+	case int64:
+		return &ValuePb{Type: int32(value.IntType), Int: vt}
+	case float32:
+		return &ValuePb{Type: int32(value.NumberType), Num: float64(vt)}
+	case float64:
+		return &ValuePb{Type: int32(value.NumberType), Num: vt}
+	case bool:
+		return &ValuePb{Type: int32(value.BoolType), Bool: vt}
+	case time.Time:
+		return &ValuePb{Type: int32(value.TimeType), Int: vt.UnixNano()}
+	}
+	// everything else (slices, maps) we send as json
+	val := value.NewValue(v)
+	by, err := json.Marshal(val.Value())
+	if err != nil {
+		return &ValuePb{Type: int32(value.StringType), Str: fmt.Sprintf("%v", v)}
+	}
+	return &ValuePb{Type: int32(value.JsonType), Bytes: by}
+}
+
+// Value convert protobuf value back to a go value, json types are
+// returned as json.RawMessage.
+func (m *ValuePb) Value() interface{} {
+	switch value.ValueType(m.Type) {
+	case value.NilType:
+		return nil
+	case value.StringType:
+		return m.Str
+	case value.ByteSliceType:
+		return m.Bytes
+	case value.IntType:
+		return m.Int
+	case value.NumberType:
+		return m.Num
+	case value.BoolType:
+		return m.Bool
+	case value.TimeType:
+		return time.Unix(0, m.Int).In(time.UTC)
+	}
+	return json.RawMessage(m.Bytes)
+}
+
+// Vals of this row as go values.
+func (m *RowPb) Vals() []interface{} {
+	vals := make([]interface{}, len(m.Values))
+	for i, v := range m.Values {
+		vals[i] = v.Value()
+	}
+	return vals
+}
@@ -0,0 +1,97 @@
+package grpcserver_test
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/araddon/qlbridge/server/grpcserver"
+	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+func startServer(t *testing.T) (grpcserver.QueryClient, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	gs := grpc.NewServer()
+	grpcserver.RegisterQueryServer(gs, grpcserver.NewServer())
+	go gs.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Equal(t, nil, err)
+	return grpcserver.NewQueryClient(conn), func() {
+		conn.Close()
+		gs.Stop()
+	}
+}
+
+func TestQueryStream(t *testing.T) {
+	client, stop := startServer(t)
+	defer stop()
+
+	stream, err := client.Query(context.Background(), &grpcserver.QueryRequest{
+		Schema:    "mockcsv",
+		Sql:       "SELECT user_id, email, referral_count FROM users",
+		BatchSize: 2,
+	})
+	assert.Equal(t, nil, err)
+
+	var cols []string
+	var rows [][]interface{}
+	batches := 0
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Equal(t, nil, err)
+		if err != nil {
+			return
+		}
+		batches++
+		if len(resp.Columns) > 0 {
+			cols = resp.Columns
+		}
+		for _, row := range resp.Rows {
+			rows = append(rows, row.Vals())
+		}
+	}
+	assert.Equal(t, []string{"user_id", "email", "referral_count"}, cols)
+	assert.Equal(t, 2, batches)
+	assert.Equal(t, 3, len(rows))
+	emails := make(map[interface{}]interface{})
+	for _, row := range rows {
+		emails[row[0]] = row[1]
+	}
+	assert.Equal(t, "aaron@email.com", emails["9Ip1aKbeZe2njCDM"])
+	assert.Equal(t, "bob@email.com", emails["hT2impsOPUREcVPc"])
+
+	stream, err = client.Query(context.Background(), &grpcserver.QueryRequest{Schema: "not_a_schema", Sql: "SELECT * FROM users"})
+	assert.Equal(t, nil, err)
+	_, err = stream.Recv()
+	assert.NotEqual(t, nil, err)
+
+	stream, err = client.Query(context.Background(), &grpcserver.QueryRequest{Schema: "mockcsv", Sql: "SELECT * FROM"})
+	assert.Equal(t, nil, err)
+	_, err = stream.Recv()
+	assert.NotEqual(t, nil, err)
+}
+
+func TestValuePb(t *testing.T) {
+	assert.Equal(t, nil, grpcserver.ValueToPb(nil).Value())
+	assert.Equal(t, "hello", grpcserver.ValueToPb("hello").Value())
+	assert.Equal(t, int64(5), grpcserver.ValueToPb(5).Value())
+	assert.Equal(t, 5.5, grpcserver.ValueToPb(5.5).Value())
+	assert.Equal(t, true, grpcserver.ValueToPb(true).Value())
+	vpb := grpcserver.ValueToPb([]string{"a", "b"})
+	assert.Equal(t, int32(value.JsonType), vpb.Type)
+	assert.Equal(t, `["a","b"]`, string(vpb.Bytes))
+}
@@ -0,0 +1,454 @@
+// Package httpserver is an embeddable http.Handler that runs sql against
+// qlbridge schemas in the schema registry and streams the results as json,
+// ndjson or csv.
+//
+//	http.Handle("/query", httpserver.NewHandler())
+//
+//	curl 'localhost:8080/query?schema=mockcsv&sql=select+*+from+users&limit=10'
+//	curl -H 'Accept: text/csv' -d 'select * from users' 'localhost:8080/query?schema=mockcsv'
+package httpserver
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+const (
+	// FormatJSON a single json document {"columns":[], "rows":[[]]}
+	FormatJSON = "json"
+	// FormatNDJSON newline delimited json, one object per row
+	FormatNDJSON = "ndjson"
+	// FormatCSV csv with header row
+	FormatCSV = "csv"
+
+	// HeaderNextPageToken is the trailer (ndjson, csv) carrying the token
+	// for the next page of results.
+	HeaderNextPageToken = "X-Next-Page-Token"
+	// HeaderError is the trailer (csv) carrying an error that occurred after
+	// results started streaming.
+	HeaderError = "X-Error"
+)
+
+var (
+	// Ensure we implement http.Handler
+	_ http.Handler = (*Handler)(nil)
+
+	// DefaultPageSize is max rows per response if not specified.
+	DefaultPageSize = 1000
+
+	contentTypes = map[string]string{
+		FormatJSON:   "application/json",
+		FormatNDJSON: "application/x-ndjson",
+		FormatCSV:    "text/csv",
+	}
+)
+
+// Handler runs sql from GET (sql, schema query params) or POST (form,
+// json {"sql":"", "schema":""}, or raw sql body) requests.
+//
+// Output format is chosen by the format query param, else by the Accept
+// header, defaulting to json.  Results are paged, limit query param is the
+// page size and page_token the token returned from previous page.
+type Handler struct {
+	// Schema default schema if request does not name one
+	Schema string
+	// PageSize default (and max) rows per response
+	PageSize int
+}
+
+// NewHandler create a new sql query handler.
+func NewHandler() *Handler {
+	exec.RegisterSqlDriver()
+	return &Handler{PageSize: DefaultPageSize}
+}
+
+// request is the sql request, from query params, form or json body.
+type request struct {
+	Sql       string `json:"sql"`
+	Schema    string `json:"schema"`
+	Limit     int    `json:"limit"`
+	PageToken string `json:"page_token"`
+	Format    string `json:"format"`
+}
+
+// pageToken is the (opaque, base64 json) position of next page.
+type pageToken struct {
+	Offset int    `json:"o"`
+	Hash   uint64 `json:"h"`
+}
+
+// httpError is an error with the http status to respond with.
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (m *httpError) Error() string { return m.msg }
+
+func newHTTPError(status int, format string, args ...interface{}) *httpError {
+	return &httpError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP implements http.Handler
+func (m *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != "GET" && r.Method != "POST" {
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, FormatJSON, newHTTPError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		return
+	}
+
+	req, err := m.parseRequest(r)
+	format := negotiateFormat(r, req)
+	if err != nil {
+		writeError(w, format, err)
+		return
+	}
+	if err = m.run(w, r, req, format); err != nil {
+		writeError(w, format, err)
+	}
+}
+
+func (m *Handler) parseRequest(r *http.Request) (*request, error) {
+
+	q := r.URL.Query()
+	req := &request{
+		Sql:       q.Get("sql"),
+		Schema:    q.Get("schema"),
+		PageToken: q.Get("page_token"),
+		Format:    q.Get("format"),
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return req, newHTTPError(http.StatusBadRequest, "invalid limit %q", limit)
+		}
+		req.Limit = n
+	}
+
+	if r.Method == "POST" {
+		ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch ct {
+		case "application/x-www-form-urlencoded", "multipart/form-data":
+			if err := r.ParseForm(); err != nil {
+				return req, newHTTPError(http.StatusBadRequest, "invalid form %v", err)
+			}
+			if v := r.PostForm.Get("sql"); v != "" {
+				req.Sql = v
+			}
+			if v := r.PostForm.Get("schema"); v != "" {
+				req.Schema = v
+			}
+		case "application/json":
+			body := &request{}
+			if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+				return req, newHTTPError(http.StatusBadRequest, "invalid json body %v", err)
+			}
+			if body.Sql != "" {
+				req.Sql = body.Sql
+			}
+			if body.Schema != "" {
+				req.Schema = body.Schema
+			}
+			if body.Limit > 0 {
+				req.Limit = body.Limit
+			}
+			if body.PageToken != "" {
+				req.PageToken = body.PageToken
+			}
+			if body.Format != "" {
+				req.Format = body.Format
+			}
+		default:
+			by, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				return req, newHTTPError(http.StatusBadRequest, "could not read body %v", err)
+			}
+			if len(by) > 0 {
+				req.Sql = string(by)
+			}
+		}
+	}
+
+	req.Sql = strings.TrimSpace(req.Sql)
+	if req.Sql == "" {
+		return req, newHTTPError(http.StatusBadRequest, "sql is required")
+	}
+	if req.Schema == "" {
+		req.Schema = m.Schema
+	}
+	if req.Schema == "" {
+		return req, newHTTPError(http.StatusBadRequest, "schema is required")
+	}
+	if req.Format != "" {
+		if _, ok := contentTypes[req.Format]; !ok {
+			return req, newHTTPError(http.StatusBadRequest, "unrecognized format %q expected json, ndjson, csv", req.Format)
+		}
+	}
+	return req, nil
+}
+
+// negotiateFormat from explicit format, else Accept header.
+func negotiateFormat(r *http.Request, req *request) string {
+	if req != nil && req.Format != "" {
+		if _, ok := contentTypes[req.Format]; ok {
+			return req.Format
+		}
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mt {
+		case "application/json", "application/*", "*/*":
+			return FormatJSON
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return FormatNDJSON
+		case "text/csv", "text/*":
+			return FormatCSV
+		}
+	}
+	return FormatJSON
+}
+
+func (m *Handler) run(w http.ResponseWriter, r *http.Request, req *request, format string) error {
+
+	stmt, err := rel.ParseSql(req.Sql)
+	if err != nil {
+		return newHTTPError(http.StatusBadRequest, "%v", err)
+	}
+	if _, ok := schema.DefaultRegistry().Schema(req.Schema); !ok {
+		return newHTTPError(http.StatusNotFound, "schema %q not found", req.Schema)
+	}
+
+	hash := queryHash(req)
+	offset := 0
+	if req.PageToken != "" {
+		tok, err := decodePageToken(req.PageToken)
+		if err != nil || tok.Hash != hash {
+			return newHTTPError(http.StatusBadRequest, "invalid page_token")
+		}
+		offset = tok.Offset
+	}
+	limit := req.Limit
+	if limit <= 0 || (m.PageSize > 0 && limit > m.PageSize) {
+		limit = m.PageSize
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+
+	db, err := sql.Open("qlbridge", req.Schema)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch stmt.(type) {
+	case *rel.SqlSelect, *rel.SqlShow, *rel.SqlDescribe:
+		// these return rows
+	default:
+		result, err := db.ExecContext(r.Context(), req.Sql)
+		if err != nil {
+			return err
+		}
+		affected, _ := result.RowsAffected()
+		w.Header().Set("Content-Type", contentTypes[FormatJSON])
+		return json.NewEncoder(w).Encode(map[string]interface{}{"rows_affected": affected})
+	}
+
+	rows, err := db.QueryContext(r.Context(), req.Sql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var rw resultWriter
+	switch format {
+	case FormatNDJSON:
+		rw = &ndjsonWriter{w: w, cols: cols}
+	case FormatCSV:
+		rw = &csvWriter{w: w, cw: csv.NewWriter(w), cols: cols}
+	default:
+		rw = &jsonWriter{w: w, cols: cols}
+	}
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("Trailer", HeaderNextPageToken+", "+HeaderError)
+
+	vals := make([]interface{}, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		w.WriteHeader(http.StatusOK)
+		return rw.start()
+	}
+
+	pos, written, more := 0, 0, false
+	for rows.Next() {
+		if pos < offset {
+			pos++
+			continue
+		}
+		if written >= limit {
+			more = true
+			break
+		}
+		for i := range vals {
+			vals[i] = nil
+		}
+		if err = rows.Scan(dest...); err != nil {
+			break
+		}
+		if err = start(); err != nil {
+			// client went away
+			return nil
+		}
+		if err = rw.row(vals); err != nil {
+			return nil
+		}
+		pos++
+		written++
+		if f, ok := w.(http.Flusher); ok && written%100 == 0 {
+			f.Flush()
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	// stop the query once the page is read, the close waits for its job
+	// so nothing is still scanning the source when the response finishes
+	rows.Close()
+	if err != nil && !started {
+		return err
+	}
+	if err != nil {
+		u.Warnf("error streaming results for %q err=%v", req.Sql, err)
+	}
+	// empty results still get columns
+	if serr := start(); serr != nil {
+		return nil
+	}
+
+	next := ""
+	if more {
+		next = encodePageToken(&pageToken{Offset: offset + written, Hash: hash})
+		w.Header().Set(HeaderNextPageToken, next)
+	}
+	if err != nil {
+		w.Header().Set(HeaderError, err.Error())
+	}
+	rw.finish(next, err)
+	return nil
+}
+
+// writeError respond with error, status from httpError else 500
+func writeError(w http.ResponseWriter, format string, err error) {
+	status := http.StatusInternalServerError
+	if he, ok := err.(*httpError); ok {
+		status = he.status
+	}
+	if format == FormatCSV {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		io.WriteString(w, err.Error()+"\n")
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[FormatJSON])
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// queryHash binds page tokens to the query they were issued for.
+func queryHash(req *request) uint64 {
+	h := fnv.New64()
+	io.WriteString(h, req.Schema)
+	h.Write([]byte{0})
+	io.WriteString(h, req.Sql)
+	return h.Sum64()
+}
+
+func encodePageToken(tok *pageToken) string {
+	by, _ := json.Marshal(tok)
+	return base64.RawURLEncoding.EncodeToString(by)
+}
+
+func decodePageToken(s string) (*pageToken, error) {
+	by, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	tok := &pageToken{}
+	if err = json.Unmarshal(by, tok); err != nil {
+		return nil, err
+	}
+	if tok.Offset < 0 {
+		return nil, fmt.Errorf("invalid offset")
+	}
+	return tok, nil
+}
+
+// jsonValue make driver values json friendly.
+func jsonValue(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case []byte:
+		return string(vt)
+	case time.Time:
+		return vt.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// textValue format driver value for csv.
+func textValue(v interface{}) string {
+	switch vt := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vt
+	case []byte:
+		return string(vt)
+	case int64:
+		return strconv.FormatInt(vt, 10)
+	case float64:
+		return strconv.FormatFloat(vt, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(vt)
+	case time.Time:
+		return vt.Format(time.RFC3339Nano)
+	}
+	by, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(by)
+}
@@ -0,0 +1,166 @@
+package httpserver_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/server/httpserver"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+type jsonResult struct {
+	Columns       []string        `json:"columns"`
+	Rows          [][]interface{} `json:"rows"`
+	NextPageToken string          `json:"next_page_token"`
+	Error         string          `json:"error"`
+}
+
+func TestHandlerJSON(t *testing.T) {
+	h := httpserver.NewHandler()
+	h.Schema = "mockcsv"
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	q := url.Values{"sql": {"SELECT user_id, email FROM users"}, "limit": {"2"}}
+	resp, err := http.Get(srv.URL + "?" + q.Encode())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	res := &jsonResult{}
+	assert.Equal(t, nil, json.NewDecoder(resp.Body).Decode(res))
+	resp.Body.Close()
+	assert.Equal(t, []string{"user_id", "email"}, res.Columns)
+	assert.Equal(t, 2, len(res.Rows))
+	assert.NotEqual(t, "", res.NextPageToken)
+
+	// next page
+	q.Set("page_token", res.NextPageToken)
+	resp, err = http.Get(srv.URL + "?" + q.Encode())
+	assert.Equal(t, nil, err)
+	res2 := &jsonResult{}
+	assert.Equal(t, nil, json.NewDecoder(resp.Body).Decode(res2))
+	resp.Body.Close()
+	assert.Equal(t, 1, len(res2.Rows))
+	assert.Equal(t, "", res2.NextPageToken)
+
+	// token is bound to its query
+	q.Set("sql", "SELECT email FROM users")
+	resp, err = http.Get(srv.URL + "?" + q.Encode())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// post json body
+	resp, err = http.Post(srv.URL, "application/json", strings.NewReader(`{"sql":"SELECT email FROM users WHERE user_id = \"hT2impsOPUREcVPc\""}`))
+	assert.Equal(t, nil, err)
+	res = &jsonResult{}
+	assert.Equal(t, nil, json.NewDecoder(resp.Body).Decode(res))
+	resp.Body.Close()
+	assert.Equal(t, [][]interface{}{{"bob@email.com"}}, res.Rows)
+
+	// error mapping
+	for _, tc := range []struct {
+		method, body, query string
+		status              int
+	}{
+		{"GET", "", "sql=SELECTX+*+FROM+users", http.StatusBadRequest},
+		{"GET", "", "", http.StatusBadRequest},
+		{"GET", "", "sql=SELECT+*+FROM+users&schema=not_a_schema", http.StatusNotFound},
+		{"GET", "", "sql=SELECT+*+FROM+users&format=xml", http.StatusBadRequest},
+		{"GET", "", "sql=SELECT+*+FROM+users&limit=abc", http.StatusBadRequest},
+		{"DELETE", "", "sql=SELECT+*+FROM+users", http.StatusMethodNotAllowed},
+	} {
+		req, _ := http.NewRequest(tc.method, srv.URL+"?"+tc.query, strings.NewReader(tc.body))
+		resp, err = http.DefaultClient.Do(req)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tc.status, resp.StatusCode, tc.query)
+		res = &jsonResult{}
+		assert.Equal(t, nil, json.NewDecoder(resp.Body).Decode(res))
+		assert.NotEqual(t, "", res.Error)
+		resp.Body.Close()
+	}
+}
+
+func TestHandlerFormats(t *testing.T) {
+	srv := httptest.NewServer(httpserver.NewHandler())
+	defer srv.Close()
+
+	// csv by Accept header, posted raw sql
+	req, _ := http.NewRequest("POST", srv.URL+"?schema=mockcsv&limit=2", strings.NewReader("SELECT user_id, referral_count FROM users"))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Accept", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	recs, err := csv.NewReader(resp.Body).ReadAll()
+	assert.Equal(t, nil, err)
+	resp.Body.Close()
+	assert.Equal(t, 3, len(recs))
+	assert.Equal(t, []string{"user_id", "referral_count"}, recs[0])
+	assert.NotEqual(t, "", resp.Trailer.Get(httpserver.HeaderNextPageToken))
+
+	// ndjson by format param
+	q := url.Values{"sql": {"SELECT user_id, email FROM users"}, "schema": {"mockcsv"}, "format": {"ndjson"}}
+	resp, err = http.Get(srv.URL + "?" + q.Encode())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	by, err := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, nil, err)
+	resp.Body.Close()
+	lines := strings.Split(strings.TrimSpace(string(by)), "\n")
+	assert.Equal(t, 3, len(lines))
+	emails := make(map[string]string)
+	for _, line := range lines {
+		row := make(map[string]string)
+		assert.Equal(t, nil, json.Unmarshal([]byte(line), &row))
+		emails[row["user_id"]] = row["email"]
+	}
+	assert.Equal(t, "aaron@email.com", emails["9Ip1aKbeZe2njCDM"])
+	assert.Equal(t, "", resp.Trailer.Get(httpserver.HeaderNextPageToken))
+}
+
+func TestProfileHandler(t *testing.T) {
+	srv := httptest.NewServer(httpserver.NewProfileHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/pprof/goroutine?debug=1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	by, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.True(t, strings.Contains(string(by), "goroutine profile"), string(by))
+
+	resp, err = http.Get(srv.URL + "/debug/pprof/heap")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	by, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NotEqual(t, 0, len(by))
+
+	resp, err = http.Get(srv.URL + "/debug/pprof/profile?seconds=1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/debug/pprof/profile?seconds=forever")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/debug/pprof/not_a_profile")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
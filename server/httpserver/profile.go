@@ -0,0 +1,88 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/araddon/qlbridge/exec"
+)
+
+var (
+	// Ensure we implement http.Handler
+	_ http.Handler = (*ProfileHandler)(nil)
+
+	// MaxProfileSeconds longest cpu profile a request may ask for.
+	MaxProfileSeconds = 300
+)
+
+// ProfileHandler serves runtime profiles in the pprof format for
+// continuous profiling tools to scrape, the last path element names the
+// profile (profile for cpu, heap, goroutine, allocs, block, mutex ...).
+// Statements are labeled with their query id and fingerprint (see
+// exec.ProfileLabels) so cpu and goroutine profiles can be attributed to
+// query shapes.
+//
+//	http.Handle("/debug/pprof/", httpserver.NewProfileHandler())
+//
+//	go tool pprof -tagfocus qlb_fingerprint=9c3b2e1f0a4d5e6f 'localhost:8080/debug/pprof/profile?seconds=30'
+type ProfileHandler struct{}
+
+// NewProfileHandler create a profile handler, turning on pprof labels
+// of statements.
+func NewProfileHandler() *ProfileHandler {
+	exec.SetProfileLabels(true)
+	return &ProfileHandler{}
+}
+
+// ServeHTTP implements http.Handler
+func (m *ProfileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	if name == "profile" {
+		m.serveCPU(w, r)
+		return
+	}
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.Error(w, fmt.Sprintf("unknown profile %q", name), http.StatusNotFound)
+		return
+	}
+	debug, _ := strconv.Atoi(r.URL.Query().Get("debug"))
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	}
+	p.WriteTo(w, debug)
+}
+
+// serveCPU profile the cpu for ?seconds (default 30).
+func (m *ProfileHandler) serveCPU(w http.ResponseWriter, r *http.Request) {
+	secs := 30
+	if s := r.URL.Query().Get("seconds"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > MaxProfileSeconds {
+			http.Error(w, fmt.Sprintf("invalid seconds %q", s), http.StatusBadRequest)
+			return
+		}
+		secs = n
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	if err := pprof.StartCPUProfile(w); err != nil {
+		// only one cpu profile may run at once
+		w.Header().Del("Content-Disposition")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.Error(w, fmt.Sprintf("could not start cpu profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+	select {
+	case <-time.After(time.Duration(secs) * time.Second):
+	case <-r.Context().Done():
+	}
+	pprof.StopCPUProfile()
+}
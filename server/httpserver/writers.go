@@ -0,0 +1,138 @@
+package httpserver
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+var (
+	// Ensure our writers implement resultWriter
+	_ resultWriter = (*jsonWriter)(nil)
+	_ resultWriter = (*ndjsonWriter)(nil)
+	_ resultWriter = (*csvWriter)(nil)
+)
+
+// resultWriter streams result rows in a given format.
+type resultWriter interface {
+	start() error
+	row(vals []interface{}) error
+	// finish the response, with the next page token if there are more
+	// rows, and error if the result stream failed part way through.
+	finish(next string, err error) error
+}
+
+// jsonWriter writes
+//
+//	{"columns":["a","b"],"rows":[[1,"x"],[2,"y"]],"next_page_token":"..."}
+type jsonWriter struct {
+	w    io.Writer
+	cols []string
+	ct   int
+}
+
+func (m *jsonWriter) start() error {
+	by, err := json.Marshal(m.cols)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(m.w, `{"columns":`+string(by)+`,"rows":[`)
+	return err
+}
+
+func (m *jsonWriter) row(vals []interface{}) error {
+	row := make([]interface{}, len(vals))
+	for i, v := range vals {
+		row[i] = jsonValue(v)
+	}
+	by, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if m.ct > 0 {
+		if _, err = io.WriteString(m.w, ","); err != nil {
+			return err
+		}
+	}
+	m.ct++
+	_, err = m.w.Write(by)
+	return err
+}
+
+func (m *jsonWriter) finish(next string, err error) error {
+	tail := "]"
+	if next != "" {
+		by, _ := json.Marshal(next)
+		tail += `,"next_page_token":` + string(by)
+	}
+	if err != nil {
+		by, _ := json.Marshal(err.Error())
+		tail += `,"error":` + string(by)
+	}
+	_, werr := io.WriteString(m.w, tail+"}\n")
+	return werr
+}
+
+// ndjsonWriter writes one json object per row, keyed by column name.  The
+// next page token, and errors, are written as a final line object
+// {"next_page_token":"..."} as well as trailers.
+type ndjsonWriter struct {
+	w    io.Writer
+	cols []string
+}
+
+func (m *ndjsonWriter) start() error { return nil }
+
+func (m *ndjsonWriter) row(vals []interface{}) error {
+	row := make(map[string]interface{}, len(vals))
+	for i, v := range vals {
+		row[m.cols[i]] = jsonValue(v)
+	}
+	by, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	_, err = m.w.Write(append(by, '\n'))
+	return err
+}
+
+func (m *ndjsonWriter) finish(next string, err error) error {
+	tail := make(map[string]string)
+	if next != "" {
+		tail["next_page_token"] = next
+	}
+	if err != nil {
+		tail["error"] = err.Error()
+	}
+	if len(tail) == 0 {
+		return nil
+	}
+	by, _ := json.Marshal(tail)
+	_, werr := m.w.Write(append(by, '\n'))
+	return werr
+}
+
+// csvWriter writes header row then values, page token and errors are
+// only available as trailers.
+type csvWriter struct {
+	w    io.Writer
+	cw   *csv.Writer
+	cols []string
+}
+
+func (m *csvWriter) start() error {
+	return m.cw.Write(m.cols)
+}
+
+func (m *csvWriter) row(vals []interface{}) error {
+	rec := make([]string, len(vals))
+	for i, v := range vals {
+		rec[i] = textValue(v)
+	}
+	return m.cw.Write(rec)
+}
+
+func (m *csvWriter) finish(next string, err error) error {
+	m.cw.Flush()
+	return m.cw.Error()
+}
@@ -0,0 +1,174 @@
+package mysqlserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// AuthNativePassword the default mysql sha1 challenge-response auth plugin
+	AuthNativePassword = "mysql_native_password"
+	// AuthClearPassword plugin where clients send the password in clear text,
+	// only use over trusted networks.
+	AuthClearPassword = "mysql_clear_password"
+)
+
+// handshakeResponse is the clients reply to our initial handshake.
+type handshakeResponse struct {
+	capabilities uint32
+	user         string
+	authData     []byte
+	db           string
+	plugin       string
+	attrs        map[string]string // connection attributes
+}
+
+// newSalt creates the 20 byte auth challenge, clients expect printable
+// non-null bytes.
+func newSalt() ([]byte, error) {
+	salt := make([]byte, 20)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	for i, b := range salt {
+		b &= 0x7f
+		if b == 0 || b == '$' {
+			b++
+		}
+		salt[i] = b
+	}
+	return salt, nil
+}
+
+// handshakePacket is the initial (protocol v10) handshake sent by server.
+func handshakePacket(version string, connID uint32, salt []byte, plugin string) []byte {
+	data := []byte{10}
+	data = append(data, version...)
+	data = append(data, 0)
+	data = appendUint32(data, connID)
+	data = append(data, salt[:8]...)
+	data = append(data, 0)
+	data = appendUint16(data, uint16(serverCapabilities&0xffff))
+	data = append(data, charsetUTF8)
+	data = appendUint16(data, serverStatusAutocommit)
+	data = appendUint16(data, uint16(serverCapabilities>>16))
+	data = append(data, byte(len(salt)+1))
+	data = append(data, make([]byte, 10)...)
+	data = append(data, salt[8:]...)
+	data = append(data, 0)
+	data = append(data, plugin...)
+	return append(data, 0)
+}
+
+// authSwitchPacket asks client to re-send auth data using @plugin.
+func authSwitchPacket(plugin string, salt []byte) []byte {
+	data := []byte{iEOF}
+	data = append(data, plugin...)
+	data = append(data, 0)
+	data = append(data, salt...)
+	return append(data, 0)
+}
+
+func parseHandshakeResponse(data []byte) (*handshakeResponse, error) {
+	if len(data) < 32 {
+		return nil, fmt.Errorf("QLBridge.mysqlserver: handshake response too short")
+	}
+	hr := &handshakeResponse{capabilities: binary.LittleEndian.Uint32(data[:4])}
+	if hr.capabilities&clientProtocol41 == 0 {
+		return nil, fmt.Errorf("QLBridge.mysqlserver: client must support protocol 4.1")
+	}
+	// skip max-packet-size(4), charset(1), filler(23)
+	pos := 32
+	var n int
+	hr.user, n = readNullString(data[pos:])
+	pos += n
+
+	switch {
+	case hr.capabilities&clientPluginAuthLenEncClientData != 0:
+		hr.authData, n = readLenEncBytes(data[pos:])
+		if n == 0 {
+			return nil, fmt.Errorf("QLBridge.mysqlserver: invalid auth data")
+		}
+		pos += n
+	case hr.capabilities&clientSecureConn != 0:
+		if pos >= len(data) || pos+1+int(data[pos]) > len(data) {
+			return nil, fmt.Errorf("QLBridge.mysqlserver: invalid auth data")
+		}
+		hr.authData = data[pos+1 : pos+1+int(data[pos])]
+		pos += 1 + int(data[pos])
+	default:
+		var s string
+		s, n = readNullString(data[pos:])
+		hr.authData = []byte(s)
+		pos += n
+	}
+
+	if hr.capabilities&clientConnectWithDB != 0 && pos < len(data) {
+		hr.db, n = readNullString(data[pos:])
+		pos += n
+	}
+	if hr.capabilities&clientPluginAuth != 0 && pos < len(data) {
+		hr.plugin, n = readNullString(data[pos:])
+		pos += n
+	}
+	if hr.capabilities&clientConnectAttrs != 0 && pos < len(data) {
+		attrs, n := readLenEncBytes(data[pos:])
+		if n == 0 {
+			return nil, fmt.Errorf("QLBridge.mysqlserver: invalid connection attributes")
+		}
+		hr.attrs = make(map[string]string)
+		for len(attrs) > 0 {
+			k, kn := readLenEncBytes(attrs)
+			if kn == 0 {
+				return nil, fmt.Errorf("QLBridge.mysqlserver: invalid connection attributes")
+			}
+			v, vn := readLenEncBytes(attrs[kn:])
+			if vn == 0 {
+				return nil, fmt.Errorf("QLBridge.mysqlserver: invalid connection attributes")
+			}
+			hr.attrs[string(k)] = string(v)
+			attrs = attrs[kn+vn:]
+		}
+	}
+	return hr, nil
+}
+
+// nativePasswordHash is the mysql_native_password scramble
+//
+//	SHA1(password) XOR SHA1(salt + SHA1(SHA1(password)))
+func nativePasswordHash(salt []byte, password string) []byte {
+	if password == "" {
+		return nil
+	}
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+	h := sha1.New()
+	h.Write(salt)
+	h.Write(stage2[:])
+	scramble := h.Sum(nil)
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	return scramble
+}
+
+// checkPassword verify the auth data sent by client using @plugin
+// against the expected password.
+func checkPassword(plugin string, salt, authData []byte, password string) bool {
+	switch plugin {
+	case AuthClearPassword:
+		authData = bytes.TrimRight(authData, "\x00")
+		return subtle.ConstantTimeCompare(authData, []byte(password)) == 1
+	case AuthNativePassword:
+		expected := nativePasswordHash(salt, password)
+		if len(expected) == 0 {
+			return len(authData) == 0
+		}
+		return subtle.ConstantTimeCompare(authData, expected) == 1
+	}
+	return false
+}
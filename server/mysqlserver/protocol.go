@@ -0,0 +1,313 @@
+package mysqlserver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+// https://dev.mysql.com/doc/internals/en/client-server-protocol.html
+
+// Commands
+const (
+	comQuit             byte = 0x01
+	comInitDB           byte = 0x02
+	comQuery            byte = 0x03
+	comFieldList        byte = 0x04
+	comPing             byte = 0x0e
+	comStmtPrepare      byte = 0x16
+	comStmtExecute      byte = 0x17
+	comStmtSendLongData byte = 0x18
+	comStmtClose        byte = 0x19
+	comStmtReset        byte = 0x1a
+	comSetOption        byte = 0x1b
+)
+
+// Capability flags
+const (
+	clientLongPassword               uint32 = 0x00000001
+	clientFoundRows                  uint32 = 0x00000002
+	clientLongFlag                   uint32 = 0x00000004
+	clientConnectWithDB              uint32 = 0x00000008
+	clientProtocol41                 uint32 = 0x00000200
+	clientTransactions               uint32 = 0x00002000
+	clientSecureConn                 uint32 = 0x00008000
+	clientMultiStatements            uint32 = 0x00010000
+	clientMultiResults               uint32 = 0x00020000
+	clientPluginAuth                 uint32 = 0x00080000
+	clientConnectAttrs               uint32 = 0x00100000
+	clientPluginAuthLenEncClientData uint32 = 0x00200000
+
+	serverCapabilities = clientLongPassword | clientFoundRows | clientLongFlag |
+		clientConnectWithDB | clientProtocol41 | clientTransactions | clientSecureConn |
+		clientMultiStatements | clientMultiResults | clientPluginAuth | clientConnectAttrs |
+		clientPluginAuthLenEncClientData
+)
+
+// COM_SET_OPTION options
+const (
+	optionMultiStatementsOn  uint16 = 0
+	optionMultiStatementsOff uint16 = 1
+)
+
+// Status flags, and packet headers
+const (
+	serverStatusAutocommit  uint16 = 0x0002
+	serverMoreResultsExists uint16 = 0x0008
+
+	iOK  byte = 0x00
+	iEOF byte = 0xfe
+	iERR byte = 0xff
+
+	charsetUTF8 byte = 33
+
+	maxPacketSize = 1<<24 - 1
+)
+
+// Column types
+const (
+	mysqlTypeDecimal    byte = 0x00
+	mysqlTypeTiny       byte = 0x01
+	mysqlTypeShort      byte = 0x02
+	mysqlTypeLong       byte = 0x03
+	mysqlTypeFloat      byte = 0x04
+	mysqlTypeDouble     byte = 0x05
+	mysqlTypeNull       byte = 0x06
+	mysqlTypeTimestamp  byte = 0x07
+	mysqlTypeLongLong   byte = 0x08
+	mysqlTypeInt24      byte = 0x09
+	mysqlTypeDate       byte = 0x0a
+	mysqlTypeTime       byte = 0x0b
+	mysqlTypeDatetime   byte = 0x0c
+	mysqlTypeYear       byte = 0x0d
+	mysqlTypeVarChar    byte = 0x0f
+	mysqlTypeBit        byte = 0x10
+	mysqlTypeJSON       byte = 0xf5
+	mysqlTypeNewDecimal byte = 0xf6
+	mysqlTypeBlob       byte = 0xfc
+	mysqlTypeVarString  byte = 0xfd
+	mysqlTypeString     byte = 0xfe
+)
+
+// Error codes, sqlstates
+const (
+	erAccessDenied       uint16 = 1045
+	erNoDB               uint16 = 1046
+	erUnknownCom         uint16 = 1047
+	erBadDB              uint16 = 1049
+	erParseError         uint16 = 1064
+	erUnknown            uint16 = 1105
+	erUnknownStmtHandler uint16 = 1243
+	erWrongArguments     uint16 = 1210
+
+	erClientInteractionTimeout uint16 = 4031
+)
+
+// packetConn reads and writes mysql packets (3 byte length, 1 byte
+// sequence id, payload) over a buffered connection.
+type packetConn struct {
+	r   *bufio.Reader
+	w   *bufio.Writer
+	seq uint8
+	// moreResults flag the OK/EOF ending a result as followed by the
+	// result of the next statement of a multi-statement query.
+	moreResults bool
+	// errored an ERR packet was written since last reset.
+	errored bool
+}
+
+func newPacketConn(rw io.ReadWriter) *packetConn {
+	return &packetConn{r: bufio.NewReaderSize(rw, 16*1024), w: bufio.NewWriterSize(rw, 16*1024)}
+}
+
+// readPacket read a full payload, joining payloads split across
+// multiple max size packets.
+func (m *packetConn) readPacket() ([]byte, error) {
+	var payload []byte
+	var hdr [4]byte
+	for {
+		if _, err := io.ReadFull(m.r, hdr[:]); err != nil {
+			return nil, err
+		}
+		if hdr[3] != m.seq {
+			return nil, fmt.Errorf("QLBridge.mysqlserver: packets out of order got %d expected %d", hdr[3], m.seq)
+		}
+		m.seq++
+		size := int(uint32(hdr[0]) | uint32(hdr[1])<<8 | uint32(hdr[2])<<16)
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(m.r, buf); err != nil {
+			return nil, err
+		}
+		if payload == nil && size < maxPacketSize {
+			return buf, nil
+		}
+		payload = append(payload, buf...)
+		if size < maxPacketSize {
+			return payload, nil
+		}
+	}
+}
+
+// writePacket write payload, splitting into multiple packets if needed,
+// writes are buffered until flush.
+func (m *packetConn) writePacket(payload []byte) error {
+	for {
+		size := len(payload)
+		if size > maxPacketSize {
+			size = maxPacketSize
+		}
+		hdr := [4]byte{byte(size), byte(size >> 8), byte(size >> 16), m.seq}
+		m.seq++
+		if _, err := m.w.Write(hdr[:]); err != nil {
+			return err
+		}
+		if _, err := m.w.Write(payload[:size]); err != nil {
+			return err
+		}
+		payload = payload[size:]
+		if size < maxPacketSize {
+			return nil
+		}
+	}
+}
+
+func (m *packetConn) flush() error {
+	return m.w.Flush()
+}
+
+func (m *packetConn) writeOK(affected, lastInsertID uint64) error {
+	data := []byte{iOK}
+	data = appendLenEncInt(data, affected)
+	data = appendLenEncInt(data, lastInsertID)
+	data = appendUint16(data, m.status())
+	data = appendUint16(data, 0) // warnings
+	return m.writePacket(data)
+}
+
+// status flags of OK/EOF packets.
+func (m *packetConn) status() uint16 {
+	if m.moreResults {
+		return serverStatusAutocommit | serverMoreResultsExists
+	}
+	return serverStatusAutocommit
+}
+
+func (m *packetConn) writeEOF() error {
+	return m.writeEOFWarnings(0)
+}
+
+// writeEOF with a count of warnings, ie results answered from a sample.
+func (m *packetConn) writeEOFWarnings(warnings int) error {
+	if warnings > 0xffff {
+		warnings = 0xffff
+	}
+	data := []byte{iEOF}
+	data = appendUint16(data, uint16(warnings))
+	data = appendUint16(data, m.status())
+	return m.writePacket(data)
+}
+
+// writeErr write err, with the code and sqlstate of a typed error (see
+// sqlerr) else those given.
+func (m *packetConn) writeErr(err error, code uint16, state string) error {
+	if e, ok := sqlerr.As(err); ok {
+		return m.writeError(uint16(e.Code), e.State, e.Message)
+	}
+	return m.writeError(code, state, err.Error())
+}
+
+func (m *packetConn) writeError(code uint16, state, msg string) error {
+	m.errored = true
+	data := []byte{iERR}
+	data = appendUint16(data, code)
+	data = append(data, '#')
+	data = append(data, state...)
+	data = append(data, msg...)
+	return m.writePacket(data)
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	return append(b, byte(n), byte(n>>8))
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func appendLenEncInt(b []byte, n uint64) []byte {
+	switch {
+	case n < 251:
+		return append(b, byte(n))
+	case n < 1<<16:
+		return append(b, 0xfc, byte(n), byte(n>>8))
+	case n < 1<<24:
+		return append(b, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return appendUint64(append(b, 0xfe), n)
+}
+
+func appendLenEncString(b []byte, s string) []byte {
+	b = appendLenEncInt(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendLenEncBytes(b []byte, s []byte) []byte {
+	b = appendLenEncInt(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// readLenEncInt returns the int, if it was null, and number of bytes read.
+func readLenEncInt(b []byte) (uint64, bool, int) {
+	if len(b) == 0 {
+		return 0, false, 0
+	}
+	switch b[0] {
+	case 0xfb:
+		return 0, true, 1
+	case 0xfc:
+		if len(b) < 3 {
+			return 0, false, 0
+		}
+		return uint64(b[1]) | uint64(b[2])<<8, false, 3
+	case 0xfd:
+		if len(b) < 4 {
+			return 0, false, 0
+		}
+		return uint64(b[1]) | uint64(b[2])<<8 | uint64(b[3])<<16, false, 4
+	case 0xfe:
+		if len(b) < 9 {
+			return 0, false, 0
+		}
+		return binary.LittleEndian.Uint64(b[1:9]), false, 9
+	}
+	return uint64(b[0]), false, 1
+}
+
+// readLenEncBytes returns the bytes, and number of bytes read, 0 on error.
+func readLenEncBytes(b []byte) ([]byte, int) {
+	n, _, pos := readLenEncInt(b)
+	if pos == 0 || uint64(len(b)-pos) < n {
+		return nil, 0
+	}
+	return b[pos : pos+int(n)], pos + int(n)
+}
+
+// readNullString returns the string, and number of bytes read (including
+// the null terminator).
+func readNullString(b []byte) (string, int) {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i]), i + 1
+		}
+	}
+	return string(b), len(b)
+}
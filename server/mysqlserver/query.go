@@ -0,0 +1,329 @@
+package mysqlserver
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	mysqlTimeFormat = "2006-01-02 15:04:05.999999"
+)
+
+// column is a result-set column definition.
+type column struct {
+	name string
+	typ  byte
+}
+
+// queries run the ; separated statements of a COM_QUERY in order, each
+// result streamed and flushed before the next statement runs.  All but
+// the last result are flagged SERVER_MORE_RESULTS_EXISTS so the client
+// reads on; an error ends the sequence, later statements are not run.
+// Only the first statement is run unless the client enabled multiple
+// statements.
+func (m *conn) queries(sql string) error {
+	if !m.multiStatements {
+		return m.query(sql, false)
+	}
+	raws, _, err := rel.SplitSqlStatements(sql)
+	if err != nil {
+		return m.pc.writeErr(err, erParseError, "42000")
+	}
+	defer func() { m.pc.moreResults = false }()
+	for i, raw := range raws {
+		m.pc.moreResults = i < len(raws)-1
+		m.pc.errored = false
+		if err = m.query(raw, false); err != nil {
+			return err
+		}
+		if m.pc.errored {
+			return nil
+		}
+		if m.pc.moreResults {
+			if err = m.pc.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// query run sql statement writing the result, prepared statements
+// (@binary) write rows in the binary protocol.
+func (m *conn) query(sql string, binary bool) error {
+
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+
+	stmt, err := rel.ParseSql(sql)
+	if err != nil {
+		return m.pc.writeErr(err, erParseError, "42000")
+	}
+	if cmd, ok := stmt.(*rel.SqlCommand); ok && cmd.Keyword() == lex.TokenUse {
+		if err = m.useSchema(cmd.Identity); err != nil {
+			return m.pc.writeErr(err, erBadDB, "42000")
+		}
+		return m.pc.writeOK(0, 0)
+	}
+	if m.ses.Schema() == nil {
+		return m.pc.writeError(erNoDB, "3D000", "No database selected")
+	}
+
+	ctx := m.ses.NewContext(sql)
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		return m.pc.writeErr(err, erUnknown, "HY000")
+	}
+	defer job.Close()
+
+	sel, ok := job.Ctx.Stmt.(*rel.SqlSelect)
+	if !ok {
+		rw := exec.NewResultExecWriter(ctx)
+		job.RootTask.Add(rw)
+		job.Setup()
+		if err = job.Run(); err != nil {
+			return m.pc.writeErr(err, erUnknown, "HY000")
+		}
+		result := rw.Result()
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return m.pc.writeErr(err, erUnknown, "HY000")
+		}
+		lastID, _ := result.LastInsertId()
+		return m.pc.writeOK(uint64(affected), uint64(lastID))
+	}
+
+	cols := columnsFor(ctx, sel)
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.name
+	}
+	rows := exec.NewResultRows(ctx, names)
+	job.RootTask.Add(rows)
+	job.Setup()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- job.Run()
+	}()
+
+	if err = m.writeColumns(cols); err != nil {
+		job.Close()
+		return err
+	}
+
+	dest := make([]driver.Value, len(cols))
+	for {
+		for i := range dest {
+			dest[i] = nil
+		}
+		if err = rows.Next(dest); err != nil {
+			break
+		}
+		var row []byte
+		if binary {
+			row = binaryRow(cols, dest)
+		} else {
+			row = textRow(dest)
+		}
+		if err = m.pc.writePacket(row); err != nil {
+			job.Close()
+			return err
+		}
+	}
+	// result writer runs until signaled to stop
+	job.Close()
+	if rerr := <-runErr; rerr != nil && err == io.EOF {
+		err = rerr
+	}
+	if err != io.EOF {
+		u.Warnf("error running %q err=%v", sql, err)
+		return m.pc.writeErr(err, erUnknown, "HY000")
+	}
+	return m.pc.writeEOFWarnings(len(ctx.Warnings))
+}
+
+// columnsFor the select, with types from the planned projection if known.
+func columnsFor(ctx *plan.Context, sel *rel.SqlSelect) []*column {
+	types := make(map[string]value.ValueType)
+	if ctx.Projection != nil && ctx.Projection.Proj != nil {
+		for _, rc := range ctx.Projection.Proj.Columns {
+			if rc.As != "" {
+				types[rc.As] = rc.Type
+			} else {
+				types[rc.Name] = rc.Type
+			}
+		}
+	}
+	names := sel.Columns.AliasedFieldNames()
+	cols := make([]*column, len(names))
+	for i, name := range names {
+		cols[i] = &column{name: name, typ: mysqlType(types[name])}
+	}
+	return cols
+}
+
+func mysqlType(vt value.ValueType) byte {
+	switch vt {
+	case value.IntType:
+		return mysqlTypeLongLong
+	case value.NumberType:
+		return mysqlTypeDouble
+	case value.BoolType:
+		return mysqlTypeTiny
+	case value.TimeType:
+		return mysqlTypeDatetime
+	case value.ByteSliceType:
+		return mysqlTypeBlob
+	}
+	return mysqlTypeVarString
+}
+
+// writeColumns column count, definitions, EOF
+func (m *conn) writeColumns(cols []*column) error {
+	if err := m.pc.writePacket(appendLenEncInt(nil, uint64(len(cols)))); err != nil {
+		return err
+	}
+	for _, col := range cols {
+		if err := m.pc.writePacket(columnDefinition(m.schemaName(), col)); err != nil {
+			return err
+		}
+	}
+	return m.pc.writeEOF()
+}
+
+func (m *conn) schemaName() string {
+	s := m.ses.Schema()
+	if s == nil {
+		return ""
+	}
+	return s.Name
+}
+
+func columnDefinition(db string, col *column) []byte {
+	data := appendLenEncString(nil, "def")
+	data = appendLenEncString(data, db)
+	data = appendLenEncString(data, "") // table
+	data = appendLenEncString(data, "") // org_table
+	data = appendLenEncString(data, col.name)
+	data = appendLenEncString(data, col.name)
+	data = append(data, 0x0c)
+	data = appendUint16(data, uint16(charsetUTF8))
+	data = appendUint32(data, 1<<24-1) // column length
+	data = append(data, col.typ)
+	data = appendUint16(data, 0) // flags
+	decimals := byte(0)
+	if col.typ == mysqlTypeDouble || col.typ == mysqlTypeVarString {
+		decimals = 0x1f
+	}
+	data = append(data, decimals)
+	return append(data, 0, 0) // filler
+}
+
+// textRow is a row of length-encoded strings, nulls are 0xfb.
+func textRow(vals []driver.Value) []byte {
+	var data []byte
+	for _, v := range vals {
+		if v == nil {
+			data = append(data, 0xfb)
+			continue
+		}
+		data = appendLenEncString(data, textValue(v))
+	}
+	return data
+}
+
+func textValue(v driver.Value) string {
+	switch vt := v.(type) {
+	case string:
+		return vt
+	case []byte:
+		return string(vt)
+	case int64:
+		return strconv.FormatInt(vt, 10)
+	case int:
+		return strconv.Itoa(vt)
+	case float64:
+		return strconv.FormatFloat(vt, 'g', -1, 64)
+	case bool:
+		if vt {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return vt.Format(mysqlTimeFormat)
+	}
+	by, err := json.Marshal(v)
+	if err != nil {
+		return value.NewValue(v).ToString()
+	}
+	return string(by)
+}
+
+// binaryRow is a row in the prepared statement binary protocol, values
+// are encoded per column type, nulls in the leading bitmap (offset 2).
+func binaryRow(cols []*column, vals []driver.Value) []byte {
+	nullMap := make([]byte, (len(cols)+7+2)/8)
+	var buf []byte
+	for i, col := range cols {
+		var ok bool
+		if vals[i] != nil {
+			buf, ok = appendBinaryValue(buf, col.typ, vals[i])
+		}
+		if !ok {
+			nullMap[(i+2)/8] |= 1 << (uint(i+2) % 8)
+		}
+	}
+	data := append([]byte{iOK}, nullMap...)
+	return append(data, buf...)
+}
+
+// appendBinaryValue encode v as column type, false if it could not be
+// converted to that type (sent as null).
+func appendBinaryValue(buf []byte, typ byte, v driver.Value) ([]byte, bool) {
+	switch typ {
+	case mysqlTypeLongLong:
+		n, ok := value.ValueToInt64(value.NewValue(v))
+		if !ok {
+			return buf, false
+		}
+		return appendUint64(buf, uint64(n)), true
+	case mysqlTypeDouble:
+		f, ok := value.ValueToFloat64(value.NewValue(v))
+		if !ok {
+			return buf, false
+		}
+		return appendUint64(buf, math.Float64bits(f)), true
+	case mysqlTypeTiny:
+		b, ok := value.ValueToBool(value.NewValue(v))
+		if !ok {
+			return buf, false
+		}
+		if b {
+			return append(buf, 1), true
+		}
+		return append(buf, 0), true
+	case mysqlTypeDatetime:
+		t, ok := value.ValueToTime(value.NewValue(v))
+		if !ok {
+			return buf, false
+		}
+		buf = append(buf, 11)
+		buf = appendUint16(buf, uint16(t.Year()))
+		buf = append(buf, byte(t.Month()), byte(t.Day()), byte(t.Hour()), byte(t.Minute()), byte(t.Second()))
+		return appendUint32(buf, uint32(t.Nanosecond()/1000)), true
+	}
+	return appendLenEncString(buf, textValue(v)), true
+}
@@ -0,0 +1,364 @@
+// Package mysqlserver is a server implementing enough of the MySQL
+// client/server protocol (handshake, auth, COM_QUERY, prepared statements)
+// that stock MySQL clients and drivers can run queries against the
+// qlbridge virtual schemas in the schema registry.
+//
+//	srv := mysqlserver.NewServer()
+//	srv.Users = map[string]string{"root": "secret"}
+//	srv.ListenAndServe(":4000")
+//
+//	mysql -h 127.0.0.1 -P 4000 -u root -psecret mockcsv
+package mysqlserver
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// DefaultVersion is the server version reported to clients.
+	DefaultVersion = "5.7.0-qlbridge"
+)
+
+// Server is a MySQL wire-protocol server.
+type Server struct {
+	// Version reported in handshake
+	Version string
+	// Schema is the default schema for connections that do not
+	// specify a database.
+	Schema string
+	// Users map of user -> password, if nil any user/password is accepted.
+	Users map[string]string
+	// AuthPlugin is the auth method requested of clients, AuthNativePassword
+	// (default) or AuthClearPassword.
+	AuthPlugin string
+	// IdleTimeout close connections idle longer than this between
+	// statements, clients may lower it with SET wait_timeout.  If zero
+	// the session wait_timeout applies.
+	IdleTimeout time.Duration
+	// MaxSessionLifetime close connections this long after they
+	// connected, at the next statement boundary, 0 for no limit.
+	MaxSessionLifetime time.Duration
+
+	mu     sync.Mutex
+	lis    net.Listener
+	connID uint32
+	conns  map[*conn]struct{}
+	closed bool
+}
+
+// NewServer create a new mysql protocol server.
+func NewServer() *Server {
+	return &Server{
+		Version:    DefaultVersion,
+		AuthPlugin: AuthNativePassword,
+		conns:      make(map[*conn]struct{}),
+	}
+}
+
+// ListenAndServe listen on tcp address and serve client connections.
+func (m *Server) ListenAndServe(address string) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return m.Serve(lis)
+}
+
+// Serve accept and serve client connections on listener, blocks until
+// listener is closed.
+func (m *Server) Serve(lis net.Listener) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		lis.Close()
+		return fmt.Errorf("QLBridge.mysqlserver: server closed")
+	}
+	m.lis = lis
+	m.mu.Unlock()
+
+	for {
+		nc, err := lis.Accept()
+		if err != nil {
+			m.mu.Lock()
+			closed := m.closed
+			m.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		c := m.newConn(nc)
+		go c.serve()
+	}
+}
+
+// Close the listener and all client connections.
+func (m *Server) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for c := range m.conns {
+		c.nc.Close()
+	}
+	if m.lis != nil {
+		return m.lis.Close()
+	}
+	return nil
+}
+
+func (m *Server) newConn(nc net.Conn) *conn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connID++
+	c := &conn{
+		srv:     m,
+		nc:      nc,
+		pc:      newPacketConn(nc),
+		id:      m.connID,
+		started: time.Now(),
+		ses:     plan.NewSession(datasource.NewMySqlSessionVars()),
+		stmts:   make(map[uint32]*stmt),
+	}
+	m.conns[c] = struct{}{}
+	return c
+}
+
+func (m *Server) removeConn(c *conn) {
+	m.mu.Lock()
+	delete(m.conns, c)
+	m.mu.Unlock()
+}
+
+// conn is a single client connection, not thread safe.
+type conn struct {
+	srv     *Server
+	nc      net.Conn
+	pc      *packetConn
+	id      uint32
+	started time.Time
+	ses     *plan.Session // user, schema, variables and budget of the connection
+	stmtID  uint32
+	stmts   map[uint32]*stmt
+	// multiStatements client allows several ; separated statements per
+	// COM_QUERY (CLIENT_MULTI_STATEMENTS, or COM_SET_OPTION).
+	multiStatements bool
+}
+
+func (m *conn) serve() {
+	defer func() {
+		m.nc.Close()
+		m.release()
+		m.srv.removeConn(m)
+	}()
+
+	if err := m.handshake(); err != nil {
+		u.Debugf("mysql handshake failed for %v err=%v", m.nc.RemoteAddr(), err)
+		return
+	}
+
+	for {
+		m.pc.seq = 0
+		deadline, lifetime := m.readDeadline()
+		m.nc.SetReadDeadline(deadline)
+		data, err := m.pc.readPacket()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				if lifetime {
+					u.Debugf("mysql conn %d exceeded max session lifetime", m.id)
+				} else {
+					u.Debugf("mysql conn %d idle timeout", m.id)
+				}
+				m.nc.SetWriteDeadline(time.Now().Add(time.Second))
+				m.pc.seq = 0
+				m.pc.writeError(erClientInteractionTimeout, "HY000",
+					"The client was disconnected by the server because of inactivity.")
+				m.pc.flush()
+			} else if err != io.EOF {
+				u.Debugf("mysql conn %d read err=%v", m.id, err)
+			}
+			return
+		}
+		if len(data) == 0 {
+			return
+		}
+		if data[0] == comQuit {
+			return
+		}
+		if err = m.dispatch(data[0], data[1:]); err != nil {
+			u.Debugf("mysql conn %d write err=%v", m.id, err)
+			return
+		}
+		if err = m.pc.flush(); err != nil {
+			return
+		}
+	}
+}
+
+// readDeadline the time to wait for the next command until, the
+// earliest of the idle timeout and the max session lifetime, and if
+// it was the lifetime.  Zero if neither is set.
+func (m *conn) readDeadline() (time.Time, bool) {
+	idle := m.ses.NewContext("").WaitTimeout()
+	if m.srv.IdleTimeout > 0 && (idle == 0 || m.srv.IdleTimeout < idle) {
+		idle = m.srv.IdleTimeout
+	}
+	var deadline time.Time
+	if idle > 0 {
+		deadline = time.Now().Add(idle)
+	}
+	if m.srv.MaxSessionLifetime > 0 {
+		end := m.started.Add(m.srv.MaxSessionLifetime)
+		if deadline.IsZero() || end.Before(deadline) {
+			return end, true
+		}
+	}
+	return deadline, false
+}
+
+// release the per-session resources, prepared statements, temporary
+// tables and the rows held for open cursors.
+func (m *conn) release() {
+	m.stmts = make(map[uint32]*stmt)
+	if ct := exec.ReleaseCursors(m.ses.Vars); ct > 0 {
+		u.Debugf("mysql conn %d released %d cursors", m.id, ct)
+	}
+	m.ses.Close()
+}
+
+func (m *conn) handshake() error {
+
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	plugin := m.srv.AuthPlugin
+	if plugin == "" {
+		plugin = AuthNativePassword
+	}
+
+	if err = m.pc.writePacket(handshakePacket(m.srv.Version, m.id, salt, plugin)); err != nil {
+		return err
+	}
+	if err = m.pc.flush(); err != nil {
+		return err
+	}
+
+	data, err := m.pc.readPacket()
+	if err != nil {
+		return err
+	}
+	hr, err := parseHandshakeResponse(data)
+	if err != nil {
+		m.pc.writeErr(err, erUnknown, "08S01")
+		m.pc.flush()
+		return err
+	}
+
+	// client may have answered with a different auth plugin, ask for ours
+	authData := hr.authData
+	if hr.capabilities&clientPluginAuth != 0 && hr.plugin != "" && hr.plugin != plugin {
+		if err = m.pc.writePacket(authSwitchPacket(plugin, salt)); err != nil {
+			return err
+		}
+		if err = m.pc.flush(); err != nil {
+			return err
+		}
+		if authData, err = m.pc.readPacket(); err != nil {
+			return err
+		}
+	}
+
+	if m.srv.Users != nil {
+		password, ok := m.srv.Users[hr.user]
+		if !ok || !checkPassword(plugin, salt, authData, password) {
+			m.pc.writeError(erAccessDenied, "28000", fmt.Sprintf("Access denied for user '%s'", hr.user))
+			m.pc.flush()
+			return fmt.Errorf("QLBridge.mysqlserver: access denied for %q", hr.user)
+		}
+	}
+	m.ses.User = hr.user
+	m.multiStatements = hr.capabilities&clientMultiStatements != 0
+
+	// clients may limit their own queries with connection attributes
+	if m.ses.Budget, err = plan.ParseBudget(hr.attrs); err != nil {
+		m.pc.writeErr(err, erUnknown, "HY000")
+		m.pc.flush()
+		return err
+	}
+
+	db := hr.db
+	if db == "" {
+		db = m.srv.Schema
+	}
+	if db != "" {
+		if err = m.useSchema(db); err != nil {
+			m.pc.writeErr(err, erBadDB, "42000")
+			m.pc.flush()
+			return err
+		}
+	}
+
+	if err = m.pc.writeOK(0, 0); err != nil {
+		return err
+	}
+	return m.pc.flush()
+}
+
+func (m *conn) useSchema(name string) error {
+	return m.ses.Use(name)
+}
+
+// dispatch a single command, writing its response.
+func (m *conn) dispatch(cmd byte, data []byte) error {
+	switch cmd {
+	case comPing:
+		return m.pc.writeOK(0, 0)
+	case comInitDB:
+		if err := m.useSchema(string(data)); err != nil {
+			return m.pc.writeErr(err, erBadDB, "42000")
+		}
+		return m.pc.writeOK(0, 0)
+	case comQuery:
+		return m.queries(string(data))
+	case comFieldList:
+		// we don't support listing fields, an empty list is valid
+		return m.pc.writeEOF()
+	case comStmtPrepare:
+		return m.stmtPrepare(string(data))
+	case comStmtExecute:
+		return m.stmtExecute(data)
+	case comStmtSendLongData:
+		// no response
+		m.stmtSendLongData(data)
+		return nil
+	case comStmtClose:
+		// no response
+		if len(data) >= 4 {
+			delete(m.stmts, uint32(data[0])|uint32(data[1])<<8|uint32(data[2])<<16|uint32(data[3])<<24)
+		}
+		return nil
+	case comStmtReset:
+		return m.stmtReset(data)
+	case comSetOption:
+		if len(data) >= 2 {
+			switch uint16(data[0]) | uint16(data[1])<<8 {
+			case optionMultiStatementsOn:
+				m.multiStatements = true
+			case optionMultiStatementsOff:
+				m.multiStatements = false
+			}
+		}
+		return m.pc.writeEOF()
+	}
+	return m.pc.writeError(erUnknownCom, "08S01", fmt.Sprintf("Unknown command %d", cmd))
+}
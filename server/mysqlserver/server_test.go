@@ -0,0 +1,367 @@
+package mysqlserver_test
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/server/mysqlserver"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+}
+
+func startServer(t *testing.T) (string, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	srv := mysqlserver.NewServer()
+	srv.Users = map[string]string{"root": "secret"}
+	go srv.Serve(lis)
+	return lis.Addr().String(), func() { srv.Close() }
+}
+
+func TestMysqlServer(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root:secret@tcp(%s)/mockcsv", addr))
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	assert.Equal(t, nil, db.Ping())
+
+	// text protocol
+	rows, err := db.Query("SELECT user_id, email, referral_count FROM users WHERE user_id = \"9Ip1aKbeZe2njCDM\"")
+	assert.Equal(t, nil, err)
+	cols, _ := rows.Columns()
+	assert.Equal(t, []string{"user_id", "email", "referral_count"}, cols)
+	ct := 0
+	for rows.Next() {
+		var id, email string
+		var refCt int64
+		assert.Equal(t, nil, rows.Scan(&id, &email, &refCt))
+		assert.Equal(t, "aaron@email.com", email)
+		assert.Equal(t, int64(82), refCt)
+		ct++
+	}
+	assert.Equal(t, nil, rows.Err())
+	assert.Equal(t, 1, ct)
+
+	// prepared statement, binary protocol
+	var email string
+	err = db.QueryRow("SELECT email FROM users WHERE user_id = ?", "hT2impsOPUREcVPc").Scan(&email)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bob@email.com", email)
+
+	// quotes in parameters are escaped
+	err = db.QueryRow("SELECT email FROM users WHERE user_id = ?", "o'brien").Scan(&email)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	stmt, err := db.Prepare("SELECT user_id FROM users WHERE referral_count > ?")
+	assert.Equal(t, nil, err)
+	for _, tc := range []struct {
+		refCt int
+		rows  int
+	}{{0, 3}, {50, 1}, {100, 0}} {
+		rows, err = stmt.Query(tc.refCt)
+		assert.Equal(t, nil, err)
+		ct = 0
+		for rows.Next() {
+			ct++
+		}
+		assert.Equal(t, tc.rows, ct, "referral_count > %d", tc.refCt)
+	}
+	stmt.Close()
+
+	// session variables
+	var version string
+	assert.Equal(t, nil, db.QueryRow("SELECT @@version_comment LIMIT 1").Scan(&version))
+	assert.NotEqual(t, "", version)
+
+	// errors are reported with their mysql error codes
+	errCode := func(err error) uint16 {
+		if me, ok := err.(*mysql.MySQLError); ok {
+			return me.Number
+		}
+		return 0
+	}
+	_, err = db.Query("SELECT a FROM t WHERE x = = 1")
+	assert.Equal(t, uint16(1064), errCode(err))
+	_, err = db.Query("SELECT * FROM not_a_table")
+	assert.Equal(t, uint16(1146), errCode(err))
+	_, err = db.Exec("SET @@not_a_variable = 1")
+	assert.Equal(t, uint16(1193), errCode(err))
+
+	_, err = db.Exec("USE not_a_schema")
+	assert.Equal(t, uint16(1049), errCode(err))
+}
+
+func TestMysqlServerAuth(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root:wrong@tcp(%s)/mockcsv", addr))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, db.Ping())
+	db.Close()
+
+	db, err = sql.Open("mysql", fmt.Sprintf("root:secret@tcp(%s)/not_a_schema", addr))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, db.Ping())
+	db.Close()
+}
+
+// rawConn a minimal mysql client, to send connection attributes.
+type rawConn struct {
+	t  *testing.T
+	nc net.Conn
+}
+
+func (m *rawConn) read() []byte {
+	hdr := make([]byte, 4)
+	_, err := io.ReadFull(m.nc, hdr)
+	assert.Equal(m.t, nil, err)
+	data := make([]byte, int(hdr[0])|int(hdr[1])<<8|int(hdr[2])<<16)
+	_, err = io.ReadFull(m.nc, data)
+	assert.Equal(m.t, nil, err)
+	return data
+}
+
+func (m *rawConn) write(seq byte, data []byte) {
+	hdr := []byte{byte(len(data)), byte(len(data) >> 8), byte(len(data) >> 16), seq}
+	_, err := m.nc.Write(append(hdr, data...))
+	assert.Equal(m.t, nil, err)
+}
+
+func lenEnc(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// connectWithAttrs handshake, sending attrs, returns the servers reply.
+func connectWithAttrs(t *testing.T, addr string, attrs ...string) (*rawConn, []byte) {
+	nc, err := net.Dial("tcp", addr)
+	assert.Equal(t, nil, err)
+	c := &rawConn{t: t, nc: nc}
+	c.read() // server handshake
+
+	// protocol41 | connect with db | secure conn | plugin auth | connect attrs
+	caps := uint32(0x200 | 0x08 | 0x8000 | 0x80000 | 0x100000)
+	data := []byte{byte(caps), byte(caps >> 8), byte(caps >> 16), byte(caps >> 24)}
+	data = append(data, 0, 0, 0, 1, 33)
+	data = append(data, make([]byte, 23)...)
+	data = append(data, "root\x00"...)
+	data = append(data, 0) // no auth data
+	data = append(data, "mockcsv\x00"...)
+	data = append(data, "mysql_native_password\x00"...)
+	var kv []byte
+	for _, a := range attrs {
+		kv = append(kv, lenEnc(a)...)
+	}
+	data = append(data, byte(len(kv)))
+	data = append(data, kv...)
+	c.write(1, data)
+	return c, c.read()
+}
+
+// query the rows, and error packet if any, of sql.
+func (m *rawConn) query(sql string) (int, []byte) {
+	m.write(0, append([]byte{0x03}, sql...))
+	return m.result()
+}
+
+// result read the rows, and error packet if any, of the next result.
+func (m *rawConn) result() (int, []byte) {
+	data := m.read()
+	switch data[0] {
+	case 0xff:
+		return 0, data
+	case 0x00:
+		return 0, nil
+	}
+	// column definitions, then eof
+	for data = m.read(); data[0] != 0xfe; data = m.read() {
+	}
+	rows := 0
+	for {
+		data = m.read()
+		switch {
+		case data[0] == 0xff:
+			return rows, data
+		case data[0] == 0xfe && len(data) < 9:
+			return rows, nil
+		}
+		rows++
+	}
+}
+
+func TestMysqlServerMultiStatements(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root:secret@tcp(%s)/mockcsv?multiStatements=true", addr))
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	countRows := func(rows *sql.Rows) int {
+		ct := 0
+		for rows.Next() {
+			ct++
+		}
+		return ct
+	}
+
+	// each statement is a result set, in order
+	rows, err := db.Query(`SELECT user_id FROM users; SELECT email FROM users WHERE user_id = "hT2impsOPUREcVPc"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, countRows(rows))
+	assert.True(t, rows.NextResultSet())
+	assert.Equal(t, 1, countRows(rows))
+	assert.False(t, rows.NextResultSet())
+	assert.Equal(t, nil, rows.Err())
+	rows.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	srv := mysqlserver.NewServer()
+	go srv.Serve(lis)
+	defer srv.Close()
+	addr = lis.Addr().String()
+
+	// clients without CLIENT_MULTI_STATEMENTS may turn it on
+	c, ok := connectWithAttrs(t, addr)
+	assert.Equal(t, byte(0), ok[0])
+	c.write(0, []byte{0x1b, 0, 0})
+	assert.Equal(t, byte(0xfe), c.read()[0])
+
+	// an error ends the sequence, later statements are not run
+	c.write(0, append([]byte{0x03}, "SELECT user_id FROM users; SELECT * FROM not_a_table; SELECT email FROM users"...))
+	rowCt, errData := c.result()
+	assert.Equal(t, 3, rowCt)
+	assert.Equal(t, []byte(nil), errData)
+	rowCt, errData = c.result()
+	assert.Equal(t, 0, rowCt)
+	assert.Equal(t, uint16(1146), uint16(errData[1])|uint16(errData[2])<<8)
+	rowCt, errData = c.query("SELECT user_id FROM users LIMIT 1")
+	assert.Equal(t, 1, rowCt)
+	assert.Equal(t, []byte(nil), errData)
+	c.nc.Close()
+
+	// pipelined queries, sent without waiting, are answered in order
+	c, ok = connectWithAttrs(t, addr)
+	assert.Equal(t, byte(0), ok[0])
+	c.write(0, append([]byte{0x03}, "SELECT user_id FROM users"...))
+	c.write(0, append([]byte{0x03}, "SELECT user_id FROM users LIMIT 1"...))
+	rowCt, errData = c.result()
+	assert.Equal(t, 3, rowCt)
+	assert.Equal(t, []byte(nil), errData)
+	rowCt, errData = c.result()
+	assert.Equal(t, 1, rowCt)
+	assert.Equal(t, []byte(nil), errData)
+	c.nc.Close()
+}
+
+func TestMysqlServerBudget(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	srv := mysqlserver.NewServer()
+	go srv.Serve(lis)
+	defer srv.Close()
+	addr := lis.Addr().String()
+
+	errCode := func(data []byte) uint16 {
+		if len(data) < 3 || data[0] != 0xff {
+			return 0
+		}
+		return uint16(data[1]) | uint16(data[2])<<8
+	}
+
+	// no budget
+	c, ok := connectWithAttrs(t, addr, "_client_name", "test")
+	assert.Equal(t, byte(0), ok[0])
+	rows, errData := c.query("SELECT user_id FROM users")
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, uint16(0), errCode(errData))
+	c.nc.Close()
+
+	// the client limits its queries to 2 rows
+	c, ok = connectWithAttrs(t, addr, "_client_name", "test", "qlb_max_rows", "2")
+	assert.Equal(t, byte(0), ok[0])
+	rows, errData = c.query("SELECT user_id FROM users")
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, uint16(1317), errCode(errData))
+	rows, errData = c.query("SELECT user_id FROM users LIMIT 2")
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, uint16(0), errCode(errData))
+	c.nc.Close()
+
+	// and bytes
+	c, ok = connectWithAttrs(t, addr, "qlb_max_bytes", "20")
+	assert.Equal(t, byte(0), ok[0])
+	rows, errData = c.query("SELECT user_id FROM users")
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, uint16(1317), errCode(errData))
+	c.nc.Close()
+
+	// invalid budgets are rejected at connect
+	c, ok = connectWithAttrs(t, addr, "qlb_max_rows", "lots")
+	assert.Equal(t, byte(0xff), ok[0])
+	c.nc.Close()
+}
+
+func TestMysqlServerIdleTimeout(t *testing.T) {
+	start := func(idle, lifetime time.Duration) (string, func()) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Equal(t, nil, err)
+		srv := mysqlserver.NewServer()
+		srv.IdleTimeout = idle
+		srv.MaxSessionLifetime = lifetime
+		go srv.Serve(lis)
+		return lis.Addr().String(), func() { srv.Close() }
+	}
+	addr, stop := start(200*time.Millisecond, 0)
+	defer stop()
+
+	// active connections stay open
+	c, ok := connectWithAttrs(t, addr)
+	assert.Equal(t, byte(0), ok[0])
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		rows, errData := c.query("SELECT user_id FROM users")
+		assert.Equal(t, 3, rows)
+		assert.Equal(t, []byte(nil), errData)
+	}
+
+	// idle ones are told why, and closed
+	time.Sleep(400 * time.Millisecond)
+	data := c.read()
+	assert.Equal(t, byte(0xff), data[0])
+	assert.Equal(t, uint16(4031), uint16(data[1])|uint16(data[2])<<8)
+	_, err := c.nc.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+	c.nc.Close()
+
+	// clients may lower, not raise, the idle timeout
+	c, _ = connectWithAttrs(t, addr)
+	_, errData := c.query("SET SESSION wait_timeout = 3600")
+	assert.Equal(t, []byte(nil), errData)
+	time.Sleep(400 * time.Millisecond)
+	assert.Equal(t, byte(0xff), c.read()[0])
+	c.nc.Close()
+
+	// and closed once past their max lifetime
+	addr, stop = start(0, 300*time.Millisecond)
+	defer stop()
+	c, _ = connectWithAttrs(t, addr)
+	_, errData = c.query("SELECT user_id FROM users")
+	assert.Equal(t, []byte(nil), errData)
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, byte(0xff), c.read()[0])
+	c.nc.Close()
+}
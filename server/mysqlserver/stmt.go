@@ -0,0 +1,321 @@
+package mysqlserver
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stmt is a server side prepared statement, parameters are bound
+// by interpolating them into the sql on each execute.
+type stmt struct {
+	id       uint32
+	sql      string
+	params   int
+	types    []byte
+	longData map[int][]byte
+}
+
+// stmtPrepare COM_STMT_PREPARE, we don't know result columns until
+// execute so send none, clients read them from the result-set.
+func (m *conn) stmtPrepare(sql string) error {
+	m.stmtID++
+	st := &stmt{id: m.stmtID, sql: sql, params: countParams(sql)}
+	m.stmts[st.id] = st
+
+	data := []byte{iOK}
+	data = appendUint32(data, st.id)
+	data = appendUint16(data, 0) // columns
+	data = appendUint16(data, uint16(st.params))
+	data = append(data, 0)
+	data = appendUint16(data, 0) // warnings
+	if err := m.pc.writePacket(data); err != nil {
+		return err
+	}
+	if st.params == 0 {
+		return nil
+	}
+	for i := 0; i < st.params; i++ {
+		if err := m.pc.writePacket(columnDefinition("", &column{name: "?", typ: mysqlTypeVarString})); err != nil {
+			return err
+		}
+	}
+	return m.pc.writeEOF()
+}
+
+func (m *conn) stmtExecute(data []byte) error {
+	if len(data) < 9 {
+		return m.pc.writeError(erWrongArguments, "HY000", "Incorrect arguments to mysqld_stmt_execute")
+	}
+	st, ok := m.stmts[binary.LittleEndian.Uint32(data[:4])]
+	if !ok {
+		return m.pc.writeError(erUnknownStmtHandler, "HY000", "Unknown prepared statement handler given to mysqld_stmt_execute")
+	}
+	// skip flags(1), iteration-count(4)
+	args, err := st.bindParams(data[9:])
+	st.longData = nil
+	if err != nil {
+		return m.pc.writeErr(err, erWrongArguments, "HY000")
+	}
+	sql, err := interpolateParams(st.sql, args)
+	if err != nil {
+		return m.pc.writeErr(err, erWrongArguments, "HY000")
+	}
+	return m.query(sql, true)
+}
+
+func (m *conn) stmtSendLongData(data []byte) {
+	if len(data) < 6 {
+		return
+	}
+	st, ok := m.stmts[binary.LittleEndian.Uint32(data[:4])]
+	if !ok {
+		return
+	}
+	if st.longData == nil {
+		st.longData = make(map[int][]byte)
+	}
+	idx := int(binary.LittleEndian.Uint16(data[4:6]))
+	st.longData[idx] = append(st.longData[idx], data[6:]...)
+}
+
+func (m *conn) stmtReset(data []byte) error {
+	if len(data) < 4 {
+		return m.pc.writeError(erWrongArguments, "HY000", "Incorrect arguments to mysqld_stmt_reset")
+	}
+	st, ok := m.stmts[binary.LittleEndian.Uint32(data[:4])]
+	if !ok {
+		return m.pc.writeError(erUnknownStmtHandler, "HY000", "Unknown prepared statement handler given to mysqld_stmt_reset")
+	}
+	st.longData = nil
+	return m.pc.writeOK(0, 0)
+}
+
+// bindParams decode the binary protocol parameter values of execute.
+func (m *stmt) bindParams(data []byte) ([]driver.Value, error) {
+
+	if m.params == 0 {
+		return nil, nil
+	}
+	errShort := fmt.Errorf("QLBridge.mysqlserver: malformed execute parameters")
+
+	nullLen := (m.params + 7) / 8
+	if len(data) < nullLen+1 {
+		return nil, errShort
+	}
+	nullMap := data[:nullLen]
+	pos := nullLen
+	if data[pos] == 1 {
+		// new-params-bound, types are sent, 2 bytes each (type, unsigned flag)
+		pos++
+		if len(data) < pos+2*m.params {
+			return nil, errShort
+		}
+		m.types = make([]byte, 2*m.params)
+		copy(m.types, data[pos:pos+2*m.params])
+		pos += 2 * m.params
+	} else {
+		pos++
+	}
+	if len(m.types) != 2*m.params {
+		return nil, fmt.Errorf("QLBridge.mysqlserver: parameter types were never bound")
+	}
+
+	args := make([]driver.Value, m.params)
+	for i := range args {
+		if nullMap[i/8]&(1<<(uint(i)%8)) != 0 {
+			continue
+		}
+		if ld, ok := m.longData[i]; ok {
+			args[i] = ld
+			continue
+		}
+		typ, unsigned := m.types[2*i], m.types[2*i+1]&0x80 != 0
+		v, n, err := readBinaryValue(data[pos:], typ, unsigned)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+		pos += n
+	}
+	return args, nil
+}
+
+// readBinaryValue read a single binary protocol value, returns value
+// and number of bytes read.
+func readBinaryValue(b []byte, typ byte, unsigned bool) (driver.Value, int, error) {
+	errShort := fmt.Errorf("QLBridge.mysqlserver: malformed parameter of type %d", typ)
+	switch typ {
+	case mysqlTypeNull:
+		return nil, 0, nil
+	case mysqlTypeTiny:
+		if len(b) < 1 {
+			return nil, 0, errShort
+		}
+		if unsigned {
+			return int64(b[0]), 1, nil
+		}
+		return int64(int8(b[0])), 1, nil
+	case mysqlTypeShort, mysqlTypeYear:
+		if len(b) < 2 {
+			return nil, 0, errShort
+		}
+		n := binary.LittleEndian.Uint16(b)
+		if unsigned {
+			return int64(n), 2, nil
+		}
+		return int64(int16(n)), 2, nil
+	case mysqlTypeLong, mysqlTypeInt24:
+		if len(b) < 4 {
+			return nil, 0, errShort
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if unsigned {
+			return int64(n), 4, nil
+		}
+		return int64(int32(n)), 4, nil
+	case mysqlTypeLongLong:
+		if len(b) < 8 {
+			return nil, 0, errShort
+		}
+		n := binary.LittleEndian.Uint64(b)
+		if unsigned && n > math.MaxInt64 {
+			return strconv.FormatUint(n, 10), 8, nil
+		}
+		return int64(n), 8, nil
+	case mysqlTypeFloat:
+		if len(b) < 4 {
+			return nil, 0, errShort
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), 4, nil
+	case mysqlTypeDouble:
+		if len(b) < 8 {
+			return nil, 0, errShort
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), 8, nil
+	case mysqlTypeDate, mysqlTypeDatetime, mysqlTypeTimestamp:
+		if len(b) < 1 || len(b) < 1+int(b[0]) {
+			return nil, 0, errShort
+		}
+		n := int(b[0])
+		var year, month, day, hour, min, sec, micro int
+		if n >= 4 {
+			year, month, day = int(binary.LittleEndian.Uint16(b[1:3])), int(b[3]), int(b[4])
+		}
+		if n >= 7 {
+			hour, min, sec = int(b[5]), int(b[6]), int(b[7])
+		}
+		if n >= 11 {
+			micro = int(binary.LittleEndian.Uint32(b[8:12]))
+		}
+		return time.Date(year, time.Month(month), day, hour, min, sec, micro*1000, time.UTC), 1 + n, nil
+	case mysqlTypeTime:
+		if len(b) < 1 || len(b) < 1+int(b[0]) {
+			return nil, 0, errShort
+		}
+		n := int(b[0])
+		if n < 8 {
+			return "00:00:00", 1 + n, nil
+		}
+		// is-negative(1) days(4) hour(1) min(1) sec(1)
+		sign := ""
+		if b[1] == 1 {
+			sign = "-"
+		}
+		hours := int(binary.LittleEndian.Uint32(b[2:6]))*24 + int(b[6])
+		return fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, b[7], b[8]), 1 + n, nil
+	}
+	// strings, blobs, decimals, json are all length encoded
+	by, n := readLenEncBytes(b)
+	if n == 0 {
+		return nil, 0, errShort
+	}
+	return string(by), n, nil
+}
+
+// countParams the number of ? placeholders outside of quotes.
+func countParams(sql string) int {
+	ct := 0
+	scanParams(sql, func(int) { ct++ })
+	return ct
+}
+
+// scanParams call @fn with the position of each ? placeholder outside
+// of quoted strings and identities.
+func scanParams(sql string, fn func(pos int)) {
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			fn(i)
+		}
+	}
+}
+
+// interpolateParams replace ? placeholders with the sql literal of args.
+func interpolateParams(sql string, args []driver.Value) (string, error) {
+	if len(args) == 0 {
+		return sql, nil
+	}
+	var buf strings.Builder
+	last, i := 0, 0
+	var err error
+	scanParams(sql, func(pos int) {
+		if i >= len(args) {
+			err = fmt.Errorf("QLBridge.mysqlserver: more placeholders than parameters")
+			return
+		}
+		buf.WriteString(sql[last:pos])
+		buf.WriteString(literal(args[i]))
+		last = pos + 1
+		i++
+	})
+	if err != nil {
+		return "", err
+	}
+	if i != len(args) {
+		return "", fmt.Errorf("QLBridge.mysqlserver: expected %d parameters got %d", i, len(args))
+	}
+	buf.WriteString(sql[last:])
+	return buf.String(), nil
+}
+
+func literal(v driver.Value) string {
+	switch vt := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(vt, 10)
+	case float64:
+		return strconv.FormatFloat(vt, 'g', -1, 64)
+	case bool:
+		if vt {
+			return "true"
+		}
+		return "false"
+	case time.Time:
+		return quote(vt.Format(mysqlTimeFormat))
+	case []byte:
+		return quote(string(vt))
+	case string:
+		return quote(vt)
+	}
+	return quote(fmt.Sprintf("%v", v))
+}
+
+func quote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
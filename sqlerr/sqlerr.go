@@ -0,0 +1,191 @@
+// Package sqlerr is the typed error model of qlbridge, errors carry a MySQL
+// error code and SQLSTATE, the position in the sql they occurred at, and
+// the error they were caused by, so protocol frontends (mysql server, sql
+// driver) can report them as their clients expect.
+package sqlerr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code is a MySQL server error number.
+type Code uint16
+
+// MySQL error codes, of
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	ErOutOfMemory           Code = 1037
+	ErConCount              Code = 1040
+	ErAccessDenied          Code = 1045
+	ErNoDB                  Code = 1046
+	ErUnknownCom            Code = 1047
+	ErBadDB                 Code = 1049
+	ErTableExists           Code = 1050
+	ErBadField              Code = 1054
+	ErParse                 Code = 1064
+	ErUnknown               Code = 1105
+	ErWrongValueCount       Code = 1136
+	ErNoSuchTable           Code = 1146
+	ErTooManyRows           Code = 1172
+	ErUnknownSystemVariable Code = 1193
+	ErWrongArguments        Code = 1210
+	ErWrongNumberOfColumns  Code = 1222
+	ErWrongValueForVar      Code = 1231
+	ErNotSupported          Code = 1235
+	ErReadOnlyVariable      Code = 1238
+	ErUnknownProcedure      Code = 1305
+	ErQueryInterrupted      Code = 1317
+	ErTruncatedWrongValue   Code = 1366
+	ErQueryTimeout          Code = 3024
+)
+
+// states the default SQLSTATE of each code, HY000 if not listed.
+var states = map[Code]string{
+	ErOutOfMemory:          "HY001",
+	ErConCount:             "08004",
+	ErAccessDenied:         "28000",
+	ErNoDB:                 "3D000",
+	ErUnknownCom:           "08S01",
+	ErBadDB:                "42000",
+	ErTableExists:          "42S01",
+	ErBadField:             "42S22",
+	ErParse:                "42000",
+	ErWrongValueCount:      "21S01",
+	ErNoSuchTable:          "42S02",
+	ErTooManyRows:          "42000",
+	ErWrongNumberOfColumns: "21000",
+	ErWrongValueForVar:     "42000",
+	ErNotSupported:         "42000",
+	ErUnknownProcedure:     "42000",
+	ErQueryInterrupted:     "70100",
+}
+
+// State the default SQLSTATE of the code.
+func (c Code) State() string {
+	if s, ok := states[c]; ok {
+		return s
+	}
+	return "HY000"
+}
+
+// Error is a qlbridge error.
+type Error struct {
+	Code    Code   // MySQL error number
+	State   string // SQLSTATE
+	Message string
+	Line    int   // Line of the sql the error is at, 0 if unknown
+	Column  int   // Column of that line
+	Pos     int   // Absolute position in the sql
+	Cause   error // underlying error, optional
+}
+
+// New error of code, with the default SQLSTATE of the code.
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, State: code.State(), Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap cause as an error of code, the message is of the cause.
+func Wrap(code Code, cause error) *Error {
+	if cause == nil {
+		return nil
+	}
+	return &Error{Code: code, State: code.State(), Message: cause.Error(), Cause: cause}
+}
+
+// At the error with the position in the sql it occurred.
+func (e *Error) At(line, column, pos int) *Error {
+	e.Line, e.Column, e.Pos = line, column, pos
+	return e
+}
+
+// Error message.
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap the cause.
+func (e *Error) Unwrap() error { return e.Cause }
+
+// Snippet the line of sql the error is at, with a caret under its column,
+// empty if the position is not known.
+//
+//     SELECT a FROM t WHERE x = = 1
+//                               ^
+//
+func (e *Error) Snippet(sql string) string {
+	lines := strings.Split(sql, "\n")
+	if e.Line < 1 || e.Line > len(lines) {
+		return ""
+	}
+	line := strings.TrimRight(lines[e.Line-1], "\r")
+	col := e.Column - 1
+	if col < 0 {
+		col = 0
+	} else if col > len(line) {
+		col = len(line)
+	}
+	var caret strings.Builder
+	for _, r := range line[:col] {
+		// keep tabs so the caret lines up
+		if r == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	caret.WriteByte('^')
+	return line + "\n" + caret.String()
+}
+
+// Is errors of the same code and message are the same, for errors.Is of
+// the exported error variables.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Message == e.Message
+}
+
+// As the first *Error of err or the errors it wraps, false if none.
+func As(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+	e := find(err)
+	return e, e != nil
+}
+
+// FromError the first *Error of err or the errors it wraps, else an
+// ErUnknown error of err.  Nil for a nil err.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := As(err); ok {
+		return e
+	}
+	return Wrap(ErUnknown, err)
+}
+
+func find(err error) *Error {
+	switch et := err.(type) {
+	case *Error:
+		return et
+	case interface{ Unwrap() []error }:
+		for _, e := range et.Unwrap() {
+			if se := find(e); se != nil {
+				return se
+			}
+		}
+	case interface{ Unwrap() error }:
+		if e := et.Unwrap(); e != nil {
+			return find(e)
+		}
+	}
+	return nil
+}
+
+// CodeOf the error code of err, ErUnknown if not typed.
+func CodeOf(err error) Code {
+	if e := FromError(err); e != nil {
+		return e.Code
+	}
+	return ErUnknown
+}
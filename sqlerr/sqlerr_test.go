@@ -0,0 +1,44 @@
+package sqlerr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/sqlerr"
+)
+
+func TestErrors(t *testing.T) {
+	err := sqlerr.New(sqlerr.ErNoSuchTable, "Table %q doesn't exist", "users")
+	assert.Equal(t, `Table "users" doesn't exist`, err.Error())
+	assert.Equal(t, "42S02", err.State)
+	assert.Equal(t, "HY000", sqlerr.ErUnknown.State())
+
+	// typed errors are found through wrapping
+	cause := fmt.Errorf("connection refused")
+	wrapped := sqlerr.Wrap(sqlerr.ErQueryInterrupted, cause)
+	assert.Equal(t, cause, wrapped.Unwrap())
+	outer := fmt.Errorf("running query: %w", wrapped)
+	e, ok := sqlerr.As(outer)
+	assert.True(t, ok)
+	assert.Equal(t, sqlerr.ErQueryInterrupted, e.Code)
+	assert.Equal(t, sqlerr.ErQueryInterrupted, sqlerr.CodeOf(outer))
+
+	// untyped errors are unknown
+	_, ok = sqlerr.As(cause)
+	assert.False(t, ok)
+	assert.Equal(t, sqlerr.ErUnknown, sqlerr.FromError(cause).Code)
+	assert.True(t, sqlerr.FromError(nil) == nil)
+	assert.True(t, sqlerr.Wrap(sqlerr.ErUnknown, nil) == nil)
+
+	// parse errors have the position of the error
+	_, perr := rel.ParseSql("SELECT a FROM t WHERE\n  x = = 1")
+	assert.NotEqual(t, nil, perr)
+	e, ok = sqlerr.As(perr)
+	assert.True(t, ok)
+	assert.Equal(t, sqlerr.ErParse, e.Code)
+	assert.Equal(t, "42000", e.State)
+	assert.Equal(t, 2, e.Line)
+}
@@ -0,0 +1,156 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+)
+
+var (
+	// UpdateGoldenEnv env var, if set CheckGoldenPlan re-writes the golden
+	// file instead of comparing against it.
+	UpdateGoldenEnv = "QLBRIDGE_UPDATE_GOLDEN"
+)
+
+// CanonicalPlan plan the sql (without running it) and serialize the plan
+// one task per line, indented by depth, properties sorted, so the same
+// plan always gives the same text.
+//
+//     select orders
+//       scan orders conn=*mockcsv.Table cost=2000.00 rows=900
+//       group by user_id
+//       projection user_id, count(*) AS ct final=true
+//
+func CanonicalPlan(ctx *plan.Context) (string, error) {
+	stmt, err := rel.ParseSql(ctx.Raw)
+	if err != nil {
+		return "", err
+	}
+	ctx.Stmt = stmt
+	p, err := plan.WalkStmt(ctx, stmt, plan.NewPlanner(ctx))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	writePlanNode(&buf, plan.PlanTree(p), 0)
+	return buf.String(), nil
+}
+
+func writePlanNode(buf *bytes.Buffer, n *plan.PlanNode, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(n.Op)
+	if n.Source != "" {
+		buf.WriteString(" ")
+		buf.WriteString(n.Source)
+	}
+	if len(n.Exprs) > 0 {
+		buf.WriteString(" ")
+		buf.WriteString(strings.Join(n.Exprs, ", "))
+	}
+	if len(n.Partitions) > 0 {
+		fmt.Fprintf(buf, " partitions=[%s]", strings.Join(n.Partitions, "; "))
+	}
+	keys := make([]string, 0, len(n.Props))
+	for k := range n.Props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(buf, " %s=%s", k, n.Props[k])
+	}
+	buf.WriteString("\n")
+	for _, c := range n.Children {
+		writePlanNode(buf, c, depth+1)
+	}
+}
+
+// CheckGoldenPlan compare the canonical plan of the sql to the golden
+// file at path, an error with a line diff if they differ.  The golden
+// file starts with the sql as a -- comment.  If the file does not exist,
+// or env QLBRIDGE_UPDATE_GOLDEN is set, it is written instead.
+func CheckGoldenPlan(t TestingT, path string, newCtx func(sql string) *plan.Context, sql string) {
+	got, err := CanonicalPlan(newCtx(sql))
+	if err != nil {
+		t.Errorf("could not plan %q: %v", sql, err)
+		return
+	}
+	got = "-- " + strings.Replace(sql, "\n", " ", -1) + "\n" + got
+
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) || os.Getenv(UpdateGoldenEnv) != "" {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = ioutil.WriteFile(path, []byte(got), 0644)
+		}
+		if err != nil {
+			t.Errorf("could not write golden plan %q: %v", path, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	if diff := DiffLines(string(want), got); diff != "" {
+		t.Errorf("plan differs from golden %q (set %s=1 to update)\n%s", path, UpdateGoldenEnv, diff)
+	}
+}
+
+// CheckGoldenPlans check each query against a golden file in dir, named
+// by its position in queries, 001.plan, 002.plan etc.
+func CheckGoldenPlans(t TestingT, dir string, newCtx func(sql string) *plan.Context, queries []string) {
+	for i, sql := range queries {
+		CheckGoldenPlan(t, filepath.Join(dir, fmt.Sprintf("%03d.plan", i+1)), newCtx, sql)
+	}
+}
+
+// DiffLines a unified style line diff of want and got, lines only in
+// want prefixed by -, only in got by +.  Empty if they are equal.
+func DiffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// longest common subsequence of lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			buf.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			buf.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			buf.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return buf.String()
+}
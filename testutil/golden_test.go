@@ -0,0 +1,65 @@
+package testutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+)
+
+type errRecorder struct {
+	errs []string
+}
+
+func (m *errRecorder) Errorf(format string, args ...interface{}) {
+	m.errs = append(m.errs, fmt.Sprintf(format, args...))
+}
+
+func TestGoldenPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qlb-golden")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	sql := `SELECT user_id, count(*) AS ct FROM orders WHERE item_id != "" GROUP BY user_id`
+	p1, err := CanonicalPlan(td.TestContext(sql))
+	assert.Equal(t, nil, err)
+	p2, err := CanonicalPlan(td.TestContext(sql))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, p1, p2)
+	assert.True(t, strings.HasPrefix(p1, "select orders\n"), p1)
+	assert.Contains(t, p1, "\n  group by user_id")
+	assert.Contains(t, p1, "scan orders")
+
+	_, err = CanonicalPlan(td.TestContext("NOT SQL"))
+	assert.NotEqual(t, nil, err)
+
+	// first check writes the golden file, then compares to it
+	path := filepath.Join(dir, "plans", "agg.plan")
+	rec := &errRecorder{}
+	CheckGoldenPlan(rec, path, td.TestContext, sql)
+	CheckGoldenPlan(rec, path, td.TestContext, sql)
+	assert.Equal(t, 0, len(rec.errs), "%v", rec.errs)
+	by, err := ioutil.ReadFile(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "-- "+sql+"\n"+p1, string(by))
+
+	// a changed plan is an error with a readable diff
+	assert.Equal(t, nil, ioutil.WriteFile(path, []byte(strings.Replace(string(by), "group by", "order by", 1)), 0644))
+	CheckGoldenPlan(rec, path, td.TestContext, sql)
+	assert.Equal(t, 1, len(rec.errs))
+	assert.Contains(t, rec.errs[0], "\n-   order by user_id")
+	assert.Contains(t, rec.errs[0], "\n+   group by user_id")
+
+	CheckGoldenPlans(rec, dir, td.TestContext, []string{sql, "SELECT email FROM users"})
+	_, err = os.Stat(filepath.Join(dir, "002.plan"))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "", DiffLines("a\nb\n", "a\nb\n"))
+	assert.Equal(t, "  a\n- b\n+ c\n  d\n", DiffLines("a\nb\nd\n", "a\nc\nd\n"))
+}
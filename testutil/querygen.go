@@ -0,0 +1,251 @@
+package testutil
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// QueryGenConfig the mix of features in generated queries, each the
+// probability (0-1) a query uses it.
+type QueryGenConfig struct {
+	Distinct float64
+	Where    float64
+	Join     float64
+	GroupBy  float64
+	OrderBy  float64
+	Limit    float64
+	Funcs    float64 // probability a projected column is wrapped in a function
+	MaxDepth int     // max depth of AND/OR/NOT in the where expression
+}
+
+// DefaultQueryGenConfig a mix using each feature regularly.
+func DefaultQueryGenConfig() QueryGenConfig {
+	return QueryGenConfig{
+		Distinct: 0.1,
+		Where:    0.7,
+		Join:     0.3,
+		GroupBy:  0.3,
+		OrderBy:  0.4,
+		Limit:    0.3,
+		Funcs:    0.2,
+		MaxDepth: 3,
+	}
+}
+
+// QueryGen generates random, valid SELECT statements against the tables
+// of a schema, for fuzz and differential testing.  The same seed gives
+// the same queries.
+//
+//     qg := testutil.NewQueryGen(1, testutil.DefaultQueryGenConfig(), users, orders)
+//     sql := qg.Select()
+//
+type QueryGen struct {
+	cfg    QueryGenConfig
+	rnd    *rand.Rand
+	tables []*schema.Table
+}
+
+// NewQueryGen create a generator over the tables.
+func NewQueryGen(seed int64, cfg QueryGenConfig, tables ...*schema.Table) *QueryGen {
+	return &QueryGen{cfg: cfg, rnd: rand.New(rand.NewSource(seed)), tables: tables}
+}
+
+type genSource struct {
+	tbl   *schema.Table
+	alias string
+}
+
+type genCol struct {
+	name string // qualified name used in the query
+	fld  *schema.Field
+}
+
+func (m *QueryGen) chance(p float64) bool {
+	return p > 0 && m.rnd.Float64() < p
+}
+
+// Select a random SELECT statement.
+func (m *QueryGen) Select() string {
+	if len(m.tables) == 0 {
+		return "SELECT 1"
+	}
+	srcs := []genSource{{tbl: m.tables[m.rnd.Intn(len(m.tables))]}}
+	joinOn := ""
+	if m.chance(m.cfg.Join) {
+		if right, on, ok := m.joinOf(srcs[0].tbl); ok {
+			srcs[0].alias = "t1"
+			srcs = append(srcs, genSource{tbl: right, alias: "t2"})
+			joinOn = fmt.Sprintf("t1.%s = t2.%s", on, on)
+		}
+	}
+
+	var cols []genCol
+	for _, src := range srcs {
+		for _, fld := range src.tbl.FieldList() {
+			name := fld.Name
+			if src.alias != "" {
+				name = src.alias + "." + fld.Name
+			}
+			cols = append(cols, genCol{name: name, fld: fld})
+		}
+	}
+	if len(cols) == 0 {
+		return "SELECT 1"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	var groupBy []genCol
+	if m.chance(m.cfg.GroupBy) {
+		groupBy = m.pick(cols, 1+m.rnd.Intn(2))
+		projected := make([]string, 0, len(groupBy)+1)
+		for _, col := range groupBy {
+			projected = append(projected, col.name)
+		}
+		projected = append(projected, "count(*) AS ct")
+		sb.WriteString(strings.Join(projected, ", "))
+	} else {
+		if m.chance(m.cfg.Distinct) {
+			sb.WriteString("DISTINCT ")
+		}
+		projected := make([]string, 0)
+		for i, col := range m.pick(cols, 1+m.rnd.Intn(len(cols))) {
+			if m.chance(m.cfg.Funcs) {
+				projected = append(projected, fmt.Sprintf("%s AS f%d", m.funcOf(col), i))
+				continue
+			}
+			projected = append(projected, col.name)
+		}
+		sb.WriteString(strings.Join(projected, ", "))
+	}
+
+	sb.WriteString(" FROM ")
+	sb.WriteString(srcs[0].tbl.Name)
+	if srcs[0].alias != "" {
+		sb.WriteString(" AS " + srcs[0].alias)
+	}
+	if len(srcs) > 1 {
+		fmt.Fprintf(&sb, " INNER JOIN %s AS %s ON %s", srcs[1].tbl.Name, srcs[1].alias, joinOn)
+	}
+	if m.chance(m.cfg.Where) {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(m.boolExpr(cols, m.cfg.MaxDepth))
+	}
+	if len(groupBy) > 0 {
+		names := make([]string, len(groupBy))
+		for i, col := range groupBy {
+			names[i] = col.name
+		}
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(strings.Join(names, ", "))
+	}
+	if m.chance(m.cfg.OrderBy) {
+		orderCols := cols
+		if len(groupBy) > 0 {
+			orderCols = groupBy
+		}
+		dir := " ASC"
+		if m.rnd.Intn(2) == 0 {
+			dir = " DESC"
+		}
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(orderCols[m.rnd.Intn(len(orderCols))].name + dir)
+	}
+	if m.chance(m.cfg.Limit) {
+		fmt.Fprintf(&sb, " LIMIT %d", 1+m.rnd.Intn(10))
+	}
+	return sb.String()
+}
+
+// joinOf another table sharing a column name with tbl.
+func (m *QueryGen) joinOf(tbl *schema.Table) (*schema.Table, string, bool) {
+	for _, i := range m.rnd.Perm(len(m.tables)) {
+		right := m.tables[i]
+		if right == tbl {
+			continue
+		}
+		for _, fld := range tbl.FieldList() {
+			if right.HasField(fld.Name) {
+				return right, fld.Name, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// pick n distinct columns, in random order.
+func (m *QueryGen) pick(cols []genCol, n int) []genCol {
+	if n > len(cols) {
+		n = len(cols)
+	}
+	picked := make([]genCol, n)
+	for i, idx := range m.rnd.Perm(len(cols))[:n] {
+		picked[i] = cols[idx]
+	}
+	return picked
+}
+
+func (m *QueryGen) funcOf(col genCol) string {
+	if isNumeric(col.fld) {
+		return fmt.Sprintf("toint(%s)", col.name)
+	}
+	switch m.rnd.Intn(3) {
+	case 0:
+		return fmt.Sprintf("tolower(%s)", col.name)
+	case 1:
+		return fmt.Sprintf("len(%s)", col.name)
+	}
+	return fmt.Sprintf("contains(%s, %q)", col.name, m.word())
+}
+
+func (m *QueryGen) boolExpr(cols []genCol, depth int) string {
+	if depth > 0 {
+		switch m.rnd.Intn(5) {
+		case 0:
+			return fmt.Sprintf("(%s AND %s)", m.boolExpr(cols, depth-1), m.boolExpr(cols, depth-1))
+		case 1:
+			return fmt.Sprintf("(%s OR %s)", m.boolExpr(cols, depth-1), m.boolExpr(cols, depth-1))
+		case 2:
+			return fmt.Sprintf("NOT (%s)", m.boolExpr(cols, depth-1))
+		}
+	}
+	col := cols[m.rnd.Intn(len(cols))]
+	if isNumeric(col.fld) {
+		ops := []string{"=", "!=", ">", ">=", "<", "<="}
+		return fmt.Sprintf("%s %s %d", col.name, ops[m.rnd.Intn(len(ops))], m.rnd.Intn(100))
+	}
+	switch m.rnd.Intn(6) {
+	case 0:
+		return fmt.Sprintf("%s = %q", col.name, m.word())
+	case 1:
+		return fmt.Sprintf("%s != %q", col.name, m.word())
+	case 2:
+		return fmt.Sprintf("%s IN (%q, %q)", col.name, m.word(), m.word())
+	case 3:
+		return fmt.Sprintf("%s LIKE %q", col.name, m.word()+"%")
+	case 4:
+		return fmt.Sprintf("%s IS NOT NULL", col.name)
+	}
+	return fmt.Sprintf("exists %s", col.name)
+}
+
+func (m *QueryGen) word() string {
+	const letters = "abcdefghij0123"
+	b := make([]byte, 1+m.rnd.Intn(4))
+	for i := range b {
+		b[i] = letters[m.rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+func isNumeric(fld *schema.Field) bool {
+	switch value.ValueType(fld.Type) {
+	case value.IntType, value.NumberType:
+		return true
+	}
+	return false
+}
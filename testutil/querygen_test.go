@@ -0,0 +1,44 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+func TestQueryGen(t *testing.T) {
+	ctx := td.TestContext("SELECT 1")
+	var tables []*schema.Table
+	for _, name := range []string{"users", "orders"} {
+		tbl, err := ctx.Schema.Table(name)
+		assert.Equal(t, nil, err)
+		tables = append(tables, tbl)
+	}
+
+	cfg := DefaultQueryGenConfig()
+	qg := NewQueryGen(7, cfg, tables...)
+	again := NewQueryGen(7, cfg, tables...)
+	for i := 0; i < 200; i++ {
+		sql := qg.Select()
+		assert.Equal(t, sql, again.Select())
+		_, err := rel.ParseSql(sql)
+		assert.Equal(t, nil, err, "could not parse %s", sql)
+		if i%10 == 0 {
+			s := TakeSnapshot(td.TestContext, sql)
+			assert.Equal(t, "", s.Err, "could not run %s", sql)
+		}
+	}
+
+	// features turned off are never generated
+	qg = NewQueryGen(1, QueryGenConfig{}, tables...)
+	for i := 0; i < 50; i++ {
+		sql := qg.Select()
+		assert.NotContains(t, sql, " WHERE ")
+		assert.NotContains(t, sql, " JOIN ")
+		assert.NotContains(t, sql, " GROUP BY ")
+	}
+}
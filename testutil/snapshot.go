@@ -0,0 +1,279 @@
+package testutil
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// UpdateSnapshotsEnv env var, if set CheckSnapshots re-writes the
+	// snapshot file instead of comparing against it.
+	UpdateSnapshotsEnv = "QLBRIDGE_UPDATE_SNAPSHOTS"
+)
+
+// Snapshot of a query for upgrade testing, its plan and a checksum of its
+// results.  Snapshots of a corpus of queries taken with one version of
+// qlbridge are compared to those of another to flag semantic changes.
+type Snapshot struct {
+	Sql      string   `json:"sql"`
+	Plan     []string `json:"plan,omitempty"`
+	Cols     []string `json:"cols,omitempty"`
+	RowCt    int      `json:"rows"`
+	Checksum string   `json:"checksum,omitempty"`
+	Err      string   `json:"err,omitempty"`
+}
+
+// SnapshotDiff a difference of a query between two sets of snapshots.
+// Kind is one of plan, cols, rows, checksum, err, missing (query not in
+// the new snapshots) or added (query not in the old).  A plan change is
+// not Semantic, the query is planned differently but has the same results.
+type SnapshotDiff struct {
+	Sql      string
+	Kind     string
+	Old      string
+	New      string
+	Semantic bool
+}
+
+func (m SnapshotDiff) String() string {
+	return fmt.Sprintf("%s changed for %q\n  old: %s\n  new: %s", m.Kind, m.Sql, m.Old, m.New)
+}
+
+// TakeSnapshot plan and run the query, newCtx creates the context (schema,
+// session) of the query and is called once to plan and once to run it.
+// Results are checksummed in order if the query has an ORDER BY, else
+// as a set as row order is not defined.
+func TakeSnapshot(newCtx func(sql string) *plan.Context, sql string) *Snapshot {
+	s := &Snapshot{Sql: sql}
+
+	stmt, err := rel.ParseSql(sql)
+	if err != nil {
+		s.Err = err.Error()
+		return s
+	}
+	rows, err := exec.Explain(newCtx(sql), stmt)
+	if err != nil {
+		s.Err = err.Error()
+		return s
+	}
+	for _, row := range rows {
+		vals := make([]string, len(row))
+		for i, v := range row {
+			vals[i] = fmt.Sprint(v)
+		}
+		s.Plan = append(s.Plan, strings.Join(vals, " | "))
+	}
+
+	ctx := newCtx(sql)
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		s.Err = err.Error()
+		return s
+	}
+	defer job.Close()
+
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+	if err = job.Setup(); err == nil {
+		err = job.Run()
+	}
+	if err != nil {
+		s.Err = err.Error()
+		return s
+	}
+
+	ordered := false
+	if sel, ok := ctx.Stmt.(*rel.SqlSelect); ok {
+		s.Cols = sel.Columns.AliasedFieldNames()
+		ordered = len(sel.OrderBy) > 0
+	}
+	lines := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		sdm, ok := msg.(*datasource.SqlDriverMessageMap)
+		if !ok {
+			s.Err = fmt.Sprintf("unexpected result message %T", msg)
+			return s
+		}
+		lines = append(lines, snapshotRow(sdm.Values()))
+	}
+	if !ordered {
+		sort.Strings(lines)
+	}
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+	s.RowCt = len(lines)
+	s.Checksum = hex.EncodeToString(h.Sum(nil))[:32]
+	return s
+}
+
+// TakeSnapshots snapshot each query of the corpus.
+func TakeSnapshots(newCtx func(sql string) *plan.Context, queries []string) []*Snapshot {
+	snaps := make([]*Snapshot, len(queries))
+	for i, sql := range queries {
+		snaps[i] = TakeSnapshot(newCtx, sql)
+	}
+	return snaps
+}
+
+// snapshotRow row as a string, values are typed so a change of type
+// (int to float) is a change of result.
+func snapshotRow(row []driver.Value) string {
+	vals := make([]string, len(row))
+	for i, v := range row {
+		switch vt := v.(type) {
+		case nil:
+			vals[i] = "null"
+		case float64:
+			vals[i] = "float:" + strconv.FormatFloat(vt, 'g', -1, 64)
+		case time.Time:
+			vals[i] = "time:" + vt.UTC().Format(time.RFC3339Nano)
+		case []byte:
+			vals[i] = "bytes:" + string(vt)
+		default:
+			vals[i] = fmt.Sprintf("%T:%v", v, v)
+		}
+	}
+	return strings.Join(vals, "\x1f")
+}
+
+// CompareSnapshots the differences between old and new snapshots, matched
+// by their sql.
+func CompareSnapshots(old, new []*Snapshot) []SnapshotDiff {
+	var diffs []SnapshotDiff
+	newBySql := make(map[string]*Snapshot, len(new))
+	for _, s := range new {
+		newBySql[s.Sql] = s
+	}
+	seen := make(map[string]bool, len(old))
+	for _, o := range old {
+		seen[o.Sql] = true
+		n, ok := newBySql[o.Sql]
+		if !ok {
+			diffs = append(diffs, SnapshotDiff{Sql: o.Sql, Kind: "missing", Old: o.Checksum, Semantic: true})
+			continue
+		}
+		add := func(kind, ov, nv string, semantic bool) {
+			if ov != nv {
+				diffs = append(diffs, SnapshotDiff{Sql: o.Sql, Kind: kind, Old: ov, New: nv, Semantic: semantic})
+			}
+		}
+		add("err", o.Err, n.Err, true)
+		add("cols", strings.Join(o.Cols, ", "), strings.Join(n.Cols, ", "), true)
+		add("rows", strconv.Itoa(o.RowCt), strconv.Itoa(n.RowCt), true)
+		if o.RowCt == n.RowCt {
+			add("checksum", o.Checksum, n.Checksum, true)
+		}
+		add("plan", strings.Join(o.Plan, "\n"), strings.Join(n.Plan, "\n"), false)
+	}
+	for _, n := range new {
+		if !seen[n.Sql] {
+			diffs = append(diffs, SnapshotDiff{Sql: n.Sql, Kind: "added", New: n.Checksum})
+		}
+	}
+	return diffs
+}
+
+// WriteSnapshots write the snapshots as json to path.
+func WriteSnapshots(path string, snaps []*Snapshot) error {
+	by, err := json.MarshalIndent(snaps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(by, '\n'), 0644)
+}
+
+// ReadSnapshots read the json snapshots written by WriteSnapshots.
+func ReadSnapshots(path string) ([]*Snapshot, error) {
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snaps []*Snapshot
+	if err = json.Unmarshal(by, &snaps); err != nil {
+		return nil, fmt.Errorf("could not read snapshots %q: %v", path, err)
+	}
+	return snaps, nil
+}
+
+// ReadQueryCorpus read a file of sql statements, each ending with a ;
+// at the end of a line, lines starting with -- are comments.
+//
+//     -- users by domain
+//     SELECT emaildomain(email) AS domain, count(*)
+//     FROM users GROUP BY domain;
+//
+func ReadQueryCorpus(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var queries []string
+	var cur []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		if strings.HasSuffix(line, ";") {
+			cur = append(cur, strings.TrimSpace(strings.TrimSuffix(line, ";")))
+			queries = append(queries, strings.Join(cur, " "))
+			cur = nil
+			continue
+		}
+		cur = append(cur, line)
+	}
+	if len(cur) > 0 {
+		queries = append(queries, strings.Join(cur, " "))
+	}
+	return queries, scanner.Err()
+}
+
+// CheckSnapshots snapshot the queries and compare to the snapshots in
+// path, semantic differences are errors, plan changes are only logged.
+// If the file does not exist, or env QLBRIDGE_UPDATE_SNAPSHOTS is set,
+// the snapshots are written to path instead.
+func CheckSnapshots(t TestingT, path string, newCtx func(sql string) *plan.Context, queries []string) {
+	snaps := TakeSnapshots(newCtx, queries)
+	old, err := ReadSnapshots(path)
+	if os.IsNotExist(err) || os.Getenv(UpdateSnapshotsEnv) != "" {
+		if err := WriteSnapshots(path, snaps); err != nil {
+			t.Errorf("could not write snapshots %q: %v", path, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	for _, diff := range CompareSnapshots(old, snaps) {
+		if diff.Semantic {
+			t.Errorf("%s", diff)
+		} else {
+			u.Warnf("%s", diff)
+		}
+	}
+}
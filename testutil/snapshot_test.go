@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+)
+
+func TestSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qlb-snap")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	corpus := filepath.Join(dir, "queries.sql")
+	assert.Equal(t, nil, ioutil.WriteFile(corpus, []byte(`
+-- users
+SELECT user_id, email FROM users;
+SELECT user_id, count(*) AS ct
+FROM orders GROUP BY user_id;
+SELECT email FROM users ORDER BY email ASC;
+SELECT nope FROM not_a_table;
+`), 0644))
+	queries, err := ReadQueryCorpus(corpus)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(queries))
+	assert.Equal(t, "SELECT user_id, count(*) AS ct FROM orders GROUP BY user_id", queries[1])
+
+	snaps := TakeSnapshots(td.TestContext, queries)
+	assert.Equal(t, 3, snaps[0].RowCt)
+	assert.Equal(t, []string{"user_id", "email"}, snaps[0].Cols)
+	assert.NotEqual(t, 0, len(snaps[0].Plan))
+	assert.Equal(t, 2, snaps[1].RowCt)
+	assert.NotEqual(t, "", snaps[3].Err)
+
+	// un-ordered results checksum the same whatever order they came in
+	again := TakeSnapshots(td.TestContext, queries)
+	assert.Equal(t, 0, len(CompareSnapshots(snaps, again)))
+
+	path := filepath.Join(dir, "snapshots.json")
+	CheckSnapshots(t, path, td.TestContext, queries)
+	read, err := ReadSnapshots(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, snaps, read)
+	CheckSnapshots(t, path, td.TestContext, queries)
+
+	// a changed result is semantic, a changed plan is not
+	read[0].Checksum = "abc"
+	read[1].Plan = []string{"1 | 0 | scan | orders | -"}
+	diffs := CompareSnapshots(read, again[:3])
+	assert.Equal(t, 3, len(diffs))
+	assert.Equal(t, "checksum", diffs[0].Kind)
+	assert.True(t, diffs[0].Semantic)
+	assert.Equal(t, "plan", diffs[1].Kind)
+	assert.True(t, !diffs[1].Semantic)
+	assert.Equal(t, "missing", diffs[2].Kind)
+}
@@ -30,6 +30,7 @@ cd $GOPATH/src/github.com/pborman/uuid && git checkout master && git pull
 cd $GOPATH/src/github.com/rcrowley/go-metrics && git checkout master && git pull
 cd $GOPATH/src/github.com/stretchr/testify && git checkout master && git pull
 cd $GOPATH/src/github.com/go.opencensus.io && git checkout master && git pull
+cd $GOPATH/src/gopkg.in/yaml.v2 && git checkout v2 && git pull
 
 cd $GOPATH/src/golang.org/x/crypto && git checkout master && git pull
 cd $GOPATH/src/golang.org/x/net && git checkout master && git pull
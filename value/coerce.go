@@ -313,7 +313,7 @@ func ValueToInt64(val Value) (int64, bool) {
 // StringToTimeAnchor Convert a string type to a time if possible.
 // If "now-3d" then use date-anchoring ie if prefix = 'now'.
 func StringToTimeAnchor(val string, anchor time.Time) (time.Time, bool) {
-	if len(val) > 3 && strings.ToLower(val[:3]) == "now" {
+	if len(val) >= 3 && strings.ToLower(val[:3]) == "now" {
 		// Is date math
 		t, err := datemath.EvalAnchor(anchor, val)
 		if err != nil {
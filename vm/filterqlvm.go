@@ -1,6 +1,8 @@
 package vm
 
 import (
+	"strings"
+
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/expr"
@@ -19,6 +21,27 @@ type filterql struct {
 	expr.Includer
 }
 
+// nestedContext an element of a nested filter, fields the element does
+// not have are read from the enclosing context.
+type nestedContext struct {
+	expr.EvalContext
+	elem map[string]value.Value
+}
+
+func (m *nestedContext) Get(key string) (value.Value, bool) {
+	if v, ok := m.elem[key]; ok {
+		return v, true
+	}
+	return m.EvalContext.Get(key)
+}
+
+func (m *nestedContext) Include(name string) (expr.Node, error) {
+	if inc, ok := m.EvalContext.(expr.Includer); ok {
+		return inc.Include(name)
+	}
+	return nil, expr.ErrNoIncluder
+}
+
 // EvalFilerSelect evaluates a FilterSelect statement from read, into write context
 //
 // @writeContext = Write results of projection
@@ -102,5 +125,37 @@ func matchesExpr(cr expr.EvalContext, n expr.Node, depth int) (bool, bool) {
 	if bv, isBool := val.(value.BoolValue); isBool {
 		return bv.Val(), ok
 	}
+	// WHERE MATCH(col) AGAINST ("terms")   rows with any relevance match
+	if fn, isFunc := n.(*expr.FuncNode); isFunc && strings.ToLower(fn.Name) == expr.MatchAgainstFunc {
+		if nv, isNum := val.(value.NumberValue); isNum {
+			return nv.Val() > 0, true
+		}
+	}
 	return false, true
 }
+
+// walkInFilter evaluates a nested filter, does any element of the left
+// side (a document, or list of documents) match the filter.
+//
+//    orders IN (AND ( total > 100, status == "paid" ))
+func walkInFilter(ctx expr.EvalContext, left expr.Node, filter *expr.BooleanNode, depth int) (value.Value, bool) {
+	lv, ok := evalDepth(ctx, left, depth+1)
+	if !ok || lv == nil {
+		return value.BoolValueFalse, true
+	}
+	elems := []value.Value{lv}
+	if sv, isSlice := lv.(value.Slice); isSlice {
+		elems = sv.SliceValue()
+	}
+	for _, elem := range elems {
+		mv, isMap := elem.(value.Map)
+		if !isMap {
+			continue
+		}
+		nctx := &nestedContext{EvalContext: ctx, elem: mv.MapValue().Val()}
+		if matches, ok := evalBool(nctx, filter, depth+1); ok && matches {
+			return value.BoolValueTrue, true
+		}
+	}
+	return value.BoolValueFalse, true
+}
@@ -91,6 +91,10 @@ func TestFilterQlVm(t *testing.T) {
 			"last.event":      map[string]time.Time{"has.period": t1},
 			"transactions":    []interface{}{t1.Add(-1 * time.Hour * 24), t1.Add(1 * time.Hour * 24)},
 			"transactionsnil": []interface{}{},
+			"orders": []interface{}{
+				map[string]interface{}{"total": 150, "status": "paid"},
+				map[string]interface{}{"total": 50, "status": "refunded"},
+			},
 		}, true),
 	}
 
@@ -173,6 +177,12 @@ func TestFilterQlVm(t *testing.T) {
 		`FILTER hits.foo > "1.5"`,
 		`FILTER NOT ( hits.foo > 5.5 )`,
 		`FILTER not_a_field NOT IN ("Yoda")`,
+		`FILTER Updated IN LAST 1 DAY`,                                 // Relative date window
+		`FILTER AND ( Updated IN LAST 2 hours, zip BETWEEN 1 AND 10 )`, // Relative date window
+		`FILTER orders IN (AND (total > 100, status == "paid"))`,       // Nested filter, any element matches
+		`FILTER orders IN (OR (status == "refunded"))`,                 // Nested filter
+		`FILTER orders IN (AND (status == "paid", name == "Yoda"))`,    // Nested filter reads enclosing fields
+		`FILTER NOT ( orders IN (AND (total > 1000)) )`,                // Nested filter (negated)
 	}
 	// hits = []string{
 	// 	`FILTER transactions < "now-1h"`, // Date Compare with []time.Time
@@ -208,6 +218,11 @@ func TestFilterQlVm(t *testing.T) {
 		`FILTER transactionsnil < "now-1h"`,         // Date Compare with empty slice
 		`FILTER ["hello","apple"] < "now-1h"`,       // Date Compare with left hand strings
 		`FILTER zip * 5 * 2`,                        // invalid statement
+		`FILTER Created IN LAST 7 DAYS`,             // Relative date window
+		`FILTER Updated IN NEXT 7 DAYS`,             // Relative date window
+		`FILTER orders IN (AND (total > 100, status == "refunded"))`,
+		`FILTER name IN (AND (total > 0))`,        // Nested filter of non document
+		`FILTER not_a_field IN (AND (total > 0))`, // Nested filter of missing field
 	}
 
 	for _, q := range misses {
@@ -0,0 +1,46 @@
+//go:build go1.18
+// +build go1.18
+
+package vm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/vm"
+)
+
+// go test -run=^$ -fuzz=FuzzEval ./vm
+func FuzzEval(f *testing.F) {
+	for _, exp := range []string{
+		`int5 + 2 > 6`,
+		`str5 == "5" AND NOT (bvalt)`,
+		`tolower(str5) IN ("5", "x")`,
+		`toint(str5) * 2.5`,
+		`urls LIKE "*foo*"`,
+		`exists nope OR created < "now-1d"`,
+		`CASE WHEN int5 > 1 THEN "a" ELSE "b" END`,
+		`len(split(str5, ","))`,
+		`1 / 0`,
+		`((int5`,
+		"&җ",
+	} {
+		f.Add(exp)
+	}
+	ctx := datasource.NewContextSimpleNative(map[string]interface{}{
+		"int5":    5,
+		"str5":    "5",
+		"bvalt":   true,
+		"urls":    []string{"http://foo.com", "http://bar.com"},
+		"created": time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	f.Fuzz(func(t *testing.T, exp string) {
+		node, err := expr.ParseExpression(exp)
+		if err != nil {
+			return
+		}
+		vm.Eval(ctx, node)
+	})
+}
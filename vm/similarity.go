@@ -0,0 +1,50 @@
+package vm
+
+import (
+	"strings"
+	"unicode"
+)
+
+var (
+	// SimilarityThreshold is the trigram similarity at or above which two
+	// strings are similar for the % operator, same default as pg_trgm.
+	//
+	//     name % "jon smith"   => similarity(name, "jon smith") >= 0.3
+	//
+	SimilarityThreshold = 0.3
+)
+
+// TrigramSimilarity of a and b, the count of trigrams they share over the
+// count of distinct trigrams of both, from 0 (none shared) to 1.  As in
+// pg_trgm, case is ignored, and each word (run of letters, digits) is
+// padded with 2 spaces before, 1 after, so word starts weigh more.
+//
+//     TrigramSimilarity("word", "words")   => 0.571
+//
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]struct{} {
+	grams := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		rs := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(rs); i++ {
+			grams[string(rs[i:i+3])] = struct{}{}
+		}
+	}
+	return grams
+}
@@ -180,7 +180,7 @@ func evalDepth(ctx expr.EvalContext, arg expr.Node, depth int) (value.Value, boo
 		switch val := argVal.Value.(type) {
 		case *value.NilValue, value.NilValue:
 			return nil, false
-		case value.SliceValue:
+		case value.SliceValue, value.ByteSliceValue:
 			return val, true
 		}
 		u.Errorf("Unknonwn node type:  %#v", argVal.Value)
@@ -325,6 +325,12 @@ func walkBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 	return val, ok
 }
 func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.Value, bool) {
+	if node.Operator.T == lex.TokenAssign {
+		return walkAssign(ctx, node, depth)
+	}
+	if filter, isFilter := node.Args[1].(*expr.BooleanNode); isFilter && node.Operator.T == lex.TokenIN {
+		return walkInFilter(ctx, node.Args[0], filter, depth)
+	}
 	ar, aok := evalDepth(ctx, node.Args[0], depth+1)
 	br, bok := evalDepth(ctx, node.Args[1], depth+1)
 
@@ -601,10 +607,28 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 				return value.BoolValueFalse, false
 			}
 			return operateTime(node.Operator.T, lht, bt.Val())
+		case value.ByteSliceValue:
+			return operateStrings(node.Operator, at, value.NewStringValue(bt.ToString())), true
 		default:
 			u.Errorf("at?%T  %v bt? %T     %v", at, at.Value(), bt, bt.Value())
 		}
 		return nil, false
+	case value.ByteSliceValue:
+		// binary compares byte wise, same as strings of its bytes
+		switch bt := br.(type) {
+		case value.ByteSliceValue, value.StringValue:
+			return operateStrings(node.Operator, value.NewStringValue(at.ToString()), value.NewStringValue(bt.ToString())), true
+		case value.Slice:
+			if node.Operator.T == lex.TokenIN {
+				for _, val := range bt.SliceValue() {
+					if at.ToString() == val.ToString() {
+						return value.NewBoolValue(true), true
+					}
+				}
+				return value.NewBoolValue(false), true
+			}
+		}
+		return nil, false
 	case value.SliceValue:
 		switch node.Operator.T {
 		case lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE, lex.TokenEqualEqual, lex.TokenEqual, lex.TokenNE:
@@ -825,6 +849,28 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 	return value.NewErrorValue(fmt.Errorf("unsupported binary expression: %s", node)), false
 }
 
+// walkAssign  @var := expr  evaluate right side and write it to the
+// context (which must be a writer), the value of the assignment is the
+// value assigned.
+func walkAssign(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.Value, bool) {
+	in, ok := node.Args[0].(*expr.IdentityNode)
+	if !ok {
+		return nil, false
+	}
+	w, ok := ctx.(expr.ContextWriter)
+	if !ok {
+		return nil, false
+	}
+	v, ok := evalDepth(ctx, node.Args[1], depth+1)
+	if !ok || v == nil {
+		v = value.NilValueVal
+	}
+	if err := w.Put(expr.SchemaInfoString(in.Text), ctx, v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
 func walkIdentity(ctx expr.EvalContext, node *expr.IdentityNode) (value.Value, bool) {
 
 	if node.IsBooleanIdentity() {
@@ -1089,7 +1135,7 @@ func operateNumbers(op lex.Token, av, bv value.NumberValue) value.Value {
 
 func operateStrings(op lex.Token, av, bv value.StringValue) value.Value {
 
-	//  Any other ops besides =, ==, !=, contains, like?
+	//  Any other ops besides =, ==, !=, contains, like, similarity (%) and ordering?
 	a, b := av.Val(), bv.Val()
 	switch op.T {
 	case lex.TokenEqualEqual, lex.TokenEqual: //  ==
@@ -1118,6 +1164,16 @@ func operateStrings(op lex.Token, av, bv value.StringValue) value.Value {
 			return value.BoolValueTrue
 		}
 		return value.BoolValueFalse
+	case lex.TokenGT: //  >
+		return value.NewBoolValue(a > b)
+	case lex.TokenGE: //  >=
+		return value.NewBoolValue(a >= b)
+	case lex.TokenLT: // <
+		return value.NewBoolValue(a < b)
+	case lex.TokenLE: // <=
+		return value.NewBoolValue(a <= b)
+	case lex.TokenModulus: // a % b similarity
+		return value.NewBoolValue(TrigramSimilarity(a, b) >= SimilarityThreshold)
 	}
 	return value.NewErrorValuef("unsupported operator for strings: %s", op.T)
 }
@@ -108,6 +108,11 @@ var (
 		vmt(`email LIKE "bob"`, false, noError),
 		vmt(`email LIKE "*.com"`, true, noError),
 
+		// string ordering
+		vmt(`email > "aaron@email.com"`, true, noError),
+		vmt(`email < "aaron@email.com"`, false, noError),
+		vmt(`"abc" <= "abc"`, true, noError),
+
 		// Native Contains keyword
 		vmt(`[1,2,3] contains int5`, false, noError),
 		vmt(`[1,2,3] NOT contains int5`, true, noError),